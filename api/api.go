@@ -34,6 +34,7 @@ type RequestOptions struct {
 
 type basicAPIClient struct {
 	baseURL string
+	headers http.Header
 }
 
 const (
@@ -44,6 +45,17 @@ const (
 	// StitchCLIHeaderValue is the value of the StitchRequestOriginHeader if the request
 	// is from the CLI
 	StitchCLIHeaderValue = "mongodb-stitch-cli"
+
+	// StitchImpersonateHeader is the name of the header asking the API to act on behalf of another user,
+	// such as a service account, whose admin rights have been delegated to the authenticated user
+	StitchImpersonateHeader = "X-STITCH-Impersonate"
+
+	// CorrelationIDHeader is the name of the header identifying the command run a request was made by, so that
+	// the requests of a run can be traced through the gateways in front of the API
+	CorrelationIDHeader = "X-Correlation-ID"
+
+	// UserAgent is the User-Agent requests are sent with, which may be followed by a configured suffix
+	UserAgent = "MongoDB-Stitch-CLI"
 )
 
 // ExecuteRequest makes an HTTP request to the provided path
@@ -57,6 +69,11 @@ func (apiClient *basicAPIClient) ExecuteRequest(method, path string, options Req
 	if req.Header == nil {
 		req.Header = http.Header{}
 	}
+	for name, values := range apiClient.headers {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = values
+		}
+	}
 	req.Header.Set(StitchRequestOriginHeader, StitchCLIHeaderValue)
 
 	client := &http.Client{}
@@ -71,6 +88,15 @@ func NewClient(baseURL string) Client {
 	}
 }
 
+// NewClientWithHeaders returns a new Client that sends the given headers with every request, unless the
+// request sets them itself
+func NewClientWithHeaders(baseURL string, headers http.Header) Client {
+	return &basicAPIClient{
+		baseURL: baseURL,
+		headers: headers,
+	}
+}
+
 // NewAuthClient returns a new *AuthClient
 func NewAuthClient(client Client, user *user.User) *AuthClient {
 	return &AuthClient{
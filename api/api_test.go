@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/10gen/stitch-cli/api"
@@ -12,6 +13,40 @@ import (
 	gc "github.com/smartystreets/goconvey/convey"
 )
 
+func TestClientWithHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	client := api.NewClientWithHeaders(server.URL, http.Header{
+		api.StitchImpersonateHeader: []string{"service-account"},
+		"Authorization":             []string{"Bearer delegated"},
+	})
+
+	t.Run("it sends the headers with every request", func(t *testing.T) {
+		res, err := client.ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		res.Body.Close()
+
+		u.So(t, received.Get(api.StitchImpersonateHeader), gc.ShouldEqual, "service-account")
+		u.So(t, received.Get("Authorization"), gc.ShouldEqual, "Bearer delegated")
+		u.So(t, received.Get(api.StitchRequestOriginHeader), gc.ShouldEqual, api.StitchCLIHeaderValue)
+	})
+
+	t.Run("it does not replace headers set by the request", func(t *testing.T) {
+		res, err := client.ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{
+			Header: http.Header{"Authorization": []string{"Bearer my.access.token"}},
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		res.Body.Close()
+
+		u.So(t, received["Authorization"], gc.ShouldResemble, []string{"Bearer my.access.token"})
+		u.So(t, received.Get(api.StitchImpersonateHeader), gc.ShouldEqual, "service-account")
+	})
+}
+
 func TestAuthClientRefreshAuth(t *testing.T) {
 	t.Run("on success should return a new access token", func(t *testing.T) {
 		client := u.NewMockClient([]*http.Response{
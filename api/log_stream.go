@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/10gen/stitch-cli/models"
+)
+
+// ErrLogStreamingUnsupported is returned by StreamLogs when the server does not stream logs, in which case they
+// are to be polled for with FetchLogs
+var ErrLogStreamingUnsupported = errors.New("the server does not support streaming logs")
+
+// logStreamMaxEventSize bounds the size of a single event of a log stream, which holds one log entry
+const logStreamMaxEventSize = 4 * 1024 * 1024
+
+// LogStream reads the log entries that the server sends as server-sent events, each of whose data is a log entry,
+// as they are logged. Events of other types than "log", such as heartbeats, and comments are skipped
+type LogStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// NewLogStream returns a LogStream reading the events in body
+func NewLogStream(body io.ReadCloser) *LogStream {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), logStreamMaxEventSize)
+	return &LogStream{body: body, scanner: scanner}
+}
+
+// Next returns the next log entry of the stream, waiting for it to be logged. It returns io.EOF once the server
+// ends the stream
+func (ls *LogStream) Next() (*models.LogEntry, error) {
+	var eventType string
+	var data []string
+
+	for ls.scanner.Scan() {
+		line := ls.scanner.Text()
+
+		if line == "" {
+			if len(data) > 0 && (eventType == "" || eventType == "log") {
+				var entry models.LogEntry
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &entry); err != nil {
+					return nil, err
+				}
+				return &entry, nil
+			}
+			eventType, data = "", nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if idx := strings.Index(line, ":"); idx != -1 {
+			field, value = line[:idx], strings.TrimPrefix(line[idx+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := ls.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Close ends the stream, making a pending Next return
+func (ls *LogStream) Close() error {
+	return ls.body.Close()
+}
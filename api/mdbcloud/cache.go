@@ -0,0 +1,92 @@
+package mdbcloud
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GroupsCacheTTL is how long a cached list of Groups is used before it is fetched again
+const GroupsCacheTTL = 5 * time.Minute
+
+// groupsCache holds the most recently fetched Groups of each set of credentials
+type groupsCache map[string]groupsCacheEntry
+
+type groupsCacheEntry struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Groups    []Group   `json:"groups"`
+}
+
+type cachingClient struct {
+	Client
+
+	path    string
+	key     string
+	ttl     time.Duration
+	refresh bool
+}
+
+// NewCachingClient returns a Client whose Groups are cached in the file at path for ttl. Entries are stored
+// under key, which should identify the credentials client is authenticated with. If refresh is true the
+// cached Groups are ignored, though the cache is still updated
+func NewCachingClient(client Client, path, key string, ttl time.Duration, refresh bool) Client {
+	return &cachingClient{
+		Client:  client,
+		path:    path,
+		key:     key,
+		ttl:     ttl,
+		refresh: refresh,
+	}
+}
+
+// Groups returns the cached Groups if they are recent enough, and otherwise fetches and caches them
+func (client *cachingClient) Groups() ([]Group, error) {
+	cache := client.loadCache()
+
+	if entry, ok := cache[client.key]; ok && !client.refresh && time.Since(entry.FetchedAt) < client.ttl {
+		return entry.Groups, nil
+	}
+
+	groups, err := client.Client.Groups()
+	if err != nil {
+		return nil, err
+	}
+
+	cache[client.key] = groupsCacheEntry{FetchedAt: time.Now(), Groups: groups}
+
+	// the cache only saves time, so failing to write it is not worth failing over
+	client.saveCache(cache)
+
+	return groups, nil
+}
+
+// loadCache reads the cache file, treating a missing or unreadable one as empty
+func (client *cachingClient) loadCache() groupsCache {
+	cache := groupsCache{}
+
+	data, err := ioutil.ReadFile(client.path)
+	if err != nil {
+		return cache
+	}
+
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return groupsCache{}
+	}
+
+	return cache
+}
+
+func (client *cachingClient) saveCache(cache groupsCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(client.path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(client.path, data, 0600)
+}
@@ -0,0 +1,84 @@
+package mdbcloud_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCachingClient(t *testing.T) {
+	setup := func(t *testing.T) (string, *int, *u.MockMDBClient) {
+		dir, err := ioutil.TempDir("", "stitch-groups-cache")
+		u.So(t, err, gc.ShouldBeNil)
+
+		var fetches int
+		return filepath.Join(dir, "cache", ".groups-cache.json"), &fetches, &u.MockMDBClient{
+			GroupsFn: func() ([]mdbcloud.Group, error) {
+				fetches++
+				return []mdbcloud.Group{{ID: "group-id", Name: "My Project"}}, nil
+			},
+		}
+	}
+
+	t.Run("it uses cached groups until they expire", func(t *testing.T) {
+		path, fetches, mockClient := setup(t)
+		defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+		for i := 0; i < 2; i++ {
+			groups, err := mdbcloud.NewCachingClient(mockClient, path, "key", time.Hour, false).Groups()
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, groups, gc.ShouldResemble, []mdbcloud.Group{{ID: "group-id", Name: "My Project"}})
+		}
+		u.So(t, *fetches, gc.ShouldEqual, 1)
+
+		_, err := mdbcloud.NewCachingClient(mockClient, path, "key", 0, false).Groups()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, *fetches, gc.ShouldEqual, 2)
+	})
+
+	t.Run("it caches groups separately for each key", func(t *testing.T) {
+		path, fetches, mockClient := setup(t)
+		defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+		for _, key := range []string{"key", "other-key", "key"} {
+			_, err := mdbcloud.NewCachingClient(mockClient, path, key, time.Hour, false).Groups()
+			u.So(t, err, gc.ShouldBeNil)
+		}
+		u.So(t, *fetches, gc.ShouldEqual, 2)
+	})
+
+	t.Run("it fetches groups again when refreshing", func(t *testing.T) {
+		path, fetches, mockClient := setup(t)
+		defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+		for _, refresh := range []bool{false, true, false} {
+			_, err := mdbcloud.NewCachingClient(mockClient, path, "key", time.Hour, refresh).Groups()
+			u.So(t, err, gc.ShouldBeNil)
+		}
+		u.So(t, *fetches, gc.ShouldEqual, 2)
+	})
+
+	t.Run("it does not cache errors", func(t *testing.T) {
+		path, _, _ := setup(t)
+		defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+		failing := &u.MockMDBClient{
+			GroupsFn: func() ([]mdbcloud.Group, error) {
+				return nil, errors.New("oh noes")
+			},
+		}
+
+		_, err := mdbcloud.NewCachingClient(failing, path, "key", time.Hour, false).Groups()
+		u.So(t, err, gc.ShouldNotBeNil)
+
+		_, statErr := os.Stat(path)
+		u.So(t, os.IsNotExist(statErr), gc.ShouldBeTrue)
+	})
+}
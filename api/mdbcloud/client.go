@@ -17,8 +17,17 @@ import (
 
 var errCommonServerError = "an unexpected server error has occurred"
 
-type groupResponse struct {
-	Results []Group `json:"results"`
+// itemsPerPage is the most results the Cloud API returns in a single page of a list
+const itemsPerPage = 500
+
+type pageResponse struct {
+	Results json.RawMessage `json:"results"`
+	Links   []link          `json:"links"`
+}
+
+type link struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
 }
 
 type errResponse struct {
@@ -29,6 +38,13 @@ type errResponse struct {
 
 // Group represents a mongodb atlas group
 type Group struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	OrgID string `json:"orgId,omitempty"`
+}
+
+// Org represents a mongodb atlas organization, which groups belong to
+type Org struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 }
@@ -38,12 +54,14 @@ type Client interface {
 	WithAuth(username, apiKey string) Client
 	Groups() ([]Group, error)
 	GroupByName(string) (*Group, error)
+	Orgs() ([]Org, error)
 	DeleteDatabaseUser(groupID, username string) error
 }
 
 type simpleClient struct {
 	transport       *digest.Transport
 	atlasAPIBaseURL string
+	headers         http.Header
 }
 
 // NewClient constructs and returns a new Client given a username, API key,
@@ -54,6 +72,15 @@ func NewClient(atlasAPIBaseURL string) Client {
 	}
 }
 
+// NewClientWithHeaders returns a new Client that sends the given headers with every request, overriding its
+// own User-Agent if they set one
+func NewClientWithHeaders(atlasAPIBaseURL string, headers http.Header) Client {
+	return &simpleClient{
+		atlasAPIBaseURL: atlasAPIBaseURL,
+		headers:         headers,
+	}
+}
+
 func (client simpleClient) WithAuth(username, apiKey string) Client {
 	// digest.NewTransport will use http.DefaultTransport
 	client.transport = digest.NewTransport(username, apiKey)
@@ -62,29 +89,88 @@ func (client simpleClient) WithAuth(username, apiKey string) Client {
 
 // Groups returns all available Groups for the user
 func (client *simpleClient) Groups() ([]Group, error) {
-	resp, err := client.do(
-		http.MethodGet,
-		fmt.Sprintf("%s/api/public/v1.0/groups", client.atlasAPIBaseURL),
-		nil,
-		true,
-	)
-	errPrefix := "failed to fetch available Projects: %s"
+	var groups []Group
+	err := client.fetchAllPages(fmt.Sprintf("%s/api/public/v1.0/groups", client.atlasAPIBaseURL), func(results json.RawMessage) error {
+		var page []Group
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
+		groups = append(groups, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch available Projects: %s", err)
+	}
+
+	return groups, nil
+}
+
+// Orgs returns all Orgs available to the user
+func (client *simpleClient) Orgs() ([]Org, error) {
+	var orgs []Org
+	err := client.fetchAllPages(fmt.Sprintf("%s/api/public/v1.0/orgs", client.atlasAPIBaseURL), func(results json.RawMessage) error {
+		var page []Org
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
+		orgs = append(orgs, page...)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf(errPrefix, err)
+		return nil, fmt.Errorf("failed to fetch available Organizations: %s", err)
+	}
+
+	return orgs, nil
+}
+
+// fetchAllPages requests every page of the list at url, passing the results of each to appendResults
+func (client *simpleClient) fetchAllPages(url string, appendResults func(results json.RawMessage) error) error {
+	requested := map[string]bool{}
+	url = fmt.Sprintf("%s?itemsPerPage=%d", url, itemsPerPage)
+	for url != "" {
+		if requested[url] {
+			return errors.New("the next page of results links back to " + url)
+		}
+		requested[url] = true
+
+		page, err := client.fetchPage(url)
+		if err != nil {
+			return err
+		}
+
+		if err := appendResults(page.Results); err != nil {
+			return err
+		}
+
+		url = ""
+		for _, l := range page.Links {
+			if l.Rel == "next" {
+				url = l.Href
+			}
+		}
+	}
+
+	return nil
+}
+
+func (client *simpleClient) fetchPage(url string) (*pageResponse, error) {
+	resp, err := client.do(http.MethodGet, url, nil, true)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(errPrefix, resp.Status)
+		return nil, errors.New(resp.Status)
 	}
 
 	dec := json.NewDecoder(resp.Body)
-	var groupResp groupResponse
-	if decodeErr := dec.Decode(&groupResp); decodeErr != nil {
+	var page pageResponse
+	if decodeErr := dec.Decode(&page); decodeErr != nil {
 		return nil, decodeErr
 	}
 
-	return groupResp.Results, nil
+	return &page, nil
 }
 
 func (client *simpleClient) GroupByName(groupName string) (*Group, error) {
@@ -163,6 +249,9 @@ func (client *simpleClient) do(
 	}
 
 	req.Header.Add("User-Agent", "MongoDB-Stitch-CLI")
+	for name, values := range client.headers {
+		req.Header[name] = values
+	}
 
 	cl := http.Client{}
 	cl.Timeout = time.Second * 20
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The second factors an account can verify a login with
+const (
+	MFAFactorTOTP     = "totp"
+	MFAFactorWebAuthn = "webauthn"
+)
+
+// mfaRequiredErrorCode is the error code of a login refused until it is verified with a second factor
+const mfaRequiredErrorCode = "MFARequired"
+
+// defaultMFAPollInterval is how often a WebAuthn verification is polled for when the server does not say
+const defaultMFAPollInterval = 2 * time.Second
+
+// ErrMFAPending is returned by PollMFAWebAuthn while the user has yet to verify the login in their browser
+var ErrMFAPending = errors.New("the login has not been verified yet")
+
+// MFARequiredError is returned by Authenticate when the account enforces MFA, in which case the login is
+// completed by verifying it with one of the Factors, identified by Token
+type MFARequiredError struct {
+	Token   string
+	Factors []string
+}
+
+func (e *MFARequiredError) Error() string {
+	return fmt.Sprintf("the login must be verified with a second factor (%s)", strings.Join(e.Factors, ", "))
+}
+
+// Supports returns whether the login can be verified with factor
+func (e *MFARequiredError) Supports(factor string) bool {
+	for _, f := range e.Factors {
+		if f == factor {
+			return true
+		}
+	}
+	return false
+}
+
+// MFAWebAuthnSession is a WebAuthn verification of a login, which the user completes with their security key at
+// URL in a browser, while the CLI polls for it every PollInterval
+type MFAWebAuthnSession struct {
+	URL          string
+	PollInterval time.Duration
+}
+
+type mfaRequiredResponse struct {
+	ErrorCode string   `json:"error_code"`
+	MFAToken  string   `json:"mfa_token"`
+	Factors   []string `json:"mfa_factors"`
+}
+
+type mfaTOTPPayload struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type mfaWebAuthnPayload struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type mfaWebAuthnResponse struct {
+	URL                 string `json:"url"`
+	PollIntervalSeconds int    `json:"poll_interval_seconds,omitempty"`
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// fetchAllPages requests the list at path and every page of results that follows it, passing each
+// response to decodePage. Subsequent pages are found by following Link headers with rel="next"
+func (sc *basicStitchClient) fetchAllPages(path string, decodePage func(res *http.Response) error) error {
+	requested := map[string]bool{}
+
+	for path != "" {
+		if requested[path] {
+			return fmt.Errorf("the next page of results links back to %s", path)
+		}
+		requested[path] = true
+
+		res, err := sc.ExecuteRequest(http.MethodGet, path, RequestOptions{})
+		if err != nil {
+			return err
+		}
+
+		decodeErr := decodePage(res)
+		res.Body.Close()
+		if decodeErr != nil {
+			return decodeErr
+		}
+
+		if path, err = nextPagePath(res.Header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextPagePath returns the path and query of the rel="next" link in the Link header, or "" if there is none
+func nextPagePath(header http.Header) (string, error) {
+	for _, value := range header["Link"] {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				if strings.Replace(strings.TrimSpace(param), " ", "", -1) != `rel="next"` {
+					continue
+				}
+
+				next, err := url.Parse(target[1 : len(target)-1])
+				if err != nil {
+					return "", fmt.Errorf("invalid next page link %s: %s", target, err)
+				}
+				return next.RequestURI(), nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -5,15 +5,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // ErrAppNotFound is used when an app cannot be found by client app ID
 type ErrAppNotFound struct {
 	ClientAppID string
+
+	// Suggestions are the IDs of existing apps that the ID may be a typo of
+	Suggestions []string
 }
 
 func (eanf ErrAppNotFound) Error() string {
-	return fmt.Sprintf("Unable to find app with ID: %q", eanf.ClientAppID)
+	if len(eanf.Suggestions) == 0 {
+		return fmt.Sprintf("Unable to find app with ID: %q", eanf.ClientAppID)
+	}
+
+	quoted := make([]string, len(eanf.Suggestions))
+	for i, suggestion := range eanf.Suggestions {
+		quoted[i] = strconv.Quote(suggestion)
+	}
+	return fmt.Sprintf("Unable to find app with ID: %q (did you mean %s?)", eanf.ClientAppID, strings.Join(quoted, " or "))
 }
 
 // ErrStitchResponse represents a response from a Stitch API call
@@ -2,14 +2,25 @@ package api
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	mrand "math/rand"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/10gen/stitch-cli/auth"
 	"github.com/10gen/stitch-cli/hosting"
@@ -18,18 +29,63 @@ import (
 
 const (
 	authProviderLoginRoute      = adminBaseURL + "/auth/providers/%s/login"
+	authMFATOTPRoute            = adminBaseURL + "/auth/mfa/totp/verify"
+	authMFAWebAuthnRoute        = adminBaseURL + "/auth/mfa/webauthn"
+	authMFAWebAuthnPollRoute    = adminBaseURL + "/auth/mfa/webauthn/poll"
 	appExportRoute              = adminBaseURL + "/groups/%s/apps/%s/export?template=%t"
 	appImportRoute              = adminBaseURL + "/groups/%s/apps/%s/import"
 	appsByGroupIDRoute          = adminBaseURL + "/groups/%s/apps"
+	appRoute                    = adminBaseURL + "/groups/%s/apps/%s"
 	userProfileRoute            = adminBaseURL + "/auth/profile"
 	hostingAssetRoute           = adminBaseURL + "/groups/%s/apps/%s/hosting/assets/asset"
 	hostingAssetsRoute          = adminBaseURL + "/groups/%s/apps/%s/hosting/assets"
 	hostingInvalidateCacheRoute = adminBaseURL + "/groups/%s/apps/%s/hosting/cache"
+	hostingConfigRoute          = adminBaseURL + "/groups/%s/apps/%s/hosting/config"
+	configSchemasRoute          = adminBaseURL + "/config/schemas"
+	configLimitsRoute           = adminBaseURL + "/config/limits"
+	appDeploymentsRoute         = adminBaseURL + "/groups/%s/apps/%s/deployments"
+	appDeploymentRoute          = adminBaseURL + "/groups/%s/apps/%s/deployments/%s"
+	deploymentHostingRoute      = adminBaseURL + "/groups/%s/apps/%s/deployments/%s/hosting/assets"
+	executeFunctionRoute        = adminBaseURL + "/groups/%s/apps/%s/debug/execute_function?run_as_system=true"
+	appValuesRoute              = adminBaseURL + "/groups/%s/apps/%s/values"
+	appValueRoute               = adminBaseURL + "/groups/%s/apps/%s/values/%s"
+	appSecretsRoute             = adminBaseURL + "/groups/%s/apps/%s/secrets"
+	appAuthProvidersRoute       = adminBaseURL + "/groups/%s/apps/%s/auth_providers"
+	appAuthProviderRoute        = adminBaseURL + "/groups/%s/apps/%s/auth_providers/%s"
+	appMeasurementsRoute        = adminBaseURL + "/groups/%s/apps/%s/measurements?start=%s&end=%s&granularity=%s"
+	appLogsRoute                = adminBaseURL + "/groups/%s/apps/%s/logs"
 )
 
+// gzipMinRequestSize is the size from which the app data sent to diff and import an app is gzip-compressed.
+// Responses are compressed whenever the server supports it, as net/http asks for and decodes them itself
+const gzipMinRequestSize = 64 * 1024
+
+// ImportIdempotencyKeyHeader is the name of the header carrying the key that identifies an import, so that the
+// server applies an import it is sent more than once, such as when it is retried, only once
+const ImportIdempotencyKeyHeader = "Idempotency-Key"
+
+// ImportPollInterval is how long to wait between checks of a deployment the server is importing in the background
+var ImportPollInterval = 2 * time.Second
+
+// DefaultImportDeploymentTimeout is how long Import waits for a deployment the server is importing in the background
+const DefaultImportDeploymentTimeout = 10 * time.Minute
+
+// deploymentMaxFetchFailures is how many checks of a deployment in a row may fail before waiting on it is given up
+const deploymentMaxFetchFailures = 3
+
+// ImportRetryBackoff is how long to wait before sending an import again after it failed in transit. It doubles
+// with each attempt, and is jittered so that clients failing together do not retry together
+var ImportRetryBackoff = time.Second
+
+// importMaxAttempts is how many times an import is sent when it keeps failing in transit
+const importMaxAttempts = 3
+
 var (
 	errExportMissingFilename = errors.New("the app export response did not specify a filename")
 	errGroupNotFound         = errors.New("group could not be found")
+
+	// ErrValueExists is returned when creating a value with the name of one the app already has
+	ErrValueExists = errors.New("a value with that name already exists")
 )
 
 const (
@@ -57,11 +113,24 @@ type invalidateCachePayload struct {
 	Path       string `json:"path"`
 }
 
+type renameAppPayload struct {
+	Name string `json:"name"`
+}
+
+type executeFunctionPayload struct {
+	Name      string        `json:"name"`
+	Arguments []interface{} `json:"arguments"`
+}
+
 // StitchClient represents a Client that can be used to call the Stitch Admin API
 type StitchClient interface {
 	Authenticate(authProvider auth.AuthenticationProvider) (*auth.Response, error)
+	VerifyMFACode(mfaToken, code string) (*auth.Response, error)
+	StartMFAWebAuthn(mfaToken string) (*MFAWebAuthnSession, error)
+	PollMFAWebAuthn(mfaToken string) (*auth.Response, error)
 	Export(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error)
 	Import(groupID, appID string, appData []byte, strategy string) error
+	ImportWithProgress(groupID, appID string, appData []byte, strategy string, timeout time.Duration, progress func(*models.Deployment)) error
 	Diff(groupID, appID string, appData []byte, strategy string) ([]string, error)
 	FetchAppByGroupIDAndClientAppID(groupID, clientAppID string) (*models.App, error)
 	FetchAppByClientAppID(clientAppID string) (*models.App, error)
@@ -73,21 +142,61 @@ type StitchClient interface {
 	DeleteAsset(groupID, appID, path string) error
 	SetAssetAttributes(groupID, appID, path string, attributes ...hosting.AssetAttribute) error
 	ListAssetsForAppID(groupID, appID string) ([]hosting.AssetMetadata, error)
+	ListAssetsForDeployment(groupID, appID, deploymentID string) ([]hosting.AssetMetadata, error)
 	InvalidateCache(groupID, appID, path string) error
+	FetchConfigSchemas() (map[string]json.RawMessage, error)
+	FetchLimits() (*models.Limits, error)
+	FetchLatestDeployment(groupID, appID string) (*models.Deployment, error)
+	FetchDeployment(groupID, appID, deploymentID string) (*models.Deployment, error)
+	ExecuteFunction(groupID, appID, name string, args []interface{}) (*models.FunctionExecution, error)
+	RenameApp(groupID, appID, name string) error
+	DeleteApp(groupID, appID string) error
+	FetchHostingConfig(groupID, appID string) (*hosting.Config, error)
+	UpdateHostingConfig(groupID, appID string, config *hosting.Config) error
+	FetchValues(groupID, appID string) ([]models.Value, error)
+	CreateValue(groupID, appID string, value models.Value) (*models.Value, error)
+	DeleteValue(groupID, appID, valueID string) error
+	FetchSecrets(groupID, appID string) ([]models.Secret, error)
+	CreateSecret(groupID, appID, name, value string) error
+	FetchMeasurements(groupID, appID string, start, end time.Time, granularity string) (*models.Measurements, error)
+	FetchUserProfile() (*models.UserProfile, error)
+	FetchAuthProviders(groupID, appID string) ([]models.AuthProvider, error)
+	FetchAuthProvider(groupID, appID, providerID string) (*models.AuthProvider, error)
+	UpdateAuthProvider(groupID, appID string, provider *models.AuthProvider) error
+	FetchLogs(groupID, appID string, query models.LogQuery) (*models.LogPage, error)
+	StreamLogs(groupID, appID string, query models.LogQuery) (*LogStream, error)
 }
 
 // NewStitchClient returns a new StitchClient to be used for making calls to the Stitch Admin API
 func NewStitchClient(client Client) StitchClient {
 	return &basicStitchClient{
-		Client: client,
+		Client:  client,
+		exports: map[string]cachedExport{},
 	}
 }
 
 type basicStitchClient struct {
 	Client
+
+	// exports caches the exports that had an ETag by route, so that exporting an app again in the same run
+	// only downloads it if it has changed
+	exportsMu sync.Mutex
+	exports   map[string]cachedExport
+
+	// gzipRejected is set once the server has refused a gzip-compressed request body, after which bodies
+	// are sent uncompressed
+	gzipRejected int32
+}
+
+// cachedExport is an app export along with the ETag it was served with
+type cachedExport struct {
+	etag     string
+	filename string
+	data     []byte
 }
 
-// Authenticate will authenticate a user given an api key and username
+// Authenticate will authenticate a user given an api key and username. If the account enforces MFA, it returns
+// an *MFARequiredError, and the login is completed with VerifyMFACode or a WebAuthn verification
 func (sc *basicStitchClient) Authenticate(authProvider auth.AuthenticationProvider) (*auth.Response, error) {
 	body, err := json.Marshal(authProvider.Payload())
 	if err != nil {
@@ -106,26 +215,128 @@ func (sc *basicStitchClient) Authenticate(authProvider auth.AuthenticationProvid
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
+		resBody, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		var mfaRequired mfaRequiredResponse
+		if json.Unmarshal(resBody, &mfaRequired) == nil && mfaRequired.ErrorCode == mfaRequiredErrorCode {
+			return nil, &MFARequiredError{Token: mfaRequired.MFAToken, Factors: mfaRequired.Factors}
+		}
+
+		res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
 		return nil, fmt.Errorf("%s: failed to authenticate: %s", res.Status, UnmarshalStitchError(res))
 	}
 
-	decoder := json.NewDecoder(res.Body)
+	return decodeAuthResponse(res)
+}
+
+// VerifyMFACode completes a login that requires MFA with a code from the user's authenticator app
+func (sc *basicStitchClient) VerifyMFACode(mfaToken, code string) (*auth.Response, error) {
+	res, err := sc.postMFA(authMFATOTPRoute, mfaTOTPPayload{MFAToken: mfaToken, Code: code})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: failed to verify the MFA code: %s", res.Status, UnmarshalStitchError(res))
+	}
 
+	return decodeAuthResponse(res)
+}
+
+// StartMFAWebAuthn starts verifying a login that requires MFA with a security key, which the user does in a
+// browser, returning where
+func (sc *basicStitchClient) StartMFAWebAuthn(mfaToken string) (*MFAWebAuthnSession, error) {
+	res, err := sc.postMFA(authMFAWebAuthnRoute, mfaWebAuthnPayload{MFAToken: mfaToken})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("%s: failed to start verifying the login with a security key: %s", res.Status, UnmarshalStitchError(res))
+	}
+
+	var webAuthn mfaWebAuthnResponse
+	if err := json.NewDecoder(res.Body).Decode(&webAuthn); err != nil {
+		return nil, err
+	}
+
+	session := &MFAWebAuthnSession{URL: webAuthn.URL, PollInterval: defaultMFAPollInterval}
+	if webAuthn.PollIntervalSeconds > 0 {
+		session.PollInterval = time.Duration(webAuthn.PollIntervalSeconds) * time.Second
+	}
+	return session, nil
+}
+
+// PollMFAWebAuthn completes a login that requires MFA once the user has verified it with their security key,
+// returning ErrMFAPending until they have
+func (sc *basicStitchClient) PollMFAWebAuthn(mfaToken string) (*auth.Response, error) {
+	res, err := sc.postMFA(authMFAWebAuthnPollRoute, mfaWebAuthnPayload{MFAToken: mfaToken})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return decodeAuthResponse(res)
+	case http.StatusAccepted:
+		return nil, ErrMFAPending
+	}
+	return nil, fmt.Errorf("%s: failed to verify the login with a security key: %s", res.Status, UnmarshalStitchError(res))
+}
+
+func (sc *basicStitchClient) postMFA(route string, payload interface{}) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return sc.Client.ExecuteRequest(http.MethodPost, route, RequestOptions{
+		Body: bytes.NewReader(body),
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+	})
+}
+
+func decodeAuthResponse(res *http.Response) (*auth.Response, error) {
 	var authResponse auth.Response
-	if err := decoder.Decode(&authResponse); err != nil {
+	if err := json.NewDecoder(res.Body).Decode(&authResponse); err != nil {
 		return nil, err
 	}
 
 	return &authResponse, nil
 }
 
-// Export will download a Stitch app as a .zip
+// Export will download a Stitch app as a .zip. An app exported before by this client is requested with the
+// ETag it was served with, and is not downloaded again if it has not changed since
 func (sc *basicStitchClient) Export(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
-	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appExportRoute, groupID, appID, isTemplated), RequestOptions{})
+	route := fmt.Sprintf(appExportRoute, groupID, appID, isTemplated)
+
+	sc.exportsMu.Lock()
+	cached, isCached := sc.exports[route]
+	sc.exportsMu.Unlock()
+
+	var options RequestOptions
+	if isCached {
+		options.Header = http.Header{"If-None-Match": []string{cached.etag}}
+	}
+
+	res, err := sc.ExecuteRequest(http.MethodGet, route, options)
 	if err != nil {
 		return "", nil, err
 	}
 
+	if isCached && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		return cached.filename, ioutil.NopCloser(bytes.NewReader(cached.data)), nil
+	}
+
 	if res.StatusCode != http.StatusOK {
 		defer res.Body.Close()
 		return "", nil, UnmarshalStitchError(res)
@@ -143,12 +354,27 @@ func (sc *basicStitchClient) Export(groupID, appID string, isTemplated bool) (st
 		return "", nil, errExportMissingFilename
 	}
 
-	return filename, res.Body, nil
+	etag := res.Header.Get("ETag")
+	if etag == "" {
+		return filename, res.Body, nil
+	}
+
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sc.exportsMu.Lock()
+	sc.exports[route] = cachedExport{etag: etag, filename: filename, data: data}
+	sc.exportsMu.Unlock()
+
+	return filename, ioutil.NopCloser(bytes.NewReader(data)), nil
 }
 
 // Diff will execute a dry-run of an import, returning a diff of proposed changes
 func (sc *basicStitchClient) Diff(groupID, appID string, appData []byte, strategy string) ([]string, error) {
-	res, err := sc.invokeImportRoute(groupID, appID, appData, strategy, true)
+	res, err := sc.invokeImportRoute(groupID, appID, appData, strategy, true, "")
 	if err != nil {
 		return nil, err
 	}
@@ -167,23 +393,95 @@ func (sc *basicStitchClient) Diff(groupID, appID string, appData []byte, strateg
 	return diffs, nil
 }
 
-// Import will push a local Stitch app to the server
+// Import will push a local Stitch app to the server, sending it again with the same idempotency key if
+// it failed in transit, so that the server applies it only once
 func (sc *basicStitchClient) Import(groupID, appID string, appData []byte, strategy string) error {
-	res, err := sc.invokeImportRoute(groupID, appID, appData, strategy, false)
+	return sc.ImportWithProgress(groupID, appID, appData, strategy, DefaultImportDeploymentTimeout, nil)
+}
+
+// ImportWithProgress imports like Import does. When the server accepts the import to process it in the
+// background, it polls the resulting deployment until it succeeds or fails, for up to timeout (or
+// DefaultImportDeploymentTimeout if timeout is zero), calling progress, if it is not nil, each time the
+// deployment changes status
+func (sc *basicStitchClient) ImportWithProgress(groupID, appID string, appData []byte, strategy string, timeout time.Duration, progress func(*models.Deployment)) error {
+	if timeout == 0 {
+		timeout = DefaultImportDeploymentTimeout
+	}
+
+	idempotencyKey, err := newIdempotencyKey()
+	if err != nil {
+		return err
+	}
+
+	var res *http.Response
+	for attempt := 1; ; attempt++ {
+		res, err = sc.invokeImportRoute(groupID, appID, appData, strategy, false, idempotencyKey)
+		if attempt == importMaxAttempts || !isTransientImportFailure(res, err) {
+			break
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+		time.Sleep(importRetryDelay(attempt))
+	}
 	if err != nil {
 		return err
 	}
 
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusNoContent {
+	switch res.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusAccepted:
+		var deployment models.Deployment
+		if err := json.NewDecoder(res.Body).Decode(&deployment); err != nil {
+			return fmt.Errorf("failed to read the deployment of the import: %s", err)
+		}
+		return sc.awaitDeployment(groupID, appID, &deployment, timeout, progress)
+	default:
 		return UnmarshalStitchError(res)
 	}
+}
 
-	return nil
+// awaitDeployment polls the deployment until it succeeds or fails, calling progress each time its status changes.
+// It gives up once timeout has passed, or once checking on the deployment has failed several times in a row
+func (sc *basicStitchClient) awaitDeployment(groupID, appID string, deployment *models.Deployment, timeout time.Duration, progress func(*models.Deployment)) error {
+	deadline := time.Now().Add(timeout)
+	lastStatus := ""
+	failures := 0
+	for {
+		if progress != nil && deployment.Status != lastStatus {
+			progress(deployment)
+		}
+		lastStatus = deployment.Status
+
+		switch deployment.Status {
+		case models.DeploymentStatusSuccessful:
+			return nil
+		case models.DeploymentStatusFailed:
+			return fmt.Errorf("deployment %s failed: %s", deployment.ID, deployment.StatusErrorMessage)
+		}
+
+		if time.Now().Add(ImportPollInterval).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for deployment %s, which is still %s", timeout, deployment.ID, deployment.Status)
+		}
+		time.Sleep(ImportPollInterval)
+
+		fetched, err := sc.FetchDeployment(groupID, appID, deployment.ID)
+		if err != nil {
+			failures++
+			if failures == deploymentMaxFetchFailures {
+				return fmt.Errorf("failed to check on deployment %s: %s", deployment.ID, err)
+			}
+			continue
+		}
+		failures = 0
+		deployment = fetched
+	}
 }
 
-func (sc *basicStitchClient) invokeImportRoute(groupID, appID string, appData []byte, strategy string, diff bool) (*http.Response, error) {
+func (sc *basicStitchClient) invokeImportRoute(groupID, appID string, appData []byte, strategy string, diff bool, idempotencyKey string) (*http.Response, error) {
 	url := fmt.Sprintf(appImportRoute, groupID, appID)
 
 	url += fmt.Sprintf("?strategy=%s", strategy)
@@ -191,27 +489,117 @@ func (sc *basicStitchClient) invokeImportRoute(groupID, appID string, appData []
 		url += "&diff=true"
 	}
 
-	return sc.ExecuteRequest(http.MethodPost, url, RequestOptions{Body: bytes.NewReader(appData)})
-}
+	header := func() http.Header {
+		header := http.Header{}
+		if idempotencyKey != "" {
+			header.Set(ImportIdempotencyKeyHeader, idempotencyKey)
+		}
+		return header
+	}
 
-func (sc *basicStitchClient) FetchAppsByGroupID(groupID string) ([]*models.App, error) {
-	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appsByGroupIDRoute, groupID), RequestOptions{})
+	if len(appData) < gzipMinRequestSize || atomic.LoadInt32(&sc.gzipRejected) == 1 {
+		return sc.ExecuteRequest(http.MethodPost, url, RequestOptions{Body: bytes.NewReader(appData), Header: header()})
+	}
+
+	compressed, err := gzipBytes(appData)
 	if err != nil {
 		return nil, err
 	}
 
-	defer res.Body.Close()
+	compressedHeader := header()
+	compressedHeader.Set("Content-Encoding", "gzip")
+	res, err := sc.ExecuteRequest(http.MethodPost, url, RequestOptions{
+		Body:   bytes.NewReader(compressed),
+		Header: compressedHeader,
+	})
+	if err != nil || res.StatusCode != http.StatusUnsupportedMediaType {
+		return res, err
+	}
 
-	if res.StatusCode != http.StatusOK {
-		if res.StatusCode == http.StatusNotFound {
-			return nil, errGroupNotFound
+	// the server does not accept compressed bodies, so this and later requests are sent as they are
+	res.Body.Close()
+	atomic.StoreInt32(&sc.gzipRejected, 1)
+
+	return sc.ExecuteRequest(http.MethodPost, url, RequestOptions{Body: bytes.NewReader(appData), Header: header()})
+}
+
+// newIdempotencyKey returns a random key identifying a single import
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate an import idempotency key: %s", err)
+	}
+
+	return hex.EncodeToString(key), nil
+}
+
+// isTransientImportFailure returns whether an import failed in transit, being worth sending again: it could not
+// connect to the server, timed out, had its response cut short, or failed at a gateway. The server may already
+// have applied such an import, which its idempotency key keeps it from applying again
+func isTransientImportFailure(res *http.Response, err error) bool {
+	if err == nil {
+		switch res.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
 		}
-		return nil, UnmarshalStitchError(res)
+		return false
 	}
 
-	dec := json.NewDecoder(res.Body)
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return true
+	}
+
+	if opErr, ok := err.(*net.OpError); ok && opErr.Op == "dial" {
+		return true
+	}
+
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// importRetryDelay returns how long to wait after the given attempt to send an import, which is between half and
+// all of ImportRetryBackoff doubled for each attempt before it
+func importRetryDelay(attempt int) time.Duration {
+	backoff := ImportRetryBackoff << uint(attempt-1)
+	return backoff/2 + time.Duration(mrand.Int63n(int64(backoff/2)+1))
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (sc *basicStitchClient) FetchAppsByGroupID(groupID string) ([]*models.App, error) {
 	var apps []*models.App
-	if err := dec.Decode(&apps); err != nil {
+	err := sc.fetchAllPages(fmt.Sprintf(appsByGroupIDRoute, groupID), func(res *http.Response) error {
+		if res.StatusCode != http.StatusOK {
+			if res.StatusCode == http.StatusNotFound {
+				return errGroupNotFound
+			}
+			return UnmarshalStitchError(res)
+		}
+
+		var page []*models.App
+		if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+			return err
+		}
+		apps = append(apps, page...)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -225,6 +613,16 @@ func (sc *basicStitchClient) FetchAppByGroupIDAndClientAppID(groupID, clientAppI
 
 // FetchAppByClientAppID fetches a Stitch app given a clientAppID
 func (sc *basicStitchClient) FetchAppByClientAppID(clientAppID string) (*models.App, error) {
+	profileData, err := sc.FetchUserProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	return sc.findProjectAppByClientAppID(profileData.AllGroupIDs(), clientAppID)
+}
+
+// FetchUserProfile fetches the profile of the current user, which lists the roles they have in each project
+func (sc *basicStitchClient) FetchUserProfile() (*models.UserProfile, error) {
 	res, err := sc.ExecuteRequest(http.MethodGet, userProfileRoute, RequestOptions{})
 	if err != nil {
 		return nil, err
@@ -242,7 +640,7 @@ func (sc *basicStitchClient) FetchAppByClientAppID(clientAppID string) (*models.
 		return nil, err
 	}
 
-	return sc.findProjectAppByClientAppID(profileData.AllGroupIDs(), clientAppID)
+	return &profileData, nil
 }
 
 // UploadAsset creates a pipe and writes the asset to an http.POST along with its metadata
@@ -318,7 +716,7 @@ func (sc *basicStitchClient) SetAssetAttributes(groupID, appID, path string, att
 
 	res, err := sc.ExecuteRequest(
 		http.MethodPatch,
-		fmt.Sprintf(hostingAssetRoute+"?%s=%s", groupID, appID, pathParam, path),
+		assetURL(groupID, appID, path),
 		RequestOptions{
 			Body: bytes.NewReader(attrs),
 		},
@@ -358,11 +756,16 @@ func (sc *basicStitchClient) invokePostRoute(groupID, appID string, payload io.R
 	)
 }
 
+// assetURL returns the URL of the asset at path, which may contain characters such as spaces, '#', and '?'
+func assetURL(groupID, appID, path string) string {
+	return fmt.Sprintf(hostingAssetRoute, groupID, appID) + "?" + url.Values{pathParam: {path}}.Encode()
+}
+
 // DeleteAsset deletes the asset at the given path
 func (sc *basicStitchClient) DeleteAsset(groupID, appID, path string) error {
 	res, err := sc.ExecuteRequest(
 		http.MethodDelete,
-		fmt.Sprintf(hostingAssetRoute+"?%s=%s", groupID, appID, pathParam, path),
+		assetURL(groupID, appID, path),
 		RequestOptions{},
 	)
 	return checkStatusNoContent(res, err, "failed to delete asset")
@@ -380,7 +783,7 @@ func (sc *basicStitchClient) findProjectAppByClientAppID(groupIDs []string, clie
 		}
 	}
 
-	return nil, ErrAppNotFound{clientAppID}
+	return nil, ErrAppNotFound{ClientAppID: clientAppID}
 }
 
 func (sc *basicStitchClient) CreateEmptyApp(groupID, appName, location, deploymentModel string) (*models.App, error) {
@@ -409,34 +812,62 @@ func (sc *basicStitchClient) CreateEmptyApp(groupID, appName, location, deployme
 }
 
 func (sc *basicStitchClient) ListAssetsForAppID(groupID, appID string) ([]hosting.AssetMetadata, error) {
-	res, err := sc.ExecuteRequest(
-		http.MethodGet,
-		fmt.Sprintf(hostingAssetsRoute+"?recursive=true", groupID, appID),
-		RequestOptions{},
-	)
+	var assetMetadata []hosting.AssetMetadata
+	err := sc.fetchAllPages(fmt.Sprintf(hostingAssetsRoute+"?recursive=true", groupID, appID), func(res *http.Response) error {
+		if res.StatusCode != http.StatusOK {
+			return UnmarshalStitchError(res)
+		}
+
+		var page []hosting.AssetMetadata
+		if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+			return err
+		}
+		assetMetadata = append(assetMetadata, page...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return nil, UnmarshalStitchError(res)
-	}
+	return assetMetadata, nil
+}
 
-	dec := json.NewDecoder(res.Body)
+// ListAssetsForDeployment fetches the metadata of the hosting assets an app had as of one of its deployments
+func (sc *basicStitchClient) ListAssetsForDeployment(groupID, appID, deploymentID string) ([]hosting.AssetMetadata, error) {
 	var assetMetadata []hosting.AssetMetadata
-	if err := dec.Decode(&assetMetadata); err != nil {
+	err := sc.fetchAllPages(fmt.Sprintf(deploymentHostingRoute+"?recursive=true", groupID, appID, deploymentID), func(res *http.Response) error {
+		if res.StatusCode != http.StatusOK {
+			return UnmarshalStitchError(res)
+		}
+
+		var page []hosting.AssetMetadata
+		if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+			return err
+		}
+		assetMetadata = append(assetMetadata, page...)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return assetMetadata, nil
 }
 
+// escapeInvalidationPath percent-encodes the characters of path that CloudFront requires to be encoded,
+// leaving the slashes between segments and any '*' wildcard as they are
+func escapeInvalidationPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = strings.Replace(url.PathEscape(segment), "%2A", "*", -1)
+	}
+	return strings.Join(segments, "/")
+}
+
 // InvalidateCache requests cache invalidation for the resource at the given
 // path in the app's CloudFront distribution
 func (sc *basicStitchClient) InvalidateCache(groupID, appID, path string) error {
-	payload, err := json.Marshal(invalidateCachePayload{Invalidate: true, Path: path})
+	payload, err := json.Marshal(invalidateCachePayload{Invalidate: true, Path: escapeInvalidationPath(path)})
 	if err != nil {
 		return err
 	}
@@ -451,6 +882,469 @@ func (sc *basicStitchClient) InvalidateCache(groupID, appID, path string) error
 	return checkStatusNoContent(res, err, "failed to invalidate cache")
 }
 
+// FetchConfigSchemas fetches the latest JSON Schema for each type of entity, keyed by entity type
+func (sc *basicStitchClient) FetchConfigSchemas() (map[string]json.RawMessage, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, configSchemasRoute, RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var schemas map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&schemas); err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+// FetchLimits fetches the limits the server enforces on apps
+func (sc *basicStitchClient) FetchLimits() (*models.Limits, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, configLimitsRoute, RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var limits models.Limits
+	if err := json.NewDecoder(res.Body).Decode(&limits); err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
+// FetchLatestDeployment fetches the most recent deployment of the given app. It returns nil if
+// the app has never been deployed
+func (sc *basicStitchClient) FetchLatestDeployment(groupID, appID string) (*models.Deployment, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appDeploymentsRoute+"?limit=1", groupID, appID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var deployments []models.Deployment
+	if err := json.NewDecoder(res.Body).Decode(&deployments); err != nil {
+		return nil, err
+	}
+
+	if len(deployments) == 0 {
+		return nil, nil
+	}
+
+	return &deployments[0], nil
+}
+
+// FetchDeployment fetches a deployment of the given app, including the status of its rollout to each region
+func (sc *basicStitchClient) FetchDeployment(groupID, appID, deploymentID string) (*models.Deployment, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appDeploymentRoute, groupID, appID, deploymentID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var deployment models.Deployment
+	if err := json.NewDecoder(res.Body).Decode(&deployment); err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// ExecuteFunction calls the named function of the given app as the system user, returning its result
+func (sc *basicStitchClient) ExecuteFunction(groupID, appID, name string, args []interface{}) (*models.FunctionExecution, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	payload, err := json.Marshal(executeFunctionPayload{Name: name, Arguments: args})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := sc.ExecuteRequest(
+		http.MethodPost,
+		fmt.Sprintf(executeFunctionRoute, groupID, appID),
+		RequestOptions{
+			Body:   bytes.NewReader(payload),
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var execution models.FunctionExecution
+	if err := json.NewDecoder(res.Body).Decode(&execution); err != nil {
+		return nil, err
+	}
+
+	return &execution, nil
+}
+
+// RenameApp changes the user-defined name of an app. The app's Client App ID is unaffected
+func (sc *basicStitchClient) RenameApp(groupID, appID, name string) error {
+	payload, err := json.Marshal(renameAppPayload{Name: name})
+	if err != nil {
+		return err
+	}
+
+	res, err := sc.ExecuteRequest(
+		http.MethodPatch,
+		fmt.Sprintf(appRoute, groupID, appID),
+		RequestOptions{
+			Body: bytes.NewReader(payload),
+		},
+	)
+	return checkStatusNoContent(res, err, "failed to rename app")
+}
+
+// DeleteApp deletes an app along with its config, secrets and values. Its hosted assets are not deleted
+func (sc *basicStitchClient) DeleteApp(groupID, appID string) error {
+	res, err := sc.ExecuteRequest(http.MethodDelete, fmt.Sprintf(appRoute, groupID, appID), RequestOptions{})
+	return checkStatusNoContent(res, err, "failed to delete app")
+}
+
+// FetchHostingConfig fetches the app-wide static hosting settings of an app
+func (sc *basicStitchClient) FetchHostingConfig(groupID, appID string) (*hosting.Config, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(hostingConfigRoute, groupID, appID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var config hosting.Config
+	if err := json.NewDecoder(res.Body).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// UpdateHostingConfig replaces the app-wide static hosting settings of an app
+func (sc *basicStitchClient) UpdateHostingConfig(groupID, appID string, config *hosting.Config) error {
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	res, err := sc.ExecuteRequest(
+		http.MethodPut,
+		fmt.Sprintf(hostingConfigRoute, groupID, appID),
+		RequestOptions{
+			Body: bytes.NewReader(payload),
+		},
+	)
+	return checkStatusNoContent(res, err, "failed to update hosting config")
+}
+
+// FetchValues fetches the values of an app
+func (sc *basicStitchClient) FetchValues(groupID, appID string) ([]models.Value, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appValuesRoute, groupID, appID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var values []models.Value
+	if err := json.NewDecoder(res.Body).Decode(&values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// CreateValue creates a value in an app, returning it along with its ID. It returns ErrValueExists if the
+// app already has a value with the same name, which makes creating a value an atomic test-and-set
+func (sc *basicStitchClient) CreateValue(groupID, appID string, value models.Value) (*models.Value, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := sc.ExecuteRequest(
+		http.MethodPost,
+		fmt.Sprintf(appValuesRoute, groupID, appID),
+		RequestOptions{Body: bytes.NewReader(payload)},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusCreated:
+	case http.StatusConflict:
+		return nil, ErrValueExists
+	default:
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var created models.Value
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// DeleteValue deletes a value of an app
+func (sc *basicStitchClient) DeleteValue(groupID, appID, valueID string) error {
+	res, err := sc.ExecuteRequest(http.MethodDelete, fmt.Sprintf(appValueRoute, groupID, appID, valueID), RequestOptions{})
+	return checkStatusNoContent(res, err, "failed to delete value")
+}
+
+// FetchSecrets fetches the secrets of an app, without their values
+func (sc *basicStitchClient) FetchSecrets(groupID, appID string) ([]models.Secret, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appSecretsRoute, groupID, appID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var secrets []models.Secret
+	if err := json.NewDecoder(res.Body).Decode(&secrets); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// CreateSecret creates a secret in an app
+func (sc *basicStitchClient) CreateSecret(groupID, appID, name, value string) error {
+	payload, err := json.Marshal(models.Secret{Name: name, Value: value})
+	if err != nil {
+		return err
+	}
+
+	res, err := sc.ExecuteRequest(
+		http.MethodPost,
+		fmt.Sprintf(appSecretsRoute, groupID, appID),
+		RequestOptions{Body: bytes.NewReader(payload)},
+	)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return UnmarshalStitchError(res)
+	}
+
+	return nil
+}
+
+// FetchMeasurements fetches the usage metrics of an app between start and end, aggregated over periods of
+// the given granularity, an ISO 8601 duration such as "P1D"
+func (sc *basicStitchClient) FetchMeasurements(groupID, appID string, start, end time.Time, granularity string) (*models.Measurements, error) {
+	route := fmt.Sprintf(
+		appMeasurementsRoute,
+		groupID,
+		appID,
+		url.QueryEscape(start.UTC().Format(time.RFC3339)),
+		url.QueryEscape(end.UTC().Format(time.RFC3339)),
+		url.QueryEscape(granularity),
+	)
+
+	res, err := sc.ExecuteRequest(http.MethodGet, route, RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var measurements models.Measurements
+	if err := json.NewDecoder(res.Body).Decode(&measurements); err != nil {
+		return nil, err
+	}
+
+	return &measurements, nil
+}
+
+// FetchAuthProviders fetches the auth providers of an app. Only their IDs, names, types and whether they are
+// disabled are listed
+func (sc *basicStitchClient) FetchAuthProviders(groupID, appID string) ([]models.AuthProvider, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appAuthProvidersRoute, groupID, appID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var providers []models.AuthProvider
+	if err := json.NewDecoder(res.Body).Decode(&providers); err != nil {
+		return nil, err
+	}
+
+	return providers, nil
+}
+
+// FetchAuthProvider fetches an auth provider of an app, along with its config
+func (sc *basicStitchClient) FetchAuthProvider(groupID, appID, providerID string) (*models.AuthProvider, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(appAuthProviderRoute, groupID, appID, providerID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var provider models.AuthProvider
+	if err := json.NewDecoder(res.Body).Decode(&provider); err != nil {
+		return nil, err
+	}
+
+	return &provider, nil
+}
+
+// UpdateAuthProvider replaces an auth provider of an app
+func (sc *basicStitchClient) UpdateAuthProvider(groupID, appID string, provider *models.AuthProvider) error {
+	payload, err := json.Marshal(provider)
+	if err != nil {
+		return err
+	}
+
+	res, err := sc.ExecuteRequest(
+		http.MethodPut,
+		fmt.Sprintf(appAuthProviderRoute, groupID, appID, provider.ID),
+		RequestOptions{
+			Body: bytes.NewReader(payload),
+		},
+	)
+	return checkStatusNoContent(res, err, "failed to update auth provider")
+}
+
+// FetchLogs fetches a page of the log entries of an app matching query, newest first
+func (sc *basicStitchClient) FetchLogs(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, logsRoute(groupID, appID, query), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalStitchError(res)
+	}
+
+	var page models.LogPage
+	if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// StreamLogs opens a stream of the log entries of an app matching query that are logged from its Start on, oldest
+// first, which the server keeps sending as they are logged. If the server does not stream logs,
+// ErrLogStreamingUnsupported is returned
+func (sc *basicStitchClient) StreamLogs(groupID, appID string, query models.LogQuery) (*LogStream, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, logsRoute(groupID, appID, query), RequestOptions{
+		Header: http.Header{"Accept": []string{"text/event-stream"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusNotAcceptable, http.StatusNotImplemented:
+		res.Body.Close()
+		return nil, ErrLogStreamingUnsupported
+	default:
+		defer res.Body.Close()
+		return nil, UnmarshalStitchError(res)
+	}
+
+	// a server that does not stream logs answers with a page of them
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		res.Body.Close()
+		return nil, ErrLogStreamingUnsupported
+	}
+
+	return NewLogStream(res.Body), nil
+}
+
+// logsRoute returns the route of the logs of an app, with the parameters selecting the entries matching query
+func logsRoute(groupID, appID string, query models.LogQuery) string {
+	params := url.Values{}
+	if !query.Start.IsZero() {
+		params.Set("start_date", query.Start.UTC().Format(time.RFC3339))
+	}
+	if !query.End.IsZero() {
+		params.Set("end_date", query.End.UTC().Format(time.RFC3339))
+	}
+	if query.Type != "" {
+		params.Set("type", query.Type)
+	}
+	if query.ErrorsOnly {
+		params.Set("errors_only", "true")
+	}
+	if query.Skip > 0 {
+		params.Set("skip", strconv.Itoa(query.Skip))
+	}
+
+	route := fmt.Sprintf(appLogsRoute, groupID, appID)
+	if len(params) > 0 {
+		route += "?" + params.Encode()
+	}
+	return route
+}
+
 func checkStatusNoContent(res *http.Response, requestErr error, errMessage string) error {
 	if requestErr != nil {
 		return requestErr
@@ -2,19 +2,26 @@ package api_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/auth"
 	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
 
 	u "github.com/10gen/stitch-cli/utils/test"
 	gc "github.com/smartystreets/goconvey/convey"
@@ -175,6 +182,24 @@ func TestListAssetsForAppID(t *testing.T) {
 	})
 }
 
+func TestListAssetsForDeployment(t *testing.T) {
+	t.Run("should list the assets recorded at the deployment", func(t *testing.T) {
+		var requestedPath string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedPath = r.URL.Path
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{"path": "/index.html", "hash": "abc"}]`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		assets, err := testClient.ListAssetsForDeployment(groupID, appID, "deployment-id")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, requestedPath, gc.ShouldEqual, fmt.Sprintf("/api/admin/v3.0/groups/%s/apps/%s/deployments/deployment-id/hosting/assets", groupID, appID))
+		u.So(t, assets, gc.ShouldResemble, []hosting.AssetMetadata{{FilePath: "/index.html", FileHash: "abc"}})
+	})
+}
+
 func TestSetAssetAttributes(t *testing.T) {
 	t.Run("setting app attributes should work", func(t *testing.T) {
 		testContents := []hosting.AssetAttribute{
@@ -213,6 +238,20 @@ func TestSetAssetAttributes(t *testing.T) {
 		err := testClient.SetAssetAttributes(groupID, appID, path, testContents...)
 		u.So(t, err, gc.ShouldBeNil)
 	})
+
+	t.Run("setting attributes of an asset with special characters in its path should encode it", func(t *testing.T) {
+		var updatedPath string
+		testHandler := func(w http.ResponseWriter, r *http.Request) {
+			updatedPath = r.URL.Query().Get(pathParam)
+			w.WriteHeader(http.StatusNoContent)
+		}
+		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
+		path := "/my docs/naïve #2?.css"
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.SetAssetAttributes(groupID, appID, path), gc.ShouldBeNil)
+		u.So(t, updatedPath, gc.ShouldEqual, path)
+	})
 }
 
 func TestPostAsset(t *testing.T) {
@@ -274,6 +313,20 @@ func TestDeleteAsset(t *testing.T) {
 		err := testClient.DeleteAsset(groupID, appID, path)
 		u.So(t, err, gc.ShouldBeNil)
 	})
+
+	t.Run("deleting an asset with special characters in its path should encode it", func(t *testing.T) {
+		var deletedPath string
+		testHandler := func(w http.ResponseWriter, r *http.Request) {
+			deletedPath = r.URL.Query().Get(pathParam)
+			w.WriteHeader(http.StatusNoContent)
+		}
+		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
+		path := "/my docs/café #1?.html&x=y"
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.DeleteAsset(groupID, appID, path), gc.ShouldBeNil)
+		u.So(t, deletedPath, gc.ShouldEqual, path)
+	})
 }
 
 func TestInvalidateCache(t *testing.T) {
@@ -306,6 +359,148 @@ func TestInvalidateCache(t *testing.T) {
 		err := testClient.InvalidateCache(groupID, appID, path)
 		u.So(t, err, gc.ShouldBeNil)
 	})
+
+	t.Run("cache invalidation should percent-encode the path but keep wildcards", func(t *testing.T) {
+		var invalidatedPath string
+		testHandler := func(w http.ResponseWriter, r *http.Request) {
+			payload := struct {
+				Path string `json:"path"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, "invalid payload", http.StatusBadRequest)
+				return
+			}
+			invalidatedPath = payload.Path
+			w.WriteHeader(http.StatusNoContent)
+		}
+		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.InvalidateCache(groupID, appID, "/my docs/café #1?/*"), gc.ShouldBeNil)
+		u.So(t, invalidatedPath, gc.ShouldEqual, "/my%20docs/caf%C3%A9%20%231%3F/*")
+	})
+}
+
+func TestExport(t *testing.T) {
+	newTestServer := func(etag string, version *string, downloads *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if etag != "" {
+				currentETag := etag + *version
+				if r.Header.Get("If-None-Match") == currentETag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", currentETag)
+			}
+			*downloads++
+			w.Header().Set(hosting.AttributeContentDisposition, `attachment; filename="my-app_20200101.zip"`)
+			io.WriteString(w, "zip data "+*version)
+		}))
+	}
+
+	readExport := func(t *testing.T, testClient api.StitchClient) (string, string) {
+		filename, body, err := testClient.Export(groupID, appID, false)
+		u.So(t, err, gc.ShouldBeNil)
+		defer body.Close()
+
+		data, err := ioutil.ReadAll(body)
+		u.So(t, err, gc.ShouldBeNil)
+		return filename, string(data)
+	}
+
+	t.Run("it does not download an unchanged export again", func(t *testing.T) {
+		version, downloads := "1", 0
+		testServer := newTestServer(`"abc`, &version, &downloads)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		for i := 0; i < 2; i++ {
+			filename, data := readExport(t, testClient)
+			u.So(t, filename, gc.ShouldEqual, "my-app_20200101.zip")
+			u.So(t, data, gc.ShouldEqual, "zip data 1")
+		}
+		u.So(t, downloads, gc.ShouldEqual, 1)
+
+		version = "2"
+		_, data := readExport(t, testClient)
+		u.So(t, data, gc.ShouldEqual, "zip data 2")
+		u.So(t, downloads, gc.ShouldEqual, 2)
+	})
+
+	t.Run("it downloads every export without an ETag", func(t *testing.T) {
+		version, downloads := "1", 0
+		testServer := newTestServer("", &version, &downloads)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		for i := 0; i < 2; i++ {
+			_, data := readExport(t, testClient)
+			u.So(t, data, gc.ShouldEqual, "zip data 1")
+		}
+		u.So(t, downloads, gc.ShouldEqual, 2)
+	})
+}
+
+func TestImportCompression(t *testing.T) {
+	largeAppData := []byte(`{"name": "` + strings.Repeat("a", 100*1024) + `"}`)
+
+	// newTestServer returns a server that records the bodies it is sent, decoded, and the encoding they
+	// were sent with, refusing compressed ones if acceptGzip is false
+	newTestServer := func(acceptGzip bool, bodies *[]string, encodings *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := r.Header.Get("Content-Encoding")
+			*encodings = append(*encodings, encoding)
+
+			if encoding == "gzip" && !acceptGzip {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+
+			var body io.Reader = r.Body
+			if encoding == "gzip" {
+				zr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				body = zr
+			}
+
+			data, err := ioutil.ReadAll(body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			*bodies = append(*bodies, string(data))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+
+	t.Run("it compresses large app data", func(t *testing.T) {
+		var bodies, encodings []string
+		testServer := newTestServer(true, &bodies, &encodings)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.Import(groupID, appID, largeAppData, "merge"), gc.ShouldBeNil)
+		u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "small"}`), "merge"), gc.ShouldBeNil)
+
+		u.So(t, encodings, gc.ShouldResemble, []string{"gzip", ""})
+		u.So(t, bodies, gc.ShouldResemble, []string{string(largeAppData), `{"name": "small"}`})
+	})
+
+	t.Run("it sends app data uncompressed once the server refuses compression", func(t *testing.T) {
+		var bodies, encodings []string
+		testServer := newTestServer(false, &bodies, &encodings)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.Import(groupID, appID, largeAppData, "merge"), gc.ShouldBeNil)
+		u.So(t, testClient.Import(groupID, appID, largeAppData, "merge"), gc.ShouldBeNil)
+
+		u.So(t, encodings, gc.ShouldResemble, []string{"gzip", "", ""})
+		u.So(t, bodies, gc.ShouldResemble, []string{string(largeAppData), string(largeAppData)})
+	})
 }
 
 func TestRequestOrigin(t *testing.T) {
@@ -325,3 +520,659 @@ func TestRequestOrigin(t *testing.T) {
 		u.So(t, resp.StatusCode, gc.ShouldEqual, http.StatusNoContent)
 	})
 }
+
+func TestRenameApp(t *testing.T) {
+	t.Run("renaming an app should send the new name", func(t *testing.T) {
+		var method, path, name string
+		testHandler := func(w http.ResponseWriter, r *http.Request) {
+			method, path = r.Method, r.URL.Path
+
+			payload := struct {
+				Name string `json:"name"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, "invalid payload", http.StatusBadRequest)
+				return
+			}
+			name = payload.Name
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.RenameApp(groupID, appID, "new-name")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, method, gc.ShouldEqual, http.MethodPatch)
+		u.So(t, path, gc.ShouldEqual, "/api/admin/v3.0/groups/groupID/apps/appID")
+		u.So(t, name, gc.ShouldEqual, "new-name")
+	})
+
+	t.Run("renaming an app should fail if the server rejects it", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": "name is already in use"}`, http.StatusConflict)
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.RenameApp(groupID, appID, "new-name")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldStartWith, "409 Conflict: failed to rename app")
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "name is already in use")
+	})
+}
+
+func TestDeleteApp(t *testing.T) {
+	t.Run("deleting an app should send a delete request for it", func(t *testing.T) {
+		var method, path string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, path = r.Method, r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.DeleteApp(groupID, appID), gc.ShouldBeNil)
+		u.So(t, method, gc.ShouldEqual, http.MethodDelete)
+		u.So(t, path, gc.ShouldEqual, "/api/admin/v3.0/groups/groupID/apps/appID")
+	})
+
+	t.Run("deleting an app should fail if the server rejects it", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": "app not found"}`, http.StatusNotFound)
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.DeleteApp(groupID, appID)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldStartWith, "404 Not Found: failed to delete app")
+	})
+}
+
+func TestAuthenticateMFA(t *testing.T) {
+	var requests []string
+	var bodies []map[string]string
+	polls := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+
+		switch r.URL.Path {
+		case "/api/admin/v3.0/auth/providers/mongodb-cloud/login":
+			if body["username"] == "locked-down" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "MFA is required", "error_code": "MFARequired", "mfa_token": "mfa-token", "mfa_factors": ["totp", "webauthn"]}`))
+				return
+			}
+			http.Error(w, `{"error": "invalid API key"}`, http.StatusUnauthorized)
+		case "/api/admin/v3.0/auth/mfa/totp/verify":
+			if body["code"] != "123456" {
+				http.Error(w, `{"error": "invalid code"}`, http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token": "totp.access.token", "refresh_token": "totp.refresh.token"}`))
+		case "/api/admin/v3.0/auth/mfa/webauthn":
+			w.Write([]byte(`{"url": "https://example.com/webauthn?token=mfa-token", "poll_interval_seconds": 3}`))
+		case "/api/admin/v3.0/auth/mfa/webauthn/poll":
+			if polls++; polls == 1 {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.Write([]byte(`{"access_token": "webauthn.access.token", "refresh_token": "webauthn.refresh.token"}`))
+		}
+	}))
+	defer testServer.Close()
+
+	testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+
+	t.Run("authenticating an account that requires MFA should return what it can be verified with", func(t *testing.T) {
+		_, err := testClient.Authenticate(auth.NewAPIKeyProvider("locked-down", "my-private-key"))
+		mfaRequired, ok := err.(*api.MFARequiredError)
+		u.So(t, ok, gc.ShouldBeTrue)
+		u.So(t, mfaRequired, gc.ShouldResemble, &api.MFARequiredError{Token: "mfa-token", Factors: []string{"totp", "webauthn"}})
+		u.So(t, mfaRequired.Supports(api.MFAFactorWebAuthn), gc.ShouldBeTrue)
+	})
+
+	t.Run("authenticating should fail as before for other errors", func(t *testing.T) {
+		_, err := testClient.Authenticate(auth.NewAPIKeyProvider("someone", "my-private-key"))
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, "401 Unauthorized: failed to authenticate: error: invalid API key")
+	})
+
+	t.Run("verifying with a code should complete the login", func(t *testing.T) {
+		authResponse, err := testClient.VerifyMFACode("mfa-token", "123456")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, authResponse.AccessToken, gc.ShouldEqual, "totp.access.token")
+		u.So(t, bodies[len(bodies)-1], gc.ShouldResemble, map[string]string{"mfa_token": "mfa-token", "code": "123456"})
+
+		_, err = testClient.VerifyMFACode("mfa-token", "654321")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldStartWith, "401 Unauthorized: failed to verify the MFA code")
+	})
+
+	t.Run("verifying with a security key should complete the login once it is verified in the browser", func(t *testing.T) {
+		session, err := testClient.StartMFAWebAuthn("mfa-token")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, session, gc.ShouldResemble, &api.MFAWebAuthnSession{URL: "https://example.com/webauthn?token=mfa-token", PollInterval: 3 * time.Second})
+
+		_, err = testClient.PollMFAWebAuthn("mfa-token")
+		u.So(t, err, gc.ShouldEqual, api.ErrMFAPending)
+
+		authResponse, err := testClient.PollMFAWebAuthn("mfa-token")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, authResponse.AccessToken, gc.ShouldEqual, "webauthn.access.token")
+		u.So(t, requests[len(requests)-1], gc.ShouldEqual, "POST /api/admin/v3.0/auth/mfa/webauthn/poll")
+	})
+}
+
+func TestPagination(t *testing.T) {
+	t.Run("listing apps should follow links to the next page", func(t *testing.T) {
+		var requested []string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = append(requested, r.URL.RequestURI())
+
+			switch r.URL.Query().Get("page") {
+			case "":
+				w.Header().Set("Link", `<http://`+r.Host+r.URL.Path+`?page=2>; rel="next", <http://`+r.Host+r.URL.Path+`?page=2>; rel="last"`)
+				w.Write([]byte(`[{"_id": "1", "client_app_id": "app-1"}]`))
+			case "2":
+				w.Write([]byte(`[{"_id": "2", "client_app_id": "app-2"}]`))
+			}
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		apps, err := testClient.FetchAppsByGroupID(groupID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, apps, gc.ShouldHaveLength, 2)
+		u.So(t, apps[0].ClientAppID, gc.ShouldEqual, "app-1")
+		u.So(t, apps[1].ClientAppID, gc.ShouldEqual, "app-2")
+		u.So(t, requested, gc.ShouldResemble, []string{
+			"/api/admin/v3.0/groups/groupID/apps",
+			"/api/admin/v3.0/groups/groupID/apps?page=2",
+		})
+	})
+
+	t.Run("listing assets should follow links to the next page", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("after") == "" {
+				w.Header().Set("Link", `</api/admin/v3.0/groups/groupID/apps/appID/hosting/assets?recursive=true&after=%2Fa.html>; rel="next"`)
+				w.Write([]byte(`[{"path": "/a.html"}]`))
+				return
+			}
+			u.So(t, r.URL.Query().Get("after"), gc.ShouldEqual, "/a.html")
+			w.Write([]byte(`[{"path": "/b.html"}]`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		assets, err := testClient.ListAssetsForAppID(groupID, appID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, assets, gc.ShouldHaveLength, 2)
+		u.So(t, assets[0].FilePath, gc.ShouldEqual, "/a.html")
+		u.So(t, assets[1].FilePath, gc.ShouldEqual, "/b.html")
+	})
+
+	t.Run("listing should fail if a page links back to one already fetched", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Link", `<`+r.URL.RequestURI()+`>; rel="next"`)
+			w.Write([]byte(`[]`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		_, err := testClient.FetchAppsByGroupID(groupID)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "links back to")
+	})
+}
+
+func TestImportIdempotency(t *testing.T) {
+	defer func(backoff time.Duration) { api.ImportRetryBackoff = backoff }(api.ImportRetryBackoff)
+
+	// unusedAddress returns an address nothing listens on, so that connecting to it is refused
+	unusedAddress := func() string {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		u.So(t, err, gc.ShouldBeNil)
+		listener.Close()
+		return listener.Addr().String()
+	}
+
+	t.Run("it retries an import that could not connect to the server with the same idempotency key", func(t *testing.T) {
+		api.ImportRetryBackoff = 200 * time.Millisecond
+
+		var keys []string
+		address := unusedAddress()
+		testServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Header.Get(api.ImportIdempotencyKeyHeader))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer testServer.Close()
+
+		// the server only starts listening once the first attempt was refused, during the backoff before the next
+		time.AfterFunc(20*time.Millisecond, func() {
+			listener, err := net.Listen("tcp", address)
+			if err != nil {
+				panic(err)
+			}
+			testServer.Listener = listener
+			testServer.Start()
+		})
+
+		testClient := api.NewStitchClient(api.NewClient("http://" + address))
+		u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge"), gc.ShouldBeNil)
+
+		u.So(t, keys, gc.ShouldHaveLength, 1)
+		u.So(t, keys[0], gc.ShouldNotBeEmpty)
+	})
+
+	t.Run("it backs off between attempts and gives up after the maximum number of attempts", func(t *testing.T) {
+		api.ImportRetryBackoff = 20 * time.Millisecond
+
+		started := time.Now()
+		testClient := api.NewStitchClient(api.NewClient("http://" + unusedAddress()))
+		u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge"), gc.ShouldNotBeNil)
+
+		// the delays after the first and second attempts are at least 10ms and 20ms
+		u.So(t, time.Since(started), gc.ShouldBeGreaterThanOrEqualTo, 30*time.Millisecond)
+	})
+
+	t.Run("it retries an import that failed at a gateway with the same idempotency key", func(t *testing.T) {
+		api.ImportRetryBackoff = time.Millisecond
+
+		var keys []string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Header.Get(api.ImportIdempotencyKeyHeader))
+			if len(keys) == 1 {
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge"), gc.ShouldBeNil)
+
+		u.So(t, keys, gc.ShouldHaveLength, 2)
+		u.So(t, keys[0], gc.ShouldNotBeEmpty)
+		u.So(t, keys[1], gc.ShouldEqual, keys[0])
+	})
+
+	t.Run("it retries an import that timed out or had its response cut short with the same idempotency key", func(t *testing.T) {
+		api.ImportRetryBackoff = time.Millisecond
+
+		var received []string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = append(received, r.Header.Get(api.ImportIdempotencyKeyHeader))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer testServer.Close()
+
+		for _, transitErr := range []error{timeoutError{}, io.ErrUnexpectedEOF} {
+			received = nil
+			client := &flakyClient{Client: api.NewClient(testServer.URL), err: &url.Error{Op: "Post", URL: testServer.URL, Err: transitErr}}
+
+			testClient := api.NewStitchClient(client)
+			u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge"), gc.ShouldBeNil)
+
+			u.So(t, client.keys, gc.ShouldHaveLength, 2)
+			u.So(t, client.keys[1], gc.ShouldEqual, client.keys[0])
+			u.So(t, received, gc.ShouldResemble, client.keys[1:])
+		}
+	})
+
+	t.Run("it gives up on an import that keeps failing at a gateway", func(t *testing.T) {
+		api.ImportRetryBackoff = time.Millisecond
+
+		var attempts int
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error": "bad gateway"}`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "bad gateway")
+		u.So(t, attempts, gc.ShouldEqual, 3)
+	})
+
+	t.Run("it does not retry an import the server rejected", func(t *testing.T) {
+		var attempts int
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "bad app"}`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "bad app")
+		u.So(t, attempts, gc.ShouldEqual, 1)
+	})
+
+	t.Run("it sends a different idempotency key with each import", func(t *testing.T) {
+		var keys []string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Header.Get(api.ImportIdempotencyKeyHeader))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge"), gc.ShouldBeNil)
+		u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge"), gc.ShouldBeNil)
+
+		u.So(t, keys, gc.ShouldHaveLength, 2)
+		u.So(t, keys[0], gc.ShouldNotEqual, keys[1])
+	})
+}
+
+func TestImportProgress(t *testing.T) {
+	defer func(interval time.Duration) { api.ImportPollInterval = interval }(api.ImportPollInterval)
+	api.ImportPollInterval = time.Millisecond
+
+	// newTestServer returns a server that accepts imports to deploy in the background, then reports the
+	// deployment with each of statuses in turn
+	newTestServer := func(statuses ...string) *httptest.Server {
+		polls := 0
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusCreated})
+				return
+			}
+
+			status := statuses[polls]
+			polls++
+			json.NewEncoder(w).Encode(models.Deployment{ID: "deployment-id", Status: status, StatusErrorMessage: "out of gas"})
+		}))
+	}
+
+	t.Run("it polls the deployment until it succeeds, reporting each change of status", func(t *testing.T) {
+		testServer := newTestServer(models.DeploymentStatusPending, models.DeploymentStatusPending, models.DeploymentStatusSuccessful)
+		defer testServer.Close()
+
+		var statuses []string
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.ImportWithProgress(groupID, appID, []byte(`{"name": "app"}`), "merge", time.Minute, func(deployment *models.Deployment) {
+			statuses = append(statuses, deployment.Status)
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, statuses, gc.ShouldResemble, []string{
+			models.DeploymentStatusCreated,
+			models.DeploymentStatusPending,
+			models.DeploymentStatusSuccessful,
+		})
+	})
+
+	t.Run("it fails when the deployment fails", func(t *testing.T) {
+		testServer := newTestServer(models.DeploymentStatusFailed)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, "deployment deployment-id failed: out of gas")
+	})
+
+	t.Run("it keeps polling the deployment when checking on it fails a few times", func(t *testing.T) {
+		polls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusCreated})
+				return
+			}
+
+			polls++
+			if polls%3 != 0 {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte(`{"error": "bad gateway"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusSuccessful})
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge"), gc.ShouldBeNil)
+		u.So(t, polls, gc.ShouldEqual, 3)
+	})
+
+	t.Run("it fails when checking on the deployment keeps failing", func(t *testing.T) {
+		polls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusCreated})
+				return
+			}
+
+			polls++
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error": "bad gateway"}`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.Import(groupID, appID, []byte(`{"name": "app"}`), "merge")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "failed to check on deployment deployment-id")
+		u.So(t, polls, gc.ShouldEqual, 3)
+	})
+
+	t.Run("it gives up on a deployment that does not finish in time", func(t *testing.T) {
+		testServer := newTestServer(models.DeploymentStatusPending, models.DeploymentStatusPending, models.DeploymentStatusPending)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.ImportWithProgress(groupID, appID, []byte(`{"name": "app"}`), "merge", 2*time.Millisecond, nil)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, "timed out after 2ms waiting for deployment deployment-id, which is still pending")
+	})
+}
+
+// flakyClient fails the first request it is asked to make with err, without making it, and records the
+// idempotency key of every request
+type flakyClient struct {
+	api.Client
+	err  error
+	keys []string
+}
+
+func (c *flakyClient) ExecuteRequest(method, path string, options api.RequestOptions) (*http.Response, error) {
+	c.keys = append(c.keys, options.Header.Get(api.ImportIdempotencyKeyHeader))
+	if err := c.err; err != nil {
+		c.err = nil
+		return nil, err
+	}
+	return c.Client.ExecuteRequest(method, path, options)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestCreateValue(t *testing.T) {
+	newTestServer := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var value models.Value
+			json.NewDecoder(r.Body).Decode(&value)
+			value.ID = "value-id"
+
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(value)
+		}))
+	}
+
+	t.Run("it returns the value created along with its ID", func(t *testing.T) {
+		testServer := newTestServer(http.StatusCreated)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		value, err := testClient.CreateValue(groupID, appID, models.Value{Name: "lock", Value: json.RawMessage(`{"holder":"me"}`), Private: true})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, value.ID, gc.ShouldEqual, "value-id")
+		u.So(t, value.Name, gc.ShouldEqual, "lock")
+		u.So(t, string(value.Value), gc.ShouldEqual, `{"holder":"me"}`)
+		u.So(t, value.Private, gc.ShouldBeTrue)
+	})
+
+	t.Run("it fails with ErrValueExists when the name is taken", func(t *testing.T) {
+		testServer := newTestServer(http.StatusConflict)
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		_, err := testClient.CreateValue(groupID, appID, models.Value{Name: "lock"})
+		u.So(t, err, gc.ShouldEqual, api.ErrValueExists)
+	})
+}
+
+func TestCreateSecret(t *testing.T) {
+	t.Run("it sends the name and value of the secret", func(t *testing.T) {
+		var received models.Secret
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u.So(t, r.URL.Path, gc.ShouldEqual, fmt.Sprintf("/api/admin/v3.0/groups/%s/apps/%s/secrets", groupID, appID))
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		u.So(t, testClient.CreateSecret(groupID, appID, "signing-key", "shh"), gc.ShouldBeNil)
+		u.So(t, received, gc.ShouldResemble, models.Secret{Name: "signing-key", Value: "shh"})
+	})
+
+	t.Run("it fails when the secret is not created", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid secret name"}`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		err := testClient.CreateSecret(groupID, appID, "bad name", "shh")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "invalid secret name")
+	})
+}
+
+func TestFetchMeasurements(t *testing.T) {
+	t.Run("it fetches the measurements of the app over the time range", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u.So(t, r.URL.Path, gc.ShouldEqual, fmt.Sprintf("/api/admin/v3.0/groups/%s/apps/%s/measurements", groupID, appID))
+			u.So(t, r.URL.Query().Get("start"), gc.ShouldEqual, "2018-03-01T00:00:00Z")
+			u.So(t, r.URL.Query().Get("end"), gc.ShouldEqual, "2018-03-31T00:00:00Z")
+			u.So(t, r.URL.Query().Get("granularity"), gc.ShouldEqual, "P1D")
+			w.Write([]byte(`{"granularity": "P1D", "measurements": [{"name": "request_count", "units": "", "data_points": [{"timestamp": "2018-03-01T00:00:00Z", "value": 12}]}]}`))
+		}))
+		defer testServer.Close()
+
+		start := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2018, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		measurements, err := testClient.FetchMeasurements(groupID, appID, start, end, "P1D")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, measurements.Measurements, gc.ShouldResemble, []models.Measurement{
+			{Name: "request_count", DataPoints: []models.MeasurementDataPoint{{Timestamp: start, Value: 12}}},
+		})
+	})
+}
+
+func TestFetchLogs(t *testing.T) {
+	t.Run("it fetches a page of the log entries matching the query", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u.So(t, r.URL.Path, gc.ShouldEqual, fmt.Sprintf("/api/admin/v3.0/groups/%s/apps/%s/logs", groupID, appID))
+			u.So(t, r.URL.Query().Get("start_date"), gc.ShouldEqual, "2018-03-01T00:00:00Z")
+			u.So(t, r.URL.Query().Get("end_date"), gc.ShouldBeEmpty)
+			u.So(t, r.URL.Query().Get("errors_only"), gc.ShouldEqual, "true")
+			u.So(t, r.URL.Query().Get("skip"), gc.ShouldEqual, "2")
+			w.Write([]byte(`{
+				"logs": [{"_id": "log1", "type": "FUNCTION", "function_name": "sum", "error": "TypeError", "started": "2018-03-02T00:00:00Z"}],
+				"nextEndDate": "2018-03-02T00:00:00Z",
+				"nextSkip": 3
+			}`))
+		}))
+		defer testServer.Close()
+
+		start := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		page, err := testClient.FetchLogs(groupID, appID, models.LogQuery{Start: start, ErrorsOnly: true, Skip: 2})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, page.Logs, gc.ShouldResemble, []models.LogEntry{
+			{ID: "log1", Type: "FUNCTION", FunctionName: "sum", Error: "TypeError", Started: start.AddDate(0, 0, 1)},
+		})
+		u.So(t, *page.NextEndDate, gc.ShouldResemble, start.AddDate(0, 0, 1))
+		u.So(t, page.NextSkip, gc.ShouldEqual, 3)
+	})
+}
+
+func TestStreamLogs(t *testing.T) {
+	start := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("it reads the log entries the server streams as events", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u.So(t, r.Header.Get("Accept"), gc.ShouldEqual, "text/event-stream")
+			u.So(t, r.URL.Query().Get("start_date"), gc.ShouldEqual, "2018-03-01T00:00:00Z")
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte(": connected\n\n" +
+				"event: log\ndata: {\"_id\": \"log1\", \"type\": \"FUNCTION\",\ndata: \"started\": \"2018-03-01T00:00:00Z\"}\n\n" +
+				"event: heartbeat\ndata: {}\n\n" +
+				"data: {\"_id\": \"log2\", \"type\": \"WEBHOOK\", \"started\": \"2018-03-02T00:00:00Z\"}\n\n"))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		stream, err := testClient.StreamLogs(groupID, appID, models.LogQuery{Start: start})
+		u.So(t, err, gc.ShouldBeNil)
+		defer stream.Close()
+
+		entry, err := stream.Next()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, *entry, gc.ShouldResemble, models.LogEntry{ID: "log1", Type: "FUNCTION", Started: start})
+
+		entry, err = stream.Next()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, *entry, gc.ShouldResemble, models.LogEntry{ID: "log2", Type: "WEBHOOK", Started: start.AddDate(0, 0, 1)})
+
+		_, err = stream.Next()
+		u.So(t, err, gc.ShouldEqual, io.EOF)
+	})
+
+	t.Run("it reports that the server does not stream logs when it answers with a page of them", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"logs": []}`))
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		_, err := testClient.StreamLogs(groupID, appID, models.LogQuery{Start: start})
+		u.So(t, err, gc.ShouldEqual, api.ErrLogStreamingUnsupported)
+	})
+
+	t.Run("it reports that the server does not stream logs when it refuses to", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotAcceptable)
+		}))
+		defer testServer.Close()
+
+		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		_, err := testClient.StreamLogs(groupID, appID, models.LogQuery{Start: start})
+		u.So(t, err, gc.ShouldEqual, api.ErrLogStreamingUnsupported)
+	})
+}
@@ -34,7 +34,12 @@ func (jwt *JWT) Expired() bool {
 }
 
 func parse(s string) (*JWT, error) {
-	b, err := base64.RawStdEncoding.DecodeString(strings.Split(s, ".")[1])
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, ErrInvalidToken
+	}
+
+	b, err := base64.RawStdEncoding.DecodeString(parts[1])
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	appRenameFlagNewName = "new-name"
+	appRenameFlagPath    = "path"
+)
+
+var (
+	errRenameAppIDRequired = fmt.Errorf("an App ID (--%s=[string]) must be supplied to rename an app", flagAppIDName)
+	errNewNameRequired     = fmt.Errorf("a new name (--%s=[string]) must be supplied to rename an app", appRenameFlagNewName)
+)
+
+// NewAppRenameCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewAppRenameCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &AppRenameCommand{
+			BaseCommand: &BaseCommand{
+				Name: "app rename",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+			writeAppConfigToFile: func(dest string, app models.AppInstanceData) error {
+				return app.MarshalFile(dest)
+			},
+		}, nil
+	}
+}
+
+// AppRenameCommand is used to change the name of a Stitch App
+type AppRenameCommand struct {
+	*BaseCommand
+
+	workingDirectory     string
+	writeAppConfigToFile func(dest string, app models.AppInstanceData) error
+
+	flagProjectID string
+	flagAppID     string
+	flagNewName   string
+	flagAppPath   string
+}
+
+// Help returns long-form help information for this command
+func (arc *AppRenameCommand) Help() string {
+	return `Rename a stitch application, updating the name in its local config.
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+  --new-name [string]
+	The new name of the app. The App ID is not changed.
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.
+
+  --path [string]
+	A path to the local directory containing your app, whose stitch.json will be updated with the new name. Defaults to the directory containing the working directory, if any.` +
+		arc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (arc *AppRenameCommand) Synopsis() string {
+	return `Rename a stitch application.`
+}
+
+// Run executes the command
+func (arc *AppRenameCommand) Run(args []string) int {
+	flags := arc.NewFlagSet()
+
+	flags.StringVar(&arc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&arc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&arc.flagNewName, appRenameFlagNewName, "", "")
+	flags.StringVar(&arc.flagAppPath, appRenameFlagPath, "", "")
+
+	if err := arc.BaseCommand.run(args); err != nil {
+		arc.Log().Error(err.Error())
+		return 1
+	}
+
+	err := arc.renameApp()
+	arc.audit(arc.flagAppID, "", err)
+	if err != nil {
+		arc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (arc *AppRenameCommand) renameApp() error {
+	if arc.flagAppID == "" {
+		return errRenameAppIDRequired
+	}
+
+	if arc.flagNewName == "" {
+		return errNewNameRequired
+	}
+
+	user, err := arc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := arc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	app, err := fetchApp(stitchClient, arc.flagProjectID, arc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	if err := arc.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	if err := stitchClient.RenameApp(app.GroupID, app.ID, arc.flagNewName); err != nil {
+		return err
+	}
+
+	if err := arc.updateLocalConfig(); err != nil {
+		return fmt.Errorf("renamed app but failed to update local config: %s", err)
+	}
+
+	arc.Success(fmt.Sprintf("Successfully renamed '%s' to %q", app.ClientAppID, arc.flagNewName))
+	return nil
+}
+
+// updateLocalConfig rewrites the name in the local config of the renamed app, if there is one
+func (arc *AppRenameCommand) updateLocalConfig() error {
+	var appPath string
+	if arc.flagAppPath != "" {
+		path, err := homedir.Expand(arc.flagAppPath)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			return errors.New("directory does not exist")
+		}
+		appPath = path
+	} else {
+		path, err := utils.GetDirectoryContainingFile(arc.workingDirectory, models.AppConfigFileName)
+		if err != nil {
+			// not within an app directory, so there is nothing to update
+			return nil
+		}
+		appPath = path
+	}
+
+	appInstanceData := models.AppInstanceData{}
+	if err := appInstanceData.UnmarshalFile(appPath); err != nil {
+		return err
+	}
+
+	if appInstanceData.AppID() != arc.flagAppID {
+		arc.Log().Warn(fmt.Sprintf("not updating %s in %s: it belongs to app '%s'", models.AppConfigFileName, appPath, appInstanceData.AppID()))
+		return nil
+	}
+
+	appInstanceData[models.AppNameField] = arc.flagNewName
+	return arc.writeAppConfigToFile(appPath, appInstanceData)
+}
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestAppRenameCommand(t *testing.T) {
+	setup := func() (*AppRenameCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewAppRenameCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		appRenameCommand := cmd.(*AppRenameCommand)
+		appRenameCommand.storage = u.NewEmptyStorage()
+		appRenameCommand.workingDirectory = ""
+		appRenameCommand.writeAppConfigToFile = func(dest string, app models.AppInstanceData) error {
+			return errors.New("local config should not be written")
+		}
+		return appRenameCommand, mockUI
+	}
+
+	t.Run("should require an app-id", func(t *testing.T) {
+		appRenameCommand, mockUI := setup()
+		exitCode := appRenameCommand.Run([]string{"--new-name=new-name"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errRenameAppIDRequired.Error())
+	})
+
+	t.Run("should require a new name", func(t *testing.T) {
+		appRenameCommand, mockUI := setup()
+		exitCode := appRenameCommand.Run([]string{"--app-id=my-app-abcdef"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errNewNameRequired.Error())
+	})
+
+	t.Run("should require the user to be logged in", func(t *testing.T) {
+		appRenameCommand, mockUI := setup()
+		exitCode := appRenameCommand.Run([]string{"--app-id=my-app-abcdef", "--new-name=new-name"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, user.ErrNotLoggedIn.Error())
+	})
+
+	t.Run("when the user is logged in", func(t *testing.T) {
+		setup := func() (*AppRenameCommand, *cli.MockUi, *[]string) {
+			appRenameCommand, mockUI := setup()
+			appRenameCommand.user = &user.User{
+				APIKey:      "my-api-key",
+				AccessToken: u.GenerateValidAccessToken(),
+			}
+
+			var renamed []string
+			appRenameCommand.stitchClient = &u.MockStitchClient{
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+				},
+				FetchAppByGroupIDAndClientAppIDFn: func(groupID, clientAppID string) (*models.App, error) {
+					return &models.App{GroupID: groupID, ID: "app-id", ClientAppID: clientAppID}, nil
+				},
+				RenameAppFn: func(groupID, appID, name string) error {
+					renamed = append(renamed, groupID, appID, name)
+					return nil
+				},
+			}
+			return appRenameCommand, mockUI, &renamed
+		}
+
+		t.Run("it renames the app outside of an app directory", func(t *testing.T) {
+			appRenameCommand, mockUI, renamed := setup()
+
+			exitCode := appRenameCommand.Run([]string{"--app-id=my-app-abcdef", "--new-name=new-name"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, `Successfully renamed 'my-app-abcdef' to "new-name"`)
+			u.So(t, *renamed, gc.ShouldResemble, []string{"group-id", "app-id", "new-name"})
+		})
+
+		t.Run("it looks up the app in the given project", func(t *testing.T) {
+			appRenameCommand, _, renamed := setup()
+
+			exitCode := appRenameCommand.Run([]string{"--app-id=my-app-abcdef", "--project-id=project-id", "--new-name=new-name"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, *renamed, gc.ShouldResemble, []string{"project-id", "app-id", "new-name"})
+		})
+
+		t.Run("it updates the name in the local config of the app", func(t *testing.T) {
+			for _, args := range [][]string{
+				{"--path=../testdata/simple_app_with_instance_data"},
+				{},
+			} {
+				appRenameCommand, mockUI, _ := setup()
+				appRenameCommand.workingDirectory = "../testdata/simple_app_with_instance_data"
+
+				var writtenTo string
+				var written models.AppInstanceData
+				appRenameCommand.writeAppConfigToFile = func(dest string, app models.AppInstanceData) error {
+					writtenTo, written = dest, app
+					return nil
+				}
+
+				exitCode := appRenameCommand.Run(append([]string{"--app-id=my-app-abcdef", "--new-name=new-name"}, args...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, abs(writtenTo), gc.ShouldEqual, abs("../testdata/simple_app_with_instance_data"))
+				u.So(t, written.AppName(), gc.ShouldEqual, "new-name")
+				u.So(t, written.AppID(), gc.ShouldEqual, "my-app-abcdef")
+			}
+		})
+
+		t.Run("it does not update the local config of a different app", func(t *testing.T) {
+			appRenameCommand, mockUI, renamed := setup()
+
+			exitCode := appRenameCommand.Run([]string{"--app-id=other-app-abcdef", "--new-name=new-name", "--path=../testdata/simple_app_with_instance_data"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "it belongs to app 'my-app-abcdef'")
+			u.So(t, *renamed, gc.ShouldHaveLength, 3)
+		})
+
+		t.Run("it fails if the app cannot be renamed", func(t *testing.T) {
+			appRenameCommand, mockUI, _ := setup()
+			appRenameCommand.stitchClient.(*u.MockStitchClient).RenameAppFn = func(groupID, appID, name string) error {
+				return errors.New("oopsies")
+			}
+
+			exitCode := appRenameCommand.Run([]string{"--app-id=my-app-abcdef", "--new-name=new-name", "--path=../testdata/simple_app_with_instance_data"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "oopsies")
+		})
+	})
+}
@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	appStatsFlagStart       = "start"
+	appStatsFlagEnd         = "end"
+	appStatsFlagGranularity = "granularity"
+	appStatsFlagOutput      = "output"
+
+	// defaultAppStatsRange is how far back from --end the metrics are reported when no --start is given
+	defaultAppStatsRange = 30 * 24 * time.Hour
+
+	appStatsDateLayout = "2006-01-02"
+)
+
+// The formats app stats can be written in
+const (
+	appStatsOutputText = "text"
+	appStatsOutputJSON = "json"
+	appStatsOutputCSV  = "csv"
+)
+
+var errAppStatsAppIDRequired = fmt.Errorf("an App ID (--%s=[string]) must be supplied to report the usage of an app", flagAppIDName)
+
+// appStatsGranularities maps the values of --granularity to the ISO 8601 durations the admin API expects
+var appStatsGranularities = map[string]string{
+	"hourly":  "PT1H",
+	"daily":   "P1D",
+	"monthly": "P31D",
+}
+
+// measurementLabels are the names the metrics reported by the admin API are shown under in text output
+var measurementLabels = map[string]string{
+	"request_count": "Requests",
+	"compute_time":  "Compute runtime",
+	"data_out":      "Data transfer",
+	"sync_time":     "Sync runtime",
+}
+
+// NewAppStatsCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewAppStatsCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &AppStatsCommand{
+			BaseCommand: &BaseCommand{
+				Name: "app stats",
+				UI:   ui,
+			},
+			now: time.Now,
+		}, nil
+	}
+}
+
+// AppStatsCommand is used to report the usage metrics of a Stitch App over a time range
+type AppStatsCommand struct {
+	*BaseCommand
+
+	now func() time.Time
+
+	flagProjectID   string
+	flagAppID       string
+	flagStart       string
+	flagEnd         string
+	flagGranularity string
+	flagOutput      string
+}
+
+// appStats are the usage metrics of an app as written by --output json
+type appStats struct {
+	AppID       string           `json:"app_id"`
+	Start       time.Time        `json:"start"`
+	End         time.Time        `json:"end"`
+	Granularity string           `json:"granularity"`
+	Metrics     []appStatsMetric `json:"metrics"`
+}
+
+// appStatsMetric is a single usage metric of an app, along with its total over the reported time range
+type appStatsMetric struct {
+	Name       string                        `json:"name"`
+	Units      string                        `json:"units"`
+	Total      float64                       `json:"total"`
+	DataPoints []models.MeasurementDataPoint `json:"data_points"`
+}
+
+// Synopsis returns a one-liner description for this command
+func (asc *AppStatsCommand) Synopsis() string {
+	return "Report the usage metrics of a stitch application."
+}
+
+// Help returns long-form help information for this command
+func (asc *AppStatsCommand) Help() string {
+	return `Report the request count, compute runtime, data transfer and sync runtime of a stitch application over a time range, e.g. for capacity planning.
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.
+
+  --start [date]
+	The start of the time range, as a date ("2006-01-02") or a time ("2006-01-02T15:04:05Z"). Defaults to 30 days before --end.
+
+  --end [date]
+	The end of the time range, as a date or a time. Defaults to now.
+
+  --granularity [hourly|daily|monthly] (default: daily)
+	The period each data point covers.
+
+  --output [text|json|csv] (default: text)
+	How to write the metrics: their totals as text, or every data point as JSON, or as CSV with a "metric,units,timestamp,value" header row for
+	loading into dashboards and spreadsheets.` +
+		asc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (asc *AppStatsCommand) Run(args []string) int {
+	flags := asc.NewFlagSet()
+
+	flags.StringVar(&asc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&asc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&asc.flagStart, appStatsFlagStart, "", "")
+	flags.StringVar(&asc.flagEnd, appStatsFlagEnd, "", "")
+	flags.StringVar(&asc.flagGranularity, appStatsFlagGranularity, "daily", "")
+	flags.StringVar(&asc.flagOutput, appStatsFlagOutput, appStatsOutputText, "")
+
+	if err := asc.BaseCommand.run(args); err != nil {
+		asc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := asc.report(); err != nil {
+		asc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (asc *AppStatsCommand) report() error {
+	if asc.flagAppID == "" {
+		return errAppStatsAppIDRequired
+	}
+
+	granularity, ok := appStatsGranularities[asc.flagGranularity]
+	if !ok {
+		return fmt.Errorf("--%s must be one of hourly, daily or monthly, got %q", appStatsFlagGranularity, asc.flagGranularity)
+	}
+
+	switch asc.flagOutput {
+	case appStatsOutputText, appStatsOutputJSON, appStatsOutputCSV:
+	default:
+		return fmt.Errorf("--%s must be one of %s, %s or %s, got %q", appStatsFlagOutput, appStatsOutputText, appStatsOutputJSON, appStatsOutputCSV, asc.flagOutput)
+	}
+
+	end := asc.now()
+	if asc.flagEnd != "" {
+		var err error
+		if end, err = parseStatsTime(asc.flagEnd); err != nil {
+			return fmt.Errorf("invalid --%s: %s", appStatsFlagEnd, err)
+		}
+	}
+
+	start := end.Add(-defaultAppStatsRange)
+	if asc.flagStart != "" {
+		var err error
+		if start, err = parseStatsTime(asc.flagStart); err != nil {
+			return fmt.Errorf("invalid --%s: %s", appStatsFlagStart, err)
+		}
+	}
+
+	if !start.Before(end) {
+		return errors.New("the start of the time range must be before its end")
+	}
+
+	user, err := asc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := asc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	app, err := fetchApp(stitchClient, asc.flagProjectID, asc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	measurements, err := stitchClient.FetchMeasurements(app.GroupID, app.ID, start, end, granularity)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the usage metrics of '%s': %s", app.ClientAppID, err)
+	}
+
+	stats := appStats{
+		AppID:       app.ClientAppID,
+		Start:       start.UTC(),
+		End:         end.UTC(),
+		Granularity: asc.flagGranularity,
+		Metrics:     make([]appStatsMetric, 0, len(measurements.Measurements)),
+	}
+	for _, measurement := range measurements.Measurements {
+		stats.Metrics = append(stats.Metrics, appStatsMetric{
+			Name:       measurement.Name,
+			Units:      measurement.Units,
+			Total:      measurement.Total(),
+			DataPoints: measurement.DataPoints,
+		})
+	}
+
+	output, err := formatAppStats(stats, asc.flagOutput)
+	if err != nil {
+		return err
+	}
+
+	asc.UI.Output(output)
+	return nil
+}
+
+// formatAppStats writes the stats in the given format
+func formatAppStats(stats appStats, format string) (string, error) {
+	switch format {
+	case appStatsOutputJSON:
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+
+	case appStatsOutputCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"metric", "units", "timestamp", "value"})
+		for _, metric := range stats.Metrics {
+			for _, point := range metric.DataPoints {
+				w.Write([]string{
+					metric.Name,
+					metric.Units,
+					point.Timestamp.UTC().Format(time.RFC3339),
+					strconv.FormatFloat(point.Value, 'f', -1, 64),
+				})
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	}
+
+	lines := []string{fmt.Sprintf(
+		"Usage of '%s' from %s to %s:",
+		stats.AppID,
+		stats.Start.Format(time.RFC3339),
+		stats.End.Format(time.RFC3339),
+	)}
+
+	if len(stats.Metrics) == 0 {
+		lines = append(lines, "  no metrics were reported for this time range")
+	}
+
+	for _, metric := range stats.Metrics {
+		label, ok := measurementLabels[metric.Name]
+		if !ok {
+			label = metric.Name
+		}
+
+		total := strconv.FormatFloat(metric.Total, 'f', -1, 64)
+		if metric.Units != "" {
+			total += " " + strings.ToLower(metric.Units)
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", label, total))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// parseStatsTime parses a date or an RFC 3339 time given for the time range of the stats
+func parseStatsTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(appStatsDateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date (%s) nor a time (%s)", s, appStatsDateLayout, time.RFC3339)
+	}
+
+	return t, nil
+}
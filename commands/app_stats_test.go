@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestAppStatsCommand(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2018, time.March, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	type fetched struct {
+		start, end  time.Time
+		granularity string
+	}
+
+	setup := func() (*AppStatsCommand, *cli.MockUi, *fetched) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewAppStatsCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		var f fetched
+		statsCommand := cmd.(*AppStatsCommand)
+		statsCommand.now = func() time.Time { return day(31) }
+		statsCommand.storage = u.NewEmptyStorage()
+		statsCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		statsCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+			FetchMeasurementsFn: func(groupID, appID string, start, end time.Time, granularity string) (*models.Measurements, error) {
+				f = fetched{start, end, granularity}
+				return &models.Measurements{
+					Start:       start,
+					End:         end,
+					Granularity: granularity,
+					Measurements: []models.Measurement{
+						{
+							Name:  "request_count",
+							Units: "",
+							DataPoints: []models.MeasurementDataPoint{
+								{Timestamp: day(1), Value: 100},
+								{Timestamp: day(2), Value: 23},
+							},
+						},
+						{
+							Name:       "compute_time",
+							Units:      "HOURS",
+							DataPoints: []models.MeasurementDataPoint{{Timestamp: day(1), Value: 1.5}},
+						},
+					},
+				}, nil
+			},
+		}
+		return statsCommand, mockUI, &f
+	}
+
+	t.Run("it requires an app id", func(t *testing.T) {
+		statsCommand, mockUI, _ := setup()
+		exitCode := statsCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errAppStatsAppIDRequired.Error())
+	})
+
+	t.Run("it rejects invalid flags", func(t *testing.T) {
+		for _, tc := range []struct {
+			args          []string
+			expectedError string
+		}{
+			{[]string{"--granularity=weekly"}, "--granularity must be one of hourly, daily or monthly"},
+			{[]string{"--output=xml"}, "--output must be one of text, json or csv"},
+			{[]string{"--start=yesterday"}, "invalid --start"},
+			{[]string{"--start=2018-03-31", "--end=2018-03-01"}, "the start of the time range must be before its end"},
+		} {
+			statsCommand, mockUI, _ := setup()
+			exitCode := statsCommand.Run(append([]string{"--app-id=my-app-abcde"}, tc.args...))
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, tc.expectedError)
+		}
+	})
+
+	t.Run("it reports the totals of the last 30 days by default", func(t *testing.T) {
+		statsCommand, mockUI, f := setup()
+		exitCode := statsCommand.Run([]string{"--app-id=my-app-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, *f, gc.ShouldResemble, fetched{day(1), day(31), "P1D"})
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, `Usage of 'my-app-abcde' from 2018-03-01T00:00:00Z to 2018-03-31T00:00:00Z:
+  Requests: 123
+  Compute runtime: 1.5 hours
+`)
+	})
+
+	t.Run("it writes every data point as JSON", func(t *testing.T) {
+		statsCommand, mockUI, f := setup()
+		exitCode := statsCommand.Run([]string{"--app-id=my-app-abcde", "--start=2018-03-01", "--end=2018-03-02T12:00:00Z", "--granularity=hourly", "--output=json"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, *f, gc.ShouldResemble, fetched{day(1), day(2).Add(12 * time.Hour), "PT1H"})
+
+		var stats appStats
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &stats), gc.ShouldBeNil)
+		u.So(t, stats.AppID, gc.ShouldEqual, "my-app-abcde")
+		u.So(t, stats.Granularity, gc.ShouldEqual, "hourly")
+		u.So(t, stats.Metrics, gc.ShouldHaveLength, 2)
+		u.So(t, stats.Metrics[0].Total, gc.ShouldEqual, 123)
+		u.So(t, stats.Metrics[0].DataPoints, gc.ShouldHaveLength, 2)
+	})
+
+	t.Run("it writes every data point as CSV", func(t *testing.T) {
+		statsCommand, mockUI, _ := setup()
+		exitCode := statsCommand.Run([]string{"--app-id=my-app-abcde", "--output=csv"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, `metric,units,timestamp,value
+request_count,,2018-03-01T00:00:00Z,100
+request_count,,2018-03-02T00:00:00Z,23
+compute_time,HOURS,2018-03-01T00:00:00Z,1.5
+`)
+	})
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"sort"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+const (
+	// maxAppIDSuggestionDistance is the largest edit distance from an App ID that was not found at which an
+	// existing app is suggested in its place
+	maxAppIDSuggestionDistance = 3
+
+	// maxAppIDSuggestions is the most apps suggested for an App ID that was not found
+	maxAppIDSuggestions = 3
+)
+
+// withAppIDSuggestions adds the IDs of the user's apps that are close to the one that was not found to err,
+// if it is an api.ErrAppNotFound, so that a typo is not taken for an app that does not exist yet. The apps
+// are looked up in the project with projectID, or in every project of the user if it is empty. Failing to
+// look them up only means there are no suggestions
+func withAppIDSuggestions(stitchClient api.StitchClient, projectID string, err error) error {
+	notFound, ok := err.(api.ErrAppNotFound)
+	if !ok || notFound.ClientAppID == "" {
+		return err
+	}
+
+	groupIDs := []string{projectID}
+	if projectID == "" {
+		profile, profileErr := stitchClient.FetchUserProfile()
+		if profileErr != nil {
+			return err
+		}
+		groupIDs = profile.AllGroupIDs()
+	}
+
+	type suggestion struct {
+		clientAppID string
+		distance    int
+	}
+
+	var suggestions []suggestion
+	for _, groupID := range groupIDs {
+		apps, appsErr := stitchClient.FetchAppsByGroupID(groupID)
+		if appsErr != nil {
+			continue
+		}
+
+		for _, app := range apps {
+			if distance := utils.EditDistance(notFound.ClientAppID, app.ClientAppID); distance <= maxAppIDSuggestionDistance {
+				suggestions = append(suggestions, suggestion{app.ClientAppID, distance})
+			}
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].clientAppID < suggestions[j].clientAppID
+	})
+
+	for i, s := range suggestions {
+		if i == maxAppIDSuggestions {
+			break
+		}
+		notFound.Suggestions = append(notFound.Suggestions, s.clientAppID)
+	}
+
+	return notFound
+}
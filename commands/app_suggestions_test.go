@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWithAppIDSuggestions(t *testing.T) {
+	var profile models.UserProfile
+	u.So(t, json.Unmarshal([]byte(`{"roles": [{"role_name": "GROUP_OWNER", "group_id": "group-1"}, {"role_name": "GROUP_OWNER", "group_id": "group-2"}]}`), &profile), gc.ShouldBeNil)
+
+	stitchClient := &u.MockStitchClient{
+		FetchUserProfileFn: func() (*models.UserProfile, error) {
+			return &profile, nil
+		},
+		FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+			if groupID == "group-1" {
+				return []*models.App{{ClientAppID: "my-app-nysja"}, {ClientAppID: "other-app-abcde"}}, nil
+			}
+			return []*models.App{{ClientAppID: "my-app-nysia"}, {ClientAppID: "my-app-ysnja"}}, nil
+		},
+	}
+
+	t.Run("it suggests the closest apps in every project", func(t *testing.T) {
+		err := withAppIDSuggestions(stitchClient, "", api.ErrAppNotFound{ClientAppID: "my-app-nysjb"})
+		u.So(t, err, gc.ShouldResemble, api.ErrAppNotFound{ClientAppID: "my-app-nysjb", Suggestions: []string{"my-app-nysja", "my-app-nysia", "my-app-ysnja"}})
+		u.So(t, err.Error(), gc.ShouldEqual, `Unable to find app with ID: "my-app-nysjb" (did you mean "my-app-nysja" or "my-app-nysia" or "my-app-ysnja"?)`)
+	})
+
+	t.Run("it only suggests apps in the given project", func(t *testing.T) {
+		err := withAppIDSuggestions(stitchClient, "group-1", api.ErrAppNotFound{ClientAppID: "my-app-nysjb"})
+		u.So(t, err, gc.ShouldResemble, api.ErrAppNotFound{ClientAppID: "my-app-nysjb", Suggestions: []string{"my-app-nysja"}})
+	})
+
+	t.Run("it suggests nothing when no app is close", func(t *testing.T) {
+		err := withAppIDSuggestions(stitchClient, "", api.ErrAppNotFound{ClientAppID: "brand-new-app"})
+		u.So(t, err, gc.ShouldResemble, api.ErrAppNotFound{ClientAppID: "brand-new-app"})
+	})
+
+	t.Run("it leaves other errors alone", func(t *testing.T) {
+		err := errors.New("oh noes")
+		u.So(t, withAppIDSuggestions(stitchClient, "", err), gc.ShouldEqual, err)
+	})
+}
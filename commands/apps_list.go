@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+
+	"github.com/mitchellh/cli"
+)
+
+// appsListColumns are the columns of the apps listed by AppsListCommand
+var appsListColumns = []string{"id", "name", "project_id", "project"}
+
+// NewAppsListCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewAppsListCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &AppsListCommand{
+			BaseCommand: &BaseCommand{
+				Name: "apps list",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// AppsListCommand is used to list the Stitch Apps available to the current user
+type AppsListCommand struct {
+	*BaseCommand
+
+	output listOutput
+
+	flagProjectID string
+	flagOrg       string
+}
+
+// listedApp is an app along with the project it belongs to
+type listedApp struct {
+	*models.App
+	Project project
+}
+
+// Synopsis returns a one-liner description for this command
+func (alc *AppsListCommand) Synopsis() string {
+	return "List the stitch applications available to you."
+}
+
+// Help returns long-form help information for this command
+func (alc *AppsListCommand) Help() string {
+	return `List the stitch applications in the Atlas Projects available to the current user.
+
+OPTIONS:
+  --project-id [string]
+	Only list the apps in the Atlas Project with this ID.
+
+  --org [string]
+	Only list the apps in the Atlas Projects of the Organization with this name or ID.
+` + listOutputHelp(appsListColumns) + alc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (alc *AppsListCommand) Run(args []string) int {
+	flags := alc.NewFlagSet()
+
+	flags.StringVar(&alc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&alc.flagOrg, flagOrgName, "", "")
+	alc.output.registerFlags(flags)
+
+	if err := alc.BaseCommand.run(args); err != nil {
+		alc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := alc.list(); err != nil {
+		alc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (alc *AppsListCommand) list() error {
+	user, err := alc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := alc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	projects := []project{{Group: mdbcloud.Group{ID: alc.flagProjectID}}}
+	if alc.flagProjectID == "" {
+		if projects, err = alc.listProjects(alc.flagOrg); err != nil {
+			return err
+		}
+	}
+
+	var apps []listedApp
+	for _, p := range projects {
+		projectApps, err := stitchClient.FetchAppsByGroupID(p.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list the apps in Project %s: %s", p.ID, err)
+		}
+
+		for _, app := range projectApps {
+			apps = append(apps, listedApp{app, p})
+		}
+	}
+
+	rows := make([][]string, len(apps))
+	for i, app := range apps {
+		rows[i] = []string{app.ClientAppID, app.Name, app.GroupID, app.Project.Name}
+	}
+
+	return alc.output.write(alc.UI, appsListColumns, rows)
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAppsListCommand(t *testing.T) {
+	setup := func() (*AppsListCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewAppsListCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		appsListCommand := cmd.(*AppsListCommand)
+		appsListCommand.storage = u.NewEmptyStorage()
+		appsListCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		appsListCommand.atlasClient = &u.MockMDBClient{
+			OrgsFn: func() ([]mdbcloud.Org, error) {
+				return []mdbcloud.Org{{ID: "org-1", Name: "Engineering"}}, nil
+			},
+			GroupsFn: func() ([]mdbcloud.Group, error) {
+				return []mdbcloud.Group{{ID: "group-1", Name: "api", OrgID: "org-1"}, {ID: "group-2", Name: "web", OrgID: "org-1"}}, nil
+			},
+		}
+		appsListCommand.stitchClient = &u.MockStitchClient{
+			FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+				return []*models.App{{ID: "app-id", GroupID: groupID, ClientAppID: groupID + "-app-abcde", Name: groupID + "-app"}}, nil
+			},
+		}
+		return appsListCommand, mockUI
+	}
+
+	t.Run("it lists the apps of every available project", func(t *testing.T) {
+		appsListCommand, mockUI := setup()
+
+		exitCode := appsListCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, `ID                 NAME         PROJECT_ID  PROJECT
+group-1-app-abcde  group-1-app  group-1     api
+group-2-app-abcde  group-2-app  group-2     web
+`)
+	})
+
+	t.Run("it only lists the apps of the project given by --project-id", func(t *testing.T) {
+		appsListCommand, mockUI := setup()
+
+		exitCode := appsListCommand.Run([]string{"--project-id=group-2", "--output=csv", "--columns=id,project_id"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "id,project_id\ngroup-2-app-abcde,group-2\n")
+	})
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	osuser "os/user"
+	"time"
+
+	"github.com/10gen/stitch-cli/logging"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// auditLogEnvVar names the environment variable setting the audit log when --audit-log is not given, so
+// that it can be set once for every command run on a machine
+const auditLogEnvVar = "STITCH_AUDIT_LOG"
+
+// auditLogPath returns the path of the audit log, or "" if commands are not audited
+func (c *BaseCommand) auditLogPath() string {
+	if c.flagAuditLog != "" {
+		return c.flagAuditLog
+	}
+	return os.Getenv(auditLogEnvVar)
+}
+
+// audit appends a record of the command having changed the app with the given Client App ID to the audit
+// log, if there is one. The result is taken from err, and deploymentID is the deployment the command made,
+// if any. Failing to write the record does not fail the command, whose changes have already been made
+func (c *BaseCommand) audit(clientAppID, deploymentID string, err error) {
+	logPath := c.auditLogPath()
+	if logPath == "" {
+		return
+	}
+
+	record := logging.AuditRecord{
+		Time:         time.Now().UTC(),
+		Command:      c.Name,
+		ClientAppID:  clientAppID,
+		Result:       logging.AuditResultSuccess,
+		DeploymentID: deploymentID,
+	}
+	if err != nil {
+		record.Result = logging.AuditResultFailure
+		record.Error = err.Error()
+	}
+
+	if c.storage != nil {
+		if user, userErr := c.User(); userErr == nil {
+			record.User = user.PublicAPIKey
+		}
+	}
+	if osUser, osUserErr := osuser.Current(); osUserErr == nil {
+		record.OSUser = osUser.Username
+	}
+	if host, hostErr := os.Hostname(); hostErr == nil {
+		record.Host = host
+	}
+
+	expandedPath, expandErr := homedir.Expand(logPath)
+	if expandErr == nil {
+		expandErr = logging.AppendAuditRecord(expandedPath, record)
+	}
+	if expandErr != nil {
+		c.Log().Warn(fmt.Sprintf("failed to write to the audit log %s: %s", logPath, expandErr))
+	}
+}
@@ -0,0 +1,385 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	authRedirectURIsFlagProvider = "provider"
+	authRedirectURIsFlagURI      = "uri"
+)
+
+var (
+	errAuthRedirectURIsAppIDRequired    = fmt.Errorf("an App ID (--%s=[string]) must be supplied to manage redirect URIs", flagAppIDName)
+	errAuthRedirectURIsProviderRequired = fmt.Errorf("an auth provider (--%s=[string]) must be supplied to manage redirect URIs", authRedirectURIsFlagProvider)
+	errAuthRedirectURIsRequired         = fmt.Errorf("at least one redirect URI (--%s=[string]) must be supplied", authRedirectURIsFlagURI)
+)
+
+// authRedirectURIsOptionsHelp describes the flags shared by the redirect URI commands
+const authRedirectURIsOptionsHelp = `
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+  --provider [string]
+	The name of the auth provider, e.g. "oauth2-google", or its type if the app has only one provider of that type.
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.`
+
+// authRedirectURIsURIHelp describes the --uri flag of the commands that change redirect URIs
+const authRedirectURIsURIHelp = `
+
+  --uri [string]
+	A redirect URI, e.g. "https://preview.example.com/auth/callback". May be given more than once.`
+
+// authRedirectURIsFlags are the flags shared by the redirect URI commands
+type authRedirectURIsFlags struct {
+	flagProjectID string
+	flagAppID     string
+	flagProvider  string
+}
+
+func (f *authRedirectURIsFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&f.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&f.flagProvider, authRedirectURIsFlagProvider, "", "")
+}
+
+// resolveAuthProvider returns a client for the logged in user, the app given by the flags and the full config of
+// its auth provider
+func (f *authRedirectURIsFlags) resolveAuthProvider(c *BaseCommand) (api.StitchClient, *models.App, *models.AuthProvider, error) {
+	if f.flagAppID == "" {
+		return nil, nil, nil, errAuthRedirectURIsAppIDRequired
+	}
+
+	if f.flagProvider == "" {
+		return nil, nil, nil, errAuthRedirectURIsProviderRequired
+	}
+
+	stitchClient, app, err := c.resolveHostingApp(f.flagProjectID, f.flagAppID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	providers, err := stitchClient.FetchAuthProviders(app.GroupID, app.ID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch the auth providers of '%s': %s", app.ClientAppID, err)
+	}
+
+	providerID, err := findAuthProvider(providers, f.flagProvider, app.ClientAppID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	provider, err := stitchClient.FetchAuthProvider(app.GroupID, app.ID, providerID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch auth provider '%s': %s", f.flagProvider, err)
+	}
+
+	return stitchClient, app, provider, nil
+}
+
+// findAuthProvider returns the ID of the provider with the given name, or else of the only provider of the given type
+func findAuthProvider(providers []models.AuthProvider, nameOrType, clientAppID string) (string, error) {
+	var ofType, names []string
+	for _, provider := range providers {
+		if provider.Name == nameOrType {
+			return provider.ID, nil
+		}
+		if provider.Type == nameOrType {
+			ofType = append(ofType, provider.ID)
+		}
+		names = append(names, provider.Name)
+	}
+
+	switch len(ofType) {
+	case 0:
+		return "", fmt.Errorf("'%s' has no auth provider named or of type %q; its providers are: %s", clientAppID, nameOrType, strings.Join(names, ", "))
+	case 1:
+		return ofType[0], nil
+	default:
+		return "", fmt.Errorf("'%s' has %d auth providers of type %q, so one must be given by name; its providers are: %s", clientAppID, len(ofType), nameOrType, strings.Join(names, ", "))
+	}
+}
+
+// NewAuthRedirectURIsListCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewAuthRedirectURIsListCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &AuthRedirectURIsListCommand{
+			BaseCommand: &BaseCommand{
+				Name: "auth redirect-uris list",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// AuthRedirectURIsListCommand is used to list the redirect URIs of an auth provider of a Stitch App
+type AuthRedirectURIsListCommand struct {
+	*BaseCommand
+	authRedirectURIsFlags
+}
+
+// Help returns long-form help information for this command
+func (arl *AuthRedirectURIsListCommand) Help() string {
+	return `List the redirect URIs an auth provider of a stitch application accepts, one per line.
+` + authRedirectURIsOptionsHelp + arl.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (arl *AuthRedirectURIsListCommand) Synopsis() string {
+	return `List the redirect URIs of an auth provider.`
+}
+
+// Run executes the command
+func (arl *AuthRedirectURIsListCommand) Run(args []string) int {
+	flags := arl.NewFlagSet()
+	arl.register(flags)
+
+	if err := arl.BaseCommand.run(args); err != nil {
+		arl.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := arl.list(); err != nil {
+		arl.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (arl *AuthRedirectURIsListCommand) list() error {
+	_, app, provider, err := arl.resolveAuthProvider(arl.BaseCommand)
+	if err != nil {
+		return err
+	}
+
+	if len(provider.RedirectURIs) == 0 {
+		arl.Log().Info(fmt.Sprintf("Auth provider '%s' of '%s' has no redirect URIs", provider.Name, app.ClientAppID))
+		return nil
+	}
+
+	arl.UI.Output(strings.Join(provider.RedirectURIs, "\n"))
+	return nil
+}
+
+// NewAuthRedirectURIsAddCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewAuthRedirectURIsAddCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &AuthRedirectURIsAddCommand{
+			BaseCommand: &BaseCommand{
+				Name: "auth redirect-uris add",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// AuthRedirectURIsAddCommand is used to add redirect URIs to an auth provider of a Stitch App
+type AuthRedirectURIsAddCommand struct {
+	*BaseCommand
+	authRedirectURIsFlags
+
+	flagURIs stringsFlag
+}
+
+// Help returns long-form help information for this command
+func (ara *AuthRedirectURIsAddCommand) Help() string {
+	return `Add redirect URIs to an auth provider of a stitch application, e.g. for a new preview environment.
+
+USAGE:
+  auth redirect-uris add --app-id [string] --provider [string] --uri [string]...
+
+  URIs the provider already accepts are left as they are.
+` + authRedirectURIsOptionsHelp + authRedirectURIsURIHelp + ara.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (ara *AuthRedirectURIsAddCommand) Synopsis() string {
+	return `Add redirect URIs to an auth provider.`
+}
+
+// Run executes the command
+func (ara *AuthRedirectURIsAddCommand) Run(args []string) int {
+	flags := ara.NewFlagSet()
+	ara.register(flags)
+	flags.Var(&ara.flagURIs, authRedirectURIsFlagURI, "")
+
+	if err := ara.BaseCommand.run(args); err != nil {
+		ara.Log().Error(err.Error())
+		return 1
+	}
+
+	err := ara.add(ara.flagURIs)
+	ara.audit(ara.flagAppID, "", err)
+	if err != nil {
+		ara.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (ara *AuthRedirectURIsAddCommand) add(uris []string) error {
+	if len(uris) == 0 {
+		return errAuthRedirectURIsRequired
+	}
+
+	for _, uri := range uris {
+		if parsed, err := url.Parse(uri); err != nil || parsed.Scheme == "" {
+			return fmt.Errorf("invalid redirect URI %q: it must be an absolute URI, e.g. \"https://preview.example.com/callback\"", uri)
+		}
+	}
+
+	stitchClient, app, provider, err := ara.resolveAuthProvider(ara.BaseCommand)
+	if err != nil {
+		return err
+	}
+
+	if err := ara.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	accepted := map[string]bool{}
+	for _, uri := range provider.RedirectURIs {
+		accepted[uri] = true
+	}
+
+	var added []string
+	for _, uri := range uris {
+		if accepted[uri] {
+			ara.Log().Info(fmt.Sprintf("'%s' is already a redirect URI", uri))
+			continue
+		}
+		accepted[uri] = true
+		added = append(added, uri)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	provider.RedirectURIs = append(provider.RedirectURIs, added...)
+	if err := stitchClient.UpdateAuthProvider(app.GroupID, app.ID, provider); err != nil {
+		return err
+	}
+
+	ara.Success(fmt.Sprintf("Successfully added %d redirect URI(s) to auth provider '%s' of '%s'", len(added), provider.Name, app.ClientAppID))
+	return nil
+}
+
+// NewAuthRedirectURIsRemoveCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewAuthRedirectURIsRemoveCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &AuthRedirectURIsRemoveCommand{
+			BaseCommand: &BaseCommand{
+				Name: "auth redirect-uris remove",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// AuthRedirectURIsRemoveCommand is used to remove redirect URIs from an auth provider of a Stitch App
+type AuthRedirectURIsRemoveCommand struct {
+	*BaseCommand
+	authRedirectURIsFlags
+
+	flagURIs stringsFlag
+}
+
+// Help returns long-form help information for this command
+func (arr *AuthRedirectURIsRemoveCommand) Help() string {
+	return `Remove redirect URIs from an auth provider of a stitch application, e.g. once a preview environment is torn down.
+
+USAGE:
+  auth redirect-uris remove --app-id [string] --provider [string] --uri [string]...
+
+  URIs the provider does not accept are skipped, so removing the same URIs again succeeds.
+` + authRedirectURIsOptionsHelp + authRedirectURIsURIHelp + arr.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (arr *AuthRedirectURIsRemoveCommand) Synopsis() string {
+	return `Remove redirect URIs from an auth provider.`
+}
+
+// Run executes the command
+func (arr *AuthRedirectURIsRemoveCommand) Run(args []string) int {
+	flags := arr.NewFlagSet()
+	arr.register(flags)
+	flags.Var(&arr.flagURIs, authRedirectURIsFlagURI, "")
+
+	if err := arr.BaseCommand.run(args); err != nil {
+		arr.Log().Error(err.Error())
+		return 1
+	}
+
+	err := arr.remove(arr.flagURIs)
+	arr.audit(arr.flagAppID, "", err)
+	if err != nil {
+		arr.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (arr *AuthRedirectURIsRemoveCommand) remove(uris []string) error {
+	if len(uris) == 0 {
+		return errAuthRedirectURIsRequired
+	}
+
+	stitchClient, app, provider, err := arr.resolveAuthProvider(arr.BaseCommand)
+	if err != nil {
+		return err
+	}
+
+	if err := arr.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	toRemove := map[string]bool{}
+	for _, uri := range uris {
+		toRemove[uri] = true
+	}
+
+	kept := []string{}
+	for _, uri := range provider.RedirectURIs {
+		if toRemove[uri] {
+			delete(toRemove, uri)
+			continue
+		}
+		kept = append(kept, uri)
+	}
+
+	for _, uri := range uris {
+		if toRemove[uri] {
+			arr.Log().Info(fmt.Sprintf("'%s' is not a redirect URI", uri))
+		}
+	}
+
+	removed := len(provider.RedirectURIs) - len(kept)
+	if removed == 0 {
+		return nil
+	}
+
+	provider.RedirectURIs = kept
+	if err := stitchClient.UpdateAuthProvider(app.GroupID, app.ID, provider); err != nil {
+		return err
+	}
+
+	arr.Success(fmt.Sprintf("Successfully removed %d redirect URI(s) from auth provider '%s' of '%s'", removed, provider.Name, app.ClientAppID))
+	return nil
+}
@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func newRedirectURIsClient(updated **models.AuthProvider) *u.MockStitchClient {
+	return &u.MockStitchClient{
+		FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+			return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+		},
+		FetchAuthProvidersFn: func(groupID, appID string) ([]models.AuthProvider, error) {
+			return []models.AuthProvider{
+				{ID: "anon-id", Name: "anon-user", Type: "anon-user"},
+				{ID: "google-id", Name: "oauth2-google", Type: "oauth2-google"},
+				{ID: "custom-1", Name: "sso-staff", Type: "custom-token"},
+				{ID: "custom-2", Name: "sso-partners", Type: "custom-token"},
+			}, nil
+		},
+		FetchAuthProviderFn: func(groupID, appID, providerID string) (*models.AuthProvider, error) {
+			return &models.AuthProvider{
+				ID:           providerID,
+				Name:         "oauth2-google",
+				Type:         "oauth2-google",
+				Config:       []byte(`{"clientId":"abc"}`),
+				RedirectURIs: []string{"https://example.com/callback", "https://pr-1.example.com/callback"},
+			}, nil
+		},
+		UpdateAuthProviderFn: func(groupID, appID string, provider *models.AuthProvider) error {
+			*updated = provider
+			return nil
+		},
+	}
+}
+
+func TestAuthRedirectURIsListCommand(t *testing.T) {
+	setup := func() (*AuthRedirectURIsListCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewAuthRedirectURIsListCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		listCommand := cmd.(*AuthRedirectURIsListCommand)
+		listCommand.storage = u.NewEmptyStorage()
+		listCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		listCommand.stitchClient = newRedirectURIsClient(new(*models.AuthProvider))
+		return listCommand, mockUI
+	}
+
+	t.Run("it requires a provider", func(t *testing.T) {
+		listCommand, mockUI := setup()
+		exitCode := listCommand.Run([]string{"--app-id=my-app-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errAuthRedirectURIsProviderRequired.Error())
+	})
+
+	t.Run("it lists the redirect URIs one per line", func(t *testing.T) {
+		listCommand, mockUI := setup()
+		exitCode := listCommand.Run([]string{"--app-id=my-app-abcde", "--provider=oauth2-google"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "https://example.com/callback\nhttps://pr-1.example.com/callback\n")
+	})
+
+	t.Run("it fails for a provider type the app has more than one provider of", func(t *testing.T) {
+		listCommand, mockUI := setup()
+		exitCode := listCommand.Run([]string{"--app-id=my-app-abcde", "--provider=custom-token"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `'my-app-abcde' has 2 auth providers of type "custom-token", so one must be given by name`)
+	})
+
+	t.Run("it fails for an unknown provider", func(t *testing.T) {
+		listCommand, mockUI := setup()
+		exitCode := listCommand.Run([]string{"--app-id=my-app-abcde", "--provider=oauth2-facebook"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `'my-app-abcde' has no auth provider named or of type "oauth2-facebook"; its providers are: anon-user, oauth2-google, sso-staff, sso-partners`)
+	})
+}
+
+func TestAuthRedirectURIsAddCommand(t *testing.T) {
+	setup := func() (*AuthRedirectURIsAddCommand, *cli.MockUi, **models.AuthProvider) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewAuthRedirectURIsAddCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		updated := new(*models.AuthProvider)
+		addCommand := cmd.(*AuthRedirectURIsAddCommand)
+		addCommand.storage = u.NewEmptyStorage()
+		addCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		addCommand.stitchClient = newRedirectURIsClient(updated)
+		return addCommand, mockUI, updated
+	}
+
+	t.Run("it requires a URI", func(t *testing.T) {
+		addCommand, mockUI, _ := setup()
+		exitCode := addCommand.Run([]string{"--app-id=my-app-abcde", "--provider=oauth2-google"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errAuthRedirectURIsRequired.Error())
+	})
+
+	t.Run("it rejects a URI that is not absolute", func(t *testing.T) {
+		addCommand, mockUI, updated := setup()
+		exitCode := addCommand.Run([]string{"--app-id=my-app-abcde", "--provider=oauth2-google", "--uri=/callback"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `invalid redirect URI "/callback"`)
+		u.So(t, *updated, gc.ShouldBeNil)
+	})
+
+	t.Run("it adds the URIs the provider does not accept yet, keeping its config", func(t *testing.T) {
+		addCommand, mockUI, updated := setup()
+		exitCode := addCommand.Run([]string{
+			"--app-id=my-app-abcde",
+			"--provider=oauth2-google",
+			"--uri=https://pr-1.example.com/callback",
+			"--uri=https://pr-2.example.com/callback",
+		})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully added 1 redirect URI(s) to auth provider 'oauth2-google' of 'my-app-abcde'")
+
+		u.So(t, (*updated).ID, gc.ShouldEqual, "google-id")
+		u.So(t, string((*updated).Config), gc.ShouldEqual, `{"clientId":"abc"}`)
+		u.So(t, (*updated).RedirectURIs, gc.ShouldResemble, []string{
+			"https://example.com/callback",
+			"https://pr-1.example.com/callback",
+			"https://pr-2.example.com/callback",
+		})
+	})
+}
+
+func TestAuthRedirectURIsRemoveCommand(t *testing.T) {
+	setup := func() (*AuthRedirectURIsRemoveCommand, *cli.MockUi, **models.AuthProvider) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewAuthRedirectURIsRemoveCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		updated := new(*models.AuthProvider)
+		removeCommand := cmd.(*AuthRedirectURIsRemoveCommand)
+		removeCommand.storage = u.NewEmptyStorage()
+		removeCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		removeCommand.stitchClient = newRedirectURIsClient(updated)
+		return removeCommand, mockUI, updated
+	}
+
+	t.Run("it removes the given URIs", func(t *testing.T) {
+		removeCommand, mockUI, updated := setup()
+		exitCode := removeCommand.Run([]string{"--app-id=my-app-abcde", "--provider=oauth2-google", "--uri=https://pr-1.example.com/callback"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully removed 1 redirect URI(s) from auth provider 'oauth2-google' of 'my-app-abcde'")
+		u.So(t, (*updated).RedirectURIs, gc.ShouldResemble, []string{"https://example.com/callback"})
+	})
+
+	t.Run("it succeeds without updating the provider if none of the URIs are accepted", func(t *testing.T) {
+		removeCommand, mockUI, updated := setup()
+		exitCode := removeCommand.Run([]string{"--app-id=my-app-abcde", "--provider=oauth2-google", "--uri=https://pr-9.example.com/callback"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "'https://pr-9.example.com/callback' is not a redirect URI")
+		u.So(t, *updated, gc.ShouldBeNil)
+	})
+}
@@ -0,0 +1,582 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/secrets"
+	u "github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	bootstrapFlagFrom           = "from"
+	bootstrapFlagProject        = "project"
+	bootstrapFlagEnv            = "env"
+	bootstrapFlagAppName        = "app-name"
+	bootstrapFlagCluster        = "cluster"
+	bootstrapFlagSecretsFile    = "secrets-file"
+	bootstrapFlagCheckpointFile = "checkpoint-file"
+
+	atlasServiceType = "mongodb-atlas"
+
+	// bootstrapCheckpointFileFormat names the default checkpoint file of an environment
+	bootstrapCheckpointFileFormat = ".stitch-bootstrap-%s.json"
+)
+
+// the steps of a bootstrap, in the order they are run
+const (
+	bootstrapStepCreateApp     = "create-app"
+	bootstrapStepSecrets       = "secrets"
+	bootstrapStepLinkCluster   = "link-cluster"
+	bootstrapStepImportConfig  = "import-config"
+	bootstrapStepDeployHosting = "deploy-hosting"
+)
+
+var errBootstrapFlagsRequired = fmt.Errorf("the template directory (--%s=[string]), the project (--%s=[string]) and the environment (--%s=[string]) must be supplied", bootstrapFlagFrom, bootstrapFlagProject, bootstrapFlagEnv)
+
+// NewBootstrapCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewBootstrapCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &BootstrapCommand{
+			BaseCommand: &BaseCommand{
+				Name: "bootstrap",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// BootstrapCommand is used to set up a new environment of an app from a template directory
+type BootstrapCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagFrom           string
+	flagProject        string
+	flagEnv            string
+	flagAppName        string
+	flagCluster        string
+	flagSecretsFile    string
+	flagCheckpointFile string
+}
+
+// bootstrapCheckpoint records how far a bootstrap got, so that it resumes from the step that failed when rerun
+type bootstrapCheckpoint struct {
+	Template    string   `json:"template"`
+	Env         string   `json:"env"`
+	GroupID     string   `json:"group_id,omitempty"`
+	AppID       string   `json:"app_id,omitempty"`
+	ClientAppID string   `json:"client_app_id,omitempty"`
+	AppName     string   `json:"app_name,omitempty"`
+	Completed   []string `json:"completed"`
+}
+
+func (cp *bootstrapCheckpoint) done(step string) bool {
+	for _, completed := range cp.Completed {
+		if completed == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Synopsis returns a one-liner description for this command
+func (bc *BootstrapCommand) Synopsis() string {
+	return "Set up a new environment of an app from a template directory."
+}
+
+// Help returns long-form help information for this command
+func (bc *BootstrapCommand) Help() string {
+	return `Set up a new environment of an app from a template directory in one go: create the app, create the secrets it refers to, link its MongoDB Atlas services to a cluster, import its config and deploy its hosted assets.
+Each completed step is recorded in a checkpoint file, so that rerunning the same command after a failure resumes from the step that failed. Remove the checkpoint file to start over.
+
+REQUIRED:
+  --` + bootstrapFlagFrom + ` [string]
+	The directory of the app to use as a template, as exported or written by hand.
+
+  --` + bootstrapFlagProject + ` [string]
+	The name or ID of the Atlas project to create the app in.
+
+  --` + bootstrapFlagEnv + ` [string]
+	The name of the environment, e.g. "staging", which names the app and its checkpoint file.
+
+OPTIONS:
+  --` + bootstrapFlagAppName + ` [string]
+	The name of the app to create. Defaults to the name of the template followed by the environment, e.g. "my-app-staging".
+
+  --` + bootstrapFlagCluster + ` [string]
+	The name of the Atlas cluster to link the MongoDB Atlas services of the template to. Required if they do not name one.
+
+  --` + bootstrapFlagSecretsFile + ` [string]
+	A file with a "NAME=value" line for each secret the template refers to. Values may be references to a secret manager, e.g. "vault:secret/data/my-app#apiKey".
+
+  --` + bootstrapFlagCheckpointFile + ` [string]
+	The file to record the completed steps in. Defaults to ".stitch-bootstrap-<env>.json" in the working directory.` +
+		bc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (bc *BootstrapCommand) Run(args []string) int {
+	flags := bc.NewFlagSet()
+
+	flags.StringVar(&bc.flagFrom, bootstrapFlagFrom, "", "")
+	flags.StringVar(&bc.flagProject, bootstrapFlagProject, "", "")
+	flags.StringVar(&bc.flagEnv, bootstrapFlagEnv, "", "")
+	flags.StringVar(&bc.flagAppName, bootstrapFlagAppName, "", "")
+	flags.StringVar(&bc.flagCluster, bootstrapFlagCluster, "", "")
+	flags.StringVar(&bc.flagSecretsFile, bootstrapFlagSecretsFile, "", "")
+	flags.StringVar(&bc.flagCheckpointFile, bootstrapFlagCheckpointFile, "", "")
+
+	if err := bc.BaseCommand.run(args); err != nil {
+		bc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := bc.bootstrap(); err != nil {
+		bc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (bc *BootstrapCommand) bootstrap() error {
+	if bc.flagFrom == "" || bc.flagProject == "" || bc.flagEnv == "" {
+		return errBootstrapFlagsRequired
+	}
+
+	templatePath, err := homedir.Expand(bc.flagFrom)
+	if err != nil {
+		return err
+	}
+	if templatePath, err = filepath.Abs(templatePath); err != nil {
+		return err
+	}
+
+	templateData := models.AppInstanceData{}
+	if err := templateData.UnmarshalFile(templatePath); err != nil {
+		return fmt.Errorf("failed to read the template at %s: %s", templatePath, err)
+	}
+
+	templateApp, err := utils.UnmarshalFromDir(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read the template at %s: %s", templatePath, err)
+	}
+
+	user, err := bc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := bc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	checkpointPath, checkpoint, err := bc.loadCheckpoint(templatePath)
+	if err != nil {
+		return err
+	}
+
+	steps := []struct {
+		name        string
+		description string
+		run         func() error
+	}{
+		{bootstrapStepCreateApp, "creating the app", func() error {
+			return bc.createApp(stitchClient, checkpoint, templateData)
+		}},
+		{bootstrapStepSecrets, "creating secrets", func() error {
+			return bc.createSecrets(stitchClient, checkpoint, templateApp)
+		}},
+		{bootstrapStepLinkCluster, "linking the Atlas cluster", func() error {
+			return bc.linkCluster(templateApp)
+		}},
+		{bootstrapStepImportConfig, "importing the config", func() error {
+			return bc.importTemplate(checkpoint, templatePath, false)
+		}},
+		{bootstrapStepDeployHosting, "deploying hosting", func() error {
+			return bc.importTemplate(checkpoint, templatePath, true)
+		}},
+	}
+
+	for i, step := range steps {
+		if checkpoint.done(step.name) {
+			bc.Log().Info(fmt.Sprintf("Skipping %s (step %d of %d), completed by a previous run", step.description, i+1, len(steps)))
+			continue
+		}
+
+		bc.Log().Info(fmt.Sprintf("%s (step %d of %d)...", strings.Title(step.description), i+1, len(steps)))
+		if err := step.run(); err != nil {
+			return fmt.Errorf("failed %s: %s; rerun the same command to resume from this step", step.description, err)
+		}
+
+		checkpoint.Completed = append(checkpoint.Completed, step.name)
+		if err := saveBootstrapCheckpoint(checkpointPath, checkpoint); err != nil {
+			return fmt.Errorf("failed to record the checkpoint in %s: %s", checkpointPath, err)
+		}
+	}
+
+	bc.Log().Info(fmt.Sprintf("Successfully bootstrapped '%s' (%s) for %s", checkpoint.AppName, checkpoint.ClientAppID, bc.flagEnv))
+	return nil
+}
+
+// loadCheckpoint reads the checkpoint of an earlier run for the environment, or starts a new one if there is none
+func (bc *BootstrapCommand) loadCheckpoint(templatePath string) (string, *bootstrapCheckpoint, error) {
+	checkpointPath := bc.flagCheckpointFile
+	if checkpointPath == "" {
+		checkpointPath = filepath.Join(bc.workingDirectory, fmt.Sprintf(bootstrapCheckpointFileFormat, bc.flagEnv))
+	}
+
+	checkpointPath, err := homedir.Expand(checkpointPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	checkpoint := &bootstrapCheckpoint{Template: templatePath, Env: bc.flagEnv}
+
+	data, err := ioutil.ReadFile(checkpointPath)
+	if os.IsNotExist(err) {
+		return checkpointPath, checkpoint, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return "", nil, fmt.Errorf("failed to read the checkpoint in %s: %s", checkpointPath, err)
+	}
+
+	if checkpoint.Template != templatePath || checkpoint.Env != bc.flagEnv {
+		return "", nil, fmt.Errorf(
+			"the checkpoint in %s is for bootstrapping %s from %s; remove it to start over",
+			checkpointPath,
+			checkpoint.Env,
+			checkpoint.Template,
+		)
+	}
+
+	bc.Log().Info(fmt.Sprintf("Resuming the bootstrap of %s recorded in %s", bc.flagEnv, checkpointPath))
+	return checkpointPath, checkpoint, nil
+}
+
+func saveBootstrapCheckpoint(path string, checkpoint *bootstrapCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// createApp creates the app of the environment in the --project, with the location and deployment model of the
+// template, failing if the project already has an app of its name
+func (bc *BootstrapCommand) createApp(stitchClient api.StitchClient, checkpoint *bootstrapCheckpoint, templateData models.AppInstanceData) error {
+	groupID, err := bc.resolveProject()
+	if err != nil {
+		return err
+	}
+
+	appName := bc.flagAppName
+	if appName == "" {
+		appName = fmt.Sprintf("%s-%s", templateData.AppName(), bc.flagEnv)
+	}
+
+	apps, err := stitchClient.FetchAppsByGroupID(groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		if app.Name == appName {
+			return fmt.Errorf("the project already has an app named '%s' (%s)", appName, app.ClientAppID)
+		}
+	}
+
+	app, err := stitchClient.CreateEmptyApp(groupID, appName, templateData.AppLocation(), templateData.AppDeploymentModel())
+	if err != nil {
+		return err
+	}
+
+	checkpoint.GroupID = app.GroupID
+	checkpoint.AppID = app.ID
+	checkpoint.ClientAppID = app.ClientAppID
+	checkpoint.AppName = app.Name
+
+	bc.Log().Info(fmt.Sprintf("Created '%s' (%s)", app.Name, app.ClientAppID))
+	return nil
+}
+
+// resolveProject returns the ID of the --project, which is given by name or ID
+func (bc *BootstrapCommand) resolveProject() (string, error) {
+	if isObjectIDHex(bc.flagProject) {
+		return bc.flagProject, nil
+	}
+
+	atlasClient, err := bc.AtlasClient()
+	if err != nil {
+		return "", fmt.Errorf("an unexpected error occurred: %s", err)
+	}
+
+	group, err := atlasClient.GroupByName(bc.flagProject)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the project '%s': %s", bc.flagProject, err)
+	}
+
+	return group.ID, nil
+}
+
+// createSecrets creates the secrets that the template refers to and the app does not have from the
+// --secrets-file, failing if any of them has no value there
+func (bc *BootstrapCommand) createSecrets(stitchClient api.StitchClient, checkpoint *bootstrapCheckpoint, templateApp map[string]interface{}) error {
+	referenced := referencedSecrets(templateApp)
+	if len(referenced) == 0 {
+		bc.Log().Info("The template refers to no secrets")
+		return nil
+	}
+
+	appSecrets, err := stitchClient.FetchSecrets(checkpoint.GroupID, checkpoint.AppID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(appSecrets))
+	for _, secret := range appSecrets {
+		existing[secret.Name] = true
+	}
+
+	fileValues, err := readSecretsFile(bc.flagSecretsFile)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, name := range referenced {
+		if _, ok := fileValues[name]; !ok && !existing[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the template refers to %d secret(s) with no value in --%s: %s", len(missing), bootstrapFlagSecretsFile, strings.Join(missing, ", "))
+	}
+
+	for _, name := range referenced {
+		// a secret created before a failure is kept, so that a rerun does not fail creating it again
+		if existing[name] {
+			continue
+		}
+
+		resolved, err := secrets.Resolve(fileValues[name])
+		if err != nil {
+			return fmt.Errorf("--%s error: %s: %s", bootstrapFlagSecretsFile, name, err)
+		}
+
+		if err := stitchClient.CreateSecret(checkpoint.GroupID, checkpoint.AppID, name, resolved); err != nil {
+			return fmt.Errorf("failed to create secret '%s': %s", name, err)
+		}
+		bc.Log().Info(fmt.Sprintf("Created secret '%s'", name))
+	}
+
+	return nil
+}
+
+// linkCluster checks that each MongoDB Atlas service of the template is linked to a cluster, either by the
+// --cluster or by the template itself
+func (bc *BootstrapCommand) linkCluster(templateApp map[string]interface{}) error {
+	services := atlasServices(templateApp)
+	if len(services) == 0 {
+		bc.Log().Info("The template has no MongoDB Atlas services to link")
+		return nil
+	}
+
+	for name, clusterName := range services {
+		if bc.flagCluster != "" {
+			clusterName = bc.flagCluster
+		}
+		if clusterName == "" {
+			return fmt.Errorf("service '%s' names no cluster, so --%s must be given to link it", name, bootstrapFlagCluster)
+		}
+		bc.Log().Info(fmt.Sprintf("Linking service '%s' to cluster '%s'", name, clusterName))
+	}
+
+	return nil
+}
+
+// atlasServices returns the cluster that each MongoDB Atlas service of an app, as loaded by UnmarshalFromDir,
+// is linked to, keyed by the name of the service
+func atlasServices(app map[string]interface{}) map[string]string {
+	services := map[string]string{}
+
+	rawServices, _ := app["services"].([]interface{})
+	for _, rawService := range rawServices {
+		service, _ := rawService.(map[string]interface{})
+		config, _ := service["config"].(map[string]interface{})
+		if serviceType, _ := config["type"].(string); serviceType != atlasServiceType {
+			continue
+		}
+
+		name, _ := config["name"].(string)
+		serviceConfig, _ := config["config"].(map[string]interface{})
+		clusterName, _ := serviceConfig["clusterName"].(string)
+		services[name] = clusterName
+	}
+
+	return services
+}
+
+// importTemplate imports a copy of the template into the app of the environment, named after it and with its
+// MongoDB Atlas services linked to the --cluster: its config, or its hosted assets if hosting is true
+func (bc *BootstrapCommand) importTemplate(checkpoint *bootstrapCheckpoint, templatePath string, hosting bool) error {
+	if hosting {
+		if _, err := os.Stat(filepath.Join(templatePath, utils.HostingFilesDirectory)); os.IsNotExist(err) {
+			bc.Log().Info("The template has no hosted assets to deploy")
+			return nil
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-bootstrap")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	appPath := filepath.Join(dir, filepath.Base(templatePath))
+	app := &models.App{ClientAppID: checkpoint.ClientAppID, Name: checkpoint.AppName}
+	if err := copyAppDirectory(templatePath, appPath, app, func(string) string { return bc.flagCluster }); err != nil {
+		return err
+	}
+
+	ic := &ImportCommand{
+		BaseCommand:      bc.BaseCommand,
+		workingDirectory: bc.workingDirectory,
+		writeToDirectory: utils.WriteAppToDir,
+		writeAppConfigToFile: func(dest string, app models.AppInstanceData) error {
+			return app.MarshalFile(dest)
+		},
+		writeProjectConfig: func(dest string, config *models.ProjectConfig) error {
+			return config.Save(dest)
+		},
+		report:          newImportReport(),
+		flagAppID:       checkpoint.ClientAppID,
+		flagAppPath:     appPath,
+		flagGroupID:     checkpoint.GroupID,
+		flagStrategy:    importStrategyReplace,
+		flagSecretsFile: bc.flagSecretsFile,
+		flagRetryFile:   defaultHostingRetryFile,
+	}
+
+	if hosting {
+		ic.flagHostingOnly = true
+		ic.flagIncludeHosting = true
+	} else {
+		// the app is new, so the entities the config refers to are created ahead of those referring to them
+		ic.flagConfigOnly = true
+		ic.flagOrdered = true
+	}
+
+	return ic.auditedImportApp()
+}
+
+// copyAppDirectory copies the app directory at srcPath to destPath, naming the copy after app and linking each of
+// its MongoDB Atlas services to the cluster that clusterOf returns for its name, unless that is empty
+func copyAppDirectory(srcPath, destPath string, app *models.App, clusterOf func(service string) string) error {
+	if err := filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(destPath, relPath), 0755)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(destPath, relPath), data, info.Mode())
+	}); err != nil {
+		return fmt.Errorf("failed to copy %s: %s", srcPath, err)
+	}
+
+	appInstanceData := models.AppInstanceData{}
+	if err := appInstanceData.UnmarshalFile(destPath); err != nil {
+		return err
+	}
+	appInstanceData[models.AppIDField] = app.ClientAppID
+	appInstanceData[models.AppNameField] = app.Name
+	if err := appInstanceData.MarshalFile(destPath); err != nil {
+		return err
+	}
+
+	serviceDirs, err := ioutil.ReadDir(filepath.Join(destPath, "services"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, serviceDir := range serviceDirs {
+		configPath := filepath.Join(destPath, "services", serviceDir.Name(), "config.json")
+
+		data, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			continue
+		}
+
+		var config map[string]interface{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("failed to read %s: %s", configPath, err)
+		}
+
+		if serviceType, _ := config["type"].(string); serviceType != atlasServiceType {
+			continue
+		}
+
+		name, _ := config["name"].(string)
+		clusterName := clusterOf(name)
+		if clusterName == "" {
+			continue
+		}
+
+		serviceConfig, _ := config["config"].(map[string]interface{})
+		if serviceConfig == nil {
+			serviceConfig = map[string]interface{}{}
+		}
+		serviceConfig["clusterName"] = clusterName
+		config["config"] = serviceConfig
+
+		if data, err = json.MarshalIndent(config, "", "    "); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(configPath, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
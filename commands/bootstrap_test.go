@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestBootstrapCommand(t *testing.T) {
+	templateDir, err := ioutil.TempDir("", "stitch-bootstrap-template")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(templateDir)
+
+	for name, data := range map[string]string{
+		"stitch.json":                        `{"name": "shop", "location": "US-OR", "deployment_model": "LOCAL"}`,
+		"services/mongodb-atlas/config.json": `{"name": "mongodb-atlas", "type": "mongodb-atlas", "config": {}}`,
+		"values/stripeKey.json":              `{"name": "stripeKey", "value": "stripe-key", "from_secret": true}`,
+	} {
+		path := filepath.Join(templateDir, name)
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	secretsFile := filepath.Join(templateDir, "staging.env")
+	u.So(t, ioutil.WriteFile(secretsFile, []byte("stripe-key=sk_test_123\n"), 0600), gc.ShouldBeNil)
+
+	setup := func() (*BootstrapCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewBootstrapCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		bootstrapCommand := cmd.(*BootstrapCommand)
+		bootstrapCommand.workingDirectory = templateDir
+		bootstrapCommand.storage = u.NewEmptyStorage()
+		bootstrapCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		bootstrapCommand.atlasClient = &u.MockMDBClient{
+			GroupByNameFn: func(name string) (*mdbcloud.Group, error) {
+				return &mdbcloud.Group{ID: "5b2d2e5c4a1f0e0012345678", Name: name}, nil
+			},
+		}
+		return bootstrapCommand, mockUI
+	}
+
+	type bootstrapCalls struct {
+		created []string
+		secrets map[string]string
+		imports []map[string]interface{}
+	}
+
+	newStitchClient := func(calls *bootstrapCalls, importErr *error) *u.MockStitchClient {
+		app := &models.App{GroupID: "5b2d2e5c4a1f0e0012345678", ID: "app-id", ClientAppID: "shop-staging-abcde", Name: "shop-staging"}
+		empty := newAppZip(t, map[string]string{
+			"stitch.json": `{"app_id": "shop-staging-abcde", "name": "shop-staging"}`,
+		})
+
+		return &u.MockStitchClient{
+			FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+				return []*models.App{{Name: "shop-production", ClientAppID: "shop-production-fghij"}}, nil
+			},
+			CreateEmptyAppFn: func(groupID, appName, location, deploymentModel string) (*models.App, error) {
+				calls.created = append(calls.created, groupID+"/"+appName+"/"+location+"/"+deploymentModel)
+				return app, nil
+			},
+			FetchAppByGroupIDAndClientAppIDFn: func(groupID, clientAppID string) (*models.App, error) {
+				return app, nil
+			},
+			FetchSecretsFn: func(groupID, appID string) ([]models.Secret, error) {
+				var existing []models.Secret
+				for name := range calls.secrets {
+					existing = append(existing, models.Secret{Name: name})
+				}
+				return existing, nil
+			},
+			CreateSecretFn: func(groupID, appID, name, value string) error {
+				calls.secrets[name] = value
+				return nil
+			},
+			ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+				return "shop-staging.zip", u.NewResponseBody(bytes.NewReader(empty)), nil
+			},
+			DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+				return []string{"services/mongodb-atlas: added"}, nil
+			},
+			ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+				if *importErr != nil {
+					return *importErr
+				}
+				var imported map[string]interface{}
+				if err := json.Unmarshal(appData, &imported); err != nil {
+					return err
+				}
+				calls.imports = append(calls.imports, imported)
+				return nil
+			},
+		}
+	}
+
+	validArgs := []string{"--from=" + templateDir, "--project=shop-project", "--env=staging", "--secrets-file=" + secretsFile, "--yes"}
+
+	t.Run("it requires the template, project and environment", func(t *testing.T) {
+		bootstrapCommand, mockUI := setup()
+		exitCode := bootstrapCommand.Run([]string{"--from=" + templateDir, "--env=staging"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errBootstrapFlagsRequired.Error())
+	})
+
+	t.Run("it fails if the project already has an app of the same name", func(t *testing.T) {
+		calls := &bootstrapCalls{secrets: map[string]string{}}
+		var importErr error
+		bootstrapCommand, mockUI := setup()
+		bootstrapCommand.stitchClient = newStitchClient(calls, &importErr)
+		defer os.Remove(filepath.Join(templateDir, ".stitch-bootstrap-production.json"))
+
+		exitCode := bootstrapCommand.Run([]string{"--from=" + templateDir, "--project=shop-project", "--env=production"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the project already has an app named 'shop-production'")
+		u.So(t, calls.created, gc.ShouldBeEmpty)
+	})
+
+	t.Run("it fails on the link step if the Atlas services name no cluster", func(t *testing.T) {
+		calls := &bootstrapCalls{secrets: map[string]string{}}
+		var importErr error
+		bootstrapCommand, mockUI := setup()
+		bootstrapCommand.stitchClient = newStitchClient(calls, &importErr)
+		defer os.Remove(filepath.Join(templateDir, ".stitch-bootstrap-staging.json"))
+
+		exitCode := bootstrapCommand.Run(validArgs)
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed linking the Atlas cluster: service 'mongodb-atlas' names no cluster")
+		u.So(t, calls.secrets, gc.ShouldResemble, map[string]string{"stripe-key": "sk_test_123"})
+		u.So(t, calls.imports, gc.ShouldBeEmpty)
+	})
+
+	t.Run("it resumes from the step that failed when rerun", func(t *testing.T) {
+		calls := &bootstrapCalls{secrets: map[string]string{}}
+		importErr := errors.New("service unavailable")
+		checkpointPath := filepath.Join(templateDir, ".stitch-bootstrap-staging.json")
+		defer os.Remove(checkpointPath)
+
+		args := append([]string{"--cluster=Staging"}, validArgs...)
+
+		bootstrapCommand, mockUI := setup()
+		bootstrapCommand.stitchClient = newStitchClient(calls, &importErr)
+		exitCode := bootstrapCommand.Run(args)
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed importing the config")
+
+		var checkpoint bootstrapCheckpoint
+		data, err := ioutil.ReadFile(checkpointPath)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, json.Unmarshal(data, &checkpoint), gc.ShouldBeNil)
+		u.So(t, checkpoint.ClientAppID, gc.ShouldEqual, "shop-staging-abcde")
+		u.So(t, checkpoint.Completed, gc.ShouldResemble, []string{bootstrapStepCreateApp, bootstrapStepSecrets, bootstrapStepLinkCluster})
+
+		importErr = nil
+		bootstrapCommand, mockUI = setup()
+		bootstrapCommand.stitchClient = newStitchClient(calls, &importErr)
+		exitCode = bootstrapCommand.Run(args)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Skipping creating the app (step 1 of 5), completed by a previous run")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "The template has no hosted assets to deploy")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully bootstrapped 'shop-staging' (shop-staging-abcde) for staging")
+
+		u.So(t, calls.created, gc.ShouldResemble, []string{"5b2d2e5c4a1f0e0012345678/shop-staging/US-OR/LOCAL"})
+		u.So(t, calls.imports, gc.ShouldNotBeEmpty)
+
+		imported := calls.imports[len(calls.imports)-1]
+		u.So(t, imported[models.AppIDField], gc.ShouldEqual, "shop-staging-abcde")
+		u.So(t, imported[models.AppNameField], gc.ShouldEqual, "shop-staging")
+
+		services := imported["services"].([]interface{})
+		u.So(t, services, gc.ShouldHaveLength, 1)
+		config := services[0].(map[string]interface{})["config"].(map[string]interface{})
+		u.So(t, config["config"], gc.ShouldResemble, map[string]interface{}{"clusterName": "Staging"})
+	})
+	t.Run("it records the failed and successful imports of the config in the audit log", func(t *testing.T) {
+		logDir, err := ioutil.TempDir("", "stitch-bootstrap-audit")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(logDir)
+		logPath := filepath.Join(logDir, "audit.jsonl")
+		defer os.Remove(filepath.Join(templateDir, ".stitch-bootstrap-staging.json"))
+
+		calls := &bootstrapCalls{secrets: map[string]string{}}
+		importErr := errors.New("service unavailable")
+		args := append([]string{"--cluster=Staging", "--audit-log=" + logPath}, validArgs...)
+
+		bootstrapCommand, _ := setup()
+		bootstrapCommand.stitchClient = newStitchClient(calls, &importErr)
+		u.So(t, bootstrapCommand.Run(args), gc.ShouldEqual, 1)
+
+		importErr = nil
+		bootstrapCommand, _ = setup()
+		bootstrapCommand.stitchClient = newStitchClient(calls, &importErr)
+		u.So(t, bootstrapCommand.Run(args), gc.ShouldEqual, 0)
+
+		contents, err := ioutil.ReadFile(logPath)
+		u.So(t, err, gc.ShouldBeNil)
+
+		var records []logging.AuditRecord
+		for _, line := range strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n") {
+			var record logging.AuditRecord
+			u.So(t, json.Unmarshal([]byte(line), &record), gc.ShouldBeNil)
+			records = append(records, record)
+		}
+		u.So(t, records, gc.ShouldHaveLength, 2)
+		for _, record := range records {
+			u.So(t, record.Command, gc.ShouldEqual, "bootstrap")
+			u.So(t, record.ClientAppID, gc.ShouldEqual, "shop-staging-abcde")
+		}
+		u.So(t, records[0].Result, gc.ShouldEqual, logging.AuditResultFailure)
+		u.So(t, records[0].Error, gc.ShouldContainSubstring, "service unavailable")
+		u.So(t, records[1].Result, gc.ShouldEqual, logging.AuditResultSuccess)
+	})
+}
@@ -3,15 +3,22 @@
 package commands
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/selector"
 	"github.com/10gen/stitch-cli/storage"
 	"github.com/10gen/stitch-cli/user"
 	"github.com/10gen/stitch-cli/utils"
@@ -24,6 +31,17 @@ import (
 const (
 	flagProjectIDName = "project-id"
 	flagAppIDName     = "app-id"
+	flagOrgName       = "org"
+	flagAsName        = "as"
+)
+
+const (
+	// accessTokenRefreshMargin is how long before it expires the access token is refreshed, so that it does not
+	// expire partway through a long-running command
+	accessTokenRefreshMargin = 5 * time.Minute
+
+	// sessionExpiryWarning is how long before the session expires commands warn about having to log in again
+	sessionExpiryWarning = 24 * time.Hour
 )
 
 var (
@@ -38,30 +56,80 @@ type BaseCommand struct {
 
 	UI cli.Ui
 
+	terminal *terminalUi
+
+	// logger reports the progress of the command, and is set up from --log-level and --log-file by run
+	logger logging.Logger
+
+	// selectOption displays an interactive selector, and is only set when running in a terminal that supports one
+	selectOption func(prompt string, options []selector.Option) (selector.Option, error)
+
 	client       api.Client
 	atlasClient  mdbcloud.Client
 	stitchClient api.StitchClient
 	user         *user.User
 	storage      *storage.Storage
 
+	// continueOnFlagError makes flag errors be returned from run rather than exiting, so tests can check them
+	continueOnFlagError bool
+
+	// configPath is the resolved location of the CLI config file, next to which cache files are kept
+	configPath string
+
+	// correlationID identifies the requests made by this run of the command, and is generated by run
+	correlationID string
+
+	// allowNewIdentity makes User return an empty user for an --as identity that is not stored yet, so that
+	// logging in can store it
+	allowNewIdentity bool
+
 	flagConfigPath    string
 	flagColorDisabled bool
 	flagBaseURL       string
 	flagAtlasBaseURL  string
 	flagYes           bool
+	flagQuiet         bool
+	flagRefresh       bool
+	flagHeaders       headerFlags
+	flagImpersonate   string
+	flagLogLevel      string
+	flagLogFile       string
+	flagAuditLog      string
+	flagUserAgent     string
+	flagAs            string
 }
 
 // NewFlagSet builds and returns the default set of flags for all commands
 func (c *BaseCommand) NewFlagSet() *flag.FlagSet {
-	set := flag.NewFlagSet(c.Name, flag.ExitOnError)
+	errorHandling := flag.ExitOnError
+	if c.continueOnFlagError {
+		errorHandling = flag.ContinueOnError
+	}
+
+	set := flag.NewFlagSet(c.Name, errorHandling)
 	set.Usage = func() {}
+	if c.continueOnFlagError {
+		set.SetOutput(ioutil.Discard)
+	}
 
 	set.BoolVar(&c.flagColorDisabled, "disable-color", false, "")
+	set.BoolVar(&c.flagColorDisabled, "no-color", false, "")
 	set.BoolVar(&c.flagYes, "yes", false, "")
 	set.BoolVar(&c.flagYes, "y", false, "")
+	set.BoolVar(&c.flagQuiet, "quiet", false, "")
+	set.BoolVar(&c.flagQuiet, "q", false, "")
 	set.StringVar(&c.flagBaseURL, "base-url", api.DefaultBaseURL, "")
 	set.StringVar(&c.flagAtlasBaseURL, "atlas-base-url", api.DefaultAtlasBaseURL, "")
 	set.StringVar(&c.flagConfigPath, "config-path", "", "")
+	set.BoolVar(&c.flagRefresh, "refresh", false, "")
+	c.flagHeaders = headerFlags{}
+	set.Var(c.flagHeaders, "header", "")
+	set.StringVar(&c.flagImpersonate, "impersonate", "", "")
+	set.StringVar(&c.flagLogLevel, "log-level", logging.LevelInfo.String(), "")
+	set.StringVar(&c.flagLogFile, "log-file", "", "")
+	set.StringVar(&c.flagAuditLog, "audit-log", "", "")
+	set.StringVar(&c.flagUserAgent, "user-agent-suffix", os.Getenv(userAgentSuffixEnvVar), "")
+	set.StringVar(&c.flagAs, flagAsName, "", "")
 
 	c.FlagSet = set
 
@@ -74,7 +142,20 @@ func (c *BaseCommand) Client() (api.Client, error) {
 		return c.client, nil
 	}
 
-	c.client = api.NewClient(c.flagBaseURL)
+	headers := http.Header{}
+	for name, values := range c.flagHeaders {
+		headers[name] = values
+	}
+	if c.flagImpersonate != "" {
+		headers.Set(api.StitchImpersonateHeader, c.flagImpersonate)
+	}
+	for name, values := range c.traceHeaders() {
+		if _, ok := headers[name]; !ok {
+			headers[name] = values
+		}
+	}
+
+	c.client = api.NewClientWithHeaders(c.flagBaseURL, headers)
 
 	return c.client, nil
 }
@@ -90,13 +171,22 @@ func (c *BaseCommand) AtlasClient() (mdbcloud.Client, error) {
 		return nil, err
 	}
 
-	c.atlasClient = mdbcloud.NewClient(c.flagAtlasBaseURL).WithAuth(user.PublicAPIKey, user.PrivateAPIKey)
+	atlasClient := mdbcloud.NewClientWithHeaders(c.flagAtlasBaseURL, c.traceHeaders()).WithAuth(user.PublicAPIKey, user.PrivateAPIKey)
+
+	cachePath, err := c.cachePath(utils.GroupsCacheFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := c.flagAtlasBaseURL + " " + user.PublicAPIKey
+	c.atlasClient = mdbcloud.NewCachingClient(atlasClient, cachePath, cacheKey, mdbcloud.GroupsCacheTTL, c.flagRefresh)
 
 	return c.atlasClient, nil
 }
 
-// AuthClient returns an api.Client that is aware of the current user's auth credentials. It also handles retrying
-// requests if a user's access token has expired
+// AuthClient returns an api.Client that is aware of the current user's auth credentials. It refreshes the user's
+// access token if it has expired or is about to, warns if the session is about to expire, and also handles
+// retrying requests if the access token expires anyway
 func (c *BaseCommand) AuthClient() (api.Client, error) {
 	client, err := c.Client()
 	if err != nil {
@@ -110,20 +200,24 @@ func (c *BaseCommand) AuthClient() (api.Client, error) {
 
 	authClient := api.NewAuthClient(client, user)
 
-	tokenIsExpired, err := user.TokenIsExpired()
+	tokenExpiresSoon, err := user.TokenExpiresWithin(accessTokenRefreshMargin)
 	if err != nil {
 		return nil, err
 	}
 
-	if tokenIsExpired {
+	if err := c.checkSession(user); err != nil {
+		return nil, err
+	}
+
+	if tokenExpiresSoon {
 		authResponse, err := authClient.RefreshAuth()
 		if err != nil {
 			return nil, err
 		}
 
-		user.AccessToken = authResponse.AccessToken
+		user.SetAccessToken(authResponse.AccessToken)
 
-		if err := c.storage.WriteUserConfig(user); err != nil {
+		if err := c.saveUser(user); err != nil {
 			return nil, err
 		}
 	}
@@ -131,6 +225,27 @@ func (c *BaseCommand) AuthClient() (api.Client, error) {
 	return authClient, nil
 }
 
+// checkSession fails if the session of u has expired, and warns if it is about to
+func (c *BaseCommand) checkSession(u *user.User) error {
+	sessionExpiry, ok := u.SessionExpiry()
+	if !ok {
+		return nil
+	}
+
+	remaining := time.Until(sessionExpiry)
+	if remaining <= 0 {
+		return user.ErrSessionExpired
+	}
+
+	if remaining < sessionExpiryWarning {
+		c.Log().Warn(fmt.Sprintf(
+			"your session expires in %s, run 'stitch-cli login' before then to avoid being logged out partway through a command",
+			remaining.Round(time.Minute),
+		))
+	}
+	return nil
+}
+
 // StitchClient returns an api.StitchClient for use in calling the API
 func (c *BaseCommand) StitchClient() (api.StitchClient, error) {
 	if c.stitchClient != nil {
@@ -147,13 +262,26 @@ func (c *BaseCommand) StitchClient() (api.StitchClient, error) {
 	return c.stitchClient, nil
 }
 
-// User returns the current user. It loads the user from storage if it is not available in memory
+// User returns the current user, i.e. the identity selected with --as or else the default one. It loads the
+// user from storage if it is not available in memory
 func (c *BaseCommand) User() (*user.User, error) {
 	if c.user != nil {
 		return c.user, nil
 	}
 
-	u, err := c.storage.ReadUserConfig()
+	var u *user.User
+	var err error
+	if c.flagAs != "" {
+		u, err = c.storage.ReadIdentity(c.flagAs)
+		if err == storage.ErrIdentityNotFound {
+			if !c.allowNewIdentity {
+				return nil, fmt.Errorf("no identity named '%s' is stored, run 'stitch-cli login --%s=%s' to log in as it", c.flagAs, flagAsName, c.flagAs)
+			}
+			u, err = &user.User{}, nil
+		}
+	} else {
+		u, err = c.storage.ReadUserConfig()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -163,6 +291,14 @@ func (c *BaseCommand) User() (*user.User, error) {
 	return u, nil
 }
 
+// saveUser writes the current user to storage, as the identity selected with --as or else as the default one
+func (c *BaseCommand) saveUser(u *user.User) error {
+	if c.flagAs != "" {
+		return c.storage.WriteIdentity(c.flagAs, u)
+	}
+	return c.storage.WriteUserConfig(u)
+}
+
 func (c *BaseCommand) run(args []string) error {
 	if c.FlagSet == nil {
 		c.NewFlagSet()
@@ -170,52 +306,228 @@ func (c *BaseCommand) run(args []string) error {
 
 	// FlagSet uses flag.ExitOnError, so we let it handle flag-related errors
 	// to avoid duplicate error output
-	c.Parse(args)
+	if err := c.Parse(args); err != nil {
+		return err
+	}
 
-	if !c.flagColorDisabled && isatty.IsTerminal(os.Stdout.Fd()) {
-		c.UI = &cli.ColoredUi{
-			ErrorColor: cli.UiColorRed,
-			WarnColor:  cli.UiColorYellow,
-			Ui:         c.UI,
-		}
+	c.terminal = newTerminalUi(c.UI, c.colorEnabled(), c.flagQuiet)
+	c.UI = c.terminal
+
+	if err := c.setUpLogger(); err != nil {
+		return err
+	}
+
+	correlationID, err := newCorrelationID()
+	if err != nil {
+		return err
+	}
+	c.correlationID = correlationID
+	c.Log().Debug(fmt.Sprintf("Correlation ID: %s", c.correlationID))
+
+	if c.selectOption == nil && !c.flagYes && canSelectInteractively() {
+		c.selectOption = selectInTerminal
 	}
 
 	if url := utils.CheckForNewCLIVersion(http.DefaultClient); url != "" {
-		c.UI.Info(url)
+		c.Log().Info(url)
 	}
 
+	configPath, err := c.resolveConfigPath()
+	if err != nil {
+		return err
+	}
+	c.configPath = configPath
+
 	if c.storage == nil {
-		path, err := homedir.Expand(c.flagConfigPath)
+		fileStrategy, err := storage.NewFileStrategy(c.configPath)
 		if err != nil {
 			return err
 		}
 
-		if path == "" {
-			home, dirErr := homedir.Dir()
-			if dirErr != nil {
-				return dirErr
-			}
-			path = filepath.Join(home, ".config", "stitch", "stitch")
-		}
+		c.storage = storage.New(fileStrategy)
+	}
+
+	return nil
+}
 
-		fileStrategy, err := storage.NewFileStrategy(path)
+// setUpLogger sets up the logger the command reports its progress through from --log-level and --log-file
+func (c *BaseCommand) setUpLogger() error {
+	level, err := logging.ParseLevel(c.flagLogLevel)
+	if err != nil {
+		return err
+	}
+
+	var file io.Writer
+	if c.flagLogFile != "" {
+		logPath, err := homedir.Expand(c.flagLogFile)
 		if err != nil {
 			return err
 		}
 
-		c.storage = storage.New(fileStrategy)
+		// the file is closed when the process exits, once every message has been written
+		f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %s", err)
+		}
+		file = f
 	}
 
+	c.logger = logging.New(c.UI, level, file)
 	return nil
 }
 
+// resolveAppDirectory returns the directory of the local app: flagAppPath, the --path the command was given,
+// if it is set, or else the directory containing workingDirectory that holds the app's config file
+func (c *BaseCommand) resolveAppDirectory(flagAppPath, workingDirectory string) (string, error) {
+	if flagAppPath != "" {
+		path, err := homedir.Expand(flagAppPath)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			return "", errors.New("directory does not exist")
+		}
+		return path, nil
+	}
+
+	return utils.GetDirectoryContainingFile(workingDirectory, models.AppConfigFileName)
+}
+
+// Log returns the logger the command reports its progress through, which displays messages of info level
+// and above on the UI until the command is run
+func (c *BaseCommand) Log() logging.Logger {
+	if c.logger == nil {
+		c.logger = logging.New(c.UI, logging.LevelInfo, nil)
+	}
+	return c.logger
+}
+
+// resolveConfigPath returns the path of the CLI config file, first moving the config and cache files out of
+// the legacy config directory if the default location has changed since they were written
+func (c *BaseCommand) resolveConfigPath() (string, error) {
+	configPath, err := utils.ResolveConfigPath(c.flagConfigPath)
+	if err != nil || c.flagConfigPath != "" {
+		return configPath, err
+	}
+
+	configDir := filepath.Dir(configPath)
+	migrated, err := utils.MigrateLegacyConfig(configDir)
+	if err != nil {
+		legacyDir, legacyErr := utils.LegacyConfigDir()
+		if legacyErr != nil {
+			return "", legacyErr
+		}
+
+		c.Log().Warn(fmt.Sprintf("failed to move CLI configuration from %s to %s, so it will continue to be read from %s: %s", legacyDir, configDir, legacyDir, err))
+		return filepath.Join(legacyDir, utils.ConfigFileName), nil
+	}
+
+	if migrated {
+		c.Log().Info(fmt.Sprintf("Moved CLI configuration to %s", configDir))
+	}
+
+	return configPath, nil
+}
+
+// cachePath returns the path of the named cache file, which is kept alongside the CLI config file
+func (c *BaseCommand) cachePath(fileName string) (string, error) {
+	configPath := c.configPath
+	if configPath == "" {
+		var err error
+		if configPath, err = utils.ResolveConfigPath(c.flagConfigPath); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(filepath.Dir(configPath), fileName), nil
+}
+
+func (c *BaseCommand) colorEnabled() bool {
+	if c.flagColorDisabled {
+		return false
+	}
+
+	// see https://no-color.org
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	return isatty.IsTerminal(os.Stdout.Fd())
+}
+
+func (c *BaseCommand) terminalUi() *terminalUi {
+	if c.terminal == nil {
+		c.terminal = newTerminalUi(c.UI, false, false)
+	}
+	return c.terminal
+}
+
+// Success logs a message reporting that the command succeeded
+func (c *BaseCommand) Success(message string) {
+	c.Log().Success(message)
+}
+
+// Diff displays a diff of proposed changes
+func (c *BaseCommand) Diff(diff string) {
+	c.terminalUi().Diff(diff)
+}
+
+// fetchApp fetches the app with the given Client App ID, looking only within the given project if projectID is set
+func fetchApp(stitchClient api.StitchClient, projectID, clientAppID string) (*models.App, error) {
+	var app *models.App
+	var err error
+	if projectID == "" {
+		app, err = stitchClient.FetchAppByClientAppID(clientAppID)
+	} else {
+		app, err = stitchClient.FetchAppByGroupIDAndClientAppID(projectID, clientAppID)
+	}
+
+	if err != nil {
+		return nil, withAppIDSuggestions(stitchClient, projectID, err)
+	}
+
+	return app, nil
+}
+
+// errNotInteractive is returned by a prompt that cannot be answered because stdin is not a terminal, naming
+// what to use instead, so that a run in CI fails rather than waiting forever for input
+func errNotInteractive(query, alternative string) error {
+	return fmt.Errorf("cannot prompt for %q as stdin is not a terminal, use %s instead", strings.TrimSuffix(query, ":"), alternative)
+}
+
+// promptable returns whether the user can answer prompts, i.e. whether the UI reads from a terminal. Input
+// that is not read from a file, such as that of a test, is assumed to be interactive
+func (c *BaseCommand) promptable() bool {
+	ui := c.UI
+	if terminal, ok := ui.(*terminalUi); ok {
+		ui = terminal.Ui
+	}
+
+	basicUI, ok := ui.(*cli.BasicUi)
+	if !ok {
+		return true
+	}
+
+	f, ok := basicUI.Reader.(*os.File)
+	if !ok {
+		return true
+	}
+
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
 // AskYesNo is used to prompt the user for yes/no input
 func (c *BaseCommand) AskYesNo(query string) (bool, error) {
 	if c.flagYes {
-		c.UI.Info(fmt.Sprintf("%s [y/n]: y", query))
+		c.Log().Info(fmt.Sprintf("%s [y/n]: y", query))
 		return true, nil
 	}
 
+	if !c.promptable() {
+		return false, errNotInteractive(query, "--yes")
+	}
+
 	res, err := c.UI.Ask(query + " [y/n]:")
 	if err != nil {
 		return false, err
@@ -240,13 +552,18 @@ func (c *BaseCommand) AskYesNo(query string) (bool, error) {
 	}
 }
 
-// Ask is used to prompt the user for input
-func (c *BaseCommand) Ask(query string, defaultVal string) (string, error) {
+// Ask is used to prompt the user for input. alternative names how the input can be given without a prompt,
+// such as a flag
+func (c *BaseCommand) Ask(query, defaultVal, alternative string) (string, error) {
 	if c.flagYes && defaultVal != "" {
-		c.UI.Info(fmt.Sprintf("%s [%s]: %s", query, defaultVal, defaultVal))
+		c.Log().Info(fmt.Sprintf("%s [%s]: %s", query, defaultVal, defaultVal))
 		return defaultVal, nil
 	}
 
+	if !c.promptable() {
+		return "", errNotInteractive(query, alternative)
+	}
+
 	var defaultClause string
 	if defaultVal != "" {
 		defaultClause = fmt.Sprintf(" [%s]", defaultVal)
@@ -275,13 +592,18 @@ func (c *BaseCommand) Ask(query string, defaultVal string) (string, error) {
 	}
 }
 
-// AskWithOptions is used to prompt user for input from a list of options
-func (c *BaseCommand) AskWithOptions(query, defaultValue string, options []string) (string, error) {
+// AskWithOptions is used to prompt user for input from a list of options. alternative names how the input can
+// be given without a prompt
+func (c *BaseCommand) AskWithOptions(query, defaultValue string, options []string, alternative string) (string, error) {
 	if c.flagYes && defaultValue != "" {
-		c.UI.Info(fmt.Sprintf("%s [%s]: %s", query, defaultValue, defaultValue))
+		c.Log().Info(fmt.Sprintf("%s [%s]: %s", query, defaultValue, defaultValue))
 		return defaultValue, nil
 	}
 
+	if !c.promptable() {
+		return "", errNotInteractive(query, alternative)
+	}
+
 	var defaultClause string
 	if defaultValue != "" {
 		defaultClause = fmt.Sprintf(" [%s]", defaultValue)
@@ -310,18 +632,55 @@ func (c *BaseCommand) AskWithOptions(query, defaultValue string, options []strin
 	}
 }
 
+// AskSecret is used to prompt the user for input that is not echoed. alternative names how the input can be
+// given without a prompt
+func (c *BaseCommand) AskSecret(query, alternative string) (string, error) {
+	if !c.promptable() {
+		return "", errNotInteractive(query, alternative)
+	}
+
+	return c.UI.AskSecret(query)
+}
+
 // Help defines help documentation for parameters that apply to all commands
 func (c *BaseCommand) Help() string {
 	return `
 
   --config-path [string]
-	File to write user configuration data to (defaults to ~/.config/stitch/stitch)
+	File to write user configuration data to, with cache files kept in the same directory. Defaults to "stitch" in $XDG_CONFIG_HOME/stitch if XDG_CONFIG_HOME is set, in %APPDATA%\stitch on Windows, and in ~/.config/stitch otherwise.
 
-  --disable-color
-	Disable the use of colors in terminal output.
+  --disable-color, --no-color
+	Disable the use of colors in terminal output. Colors are also disabled when output is not a terminal or the NO_COLOR environment variable is set.
 
   -y, --yes
-	Bypass prompts. Provide this parameter if you do not want to be prompted for input.`
+	Bypass prompts. Provide this parameter if you do not want to be prompted for input.
+
+  -q, --quiet
+	Only print errors and requested output, suppressing informational messages and warnings.
+
+  --log-level [debug|info|warn|error] (default: info)
+	The least severe messages to print and write to --log-file.
+
+  --log-file [string]
+	A file to append every printed message to, with its time and level, e.g. to keep a record of CI deploys. Prompts are not written to it.
+
+  --audit-log [string]
+	A file to append a JSON line to for every command that changes an app, recording who ran it, when, against which app, its result, and the deployment it made. Defaults to the ` + auditLogEnvVar + ` environment variable, if set.
+
+  --refresh
+	Fetch the list of projects from Atlas rather than using the copy cached from the last few minutes.
+
+  --header [string]
+	An extra header, as "Name: value", to send with every request to the Stitch API, e.g. for delegated admin credentials. May be given more than once.
+
+  --as [string]
+	Run the command as the identity stored under this name by "login --as", e.g. a bot's credentials in a deploy script, rather than as the default one. Unlike --config-path, it only changes the credentials used.
+
+  --impersonate [string]
+	Act on behalf of the given user, such as a service account, whose admin rights have been delegated to you. Sent as the ` + api.StitchImpersonateHeader + ` header.
+
+  --user-agent-suffix [string]
+	Text to append to the User-Agent of every API request, e.g. to tell CI runs apart in gateway logs. Defaults to the ` + userAgentSuffixEnvVar + ` environment variable, if set. Every request of a run also carries the same ` + api.CorrelationIDHeader + `, which is printed with --log-level=debug.`
 }
 
 func yay(s string) bool {
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"github.com/mitchellh/cli"
+)
+
+// NewCommandFactories returns the factories of every command the CLI runs, keyed by the name they are run with
+func NewCommandFactories(ui cli.Ui) map[string]cli.CommandFactory {
+	factories := map[string]cli.CommandFactory{
+		"whoami":                    NewWhoamiCommandFactory(ui),
+		"login":                     NewLoginCommandFactory(ui),
+		"logout":                    NewLogoutCommandFactory(ui),
+		"export":                    NewExportCommandFactory(ui),
+		"import":                    NewImportCommandFactory(ui),
+		"validate":                  NewValidateCommandFactory(ui),
+		"app rename":                NewAppRenameCommandFactory(ui),
+		"app stats":                 NewAppStatsCommandFactory(ui),
+		"apps list":                 NewAppsListCommandFactory(ui),
+		"hosting config get":        NewHostingConfigGetCommandFactory(ui),
+		"hosting config set":        NewHostingConfigSetCommandFactory(ui),
+		"hosting diff":              NewHostingDiffCommandFactory(ui),
+		"hosting retry":             NewHostingRetryCommandFactory(ui),
+		"hosting invalidate":        NewHostingInvalidateCommandFactory(ui),
+		"hosting headers":           NewHostingHeadersCommandFactory(ui),
+		"hosting check-links":       NewHostingCheckLinksCommandFactory(ui),
+		"hosting assets list":       NewHostingAssetsListCommandFactory(ui),
+		"hosting attrs generate":    NewHostingAttrsGenerateCommandFactory(ui),
+		"auth redirect-uris list":   NewAuthRedirectURIsListCommandFactory(ui),
+		"auth redirect-uris add":    NewAuthRedirectURIsAddCommandFactory(ui),
+		"auth redirect-uris remove": NewAuthRedirectURIsRemoveCommandFactory(ui),
+		"orgs list":                 NewOrgsListCommandFactory(ui),
+		"logs":                      NewLogsCommandFactory(ui),
+		"logs resolve":              NewLogsResolveCommandFactory(ui),
+		"logs summarize":            NewLogsSummarizeCommandFactory(ui),
+		"dev values":                NewDevValuesCommandFactory(ui),
+		"test":                      NewTestCommandFactory(ui),
+		"hooks install":             NewHooksInstallCommandFactory(ui),
+		"diff":                      NewDiffCommandFactory(ui),
+		"promote":                   NewPromoteCommandFactory(ui),
+		"bootstrap":                 NewBootstrapCommandFactory(ui),
+		"teardown":                  NewTeardownCommandFactory(ui),
+		"preview create":            NewPreviewCreateCommandFactory(ui),
+		"preview delete":            NewPreviewDeleteCommandFactory(ui),
+		"inspect":                   NewInspectCommandFactory(ui),
+	}
+
+	factories["help"] = NewHelpCommandFactory(ui, factories)
+	return factories
+}
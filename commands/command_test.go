@@ -1,11 +1,21 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/auth"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/storage"
 	"github.com/10gen/stitch-cli/user"
 	u "github.com/10gen/stitch-cli/utils/test"
 	gc "github.com/smartystreets/goconvey/convey"
@@ -30,6 +40,76 @@ func TestBaseCommandClient(t *testing.T) {
 
 		u.So(t, base.client, gc.ShouldNotBeNil)
 	})
+
+	t.Run("should send extra headers and impersonate", func(t *testing.T) {
+		var received http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header
+		}))
+		defer server.Close()
+
+		base := &BaseCommand{continueOnFlagError: true}
+		base.NewFlagSet()
+		u.So(t, base.Parse([]string{
+			"--base-url=" + server.URL,
+			"--header=X-Delegated-Credentials: abc",
+			"--header", "X-Team:  platform ",
+			"--impersonate=svc-deployer",
+		}), gc.ShouldBeNil)
+
+		client, err := base.Client()
+		u.So(t, err, gc.ShouldBeNil)
+
+		res, err := client.ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		res.Body.Close()
+
+		u.So(t, received.Get("X-Delegated-Credentials"), gc.ShouldEqual, "abc")
+		u.So(t, received.Get("X-Team"), gc.ShouldEqual, "platform")
+		u.So(t, received.Get(api.StitchImpersonateHeader), gc.ShouldEqual, "svc-deployer")
+	})
+
+	t.Run("should identify the run with its User-Agent and correlation ID", func(t *testing.T) {
+		var received []http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = append(received, r.Header)
+		}))
+		defer server.Close()
+
+		mockUI := cli.NewMockUi()
+		base := &BaseCommand{Name: "test", UI: mockUI, storage: u.NewEmptyStorage(), continueOnFlagError: true}
+		u.So(t, base.run([]string{
+			"--base-url=" + server.URL,
+			"--user-agent-suffix=ci/build-42",
+			"--log-level=debug",
+		}), gc.ShouldBeNil)
+
+		client, err := base.Client()
+		u.So(t, err, gc.ShouldBeNil)
+
+		for i := 0; i < 2; i++ {
+			res, err := client.ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+			u.So(t, err, gc.ShouldBeNil)
+			res.Body.Close()
+		}
+
+		u.So(t, received, gc.ShouldHaveLength, 2)
+		u.So(t, received[0].Get("User-Agent"), gc.ShouldEqual, "MongoDB-Stitch-CLI ci/build-42")
+
+		correlationID := received[0].Get(api.CorrelationIDHeader)
+		u.So(t, correlationID, gc.ShouldHaveLength, 32)
+		u.So(t, received[1].Get(api.CorrelationIDHeader), gc.ShouldEqual, correlationID)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Correlation ID: "+correlationID)
+	})
+
+	t.Run("should reject a malformed header", func(t *testing.T) {
+		base := &BaseCommand{continueOnFlagError: true}
+		base.NewFlagSet()
+
+		err := base.Parse([]string{"--header=no-colon"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `invalid header "no-colon"`)
+	})
 }
 
 func TestBaseCommandUser(t *testing.T) {
@@ -137,6 +217,107 @@ func TestBaseCommandAuthClient(t *testing.T) {
 	})
 }
 
+func TestBaseCommandAuthClientExpiry(t *testing.T) {
+	setup := func(accessToken, refreshToken string) (*BaseCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		mockClient := u.NewMockClient([]*http.Response{
+			{
+				StatusCode: http.StatusCreated,
+				Body:       u.NewAuthResponseBody(auth.Response{AccessToken: u.GenerateToken(time.Now().Add(30 * time.Minute))}),
+			},
+		})
+
+		usr := &user.User{}
+		usr.SetAccessToken(accessToken)
+		usr.SetRefreshToken(refreshToken)
+		return &BaseCommand{UI: mockUI, user: usr, client: mockClient, storage: u.NewEmptyStorage()}, mockUI
+	}
+
+	t.Run("it refreshes an access token that is about to expire", func(t *testing.T) {
+		base, _ := setup(u.GenerateToken(time.Now().Add(time.Minute)), u.GenerateToken(time.Now().Add(30*24*time.Hour)))
+
+		_, err := base.AuthClient()
+		u.So(t, err, gc.ShouldBeNil)
+
+		userFromStorage, err := base.storage.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, time.Unix(userFromStorage.AccessTokenExpiresAt, 0), gc.ShouldHappenAfter, time.Now().Add(25*time.Minute))
+	})
+
+	t.Run("it keeps an access token that is not about to expire", func(t *testing.T) {
+		accessToken := u.GenerateToken(time.Now().Add(time.Hour))
+		base, mockUI := setup(accessToken, u.GenerateToken(time.Now().Add(30*24*time.Hour)))
+
+		_, err := base.AuthClient()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, base.user.AccessToken, gc.ShouldEqual, accessToken)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+	})
+
+	t.Run("it warns when the session is about to expire", func(t *testing.T) {
+		base, mockUI := setup(u.GenerateToken(time.Now().Add(time.Hour)), u.GenerateToken(time.Now().Add(3*time.Hour)))
+
+		_, err := base.AuthClient()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "your session expires in 3h0m0s, run 'stitch-cli login' before then")
+	})
+
+	t.Run("it fails once the session has expired", func(t *testing.T) {
+		base, _ := setup(u.GenerateToken(time.Now().Add(-time.Hour)), u.GenerateToken(time.Now().Add(-time.Minute)))
+
+		_, err := base.AuthClient()
+		u.So(t, err, gc.ShouldEqual, user.ErrSessionExpired)
+	})
+}
+
+func TestBaseCommandUserAs(t *testing.T) {
+	newStorage := func() *storage.Storage {
+		s := u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		if err := s.WriteIdentity("deploy-bot", &user.User{PublicAPIKey: "bot-public", PrivateAPIKey: "bot-private-key"}); err != nil {
+			panic(err)
+		}
+		return s
+	}
+
+	t.Run("it uses the default identity without --as", func(t *testing.T) {
+		base := &BaseCommand{Name: "test", UI: cli.NewMockUi(), storage: newStorage(), continueOnFlagError: true}
+		u.So(t, base.run([]string{}), gc.ShouldBeNil)
+
+		usr, err := base.User()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, usr.PublicAPIKey, gc.ShouldEqual, "user.name")
+	})
+
+	t.Run("it uses the identity selected with --as, and saves it there", func(t *testing.T) {
+		base := &BaseCommand{Name: "test", UI: cli.NewMockUi(), storage: newStorage(), continueOnFlagError: true}
+		u.So(t, base.run([]string{"--as=deploy-bot"}), gc.ShouldBeNil)
+
+		usr, err := base.User()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, usr.PublicAPIKey, gc.ShouldEqual, "bot-public")
+
+		usr.SetAccessToken("bot.access.token")
+		u.So(t, base.saveUser(usr), gc.ShouldBeNil)
+
+		identity, err := base.storage.ReadIdentity("deploy-bot")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, identity.AccessToken, gc.ShouldEqual, "bot.access.token")
+
+		defaultUser, err := base.storage.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, defaultUser.AccessToken, gc.ShouldNotEqual, "bot.access.token")
+	})
+
+	t.Run("it fails for an identity that is not stored", func(t *testing.T) {
+		base := &BaseCommand{Name: "test", UI: cli.NewMockUi(), storage: newStorage(), continueOnFlagError: true}
+		u.So(t, base.run([]string{"--as=alice"}), gc.ShouldBeNil)
+
+		_, err := base.User()
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, "no identity named 'alice' is stored, run 'stitch-cli login --as=alice' to log in as it")
+	})
+}
+
 func TestBaseCommandAsk(t *testing.T) {
 	t.Run("should handle valid input", func(t *testing.T) {
 		type testCase struct {
@@ -236,4 +417,177 @@ func TestBaseCommandAsk(t *testing.T) {
 			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Could not understand response")
 		}
 	})
+
+	t.Run("should fail without waiting for input when stdin is not a terminal", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		u.So(t, err, gc.ShouldBeNil)
+		defer r.Close()
+		defer w.Close()
+
+		baseCommand := &BaseCommand{
+			UI: &cli.BasicUi{Reader: r, Writer: ioutil.Discard, ErrorWriter: ioutil.Discard},
+		}
+
+		_, err = baseCommand.AskYesNo("Please confirm the changes shown above:")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, `cannot prompt for "Please confirm the changes shown above" as stdin is not a terminal, use --yes instead`)
+
+		_, err = baseCommand.Ask("App name", "", "--app-name")
+		u.So(t, err.Error(), gc.ShouldEqual, `cannot prompt for "App name" as stdin is not a terminal, use --app-name instead`)
+
+		_, err = baseCommand.AskSecret("Password:", "--password")
+		u.So(t, err.Error(), gc.ShouldEqual, `cannot prompt for "Password" as stdin is not a terminal, use --password instead`)
+
+		baseCommand.flagYes = true
+		confirmed, err := baseCommand.AskYesNo("Please confirm the changes shown above:")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, confirmed, gc.ShouldBeTrue)
+	})
+}
+
+func TestBaseCommandQuiet(t *testing.T) {
+	for _, flag := range []string{"--quiet", "-q"} {
+		t.Run("should only print errors and output with "+flag, func(t *testing.T) {
+			mockUI := cli.NewMockUi()
+			baseCommand := &BaseCommand{
+				Name:    "test",
+				UI:      mockUI,
+				storage: u.NewEmptyStorage(),
+			}
+
+			u.So(t, baseCommand.run([]string{flag}), gc.ShouldBeNil)
+
+			baseCommand.UI.Info("some info")
+			baseCommand.UI.Warn("some warning")
+			baseCommand.UI.Output("some output")
+			baseCommand.UI.Error("some error")
+
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "some output\n")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "some error\n")
+		})
+	}
+}
+
+func TestBaseCommandLogger(t *testing.T) {
+	t.Run("should reject an unknown log level", func(t *testing.T) {
+		baseCommand := &BaseCommand{
+			Name:    "test",
+			UI:      cli.NewMockUi(),
+			storage: u.NewEmptyStorage(),
+		}
+
+		err := baseCommand.run([]string{"--log-level=bogus"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `unknown log level "bogus"`)
+	})
+
+	t.Run("should only display messages at or above the log level", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		baseCommand := &BaseCommand{
+			Name:    "test",
+			UI:      mockUI,
+			storage: u.NewEmptyStorage(),
+		}
+
+		u.So(t, baseCommand.run([]string{"--log-level=warn"}), gc.ShouldBeNil)
+
+		baseCommand.Log().Debug("some debug")
+		baseCommand.Log().Info("some info")
+		baseCommand.Log().Warn("some warning")
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "some warning\n")
+	})
+
+	t.Run("should also write messages to the log file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "stitch-cli-log")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		logPath := filepath.Join(dir, "stitch.log")
+		baseCommand := &BaseCommand{
+			Name:    "test",
+			UI:      cli.NewMockUi(),
+			storage: u.NewEmptyStorage(),
+		}
+
+		u.So(t, baseCommand.run([]string{"--log-level=debug", "--log-file=" + logPath}), gc.ShouldBeNil)
+
+		baseCommand.Log().Debug("some debug")
+
+		contents, err := ioutil.ReadFile(logPath)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(contents), gc.ShouldEndWith, " DEBUG some debug\n")
+	})
+}
+
+func TestBaseCommandAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-cli-audit")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	readRecords := func(logPath string) []logging.AuditRecord {
+		contents, err := ioutil.ReadFile(logPath)
+		u.So(t, err, gc.ShouldBeNil)
+
+		var records []logging.AuditRecord
+		for _, line := range strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n") {
+			var record logging.AuditRecord
+			u.So(t, json.Unmarshal([]byte(line), &record), gc.ShouldBeNil)
+			records = append(records, record)
+		}
+		return records
+	}
+
+	t.Run("should append a record of each command to the audit log", func(t *testing.T) {
+		logPath := filepath.Join(dir, "audit.jsonl")
+		baseCommand := &BaseCommand{
+			Name:    "app rename",
+			UI:      cli.NewMockUi(),
+			storage: u.NewEmptyStorage(),
+		}
+		u.So(t, baseCommand.run([]string{"--audit-log=" + logPath}), gc.ShouldBeNil)
+
+		baseCommand.audit("my-app-abcde", "deployment-id", nil)
+		baseCommand.audit("my-app-abcde", "", errors.New("oopsies"))
+
+		records := readRecords(logPath)
+		u.So(t, records, gc.ShouldHaveLength, 2)
+		u.So(t, records[0].Command, gc.ShouldEqual, "app rename")
+		u.So(t, records[0].ClientAppID, gc.ShouldEqual, "my-app-abcde")
+		u.So(t, records[0].Result, gc.ShouldEqual, logging.AuditResultSuccess)
+		u.So(t, records[0].DeploymentID, gc.ShouldEqual, "deployment-id")
+		u.So(t, records[0].Time.IsZero(), gc.ShouldBeFalse)
+		u.So(t, records[1].Result, gc.ShouldEqual, logging.AuditResultFailure)
+		u.So(t, records[1].Error, gc.ShouldEqual, "oopsies")
+	})
+
+	t.Run("should default to the log set in the environment", func(t *testing.T) {
+		logPath := filepath.Join(dir, "env-audit.jsonl")
+		defer os.Unsetenv(auditLogEnvVar)
+		os.Setenv(auditLogEnvVar, logPath)
+
+		baseCommand := &BaseCommand{
+			Name:    "import",
+			UI:      cli.NewMockUi(),
+			storage: u.NewEmptyStorage(),
+		}
+		u.So(t, baseCommand.run([]string{}), gc.ShouldBeNil)
+
+		baseCommand.audit("my-app-abcde", "", nil)
+		u.So(t, readRecords(logPath), gc.ShouldHaveLength, 1)
+	})
+
+	t.Run("should warn without failing when the audit log cannot be written", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		baseCommand := &BaseCommand{
+			Name:    "import",
+			UI:      mockUI,
+			storage: u.NewEmptyStorage(),
+		}
+		u.So(t, baseCommand.run([]string{"--audit-log=" + filepath.Join(dir, "missing", "audit.jsonl")}), gc.ShouldBeNil)
+
+		baseCommand.audit("my-app-abcde", "", nil)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed to write to the audit log")
+	})
 }
@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	devValuesFlagPath    = "path"
+	devValuesFlagEnvFile = "env-file"
+	devValuesFlagOutput  = "output"
+
+	defaultDevEnvFile = ".env"
+)
+
+// NewDevValuesCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDevValuesCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DevValuesCommand{
+			BaseCommand: &BaseCommand{
+				Name: "dev values",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// DevValuesCommand is used to resolve an app's values and secrets from a local .env file, for running its
+// functions locally without a deployed app
+type DevValuesCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagAppPath string
+	flagEnvFile string
+	flagOutput  string
+}
+
+// devValues are the values and secrets of an app as functions would see them, resolved from a local .env file
+type devValues struct {
+	Values  map[string]interface{} `json:"values"`
+	Secrets map[string]string      `json:"secrets"`
+}
+
+// Synopsis returns a one-liner description for this command
+func (dvc *DevValuesCommand) Synopsis() string {
+	return "Resolve an app's values and secrets from a local .env file."
+}
+
+// Help returns long-form help information for this command
+func (dvc *DevValuesCommand) Help() string {
+	return `Resolve the values of an app, and the secrets they refer to, from a local .env file, so that its functions can be run and tested locally without a deployed app.
+The result is written as JSON: {"values": {"<name>": <value>, ...}, "secrets": {"<name>": "<secret>", ...}}.
+
+Each line of the .env file is KEY=VALUE. A key named after a value overrides it (as JSON if it parses as JSON, and as a string otherwise), and every other key is a secret.
+Values that come from a secret ("from_secret": true) take the secret named by their "value" from the file, which must define it.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --env-file [string] (default: ` + defaultDevEnvFile + ` in the app directory)
+	The .env file to read values and secrets from. It should not be committed.
+
+  --output [string]
+	Write the resolved values and secrets to the given file rather than printing them.` +
+		dvc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (dvc *DevValuesCommand) Run(args []string) int {
+	flags := dvc.NewFlagSet()
+
+	flags.StringVar(&dvc.flagAppPath, devValuesFlagPath, "", "")
+	flags.StringVar(&dvc.flagEnvFile, devValuesFlagEnvFile, "", "")
+	flags.StringVar(&dvc.flagOutput, devValuesFlagOutput, "", "")
+
+	if err := dvc.BaseCommand.run(args); err != nil {
+		dvc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := dvc.resolve(); err != nil {
+		dvc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (dvc *DevValuesCommand) resolve() error {
+	appPath, err := dvc.resolveAppDirectory(dvc.flagAppPath, dvc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	envPath := filepath.Join(appPath, defaultDevEnvFile)
+	if dvc.flagEnvFile != "" {
+		if envPath, err = homedir.Expand(dvc.flagEnvFile); err != nil {
+			return err
+		}
+	}
+
+	env, err := utils.ReadEnvFile(envPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", envPath, err)
+	}
+
+	app, err := utils.UnmarshalFromDir(appPath)
+	if err != nil {
+		return err
+	}
+
+	values, _ := app["values"].([]interface{})
+	resolved, err := resolveDevValues(values, env)
+	if err != nil {
+		return fmt.Errorf("%s (in %s)", err, envPath)
+	}
+
+	data, err := json.MarshalIndent(resolved, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if dvc.flagOutput == "" {
+		dvc.UI.Output(string(data))
+		return nil
+	}
+
+	outputPath, err := homedir.Expand(dvc.flagOutput)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(outputPath, data, 0600); err != nil {
+		return err
+	}
+
+	dvc.Success(fmt.Sprintf("Wrote %d value(s) and %d secret(s) to %s", len(resolved.Values), len(resolved.Secrets), dvc.flagOutput))
+	return nil
+}
+
+// resolveDevValues resolves the app's values, as loaded from its values directory, against the .env entries
+func resolveDevValues(values []interface{}, env map[string]string) (*devValues, error) {
+	resolved := &devValues{
+		Values:  map[string]interface{}{},
+		Secrets: map[string]string{},
+	}
+
+	valueNames := map[string]bool{}
+	var missing []string
+	for _, v := range values {
+		value, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := value["name"].(string)
+		valueNames[name] = true
+
+		if override, ok := env[name]; ok {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(override), &parsed); err != nil {
+				parsed = override
+			}
+			resolved.Values[name] = parsed
+			continue
+		}
+
+		if fromSecret, _ := value["from_secret"].(bool); fromSecret {
+			secretName, _ := value["value"].(string)
+			secret, ok := env[secretName]
+			if !ok {
+				missing = append(missing, fmt.Sprintf("%s (from secret %s)", name, secretName))
+				continue
+			}
+			resolved.Values[name] = secret
+			continue
+		}
+
+		resolved.Values[name] = value["value"]
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("the secrets of %d value(s) are not defined: %s", len(missing), strings.Join(missing, ", "))
+	}
+
+	for key, secret := range env {
+		if !valueNames[key] {
+			resolved.Secrets[key] = secret
+		}
+	}
+
+	return resolved, nil
+}
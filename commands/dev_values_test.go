@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestDevValuesCommand(t *testing.T) {
+	setup := func() (*DevValuesCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDevValuesCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		devValuesCommand := cmd.(*DevValuesCommand)
+		devValuesCommand.storage = u.NewEmptyStorage()
+		return devValuesCommand, mockUI
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-dev-values")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	appDir := filepath.Join(dir, "app")
+	u.So(t, os.MkdirAll(filepath.Join(appDir, "values"), 0755), gc.ShouldBeNil)
+	for name, data := range map[string]string{
+		"stitch.json":        `{"name": "my-app", "app_id": "my-app-abcdef"}`,
+		"values/plain.json":  `{"name": "plain", "value": {"retries": 3}}`,
+		"values/apiKey.json": `{"name": "apiKey", "value": "weatherApiKey", "from_secret": true}`,
+		"values/limit.json":  `{"name": "limit", "value": 10}`,
+	} {
+		u.So(t, ioutil.WriteFile(filepath.Join(appDir, name), []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	writeEnv := func(data string) {
+		u.So(t, ioutil.WriteFile(filepath.Join(appDir, ".env"), []byte(data), 0600), gc.ShouldBeNil)
+	}
+
+	t.Run("it resolves values and secrets from the app's .env file", func(t *testing.T) {
+		writeEnv("weatherApiKey=shh\nlimit=25\nDB_PASSWORD=hunter2\n")
+
+		devValuesCommand, mockUI := setup()
+		exitCode := devValuesCommand.Run([]string{"--path=" + appDir})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+
+		var resolved devValues
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &resolved), gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldResemble, devValues{
+			Values: map[string]interface{}{
+				"plain":  map[string]interface{}{"retries": float64(3)},
+				"apiKey": "shh",
+				"limit":  float64(25),
+			},
+			Secrets: map[string]string{
+				"weatherApiKey": "shh",
+				"DB_PASSWORD":   "hunter2",
+			},
+		})
+	})
+
+	t.Run("it writes the resolved values to a file", func(t *testing.T) {
+		envPath := filepath.Join(dir, "other.env")
+		u.So(t, ioutil.WriteFile(envPath, []byte("weatherApiKey=shh"), 0600), gc.ShouldBeNil)
+		outputPath := filepath.Join(dir, "values.json")
+
+		devValuesCommand, mockUI := setup()
+		exitCode := devValuesCommand.Run([]string{"--path=" + appDir, "--env-file=" + envPath, "--output=" + outputPath})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Wrote 3 value(s) and 1 secret(s) to "+outputPath)
+
+		data, err := ioutil.ReadFile(outputPath)
+		u.So(t, err, gc.ShouldBeNil)
+
+		var resolved devValues
+		u.So(t, json.Unmarshal(data, &resolved), gc.ShouldBeNil)
+		u.So(t, resolved.Values["apiKey"], gc.ShouldEqual, "shh")
+		u.So(t, resolved.Values["limit"], gc.ShouldEqual, 10)
+	})
+
+	t.Run("it fails when a value's secret is not defined", func(t *testing.T) {
+		writeEnv("DB_PASSWORD=hunter2\n")
+
+		devValuesCommand, mockUI := setup()
+		exitCode := devValuesCommand.Run([]string{"--path=" + appDir})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the secrets of 1 value(s) are not defined: apiKey (from secret weatherApiKey)")
+	})
+
+	t.Run("it fails without a .env file", func(t *testing.T) {
+		devValuesCommand, mockUI := setup()
+		exitCode := devValuesCommand.Run([]string{"--path=" + appDir, "--env-file=" + filepath.Join(dir, "missing.env")})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed to read "+filepath.Join(dir, "missing.env"))
+	})
+}
@@ -0,0 +1,276 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/diff"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	diffFlagRemoteAppID  = "remote-app-id"
+	diffFlagRemoteAppID2 = "remote-app-id2"
+	diffFlagProjectID2   = "project-id2"
+	diffFlagGroupByOwner = "group-by-owner"
+	diffFlagOwnersPath   = "owners-path"
+	diffFlagOwner        = "owner"
+)
+
+// unownedGroup is the heading of the changes to entities that no owner is given to
+const unownedGroup = "(no owner)"
+
+var errDiffAppIDsRequired = fmt.Errorf("two App IDs (--%s=[string] and --%s=[string]) must be supplied to diff apps", diffFlagRemoteAppID, diffFlagRemoteAppID2)
+
+// NewDiffCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDiffCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &DiffCommand{
+			BaseCommand: &BaseCommand{
+				Name: "diff",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// DiffCommand is used to compare the configuration of two deployed apps
+type DiffCommand struct {
+	*BaseCommand
+
+	flagProjectID    string
+	flagProjectID2   string
+	flagAppID        string
+	flagAppID2       string
+	flagGroupByOwner string
+	flagOwnersPath   string
+	flagOwner        string
+}
+
+// Synopsis returns a one-liner description for this command
+func (dc *DiffCommand) Synopsis() string {
+	return "Show the differences between two deployed apps."
+}
+
+// Help returns long-form help information for this command
+func (dc *DiffCommand) Help() string {
+	return `Show the differences between the configuration of two deployed apps, e.g. staging and production, entity by entity, without exporting either to a local directory.
+Each line names an entity that is only in the second app (+), only in the first app (-), or in both with the listed fields differing (*). Entities are matched by name, and IDs are not compared.
+A function that was renamed, i.e. that is only in each app under a different name but with the same config and source, is shown as renamed (~).
+
+REQUIRED:
+  --` + diffFlagRemoteAppID + ` [string]
+	The App ID of the app to compare from (i.e. the name of your app followed by a unique suffix, like "my-app-nysja").
+
+  --` + diffFlagRemoteAppID2 + ` [string]
+	The App ID of the app to compare to.
+
+OPTIONS:
+  --project-id [string]
+	Lookup the first app in this project, as opposed to the apps associated with the current user profile.
+
+  --` + diffFlagProjectID2 + ` [string]
+	Lookup the second app in this project. Defaults to --project-id.
+
+  --` + diffFlagGroupByOwner + ` [string]
+	Group the changes by the owners that the given CODEOWNERS-style file gives to the changed entities, each of which is matched by its path within the app directory, e.g. "functions/myFunc" or "services/mongodb-atlas/rules/db.users".
+
+  --` + diffFlagOwnersPath + ` [string]
+	The path of the app directory relative to the CODEOWNERS-style file's root, e.g. "apps/my-app" in a monorepo.
+
+  --` + diffFlagOwner + ` [string]
+	Only show the changes to the entities annotated with "` + utils.OwnerField + `": "<owner>" in either app, e.g. "payments". The incoming webhooks and rules of a service belong to its owner unless annotated themselves.` +
+		dc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (dc *DiffCommand) Run(args []string) int {
+	flags := dc.NewFlagSet()
+
+	flags.StringVar(&dc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&dc.flagProjectID2, diffFlagProjectID2, "", "")
+	flags.StringVar(&dc.flagAppID, diffFlagRemoteAppID, "", "")
+	flags.StringVar(&dc.flagAppID2, diffFlagRemoteAppID2, "", "")
+	flags.StringVar(&dc.flagGroupByOwner, diffFlagGroupByOwner, "", "")
+	flags.StringVar(&dc.flagOwnersPath, diffFlagOwnersPath, "", "")
+	flags.StringVar(&dc.flagOwner, diffFlagOwner, "", "")
+
+	if err := dc.BaseCommand.run(args); err != nil {
+		dc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := dc.diff(); err != nil {
+		dc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (dc *DiffCommand) diff() error {
+	if dc.flagAppID == "" || dc.flagAppID2 == "" {
+		return errDiffAppIDsRequired
+	}
+
+	var codeOwners *utils.CodeOwners
+	if dc.flagGroupByOwner != "" {
+		ownersPath, err := homedir.Expand(dc.flagGroupByOwner)
+		if err != nil {
+			return err
+		}
+
+		if codeOwners, err = utils.CodeOwnersFileToCodeOwners(ownersPath); err != nil {
+			return fmt.Errorf("failed to read owners from %s: %s", dc.flagGroupByOwner, err)
+		}
+	}
+
+	projectID2 := dc.flagProjectID2
+	if projectID2 == "" {
+		projectID2 = dc.flagProjectID
+	}
+
+	user, err := dc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := dc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	from, err := loadRemoteApp(stitchClient, dc.flagProjectID, dc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	to, err := loadRemoteApp(stitchClient, projectID2, dc.flagAppID2)
+	if err != nil {
+		return err
+	}
+
+	diffs := diff.Apps(from, to)
+	if dc.flagOwner != "" {
+		diffs = ownedChanges(diffs, from, to, dc.flagOwner)
+	}
+
+	if len(diffs) == 0 {
+		if dc.flagOwner != "" {
+			dc.Log().Info(fmt.Sprintf("'%s' and '%s' have the same configuration for the entities of %s", dc.flagAppID, dc.flagAppID2, dc.flagOwner))
+			return nil
+		}
+		dc.Log().Info(fmt.Sprintf("'%s' and '%s' have the same configuration", dc.flagAppID, dc.flagAppID2))
+		return nil
+	}
+
+	lines := []string{"--- " + dc.flagAppID, "+++ " + dc.flagAppID2}
+	if codeOwners == nil {
+		for _, d := range diffs {
+			lines = append(lines, d.String())
+		}
+	} else {
+		lines = append(lines, groupDiffsByOwner(diffs, codeOwners, dc.flagOwnersPath)...)
+	}
+	dc.Diff(strings.Join(lines, "\n"))
+
+	return nil
+}
+
+// ownedChanges returns the changes to the entities that owner owns in either app
+func ownedChanges(diffs []diff.Change, from, to map[string]interface{}, owner string) []diff.Change {
+	fromOwners := utils.EntityOwners(from)
+	toOwners := utils.EntityOwners(to)
+
+	var owned []diff.Change
+	for _, d := range diffs {
+		fromEntity := d.Entity
+		if d.Kind == diff.Renamed {
+			fromEntity = d.From
+		}
+
+		if fromOwners[fromEntity] == owner || toOwners[d.Entity] == owner {
+			owned = append(owned, d)
+		}
+	}
+	return owned
+}
+
+// groupDiffsByOwner formats the differences under a "# owners" heading for each set of owners, in order,
+// followed by those that have no owner. Entities are matched to owners by their paths under ownersPath
+func groupDiffsByOwner(diffs []diff.Change, codeOwners *utils.CodeOwners, ownersPath string) []string {
+	groups := map[string][]string{}
+	for _, d := range diffs {
+		owners := strings.Join(codeOwners.Owners(path.Join(filepath.ToSlash(ownersPath), d.Entity)), " ")
+		if owners == "" {
+			owners = unownedGroup
+		}
+		groups[owners] = append(groups[owners], d.String())
+	}
+
+	headings := make([]string, 0, len(groups))
+	for heading := range groups {
+		if heading != unownedGroup {
+			headings = append(headings, heading)
+		}
+	}
+	sort.Strings(headings)
+	if _, ok := groups[unownedGroup]; ok {
+		headings = append(headings, unownedGroup)
+	}
+
+	var lines []string
+	for _, heading := range headings {
+		lines = append(lines, "# "+heading)
+		lines = append(lines, groups[heading]...)
+	}
+	return lines
+}
+
+// loadRemoteApp exports the deployed app with the given Client App ID and loads it as UnmarshalFromDir would
+// from a local directory
+func loadRemoteApp(stitchClient api.StitchClient, projectID, clientAppID string) (map[string]interface{}, error) {
+	app, err := fetchApp(stitchClient, projectID, clientAppID)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadDeployedApp(stitchClient, app)
+}
+
+// loadDeployedApp exports app and loads it as UnmarshalFromDir would from a local directory
+func loadDeployedApp(stitchClient api.StitchClient, app *models.App) (map[string]interface{}, error) {
+	_, body, err := stitchClient.Export(app.GroupID, app.ID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export '%s': %s", app.ClientAppID, err)
+	}
+	defer body.Close()
+
+	dir, err := ioutil.TempDir("", "stitch-diff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	appDir := filepath.Join(dir, "app")
+	if err := utils.WriteAppToDir(appDir, body, false); err != nil {
+		return nil, fmt.Errorf("failed to read the export of '%s': %s", app.ClientAppID, err)
+	}
+
+	return utils.UnmarshalFromDir(appDir)
+}
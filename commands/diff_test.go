@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+// newAppZip builds an app export holding the given files, along with the directories containing them
+func newAppZip(t *testing.T, files map[string]string) []byte {
+	entries := map[string]string{}
+	for name, data := range files {
+		entries[name] = data
+		for dir := name; strings.Contains(dir, "/"); {
+			dir = dir[:strings.LastIndex(dir, "/")]
+			entries[dir+"/"] = ""
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var zipData bytes.Buffer
+	zw := zip.NewWriter(&zipData)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		u.So(t, err, gc.ShouldBeNil)
+		_, err = w.Write([]byte(entries[name]))
+		u.So(t, err, gc.ShouldBeNil)
+	}
+	u.So(t, zw.Close(), gc.ShouldBeNil)
+
+	return zipData.Bytes()
+}
+
+func TestDiffCommand(t *testing.T) {
+	setup := func() (*DiffCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDiffCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		diffCommand := cmd.(*DiffCommand)
+		diffCommand.storage = u.NewEmptyStorage()
+		diffCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		return diffCommand, mockUI
+	}
+
+	staging := newAppZip(t, map[string]string{
+		"stitch.json":                            `{"app_id": "staging-abcde", "name": "staging", "location": "US-VA"}`,
+		"values/limit.json":                      `{"id": "1", "name": "limit", "value": 10}`,
+		"values/old.json":                        `{"id": "2", "name": "old", "value": true}`,
+		"functions/sum/config.json":              `{"id": "3", "name": "sum", "private": false}`,
+		"functions/sum/source.js":                `exports = (a, b) => a + b;`,
+		"services/http1/config.json":             `{"id": "4", "name": "http1", "type": "http", "config": {}}`,
+		"services/http1/rules/get.json":          `{"id": "5", "name": "get", "actions": ["get"]}`,
+		"services/mongodb-atlas/config.json":     `{"id": "6", "name": "mongodb-atlas", "type": "mongodb-atlas", "config": {"clusterName": "Staging"}}`,
+		"triggers/onInsert.json":                 `{"id": "7", "name": "onInsert", "function_id": "3", "disabled": false}`,
+		"auth_providers/api-key.json":            `{"id": "8", "name": "api-key", "type": "api-key", "disabled": false}`,
+		"services/http1/incoming_webhooks/.keep": ``,
+	})
+
+	prod := newAppZip(t, map[string]string{
+		"stitch.json":                        `{"app_id": "prod-fghij", "name": "prod", "location": "US-VA"}`,
+		"values/limit.json":                  `{"id": "11", "name": "limit", "value": 100}`,
+		"functions/sum/config.json":          `{"id": "13", "name": "sum", "private": true}`,
+		"functions/sum/source.js":            `exports = (a, b) => a + b;`,
+		"functions/greet/config.json":        `{"id": "19", "name": "greet"}`,
+		"functions/greet/source.js":          `exports = () => "hi";`,
+		"services/http1/config.json":         `{"id": "14", "name": "http1", "type": "http", "config": {}}`,
+		"services/http1/rules/get.json":      `{"id": "15", "name": "get", "actions": ["get"]}`,
+		"services/mongodb-atlas/config.json": `{"id": "16", "name": "mongodb-atlas", "type": "mongodb-atlas", "config": {"clusterName": "Prod"}}`,
+		"triggers/onInsert.json":             `{"id": "17", "name": "onInsert", "function_id": "13", "disabled": false}`,
+		"auth_providers/api-key.json":        `{"id": "18", "name": "api-key", "type": "api-key", "disabled": false}`,
+	})
+
+	newStitchClient := func(exports map[string][]byte) *u.MockStitchClient {
+		return &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: clientAppID + "-id", ClientAppID: clientAppID}, nil
+			},
+			ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+				return appID + ".zip", u.NewResponseBody(bytes.NewReader(exports[appID])), nil
+			},
+		}
+	}
+
+	t.Run("it requires two app IDs", func(t *testing.T) {
+		diffCommand, mockUI := setup()
+		exitCode := diffCommand.Run([]string{"--remote-app-id=staging-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errDiffAppIDsRequired.Error())
+	})
+
+	t.Run("it prints the entities that differ", func(t *testing.T) {
+		diffCommand, mockUI := setup()
+		diffCommand.stitchClient = newStitchClient(map[string][]byte{"staging-abcde-id": staging, "prod-fghij-id": prod})
+
+		exitCode := diffCommand.Run([]string{"--remote-app-id=staging-abcde", "--remote-app-id2=prod-fghij"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"--- staging-abcde",
+			"+++ prod-fghij",
+			"+ functions/greet",
+			"* functions/sum: .config.private",
+			"* services/mongodb-atlas: .config.clusterName",
+			"* values/limit: .value",
+			"- values/old",
+		}, "\n")+"\n")
+	})
+
+	t.Run("it groups the entities that differ by owner", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "stitch-diff-owners")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		ownersPath := filepath.Join(dir, "CODEOWNERS")
+		u.So(t, ioutil.WriteFile(ownersPath, []byte(strings.Join([]string{
+			"# owners of the app",
+			"/apps/my-app/functions/ @fns",
+			"/apps/my-app/functions/greet @fns @greeters",
+			"values @config",
+		}, "\n")), 0644), gc.ShouldBeNil)
+
+		diffCommand, mockUI := setup()
+		diffCommand.stitchClient = newStitchClient(map[string][]byte{"staging-abcde-id": staging, "prod-fghij-id": prod})
+
+		exitCode := diffCommand.Run([]string{
+			"--remote-app-id=staging-abcde",
+			"--remote-app-id2=prod-fghij",
+			"--group-by-owner=" + ownersPath,
+			"--owners-path=apps/my-app",
+		})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"--- staging-abcde",
+			"+++ prod-fghij",
+			"# @config",
+			"* values/limit: .value",
+			"- values/old",
+			"# @fns",
+			"* functions/sum: .config.private",
+			"# @fns @greeters",
+			"+ functions/greet",
+			"# (no owner)",
+			"* services/mongodb-atlas: .config.clusterName",
+		}, "\n")+"\n")
+	})
+
+	t.Run("it only prints the entities of the given owner", func(t *testing.T) {
+		owned := newAppZip(t, map[string]string{
+			"stitch.json":                        `{"app_id": "prod-fghij", "name": "prod", "location": "US-VA"}`,
+			"values/limit.json":                  `{"id": "11", "name": "limit", "value": 100, "x-owner": "config"}`,
+			"functions/sum/config.json":          `{"id": "13", "name": "sum", "private": true, "x-owner": "payments"}`,
+			"functions/sum/source.js":            `exports = (a, b) => a + b;`,
+			"functions/greet/config.json":        `{"id": "19", "name": "greet", "x-owner": "web"}`,
+			"functions/greet/source.js":          `exports = () => "hi";`,
+			"services/http1/config.json":         `{"id": "14", "name": "http1", "type": "http", "config": {}, "x-owner": "payments"}`,
+			"services/http1/rules/get.json":      `{"id": "15", "name": "get", "actions": ["post"]}`,
+			"services/mongodb-atlas/config.json": `{"id": "16", "name": "mongodb-atlas", "type": "mongodb-atlas", "config": {"clusterName": "Prod"}}`,
+			"triggers/onInsert.json":             `{"id": "17", "name": "onInsert", "function_id": "13", "disabled": false}`,
+			"auth_providers/api-key.json":        `{"id": "18", "name": "api-key", "type": "api-key", "disabled": false}`,
+		})
+
+		diffCommand, mockUI := setup()
+		diffCommand.stitchClient = newStitchClient(map[string][]byte{"staging-abcde-id": staging, "prod-fghij-id": owned})
+
+		exitCode := diffCommand.Run([]string{"--remote-app-id=staging-abcde", "--remote-app-id2=prod-fghij", "--owner=payments"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"--- staging-abcde",
+			"+++ prod-fghij",
+			"* functions/sum: .config.private, .config.x-owner",
+			"* services/http1: .x-owner",
+			"* services/http1/rules/get: .actions",
+		}, "\n")+"\n")
+
+		diffCommand, mockUI = setup()
+		diffCommand.stitchClient = newStitchClient(map[string][]byte{"staging-abcde-id": staging, "prod-fghij-id": owned})
+
+		exitCode = diffCommand.Run([]string{"--remote-app-id=staging-abcde", "--remote-app-id2=prod-fghij", "--owner=billing"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "'staging-abcde' and 'prod-fghij' have the same configuration for the entities of billing")
+	})
+
+	t.Run("it reports apps with the same configuration", func(t *testing.T) {
+		diffCommand, mockUI := setup()
+		diffCommand.stitchClient = newStitchClient(map[string][]byte{"staging-abcde-id": staging, "prod-fghij-id": staging})
+
+		exitCode := diffCommand.Run([]string{"--remote-app-id=staging-abcde", "--remote-app-id2=prod-fghij"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "'staging-abcde' and 'prod-fghij' have the same configuration")
+	})
+}
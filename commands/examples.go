@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cliName is the name the CLI is invoked by in example invocations
+const cliName = "stitch-cli"
+
+// Example is a runnable invocation of a command, shown by "help <command> --examples"
+type Example struct {
+	Description string
+	Args        []string
+}
+
+// Invocation returns the full command line for the example of the named command
+func (e Example) Invocation(command string) string {
+	return strings.Join(append([]string{cliName, command}, e.Args...), " ")
+}
+
+// commandExamples holds the examples of each command, keyed by the name the command is registered under.
+// Every example is parsed by the command it belongs to in the tests, so the flags shown here must exist
+var commandExamples = map[string][]Example{
+	"import": {
+		{
+			Description: "Deploy an existing app from CI without being prompted, replacing anything missing locally",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--strategy=replace", "--no-create", "--yes"},
+		},
+		{
+			Description: "Deploy the app along with its hosted assets, invalidating the CDN cache for the ones that changed",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--include-hosting", "--reset-cdn-cache", "--yes"},
+		},
+		{
+			Description: "Deploy the app along with its hosted assets, only invalidating the CDN cache for the HTML pages that changed",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--include-hosting", "--invalidate-path=/*.html", "--invalidate-path=/*/*.html"},
+		},
+		{
+			Description: "Deploy only the hosted assets of the app, leaving its backend config as deployed",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--hosting-only", "--reset-cdn-cache"},
+		},
+		{
+			Description: "Deploy an app whose functions are written in TypeScript, keeping their source maps for reading stack traces",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--build-functions"},
+		},
+		{
+			Description: "Deploy only the entities owned by the payments team, leaving those of other teams as deployed",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./payments", "--owner=payments"},
+		},
+		{
+			Description: "Deploy only the entities tagged billing, along with the functions, services and values they depend on",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--tag=billing"},
+		},
+		{
+			Description: "Deploy new services along with the incoming webhooks and rules that belong to them, creating the services first",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--ordered"},
+		},
+		{
+			Description: "Create a new app in an Atlas project from a local directory",
+			Args:        []string{"--path=./my-app", "--app-name=my-app", "--project-id=5a1b2c3d4e5f6a7b8c9d0e1f"},
+		},
+	},
+	"export": {
+		{
+			Description: "Export an app, including its hosted assets, to a local directory",
+			Args:        []string{"--app-id=my-app-abcde", "--output=./my-app", "--include-hosting"},
+		},
+		{
+			Description: "Export an app as a template, without its IDs, for creating new apps from",
+			Args:        []string{"--app-id=my-app-abcde", "--output=./my-app-template", "--as-template"},
+		},
+		{
+			Description: "Keep a git repository up to date with changes made to an app in the UI",
+			Args:        []string{"--app-id=my-app-abcde", "--output=./my-app", "--watch-remote", "--poll-interval=5m", "--commit"},
+		},
+		{
+			Description: "Export only the entities owned by the payments team in an app shared by several teams",
+			Args:        []string{"--app-id=my-app-abcde", "--output=./payments", "--owner=payments"},
+		},
+	},
+	"app stats": {
+		{
+			Description: "Load the daily usage of an app over a month into a capacity dashboard",
+			Args:        []string{"--app-id=my-app-abcde", "--start=2018-03-01", "--end=2018-04-01", "--output=csv"},
+		},
+	},
+	"apps list": {
+		{
+			Description: "Export the apps of an Organization to a spreadsheet",
+			Args:        []string{"--org=Engineering", "--output=csv", "--columns=name,id,project"},
+		},
+	},
+	"hosting assets list": {
+		{
+			Description: "List the size of each hosted asset as CSV",
+			Args:        []string{"--app-id=my-app-abcde", "--output=csv", "--columns=path,size"},
+		},
+		{
+			Description: "List the largest hosted assets first",
+			Args:        []string{"--app-id=my-app-abcde", "--columns=path,size", "--sort=size:desc"},
+		},
+	},
+	"validate": {
+		{
+			Description: "Check an app's configuration against the latest schemas before deploying it",
+			Args:        []string{"--path=./my-app", "--strict", "--fetch-schemas"},
+		},
+	},
+	"diff": {
+		{
+			Description: "Compare a staging app to the production app it is promoted to",
+			Args:        []string{"--remote-app-id=my-app-staging-abcde", "--remote-app-id2=my-app-fghij"},
+		},
+		{
+			Description: "Route the review of a promotion to the teams owning each change in a monorepo",
+			Args:        []string{"--remote-app-id=my-app-staging-abcde", "--remote-app-id2=my-app-fghij", "--group-by-owner=./.github/CODEOWNERS", "--owners-path=apps/my-app"},
+		},
+		{
+			Description: "Review only the changes to the entities owned by the payments team before promoting them",
+			Args:        []string{"--remote-app-id=my-app-staging-abcde", "--remote-app-id2=my-app-fghij", "--owner=payments"},
+		},
+	},
+	"promote": {
+		{
+			Description: "Copy a staging app, along with its hosted assets, to production after reviewing the changes",
+			Args:        []string{"--from=my-app-staging-abcde", "--to=my-app-fghij", "--include=hosting"},
+		},
+	},
+	"bootstrap": {
+		{
+			Description: "Set up a staging environment from a template, linked to its own cluster and with secrets from a file",
+			Args:        []string{"--from=./my-app", "--project=my-project", "--env=staging", "--cluster=Staging", "--secrets-file=./staging.env", "--yes"},
+		},
+	},
+	"teardown": {
+		{
+			Description: "Delete the preview environment of a pull request once it is merged, from CI",
+			Args:        []string{"--app-id=my-app-pr-123-abcde", "--delete-database-users", "--yes"},
+		},
+	},
+	"preview create": {
+		{
+			Description: "Deploy the branch of pull request 123 to a preview app based on staging, from CI",
+			Args:        []string{"--pr=123", "--base-app-id=my-app-staging-abcde", "--secrets-file=./preview.env", "--yes"},
+		},
+	},
+	"preview delete": {
+		{
+			Description: "Delete the preview app of pull request 123 once it is closed",
+			Args:        []string{"--pr=123", "--base-app-id=my-app-staging-abcde", "--yes"},
+		},
+	},
+	"hosting diff": {
+		{
+			Description: "Preview the hosted asset changes a replacing import would make",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--strategy=replace"},
+		},
+		{
+			Description: "Preview what rolling the hosted assets back to a past deployment would change",
+			Args:        []string{"--app-id=my-app-abcde", "--against-deployment=5d1b4e3c2a1f0e0012345678"},
+		},
+	},
+	"hosting retry": {
+		{
+			Description: "Retry the hosted asset operations that failed during the last import",
+			Args:        []string{"--from=./stitch-hosting-retry.json", "--path=./my-app"},
+		},
+	},
+	"hosting check-links": {
+		{
+			Description: "Check for broken links before importing, accepting links to assets that stay deployed",
+			Args:        []string{"--path=./my-app", "--app-id=my-app-abcde"},
+		},
+	},
+	"hosting headers": {
+		{
+			Description: "Check which Cache-Control the home page is served with",
+			Args:        []string{"--app-id=my-app-abcde", "--path=/index.html"},
+		},
+	},
+	"hosting invalidate": {
+		{
+			Description: "Stop serving a stale copy of the home page from the CDN right away",
+			Args:        []string{"--app-id=my-app-abcde", "--path=/index.html"},
+		},
+	},
+	"hosting attrs generate": {
+		{
+			Description: "Give new hosting assets their default attributes and drop the entries of deleted ones",
+			Args:        []string{"--path=./my-app", "--prune"},
+		},
+	},
+	"auth redirect-uris add": {
+		{
+			Description: "Let a preview environment log in with Google",
+			Args:        []string{"--app-id=my-app-abcde", "--provider=oauth2-google", "--uri=https://pr-42.preview.example.com/auth/callback"},
+		},
+	},
+	"auth redirect-uris remove": {
+		{
+			Description: "Stop accepting the redirect URI of a torn down preview environment",
+			Args:        []string{"--app-id=my-app-abcde", "--provider=oauth2-google", "--uri=https://pr-42.preview.example.com/auth/callback"},
+		},
+	},
+	"logs": {
+		{
+			Description: "Follow the failed requests to an app during an incident",
+			Args:        []string{"--app-id=my-app-abcde", "--errors-only", "--tail"},
+		},
+		{
+			Description: "Show the recent failures of one function",
+			Args:        []string{"--app-id=my-app-abcde", `--filter=status>=400 && function=="checkout"`},
+		},
+	},
+	"logs resolve": {
+		{
+			Description: "Find where in the TypeScript sources and shared modules the app's recent errors were thrown",
+			Args:        []string{"--app-id=my-app-abcde", "--path=./my-app", "--limit=5"},
+		},
+		{
+			Description: "Resolve a stack trace copied from the logs in the UI",
+			Args:        []string{"--path=./my-app", "--input=-", "--function=sum"},
+		},
+	},
+	"logs summarize": {
+		{
+			Description: "Check the failure rates and execution times of an app's functions over the last hour",
+			Args:        []string{"--app-id=my-app-abcde", "--since=1h"},
+		},
+		{
+			Description: "Export a day's summary of the app's functions for a dashboard",
+			Args:        []string{"--app-id=my-app-abcde", "--since=24h", "--output=json"},
+		},
+	},
+	"inspect": {
+		{
+			Description: "List the ten largest entities and hosting assets of a deployed app",
+			Args:        []string{"--app-id=my-app-abcde", "--top=10"},
+		},
+	},
+}
+
+// formatExamples renders the examples of the named command for display
+func formatExamples(command string, examples []Example) string {
+	lines := make([]string, 0, len(examples)*3)
+	for i, example := range examples {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, fmt.Sprintf("  # %s", example.Description), "  "+example.Invocation(command))
+	}
+	return strings.Join(lines, "\n")
+}
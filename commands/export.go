@@ -1,10 +1,14 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/10gen/stitch-cli/models"
 	u "github.com/10gen/stitch-cli/user"
@@ -26,9 +30,10 @@ func NewExportCommandFactory(ui cli.Ui) cli.CommandFactory {
 
 		return &ExportCommand{
 			workingDirectory:     workingDirectory,
-			exportToDirectory:    utils.WriteZipToDir,
+			exportToDirectory:    utils.WriteAppToDir,
 			writeFileToDirectory: utils.WriteFileToDir,
 			getAssetAtURL:        getAssetAtURL,
+			encryptArchive:       utils.EncryptWithAge,
 			BaseCommand: &BaseCommand{
 				Name: "export",
 				UI:   ui,
@@ -44,13 +49,24 @@ type ExportCommand struct {
 	workingDirectory     string
 	exportToDirectory    func(dest string, zipData io.Reader, overwrite bool) error
 	writeFileToDirectory func(dest string, data io.Reader) error
-	getAssetAtURL        func(url string) (io.ReadCloser, error)
+	getAssetAtURL        func(url string, offset int64) (io.ReadCloser, int64, error)
+	encryptArchive       func(recipient, dest string, zipData io.Reader) error
 
 	flagProjectID      string
+	flagOrg            string
 	flagAppID          string
 	flagOutput         string
 	flagAsTemplate     bool
 	flagIncludeHosting bool
+	flagConcurrency    int
+	flagEncryptWith    string
+	flagRedact         bool
+	flagWatchRemote    bool
+	flagPollInterval   time.Duration
+	flagCommit         bool
+	flagSkipDisabled   bool
+	flagListDisabled   bool
+	flagOwner          string
 }
 
 // Help returns long-form help information for this command
@@ -59,12 +75,15 @@ func (ec *ExportCommand) Help() string {
 
 REQUIRED:
   --app-id [string]
-	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja"). When running in a terminal, you will be prompted to choose an app if this is omitted.
 
 OPTIONS:
   --project-id [string]
 	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.
 
+  --org [string]
+	Only offer Atlas Projects in the Organization with this name or ID when prompting for an app.
+
   -o [string], --output [string]
 	Directory to write the exported configuration. Defaults to "<app_name>_<timestamp>"
 
@@ -72,7 +91,40 @@ OPTIONS:
 	Indicate that the application should be exported as a template.
 
   --include-hosting
-	Download static assets associated with this project` +
+	Download static assets associated with this project, along with its hosting settings
+
+  --concurrency [int] (default: 4)
+	The number of static assets to download at once when using --include-hosting
+
+  --encrypt-with [string]
+	Write the app as an encrypted archive rather than a directory, e.g. "age:age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p". The
+	archive is named "<app_name>` + utils.AgeEncryptedArchiveExtension + `" unless --output is given, and can be imported with "import --path <archive> --decrypt-identity <file>".
+	Requires the age command (https://age-encryption.org) and cannot be combined with --include-hosting.
+
+  --redact
+	Replace credentials, such as OAuth client secrets of auth providers, API keys in service config and the secrets file, with "` + utils.RedactedPlaceholder + `",
+	for sharing the app's configuration safely, e.g. in bug reports. The redacted fields are listed in ` + utils.RedactionsFileName + `, and are resolved when the app is imported (see "help import").
+
+  --skip-disabled
+	Leave the triggers, auth providers and functions that are disabled out of the export, so that their config does not pile up in the app's repository.
+
+  --list-disabled
+	List the triggers, auth providers and functions that are disabled after exporting the app, or those left out of it with --skip-disabled.
+
+  --owner [string]
+	Only export the entities annotated with "` + utils.OwnerField + `": "<owner>", e.g. "payments", so that a team can work on its own part of an app shared with others.
+	The incoming webhooks and rules of a service belong to its owner unless annotated themselves, and the config of a service is kept for those it owns.
+	Cannot be combined with --encrypt-with or --watch-remote.
+
+  --watch-remote
+	Keep running after the export, checking the deployed app for changes, e.g. made in the UI, and exporting it again whenever it is deployed, until interrupted.
+	The contents of the output directory are replaced on each export, other than hidden files such as .git. Cannot be combined with --encrypt-with.
+
+  --poll-interval [duration] (default: ` + defaultWatchPollInterval.String() + `)
+	How long to wait between checks for changes with --watch-remote, e.g. "30s" or "5m".
+
+  --commit
+	Commit each export to the git repository containing the output directory, when using --watch-remote.` +
 		ec.BaseCommand.Help()
 }
 
@@ -86,19 +138,29 @@ func (ec *ExportCommand) Run(args []string) int {
 	set := ec.NewFlagSet()
 
 	set.StringVar(&ec.flagProjectID, flagProjectIDName, "", "")
+	set.StringVar(&ec.flagOrg, flagOrgName, "", "")
 	set.StringVar(&ec.flagAppID, flagAppIDName, "", "")
 	set.StringVar(&ec.flagOutput, "output", "", "")
 	set.StringVar(&ec.flagOutput, "o", "", "")
 	set.BoolVar(&ec.flagAsTemplate, "as-template", false, "")
 	set.BoolVar(&ec.flagIncludeHosting, "include-hosting", false, "")
+	set.IntVar(&ec.flagConcurrency, "concurrency", numWorkers, "")
+	set.StringVar(&ec.flagEncryptWith, "encrypt-with", "", "")
+	set.BoolVar(&ec.flagRedact, "redact", false, "")
+	set.BoolVar(&ec.flagWatchRemote, "watch-remote", false, "")
+	set.DurationVar(&ec.flagPollInterval, "poll-interval", defaultWatchPollInterval, "")
+	set.BoolVar(&ec.flagCommit, "commit", false, "")
+	set.BoolVar(&ec.flagSkipDisabled, "skip-disabled", false, "")
+	set.BoolVar(&ec.flagListDisabled, "list-disabled", false, "")
+	set.StringVar(&ec.flagOwner, "owner", "", "")
 
 	if err := ec.BaseCommand.run(args); err != nil {
-		ec.UI.Error(err.Error())
+		ec.Log().Error(err.Error())
 		return 1
 	}
 
 	if err := ec.run(); err != nil {
-		ec.UI.Error(err.Error())
+		ec.Log().Error(err.Error())
 		return 1
 	}
 
@@ -106,10 +168,50 @@ func (ec *ExportCommand) Run(args []string) int {
 }
 
 func (ec *ExportCommand) run() error {
-	if ec.flagAppID == "" {
+	if ec.flagAppID == "" && ec.selectOption == nil {
 		return errAppIDRequired
 	}
 
+	if ec.flagConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", ec.flagConcurrency)
+	}
+
+	if ec.flagCommit && !ec.flagWatchRemote {
+		return errors.New("--commit requires --watch-remote")
+	}
+
+	if ec.flagWatchRemote && ec.flagPollInterval <= 0 {
+		return fmt.Errorf("--poll-interval must be positive, got %s", ec.flagPollInterval)
+	}
+
+	if ec.flagWatchRemote && ec.flagOwner != "" {
+		return errors.New("--owner cannot be combined with --watch-remote")
+	}
+
+	var recipient string
+	if ec.flagEncryptWith != "" {
+		if ec.flagWatchRemote {
+			return errors.New("--encrypt-with cannot be combined with --watch-remote")
+		}
+		if ec.flagIncludeHosting {
+			return errors.New("--encrypt-with cannot be combined with --include-hosting")
+		}
+		if ec.flagRedact {
+			return errors.New("--encrypt-with cannot be combined with --redact")
+		}
+		if ec.flagSkipDisabled || ec.flagListDisabled {
+			return errors.New("--encrypt-with cannot be combined with --skip-disabled or --list-disabled")
+		}
+		if ec.flagOwner != "" {
+			return errors.New("--encrypt-with cannot be combined with --owner")
+		}
+
+		var err error
+		if recipient, err = utils.ParseEncryptionRecipient(ec.flagEncryptWith); err != nil {
+			return err
+		}
+	}
+
 	user, err := ec.User()
 	if err != nil {
 		return err
@@ -129,13 +231,13 @@ func (ec *ExportCommand) run() error {
 	}
 
 	var app *models.App
-	if ec.flagProjectID == "" {
-		app, err = stitchClient.FetchAppByClientAppID(ec.flagAppID)
+	if ec.flagAppID == "" {
+		app, err = ec.selectApp(ec.flagProjectID, ec.flagOrg, stitchClient)
 		if err != nil {
 			return err
 		}
 	} else {
-		app, err = stitchClient.FetchAppByGroupIDAndClientAppID(ec.flagProjectID, ec.flagAppID)
+		app, err = fetchApp(stitchClient, ec.flagProjectID, ec.flagAppID)
 		if err != nil {
 			return err
 		}
@@ -155,10 +257,42 @@ func (ec *ExportCommand) run() error {
 		filename = filename[:lastUnderscoreIdx]
 	}
 
+	if recipient != "" {
+		return ec.exportEncryptedArchive(recipient, filename, body)
+	}
+
+	if ec.flagWatchRemote {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		defer signal.Stop(stop)
+
+		return ec.watchRemote(stitchClient, app, filename, body, stop)
+	}
+
 	if err := ec.exportToDirectory(filename, body, false); err != nil {
 		return err
 	}
 
+	if ec.flagOwner != "" {
+		removed, err := utils.RemoveUnownedEntities(filename, ec.flagOwner)
+		if err != nil {
+			return fmt.Errorf("failed to remove the entities not owned by %s: %s", ec.flagOwner, err)
+		}
+		ec.Log().Info(fmt.Sprintf("Left %d entities not owned by %s out of the export", len(removed), ec.flagOwner))
+	}
+
+	if err := ec.handleDisabled(filename); err != nil {
+		return err
+	}
+
+	if ec.flagRedact {
+		redacted, err := utils.RedactAppDir(filename)
+		if err != nil {
+			return fmt.Errorf("failed to redact app: %s", err)
+		}
+		ec.Log().Info(fmt.Sprintf("Redacted %d sensitive field(s), listed in %s", len(redacted), filepath.Join(filename, utils.RedactionsFileName)))
+	}
+
 	if ec.flagIncludeHosting {
 		if err := exportStaticHostingAssets(stitchClient, ec, filename, app); err != nil {
 			return err
@@ -166,3 +300,53 @@ func (ec *ExportCommand) run() error {
 	}
 	return nil
 }
+
+// exportEncryptedArchive writes the app archive, encrypted to recipient, to the file at dest or, when no
+// --output is given, to dest with an extension identifying it as an encrypted archive
+func (ec *ExportCommand) exportEncryptedArchive(recipient, dest string, zipData io.Reader) error {
+	if ec.flagOutput == "" {
+		dest += utils.AgeEncryptedArchiveExtension
+	}
+
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("failed to create archive %q: file already exists", dest)
+	}
+
+	if err := ec.encryptArchive(recipient, dest, zipData); err != nil {
+		return fmt.Errorf("failed to encrypt archive: %s", err)
+	}
+
+	return nil
+}
+
+// handleDisabled removes the disabled entities of the app exported to dir with --skip-disabled, and lists
+// them with --list-disabled
+func (ec *ExportCommand) handleDisabled(dir string) error {
+	if !ec.flagSkipDisabled && !ec.flagListDisabled {
+		return nil
+	}
+
+	listDisabled := utils.ListDisabled
+	if ec.flagSkipDisabled {
+		listDisabled = utils.RemoveDisabled
+	}
+
+	disabled, err := listDisabled(dir)
+	if err != nil {
+		return fmt.Errorf("failed to find disabled entities: %s", err)
+	}
+
+	if ec.flagListDisabled {
+		for _, entity := range disabled {
+			ec.Log().Info(fmt.Sprintf("Disabled %s '%s' (%s)", strings.Replace(string(entity.Kind), "_", " ", -1), entity.Name, entity.Path))
+		}
+	}
+
+	if ec.flagSkipDisabled {
+		ec.Log().Info(fmt.Sprintf("Left %d disabled trigger(s), auth provider(s) and function(s) out of the export", len(disabled)))
+	} else if len(disabled) == 0 {
+		ec.Log().Info("The app has no disabled triggers, auth providers or functions")
+	}
+
+	return nil
+}
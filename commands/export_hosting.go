@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
 	"sync"
 
 	"github.com/10gen/stitch-cli/api"
@@ -16,16 +18,46 @@ import (
 	"github.com/10gen/stitch-cli/utils"
 )
 
-func getAssetAtURL(url string) (io.ReadCloser, error) {
-	resp, err := http.Get(url)
+const (
+	// partialDownloadSuffix is appended to the destination of an asset while it is being downloaded
+	partialDownloadSuffix = ".partial"
+
+	// maxDownloadAttempts is the number of times a single asset download is attempted before giving up
+	maxDownloadAttempts = 3
+)
+
+// getAssetAtURL requests the asset at the given URL starting from the byte offset provided. It returns
+// the body along with the offset the body actually starts at, which is 0 if the server ignored the range
+func getAssetAtURL(url string, offset int64) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	if resp.StatusCode != http.StatusOK {
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, 0, nil
+	case http.StatusPartialContent:
+		return resp.Body, offset, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is no shorter than the asset, which has changed since it was written
+		resp.Body.Close()
+		if offset > 0 {
+			return getAssetAtURL(url, 0)
+		}
+		return nil, 0, fmt.Errorf("downloading asset (url: %s) failed: response status code was %d", url, resp.StatusCode)
+	default:
 		resp.Body.Close()
-		return nil, fmt.Errorf("downloading asset (url: %s) failed: response status code was %d", url, resp.StatusCode)
+		return nil, 0, fmt.Errorf("downloading asset (url: %s) failed: response status code was %d", url, resp.StatusCode)
 	}
-	return resp.Body, nil
 }
 
 func exportStaticHostingAssets(stitchClient api.StitchClient, ec *ExportCommand, appPath string, app *models.App) error {
@@ -45,6 +77,19 @@ func exportStaticHostingAssets(stitchClient api.StitchClient, ec *ExportCommand,
 		return fmt.Errorf("failed to write static hosting asset attributes file at: %s", path.Join(appPath, utils.HostingAttributes))
 	}
 
+	hostingConfig, err := stitchClient.FetchHostingConfig(app.GroupID, app.ID)
+	if err != nil {
+		return err
+	}
+	hostingConfigData, err := json.MarshalIndent(hostingConfig, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = ec.writeFileToDirectory(path.Join(appPath, utils.HostingConfig), bytes.NewReader(hostingConfigData))
+	if err != nil {
+		return fmt.Errorf("failed to write static hosting config file at: %s", path.Join(appPath, utils.HostingConfig))
+	}
+
 	// Variables for the parallelization below
 	var wg sync.WaitGroup
 	jobs := make(chan hosting.AssetMetadata)
@@ -63,10 +108,13 @@ func exportStaticHostingAssets(stitchClient api.StitchClient, ec *ExportCommand,
 	// run the error handler
 	go errChecker(errs, errorsHandlerDone)
 
+	// directories without any assets in them are given placeholders so that they survive version control
+	nonEmptyDirs := hosting.AncestorDirectories(assetMetadatas)
+
 	// Spawn the workers
-	for n := 0; n < numWorkers; n++ {
+	for n := 0; n < ec.flagConcurrency; n++ {
 		wg.Add(1)
-		go assetDownloadWorker(jobs, &wg, errs, ec, appPath)
+		go assetDownloadWorker(jobs, &wg, errs, ec, appPath, nonEmptyDirs)
 	}
 
 	// Pass in the information
@@ -87,7 +135,7 @@ func exportStaticHostingAssets(stitchClient api.StitchClient, ec *ExportCommand,
 }
 
 // function for workers to run
-func assetDownloadWorker(jobs <-chan hosting.AssetMetadata, wg *sync.WaitGroup, errs chan<- error, ec *ExportCommand, appPath string) {
+func assetDownloadWorker(jobs <-chan hosting.AssetMetadata, wg *sync.WaitGroup, errs chan<- error, ec *ExportCommand, appPath string, nonEmptyDirs map[string]bool) {
 	defer wg.Done()
 
 	for job := range jobs {
@@ -95,23 +143,89 @@ func assetDownloadWorker(jobs <-chan hosting.AssetMetadata, wg *sync.WaitGroup,
 			assetDir := path.Join(appPath, utils.HostingFilesDirectory, job.FilePath)
 			if mkdirErr := os.MkdirAll(assetDir, os.ModePerm); mkdirErr != nil {
 				errs <- fmt.Errorf("failed to create directory %q: %s", assetDir, mkdirErr)
+				continue
+			}
+
+			if job.FilePath != "/" && !nonEmptyDirs[job.FilePath] {
+				placeholder := path.Join(assetDir, utils.HostingDirectoryPlaceholder)
+				if writeErr := ioutil.WriteFile(placeholder, []byte{}, 0644); writeErr != nil {
+					errs <- fmt.Errorf("failed to create directory placeholder %q: %s", placeholder, writeErr)
+				}
 			}
 			continue
 		}
 
-		// Go get the asset at the given URL
-		reader, err := ec.getAssetAtURL(job.URL)
-		if err != nil {
+		if err := downloadAsset(ec.getAssetAtURL, job, filepath.Join(appPath, utils.HostingFilesDirectory, job.FilePath)); err != nil {
 			errs <- err
-			continue
 		}
+	}
+}
+
+// downloadAsset downloads an asset to dest by way of a partial file. If the transfer is interrupted
+// it is resumed from the last byte written, and once complete the file is verified against the asset's
+// hash before being moved into place. The partial file is removed if the download fails, so that it is
+// not left among the hosting files to be imported as an asset
+func downloadAsset(getAsset func(url string, offset int64) (io.ReadCloser, int64, error), asset hosting.AssetMetadata, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create sub-directory %q: %s", dest, err)
+	}
+
+	partialPath := dest + partialDownloadSuffix
+	f, err := os.OpenFile(partialPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %s", partialPath, err)
+	}
+
+	var written int64
+	var downloadErr error
+	for attempt := 0; attempt < maxDownloadAttempts; attempt++ {
+		written, downloadErr = resumeDownload(getAsset, asset.URL, f, written)
+		if downloadErr == nil {
+			break
+		}
+	}
 
-		// Now store the asset at the proper filePath
-		err = ec.writeFileToDirectory(path.Join(appPath, utils.HostingFilesDirectory, job.FilePath), reader)
-		reader.Close()
-		if err != nil {
-			errs <- err
-			continue
+	if closeErr := f.Close(); downloadErr == nil {
+		downloadErr = closeErr
+	}
+
+	if downloadErr != nil {
+		os.Remove(partialPath)
+		return downloadErr
+	}
+
+	if asset.FileHash != "" {
+		hash, hashErr := utils.GenerateFileHashStr(partialPath)
+		if hashErr != nil {
+			os.Remove(partialPath)
+			return hashErr
+		}
+
+		if hash != asset.FileHash {
+			os.Remove(partialPath)
+			return fmt.Errorf("downloading asset %q failed: expected hash %s but got %s", asset.FilePath, asset.FileHash, hash)
+		}
+	}
+
+	return os.Rename(partialPath, dest)
+}
+
+// resumeDownload continues writing the asset at url into f, which is opened for appending, from the given
+// offset and returns the total number of bytes written to f
+func resumeDownload(getAsset func(url string, offset int64) (io.ReadCloser, int64, error), url string, f *os.File, offset int64) (int64, error) {
+	body, start, err := getAsset(url, offset)
+	if err != nil {
+		return offset, err
+	}
+	defer body.Close()
+
+	// the server could not honor the range so the asset has to be written from the start
+	if start != offset {
+		if err := f.Truncate(start); err != nil {
+			return 0, err
 		}
 	}
+
+	n, err := io.Copy(f, body)
+	return start + n, err
 }
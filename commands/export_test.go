@@ -1,15 +1,26 @@
 package commands
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/10gen/stitch-cli/api/mdbcloud"
 	"github.com/10gen/stitch-cli/hosting"
 	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/selector"
 	"github.com/10gen/stitch-cli/user"
 	"github.com/10gen/stitch-cli/utils"
 	u "github.com/10gen/stitch-cli/utils/test"
@@ -67,6 +78,7 @@ func TestExportCommand(t *testing.T) {
 				Description          string
 				ExpectedDestination  string
 				ExpectedMetadataFile string
+				ExpectedConfigFile   string
 				Args                 []string
 
 				ExpectedGroupID                         string
@@ -115,6 +127,25 @@ func TestExportCommand(t *testing.T) {
 			}
 			expectedMetadataFile := string(assetDescriptionData)
 			expectedAssetFile := "here is my fake file it means nothing"
+			expectedConfigFile := `{
+    "enabled": true,
+    "rewrites": [
+        {
+            "from": "/*",
+            "to": "/index.html"
+        }
+    ]
+}`
+
+			// hosting assets are downloaded to the output path, so the home directory it expands from is a temporary one
+			tmpHomeDir, err := ioutil.TempDir("", "stitch-export-home")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(tmpHomeDir)
+
+			defer os.Setenv("HOME", os.Getenv("HOME"))
+			os.Setenv("HOME", tmpHomeDir)
+			homedir.DisableCache = true
+			defer func() { homedir.DisableCache = false }()
 
 			homeDir, err := homedir.Dir()
 			u.So(t, err, gc.ShouldBeNil)
@@ -173,6 +204,7 @@ func TestExportCommand(t *testing.T) {
 					ExpectedDestination:  homeDir + "/my_app",
 					Args:                 []string{`--app-id=` + appID, `--include-hosting=true`, `--output=~/my_app`},
 					ExpectedMetadataFile: expectedMetadataFile,
+					ExpectedConfigFile:   expectedConfigFile,
 
 					ExpectedGroupID:               "group-id",
 					FetchAppByClientIDInvocations: 1,
@@ -214,6 +246,12 @@ func TestExportCommand(t *testing.T) {
 
 							return zipFileName, u.NewResponseBody(strings.NewReader(zipData)), nil
 						},
+						FetchHostingConfigFn: func(groupID, appID string) (*hosting.Config, error) {
+							return &hosting.Config{
+								Enabled:  true,
+								Rewrites: []hosting.Rewrite{{From: "/*", To: "/index.html"}},
+							}, nil
+						},
 					}
 
 					exportCommand.stitchClient = &mockStitchClient
@@ -236,7 +274,7 @@ func TestExportCommand(t *testing.T) {
 					}
 
 					metadataStr := ""
-					var fileStrs []string
+					configStr := ""
 
 					exportCommand.writeFileToDirectory = func(dest string, data io.Reader) error {
 						b, err := ioutil.ReadAll(data)
@@ -246,15 +284,16 @@ func TestExportCommand(t *testing.T) {
 
 						if strings.HasSuffix(dest, utils.HostingAttributes) {
 							metadataStr = string(b)
-						} else {
-							fileStrs = append(fileStrs, string(b))
+						}
+						if strings.HasSuffix(dest, utils.HostingConfig) {
+							configStr = string(b)
 						}
 						return nil
 					}
 
-					exportCommand.getAssetAtURL = func(url string) (io.ReadCloser, error) {
+					exportCommand.getAssetAtURL = func(url string, offset int64) (io.ReadCloser, int64, error) {
 						reader := strings.NewReader("here is my fake file it means nothing")
-						return ioutil.NopCloser(reader), nil
+						return ioutil.NopCloser(reader), 0, nil
 					}
 
 					exitCode := exportCommand.Run(tc.Args)
@@ -263,8 +302,21 @@ func TestExportCommand(t *testing.T) {
 					u.So(t, destination, gc.ShouldEqual, tc.ExpectedDestination)
 					u.So(t, zipData, gc.ShouldEqual, zipData)
 					u.So(t, metadataStr, gc.ShouldEqual, tc.ExpectedMetadataFile)
-					for _, fileStr := range fileStrs {
-						u.So(t, fileStr, gc.ShouldEqual, expectedAssetFile)
+					u.So(t, configStr, gc.ShouldEqual, tc.ExpectedConfigFile)
+					if tc.ExpectedMetadataFile != "" {
+						filesDir := filepath.Join(tc.ExpectedDestination, utils.HostingFilesDirectory)
+						walkErr := filepath.Walk(filesDir, func(path string, info os.FileInfo, err error) error {
+							if err != nil || info.IsDir() {
+								return err
+							}
+							u.So(t, filepath.Ext(path), gc.ShouldNotEqual, partialDownloadSuffix)
+
+							b, err := ioutil.ReadFile(path)
+							u.So(t, err, gc.ShouldBeNil)
+							u.So(t, string(b), gc.ShouldEqual, expectedAssetFile)
+							return nil
+						})
+						u.So(t, walkErr, gc.ShouldBeNil)
 					}
 
 					u.So(t, fetchAppByClientAppID, gc.ShouldEqual, tc.FetchAppByClientIDInvocations)
@@ -273,6 +325,539 @@ func TestExportCommand(t *testing.T) {
 			}
 		})
 
+		t.Run("with --encrypt-with", func(t *testing.T) {
+			setupEncrypted := func() (*ExportCommand, *cli.MockUi) {
+				exportCommand, mockUI := setup()
+				exportCommand.stitchClient = &u.MockStitchClient{
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{ClientAppID: clientAppID, GroupID: "group-id", ID: "app-id"}, nil
+					},
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "my_app_1234", u.NewResponseBody(strings.NewReader("zip data")), nil
+					},
+				}
+				exportCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+				exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+					t.Error("should not write a directory when encrypting")
+					return nil
+				}
+				return exportCommand, mockUI
+			}
+
+			for _, tc := range []struct {
+				description  string
+				args         []string
+				expectedDest string
+			}{
+				{
+					description:  "it writes an encrypted archive named after the app",
+					args:         []string{"--app-id=my-cool-app", "--encrypt-with=age:age1recipient"},
+					expectedDest: "my_app" + utils.AgeEncryptedArchiveExtension,
+				},
+				{
+					description:  "it writes an encrypted archive to the output path",
+					args:         []string{"--app-id=my-cool-app", "--encrypt-with=age:age1recipient", "--output=snapshots/app.age"},
+					expectedDest: "snapshots/app.age",
+				},
+			} {
+				t.Run(tc.description, func(t *testing.T) {
+					exportCommand, mockUI := setupEncrypted()
+
+					var recipient, dest, data string
+					exportCommand.encryptArchive = func(r, d string, zipData io.Reader) error {
+						b, err := ioutil.ReadAll(zipData)
+						recipient, dest, data = r, d, string(b)
+						return err
+					}
+
+					exitCode := exportCommand.Run(tc.args)
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+					u.So(t, recipient, gc.ShouldEqual, "age1recipient")
+					u.So(t, dest, gc.ShouldEqual, tc.expectedDest)
+					u.So(t, data, gc.ShouldEqual, "zip data")
+				})
+			}
+
+			for _, tc := range []struct {
+				description   string
+				args          []string
+				expectedError string
+			}{
+				{
+					description:   "it fails for an unsupported scheme",
+					args:          []string{"--app-id=my-cool-app", "--encrypt-with=gpg:someone@example.com"},
+					expectedError: `unsupported encryption scheme "gpg"`,
+				},
+				{
+					description:   "it fails when combined with --include-hosting",
+					args:          []string{"--app-id=my-cool-app", "--encrypt-with=age:age1recipient", "--include-hosting"},
+					expectedError: "--encrypt-with cannot be combined with --include-hosting",
+				},
+				{
+					description:   "it fails when combined with --redact",
+					args:          []string{"--app-id=my-cool-app", "--encrypt-with=age:age1recipient", "--redact"},
+					expectedError: "--encrypt-with cannot be combined with --redact",
+				},
+			} {
+				t.Run(tc.description, func(t *testing.T) {
+					exportCommand, mockUI := setupEncrypted()
+
+					exitCode := exportCommand.Run(tc.args)
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, tc.expectedError)
+				})
+			}
+		})
+
+		t.Run("with --redact it masks credentials in the exported app", func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "stitch-export-redact")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			exportCommand, mockUI := setup()
+			exportCommand.stitchClient = &u.MockStitchClient{
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return &models.App{ClientAppID: clientAppID, GroupID: "group-id", ID: "app-id"}, nil
+				},
+				ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+					return "my_app_1234", u.NewResponseBody(strings.NewReader("zip data")), nil
+				},
+			}
+			exportCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+			exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+				providerDir := filepath.Join(dest, "auth_providers")
+				u.So(t, os.MkdirAll(providerDir, 0755), gc.ShouldBeNil)
+				u.So(t, ioutil.WriteFile(filepath.Join(dest, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+				return ioutil.WriteFile(
+					filepath.Join(providerDir, "oauth2-google.json"),
+					[]byte(`{"name": "oauth2-google", "config": {"clientId": "my-client", "clientSecret": "shh"}}`),
+					0644,
+				)
+			}
+
+			output := filepath.Join(dir, "my_app")
+			exitCode := exportCommand.Run([]string{"--app-id=my-cool-app", "--output=" + output, "--redact"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Redacted 1 sensitive field(s)")
+
+			data, err := ioutil.ReadFile(filepath.Join(output, "auth_providers", "oauth2-google.json"))
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, string(data), gc.ShouldNotContainSubstring, "shh")
+			u.So(t, string(data), gc.ShouldContainSubstring, "my-client")
+			u.So(t, utils.IsRedacted(output), gc.ShouldBeTrue)
+		})
+
+		t.Run("with --skip-disabled and --list-disabled", func(t *testing.T) {
+			setupDisabled := func(t *testing.T) (*ExportCommand, *cli.MockUi) {
+				exportCommand, mockUI := setup()
+				exportCommand.stitchClient = &u.MockStitchClient{
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{ClientAppID: clientAppID, GroupID: "group-id", ID: "app-id"}, nil
+					},
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "my_app_1234", u.NewResponseBody(strings.NewReader("zip data")), nil
+					},
+				}
+				exportCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+				exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+					triggersDir := filepath.Join(dest, "triggers")
+					u.So(t, os.MkdirAll(triggersDir, 0755), gc.ShouldBeNil)
+					u.So(t, ioutil.WriteFile(filepath.Join(dest, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+					u.So(t, ioutil.WriteFile(filepath.Join(triggersDir, "onLogin.json"), []byte(`{"name": "onLogin", "disabled": false}`), 0644), gc.ShouldBeNil)
+					return ioutil.WriteFile(filepath.Join(triggersDir, "onInsert.json"), []byte(`{"name": "onInsert", "disabled": true}`), 0644)
+				}
+				return exportCommand, mockUI
+			}
+
+			t.Run("it leaves the disabled entities out of the export", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-export-disabled")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				exportCommand, mockUI := setupDisabled(t)
+
+				output := filepath.Join(dir, "my_app")
+				exitCode := exportCommand.Run([]string{"--app-id=my-cool-app", "--output=" + output, "--skip-disabled"})
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Left 1 disabled trigger(s), auth provider(s) and function(s) out of the export")
+
+				_, err = os.Stat(filepath.Join(output, "triggers", "onInsert.json"))
+				u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+				_, err = os.Stat(filepath.Join(output, "triggers", "onLogin.json"))
+				u.So(t, err, gc.ShouldBeNil)
+			})
+
+			t.Run("it lists the disabled entities and keeps them", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-export-disabled")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				exportCommand, mockUI := setupDisabled(t)
+
+				output := filepath.Join(dir, "my_app")
+				exitCode := exportCommand.Run([]string{"--app-id=my-cool-app", "--output=" + output, "--list-disabled"})
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Disabled trigger 'onInsert' (triggers/onInsert.json)")
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "onLogin")
+
+				_, err = os.Stat(filepath.Join(output, "triggers", "onInsert.json"))
+				u.So(t, err, gc.ShouldBeNil)
+			})
+
+			t.Run("it fails when combined with --encrypt-with", func(t *testing.T) {
+				exportCommand, mockUI := setupDisabled(t)
+
+				exitCode := exportCommand.Run([]string{"--app-id=my-cool-app", "--encrypt-with=age:age1recipient", "--skip-disabled"})
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--encrypt-with cannot be combined with --skip-disabled or --list-disabled")
+			})
+		})
+
+		t.Run("with --owner", func(t *testing.T) {
+			setupOwner := func(t *testing.T) (*ExportCommand, *cli.MockUi) {
+				exportCommand, mockUI := setup()
+				exportCommand.stitchClient = &u.MockStitchClient{
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{ClientAppID: clientAppID, GroupID: "group-id", ID: "app-id"}, nil
+					},
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "my_app_1234", u.NewResponseBody(bytes.NewReader(newAppZip(t, map[string]string{
+							"stitch.json":                   `{"name": "my-app"}`,
+							"functions/charge/config.json":  `{"name": "charge", "x-owner": "payments"}`,
+							"functions/charge/source.js":    `exports = () => 1;`,
+							"functions/greet/config.json":   `{"name": "greet", "x-owner": "web"}`,
+							"functions/greet/source.js":     `exports = () => "hi";`,
+							"services/http1/config.json":    `{"name": "http1", "type": "http", "config": {}}`,
+							"services/http1/rules/pay.json": `{"name": "pay", "x-owner": "payments"}`,
+							"services/http1/rules/get.json": `{"name": "get"}`,
+						}))), nil
+					},
+				}
+				exportCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+				return exportCommand, mockUI
+			}
+
+			t.Run("it leaves the entities of other owners out of the export", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-export-owner")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				exportCommand, mockUI := setupOwner(t)
+
+				output := filepath.Join(dir, "my_app")
+				exitCode := exportCommand.Run([]string{"--app-id=my-cool-app", "--output=" + output, "--owner=payments"})
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Left 2 entities not owned by payments out of the export")
+
+				for _, path := range []string{"functions/charge/config.json", "services/http1/config.json", "services/http1/rules/pay.json"} {
+					_, err := os.Stat(filepath.Join(output, path))
+					u.So(t, err, gc.ShouldBeNil)
+				}
+				for _, path := range []string{"functions/greet", "services/http1/rules/get.json"} {
+					_, err := os.Stat(filepath.Join(output, path))
+					u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+				}
+			})
+
+			t.Run("it fails when combined with --encrypt-with", func(t *testing.T) {
+				exportCommand, mockUI := setupOwner(t)
+
+				exitCode := exportCommand.Run([]string{"--app-id=my-cool-app", "--encrypt-with=age:age1recipient", "--owner=payments"})
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--encrypt-with cannot be combined with --owner")
+			})
+		})
+
+		t.Run("with --watch-remote", func(t *testing.T) {
+			for _, tc := range []struct {
+				description   string
+				args          []string
+				expectedError string
+			}{
+				{
+					description:   "it fails when --commit is given without it",
+					args:          []string{"--app-id=my-cool-app", "--commit"},
+					expectedError: "--commit requires --watch-remote",
+				},
+				{
+					description:   "it fails when combined with --encrypt-with",
+					args:          []string{"--app-id=my-cool-app", "--watch-remote", "--encrypt-with=age:age1recipient"},
+					expectedError: "--encrypt-with cannot be combined with --watch-remote",
+				},
+				{
+					description:   "it fails when combined with --owner",
+					args:          []string{"--app-id=my-cool-app", "--watch-remote", "--owner=payments"},
+					expectedError: "--owner cannot be combined with --watch-remote",
+				},
+				{
+					description:   "it fails for a poll interval that is not positive",
+					args:          []string{"--app-id=my-cool-app", "--watch-remote", "--poll-interval=0s"},
+					expectedError: "--poll-interval must be positive, got 0s",
+				},
+			} {
+				t.Run(tc.description, func(t *testing.T) {
+					exportCommand, mockUI := setup()
+
+					exitCode := exportCommand.Run(tc.args)
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, tc.expectedError)
+				})
+			}
+
+			t.Run("it exports the app again and commits it whenever it is deployed", func(t *testing.T) {
+				if _, err := exec.LookPath(gitCommand); err != nil {
+					u.MustSkipf(t, "%s is not installed", gitCommand)
+				}
+
+				for name, value := range map[string]string{
+					"GIT_AUTHOR_NAME":     "stitch",
+					"GIT_AUTHOR_EMAIL":    "stitch@example.com",
+					"GIT_COMMITTER_NAME":  "stitch",
+					"GIT_COMMITTER_EMAIL": "stitch@example.com",
+				} {
+					os.Setenv(name, value)
+					defer os.Unsetenv(name)
+				}
+
+				dir, err := ioutil.TempDir("", "stitch-export-watch")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				_, err = gitOutput(dir, "init")
+				u.So(t, err, gc.ShouldBeNil)
+
+				stop := make(chan os.Signal)
+				deployments := []string{"deployment-1", "deployment-1", "deployment-2", "deployment-2"}
+				var polls int
+
+				exportCommand, mockUI := setup()
+				exportCommand.flagPollInterval = time.Millisecond
+				exportCommand.flagCommit = true
+				exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+					version, err := ioutil.ReadAll(r)
+					if err != nil {
+						return err
+					}
+					u.So(t, os.MkdirAll(filepath.Join(dest, "functions"), 0755), gc.ShouldBeNil)
+					u.So(t, ioutil.WriteFile(filepath.Join(dest, "functions", string(version)), version, 0644), gc.ShouldBeNil)
+					return ioutil.WriteFile(filepath.Join(dest, models.AppConfigFileName), version, 0644)
+				}
+				stitchClient := &u.MockStitchClient{
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						deployment := deployments[polls]
+						if polls++; polls == len(deployments) {
+							close(stop)
+						}
+						return &models.Deployment{ID: deployment}, nil
+					},
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "my_app_1234", u.NewResponseBody(strings.NewReader("v2")), nil
+					},
+				}
+
+				output := filepath.Join(dir, "my_app")
+				app := &models.App{ClientAppID: "my-cool-app", GroupID: "group-id", ID: "app-id"}
+				u.So(t, exportCommand.watchRemote(stitchClient, app, output, strings.NewReader("v1"), stop), gc.ShouldBeNil)
+
+				data, err := ioutil.ReadFile(filepath.Join(output, models.AppConfigFileName))
+				u.So(t, err, gc.ShouldBeNil)
+				u.So(t, string(data), gc.ShouldEqual, "v2")
+
+				_, err = os.Stat(filepath.Join(output, "functions", "v1"))
+				u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+				log, err := gitOutput(dir, "log", "--format=%s")
+				u.So(t, err, gc.ShouldBeNil)
+				u.So(t, log, gc.ShouldEqual, "Export 'my-cool-app' as of deployment deployment-2\nExport 'my-cool-app' as of deployment deployment-1")
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Stopped watching 'my-cool-app'")
+			})
+
+			t.Run("it keeps the files that are not part of the export out of the directory and its commits", func(t *testing.T) {
+				if _, err := exec.LookPath(gitCommand); err != nil {
+					u.MustSkipf(t, "%s is not installed", gitCommand)
+				}
+
+				for name, value := range map[string]string{
+					"GIT_AUTHOR_NAME":     "stitch",
+					"GIT_AUTHOR_EMAIL":    "stitch@example.com",
+					"GIT_COMMITTER_NAME":  "stitch",
+					"GIT_COMMITTER_EMAIL": "stitch@example.com",
+				} {
+					os.Setenv(name, value)
+					defer os.Unsetenv(name)
+				}
+
+				dir, err := ioutil.TempDir("", "stitch-export-watch")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				_, err = gitOutput(dir, "init")
+				u.So(t, err, gc.ShouldBeNil)
+
+				output := filepath.Join(dir, "my_app")
+				localFiles := map[string]string{
+					models.AppConfigFileName:                                   "v0",
+					filepath.Join("functions", "checkout", "source.ts"):        "export default function() {}",
+					filepath.Join("functions", "checkout", "checkout.test.js"): "test()",
+					filepath.Join("functions", "_lib", "dates.js"):             "module.exports = {}",
+					"notes.txt": "keep me",
+				}
+				for path, data := range localFiles {
+					u.So(t, os.MkdirAll(filepath.Dir(filepath.Join(output, path)), 0755), gc.ShouldBeNil)
+					u.So(t, ioutil.WriteFile(filepath.Join(output, path), []byte(data), 0644), gc.ShouldBeNil)
+				}
+
+				stop := make(chan os.Signal)
+				deployments := []string{"deployment-1", "deployment-2"}
+				var polls int
+
+				exportCommand, mockUI := setup()
+				exportCommand.flagPollInterval = time.Millisecond
+				exportCommand.flagCommit = true
+				exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+					version, err := ioutil.ReadAll(r)
+					if err != nil {
+						return err
+					}
+					functionDir := filepath.Join(dest, "functions", "checkout")
+					u.So(t, os.MkdirAll(functionDir, 0755), gc.ShouldBeNil)
+					u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "config.json"), version, 0644), gc.ShouldBeNil)
+					u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "source.js"), []byte("// built"), 0644), gc.ShouldBeNil)
+					return ioutil.WriteFile(filepath.Join(dest, models.AppConfigFileName), version, 0644)
+				}
+				stitchClient := &u.MockStitchClient{
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						deployment := deployments[polls]
+						if polls++; polls == len(deployments) {
+							close(stop)
+						}
+						return &models.Deployment{ID: deployment}, nil
+					},
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "my_app_1234", u.NewResponseBody(strings.NewReader("v2")), nil
+					},
+				}
+
+				app := &models.App{ClientAppID: "my-cool-app", GroupID: "group-id", ID: "app-id"}
+				u.So(t, exportCommand.watchRemote(stitchClient, app, output, strings.NewReader("v1"), stop), gc.ShouldBeNil)
+
+				for path, data := range localFiles {
+					if path == models.AppConfigFileName {
+						continue
+					}
+					local, err := ioutil.ReadFile(filepath.Join(output, path))
+					u.So(t, err, gc.ShouldBeNil)
+					u.So(t, string(local), gc.ShouldEqual, data)
+				}
+
+				_, err = os.Stat(filepath.Join(output, "functions", "checkout", "source.js"))
+				u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Kept the local source of "+filepath.Join("functions", "checkout"))
+
+				committed, err := gitOutput(dir, "ls-files")
+				u.So(t, err, gc.ShouldBeNil)
+				u.So(t, committed, gc.ShouldEqual, "my_app/functions/checkout/config.json\nmy_app/stitch.json")
+			})
+
+			t.Run("it removes the files an earlier watch exported and committed that the app no longer has", func(t *testing.T) {
+				if _, err := exec.LookPath(gitCommand); err != nil {
+					u.MustSkipf(t, "%s is not installed", gitCommand)
+				}
+
+				for name, value := range map[string]string{
+					"GIT_AUTHOR_NAME":     "stitch",
+					"GIT_AUTHOR_EMAIL":    "stitch@example.com",
+					"GIT_COMMITTER_NAME":  "stitch",
+					"GIT_COMMITTER_EMAIL": "stitch@example.com",
+				} {
+					os.Setenv(name, value)
+					defer os.Unsetenv(name)
+				}
+
+				dir, err := ioutil.TempDir("", "stitch-export-watch")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				_, err = gitOutput(dir, "init")
+				u.So(t, err, gc.ShouldBeNil)
+
+				output := filepath.Join(dir, "my_app")
+				commitFiles := func(message string, files map[string]string) {
+					for path, data := range files {
+						u.So(t, os.MkdirAll(filepath.Dir(filepath.Join(output, path)), 0755), gc.ShouldBeNil)
+						u.So(t, ioutil.WriteFile(filepath.Join(output, path), []byte(data), 0644), gc.ShouldBeNil)
+					}
+					_, err := gitOutput(dir, "add", "--all")
+					u.So(t, err, gc.ShouldBeNil)
+					_, err = gitOutput(dir, "commit", "--message", message)
+					u.So(t, err, gc.ShouldBeNil)
+				}
+				commitFiles("Export 'my-cool-app' as of deployment deployment-0", map[string]string{
+					models.AppConfigFileName:                        "v0",
+					filepath.Join("functions", "v0"):                "v0",
+					filepath.Join("functions", "checkout", "notes"): "exported",
+				})
+				commitFiles("Add the checkout source", map[string]string{
+					filepath.Join("functions", "checkout", "source.ts"): "export default function() {}",
+					filepath.Join("functions", "checkout", "notes"):     "edited",
+				})
+
+				stop := make(chan os.Signal)
+				exportCommand, _ := setup()
+				exportCommand.flagPollInterval = time.Millisecond
+				exportCommand.flagCommit = true
+				exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+					version, err := ioutil.ReadAll(r)
+					if err != nil {
+						return err
+					}
+					u.So(t, os.MkdirAll(filepath.Join(dest, "functions"), 0755), gc.ShouldBeNil)
+					u.So(t, ioutil.WriteFile(filepath.Join(dest, "functions", string(version)), version, 0644), gc.ShouldBeNil)
+					return ioutil.WriteFile(filepath.Join(dest, models.AppConfigFileName), version, 0644)
+				}
+				stitchClient := &u.MockStitchClient{
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						select {
+						case <-stop:
+						default:
+							close(stop)
+						}
+						return &models.Deployment{ID: "deployment-1"}, nil
+					},
+				}
+
+				app := &models.App{ClientAppID: "my-cool-app", GroupID: "group-id", ID: "app-id"}
+				u.So(t, exportCommand.watchRemote(stitchClient, app, output, strings.NewReader("v1"), stop), gc.ShouldBeNil)
+
+				_, err = os.Stat(filepath.Join(output, "functions", "v0"))
+				u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+				committed, err := gitOutput(dir, "ls-files")
+				u.So(t, err, gc.ShouldBeNil)
+				u.So(t, committed, gc.ShouldEqual, "my_app/functions/checkout/notes\nmy_app/functions/checkout/source.ts\nmy_app/functions/v1\nmy_app/stitch.json")
+
+				log, err := gitOutput(dir, "log", "--format=%s")
+				u.So(t, err, gc.ShouldBeNil)
+				u.So(t, log, gc.ShouldEqual, "Export 'my-cool-app' as of deployment deployment-1\nAdd the checkout source\nExport 'my-cool-app' as of deployment deployment-0")
+			})
+
+			t.Run("it refuses to replace a directory that does not contain an exported app", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-export-watch")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				u.So(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep me"), 0644), gc.ShouldBeNil)
+
+				exportCommand, _ := setup()
+				app := &models.App{ClientAppID: "my-cool-app", GroupID: "group-id", ID: "app-id"}
+				err = exportCommand.watchRemote(&u.MockStitchClient{}, app, dir, strings.NewReader("v1"), nil)
+				u.So(t, err, gc.ShouldNotBeNil)
+				u.So(t, err.Error(), gc.ShouldContainSubstring, "it is not empty and does not contain an exported app")
+			})
+		})
+
 		t.Run("returns an error when the response from the API is unexpected", func(t *testing.T) {
 			exportCommand, mockUI := setup()
 
@@ -300,5 +885,283 @@ func TestExportCommand(t *testing.T) {
 
 			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "oh noes")
 		})
+
+		t.Run("prompts to select a project and app when no app-id is given in a terminal", func(t *testing.T) {
+			exportCommand, mockUI := setup()
+
+			var prompts []string
+			exportCommand.selectOption = func(prompt string, options []selector.Option) (selector.Option, error) {
+				prompts = append(prompts, prompt)
+				return options[len(options)-1], nil
+			}
+			exportCommand.atlasClient = &u.MockMDBClient{
+				GroupsFn: func() ([]mdbcloud.Group, error) {
+					return []mdbcloud.Group{{ID: "group-1", Name: "First"}, {ID: "group-2", Name: "Second"}}, nil
+				},
+			}
+
+			var exportedGroupID, exportedAppID string
+			exportCommand.stitchClient = &u.MockStitchClient{
+				FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+					u.So(t, groupID, gc.ShouldEqual, "group-2")
+					return []*models.App{
+						{ID: "app-1", GroupID: groupID, ClientAppID: "first-app-abcde", Name: "first-app"},
+						{ID: "app-2", GroupID: groupID, ClientAppID: "second-app-abcde", Name: "second-app"},
+					}, nil
+				},
+				ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+					exportedGroupID, exportedAppID = groupID, appID
+					return "second_app_123456.zip", u.NewResponseBody(strings.NewReader("myZipData")), nil
+				},
+			}
+			exportCommand.user = &user.User{
+				APIKey:      "my-api-key",
+				AccessToken: u.GenerateValidAccessToken(),
+			}
+			exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+				return nil
+			}
+
+			exitCode := exportCommand.Run([]string{})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, prompts, gc.ShouldResemble, []string{"Atlas Project", "App"})
+			u.So(t, exportedGroupID, gc.ShouldEqual, "group-2")
+			u.So(t, exportedAppID, gc.ShouldEqual, "app-2")
+		})
+
+		t.Run("labels projects with their organization and filters them by --org", func(t *testing.T) {
+			exportCommand, mockUI := setup()
+
+			var projectOptions []selector.Option
+			exportCommand.selectOption = func(prompt string, options []selector.Option) (selector.Option, error) {
+				if prompt == "Atlas Project" {
+					projectOptions = options
+				}
+				return options[0], nil
+			}
+			exportCommand.atlasClient = &u.MockMDBClient{
+				GroupsFn: func() ([]mdbcloud.Group, error) {
+					return []mdbcloud.Group{
+						{ID: "group-1", Name: "Web", OrgID: "org-2"},
+						{ID: "group-2", Name: "Api", OrgID: "org-1"},
+						{ID: "group-3", Name: "Site", OrgID: "org-2"},
+					}, nil
+				},
+				OrgsFn: func() ([]mdbcloud.Org, error) {
+					return []mdbcloud.Org{{ID: "org-1", Name: "Engineering"}, {ID: "org-2", Name: "Marketing"}}, nil
+				},
+			}
+			exportCommand.stitchClient = &u.MockStitchClient{
+				FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+					return []*models.App{{ID: "app-1", GroupID: groupID, ClientAppID: "first-app-abcde", Name: "first-app"}}, nil
+				},
+				ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+					return "first_app_123456.zip", u.NewResponseBody(strings.NewReader("myZipData")), nil
+				},
+			}
+			exportCommand.user = &user.User{
+				APIKey:      "my-api-key",
+				AccessToken: u.GenerateValidAccessToken(),
+			}
+			exportCommand.exportToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+				return nil
+			}
+
+			exitCode := exportCommand.Run([]string{"--org=Marketing"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, projectOptions, gc.ShouldResemble, []selector.Option{
+				{Label: "Marketing / Web - group-1", Value: "group-1"},
+				{Label: "Marketing / Site - group-3", Value: "group-3"},
+			})
+		})
+	})
+}
+
+func TestDownloadAsset(t *testing.T) {
+	const content = "the quick brown fox jumps over the lazy dog"
+
+	contentHash := func() string {
+		f, err := ioutil.TempFile("", "stitch-asset-hash")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.Remove(f.Name())
+
+		_, err = f.WriteString(content)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, f.Close(), gc.ShouldBeNil)
+
+		hash, err := utils.GenerateFileHashStr(f.Name())
+		u.So(t, err, gc.ShouldBeNil)
+		return hash
+	}()
+
+	setup := func() string {
+		dir, err := ioutil.TempDir("", "stitch-download-asset")
+		u.So(t, err, gc.ShouldBeNil)
+		return dir
+	}
+
+	t.Run("should resume an interrupted download from the last byte written", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		var ranges []string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			if r.Header.Get("Range") == "" {
+				// advertise the full length but drop the connection halfway through
+				w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(content[:10]))
+				return
+			}
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(content[10:]))
+		}))
+		defer testServer.Close()
+
+		dest := filepath.Join(dir, "nested", "asset.txt")
+		err := downloadAsset(getAssetAtURL, hosting.AssetMetadata{FilePath: "/nested/asset.txt", URL: testServer.URL, FileHash: contentHash}, dest)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, ranges, gc.ShouldResemble, []string{"", "bytes=10-"})
+
+		b, err := ioutil.ReadFile(dest)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(b), gc.ShouldEqual, content)
+	})
+
+	t.Run("should download over a partial file left by an earlier export", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		var ranges []string
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(content))
+		}))
+		defer testServer.Close()
+
+		dest := filepath.Join(dir, "asset.txt")
+		asset := hosting.AssetMetadata{FilePath: "/asset.txt", URL: testServer.URL, FileHash: contentHash}
+
+		u.So(t, ioutil.WriteFile(dest+partialDownloadSuffix, []byte("stale"), 0644), gc.ShouldBeNil)
+		u.So(t, downloadAsset(getAssetAtURL, asset, dest), gc.ShouldBeNil)
+		u.So(t, ranges, gc.ShouldResemble, []string{""})
+
+		b, err := ioutil.ReadFile(dest)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(b), gc.ShouldEqual, content)
+	})
+
+	t.Run("should leave no partial file behind when the download fails", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		getAsset := func(url string, offset int64) (io.ReadCloser, int64, error) {
+			if offset > 0 {
+				return nil, offset, errors.New("connection refused")
+			}
+			return ioutil.NopCloser(io.MultiReader(strings.NewReader(content[:5]), errReader{})), 0, nil
+		}
+
+		dest := filepath.Join(dir, "asset.txt")
+		u.So(t, downloadAsset(getAsset, hosting.AssetMetadata{FilePath: "/asset.txt", FileHash: contentHash}, dest), gc.ShouldNotBeNil)
+
+		_, statErr := os.Stat(dest + partialDownloadSuffix)
+		u.So(t, os.IsNotExist(statErr), gc.ShouldBeTrue)
+		_, statErr = os.Stat(dest)
+		u.So(t, os.IsNotExist(statErr), gc.ShouldBeTrue)
+	})
+
+	t.Run("should restart the download if the server ignores the requested range", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		calls := 0
+		getAsset := func(url string, offset int64) (io.ReadCloser, int64, error) {
+			calls++
+			if calls == 1 {
+				return ioutil.NopCloser(io.MultiReader(strings.NewReader(content[:5]), errReader{})), 0, nil
+			}
+			return ioutil.NopCloser(strings.NewReader(content)), 0, nil
+		}
+
+		dest := filepath.Join(dir, "asset.txt")
+		u.So(t, downloadAsset(getAsset, hosting.AssetMetadata{FilePath: "/asset.txt", FileHash: contentHash}, dest), gc.ShouldBeNil)
+
+		b, err := ioutil.ReadFile(dest)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(b), gc.ShouldEqual, content)
+	})
+
+	t.Run("should fail and clean up when the downloaded content does not match the asset hash", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		getAsset := func(url string, offset int64) (io.ReadCloser, int64, error) {
+			return ioutil.NopCloser(strings.NewReader("corrupted")), 0, nil
+		}
+
+		dest := filepath.Join(dir, "asset.txt")
+		err := downloadAsset(getAsset, hosting.AssetMetadata{FilePath: "/asset.txt", FileHash: contentHash}, dest)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "expected hash")
+
+		_, statErr := os.Stat(dest)
+		u.So(t, os.IsNotExist(statErr), gc.ShouldBeTrue)
+		_, statErr = os.Stat(dest + partialDownloadSuffix)
+		u.So(t, os.IsNotExist(statErr), gc.ShouldBeTrue)
+	})
+}
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestAssetDownloadWorker(t *testing.T) {
+	t.Run("it creates directories, adding placeholders to those without assets", func(t *testing.T) {
+		appPath, err := ioutil.TempDir("", "stitch-export-dirs")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(appPath)
+
+		assetMetadata := []hosting.AssetMetadata{
+			{FilePath: "/"},
+			{FilePath: "/empty/"},
+			{FilePath: "/ships/"},
+			{FilePath: "/ships/nostromo.json", URL: "URL/ships/nostromo.json"},
+		}
+
+		exportCommand := &ExportCommand{
+			getAssetAtURL: func(url string, offset int64) (io.ReadCloser, int64, error) {
+				return ioutil.NopCloser(strings.NewReader("{}")), 0, nil
+			},
+		}
+
+		var wg sync.WaitGroup
+		jobs := make(chan hosting.AssetMetadata, len(assetMetadata))
+		errs := make(chan error, len(assetMetadata))
+		for _, am := range assetMetadata {
+			jobs <- am
+		}
+		close(jobs)
+
+		wg.Add(1)
+		assetDownloadWorker(jobs, &wg, errs, exportCommand, appPath, hosting.AncestorDirectories(assetMetadata))
+		close(errs)
+		u.So(t, <-errs, gc.ShouldBeNil)
+
+		filesDir := filepath.Join(appPath, utils.HostingFilesDirectory)
+		_, err = os.Stat(filepath.Join(filesDir, "empty", utils.HostingDirectoryPlaceholder))
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = os.Stat(filepath.Join(filesDir, "ships", utils.HostingDirectoryPlaceholder))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		_, err = os.Stat(filepath.Join(filesDir, utils.HostingDirectoryPlaceholder))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
 	})
 }
@@ -0,0 +1,351 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// defaultWatchPollInterval is how long to wait between checks for changes to the deployed app with --watch-remote
+const defaultWatchPollInterval = time.Minute
+
+// watchRemote writes the exported app in zipData to dest, and then re-exports the app to it whenever a new
+// deployment of the app is made, e.g. by changes made in the UI, until stop receives. Failing to check for
+// or export changes is only logged, so that a temporary outage does not end the watch. Files of dest that are
+// not part of the export, such as a function's source.ts or tests, are left as they are. With --commit, the
+// files an earlier watch exported and committed to dest that the app no longer has are removed as well
+func (ec *ExportCommand) watchRemote(stitchClient api.StitchClient, app *models.App, dest string, zipData io.Reader, stop <-chan os.Signal) error {
+	if err := checkWatchDestination(dest); err != nil {
+		return err
+	}
+
+	deployment, err := stitchClient.FetchLatestDeployment(app.GroupID, app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest deployment: %s", err)
+	}
+	lastDeploymentID := deploymentIDOf(deployment)
+
+	var previous []string
+	if ec.flagCommit {
+		if previous, err = committedExportPaths(dest, app); err != nil {
+			return fmt.Errorf("failed to find the files exported to %s before: %s", dest, err)
+		}
+	}
+
+	exported, err := ec.refreshAppDir(stitchClient, app, dest, zipData, lastDeploymentID, previous)
+	if err != nil {
+		return err
+	}
+
+	ec.Log().Info(fmt.Sprintf("Watching '%s' for changes every %s, press Ctrl+C to stop", app.ClientAppID, ec.flagPollInterval))
+
+	for {
+		select {
+		case <-stop:
+			ec.Log().Info(fmt.Sprintf("Stopped watching '%s'", app.ClientAppID))
+			return nil
+		case <-time.After(ec.flagPollInterval):
+		}
+
+		deployment, err := stitchClient.FetchLatestDeployment(app.GroupID, app.ID)
+		if err != nil {
+			ec.Log().Warn(fmt.Sprintf("failed to check '%s' for changes: %s", app.ClientAppID, err))
+			continue
+		}
+
+		if deploymentIDOf(deployment) == lastDeploymentID {
+			continue
+		}
+
+		_, body, err := stitchClient.Export(app.GroupID, app.ID, ec.flagAsTemplate)
+		if err != nil {
+			ec.Log().Warn(fmt.Sprintf("failed to export the changes to '%s': %s", app.ClientAppID, err))
+			continue
+		}
+
+		refreshed, err := ec.refreshAppDir(stitchClient, app, dest, body, deploymentIDOf(deployment), exported)
+		body.Close()
+		if err != nil {
+			ec.Log().Warn(err.Error())
+			continue
+		}
+
+		exported = refreshed
+		lastDeploymentID = deploymentIDOf(deployment)
+	}
+}
+
+// refreshAppDir writes the exported app in zipData over the app in dest, removing the files of the previous
+// export that it no longer has, then commits those changes to git with --commit. It returns the files of the
+// export, relative to dest. The app is written to a new directory beside dest first, so that an export that
+// fails partway through leaves dest as it was
+func (ec *ExportCommand) refreshAppDir(stitchClient api.StitchClient, app *models.App, dest string, zipData io.Reader, deploymentID string, previous []string) ([]string, error) {
+	tmpDir, err := ioutil.TempDir(filepath.Dir(dest), "."+filepath.Base(dest)+"-export")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export '%s': %s", app.ClientAppID, err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	exported := filepath.Join(tmpDir, filepath.Base(dest))
+	if err := ec.exportToDirectory(exported, zipData, false); err != nil {
+		return nil, fmt.Errorf("failed to export '%s': %s", app.ClientAppID, err)
+	}
+
+	if err := ec.handleDisabled(exported); err != nil {
+		return nil, err
+	}
+
+	if ec.flagRedact {
+		if _, err := utils.RedactAppDir(exported); err != nil {
+			return nil, fmt.Errorf("failed to redact app: %s", err)
+		}
+	}
+
+	if ec.flagIncludeHosting {
+		if err := exportStaticHostingAssets(stitchClient, ec, exported, app); err != nil {
+			return nil, err
+		}
+	}
+
+	written, kept, err := replaceExportedFiles(dest, exported, previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write '%s' to %s: %s", app.ClientAppID, dest, err)
+	}
+
+	for _, path := range kept {
+		ec.Log().Info(fmt.Sprintf("Kept the local source of %s, which its deployed source is built from", filepath.Dir(path)))
+	}
+
+	if deploymentID == "" {
+		ec.Log().Info(fmt.Sprintf("Exported '%s' to %s", app.ClientAppID, dest))
+	} else {
+		ec.Log().Info(fmt.Sprintf("Exported '%s' as of deployment %s to %s", app.ClientAppID, deploymentID, dest))
+	}
+
+	files := append(written, kept...)
+	if !ec.flagCommit {
+		return files, nil
+	}
+
+	message := fmt.Sprintf("Export '%s'", app.ClientAppID)
+	if deploymentID != "" {
+		message = fmt.Sprintf("Export '%s' as of deployment %s", app.ClientAppID, deploymentID)
+	}
+
+	committed, err := commitPaths(dest, append(written, removedPaths(previous, files)...), message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit the export of '%s': %s", app.ClientAppID, err)
+	}
+	if committed {
+		ec.Log().Info(fmt.Sprintf("Committed the changes to %s", dest))
+	}
+
+	return files, nil
+}
+
+// checkWatchDestination fails if dest exists but does not hold an exported app, as exported files are written over it
+func checkWatchDestination(dest string) error {
+	infos, err := ioutil.ReadDir(dest)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if info.Name() == models.AppConfigFileName {
+			return nil
+		}
+	}
+
+	for _, info := range infos {
+		if !strings.HasPrefix(info.Name(), ".") {
+			return fmt.Errorf("cannot watch into %q: it is not empty and does not contain an exported app", dest)
+		}
+	}
+
+	return nil
+}
+
+// replaceExportedFiles moves each file of the export in src to the same path in dest, and removes the files
+// listed in previous, those of the previous export, that src no longer has, such as those of a function
+// deleted in the UI. Any other file of dest, such as a function's source.ts or tests or the shared modules of
+// functions/_lib, is not part of an export and is left as it is. So is the source.js of a function that is
+// built from one, which is returned in kept rather than written. Paths are relative to dest
+func replaceExportedFiles(dest, src string, previous []string) (written, kept []string, err error) {
+	var paths []string
+	if err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, rel)
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(filepath.Join(src, path))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := utils.LocalFunctionSource(dest, path, data); ok {
+			kept = append(kept, path)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dest, path)), os.ModePerm); err != nil {
+			return nil, nil, err
+		}
+		if err := os.Rename(filepath.Join(src, path), filepath.Join(dest, path)); err != nil {
+			return nil, nil, err
+		}
+		written = append(written, path)
+	}
+
+	for _, path := range removedPaths(previous, append(written, kept...)) {
+		if err := os.Remove(filepath.Join(dest, path)); err != nil && !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+
+		// remove the directories left empty, such as that of a deleted function
+		for dir := filepath.Dir(path); dir != "."; dir = filepath.Dir(dir) {
+			if os.Remove(filepath.Join(dest, dir)) != nil {
+				break
+			}
+		}
+	}
+
+	return written, kept, nil
+}
+
+// committedExportPaths returns the files of dest, relative to it, that an earlier watch of the app exported,
+// so that those the app no longer has are removed by the first export of this one. These are the files git
+// tracks whose latest commit is an export of the app, so that none committed otherwise is ever removed
+func committedExportPaths(dest string, app *models.App) ([]string, error) {
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		return nil, nil
+	}
+	if _, err := gitOutput(dest, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return nil, nil
+	}
+
+	tracked, err := gitOutput(dest, "ls-files")
+	if err != nil {
+		return nil, err
+	}
+	trackedPaths := map[string]bool{}
+	for _, path := range strings.Split(tracked, "\n") {
+		trackedPaths[filepath.FromSlash(path)] = true
+	}
+
+	history, err := gitOutput(dest, "log", "--format=%x00%s", "--name-only", "--relative", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	exportMessage := fmt.Sprintf("Export '%s'", app.ClientAppID)
+	seen := map[string]bool{}
+	var paths []string
+	var exportCommit bool
+	for _, line := range strings.Split(history, "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			subject := strings.TrimPrefix(line, "\x00")
+			exportCommit = subject == exportMessage || strings.HasPrefix(subject, exportMessage+" as of deployment ")
+			continue
+		}
+
+		path := filepath.FromSlash(line)
+		if line == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		if exportCommit && trackedPaths[path] {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// removedPaths returns the paths of previous that are not in current
+func removedPaths(previous, current []string) []string {
+	currentPaths := make(map[string]bool, len(current))
+	for _, path := range current {
+		currentPaths[path] = true
+	}
+
+	var removed []string
+	for _, path := range previous {
+		if !currentPaths[path] {
+			removed = append(removed, path)
+		}
+	}
+	return removed
+}
+
+// commitPaths commits the changes to paths, relative to dir, to the git repository containing dir, returning
+// whether there were any to commit. Changes to other files, staged or not, are left out of the commit
+func commitPaths(dir string, paths []string, message string) (bool, error) {
+	if len(paths) == 0 {
+		return false, nil
+	}
+
+	var existing, missing []string
+	for _, path := range paths {
+		if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+			existing = append(existing, path)
+		} else {
+			missing = append(missing, path)
+		}
+	}
+
+	if len(existing) > 0 {
+		if _, err := gitOutput(dir, append([]string{"add", "--"}, existing...)...); err != nil {
+			return false, err
+		}
+	}
+	if len(missing) > 0 {
+		if _, err := gitOutput(dir, append([]string{"rm", "--cached", "--ignore-unmatch", "--quiet", "--"}, missing...)...); err != nil {
+			return false, err
+		}
+	}
+
+	staged, err := gitOutput(dir, append([]string{"diff", "--cached", "--name-only", "--relative", "--"}, paths...)...)
+	if err != nil {
+		return false, err
+	}
+
+	if staged == "" {
+		return false, nil
+	}
+
+	if _, err := gitOutput(dir, append([]string{"commit", "--message", message, "--"}, strings.Split(staged, "\n")...)...); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// deploymentIDOf returns the ID of the deployment, or "" if the app has not been deployed
+func deploymentIDOf(deployment *models.Deployment) string {
+	if deployment == nil {
+		return ""
+	}
+	return deployment.ID
+}
@@ -0,0 +1,20 @@
+package commands
+
+import "strings"
+
+// stringsFlag collects the values of a repeatable string flag
+type stringsFlag []string
+
+// String returns the values given so far
+func (sf *stringsFlag) String() string {
+	if sf == nil {
+		return ""
+	}
+	return strings.Join(*sf, ", ")
+}
+
+// Set adds a value
+func (sf *stringsFlag) Set(s string) error {
+	*sf = append(*sf, s)
+	return nil
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// headerFlags collects the headers given by a repeatable "Name: value" flag
+type headerFlags http.Header
+
+// String returns the headers in the form they are given in
+func (hf headerFlags) String() string {
+	var headers []string
+	for name, values := range hf {
+		for _, value := range values {
+			headers = append(headers, name+": "+value)
+		}
+	}
+	sort.Strings(headers)
+	return strings.Join(headers, ", ")
+}
+
+// Set adds a header given as "Name: value"
+func (hf headerFlags) Set(s string) error {
+	idx := strings.Index(s, ":")
+	if idx == -1 {
+		return fmt.Errorf("invalid header %q: expected \"Name: value\"", s)
+	}
+
+	name := strings.TrimSpace(s[:idx])
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid header %q: expected \"Name: value\"", s)
+	}
+
+	http.Header(hf).Add(name, strings.TrimSpace(s[idx+1:]))
+	return nil
+}
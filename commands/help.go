@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitchellh/cli"
+)
+
+const helpFlagExamples = "examples"
+
+// NewHelpCommandFactory returns a new cli.CommandFactory given a cli.Ui and the commands it can describe
+func NewHelpCommandFactory(ui cli.Ui, commands map[string]cli.CommandFactory) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &HelpCommand{
+			BaseCommand: &BaseCommand{
+				Name: "help",
+				UI:   ui,
+			},
+			commands: commands,
+		}, nil
+	}
+}
+
+// HelpCommand is used to show the help, or the example invocations, of a command
+type HelpCommand struct {
+	*BaseCommand
+
+	commands map[string]cli.CommandFactory
+
+	flagExamples bool
+}
+
+// Synopsis returns a one-liner description for this command
+func (hc *HelpCommand) Synopsis() string {
+	return "Show help and example invocations for a command."
+}
+
+// Help returns long-form help information for this command
+func (hc *HelpCommand) Help() string {
+	return `Show the help for a command (e.g. "help hosting diff"), or list the available commands if none is given.
+
+OPTIONS:
+  --examples
+	Show runnable example invocations of the command instead of its help.` +
+		hc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (hc *HelpCommand) Run(args []string) int {
+	flags := hc.NewFlagSet()
+
+	flags.BoolVar(&hc.flagExamples, helpFlagExamples, false, "")
+
+	if err := hc.BaseCommand.run(args); err != nil {
+		hc.Log().Error(err.Error())
+		return 1
+	}
+
+	// the words of a command name may come before or after the flags, so keep parsing past each of them
+	var words []string
+	for rest := hc.Args(); len(rest) > 0; rest = hc.Args() {
+		words = append(words, rest[0])
+		if err := hc.Parse(rest[1:]); err != nil {
+			hc.Log().Error(err.Error())
+			return 1
+		}
+	}
+
+	if err := hc.help(strings.Join(words, " ")); err != nil {
+		hc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hc *HelpCommand) help(name string) error {
+	if name == "" {
+		if hc.flagExamples {
+			return fmt.Errorf("a command must be given to show the examples of, e.g. \"help import --%s\"", helpFlagExamples)
+		}
+		return hc.listCommands()
+	}
+
+	factory, ok := hc.commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q; run \"help\" to list the available commands", name)
+	}
+
+	examples := commandExamples[name]
+	if hc.flagExamples {
+		if len(examples) == 0 {
+			return fmt.Errorf("there are no examples of %q; run \"help %s\" for its usage", name, name)
+		}
+		hc.UI.Output(formatExamples(name, examples))
+		return nil
+	}
+
+	command, err := factory()
+	if err != nil {
+		return err
+	}
+
+	hc.UI.Output(command.Help())
+	if len(examples) != 0 {
+		hc.UI.Output(fmt.Sprintf("\nRun \"help %s --%s\" for example invocations.", name, helpFlagExamples))
+	}
+	return nil
+}
+
+func (hc *HelpCommand) listCommands() error {
+	names := make([]string, 0, len(hc.commands))
+	for name := range hc.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 4, ' ', 0)
+	for _, name := range names {
+		command, err := hc.commands[name]()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "    %s\t%s\n", name, command.Synopsis())
+	}
+	w.Flush()
+
+	hc.UI.Output("Available commands are:")
+	hc.UI.Output(strings.TrimRight(buf.String(), "\n"))
+	return nil
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"testing"
+
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func (c *BaseCommand) base() *BaseCommand {
+	return c
+}
+
+func TestCommandExamples(t *testing.T) {
+	commands := NewCommandFactories(cli.NewMockUi())
+
+	for name, examples := range commandExamples {
+		factory, ok := commands[name]
+		u.So(t, ok, gc.ShouldBeTrue)
+
+		for _, example := range examples {
+			t.Run(example.Invocation(name), func(t *testing.T) {
+				cmd, err := factory()
+				u.So(t, err, gc.ShouldBeNil)
+
+				base := cmd.(interface{ base() *BaseCommand }).base()
+				base.continueOnFlagError = true
+				base.storage = u.NewEmptyStorage()
+
+				cmd.Run(append([]string{"--config-path=../testdata/configs/tmp/stitch.json"}, example.Args...))
+
+				// the command's flags are registered by now, so parse the example again to check them
+				u.So(t, base.FlagSet, gc.ShouldNotBeNil)
+				u.So(t, base.FlagSet.Parse(example.Args), gc.ShouldBeNil)
+				u.So(t, base.FlagSet.Args(), gc.ShouldBeEmpty)
+			})
+		}
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	setup := func() (*HelpCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewCommandFactories(mockUI)["help"]()
+		if err != nil {
+			panic(err)
+		}
+
+		helpCommand := cmd.(*HelpCommand)
+		helpCommand.storage = u.NewEmptyStorage()
+		helpCommand.continueOnFlagError = true
+		return helpCommand, mockUI
+	}
+
+	t.Run("should list the available commands when none is given", func(t *testing.T) {
+		helpCommand, mockUI := setup()
+		exitCode := helpCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		output := mockUI.OutputWriter.String()
+		u.So(t, output, gc.ShouldStartWith, "Available commands are:\n")
+		u.So(t, output, gc.ShouldContainSubstring, "    hosting diff                 Show the changes that importing local hosting assets would make.")
+		u.So(t, output, gc.ShouldContainSubstring, "    orgs list                    List the Atlas Organizations available to you.")
+	})
+
+	t.Run("should show the help of a command", func(t *testing.T) {
+		helpCommand, mockUI := setup()
+		exitCode := helpCommand.Run([]string{"hosting", "retry"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		output := mockUI.OutputWriter.String()
+		u.So(t, output, gc.ShouldStartWith, "Retry the hosting asset uploads")
+		u.So(t, output, gc.ShouldEndWith, "Run \"help hosting retry --examples\" for example invocations.\n")
+	})
+
+	t.Run("should show the examples of a command", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"import", "--examples"},
+			{"--examples", "import"},
+		} {
+			helpCommand, mockUI := setup()
+			exitCode := helpCommand.Run(args)
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, formatExamples("import", commandExamples["import"])+"\n")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "  stitch-cli import --path=./my-app --app-name=my-app")
+		}
+	})
+
+	t.Run("should fail for a command without examples", func(t *testing.T) {
+		helpCommand, mockUI := setup()
+		exitCode := helpCommand.Run([]string{"orgs", "list", "--examples"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `there are no examples of "orgs list"`)
+	})
+
+	t.Run("should fail for an unknown command", func(t *testing.T) {
+		helpCommand, mockUI := setup()
+		exitCode := helpCommand.Run([]string{"deploy"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `unknown command "deploy"`)
+	})
+}
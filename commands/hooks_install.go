@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/models"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	hooksInstallFlagPath   = "path"
+	hooksInstallFlagHook   = "hook"
+	hooksInstallFlagStrict = "strict"
+	hooksInstallFlagDiff   = "diff"
+	hooksInstallFlagForce  = "force"
+
+	hookPreCommit = "pre-commit"
+	hookPrePush   = "pre-push"
+
+	gitCommand = "git"
+
+	// hookMarker identifies hooks written by "hooks install", which it may replace without --force
+	hookMarker = "# Installed by \"" + cliName + " hooks install\""
+)
+
+// NewHooksInstallCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHooksInstallCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &HooksInstallCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hooks install",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// HooksInstallCommand is used to install a git hook that validates an app before its changes are committed or pushed
+type HooksInstallCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagAppPath string
+	flagHook    string
+	flagStrict  bool
+	flagDiff    bool
+	flagForce   bool
+}
+
+// Synopsis returns a one-liner description for this command
+func (hic *HooksInstallCommand) Synopsis() string {
+	return "Install a git hook that validates an app before committing or pushing."
+}
+
+// Help returns long-form help information for this command
+func (hic *HooksInstallCommand) Help() string {
+	return `Install a git hook, in the repository containing a local app, that runs "validate" on the app and stops the commit or push if it fails.
+Options not given default to the "hooks" settings in the app's ` + models.ProjectConfigFileName + ` file (hook, strict and diff), if any. The hook runs "` + cliName + `", which must be on the PATH.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --hook [` + hookPreCommit + `|` + hookPrePush + `] (default: ` + hookPrePush + `)
+	The git hook to install.
+
+  --strict
+	Validate strictly, treating unrecognized fields as errors.
+
+  --diff
+	Also show the changes that importing the app's hosting assets would make, with "hosting diff". Requires login when the hook runs.
+
+  --force
+	Replace an existing hook that was not installed by this command.` +
+		hic.BaseCommand.Help()
+}
+
+// Run executes the command
+func (hic *HooksInstallCommand) Run(args []string) int {
+	flags := hic.NewFlagSet()
+
+	flags.StringVar(&hic.flagAppPath, hooksInstallFlagPath, "", "")
+	flags.StringVar(&hic.flagHook, hooksInstallFlagHook, "", "")
+	flags.BoolVar(&hic.flagStrict, hooksInstallFlagStrict, false, "")
+	flags.BoolVar(&hic.flagDiff, hooksInstallFlagDiff, false, "")
+	flags.BoolVar(&hic.flagForce, hooksInstallFlagForce, false, "")
+
+	if err := hic.BaseCommand.run(args); err != nil {
+		hic.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := hic.install(); err != nil {
+		hic.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hic *HooksInstallCommand) install() error {
+	appPath, err := hic.resolveAppDirectory(hic.flagAppPath, hic.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	projectConfig, err := models.LoadProjectConfig(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+	}
+
+	settings := projectConfig.Hooks
+	if hic.flagHook != "" {
+		settings.Hook = hic.flagHook
+	}
+	if settings.Hook == "" {
+		settings.Hook = hookPrePush
+	}
+	settings.Strict = settings.Strict || hic.flagStrict
+	settings.Diff = settings.Diff || hic.flagDiff
+
+	if settings.Hook != hookPreCommit && settings.Hook != hookPrePush {
+		return fmt.Errorf("unknown hook %q; accepted values are [%s|%s]", settings.Hook, hookPreCommit, hookPrePush)
+	}
+
+	if _, err := exec.LookPath(gitCommand); err != nil {
+		return fmt.Errorf("the %s command must be installed to install hooks", gitCommand)
+	}
+
+	repoRoot, err := gitOutput(appPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("%s is not in a git repository: %s", appPath, err)
+	}
+
+	hooksDir, err := gitOutput(appPath, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(appPath, hooksDir)
+	}
+
+	absAppPath, err := filepath.Abs(appPath)
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(absAppPath); err == nil {
+		absAppPath = resolved
+	}
+
+	relAppPath, err := filepath.Rel(filepath.FromSlash(repoRoot), absAppPath)
+	if err != nil {
+		return err
+	}
+
+	hookPath := filepath.Join(hooksDir, settings.Hook)
+	if existing, err := ioutil.ReadFile(hookPath); err == nil {
+		if !bytes.Contains(existing, []byte(hookMarker)) && !hic.flagForce {
+			return fmt.Errorf("a %s hook not installed by this command already exists at %s; use --%s to replace it", settings.Hook, hookPath, hooksInstallFlagForce)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(hookPath, []byte(hookScript(filepath.ToSlash(relAppPath), settings)), 0755); err != nil {
+		return err
+	}
+
+	// WriteFile leaves the mode of an existing file alone, so make sure the hook is executable
+	if err := os.Chmod(hookPath, 0755); err != nil {
+		return err
+	}
+
+	hic.Success(fmt.Sprintf("Installed a %s hook validating %s at %s", settings.Hook, relAppPath, hookPath))
+	return nil
+}
+
+// hookScript returns the shell script run by the hook for the app at appPath, relative to the root of the repository
+func hookScript(appPath string, settings models.HooksConfig) string {
+	pathArg := "--path=" + shellQuote(appPath)
+
+	validate := []string{cliName, "validate", pathArg}
+	if settings.Strict {
+		validate = append(validate, "--"+validateFlagStrict)
+	}
+
+	lines := []string{
+		"#!/bin/sh",
+		hookMarker + "; run it again rather than editing this file.",
+		"",
+		`cd "$(git rev-parse --show-toplevel)" || exit 1`,
+		"",
+		strings.Join(validate, " ") + " || exit 1",
+	}
+
+	if settings.Diff {
+		lines = append(lines, strings.Join([]string{cliName, "hosting diff", pathArg}, " ")+" || exit 1")
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// shellQuote quotes s for use as a single word in a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// gitOutput runs git with the given args in dir and returns its trimmed output
+func gitOutput(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(gitCommand, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
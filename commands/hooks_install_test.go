@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestHooksInstallCommand(t *testing.T) {
+	if _, err := exec.LookPath(gitCommand); err != nil {
+		u.MustSkipf(t, "%s is not installed", gitCommand)
+	}
+
+	setup := func() (*HooksInstallCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHooksInstallCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		hooksInstallCommand := cmd.(*HooksInstallCommand)
+		hooksInstallCommand.storage = u.NewEmptyStorage()
+		return hooksInstallCommand, mockUI
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-hooks-install")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	_, err = gitOutput(dir, "init")
+	u.So(t, err, gc.ShouldBeNil)
+
+	appDir := filepath.Join(dir, "my app")
+	u.So(t, os.MkdirAll(appDir, 0755), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+
+	hooksDir := filepath.Join(dir, ".git", "hooks")
+
+	readHook := func(t *testing.T, name string) string {
+		info, err := os.Stat(filepath.Join(hooksDir, name))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, info.Mode()&0100, gc.ShouldNotEqual, 0)
+
+		data, err := ioutil.ReadFile(filepath.Join(hooksDir, name))
+		u.So(t, err, gc.ShouldBeNil)
+		return string(data)
+	}
+
+	t.Run("it installs a pre-push hook validating the app by default", func(t *testing.T) {
+		hooksInstallCommand, mockUI := setup()
+		exitCode := hooksInstallCommand.Run([]string{"--path=" + appDir})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+
+		hook := readHook(t, hookPrePush)
+		u.So(t, hook, gc.ShouldContainSubstring, hookMarker)
+		u.So(t, hook, gc.ShouldContainSubstring, "stitch-cli validate --path='my app' || exit 1")
+		u.So(t, hook, gc.ShouldNotContainSubstring, "hosting diff")
+	})
+
+	t.Run("it replaces a hook it installed, taking settings from flags", func(t *testing.T) {
+		hooksInstallCommand, _ := setup()
+		exitCode := hooksInstallCommand.Run([]string{"--path=" + appDir, "--strict", "--diff"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		hook := readHook(t, hookPrePush)
+		u.So(t, hook, gc.ShouldContainSubstring, "stitch-cli validate --path='my app' --strict || exit 1")
+		u.So(t, hook, gc.ShouldContainSubstring, "stitch-cli hosting diff --path='my app' || exit 1")
+	})
+
+	t.Run("it takes settings from the app's project config", func(t *testing.T) {
+		projectConfig := &models.ProjectConfig{Hooks: models.HooksConfig{Hook: hookPreCommit, Strict: true}}
+		u.So(t, projectConfig.Save(appDir), gc.ShouldBeNil)
+		defer os.Remove(filepath.Join(appDir, models.ProjectConfigFileName))
+
+		hooksInstallCommand, _ := setup()
+		exitCode := hooksInstallCommand.Run([]string{"--path=" + appDir})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		u.So(t, readHook(t, hookPreCommit), gc.ShouldContainSubstring, "--strict")
+	})
+
+	t.Run("it does not replace a hook it did not install without --force", func(t *testing.T) {
+		foreignHook := "#!/bin/sh\nmake lint\n"
+		u.So(t, ioutil.WriteFile(filepath.Join(hooksDir, hookPrePush), []byte(foreignHook), 0644), gc.ShouldBeNil)
+
+		hooksInstallCommand, mockUI := setup()
+		exitCode := hooksInstallCommand.Run([]string{"--path=" + appDir})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "use --force to replace it")
+
+		data, err := ioutil.ReadFile(filepath.Join(hooksDir, hookPrePush))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(data), gc.ShouldEqual, foreignHook)
+
+		hooksInstallCommand, _ = setup()
+		exitCode = hooksInstallCommand.Run([]string{"--path=" + appDir, "--force"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, readHook(t, hookPrePush), gc.ShouldContainSubstring, hookMarker)
+	})
+
+	t.Run("it rejects an unknown hook", func(t *testing.T) {
+		hooksInstallCommand, mockUI := setup()
+		exitCode := hooksInstallCommand.Run([]string{"--path=" + appDir, "--hook=post-merge"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `unknown hook "post-merge"`)
+	})
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/10gen/stitch-cli/hosting"
+
+	"github.com/mitchellh/cli"
+)
+
+// hostingAssetsListColumns are the columns of the assets listed by HostingAssetsListCommand
+var hostingAssetsListColumns = []string{"path", "size", "hash", "last_modified"}
+
+// NewHostingAssetsListCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingAssetsListCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &HostingAssetsListCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting assets list",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// HostingAssetsListCommand is used to list the hosted assets of a Stitch App
+type HostingAssetsListCommand struct {
+	*BaseCommand
+
+	output listOutput
+
+	flagProjectID string
+	flagAppID     string
+}
+
+// Help returns long-form help information for this command
+func (halc *HostingAssetsListCommand) Help() string {
+	return `List the hosted assets of a stitch application, with their sizes.
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.
+` + listOutputHelp(hostingAssetsListColumns) + halc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (halc *HostingAssetsListCommand) Synopsis() string {
+	return `List the hosted assets of a stitch application.`
+}
+
+// Run executes the command
+func (halc *HostingAssetsListCommand) Run(args []string) int {
+	flags := halc.NewFlagSet()
+
+	flags.StringVar(&halc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&halc.flagAppID, flagAppIDName, "", "")
+	halc.output.registerFlags(flags)
+
+	if err := halc.BaseCommand.run(args); err != nil {
+		halc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := halc.list(); err != nil {
+		halc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (halc *HostingAssetsListCommand) list() error {
+	stitchClient, app, err := halc.resolveHostingApp(halc.flagProjectID, halc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	assetMetadata, err := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return err
+	}
+
+	var assets []hosting.AssetMetadata
+	for _, asset := range assetMetadata {
+		if !asset.IsDir() {
+			assets = append(assets, asset)
+		}
+	}
+
+	rows := make([][]string, len(assets))
+	for i, asset := range assets {
+		var lastModified string
+		if asset.LastModified != 0 {
+			lastModified = time.Unix(asset.LastModified, 0).UTC().Format(time.RFC3339)
+		}
+		rows[i] = []string{asset.FilePath, strconv.FormatInt(asset.FileSize, 10), asset.FileHash, lastModified}
+	}
+
+	return halc.output.write(halc.UI, hostingAssetsListColumns, rows)
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHostingAssetsListCommand(t *testing.T) {
+	setup := func() (*HostingAssetsListCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingAssetsListCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		assetsListCommand := cmd.(*HostingAssetsListCommand)
+		assetsListCommand.storage = u.NewEmptyStorage()
+		assetsListCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		assetsListCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+			ListAssetsForAppIDFn: func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+				return []hosting.AssetMetadata{
+					{FilePath: "/index.html", FileSize: 2048, FileHash: "abc", LastModified: 1521000000},
+					{FilePath: "/static/"},
+					{FilePath: "/static/app.js", FileSize: 100, FileHash: "def"},
+				}, nil
+			},
+		}
+		return assetsListCommand, mockUI
+	}
+
+	t.Run("it requires an app id", func(t *testing.T) {
+		assetsListCommand, mockUI := setup()
+
+		exitCode := assetsListCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errHostingConfigAppIDRequired.Error())
+	})
+
+	t.Run("it lists each asset with its size", func(t *testing.T) {
+		assetsListCommand, mockUI := setup()
+
+		exitCode := assetsListCommand.Run([]string{"--app-id=my-app-abcde", "--columns=path,size"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, `PATH            SIZE
+/index.html     2048
+/static/app.js  100
+`)
+	})
+
+	t.Run("it sorts the assets by size", func(t *testing.T) {
+		assetsListCommand, mockUI := setup()
+
+		exitCode := assetsListCommand.Run([]string{"--app-id=my-app-abcde", "--columns=path,size", "--sort=size"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, `PATH            SIZE
+/static/app.js  100
+/index.html     2048
+`)
+	})
+
+	t.Run("it writes the assets as CSV", func(t *testing.T) {
+		assetsListCommand, mockUI := setup()
+
+		exitCode := assetsListCommand.Run([]string{"--app-id=my-app-abcde", "--output=csv"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "path,size,hash,last_modified\n/index.html,2048,abc,2018-03-14T04:00:00Z\n/static/app.js,100,def,\n")
+	})
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	hostingAttrsFlagPath  = "path"
+	hostingAttrsFlagPrune = "prune"
+)
+
+// NewHostingAttrsGenerateCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingAttrsGenerateCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &HostingAttrsGenerateCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting attrs generate",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// HostingAttrsGenerateCommand is used to generate the hosting metadata file of a local app from its hosting assets
+type HostingAttrsGenerateCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagAppPath string
+	flagPrune   bool
+}
+
+// Help returns long-form help information for this command
+func (hag *HostingAttrsGenerateCommand) Help() string {
+	return `Generate or update the entries of "` + utils.HostingAttributes + `" for the hosting assets of a local app.
+
+Each asset is given the Content-Type and Cache-Control of its file extension, where they are known, unless its entry already sets them. HTML pages are not cached ("` + hosting.CacheControlNoCache + `"), while scripts, stylesheets, images and fonts are cached for a day ("` + hosting.CacheControlOneDay + `"). Entries for assets that no longer exist are reported as stale.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --prune
+	Remove stale entries rather than only reporting them.` +
+		hag.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hag *HostingAttrsGenerateCommand) Synopsis() string {
+	return `Generate hosting asset attributes from the local hosting assets.`
+}
+
+// Run executes the command
+func (hag *HostingAttrsGenerateCommand) Run(args []string) int {
+	flags := hag.NewFlagSet()
+
+	flags.StringVar(&hag.flagAppPath, hostingAttrsFlagPath, "", "")
+	flags.BoolVar(&hag.flagPrune, hostingAttrsFlagPrune, false, "")
+
+	if err := hag.BaseCommand.run(args); err != nil {
+		hag.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := hag.generate(); err != nil {
+		hag.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hag *HostingAttrsGenerateCommand) generate() error {
+	appPath, err := hag.resolveAppDirectory(hag.flagAppPath, hag.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	projectConfig, err := models.LoadProjectConfig(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+	}
+
+	rootDir := filepath.Join(appPath, utils.HostingFilesDirectory)
+	if len(projectConfig.Hosting.Roots) > 0 {
+		mergedDir, mErr := hosting.MergeRoots(appPath, projectConfig.Hosting.Roots)
+		if mErr != nil {
+			return mErr
+		}
+		defer os.RemoveAll(mergedDir)
+		rootDir = mergedDir
+	}
+
+	metadataPath := filepath.Join(appPath, utils.HostingAttributes)
+	existing, err := hosting.MetadataFileToAssetDescriptions(metadataPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("error loading metadata.json file: %v", err)
+		}
+		existing = map[string]hosting.AssetDescription{}
+	}
+
+	generated, err := hosting.GenerateAssetDescriptions(rootDir, existing, hag.flagPrune)
+	if err != nil {
+		return fmt.Errorf("error processing local assets %s: %s", rootDir, err)
+	}
+
+	for _, assetPath := range generated.Stale {
+		if hag.flagPrune {
+			hag.Log().Info(fmt.Sprintf("Removed the entry for '%s', which no longer exists", assetPath))
+		} else {
+			hag.Log().Warn(fmt.Sprintf("'%s' has an entry but no longer exists; run with --%s to remove it", assetPath, hostingAttrsFlagPrune))
+		}
+	}
+
+	descriptions := generated.Descriptions
+	if descriptions == nil {
+		descriptions = []hosting.AssetDescription{}
+	}
+	data, err := json.MarshalIndent(descriptions, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(metadataPath, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %s", metadataPath, err)
+	}
+
+	hag.Success(fmt.Sprintf("Successfully updated %d entries in %s", len(generated.Updated), metadataPath))
+	return nil
+}
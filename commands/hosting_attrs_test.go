@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestHostingAttrsGenerateCommand(t *testing.T) {
+	setup := func(t *testing.T) (*HostingAttrsGenerateCommand, *cli.MockUi, string) {
+		appPath, err := ioutil.TempDir("", "stitch-hosting-attrs")
+		u.So(t, err, gc.ShouldBeNil)
+
+		filesDir := filepath.Join(appPath, utils.HostingFilesDirectory)
+		u.So(t, os.MkdirAll(filesDir, os.ModePerm), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(filesDir, "index.html"), []byte("<html></html>"), 0644), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(
+			filepath.Join(appPath, utils.HostingAttributes),
+			[]byte(`[{"path": "/gone.css", "attrs": [{"name": "Content-Type", "value": "text/css"}]}]`),
+			0644,
+		), gc.ShouldBeNil)
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingAttrsGenerateCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		generateCommand := cmd.(*HostingAttrsGenerateCommand)
+		generateCommand.storage = u.NewEmptyStorage()
+		return generateCommand, mockUI, appPath
+	}
+
+	t.Run("it adds default attributes and reports stale entries", func(t *testing.T) {
+		generateCommand, mockUI, appPath := setup(t)
+		defer os.RemoveAll(appPath)
+
+		exitCode := generateCommand.Run([]string{"--path=" + appPath})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "'/gone.css' has an entry but no longer exists; run with --prune to remove it")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully updated 1 entries")
+
+		descs, err := hosting.MetadataFileToAssetDescriptions(filepath.Join(appPath, utils.HostingAttributes))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, descs, gc.ShouldHaveLength, 2)
+		u.So(t, descs["/index.html"].Attrs, gc.ShouldResemble, []hosting.AssetAttribute{
+			{Name: hosting.AttributeContentType, Value: "text/html"},
+			{Name: hosting.AttributeCacheControl, Value: hosting.CacheControlNoCache},
+		})
+	})
+
+	t.Run("it removes stale entries with --prune", func(t *testing.T) {
+		generateCommand, mockUI, appPath := setup(t)
+		defer os.RemoveAll(appPath)
+
+		exitCode := generateCommand.Run([]string{"--path=" + appPath, "--prune"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Removed the entry for '/gone.css', which no longer exists")
+
+		descs, err := hosting.MetadataFileToAssetDescriptions(filepath.Join(appPath, utils.HostingAttributes))
+		u.So(t, err, gc.ShouldBeNil)
+		_, ok := descs["/gone.css"]
+		u.So(t, ok, gc.ShouldBeFalse)
+	})
+}
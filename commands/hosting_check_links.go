@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+const hostingCheckLinksFlagPath = "path"
+
+// NewHostingCheckLinksCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingCheckLinksCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &HostingCheckLinksCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting check-links",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// HostingCheckLinksCommand is used to find the links in local HTML assets to paths no asset would be hosted at
+type HostingCheckLinksCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagProjectID string
+	flagAppID     string
+	flagAppPath   string
+}
+
+// Help returns long-form help information for this command
+func (hclc *HostingCheckLinksCommand) Help() string {
+	return `Check the links and asset references in the local HTML assets of a stitch application, before importing them.
+
+Each href and src that points at the app's own site must resolve to a local asset as it would be uploaded, with the hosting roots and transforms of ` + models.ProjectConfigFileName + ` applied, or to a redirect or rewrite of the local hosting config. A link to a directory resolves to its index.html. Links to other sites are not checked.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --app-id [string]
+	Also accept links to the assets deployed to this app, which a merging import keeps.
+
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.` +
+		hclc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hclc *HostingCheckLinksCommand) Synopsis() string {
+	return `Check local HTML assets for broken links.`
+}
+
+// Run executes the command
+func (hclc *HostingCheckLinksCommand) Run(args []string) int {
+	flags := hclc.NewFlagSet()
+
+	flags.StringVar(&hclc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&hclc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&hclc.flagAppPath, hostingCheckLinksFlagPath, "", "")
+
+	if err := hclc.BaseCommand.run(args); err != nil {
+		hclc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := hclc.checkLinks(); err != nil {
+		hclc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hclc *HostingCheckLinksCommand) checkLinks() error {
+	appPath, err := hclc.resolveAppDirectory(hclc.flagAppPath, hclc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	projectConfig, err := models.LoadProjectConfig(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+	}
+
+	rootDir, localAssetMetadata, cleanup, err := hclc.listLocalAssets(appPath, hclc.flagAppID, projectConfig.Hosting)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	hosted := map[string]bool{}
+	for _, asset := range localAssetMetadata {
+		hosted[asset.FilePath] = true
+	}
+
+	if hclc.flagAppID != "" {
+		stitchClient, app, rErr := hclc.resolveHostingApp(hclc.flagProjectID, hclc.flagAppID)
+		if rErr != nil {
+			return rErr
+		}
+
+		remoteAssetMetadata, lErr := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+		if lErr != nil {
+			return fmt.Errorf("error retrieving remote assets: %s", lErr)
+		}
+
+		for _, asset := range remoteAssetMetadata {
+			hosted[asset.FilePath] = true
+		}
+	}
+
+	config, err := hosting.ConfigFileToConfig(filepath.Join(appPath, utils.HostingConfig))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error loading config.json file: %s", err)
+	}
+
+	broken, err := hosting.CheckLinks(rootDir, localAssetMetadata, func(assetPath string) bool {
+		return hosted[assetPath] || hosted[path.Join(assetPath, "index.html")] || routed(config, assetPath)
+	})
+	if err != nil {
+		return fmt.Errorf("error checking the links of local assets %s: %s", rootDir, err)
+	}
+
+	pages := 0
+	for _, asset := range localAssetMetadata {
+		if hosting.IsHTML(asset.FilePath) {
+			pages++
+		}
+	}
+
+	if len(broken) == 0 {
+		hclc.Success(fmt.Sprintf("Found no broken links in %d HTML asset(s)", pages))
+		return nil
+	}
+
+	for _, link := range broken {
+		hclc.UI.Output(fmt.Sprintf("%s:%d: '%s' => no asset at '%s'", link.Asset, link.Line, link.Reference, link.Path))
+	}
+
+	return fmt.Errorf("found %d broken link(s) in %d HTML asset(s)", len(broken), pages)
+}
+
+// routed is true if a request for assetPath is answered by one of the redirects or rewrites of config, whose
+// paths may be patterns as accepted by path.Match. config may be nil
+func routed(config *hosting.Config, assetPath string) bool {
+	if config == nil {
+		return false
+	}
+
+	var patterns []string
+	for _, redirect := range config.Redirects {
+		patterns = append(patterns, redirect.From)
+	}
+	for _, rewrite := range config.Rewrites {
+		patterns = append(patterns, rewrite.From)
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, assetPath); matched || pattern == assetPath {
+			return true
+		}
+	}
+
+	return false
+}
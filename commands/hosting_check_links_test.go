@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestHostingCheckLinksCommand(t *testing.T) {
+	appDir, err := ioutil.TempDir("", "stitch-check-links")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(appDir)
+
+	filesDir := filepath.Join(appDir, "hosting", "files")
+	u.So(t, os.MkdirAll(filepath.Join(filesDir, "docs"), 0755), gc.ShouldBeNil)
+	for name, contents := range map[string]string{
+		"hosting/metadata.json": `[]`,
+		"hosting/config.json":   `{"enabled": true, "rewrites": [{"from": "/app/*", "to": "/index.html"}]}`,
+		"hosting/files/index.html": `<a href="/docs/">Docs</a>
+<a href="/app/settings">Settings</a>
+<img src="logo.png">
+<script src="/legacy.js"></script>`,
+		"hosting/files/docs/index.html": `<a href="../">Home</a>`,
+	} {
+		u.So(t, ioutil.WriteFile(filepath.Join(appDir, filepath.FromSlash(name)), []byte(contents), 0644), gc.ShouldBeNil)
+	}
+
+	setup := func() (*HostingCheckLinksCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingCheckLinksCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		checkLinksCommand := cmd.(*HostingCheckLinksCommand)
+		checkLinksCommand.storage = u.NewEmptyStorage()
+		return checkLinksCommand, mockUI
+	}
+
+	args := []string{"--path=" + appDir, "--config-path=" + filepath.Join(appDir, "stitch.json")}
+
+	t.Run("it reports the references to paths no local asset would be hosted at", func(t *testing.T) {
+		checkLinksCommand, mockUI := setup()
+		exitCode := checkLinksCommand.Run(args)
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "/index.html:3: 'logo.png' => no asset at '/logo.png'\n"+
+			"/index.html:4: '/legacy.js' => no asset at '/legacy.js'\n")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "found 2 broken link(s) in 2 HTML asset(s)")
+	})
+
+	t.Run("it accepts references to the assets deployed to the app given by --app-id", func(t *testing.T) {
+		checkLinksCommand, mockUI := setup()
+		checkLinksCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		checkLinksCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+			ListAssetsForAppIDFn: func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+				return []hosting.AssetMetadata{{FilePath: "/logo.png"}, {FilePath: "/legacy.js"}}, nil
+			},
+		}
+
+		exitCode := checkLinksCommand.Run(append([]string{"--app-id=my-app-abcde"}, args...))
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Found no broken links in 2 HTML asset(s)")
+	})
+}
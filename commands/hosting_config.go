@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const hostingConfigFlagFile = "file"
+
+var (
+	errHostingConfigAppIDRequired = fmt.Errorf("an App ID (--%s=[string]) must be supplied to manage hosting config", flagAppIDName)
+	errHostingConfigSetUsage      = fmt.Errorf("either a setting and value or --%s=[string] must be supplied", hostingConfigFlagFile)
+)
+
+// NewHostingConfigGetCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingConfigGetCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &HostingConfigGetCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting config get",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// HostingConfigGetCommand is used to display the static hosting settings of a Stitch App
+type HostingConfigGetCommand struct {
+	*BaseCommand
+
+	flagProjectID string
+	flagAppID     string
+}
+
+// Help returns long-form help information for this command
+func (hcg *HostingConfigGetCommand) Help() string {
+	return `Display the static hosting configuration of a stitch application.
+
+USAGE:
+  hosting config get --app-id [string] [setting]
+
+  Displays the entire configuration as JSON, or only the value of the given setting. Settings are: ` + strings.Join(hosting.ConfigSettings, ", ") + `
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.` +
+		hcg.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hcg *HostingConfigGetCommand) Synopsis() string {
+	return `Display the static hosting configuration of a stitch application.`
+}
+
+// Run executes the command
+func (hcg *HostingConfigGetCommand) Run(args []string) int {
+	flags := hcg.NewFlagSet()
+
+	flags.StringVar(&hcg.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&hcg.flagAppID, flagAppIDName, "", "")
+
+	if err := hcg.BaseCommand.run(args); err != nil {
+		hcg.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := hcg.getConfig(flags.Args()); err != nil {
+		hcg.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hcg *HostingConfigGetCommand) getConfig(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("expected at most one setting, got %d", len(args))
+	}
+
+	stitchClient, app, err := hcg.resolveHostingApp(hcg.flagProjectID, hcg.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	config, err := stitchClient.FetchHostingConfig(app.GroupID, app.ID)
+	if err != nil {
+		return err
+	}
+
+	var value json.RawMessage
+	if len(args) == 0 {
+		if value, err = json.Marshal(config); err != nil {
+			return err
+		}
+	} else if value, err = config.Get(args[0]); err != nil {
+		return err
+	}
+
+	// display strings as plain text so they can be used directly in scripts
+	if bytes.HasPrefix(value, []byte(`"`)) {
+		var str string
+		if err := json.Unmarshal(value, &str); err != nil {
+			return err
+		}
+		hcg.UI.Output(str)
+		return nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, value, "", "    "); err != nil {
+		return err
+	}
+
+	hcg.UI.Output(indented.String())
+	return nil
+}
+
+// NewHostingConfigSetCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingConfigSetCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &HostingConfigSetCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting config set",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// HostingConfigSetCommand is used to change the static hosting settings of a Stitch App
+type HostingConfigSetCommand struct {
+	*BaseCommand
+
+	flagProjectID string
+	flagAppID     string
+	flagFile      string
+}
+
+// Help returns long-form help information for this command
+func (hcs *HostingConfigSetCommand) Help() string {
+	return `Change the static hosting configuration of a stitch application.
+
+USAGE:
+  hosting config set --app-id [string] [setting] [value]
+  hosting config set --app-id [string] --file [string]
+
+  Sets a single setting to the given value, which may be JSON or plain text, or replaces the entire configuration
+  with the JSON in the given file (such as one written by "hosting config get"). Settings are: ` + strings.Join(hosting.ConfigSettings, ", ") + `
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.
+
+  --file [string]
+	A path to a JSON file containing the entire hosting configuration.` +
+		hcs.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hcs *HostingConfigSetCommand) Synopsis() string {
+	return `Change the static hosting configuration of a stitch application.`
+}
+
+// Run executes the command
+func (hcs *HostingConfigSetCommand) Run(args []string) int {
+	flags := hcs.NewFlagSet()
+
+	flags.StringVar(&hcs.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&hcs.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&hcs.flagFile, hostingConfigFlagFile, "", "")
+
+	if err := hcs.BaseCommand.run(args); err != nil {
+		hcs.Log().Error(err.Error())
+		return 1
+	}
+
+	err := hcs.setConfig(flags.Args())
+	hcs.audit(hcs.flagAppID, "", err)
+	if err != nil {
+		hcs.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hcs *HostingConfigSetCommand) setConfig(args []string) error {
+	validUsage := (hcs.flagFile != "" && len(args) == 0) || (hcs.flagFile == "" && len(args) == 2)
+	if !validUsage {
+		return errHostingConfigSetUsage
+	}
+
+	var fileConfig *hosting.Config
+	if hcs.flagFile != "" {
+		path, err := homedir.Expand(hcs.flagFile)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if fileConfig, err = hosting.ParseConfig(data); err != nil {
+			return fmt.Errorf("failed to parse %s: %s", hcs.flagFile, err)
+		}
+	}
+
+	stitchClient, app, err := hcs.resolveHostingApp(hcs.flagProjectID, hcs.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	if err := hcs.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	config := fileConfig
+	if config == nil {
+		if config, err = stitchClient.FetchHostingConfig(app.GroupID, app.ID); err != nil {
+			return err
+		}
+
+		if err := config.Set(args[0], args[1]); err != nil {
+			return err
+		}
+	}
+
+	if err := stitchClient.UpdateHostingConfig(app.GroupID, app.ID, config); err != nil {
+		return err
+	}
+
+	hcs.Success(fmt.Sprintf("Successfully updated hosting config for '%s'", app.ClientAppID))
+	return nil
+}
+
+// resolveHostingApp returns a client for the logged in user and the app whose hosting config is being managed
+func (c *BaseCommand) resolveHostingApp(projectID, appID string) (api.StitchClient, *models.App, error) {
+	if appID == "" {
+		return nil, nil, errHostingConfigAppIDRequired
+	}
+
+	user, err := c.User()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.LoggedIn() {
+		return nil, nil, u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := c.StitchClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	app, err := fetchApp(stitchClient, projectID, appID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stitchClient, app, nil
+}
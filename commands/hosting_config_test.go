@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func newHostingConfigStitchClient(config *hosting.Config, updated **hosting.Config) *u.MockStitchClient {
+	return &u.MockStitchClient{
+		FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+			return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+		},
+		FetchHostingConfigFn: func(groupID, appID string) (*hosting.Config, error) {
+			return config, nil
+		},
+		UpdateHostingConfigFn: func(groupID, appID string, config *hosting.Config) error {
+			*updated = config
+			return nil
+		},
+	}
+}
+
+func TestHostingConfigGetCommand(t *testing.T) {
+	setup := func() (*HostingConfigGetCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingConfigGetCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		getCommand := cmd.(*HostingConfigGetCommand)
+		getCommand.storage = u.NewEmptyStorage()
+		return getCommand, mockUI
+	}
+
+	t.Run("should require an app-id", func(t *testing.T) {
+		getCommand, mockUI := setup()
+		exitCode := getCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errHostingConfigAppIDRequired.Error())
+	})
+
+	t.Run("should require the user to be logged in", func(t *testing.T) {
+		getCommand, mockUI := setup()
+		exitCode := getCommand.Run([]string{"--app-id=my-app-abcdef"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, user.ErrNotLoggedIn.Error())
+	})
+
+	t.Run("when the user is logged in", func(t *testing.T) {
+		config := &hosting.Config{
+			Enabled:          true,
+			DefaultErrorPath: "/404.html",
+			Rewrites:         []hosting.Rewrite{{From: "/app/*", To: "/index.html"}},
+		}
+
+		for _, tc := range []struct {
+			description    string
+			args           []string
+			expectedOutput string
+		}{
+			{
+				description: "it displays the entire config",
+				args:        []string{"--app-id=my-app-abcdef"},
+				expectedOutput: `{
+    "enabled": true,
+    "default_error_path": "/404.html",
+    "rewrites": [
+        {
+            "from": "/app/*",
+            "to": "/index.html"
+        }
+    ]
+}
+`,
+			},
+			{
+				description:    "it displays a string setting as plain text",
+				args:           []string{"--app-id=my-app-abcdef", "default_error_path"},
+				expectedOutput: "/404.html\n",
+			},
+			{
+				description:    "it displays an unset setting as null",
+				args:           []string{"--app-id=my-app-abcdef", "custom_domain"},
+				expectedOutput: "null\n",
+			},
+		} {
+			t.Run(tc.description, func(t *testing.T) {
+				getCommand, mockUI := setup()
+				getCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+				getCommand.stitchClient = newHostingConfigStitchClient(config, nil)
+
+				exitCode := getCommand.Run(tc.args)
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, tc.expectedOutput)
+			})
+		}
+
+		t.Run("it fails for an unknown setting", func(t *testing.T) {
+			getCommand, mockUI := setup()
+			getCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+			getCommand.stitchClient = newHostingConfigStitchClient(config, nil)
+
+			exitCode := getCommand.Run([]string{"--app-id=my-app-abcdef", "bogus"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `unknown hosting setting "bogus"`)
+		})
+	})
+}
+
+func TestHostingConfigSetCommand(t *testing.T) {
+	setup := func() (*HostingConfigSetCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingConfigSetCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		setCommand := cmd.(*HostingConfigSetCommand)
+		setCommand.storage = u.NewEmptyStorage()
+		setCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		return setCommand, mockUI
+	}
+
+	for _, args := range [][]string{
+		{"--app-id=my-app-abcdef"},
+		{"--app-id=my-app-abcdef", "custom_domain"},
+		{"--app-id=my-app-abcdef", "--file=config.json", "custom_domain", "www.example.com"},
+	} {
+		t.Run("should fail with invalid usage", func(t *testing.T) {
+			setCommand, mockUI := setup()
+			exitCode := setCommand.Run(args)
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errHostingConfigSetUsage.Error())
+		})
+	}
+
+	t.Run("it updates a single setting", func(t *testing.T) {
+		setCommand, mockUI := setup()
+
+		var updated *hosting.Config
+		setCommand.stitchClient = newHostingConfigStitchClient(&hosting.Config{Enabled: true}, &updated)
+
+		exitCode := setCommand.Run([]string{"--app-id=my-app-abcdef", "custom_domain", "www.example.com"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully updated hosting config for 'my-app-abcdef'")
+		u.So(t, updated, gc.ShouldResemble, &hosting.Config{Enabled: true, CustomDomain: "www.example.com"})
+	})
+
+	t.Run("it replaces the config with one from a file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "stitch-hosting-config")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "config.json")
+		u.So(t, ioutil.WriteFile(path, []byte(`{"enabled": true, "redirects": [{"from": "/old", "to": "/new"}]}`), 0644), gc.ShouldBeNil)
+
+		setCommand, mockUI := setup()
+
+		var updated *hosting.Config
+		setCommand.stitchClient = newHostingConfigStitchClient(&hosting.Config{CustomDomain: "www.example.com"}, &updated)
+
+		exitCode := setCommand.Run([]string{"--app-id=my-app-abcdef", "--file=" + path})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, updated, gc.ShouldResemble, &hosting.Config{
+			Enabled:   true,
+			Redirects: []hosting.Redirect{{From: "/old", To: "/new"}},
+		})
+	})
+}
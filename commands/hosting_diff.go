@@ -0,0 +1,268 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	hostingDiffFlagPath     = "path"
+	hostingDiffFlagManifest = "manifest"
+	hostingDiffFlagStrategy = "strategy"
+
+	hostingDiffFlagAgainstDeployment = "against-deployment"
+)
+
+var errHostingDiffAppIDRequired = fmt.Errorf("an App ID (--%s=[string]) or a manifest (--%s=[string]) must be supplied to diff hosting assets", flagAppIDName, hostingDiffFlagManifest)
+
+// NewHostingDiffCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingDiffCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &HostingDiffCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting diff",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// HostingDiffCommand is used to compare local hosting assets to those deployed, or to a saved manifest of them
+type HostingDiffCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagProjectID string
+	flagAppID     string
+	flagAppPath   string
+	flagManifest  string
+	flagStrategy  string
+
+	flagAgainstDeployment string
+}
+
+// Help returns long-form help information for this command
+func (hdc *HostingDiffCommand) Help() string {
+	return `Show the changes that importing the local hosting assets of a stitch application would make.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --manifest [string]
+	Compare against a manifest saved by "import --save-manifest" instead of the deployed app. No login is needed.
+
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja"). Defaults to the App ID in the app's stitch.json.
+
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.
+
+  --strategy [merge|replace] (default: merge)
+	The import strategy to diff for. Assets missing locally are only shown as removed with "replace".
+
+  --against-deployment [string]
+	Instead of the local assets, compare the assets deployed now to those the app had as of the deployment with this ID, showing what rolling back to it would change.` +
+		hdc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hdc *HostingDiffCommand) Synopsis() string {
+	return `Show the changes that importing local hosting assets would make.`
+}
+
+// Run executes the command
+func (hdc *HostingDiffCommand) Run(args []string) int {
+	flags := hdc.NewFlagSet()
+
+	flags.StringVar(&hdc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&hdc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&hdc.flagAppPath, hostingDiffFlagPath, "", "")
+	flags.StringVar(&hdc.flagManifest, hostingDiffFlagManifest, "", "")
+	flags.StringVar(&hdc.flagStrategy, hostingDiffFlagStrategy, importStrategyMerge, "")
+	flags.StringVar(&hdc.flagAgainstDeployment, hostingDiffFlagAgainstDeployment, "", "")
+
+	if err := hdc.BaseCommand.run(args); err != nil {
+		hdc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := hdc.diff(); err != nil {
+		hdc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hdc *HostingDiffCommand) diff() error {
+	if hdc.flagStrategy != importStrategyMerge && hdc.flagStrategy != importStrategyReplace {
+		return fmt.Errorf("unknown import strategy %q; accepted values are [%s|%s]", hdc.flagStrategy, importStrategyMerge, importStrategyReplace)
+	}
+
+	if hdc.flagAgainstDeployment != "" {
+		return hdc.diffDeployment()
+	}
+
+	appPath, err := hdc.resolveAppDirectory(hdc.flagAppPath, hdc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	appInstanceData := models.AppInstanceData{}
+	if err := appInstanceData.UnmarshalFile(appPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	appID := hdc.flagAppID
+	if appID == "" {
+		appID = appInstanceData.AppID()
+	}
+
+	projectConfig, err := models.LoadProjectConfig(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+	}
+
+	_, localAssetMetadata, cleanup, err := hdc.listLocalAssets(appPath, appID, projectConfig.Hosting)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	remoteAssetMetadata, against, err := hdc.remoteAssetMetadata(appID)
+	if err != nil {
+		return err
+	}
+
+	diffs := hosting.DiffAssetMetadata(localAssetMetadata, remoteAssetMetadata, hdc.flagStrategy == importStrategyMerge).Diff()
+	if len(diffs) == 0 {
+		hdc.Log().Info(fmt.Sprintf("Local hosting assets are identical to %s.", against))
+		return nil
+	}
+
+	for _, diff := range diffs {
+		hdc.Diff(diff)
+	}
+
+	return nil
+}
+
+// remoteAssetMetadata returns the asset metadata to diff against, along with a description of where it came from
+func (hdc *HostingDiffCommand) remoteAssetMetadata(appID string) ([]hosting.AssetMetadata, string, error) {
+	if hdc.flagManifest != "" {
+		manifestPath, err := homedir.Expand(hdc.flagManifest)
+		if err != nil {
+			return nil, "", err
+		}
+
+		assetMetadata, err := hosting.ManifestFileToAssetMetadata(manifestPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read manifest %s: %s", hdc.flagManifest, err)
+		}
+
+		return assetMetadata, fmt.Sprintf("manifest %s", hdc.flagManifest), nil
+	}
+
+	stitchClient, app, err := hdc.fetchDeployedApp(appID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	assetMetadata, err := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("error retrieving remote assets: %s", err)
+	}
+
+	return assetMetadata, fmt.Sprintf("those deployed to '%s'", app.ClientAppID), nil
+}
+
+// diffDeployment shows the changes to the deployed assets that rolling back to the deployment given by
+// --against-deployment would make
+func (hdc *HostingDiffCommand) diffDeployment() error {
+	if hdc.flagManifest != "" {
+		return fmt.Errorf("--%s cannot be used with --%s", hostingDiffFlagAgainstDeployment, hostingDiffFlagManifest)
+	}
+
+	appID := hdc.flagAppID
+	if appID == "" {
+		if appPath, err := hdc.resolveAppDirectory(hdc.flagAppPath, hdc.workingDirectory); err == nil {
+			appInstanceData := models.AppInstanceData{}
+			if err := appInstanceData.UnmarshalFile(appPath); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			appID = appInstanceData.AppID()
+		}
+	}
+
+	stitchClient, app, err := hdc.fetchDeployedApp(appID)
+	if err != nil {
+		return err
+	}
+
+	deployedAssetMetadata, err := stitchClient.ListAssetsForDeployment(app.GroupID, app.ID, hdc.flagAgainstDeployment)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the hosting assets of deployment %s: %s", hdc.flagAgainstDeployment, err)
+	}
+
+	currentAssetMetadata, err := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving remote assets: %s", err)
+	}
+
+	diffs := hosting.DiffAssetMetadata(deployedAssetMetadata, currentAssetMetadata, false).Diff()
+	if len(diffs) == 0 {
+		hdc.Log().Info(fmt.Sprintf("The hosting assets of '%s' are identical to those of deployment %s.", app.ClientAppID, hdc.flagAgainstDeployment))
+		return nil
+	}
+
+	hdc.Log().Info(fmt.Sprintf("Rolling '%s' back to deployment %s would make these changes to its hosting assets:", app.ClientAppID, hdc.flagAgainstDeployment))
+	for _, diff := range diffs {
+		hdc.Diff(diff)
+	}
+
+	return nil
+}
+
+// fetchDeployedApp fetches the app with the given App ID, which requires the user to be logged in
+func (hdc *HostingDiffCommand) fetchDeployedApp(appID string) (api.StitchClient, *models.App, error) {
+	if appID == "" {
+		return nil, nil, errHostingDiffAppIDRequired
+	}
+
+	user, err := hdc.User()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.LoggedIn() {
+		return nil, nil, u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := hdc.StitchClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	app, err := fetchApp(stitchClient, hdc.flagProjectID, appID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stitchClient, app, nil
+}
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestHostingDiffCommand(t *testing.T) {
+	setup := func() (*HostingDiffCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingDiffCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		diffCommand := cmd.(*HostingDiffCommand)
+		diffCommand.storage = u.NewEmptyStorage()
+		return diffCommand, mockUI
+	}
+
+	configArg := "--config-path=../testdata/configs/tmp/stitch.json"
+	defer os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+	manifestDir, err := ioutil.TempDir("", "stitch-hosting-diff")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(manifestDir)
+
+	manifestPath := filepath.Join(manifestDir, "manifest.json")
+	u.So(t, hosting.WriteManifestFile(manifestPath, []hosting.AssetMetadata{
+		{FilePath: "/asset_file0.json", FileHash: "stale", Attrs: []hosting.AssetAttribute{}},
+		{FilePath: "/gone.html", FileHash: "abc"},
+	}), gc.ShouldBeNil)
+
+	t.Run("should require an app id or a manifest", func(t *testing.T) {
+		diffCommand, mockUI := setup()
+		exitCode := diffCommand.Run([]string{"--path=../testdata/full_app", configArg})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errHostingDiffAppIDRequired.Error())
+	})
+
+	t.Run("should require the user to be logged in to diff against a deployed app", func(t *testing.T) {
+		diffCommand, mockUI := setup()
+		exitCode := diffCommand.Run([]string{"--path=../testdata/full_app", "--app-id=my-app-abcdef", configArg})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, user.ErrNotLoggedIn.Error())
+	})
+
+	for _, tc := range []struct {
+		description    string
+		args           []string
+		expectedOutput string
+	}{
+		{
+			description: "it diffs against a manifest without logging in",
+			args:        []string{"--manifest=" + manifestPath},
+			expectedOutput: "New Files:\n" +
+				"\t+ /asset_file1.html\n" +
+				"\t+ /ships/nostromo.json\n" +
+				"Modified Files:\n" +
+				"\t* /asset_file0.json\n",
+		},
+		{
+			description: "it shows assets missing locally as removed when replacing",
+			args:        []string{"--manifest=" + manifestPath, "--strategy=replace"},
+			expectedOutput: "New Files:\n" +
+				"\t+ /asset_file1.html\n" +
+				"\t+ /ships/nostromo.json\n" +
+				"Removed Files:\n" +
+				"\t- /gone.html\n" +
+				"Modified Files:\n" +
+				"\t* /asset_file0.json\n",
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			diffCommand, mockUI := setup()
+			exitCode := diffCommand.Run(append([]string{"--path=../testdata/full_app", configArg}, tc.args...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, tc.expectedOutput)
+		})
+	}
+
+	t.Run("it diffs against the deployed app", func(t *testing.T) {
+		diffCommand, mockUI := setup()
+		diffCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		diffCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+		}
+
+		exitCode := diffCommand.Run([]string{"--path=../testdata/full_app", "--app-id=my-app-abcdef", configArg})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "New Files:")
+	})
+
+	t.Run("with --against-deployment", func(t *testing.T) {
+		newStitchClient := func() *u.MockStitchClient {
+			return &u.MockStitchClient{
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+				},
+				ListAssetsForAppIDFn: func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+					return []hosting.AssetMetadata{
+						{FilePath: "/index.html", FileHash: "new"},
+						{FilePath: "/added.js", FileHash: "abc"},
+					}, nil
+				},
+			}
+		}
+
+		t.Run("it shows what rolling the deployed assets back to the deployment would change", func(t *testing.T) {
+			diffCommand, mockUI := setup()
+			diffCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+			stitchClient := newStitchClient()
+			var listedDeploymentID string
+			stitchClient.ListAssetsForDeploymentFn = func(groupID, appID, deploymentID string) ([]hosting.AssetMetadata, error) {
+				listedDeploymentID = deploymentID
+				return []hosting.AssetMetadata{
+					{FilePath: "/index.html", FileHash: "old"},
+					{FilePath: "/removed.css", FileHash: "def"},
+				}, nil
+			}
+			diffCommand.stitchClient = stitchClient
+
+			exitCode := diffCommand.Run([]string{"--app-id=my-app-abcdef", "--against-deployment=deployment-id", configArg})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, listedDeploymentID, gc.ShouldEqual, "deployment-id")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Rolling 'my-app-abcdef' back to deployment deployment-id would make these changes to its hosting assets:\n"+
+				"New Files:\n"+
+				"\t+ /removed.css\n"+
+				"Removed Files:\n"+
+				"\t- /added.js\n"+
+				"Modified Files:\n"+
+				"\t* /index.html\n")
+		})
+
+		t.Run("it fails if the assets of the deployment cannot be fetched", func(t *testing.T) {
+			diffCommand, mockUI := setup()
+			diffCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+			stitchClient := newStitchClient()
+			stitchClient.ListAssetsForDeploymentFn = func(groupID, appID, deploymentID string) ([]hosting.AssetMetadata, error) {
+				return nil, errors.New("deployment not found")
+			}
+			diffCommand.stitchClient = stitchClient
+
+			exitCode := diffCommand.Run([]string{"--app-id=my-app-abcdef", "--against-deployment=deployment-id", configArg})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed to fetch the hosting assets of deployment deployment-id: deployment not found")
+		})
+
+		t.Run("it cannot be combined with --manifest", func(t *testing.T) {
+			diffCommand, mockUI := setup()
+			exitCode := diffCommand.Run([]string{"--app-id=my-app-abcdef", "--against-deployment=deployment-id", "--manifest=" + manifestPath, configArg})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--against-deployment cannot be used with --manifest")
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/10gen/stitch-cli/hosting"
+
+	"github.com/mitchellh/cli"
+)
+
+const hostingHeadersFlagPath = "path"
+
+var errHostingHeadersPathRequired = fmt.Errorf("the path of an asset (--%s=[string]) must be supplied to show its headers", hostingHeadersFlagPath)
+
+// NewHostingHeadersCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingHeadersCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &HostingHeadersCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting headers",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// HostingHeadersCommand is used to show the response headers a hosted asset is served with
+type HostingHeadersCommand struct {
+	*BaseCommand
+
+	flagProjectID string
+	flagAppID     string
+	flagPath      string
+}
+
+// Help returns long-form help information for this command
+func (hhc *HostingHeadersCommand) Help() string {
+	return `Show the response headers a hosted asset is served with: its attributes, along with the default headers of the hosting config that it does not set itself.
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+  --` + hostingHeadersFlagPath + ` [string]
+	The path of the asset, e.g. "/index.html".
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.` +
+		hhc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hhc *HostingHeadersCommand) Synopsis() string {
+	return `Show the response headers of a hosted asset.`
+}
+
+// Run executes the command
+func (hhc *HostingHeadersCommand) Run(args []string) int {
+	flags := hhc.NewFlagSet()
+
+	flags.StringVar(&hhc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&hhc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&hhc.flagPath, hostingHeadersFlagPath, "", "")
+
+	if err := hhc.BaseCommand.run(args); err != nil {
+		hhc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := hhc.headers(); err != nil {
+		hhc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hhc *HostingHeadersCommand) headers() error {
+	if hhc.flagPath == "" {
+		return errHostingHeadersPathRequired
+	}
+
+	if !strings.HasPrefix(hhc.flagPath, "/") {
+		return fmt.Errorf("invalid path %q: paths must start with \"/\"", hhc.flagPath)
+	}
+
+	stitchClient, app, err := hhc.resolveHostingApp(hhc.flagProjectID, hhc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	assetMetadata, err := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving remote assets: %s", err)
+	}
+
+	var asset *hosting.AssetMetadata
+	for i := range assetMetadata {
+		if assetMetadata[i].FilePath == hhc.flagPath {
+			asset = &assetMetadata[i]
+			break
+		}
+	}
+
+	if asset == nil || asset.IsDir() {
+		return fmt.Errorf("there is no hosted asset at '%s' in '%s'", hhc.flagPath, app.ClientAppID)
+	}
+
+	config, err := stitchClient.FetchHostingConfig(app.GroupID, app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the hosting config of '%s': %s", app.ClientAppID, err)
+	}
+
+	headers := hosting.EffectiveHeaders(*asset, config)
+	if len(headers) == 0 {
+		hhc.Log().Info(fmt.Sprintf("'%s' is served without any headers of its own or from the hosting config.", hhc.flagPath))
+		return nil
+	}
+
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		lines[i] = header.Name + ": " + header.Value
+		if header.Default {
+			lines[i] += "  (default_headers)"
+		}
+	}
+
+	hhc.UI.Output(strings.Join(lines, "\n"))
+	return nil
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestHostingHeadersCommand(t *testing.T) {
+	setup := func() (*HostingHeadersCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingHeadersCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		headersCommand := cmd.(*HostingHeadersCommand)
+		headersCommand.storage = u.NewEmptyStorage()
+		headersCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		headersCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+			ListAssetsForAppIDFn: func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+				return []hosting.AssetMetadata{
+					{FilePath: "/static/"},
+					{FilePath: "/index.html", Attrs: []hosting.AssetAttribute{
+						{Name: hosting.AttributeContentType, Value: "text/html"},
+						{Name: hosting.AttributeCacheControl, Value: "no-cache"},
+					}},
+				}, nil
+			},
+			FetchHostingConfigFn: func(groupID, appID string) (*hosting.Config, error) {
+				return &hosting.Config{DefaultHeaders: []hosting.AssetAttribute{
+					{Name: hosting.AttributeCacheControl, Value: "public, max-age=3600"},
+					{Name: hosting.AttributeContentLanguage, Value: "en"},
+				}}, nil
+			},
+		}
+		return headersCommand, mockUI
+	}
+
+	t.Run("it requires a path", func(t *testing.T) {
+		headersCommand, mockUI := setup()
+		exitCode := headersCommand.Run([]string{"--app-id=my-app-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errHostingHeadersPathRequired.Error())
+	})
+
+	t.Run("it shows the attributes of the asset along with the default headers it does not override", func(t *testing.T) {
+		headersCommand, mockUI := setup()
+		exitCode := headersCommand.Run([]string{"--app-id=my-app-abcde", "--path=/index.html"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Cache-Control: no-cache\n"+
+			"Content-Language: en  (default_headers)\n"+
+			"Content-Type: text/html\n")
+	})
+
+	t.Run("it fails for a path without an asset", func(t *testing.T) {
+		for _, path := range []string{"/missing.html", "/static/"} {
+			headersCommand, mockUI := setup()
+			exitCode := headersCommand.Run([]string{"--app-id=my-app-abcde", "--path=" + path})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "there is no hosted asset at '"+path+"' in 'my-app-abcde'")
+		}
+	})
+}
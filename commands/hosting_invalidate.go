@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+const hostingInvalidateFlagPath = "path"
+
+var errHostingInvalidatePathRequired = fmt.Errorf("at least one path (--%s=[string]) must be supplied to invalidate", hostingInvalidateFlagPath)
+
+// NewHostingInvalidateCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingInvalidateCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &HostingInvalidateCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting invalidate",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// HostingInvalidateCommand is used to invalidate the CDN cache of hosted assets without importing
+type HostingInvalidateCommand struct {
+	*BaseCommand
+
+	flagProjectID string
+	flagAppID     string
+	flagPaths     stringsFlag
+}
+
+// Help returns long-form help information for this command
+func (hic *HostingInvalidateCommand) Help() string {
+	return `Invalidate the CDN cache of hosted assets right away, e.g. to stop serving a broken file, without importing the app.
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja")
+
+  --` + hostingInvalidateFlagPath + ` [string]
+	The path of an asset to invalidate, e.g. "/index.html", or "/*" for every asset. A "*" may only end a path. May be given more than once.
+
+OPTIONS:
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.` +
+		hic.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hic *HostingInvalidateCommand) Synopsis() string {
+	return `Invalidate the CDN cache of hosted assets.`
+}
+
+// Run executes the command
+func (hic *HostingInvalidateCommand) Run(args []string) int {
+	flags := hic.NewFlagSet()
+
+	flags.StringVar(&hic.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&hic.flagAppID, flagAppIDName, "", "")
+	flags.Var(&hic.flagPaths, hostingInvalidateFlagPath, "")
+
+	if err := hic.BaseCommand.run(args); err != nil {
+		hic.Log().Error(err.Error())
+		return 1
+	}
+
+	err := hic.invalidate()
+	hic.audit(hic.flagAppID, "", err)
+	if err != nil {
+		hic.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hic *HostingInvalidateCommand) invalidate() error {
+	if len(hic.flagPaths) == 0 {
+		return errHostingInvalidatePathRequired
+	}
+
+	for _, path := range hic.flagPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid path %q: paths must start with \"/\"", path)
+		}
+		if idx := strings.Index(path, "*"); idx != -1 && idx != len(path)-1 {
+			return fmt.Errorf("invalid path %q: a \"*\" may only end a path", path)
+		}
+	}
+
+	stitchClient, app, err := hic.resolveHostingApp(hic.flagProjectID, hic.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	if err := hic.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	for _, path := range hic.flagPaths {
+		if err := stitchClient.InvalidateCache(app.GroupID, app.ID, path); err != nil {
+			return fmt.Errorf("failed to invalidate '%s': %s", path, err)
+		}
+		hic.Log().Info(fmt.Sprintf("Invalidated '%s'", path))
+	}
+
+	hic.Success(fmt.Sprintf("Successfully invalidated %d path(s) for '%s'", len(hic.flagPaths), hic.flagAppID))
+	return nil
+}
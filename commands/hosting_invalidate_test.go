@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestHostingInvalidateCommand(t *testing.T) {
+	setup := func() (*HostingInvalidateCommand, *cli.MockUi, *[]string) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingInvalidateCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		var invalidated []string
+		invalidateCommand := cmd.(*HostingInvalidateCommand)
+		invalidateCommand.storage = u.NewEmptyStorage()
+		invalidateCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		invalidateCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+			InvalidateCacheFn: func(groupID, appID, path string) error {
+				if path == "/broken.html" {
+					return errors.New("oh noes")
+				}
+				invalidated = append(invalidated, path)
+				return nil
+			},
+		}
+		return invalidateCommand, mockUI, &invalidated
+	}
+
+	t.Run("it requires a path", func(t *testing.T) {
+		invalidateCommand, mockUI, _ := setup()
+		exitCode := invalidateCommand.Run([]string{"--app-id=my-app-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errHostingInvalidatePathRequired.Error())
+	})
+
+	t.Run("it rejects paths CloudFront does not accept", func(t *testing.T) {
+		for _, path := range []string{"index.html", "/*.html"} {
+			invalidateCommand, mockUI, invalidated := setup()
+			exitCode := invalidateCommand.Run([]string{"--app-id=my-app-abcde", "--path=" + path})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "invalid path")
+			u.So(t, *invalidated, gc.ShouldBeEmpty)
+		}
+	})
+
+	t.Run("it invalidates each path", func(t *testing.T) {
+		invalidateCommand, mockUI, invalidated := setup()
+		exitCode := invalidateCommand.Run([]string{"--app-id=my-app-abcde", "--path=/index.html", "--path=/static/*"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, *invalidated, gc.ShouldResemble, []string{"/index.html", "/static/*"})
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully invalidated 2 path(s) for 'my-app-abcde'")
+	})
+
+	t.Run("it reports a failed invalidation", func(t *testing.T) {
+		invalidateCommand, mockUI, _ := setup()
+		exitCode := invalidateCommand.Run([]string{"--app-id=my-app-abcde", "--path=/broken.html"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed to invalidate '/broken.html': oh noes")
+	})
+}
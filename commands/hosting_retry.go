@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	hostingRetryFlagFrom = "from"
+	hostingRetryFlagPath = "path"
+)
+
+var errHostingRetryFromRequired = fmt.Errorf("a retry list (--%s=[string]) written by \"import --%s\" must be supplied", hostingRetryFlagFrom, importFlagIncludeHosting)
+
+// NewHostingRetryCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewHostingRetryCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &HostingRetryCommand{
+			BaseCommand: &BaseCommand{
+				Name: "hosting retry",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// HostingRetryCommand is used to retry the hosting asset operations that failed during an import
+type HostingRetryCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	// clientAppID is the app whose operations are retried, as read from the retry list
+	clientAppID string
+
+	flagFrom    string
+	flagAppPath string
+}
+
+// Help returns long-form help information for this command
+func (hrc *HostingRetryCommand) Help() string {
+	return `Retry the hosting asset uploads, attribute updates, and deletions that failed during an import.
+
+REQUIRED:
+  --from [string]
+	The retry list written by "import --include-hosting" when hosting operations fail (see its --retry-file option).
+	Operations that fail again are written back to it, and it is removed once they all succeed.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.` +
+		hrc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (hrc *HostingRetryCommand) Synopsis() string {
+	return `Retry the hosting asset operations that failed during an import.`
+}
+
+// Run executes the command
+func (hrc *HostingRetryCommand) Run(args []string) int {
+	flags := hrc.NewFlagSet()
+
+	flags.StringVar(&hrc.flagFrom, hostingRetryFlagFrom, "", "")
+	flags.StringVar(&hrc.flagAppPath, hostingRetryFlagPath, "", "")
+
+	if err := hrc.BaseCommand.run(args); err != nil {
+		hrc.Log().Error(err.Error())
+		return 1
+	}
+
+	err := hrc.retry()
+	hrc.audit(hrc.clientAppID, "", err)
+	if err != nil {
+		hrc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (hrc *HostingRetryCommand) retry() error {
+	if hrc.flagFrom == "" {
+		return errHostingRetryFromRequired
+	}
+
+	retryPath, err := homedir.Expand(hrc.flagFrom)
+	if err != nil {
+		return err
+	}
+
+	retryList, err := hosting.RetryFileToRetryList(retryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read retry list %s: %s", hrc.flagFrom, err)
+	}
+
+	hrc.clientAppID = retryList.ClientAppID
+
+	if len(retryList.Failures) == 0 {
+		hrc.Log().Info("There are no hosting operations to retry.")
+		return os.Remove(retryPath)
+	}
+
+	user, err := hrc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	appPath, err := hrc.resolveAppDirectory(hrc.flagAppPath, hrc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	projectConfig, err := models.LoadProjectConfig(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+	}
+
+	rootDir, localAssetMetadata, cleanup, err := hrc.listLocalAssets(appPath, retryList.ClientAppID, projectConfig.Hosting)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	stitchClient, err := hrc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	app := &models.App{GroupID: retryList.GroupID, ID: retryList.AppID, ClientAppID: retryList.ClientAppID}
+	if err := hrc.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	assetMetadataDiffs, failures := retryList.AssetMetadataDiffs(localAssetMetadata)
+	for _, failure := range failures {
+		hrc.Log().Error(fmt.Sprintf("%s '%s' can not be retried => %s", failure.Operation, failure.FilePath, failure.Reason))
+	}
+
+	hrc.Log().Info(fmt.Sprintf("Retrying %d hosting operation(s) for '%s'...", len(retryList.Failures)-len(failures), retryList.ClientAppID))
+	if importErr := ImportHosting(retryList.GroupID, retryList.AppID, rootDir, assetMetadataDiffs, stitchClient, hrc.Log()); importErr != nil {
+		failedErr, ok := importErr.(*hostingImportError)
+		if !ok {
+			return importErr
+		}
+		failures = append(failures, failedErr.failures...)
+	}
+
+	if len(failures) == 0 {
+		if err := os.Remove(retryPath); err != nil {
+			hrc.Log().Warn(fmt.Sprintf("failed to remove retry list %s: %s", hrc.flagFrom, err))
+		}
+		hrc.Success(fmt.Sprintf("Successfully retried %d hosting operation(s)", len(retryList.Failures)))
+		return nil
+	}
+
+	hrc.UI.Output("Failed hosting operations:")
+	hrc.UI.Output(formatFailedOperations(failures))
+
+	retryList.Failures = failures
+	if err := hosting.WriteRetryFile(retryPath, retryList); err != nil {
+		return fmt.Errorf("%d hosting operation(s) failed again, and the list of them could not be saved: %s", len(failures), err)
+	}
+
+	return fmt.Errorf("%d hosting operation(s) failed again; they remain in %s", len(failures), hrc.flagFrom)
+}
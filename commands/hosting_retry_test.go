@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestHostingRetryCommand(t *testing.T) {
+	setup := func() (*HostingRetryCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewHostingRetryCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		retryCommand := cmd.(*HostingRetryCommand)
+		retryCommand.storage = u.NewEmptyStorage()
+		return retryCommand, mockUI
+	}
+
+	configArg := "--config-path=../testdata/configs/tmp/stitch.json"
+	defer os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+	dir, err := ioutil.TempDir("", "stitch-hosting-retry")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	retryPath := filepath.Join(dir, "retry.json")
+	writeRetryList := func() {
+		u.So(t, hosting.WriteRetryFile(retryPath, &hosting.RetryList{
+			GroupID:     "group-id",
+			AppID:       "app-id",
+			ClientAppID: "my-app-abcdef",
+			Failures: []hosting.FailedOperation{
+				{Operation: hosting.OperationUpload, FilePath: "/asset_file0.json", Reason: "timeout"},
+				{Operation: hosting.OperationUpload, FilePath: "/ships/nostromo.json", Reason: "timeout"},
+				{Operation: hosting.OperationDelete, FilePath: "/old.html", Reason: "timeout"},
+			},
+		}), gc.ShouldBeNil)
+	}
+
+	t.Run("should require a retry list", func(t *testing.T) {
+		retryCommand, mockUI := setup()
+		exitCode := retryCommand.Run([]string{"--path=../testdata/full_app", configArg})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errHostingRetryFromRequired.Error())
+	})
+
+	t.Run("should require the user to be logged in", func(t *testing.T) {
+		writeRetryList()
+
+		retryCommand, mockUI := setup()
+		exitCode := retryCommand.Run([]string{"--path=../testdata/full_app", "--from=" + retryPath, configArg})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, user.ErrNotLoggedIn.Error())
+	})
+
+	t.Run("should retry the failed operations and remove the retry list once they succeed", func(t *testing.T) {
+		writeRetryList()
+
+		retryCommand, mockUI := setup()
+		retryCommand.user = &user.User{
+			APIKey:      "my-api-key",
+			AccessToken: u.GenerateValidAccessToken(),
+		}
+
+		var mu sync.Mutex
+		var uploaded, deleted []string
+		retryCommand.stitchClient = &u.MockStitchClient{
+			UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+				u.So(t, groupID, gc.ShouldEqual, "group-id")
+				u.So(t, appID, gc.ShouldEqual, "app-id")
+				mu.Lock()
+				defer mu.Unlock()
+				uploaded = append(uploaded, path)
+				return nil
+			},
+			DeleteAssetFn: func(groupID, appID, path string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				deleted = append(deleted, path)
+				return nil
+			},
+		}
+
+		exitCode := retryCommand.Run([]string{"--path=../testdata/full_app", "--from=" + retryPath, configArg})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully retried 3 hosting operation(s)")
+
+		sort.Strings(uploaded)
+		u.So(t, uploaded, gc.ShouldResemble, []string{"/asset_file0.json", "/ships/nostromo.json"})
+		u.So(t, deleted, gc.ShouldResemble, []string{"/old.html"})
+
+		_, err := os.Stat(retryPath)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+	})
+
+	t.Run("should write the operations that fail again back to the retry list", func(t *testing.T) {
+		writeRetryList()
+
+		retryCommand, mockUI := setup()
+		retryCommand.user = &user.User{
+			APIKey:      "my-api-key",
+			AccessToken: u.GenerateValidAccessToken(),
+		}
+		retryCommand.stitchClient = &u.MockStitchClient{
+			UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+				if path == "/ships/nostromo.json" {
+					return errors.New("oh noes")
+				}
+				return nil
+			},
+			DeleteAssetFn: func(groupID, appID, path string) error {
+				return nil
+			},
+		}
+
+		exitCode := retryCommand.Run([]string{"--path=../testdata/full_app", "--from=" + retryPath, configArg})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "1 hosting operation(s) failed again; they remain in "+retryPath)
+
+		retryList, err := hosting.RetryFileToRetryList(retryPath)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, retryList.Failures, gc.ShouldResemble, []hosting.FailedOperation{
+			{Operation: hosting.OperationUpload, FilePath: "/ships/nostromo.json", Reason: "oh noes"},
+		})
+	})
+}
@@ -1,32 +1,68 @@
 package commands
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/hosting"
 	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/secrets"
 	u "github.com/10gen/stitch-cli/user"
 	"github.com/10gen/stitch-cli/utils"
+	"github.com/10gen/stitch-cli/validation"
 
 	"github.com/mitchellh/cli"
 	"github.com/mitchellh/go-homedir"
 )
 
 const (
-	importFlagPath           = "path"
-	importFlagStrategy       = "strategy"
-	importFlagAppName        = "app-name"
-	importFlagIncludeHosting = "include-hosting"
-	importFlagResetCDNCache  = "reset-cdn-cache"
-	importStrategyMerge      = "merge"
-	importStrategyReplace    = "replace"
+	importFlagPath            = "path"
+	importFlagStrategy        = "strategy"
+	importFlagAppName         = "app-name"
+	importFlagIncludeHosting  = "include-hosting"
+	importFlagResetCDNCache   = "reset-cdn-cache"
+	importFlagInvalidatePath  = "invalidate-path"
+	importFlagStrict          = "strict"
+	importFlagReportFile      = "report-file"
+	importFlagUploadRate      = "upload-rate-limit"
+	importFlagSaveManifest    = "save-manifest"
+	importFlagDecryptIdentity = "decrypt-identity"
+	importFlagNotifyWebhook   = "notify-webhook"
+	importFlagNotifyTemplate  = "notify-template"
+	importFlagKeepGoing       = "keep-going"
+	importFlagRetryFile       = "retry-file"
+	importFlagDeployTimeout   = "deploy-timeout"
+	importFlagVerify          = "verify"
+	importFlagVerifyTimeout   = "verify-timeout"
+	importFlagSmokeTest       = "smoke-test"
+	importFlagCanaryAppID     = "canary-app-id"
+	importFlagSecretsFile     = "secrets-file"
+	importFlagWait            = "wait"
+	importFlagWaitTimeout     = "wait-timeout"
+	importFlagForceUnlock     = "force-unlock"
+	importFlagNoCreate        = "no-create"
+	importFlagHostingOnly     = "hosting-only"
+	importFlagConfigOnly      = "config-only"
+	importFlagBuildFunctions  = "build-functions"
+	importFlagOwner           = "owner"
+	importFlagTag             = "tag"
+	importFlagOrdered         = "ordered"
+	importStrategyMerge       = "merge"
+	importStrategyReplace     = "replace"
+
+	defaultHostingRetryFile = "stitch-hosting-retry.json"
+
+	// secretsKey holds the contents of the app's secrets file in the loaded app
+	secretsKey = "secrets"
 )
 
 // Set of location and deployment model options supported by Stitch backend
@@ -61,10 +97,15 @@ func NewImportCommandFactory(ui cli.Ui) cli.CommandFactory {
 				UI:   ui,
 			},
 			workingDirectory: workingDirectory,
-			writeToDirectory: utils.WriteZipToDir,
+			writeToDirectory: utils.WriteAppToDir,
 			writeAppConfigToFile: func(dest string, app models.AppInstanceData) error {
 				return app.MarshalFile(dest)
 			},
+			writeProjectConfig: func(dest string, config *models.ProjectConfig) error {
+				return config.Save(dest)
+			},
+			decryptArchive: utils.DecryptWithAge,
+			currentBranch:  currentGitBranch,
 		}, nil
 	}
 }
@@ -75,46 +116,204 @@ type ImportCommand struct {
 
 	writeToDirectory     func(dest string, zipData io.Reader, overwrite bool) error
 	writeAppConfigToFile func(dest string, app models.AppInstanceData) error
+	writeProjectConfig   func(dest string, config *models.ProjectConfig) error
+	decryptArchive       func(identityPath, src string) ([]byte, error)
+	currentBranch        func(dir string) (string, error)
 	workingDirectory     string
-
-	flagAppID          string
-	flagAppPath        string
-	flagAppName        string
-	flagGroupID        string
-	flagStrategy       string
-	flagIncludeHosting bool
-	flagResetCDNCache  bool
+	report               *importReport
+	projectConfig        *models.ProjectConfig
+	uploadRateLimit      int64
+
+	// builtFunctionDirs are the directories of the functions whose source was built from local sources, which
+	// the sync keeps rather than writing the deployed source over them
+	builtFunctionDirs []string
+
+	// valueReferences are the values that held secret references before they were resolved, by name, which
+	// the sync writes back rather than the secrets they were resolved to
+	valueReferences map[string]interface{}
+
+	flagAppID           string
+	flagAppPath         string
+	flagAppName         string
+	flagGroupID         string
+	flagOrg             string
+	flagStrategy        string
+	flagIncludeHosting  bool
+	flagResetCDNCache   bool
+	flagInvalidatePaths stringsFlag
+	flagStrict          bool
+	flagReportFile      string
+	flagUploadRate      string
+	flagSaveManifest    string
+	flagDecryptIdentity string
+	flagNotifyWebhook   string
+	flagNotifyTemplate  string
+	flagKeepGoing       bool
+	flagRetryFile       string
+	flagDeployTimeout   time.Duration
+	flagVerify          bool
+	flagVerifyTimeout   time.Duration
+	flagSmokeTest       string
+	flagCanaryAppID     string
+	flagSecretsFile     string
+	flagWait            bool
+	flagWaitTimeout     time.Duration
+	flagForceUnlock     bool
+	flagNoCreate        bool
+	flagHostingOnly     bool
+	flagConfigOnly      bool
+	flagBuildFunctions  bool
+	flagOwner           string
+	flagTag             string
+	flagOrdered         bool
+
+	smokeTests *smokeTests
 }
 
 // Help returns long-form help information for this command
 func (ic *ImportCommand) Help() string {
 	return `Import and deploy a stitch application from a local directory.
 
+Before the app is deployed, the secrets it refers to (by the secret_config of its auth providers and services, values with "from_secret": true, and
+"%%secrets.<name>" expansions) are checked to exist in the app it is imported to. Missing secrets are created from the --secrets-file entry of
+the same name, if any, and the rest are prompted for and created, unless --yes is given, in which case the import fails listing them. Secrets
+whose value the app's secrets.json gives are not checked, as the import creates them.
+
 REQUIRED:
   --app-id [string]
-	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja").
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja"). Defaults to the App ID that the "branches" setting in the app's .stitchrc file maps the current git branch to, if any (e.g. "- {branch: main, app_id: prod-app-abcde}"), and then to the App ID in stitch.json. When "branches" is set, the import fails if the current git branch cannot be determined, such as when HEAD is detached.
 
   --app-name [string]
 	The name of your app to be used if app is to be created new.
 
 OPTIONS:
   --path [string]
-	A path to the local directory containing your app.
+	A path to the local directory containing your app, or to an encrypted archive written by "export --encrypt-with". An archive is not updated by the import: it is not synced with the deployed app, nor given the App ID of an app created from it.
+
+  --decrypt-identity [string]
+	A path to the age identity file used to decrypt an encrypted archive given by --path. Requires the age command (https://age-encryption.org).
+
+  --secrets-file [string]
+	A .env file of values for the fields of an app exported with "export --redact". Each field is named like the environment variable that can
+	also supply it, e.g. ` + redactedEnvPrefix + `AUTH_PROVIDERS_OAUTH2_GOOGLE_CONFIG_CLIENTSECRET for ".config.clientSecret" in "auth_providers/oauth2-google.json".
+	Fields with no value from either are prompted for, unless --yes is given, in which case the import fails listing them.
+	Entries named like a missing secret of the app, e.g. "api-key=env:API_KEY", supply the value it is created with.
 
   --project-id [string]
 	The Atlas Project ID.
 
+  --no-create
+	Fail if the app does not exist, rather than offering to create it, e.g. so that a mistyped App ID fails a CI run instead of waiting on a prompt.
+
+  --org [string]
+	Only offer Atlas Projects in the Organization with this name or ID when prompting for a project.
+
   --strategy [merge|replace] (default: merge)
 	How your app should be imported.	
 	merge - import and overwrite existing entities while preserving those that exist on Stitch. Secrets missing will not be lost.
 	replace - like merge but does not preserve entities missing from the local directory's app configuration.
 
-
   --include-hosting
-	Upload static assets from "/hosting" directory.
+	Upload static assets from "/hosting" directory, and apply the hosting settings (redirects, rewrites, default headers, etc.) in "/hosting/config.json" if it exists. Assets are taken instead from the "roots" configured under "hosting" in ` + models.ProjectConfigFileName + ` if there are any, each a "dir" relative to the app directory hosted under a "prefix" such as "/docs", and are first run through any transforms configured there. Empty directories, and those holding only a "` + utils.HostingDirectoryPlaceholder + `" placeholder, are created as well. Implied by "include: true" under "hosting" in ` + models.ProjectConfigFileName + `.
+
+  --config-only
+	Only import the app's config, making no changes to its hosting assets or settings even if "include" is set under "hosting" in ` + models.ProjectConfigFileName + `,
+	e.g. for a backend hotfix.
+
+  --hosting-only
+	Only import the hosting assets and settings, as --include-hosting does, leaving the rest of the app's config as deployed. Its config is neither
+	diffed nor imported, and the local directory is not synced with it. The app must already exist.
+
+  --build-functions
+	Build a source.js for each function and incoming webhook that has a source.ts, by running the "build" command configured under "functions" in
+	` + models.ProjectConfigFileName + ` (default: ` + utils.DefaultFunctionBuildCommand + `) with the TypeScript on stdin and its path in $FUNCTION_SOURCE.
+	The local directory is left as it is, apart from the source maps of the built functions, which are kept in "` + utils.FunctionSourceMapsDir + `".
+
+  --` + importFlagOwner + ` [string]
+	Only import the entities annotated with "` + utils.OwnerField + `": "<owner>", e.g. "payments", leaving those of other owners, and those without one, as deployed.
+	The incoming webhooks and rules of a service belong to its owner unless annotated themselves. Entities of the owner that are deployed but no
+	longer in the local directory are removed, and only the owner's entities are synced to it. The app must already exist.
+
+  --` + importFlagTag + ` [string]
+	Only import the functions, triggers and services with this tag in the "` + utils.TagsField + `" of their config, e.g. "billing", along with every entity they
+	depend on: the functions and services of triggers, the functions, services and values that sources get from the context, and the incoming
+	webhooks and rules of services. Other entities are left as deployed, and the local directory is not synced. Cannot be combined with
+	--strategy=replace or --` + importFlagOwner + `.
+
+  --` + importFlagOrdered + `
+	Import the new entities that others refer to in their config ahead of them, each stage in an import of its own, e.g. a new service before its
+	new incoming webhooks and rules, or a new function before the new triggers calling it, so that they are not created out of order. This is
+	always done for a new app, and requires exporting an existing one to find which of its entities are new.
 
   --reset-cdn-cache
 	Invalidate cdn cache for modified files.	
+
+  --` + importFlagInvalidatePath + ` [string]
+	Only invalidate the cdn cache for the added, removed or modified files whose paths match this glob, e.g. "/*.html" or "/index.html", rather than for every file.
+	A "*" does not match "/". May be given more than once, and implies --reset-cdn-cache.
+
+  --keep-going
+	Finish the rest of the import (resetting the CDN cache, applying hosting settings, and syncing the local directory) even if some hosting assets fail to upload, update, or delete.
+
+  --retry-file [string] (default: ` + defaultHostingRetryFile + `)
+	Where to write the list of hosting asset operations that failed, which "hosting retry --from" can retry.
+
+  --deploy-timeout [duration] (default: ` + api.DefaultImportDeploymentTimeout.String() + `)
+	How long to wait for an import the server deploys in the background to succeed or fail, e.g. "90s" or "20m".
+
+  --verify
+	After importing, wait until the new deployment is live in every region the app is served from, failing if its rollout fails or does not finish in time.
+
+  --verify-timeout [duration] (default: ` + defaultVerifyTimeout.String() + `)
+	How long --verify waits for the deployment to go live everywhere, e.g. "90s" or "10m".
+
+  --smoke-test [string]
+	A JSON file of checks to run once the app is imported, failing the import if any of them fail. Each check calls either a function (as the system user) or an incoming webhook (with a GET), e.g.
+	{"checks": [
+	  {"name": "adds", "function": "sum", "args": [1, 2], "expect": {"result": 3}},
+	  {"webhook": "http1/status", "query": {"verbose": "true"}, "expect": {"status": 200, "contains": "ok"}}
+	]}
+
+  --canary-app-id [string]
+	The App ID of an existing app to import to first. The app is only imported to the target app once the canary has been imported, and has passed --verify and the --smoke-test checks if they are given. Hosting assets are only imported to the target app.
+
+  --wait
+	If the app is being imported by someone else, wait for that import to finish rather than failing. An app is locked while it is imported, and the lock expires on its own after ` + deployLockTTL.String() + `.
+
+  --wait-timeout [duration] (default: ` + defaultDeployLockWaitTimeout.String() + `)
+	How long to wait for the import by someone else to finish with --wait before failing, e.g. "90s" or "20m".
+
+  --force-unlock
+	Remove the lock held on the app by another import before importing, e.g. if that import was killed before it could release it.
+
+  --upload-rate-limit [string]
+	Limit the combined rate at which hosting assets are uploaded, e.g. "5MB/s" or "512KiB/s".
+
+  --save-manifest [string]
+	After a successful import, save a snapshot of the deployed hosting assets to the given file, which "hosting diff --manifest" can compare against offline.
+
+  --strict
+	Validate the app before importing, failing if any entity configuration is invalid or contains unrecognized fields.
+
+  --report-file [string]
+	Write a JSON report of the import (diff, deployment, hosting changes, durations, and warnings) to the given file, whether or not the import succeeds.
+
+  --notify-webhook [string]
+	POST a JSON summary of the import outcome to the given URL, whether or not the import succeeds. Defaults to the "notify.webhook" setting in the app's .stitchrc file, if any.
+
+  --notify-template [string]
+	A Go text/template used to render the "text" field of the notification from the import report (e.g. "{{.ClientAppID}}: {{.Outcome}}", where the outcome is one of imported, failed, cancelled or no_changes). Defaults to the "notify.template" setting in the app's .stitchrc file, if any.
+
+SECRET REFERENCES:
+  Strings in the app's secrets.json and in its values (other than those that come from a secret), and the values given for redacted fields and missing secrets, may refer to a secret kept elsewhere, which is read at import time. The app directory keeps the references when it is synced with the imported app:
+	env:<NAME>                      The environment variable NAME.
+	vault:<path>#<field>            A field of a Vault KV secret, read with the vault command.
+	aws-sm:<secret-id>[#<key>]      An AWS Secrets Manager secret, or a key of one holding a JSON object, read with the aws command.
+
+SHARED CODE:
+  Modules in "functions/` + utils.FunctionLibDir + `" are shared between the functions and incoming webhooks of the app, which the platform has no way of doing itself.
+  A source.js that requires one, e.g. as require("` + utils.FunctionLibDir + `/dates") or require("../` + utils.FunctionLibDir + `/dates") for "functions/` + utils.FunctionLibDir + `/dates.js", has it injected into its
+  source on import, along with the modules it requires in turn by relative paths. What was injected into each function is listed in "` + utils.FunctionLibManifestFileName + `".
 	` +
 		ic.BaseCommand.Help()
 }
@@ -131,29 +330,138 @@ func (ic *ImportCommand) Run(args []string) int {
 	flags.StringVar(&ic.flagAppID, flagAppIDName, "", "")
 	flags.StringVar(&ic.flagAppPath, importFlagPath, "", "")
 	flags.StringVar(&ic.flagGroupID, flagProjectIDName, "", "")
+	flags.StringVar(&ic.flagOrg, flagOrgName, "", "")
 	flags.StringVar(&ic.flagAppName, importFlagAppName, "", "")
 	flags.StringVar(&ic.flagStrategy, importFlagStrategy, importStrategyMerge, "")
 	flags.BoolVar(&ic.flagIncludeHosting, importFlagIncludeHosting, false, "")
 	flags.BoolVar(&ic.flagResetCDNCache, importFlagResetCDNCache, false, "")
+	flags.Var(&ic.flagInvalidatePaths, importFlagInvalidatePath, "")
+	flags.BoolVar(&ic.flagStrict, importFlagStrict, false, "")
+	flags.StringVar(&ic.flagReportFile, importFlagReportFile, "", "")
+	flags.StringVar(&ic.flagUploadRate, importFlagUploadRate, "", "")
+	flags.StringVar(&ic.flagSaveManifest, importFlagSaveManifest, "", "")
+	flags.StringVar(&ic.flagDecryptIdentity, importFlagDecryptIdentity, "", "")
+	flags.StringVar(&ic.flagNotifyWebhook, importFlagNotifyWebhook, "", "")
+	flags.StringVar(&ic.flagNotifyTemplate, importFlagNotifyTemplate, "", "")
+	flags.BoolVar(&ic.flagKeepGoing, importFlagKeepGoing, false, "")
+	flags.StringVar(&ic.flagRetryFile, importFlagRetryFile, defaultHostingRetryFile, "")
+	flags.DurationVar(&ic.flagDeployTimeout, importFlagDeployTimeout, api.DefaultImportDeploymentTimeout, "")
+	flags.BoolVar(&ic.flagVerify, importFlagVerify, false, "")
+	flags.DurationVar(&ic.flagVerifyTimeout, importFlagVerifyTimeout, defaultVerifyTimeout, "")
+	flags.StringVar(&ic.flagSmokeTest, importFlagSmokeTest, "", "")
+	flags.StringVar(&ic.flagCanaryAppID, importFlagCanaryAppID, "", "")
+	flags.StringVar(&ic.flagSecretsFile, importFlagSecretsFile, "", "")
+	flags.BoolVar(&ic.flagWait, importFlagWait, false, "")
+	flags.DurationVar(&ic.flagWaitTimeout, importFlagWaitTimeout, defaultDeployLockWaitTimeout, "")
+	flags.BoolVar(&ic.flagForceUnlock, importFlagForceUnlock, false, "")
+	flags.BoolVar(&ic.flagNoCreate, importFlagNoCreate, false, "")
+	flags.BoolVar(&ic.flagHostingOnly, importFlagHostingOnly, false, "")
+	flags.BoolVar(&ic.flagConfigOnly, importFlagConfigOnly, false, "")
+	flags.BoolVar(&ic.flagBuildFunctions, importFlagBuildFunctions, false, "")
+	flags.StringVar(&ic.flagOwner, importFlagOwner, "", "")
+	flags.StringVar(&ic.flagTag, importFlagTag, "", "")
+	flags.BoolVar(&ic.flagOrdered, importFlagOrdered, false, "")
 
 	if err := ic.BaseCommand.run(args); err != nil {
-		ic.UI.Error(err.Error())
+		ic.Log().Error(err.Error())
 		return 1
 	}
 
 	if ic.flagStrategy != importStrategyMerge && ic.flagStrategy != importStrategyReplace {
-		ic.UI.Error(fmt.Sprintf("unknown import strategy %q; accepted values are [%s|%s]", ic.flagStrategy, importStrategyMerge, importStrategyReplace))
+		ic.Log().Error(fmt.Sprintf("unknown import strategy %q; accepted values are [%s|%s]", ic.flagStrategy, importStrategyMerge, importStrategyReplace))
+		return 1
+	}
+
+	if ic.flagHostingOnly {
+		if ic.flagCanaryAppID != "" || ic.flagVerify {
+			ic.Log().Error(fmt.Sprintf("--%s cannot be combined with --%s or --%s", importFlagHostingOnly, importFlagCanaryAppID, importFlagVerify))
+			return 1
+		}
+		ic.flagIncludeHosting = true
+	}
+
+	if ic.flagHostingOnly && (ic.flagOwner != "" || ic.flagTag != "") {
+		ic.Log().Error(fmt.Sprintf("--%s cannot be combined with --%s or --%s", importFlagHostingOnly, importFlagOwner, importFlagTag))
+		return 1
+	}
+
+	if ic.flagTag != "" && (ic.flagOwner != "" || ic.flagStrategy == importStrategyReplace) {
+		ic.Log().Error(fmt.Sprintf("--%s cannot be combined with --%s or --%s=%s", importFlagTag, importFlagOwner, importFlagStrategy, importStrategyReplace))
+		return 1
+	}
+
+	if ic.flagConfigOnly && (ic.flagIncludeHosting || ic.flagResetCDNCache || len(ic.flagInvalidatePaths) > 0) {
+		ic.Log().Error(fmt.Sprintf(
+			"--%s cannot be combined with --%s, --%s, --%s or --%s",
+			importFlagConfigOnly,
+			importFlagIncludeHosting,
+			importFlagHostingOnly,
+			importFlagResetCDNCache,
+			importFlagInvalidatePath,
+		))
+		return 1
+	}
+
+	if err := hosting.CheckInvalidationGlobs(ic.flagInvalidatePaths); err != nil {
+		ic.Log().Error(fmt.Sprintf("--%s error: %s", importFlagInvalidatePath, err))
 		return 1
 	}
 
-	if err := ic.importApp(); err != nil {
-		ic.UI.Error(err.Error())
+	if ic.flagUploadRate != "" {
+		rate, err := utils.ParseRate(ic.flagUploadRate)
+		if err != nil {
+			ic.Log().Error(fmt.Sprintf("--%s error: %s", importFlagUploadRate, err))
+			return 1
+		}
+		ic.uploadRateLimit = rate
+	}
+
+	if ic.flagSmokeTest != "" {
+		tests, err := readSmokeTests(ic.flagSmokeTest)
+		if err != nil {
+			ic.Log().Error(fmt.Sprintf("failed to read smoke tests %s: %s", ic.flagSmokeTest, err))
+			return 1
+		}
+		ic.smokeTests = tests
+	}
+
+	ic.report = newImportReport()
+	ic.report.Strategy = ic.flagStrategy
+	ic.logger = &reportingLogger{Logger: ic.Log(), report: ic.report}
+
+	importErr := ic.auditedImportApp()
+
+	if ic.flagReportFile != "" {
+		if err := ic.report.writeFile(ic.flagReportFile); err != nil {
+			ic.Log().Error(fmt.Sprintf("failed to write import report: %s", err))
+			return 1
+		}
+	}
+
+	if err := ic.notify(); err != nil {
+		ic.Log().Warn(fmt.Sprintf("failed to send import notification: %s", err))
+	}
+
+	if importErr != nil {
+		ic.Log().Error(importErr.Error())
 		return 1
 	}
 
 	return 0
 }
 
+// auditedImportApp imports the app, finishing the import report and recording the import in the audit log,
+// unless it was cancelled or had nothing to change. Commands that import an app on behalf of another command
+// import it through this as well
+func (ic *ImportCommand) auditedImportApp() error {
+	err := ic.importApp()
+	ic.report.finish(err)
+	if ic.report.changed() {
+		ic.audit(ic.report.ClientAppID, ic.report.DeploymentID, err)
+	}
+	return err
+}
+
 func (ic *ImportCommand) importApp() error {
 	user, err := ic.User()
 	if err != nil {
@@ -164,21 +472,102 @@ func (ic *ImportCommand) importApp() error {
 		return u.ErrNotLoggedIn
 	}
 
-	appPath, err := ic.resolveAppDirectory()
+	appPath, err := ic.resolveAppDirectory(ic.flagAppPath, ic.workingDirectory)
 	if err != nil {
 		return err
 	}
 
+	// an encrypted archive is imported from a temporary directory it is extracted to, so nothing is written
+	// back to it, such as the App ID of a new app or the app as deployed
+	var archivePath string
+	if info, statErr := os.Stat(appPath); statErr == nil && !info.IsDir() {
+		archiveDir, archiveErr := ic.extractEncryptedArchive(appPath)
+		if archiveErr != nil {
+			return archiveErr
+		}
+		defer os.RemoveAll(archiveDir)
+		archivePath, appPath = appPath, archiveDir
+	}
+
+	ic.projectConfig, err = models.LoadProjectConfig(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+	}
+
 	appInstanceData, err := ic.resolveAppInstanceData(appPath)
 	if err != nil {
 		return err
 	}
 
-	loadedApp, err := utils.UnmarshalFromDir(appPath)
+	if ic.projectConfig.Hosting.Include && !ic.flagConfigOnly {
+		ic.flagIncludeHosting = true
+	}
+
+	if ic.flagHostingOnly {
+		return ic.importHostingOnly(appPath, appInstanceData)
+	}
+
+	// the config of a redacted app is read from a copy with its placeholders resolved
+	configPath := appPath
+	if utils.IsRedacted(appPath) {
+		resolvedDir, resolveErr := ic.resolveRedactions(appPath)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		defer os.RemoveAll(resolvedDir)
+		configPath = resolvedDir
+	}
+
+	if ic.flagBuildFunctions {
+		builtDir, buildErr := ic.buildFunctions(configPath, appPath)
+		if buildErr != nil {
+			return buildErr
+		}
+		defer os.RemoveAll(builtDir)
+		configPath = builtDir
+	}
+
+	if utils.HasFunctionLib(configPath) {
+		bundledDir, bundleErr := ic.bundleFunctionLibs(configPath, appPath)
+		if bundleErr != nil {
+			return bundleErr
+		}
+		defer os.RemoveAll(bundledDir)
+		configPath = bundledDir
+	}
+
+	if ic.flagStrict {
+		if err := checkAppConfig(ic.Log(), configPath, validation.DefaultSchemas, true); err != nil {
+			return err
+		}
+	}
+
+	ic.Log().Debug(fmt.Sprintf("Loading app from %s", configPath))
+	loadedApp, err := utils.UnmarshalFromDir(configPath)
 	if err != nil {
 		return err
 	}
 
+	if err := checkValueTypes(ic.Log(), "local app", ic.projectConfig.ValueTypes, localValues(loadedApp)); err != nil {
+		return err
+	}
+
+	// secrets may refer to a secret store rather than hold the secret
+	if appSecrets, ok := loadedApp[secretsKey]; ok {
+		if err := secrets.ResolveAll(appSecrets); err != nil {
+			return fmt.Errorf("failed to resolve secrets: %s", err)
+		}
+	}
+	if ic.valueReferences, err = resolveValueReferences(loadedApp); err != nil {
+		return fmt.Errorf("failed to resolve secrets: %s", err)
+	}
+
+	if ic.flagTag != "" {
+		if loadedApp, err = ic.selectTagged(loadedApp); err != nil {
+			return err
+		}
+	}
+
 	appData, err := json.Marshal(loadedApp)
 	if err != nil {
 		return err
@@ -189,11 +578,38 @@ func (ic *ImportCommand) importApp() error {
 		return err
 	}
 
+	// the app is checked against the limits the server enforces before anything is changed, so that it
+	// does not fail on the server with a less helpful message
+	limits := ic.fetchLimits(stitchClient)
+	usage, err := appUsage(loadedApp)
+	if err != nil {
+		return err
+	}
+	if err := reportLimitProblems(ic.Log(), validation.CheckLimits(limits, usage)); err != nil {
+		return err
+	}
+
+	ic.report.ClientAppID = appInstanceData.AppID()
+
 	app, err := ic.fetchAppByClientAppID(appInstanceData.AppID())
 	var appNotFound bool
 	if err != nil {
 		switch err.(type) {
 		case api.ErrAppNotFound:
+			// an ID close to that of an existing app is more likely a typo than an app to create, so one is
+			// only created from it when the user says so
+			if len(err.(api.ErrAppNotFound).Suggestions) > 0 && ic.flagYes {
+				return err
+			}
+
+			if ic.flagNoCreate {
+				return fmt.Errorf("%s, and --%s was given", err, importFlagNoCreate)
+			}
+
+			if ic.flagOwner != "" {
+				return fmt.Errorf("%s, and --%s only imports into an existing app", err, importFlagOwner)
+			}
+
 			appNotFound = true
 			if appInstanceData.AppID() == "" {
 				err = errors.New("this app does not exist yet")
@@ -203,120 +619,234 @@ func (ic *ImportCommand) importApp() error {
 		}
 	}
 
+	if !appNotFound {
+		if err := ic.checkWriteAccess(stitchClient, app); err != nil {
+			return err
+		}
+	}
+
+	if ic.flagOwner != "" {
+		if loadedApp, err = ic.composeOwnedApp(stitchClient, app, loadedApp); err != nil {
+			return err
+		}
+		if appData, err = json.Marshal(loadedApp); err != nil {
+			return err
+		}
+	}
+
 	var skipDiff bool
 
 	if appNotFound {
 		skipDiff = true
 		ic.flagStrategy = importStrategyReplace
 
+		// answers given the last time an app was created from this directory take precedence over the
+		// built-in defaults, but not over values set in the app config
+		defaultLocation := appInstanceData.AppLocation()
+		if _, ok := appInstanceData[models.AppLocationField]; !ok && ic.projectConfig.Defaults.Location != "" {
+			defaultLocation = ic.projectConfig.Defaults.Location
+		}
+
+		defaultDeploymentModel := appInstanceData.AppDeploymentModel()
+		if _, ok := appInstanceData[models.AppDeploymentModelField]; !ok && ic.projectConfig.Defaults.DeploymentModel != "" {
+			defaultDeploymentModel = ic.projectConfig.Defaults.DeploymentModel
+		}
+
 		var wantedNewApp bool
-		app, wantedNewApp, err = ic.askCreateEmptyApp(err.Error(), appInstanceData.AppName(), appInstanceData.AppLocation(), appInstanceData.AppDeploymentModel(), stitchClient)
+		app, wantedNewApp, err = ic.askCreateEmptyApp(err.Error(), appInstanceData.AppName(), defaultLocation, defaultDeploymentModel, stitchClient)
 		if err != nil {
 			return err
 		}
 		if !wantedNewApp {
+			ic.report.skip(importOutcomeCancelled)
 			return nil
 		}
 
 		appInstanceData[models.AppIDField] = app.ClientAppID
 		appInstanceData[models.AppNameField] = app.Name
 
-		if writeErr := ic.writeAppConfigToFile(appPath, appInstanceData); writeErr != nil {
-			return errCreateAppSyncFailure(writeErr)
-		}
-	}
-
-	var assetMetadataDiffs *hosting.AssetMetadataDiffs
-	rootDir, dirErr := filepath.Abs(filepath.Join(appPath, utils.HostingFilesDirectory))
-	if dirErr != nil {
-		return dirErr
-	}
-	if ic.flagIncludeHosting {
-		assetDescs, fileErr := hosting.MetadataFileToAssetDescriptions(filepath.Join(appPath, utils.HostingAttributes))
-		if fileErr != nil {
-			return errIncludeHosting(fmt.Errorf("error loading metadata.json file: %v", fileErr))
-		}
-
-		cachePath, cPErr := getAssetCachePath(ic.flagConfigPath)
-		if cPErr != nil {
-			return cPErr
-		}
+		ic.report.NewApp = true
+		ic.report.ClientAppID = app.ClientAppID
+
+		if archivePath != "" {
+			ic.Log().Warn(fmt.Sprintf(
+				"The App ID of the new app was not saved to %s, as it is an encrypted archive: use --app-id=%s to import it to the same app again",
+				archivePath,
+				app.ClientAppID,
+			))
+		} else {
+			if writeErr := ic.writeProjectConfig(appPath, ic.projectConfig); writeErr != nil {
+				ic.Log().Warn(fmt.Sprintf("failed to save answers to %s: %s", models.ProjectConfigFileName, writeErr))
+			}
 
-		assetCache, cErr := hosting.CacheFileToAssetCache(cachePath)
-		if cErr != nil {
-			if !os.IsNotExist(cErr) {
-				return cErr
+			if writeErr := ic.writeAppConfigToFile(appPath, appInstanceData); writeErr != nil {
+				return errCreateAppSyncFailure(writeErr)
 			}
-			assetCache = hosting.NewAssetCache()
 		}
+	}
 
-		localAssetMetadata, aMErr :=
-			hosting.ListLocalAssetMetadata(appInstanceData.AppID(), rootDir, assetDescs, assetCache)
+	ic.report.GroupID = app.GroupID
+	ic.report.AppID = app.ID
+	ic.report.Strategy = ic.flagStrategy
 
-		if aMErr != nil {
-			return errIncludeHosting(fmt.Errorf("error processing local assets %s: %s", rootDir, aMErr))
-		}
+	lock, err := ic.acquireDeployLock(stitchClient, app)
+	if err != nil {
+		return err
+	}
+	defer ic.releaseDeployLock(stitchClient, app, lock)
 
-		if assetCache.Dirty() {
-			if uError := hosting.UpdateCacheFile(cachePath, assetCache); uError != nil {
-				ic.UI.Error(uError.Error())
-			}
+	// the canary app is imported without the lock, which only belongs to the target app
+	canaryAppData := appData
+	if ic.flagStrategy == importStrategyReplace {
+		if appData, err = json.Marshal(withDeployLock(loadedApp, lock)); err != nil {
+			return err
 		}
+	}
 
-		remoteAssetMetadata, rAMErr := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
-		if rAMErr != nil {
-			return errIncludeHosting(fmt.Errorf("error retrieving remote assets: %s", rAMErr))
+	var hostingDiffs *hostingPlan
+	var hostingFailures []hosting.FailedOperation
+	if ic.flagIncludeHosting {
+		var cleanup func()
+		if hostingDiffs, cleanup, err = ic.planHosting(stitchClient, app, appPath, appInstanceData.AppID(), limits); err != nil {
+			return err
 		}
-
-		assetMetadataDiffs = hosting.DiffAssetMetadata(localAssetMetadata, remoteAssetMetadata, ic.flagStrategy == importStrategyMerge)
+		defer cleanup()
 	}
 
 	// Diff changes unless -y flag has been provided or if this is a new app
 	if !ic.flagYes && !skipDiff {
+		diffStart := time.Now()
 		diffs, diffErr := stitchClient.Diff(app.GroupID, app.ID, appData, ic.flagStrategy)
+		diffs = withoutDeployLock(diffs)
+		ic.report.timeSince("diff", diffStart)
+		ic.Log().Debug(fmt.Sprintf("Diffed app against '%s' with the %s strategy in %s", app.ClientAppID, ic.flagStrategy, time.Since(diffStart)))
 
 		if diffErr != nil {
 			return fmt.Errorf("failed to diff app with currently deployed instance: %s", diffErr)
 		}
 
-		if ic.flagIncludeHosting && assetMetadataDiffs != nil {
-			hostingDiff := assetMetadataDiffs.Diff()
-			diffs = append(diffs, hostingDiff...)
+		if hostingDiffs != nil {
+			diffs = append(diffs, hostingDiffs.diff()...)
 		}
 
+		ic.report.Diff = append(ic.report.Diff, diffs...)
+
 		if len(diffs) == 0 {
-			ic.UI.Info("Deployed app is identical to proposed version, nothing to do.")
+			ic.Log().Info("Deployed app is identical to proposed version, nothing to do.")
+			ic.report.skip(importOutcomeNoChanges)
 			return nil
 		}
 
 		for _, diff := range diffs {
-			ic.UI.Info(diff)
+			ic.Diff(diff)
 		}
 
+		ic.describeTarget(stitchClient, app)
+
 		confirm, askErr := ic.AskYesNo("Please confirm the changes shown above:")
 		if askErr != nil {
 			return askErr
 		}
 
 		if !confirm {
+			ic.report.skip(importOutcomeCancelled)
 			return nil
 		}
 	}
 
-	ic.UI.Info("Importing app...")
-	if importErr := stitchClient.Import(app.GroupID, app.ID, appData, ic.flagStrategy); importErr != nil {
+	// secrets are only created once the changes are confirmed
+	if err := ic.checkSecrets(stitchClient, app, loadedApp); err != nil {
+		return err
+	}
+
+	if ic.flagCanaryAppID != "" {
+		if ic.flagCanaryAppID == app.ClientAppID {
+			return fmt.Errorf("the canary app must be a different app than '%s'", app.ClientAppID)
+		}
+
+		if err := ic.importCanary(stitchClient, canaryAppData); err != nil {
+			return err
+		}
+	}
+
+	if err := ic.importStages(stitchClient, app, loadedApp, appNotFound); err != nil {
+		return err
+	}
+
+	ic.Log().Info("Importing app...")
+	importStart := time.Now()
+	// large imports may be deployed in the background, in which case their progress is shown as it is made
+	reportProgress := func(deployment *models.Deployment) {
+		ic.report.DeploymentID = deployment.ID
+		ic.Log().Info(fmt.Sprintf("Deployment %s is %s...", deployment.ID, deployment.Status))
+	}
+	if importErr := stitchClient.ImportWithProgress(app.GroupID, app.ID, appData, ic.flagStrategy, ic.flagDeployTimeout, reportProgress); importErr != nil {
 		return fmt.Errorf("failed to import app: %s", importErr)
 	}
-	ic.UI.Info("Done.")
+	ic.report.timeSince("import", importStart)
+	ic.Log().Info("Done.")
+
+	if ic.report.DeploymentID == "" && (ic.flagReportFile != "" || ic.notifyWebhook() != "" || ic.auditLogPath() != "" || ic.flagVerify) {
+		deployment, deploymentErr := stitchClient.FetchLatestDeployment(app.GroupID, app.ID)
+		if deploymentErr != nil {
+			ic.Log().Warn(fmt.Sprintf("failed to fetch latest deployment: %s", deploymentErr))
+		} else if deployment != nil {
+			ic.report.DeploymentID = deployment.ID
+		}
+	}
+
+	if hostingDiffs != nil {
+		if hostingFailures, err = ic.applyHosting(stitchClient, app, hostingDiffs); err != nil {
+			return err
+		}
+	}
+
+	// the directory is only synced with the deployed app when all of it was imported, so that local changes to
+	// the entities left out are kept
+	switch {
+	case archivePath != "":
+		ic.Log().Info(fmt.Sprintf("%s was not synced with '%s', as it is an encrypted archive: export the app again to update it", archivePath, app.ClientAppID))
+	case ic.flagTag != "":
+		ic.Log().Info(fmt.Sprintf("The local directory was not synced with '%s', as only the entities tagged %s were imported", app.ClientAppID, ic.flagTag))
+	default:
+		if err := ic.syncAppDirectory(stitchClient, app, appPath, configPath, loadedApp); err != nil {
+			return err
+		}
+	}
+
+	if ic.flagSaveManifest != "" {
+		if err := saveAssetManifest(stitchClient, app, ic.flagSaveManifest); err != nil {
+			return fmt.Errorf("imported app but failed to save asset manifest: %s", err)
+		}
+	}
 
-	if ic.flagIncludeHosting && assetMetadataDiffs != nil {
-		ic.UI.Info("Importing hosting assets...")
-		if hostingImportErr := ImportHosting(app.GroupID, app.ID, rootDir, assetMetadataDiffs, ic.flagResetCDNCache, stitchClient, ic.UI); hostingImportErr != nil {
-			return fmt.Errorf("failed to import hosting assets %s", hostingImportErr)
+	if len(hostingFailures) > 0 {
+		return ic.reportHostingFailures(app, hostingFailures)
+	}
+
+	if ic.flagVerify {
+		verifyStart := time.Now()
+		if err := ic.verifyDeployment(stitchClient, app, ic.report.DeploymentID); err != nil {
+			return err
+		}
+		ic.report.timeSince("verify", verifyStart)
+	}
+
+	if ic.smokeTests != nil {
+		smokeTestStart := time.Now()
+		if err := ic.runSmokeTests(stitchClient, app, ic.smokeTests); err != nil {
+			return err
 		}
-		ic.UI.Info("Done.")
+		ic.report.timeSince("smoke_test", smokeTestStart)
 	}
 
+	ic.Success(fmt.Sprintf("Successfully imported '%s'", app.ClientAppID))
+
+	return nil
+}
+
+// syncAppDirectory replaces the app directory at appPath with the imported app, as deployed, to sync its IDs
+func (ic *ImportCommand) syncAppDirectory(stitchClient api.StitchClient, app *models.App, appPath, configPath string, loadedApp map[string]interface{}) error {
 	// re-fetch imported app to sync IDs
 	_, body, err := stitchClient.Export(app.GroupID, app.ID, false)
 	if err != nil {
@@ -329,7 +859,34 @@ func (ic *ImportCommand) importApp() error {
 		return errImportAppSyncFailure(err)
 	}
 
-	ic.UI.Info(fmt.Sprintf("Successfully imported '%s'", app.ClientAppID))
+	if err := utils.RestoreValues(appPath, ic.valueReferences); err != nil {
+		return errImportAppSyncFailure(err)
+	}
+
+	for _, dir := range ic.builtFunctionDirs {
+		ic.Log().Info(fmt.Sprintf("Kept the local source of %s, which its deployed source is built from", dir))
+	}
+
+	if ic.flagOwner != "" {
+		if _, err := utils.RemoveUnownedEntities(appPath, ic.flagOwner); err != nil {
+			return errImportAppSyncFailure(err)
+		}
+	}
+
+	// the synced directory holds the app as deployed, so it is no longer redacted
+	if configPath != appPath {
+		if err := os.Remove(filepath.Join(appPath, utils.RedactionsFileName)); err != nil && !os.IsNotExist(err) {
+			ic.Log().Warn(fmt.Sprintf("failed to remove %s: %s", utils.RedactionsFileName, err))
+		}
+	}
+
+	// fields such as the roles of a rule are evaluated in order, so a deployment that reorders them may
+	// behave differently than declared
+	if deployedApp, loadErr := utils.UnmarshalFromDir(appPath); loadErr == nil {
+		for _, field := range utils.OrderDifferences(loadedApp, deployedApp) {
+			ic.Log().Warn(fmt.Sprintf("%s was deployed in a different order than declared, and is evaluated in the deployed order", field))
+		}
+	}
 
 	return nil
 }
@@ -340,11 +897,7 @@ func (ic *ImportCommand) fetchAppByClientAppID(clientAppID string) (*models.App,
 		return nil, err
 	}
 
-	if ic.flagGroupID == "" {
-		return stitchClient.FetchAppByClientAppID(clientAppID)
-	}
-
-	return stitchClient.FetchAppByGroupIDAndClientAppID(ic.flagGroupID, clientAppID)
+	return fetchApp(stitchClient, ic.flagGroupID, clientAppID)
 }
 
 func (ic *ImportCommand) resolveGroupID() (string, error) {
@@ -352,34 +905,51 @@ func (ic *ImportCommand) resolveGroupID() (string, error) {
 		return ic.flagGroupID, nil
 	}
 
+	if ic.selectOption != nil {
+		return ic.selectProject(ic.projectConfig.Defaults.ProjectID, ic.flagOrg)
+	}
+
 	atlasClient, err := ic.AtlasClient()
 	if err != nil {
 		return "", fmt.Errorf("an unexpected error occurred: %s", err)
 	}
 
-	groups, err := atlasClient.Groups()
+	projects, err := ic.listProjects(ic.flagOrg)
 	if err != nil {
 		return "", err
 	}
 
 	groupsByName := map[string]string{}
-	for _, group := range groups {
-		groupsByName[group.Name] = group.ID
+	for _, p := range projects {
+		groupsByName[p.Name] = p.ID
 	}
 
-	if len(groupsByName) == 0 {
-		return "", errors.New("no available Projects")
-	}
+	ic.UI.Output("Available Projects:")
+
+	orgName := ""
+	for i, p := range projects {
+		if p.OrgName != "" && (i == 0 || p.OrgName != orgName) {
+			orgName = p.OrgName
+			ic.UI.Output(fmt.Sprintf("%s (%s):", p.OrgName, p.OrgID))
+		}
 
-	ic.UI.Info("Available Projects:")
+		if p.OrgName == "" {
+			ic.UI.Output(fmt.Sprintf("%s - %s", p.Name, p.ID))
+		} else {
+			ic.UI.Output(fmt.Sprintf("  %s - %s", p.Name, p.ID))
+		}
+	}
 
-	for name, id := range groupsByName {
-		ic.UI.Info(fmt.Sprintf("%s - %s", name, id))
+	defaultProject := projects[0].Name
+	for _, p := range projects {
+		if p.ID == ic.projectConfig.Defaults.ProjectID {
+			defaultProject = p.Name
+		}
 	}
 
 	var groupID string
 	for {
-		projectResponse, err := ic.Ask("Atlas Project Name or ID", groups[0].Name)
+		projectResponse, err := ic.Ask("Atlas Project Name or ID", defaultProject, "--"+flagProjectIDName)
 		if err != nil {
 			return "", err
 		}
@@ -404,7 +974,7 @@ func (ic *ImportCommand) resolveGroupID() (string, error) {
 			break
 		}
 
-		ic.UI.Info("Could not understand response, please try again")
+		ic.Log().Info("Could not understand response, please try again")
 	}
 
 	return groupID, nil
@@ -424,7 +994,7 @@ func (ic *ImportCommand) askCreateEmptyApp(query, defaultAppName, defaultLocatio
 		return nil, false, nil
 	}
 
-	appName, err := ic.Ask("App name", defaultAppName)
+	appName, err := ic.Ask("App name", defaultAppName, "--"+importFlagAppName)
 	if err != nil {
 		return nil, false, err
 	}
@@ -445,12 +1015,12 @@ func (ic *ImportCommand) askCreateEmptyApp(query, defaultAppName, defaultLocatio
 		}
 	}
 
-	location, err := ic.AskWithOptions("Location", defaultLocation, locationOptions)
+	location, err := ic.AskWithOptions("Location", defaultLocation, locationOptions, `"location" in stitch.json`)
 	if err != nil {
 		return nil, false, err
 	}
 
-	deploymentModel, err := ic.AskWithOptions("Deployment Model", defaultDeploymentModel, deploymentModelOptions)
+	deploymentModel, err := ic.AskWithOptions("Deployment Model", defaultDeploymentModel, deploymentModelOptions, `"deployment_model" in stitch.json`)
 	if err != nil {
 		return nil, false, err
 	}
@@ -460,35 +1030,71 @@ func (ic *ImportCommand) askCreateEmptyApp(query, defaultAppName, defaultLocatio
 		return nil, false, err
 	}
 
-	ic.UI.Info(fmt.Sprintf("New app created: %s", app.ClientAppID))
+	ic.projectConfig.Defaults = models.PromptDefaults{
+		ProjectID:       groupID,
+		Location:        location,
+		DeploymentModel: deploymentModel,
+	}
+
+	ic.Log().Info(fmt.Sprintf("New app created: %s", app.ClientAppID))
 	return app, true, nil
 }
 
-func (ic *ImportCommand) resolveAppDirectory() (string, error) {
-	if ic.flagAppPath != "" {
-		path, err := homedir.Expand(ic.flagAppPath)
-		if err != nil {
-			return "", err
-		}
+// extractEncryptedArchive decrypts the app archive at path into a new temporary directory and returns it
+func (ic *ImportCommand) extractEncryptedArchive(path string) (string, error) {
+	encrypted, err := utils.IsAgeEncrypted(path)
+	if err != nil {
+		return "", err
+	}
 
-		if _, err := os.Stat(path); err != nil {
-			return "", errors.New("directory does not exist")
-		}
-		return path, nil
+	if !encrypted {
+		return "", fmt.Errorf("%s is neither a directory nor an encrypted app archive", path)
+	}
+
+	if ic.flagDecryptIdentity == "" {
+		return "", fmt.Errorf("an identity (--%s=[string]) must be supplied to import an encrypted archive", importFlagDecryptIdentity)
 	}
 
-	return utils.GetDirectoryContainingFile(ic.workingDirectory, models.AppConfigFileName)
+	identityPath, err := homedir.Expand(ic.flagDecryptIdentity)
+	if err != nil {
+		return "", err
+	}
+
+	zipData, err := ic.decryptArchive(identityPath, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt archive: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-import")
+	if err != nil {
+		return "", err
+	}
+
+	if err := utils.WriteAppToDir(dir, bytes.NewReader(zipData), true); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to extract archive: %s", err)
+	}
+
+	return dir, nil
 }
 
 // resolveAppInstanceData loads data for an app from a stitch.json file located in the provided directory path,
-// merging in any overridden parameters from command line flags
+// merging in any overridden parameters from command line flags or the git branch mapping in .stitchrc
 func (ic *ImportCommand) resolveAppInstanceData(path string) (models.AppInstanceData, error) {
+	appID := ic.flagAppID
+	if appID == "" {
+		var err error
+		if appID, err = ic.branchAppID(path); err != nil {
+			return nil, err
+		}
+	}
+
 	appInstanceDataFromFile := models.AppInstanceData{}
 	err := appInstanceDataFromFile.UnmarshalFile(path)
 
 	if os.IsNotExist(err) {
 		return models.AppInstanceData{
-			models.AppIDField: ic.flagAppID,
+			models.AppIDField: appID,
 		}, nil
 	}
 
@@ -496,13 +1102,55 @@ func (ic *ImportCommand) resolveAppInstanceData(path string) (models.AppInstance
 		return nil, err
 	}
 
-	if ic.flagAppID != "" {
-		appInstanceDataFromFile[models.AppIDField] = ic.flagAppID
+	if appID != "" {
+		appInstanceDataFromFile[models.AppIDField] = appID
 	}
 
 	return appInstanceDataFromFile, nil
 }
 
+// branchAppID returns the App ID that .stitchrc maps the git branch checked out at path to, or "" if there is none.
+// It fails if .stitchrc maps branches to apps but the branch cannot be determined, such as when HEAD is detached,
+// rather than import to the app in stitch.json, which the mapping is there to avoid
+func (ic *ImportCommand) branchAppID(path string) (string, error) {
+	if len(ic.projectConfig.Branches) == 0 {
+		return "", nil
+	}
+
+	branch, err := ic.currentBranch(path)
+	if err != nil {
+		return "", fmt.Errorf(
+			"failed to determine the git branch that %s maps to an app: %s; use --%s to choose the app to import to",
+			models.ProjectConfigFileName,
+			err,
+			flagAppIDName,
+		)
+	}
+
+	appID, ok := ic.projectConfig.AppIDForBranch(branch)
+	if !ok {
+		ic.Log().Warn(fmt.Sprintf("No app is mapped to the git branch '%s' in %s, so the App ID in %s is used", branch, models.ProjectConfigFileName, models.AppConfigFileName))
+		return "", nil
+	}
+
+	ic.Log().Info(fmt.Sprintf("Importing to '%s', which %s maps the git branch '%s' to", appID, models.ProjectConfigFileName, branch))
+	return appID, nil
+}
+
+// currentGitBranch returns the git branch checked out in the repository containing dir
+func currentGitBranch(dir string) (string, error) {
+	branch, err := gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	if branch == "HEAD" {
+		return "", errors.New("HEAD is detached")
+	}
+
+	return branch, nil
+}
+
 // isObjectIDHex returns whether s is a valid hex representation of an ObjectId.
 // copied from mgo/bson#IsObjectIdHex
 func isObjectIDHex(s string) bool {
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+)
+
+// canaryReport records the import of an app to its canary, which precedes the import to the target app
+type canaryReport struct {
+	ClientAppID  string `json:"client_app_id"`
+	GroupID      string `json:"group_id,omitempty"`
+	AppID        string `json:"app_id,omitempty"`
+	Success      bool   `json:"success"`
+	Error        string `json:"error,omitempty"`
+	DeploymentID string `json:"deployment_id,omitempty"`
+	Verified     bool   `json:"verified"`
+	SmokeTested  bool   `json:"smoke_tested"`
+}
+
+// importCanary imports the app to the canary app given by --canary-app-id, verifying the deployment and
+// running the smoke tests against it if asked to. The target app is only imported if this succeeds
+func (ic *ImportCommand) importCanary(stitchClient api.StitchClient, appData []byte) error {
+	report := &canaryReport{ClientAppID: ic.flagCanaryAppID}
+	ic.report.Canary = report
+
+	canaryStart := time.Now()
+	err := ic.deployCanary(stitchClient, appData, report)
+	ic.report.timeSince("canary", canaryStart)
+
+	if err != nil {
+		report.Error = err.Error()
+		return fmt.Errorf("import to canary '%s' failed, so '%s' was not imported: %s", ic.flagCanaryAppID, ic.report.ClientAppID, err)
+	}
+
+	report.Success = true
+	ic.Log().Info(fmt.Sprintf("Canary '%s' is healthy; continuing with '%s'.", ic.flagCanaryAppID, ic.report.ClientAppID))
+	return nil
+}
+
+func (ic *ImportCommand) deployCanary(stitchClient api.StitchClient, appData []byte, report *canaryReport) error {
+	canary, err := ic.fetchAppByClientAppID(ic.flagCanaryAppID)
+	if err != nil {
+		return err
+	}
+	report.GroupID = canary.GroupID
+	report.AppID = canary.ID
+
+	ic.Log().Info(fmt.Sprintf("Importing app to canary '%s'...", ic.flagCanaryAppID))
+	if err := stitchClient.Import(canary.GroupID, canary.ID, appData, ic.flagStrategy); err != nil {
+		return err
+	}
+
+	if ic.flagVerify {
+		deployment, err := stitchClient.FetchLatestDeployment(canary.GroupID, canary.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch latest deployment: %s", err)
+		}
+		if deployment != nil {
+			report.DeploymentID = deployment.ID
+		}
+
+		if err := ic.verifyDeployment(stitchClient, canary, report.DeploymentID); err != nil {
+			return err
+		}
+		report.Verified = true
+	}
+
+	if ic.smokeTests != nil {
+		if err := ic.runSmokeTests(stitchClient, canary, ic.smokeTests); err != nil {
+			return err
+		}
+		report.SmokeTested = true
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// buildFunctions copies the app config at configPath to a temporary directory, building the source.ts of each
+// function and incoming webhook into a source.js there, and returns the directory. The source maps of the built
+// functions are kept in the app directory at appPath
+func (ic *ImportCommand) buildFunctions(configPath, appPath string) (string, error) {
+	command := ic.projectConfig.Functions.Build
+	if command == "" {
+		command = utils.DefaultFunctionBuildCommand
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-import-built")
+	if err != nil {
+		return "", err
+	}
+
+	built, err := utils.BuildFunctionSources(configPath, dir, appPath, command)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to build function sources: %s", err)
+	}
+
+	for _, function := range built {
+		ic.addBuiltFunctionDir(function.Dir)
+		if function.SourceMap != "" {
+			ic.Log().Info(fmt.Sprintf("Built %s (source map: %s)", function.Dir, function.SourceMap))
+		} else {
+			ic.Log().Info(fmt.Sprintf("Built %s", function.Dir))
+		}
+	}
+
+	return dir, nil
+}
+
+// bundleFunctionLibs copies the app config at configPath to a temporary directory, injecting the shared modules
+// of the functions into those that require them there, and returns the directory. What was injected is recorded
+// in the import report, and in the manifest kept in the app directory at appPath
+func (ic *ImportCommand) bundleFunctionLibs(configPath, appPath string) (string, error) {
+	dir, err := ioutil.TempDir("", "stitch-import-bundled")
+	if err != nil {
+		return "", err
+	}
+
+	manifest, err := utils.BundleFunctionLibs(configPath, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to inject shared function code: %s", err)
+	}
+
+	for _, function := range manifest.Functions {
+		names := make([]string, 0, len(function.Modules))
+		for _, module := range function.Modules {
+			names = append(names, module.Name)
+		}
+		ic.Log().Info(fmt.Sprintf("Injected %s into %s", strings.Join(names, ", "), function.Dir))
+		ic.addBuiltFunctionDir(function.Dir)
+	}
+	ic.report.SharedCode = manifest.Functions
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(appPath, utils.FunctionLibManifestFileName), append(data, '\n'), 0644)
+	}
+	if err != nil {
+		ic.Log().Warn(fmt.Sprintf("failed to write %s: %s", utils.FunctionLibManifestFileName, err))
+	}
+
+	return dir, nil
+}
+
+// addBuiltFunctionDir records that the source of the function in dir was built from local sources
+func (ic *ImportCommand) addBuiltFunctionDir(dir string) {
+	for _, builtDir := range ic.builtFunctionDirs {
+		if builtDir == dir {
+			return
+		}
+	}
+	ic.builtFunctionDirs = append(ic.builtFunctionDirs, dir)
+}
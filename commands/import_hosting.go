@@ -1,85 +1,179 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"text/tabwriter"
+	"time"
 
 	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/models"
 	"github.com/10gen/stitch-cli/utils"
+	"github.com/10gen/stitch-cli/validation"
 
-	"github.com/mitchellh/cli"
 	"github.com/mitchellh/go-homedir"
 )
 
-// checkErrs builds a list of errors from the error channel errChan and logs them
-func checkErrs(errChan <-chan error, errDoneChan chan<- struct{}, ui cli.Ui, errors *[]error) {
-	for err := range errChan {
-		*errors = append(*errors, err)
-		ui.Error(err.Error())
-	}
-	errDoneChan <- struct{}{}
+// hostingImportError is returned by ImportHosting when any hosting operations fail
+type hostingImportError struct {
+	failures []hosting.FailedOperation
 }
 
-// ImportHosting will push local Stitch hosting assets to the server
-func ImportHosting(groupID, appID, rootDir string, assetMetadataDiffs *hosting.AssetMetadataDiffs, resetCache bool, client api.StitchClient, ui cli.Ui) error {
-	// build a channel of hosting operations
-	var opWG sync.WaitGroup
-	opChan := make(chan hostingOp)
-	errChan := make(chan error)
-	errDoneChan := make(chan struct{})
+func (e *hostingImportError) Error() string {
+	return fmt.Sprintf("%v error(s) occurred while importing hosting assets", len(e.failures))
+}
 
-	var errors []error
-	go checkErrs(errChan, errDoneChan, ui, &errors)
+// hostingOpError is the error of a failed hostingOp
+type hostingOpError struct {
+	operation string
+	filePath  string
+	err       error
+}
 
-	// create workers
-	for n := 0; n < numWorkers; n++ {
-		opWG.Add(1)
-		go hostingOpHandler(opChan, &opWG, errChan)
+func (e *hostingOpError) Error() string {
+	switch e.operation {
+	case hosting.OperationUpload:
+		return fmt.Sprintf("uploading '%s' failed => %s", e.filePath, e.err)
+	case hosting.OperationDelete:
+		return fmt.Sprintf("deleting '%s' failed => %s", e.filePath, e.err)
+	default:
+		return fmt.Sprintf("%s => %s", e.filePath, e.err)
 	}
+}
+
+// hostingOpResult is the outcome of performing a hostingOp
+type hostingOpResult struct {
+	op  hostingOp
+	err error
+}
 
+// checkResults logs the outcome of each hosting operation as it completes and builds a list of the failures
+func checkResults(resultChan <-chan hostingOpResult, resultDoneChan chan<- struct{}, total int, log logging.Logger, failures *[]hosting.FailedOperation) {
+	done := 0
+	for result := range resultChan {
+		done++
+		if err := result.err; err != nil {
+			log.Error(err.Error())
+
+			failure := hosting.FailedOperation{Reason: err.Error()}
+			if opErr, ok := err.(*hostingOpError); ok {
+				failure = hosting.FailedOperation{Operation: opErr.operation, FilePath: opErr.filePath, Reason: opErr.err.Error()}
+			}
+			*failures = append(*failures, failure)
+			continue
+		}
+		log.Info(fmt.Sprintf("(%d/%d) %s", done, total, result.op.Description()))
+	}
+	resultDoneChan <- struct{}{}
+}
+
+// ImportHosting will push local Stitch hosting assets to the server. If any operations fail, a
+// *hostingImportError listing them is returned once the rest have been attempted
+func ImportHosting(groupID, appID, rootDir string, assetMetadataDiffs *hosting.AssetMetadataDiffs, client api.StitchClient, log logging.Logger) error {
 	baseOp := baseHostingOp{groupID, appID, rootDir, client}
-	// create hosting Ops to be handled
+
+	var ops []hostingOp
 	for _, added := range assetMetadataDiffs.AddedLocally {
-		opChan <- &addOp{baseOp, added}
+		ops = append(ops, &addOp{baseOp, added})
 	}
 
 	for _, deleted := range assetMetadataDiffs.DeletedLocally {
-		opChan <- &deleteOp{baseOp, deleted}
+		ops = append(ops, &deleteOp{baseOp, deleted})
 	}
 
 	for _, modified := range assetMetadataDiffs.ModifiedLocally {
-		opChan <- &modifyOp{baseOp, modified}
+		ops = append(ops, &modifyOp{baseOp, modified})
 	}
 
-	close(opChan)
-	opWG.Wait()
-	close(errChan)
-	<-errDoneChan
+	ops, copies := dedupUploads(baseOp, ops)
+
+	resultChan := make(chan hostingOpResult)
+	resultDoneChan := make(chan struct{})
+
+	var failures []hosting.FailedOperation
+	go checkResults(resultChan, resultDoneChan, len(ops)+len(copies), log, &failures)
+
+	// duplicates are copied once the upload of their content is done
+	runHostingOps(ops, resultChan)
+	runHostingOps(copies, resultChan)
 
-	if len(errors) > 0 {
-		return fmt.Errorf("%v error(s) occurred while importing hosting assets", len(errors))
+	close(resultChan)
+	<-resultDoneChan
+
+	if len(failures) > 0 {
+		return &hostingImportError{failures}
 	}
 
-	if resetCache {
-		if err := client.InvalidateCache(groupID, appID, "/*"); err != nil {
-			return err
+	return nil
+}
+
+// invalidateMaxAttempts is how many times the CDN cache of a path is invalidated before giving up on it
+const invalidateMaxAttempts = 4
+
+// invalidateRetryDelay is how long to wait before retrying a failed invalidation the first time. It doubles
+// with each retry
+var invalidateRetryDelay = time.Second
+
+// invalidateCache resets the CDN cache of each of the paths, retrying a failed invalidation with an increasing
+// delay, starting at invalidateRetryDelay. It returns the paths that could not be invalidated, whose cache
+// may still be stale
+func invalidateCache(groupID, appID string, paths []string, client api.StitchClient, log logging.Logger) []string {
+	var stale []string
+	for _, path := range paths {
+		delay := invalidateRetryDelay
+		for attempt := 1; ; attempt++ {
+			err := client.InvalidateCache(groupID, appID, path)
+			if err == nil {
+				break
+			}
+
+			if attempt == invalidateMaxAttempts {
+				log.Error(fmt.Sprintf("failed to invalidate the CDN cache of '%s' => %s", path, err))
+				stale = append(stale, path)
+				break
+			}
+
+			log.Debug(fmt.Sprintf("Retrying the invalidation of '%s' in %s, as it failed: %s", path, delay, err))
+			time.Sleep(delay)
+			delay *= 2
 		}
 	}
 
-	return nil
+	return stale
 }
 
-func hostingOpHandler(opChan <-chan hostingOp, opWG *sync.WaitGroup, errChan chan<- error) {
+// runHostingOps performs the operations with a pool of workers, sending the result of each to resultChan,
+// and returns once they are all done
+func runHostingOps(ops []hostingOp, resultChan chan<- hostingOpResult) {
+	var opWG sync.WaitGroup
+	opChan := make(chan hostingOp)
+
+	for n := 0; n < numWorkers; n++ {
+		opWG.Add(1)
+		go hostingOpHandler(opChan, &opWG, resultChan)
+	}
+
+	for _, op := range ops {
+		opChan <- op
+	}
+
+	close(opChan)
+	opWG.Wait()
+}
+
+func hostingOpHandler(opChan <-chan hostingOp, opWG *sync.WaitGroup, resultChan chan<- hostingOpResult) {
 	defer opWG.Done()
 
 	for op := range opChan {
-		if doErr := op.Do(); doErr != nil {
-			errChan <- doErr
-			continue
-		}
+		resultChan <- hostingOpResult{op, op.Do()}
 	}
 }
 
@@ -93,6 +187,7 @@ type baseHostingOp struct {
 // hostingOp represents an import operation done with hosting assets
 type hostingOp interface {
 	Do() error
+	Description() string
 }
 
 type addOp struct {
@@ -105,6 +200,11 @@ func (op *addOp) Do() error {
 	return doUpload(op.groupID, op.appID, op.rootDir, op.client, op.assetMetadata)
 }
 
+// Description describes the operation as done
+func (op *addOp) Description() string {
+	return fmt.Sprintf("uploaded '%s'", op.assetMetadata.FilePath)
+}
+
 type deleteOp struct {
 	baseHostingOp
 	assetMetadata hosting.AssetMetadata
@@ -114,11 +214,16 @@ type deleteOp struct {
 func (op *deleteOp) Do() error {
 	fp := op.assetMetadata.FilePath
 	if err := op.client.DeleteAsset(op.groupID, op.appID, fp); err != nil {
-		return fmt.Errorf("deleting '%s' failed => %s", fp, err)
+		return &hostingOpError{hosting.OperationDelete, fp, err}
 	}
 	return nil
 }
 
+// Description describes the operation as done
+func (op *deleteOp) Description() string {
+	return fmt.Sprintf("deleted '%s'", op.assetMetadata.FilePath)
+}
+
 type modifyOp struct {
 	baseHostingOp
 	modifiedAssetMetadata hosting.ModifiedAssetMetadata
@@ -136,7 +241,7 @@ func (op *modifyOp) Do() error {
 				op.appID,
 				fp,
 				mAM.AssetMetadata.Attrs...); err != nil {
-			return fmt.Errorf("%s => %s", fp, err)
+			return &hostingOpError{hosting.OperationSetAttributes, fp, err}
 		}
 
 		return nil
@@ -149,37 +254,489 @@ func (op *modifyOp) Do() error {
 	return nil
 }
 
+// Description describes the operation as done
+func (op *modifyOp) Description() string {
+	mAM := op.modifiedAssetMetadata
+	if mAM.AttrModified && !mAM.BodyModified {
+		return fmt.Sprintf("updated attributes of '%s'", mAM.AssetMetadata.FilePath)
+	}
+	return fmt.Sprintf("uploaded '%s'", mAM.AssetMetadata.FilePath)
+}
+
+// minDedupSize is the smallest asset whose duplicates are copied on the server rather than uploaded, as copying
+// an asset and setting its attributes takes two requests, which is only worth saving the upload of a larger body
+const minDedupSize = 16 * 1024
+
+// dedupUploads finds the uploads among ops of content that is also uploaded to another path, e.g. the same
+// vendor file bundled in several places. The content is only uploaded once, by the first of those uploads,
+// and the others are returned separately as copies of it, which must be done after ops
+func dedupUploads(baseOp baseHostingOp, ops []hostingOp) ([]hostingOp, []hostingOp) {
+	var deduped, copies []hostingOp
+	sources := map[string]*recordedOp{}
+
+	for _, op := range ops {
+		am, uploads := uploadedAsset(op)
+		if !uploads || am.IsDir() || am.FileHash == "" || am.FileSize < minDedupSize {
+			deduped = append(deduped, op)
+			continue
+		}
+
+		if source, ok := sources[am.FileHash]; ok {
+			copies = append(copies, &copyOp{baseOp, source, am})
+			continue
+		}
+
+		source := &recordedOp{hostingOp: op, assetMetadata: am}
+		sources[am.FileHash] = source
+		deduped = append(deduped, source)
+	}
+
+	return deduped, copies
+}
+
+// uploadedAsset returns the asset the operation uploads, if it uploads one
+func uploadedAsset(op hostingOp) (hosting.AssetMetadata, bool) {
+	switch op := op.(type) {
+	case *addOp:
+		return op.assetMetadata, true
+	case *modifyOp:
+		return op.modifiedAssetMetadata.AssetMetadata, op.modifiedAssetMetadata.BodyModified || !op.modifiedAssetMetadata.AttrModified
+	}
+	return hosting.AssetMetadata{}, false
+}
+
+// recordedOp is an upload whose outcome is kept, so that the copies of the asset it uploads know whether
+// there is anything to copy
+type recordedOp struct {
+	hostingOp
+	assetMetadata hosting.AssetMetadata
+	err           error
+}
+
+// Do performs the upload, recording its outcome
+func (op *recordedOp) Do() error {
+	op.err = op.hostingOp.Do()
+	return op.err
+}
+
+type copyOp struct {
+	baseHostingOp
+	source        *recordedOp
+	assetMetadata hosting.AssetMetadata
+}
+
+// Do copies the asset uploaded by the source, then gives it its own attributes, since a copy has those of the
+// source. If the source failed to upload, the asset is uploaded instead
+func (op *copyOp) Do() error {
+	if op.source.err != nil {
+		return doUpload(op.groupID, op.appID, op.rootDir, op.client, op.assetMetadata)
+	}
+
+	fp := op.assetMetadata.FilePath
+	if err := op.client.CopyAsset(op.groupID, op.appID, op.source.assetMetadata.FilePath, fp); err != nil {
+		return &hostingOpError{hosting.OperationUpload, fp, err}
+	}
+
+	if err := op.client.SetAssetAttributes(op.groupID, op.appID, fp, op.assetMetadata.Attrs...); err != nil {
+		return &hostingOpError{hosting.OperationUpload, fp, err}
+	}
+
+	return nil
+}
+
+// Description describes the operation as done
+func (op *copyOp) Description() string {
+	if op.source.err != nil {
+		return fmt.Sprintf("uploaded '%s'", op.assetMetadata.FilePath)
+	}
+	return fmt.Sprintf("uploaded '%s' as a copy of '%s'", op.assetMetadata.FilePath, op.source.assetMetadata.FilePath)
+}
+
 func doUpload(groupID, appID, rootDir string, client api.StitchClient, am hosting.AssetMetadata) error {
-	errStrF := "uploading '%s' failed => %s"
+	// directories are created by uploading an entry without a body
+	if am.IsDir() {
+		if uploadErr := client.UploadAsset(groupID, appID, am.FilePath, am.FileHash, 0, bytes.NewReader(nil), am.Attrs...); uploadErr != nil {
+			return &hostingOpError{hosting.OperationUpload, am.FilePath, uploadErr}
+		}
+		return nil
+	}
 
-	body, bodyErr := os.Open(filepath.Join(rootDir, am.FilePath))
+	body, bodyErr := hosting.OpenAsset(rootDir, am.FilePath)
 	if bodyErr != nil {
-		return fmt.Errorf(errStrF, am.FilePath, bodyErr)
+		return &hostingOpError{hosting.OperationUpload, am.FilePath, bodyErr}
 	}
 	defer body.Close()
 
 	if uploadErr := client.UploadAsset(groupID, appID, am.FilePath, am.FileHash, am.FileSize, body, am.Attrs...); uploadErr != nil {
-		return fmt.Errorf(errStrF, am.FilePath, uploadErr)
+		return &hostingOpError{hosting.OperationUpload, am.FilePath, uploadErr}
 	}
 
 	return nil
 }
 
-func getAssetCachePath(configPath string) (string, error) {
-	cachePath, eErr := homedir.Expand(configPath)
-	if eErr != nil {
-		return "", eErr
+// rateLimitedClient is a StitchClient whose asset uploads share a limit on the rate they are sent at
+type rateLimitedClient struct {
+	api.StitchClient
+	limiter *utils.RateLimiter
+}
+
+// UploadAsset uploads the asset, reading its body no faster than the limiter allows
+func (c *rateLimitedClient) UploadAsset(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+	return c.StitchClient.UploadAsset(groupID, appID, path, hash, size, utils.NewRateLimitedReader(body, c.limiter), attributes...)
+}
+
+// listLocalAssets returns the metadata of the hosting assets in the app directory at appPath, along with the
+// directory they are to be uploaded from. This is a staging directory if several hosting roots are merged or
+// any transforms apply, which the returned cleanup function removes
+func (c *BaseCommand) listLocalAssets(appPath, appID string, options models.HostingOptions) (string, []hosting.AssetMetadata, func(), error) {
+	cleanup := func() {}
+
+	rootDir, err := filepath.Abs(filepath.Join(appPath, utils.HostingFilesDirectory))
+	if err != nil {
+		return "", nil, cleanup, err
 	}
 
-	if cachePath == "" {
-		home, dirErr := homedir.Dir()
-		if dirErr != nil {
-			return "", dirErr
+	assetDescs, err := hosting.MetadataFileToAssetDescriptions(filepath.Join(appPath, utils.HostingAttributes))
+	if err != nil {
+		return "", nil, cleanup, fmt.Errorf("error loading metadata.json file: %v", err)
+	}
+
+	if len(options.Roots) > 0 {
+		mergedDir, mErr := hosting.MergeRoots(appPath, options.Roots)
+		if mErr != nil {
+			return "", nil, cleanup, mErr
+		}
+		cleanup = func() { os.RemoveAll(mergedDir) }
+		rootDir = mergedDir
+	}
+
+	if len(options.Transforms) > 0 {
+		c.Log().Info("Transforming hosting assets...")
+		stagingDir, tErr := hosting.TransformAssets(rootDir, options.Transforms, assetDescs)
+		if tErr != nil {
+			cleanup()
+			return "", nil, func() {}, tErr
 		}
-		cachePath = filepath.Join(home, ".config", "stitch")
-	} else {
-		cachePath = filepath.Dir(cachePath)
+		mergeCleanup := cleanup
+		cleanup = func() {
+			mergeCleanup()
+			os.RemoveAll(stagingDir)
+		}
+		rootDir = stagingDir
+	}
+
+	cachePath, err := c.cachePath(utils.HostingCacheFileName)
+	if err != nil {
+		cleanup()
+		return "", nil, func() {}, err
 	}
 
-	return filepath.Join(cachePath, utils.HostingCacheFileName), nil
+	assetCache, err := hosting.CacheFileToAssetCache(cachePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			cleanup()
+			return "", nil, func() {}, err
+		}
+		assetCache = hosting.NewAssetCache()
+	}
+
+	assetMetadata, err := hosting.ListLocalAssetMetadata(appID, rootDir, assetDescs, assetCache)
+	if err != nil {
+		cleanup()
+		return "", nil, func() {}, fmt.Errorf("error processing local assets %s: %s", rootDir, err)
+	}
+
+	if assetCache.Dirty() {
+		if uError := hosting.UpdateCacheFile(cachePath, assetCache); uError != nil {
+			c.Log().Error(uError.Error())
+		}
+	}
+
+	return rootDir, assetMetadata, cleanup, nil
+}
+
+// saveAssetManifest writes a snapshot of the app's deployed hosting assets to the file at path
+func saveAssetManifest(client api.StitchClient, app *models.App, path string) error {
+	manifestPath, err := homedir.Expand(path)
+	if err != nil {
+		return err
+	}
+
+	assetMetadata, err := client.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return err
+	}
+
+	return hosting.WriteManifestFile(manifestPath, assetMetadata)
+}
+
+// reportHostingFailures displays the hosting operations that failed and writes them to the retry file
+func (ic *ImportCommand) reportHostingFailures(app *models.App, failures []hosting.FailedOperation) error {
+	ic.UI.Output("Failed hosting operations:")
+	ic.UI.Output(formatFailedOperations(failures))
+
+	retryPath, err := homedir.Expand(ic.flagRetryFile)
+	if err != nil {
+		return err
+	}
+
+	retryList := &hosting.RetryList{
+		GroupID:     app.GroupID,
+		AppID:       app.ID,
+		ClientAppID: app.ClientAppID,
+		Failures:    failures,
+	}
+	if err := hosting.WriteRetryFile(retryPath, retryList); err != nil {
+		return fmt.Errorf("%d hosting operation(s) failed, and the list of them could not be saved: %s", len(failures), err)
+	}
+
+	return fmt.Errorf("%d hosting operation(s) failed; run \"hosting retry --from %s\" to retry them", len(failures), ic.flagRetryFile)
+}
+
+// formatFailedOperations lays the failed operations out as a table, sorted by path
+func formatFailedOperations(failures []hosting.FailedOperation) string {
+	sorted := make([]hosting.FailedOperation, len(failures))
+	copy(sorted, failures)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FilePath < sorted[j].FilePath
+	})
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tPATH\tREASON")
+	for _, failure := range sorted {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", failure.Operation, failure.FilePath, failure.Reason)
+	}
+	w.Flush()
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// hostingPlan holds the changes an import makes to the hosting assets and settings of an app
+type hostingPlan struct {
+	rootDir    string
+	assetDiffs *hosting.AssetMetadataDiffs
+
+	// config is the hosting config to apply, or nil if the deployed one is unchanged
+	config     *hosting.Config
+	configDiff []string
+}
+
+// diff returns the changes as they are shown before they are confirmed
+func (hp *hostingPlan) diff() []string {
+	return append(hp.assetDiffs.Diff(), hp.configDiff...)
+}
+
+// planHosting compares the hosting assets and settings of the app at appPath to those deployed to app. The
+// returned func removes the directory the assets were transformed in, if any
+func (ic *ImportCommand) planHosting(stitchClient api.StitchClient, app *models.App, appPath, appID string, limits models.Limits) (*hostingPlan, func(), error) {
+	rootDir, localAssetMetadata, cleanup, err := ic.listLocalAssets(appPath, appID, ic.projectConfig.Hosting)
+	if err != nil {
+		return nil, nil, errIncludeHosting(err)
+	}
+
+	fail := func(err error) (*hostingPlan, func(), error) {
+		cleanup()
+		return nil, nil, errIncludeHosting(err)
+	}
+
+	// every asset over the size limit is listed before any is uploaded, rather than failing partway through
+	assetUsage := validation.Usage{AssetSizes: assetSizes(localAssetMetadata)}
+	if limitErr := reportLimitProblems(ic.Log(), validation.CheckLimits(limits, assetUsage)); limitErr != nil {
+		return fail(limitErr)
+	}
+
+	remoteAssetMetadata, err := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return fail(fmt.Errorf("error retrieving remote assets: %s", err))
+	}
+
+	plan := &hostingPlan{
+		rootDir:    rootDir,
+		assetDiffs: hosting.DiffAssetMetadata(localAssetMetadata, remoteAssetMetadata, ic.flagStrategy == importStrategyMerge),
+	}
+
+	localConfig, err := hosting.ConfigFileToConfig(filepath.Join(appPath, utils.HostingConfig))
+	if err != nil && !os.IsNotExist(err) {
+		return fail(fmt.Errorf("error loading config.json file: %s", err))
+	}
+
+	if localConfig != nil {
+		remoteConfig, rCErr := stitchClient.FetchHostingConfig(app.GroupID, app.ID)
+		if rCErr != nil {
+			return fail(fmt.Errorf("error retrieving remote hosting config: %s", rCErr))
+		}
+
+		if ic.flagStrategy == importStrategyMerge {
+			localConfig = hosting.MergeConfig(localConfig, remoteConfig)
+		}
+
+		if plan.configDiff = hosting.DiffConfig(localConfig, remoteConfig); len(plan.configDiff) > 0 {
+			plan.config = localConfig
+		}
+	}
+
+	return plan, cleanup, nil
+}
+
+// applyHosting uploads, updates and deletes the assets of app as planned, and applies the planned hosting config.
+// It returns the operations that failed, and fails itself if any did unless --keep-going is set
+func (ic *ImportCommand) applyHosting(stitchClient api.StitchClient, app *models.App, plan *hostingPlan) ([]hosting.FailedOperation, error) {
+	ic.Log().Info("Importing hosting assets...")
+	hostingStart := time.Now()
+	hostingClient := stitchClient
+	if ic.uploadRateLimit > 0 {
+		hostingClient = &rateLimitedClient{stitchClient, utils.NewRateLimiter(ic.uploadRateLimit)}
+	}
+
+	var invalidatePaths []string
+	if ic.flagResetCDNCache || len(ic.flagInvalidatePaths) > 0 {
+		var err error
+		if invalidatePaths, err = hosting.InvalidationPaths(plan.assetDiffs, ic.flagInvalidatePaths); err != nil {
+			return nil, err
+		}
+	}
+
+	var failures []hosting.FailedOperation
+	if err := ImportHosting(app.GroupID, app.ID, plan.rootDir, plan.assetDiffs, hostingClient, ic.Log()); err != nil {
+		failedErr, ok := err.(*hostingImportError)
+		if !ok {
+			return nil, fmt.Errorf("failed to import hosting assets %s", err)
+		}
+
+		failures = failedErr.failures
+		if !ic.flagKeepGoing {
+			ic.report.recordHosting(plan.assetDiffs, nil, failures)
+			return failures, ic.reportHostingFailures(app, failures)
+		}
+	}
+	ic.report.timeSince("hosting", hostingStart)
+
+	// a failed invalidation leaves an outdated copy of an asset in the CDN for a while, which is not worth
+	// failing an import whose assets are uploaded over
+	stale := invalidateCache(app.GroupID, app.ID, invalidatePaths, stitchClient, ic.Log())
+	if len(stale) > 0 {
+		ic.Log().Warn(fmt.Sprintf(
+			"the CDN cache of %d path(s) may still be stale, as it could not be invalidated: %s. Run \"hosting invalidate\" with them to try again",
+			len(stale),
+			strings.Join(stale, ", "),
+		))
+	}
+
+	isStale := map[string]bool{}
+	for _, path := range stale {
+		isStale[path] = true
+	}
+
+	invalidated := make([]string, 0, len(invalidatePaths))
+	for _, path := range invalidatePaths {
+		if !isStale[path] {
+			invalidated = append(invalidated, path)
+		}
+	}
+
+	ic.report.recordHosting(plan.assetDiffs, invalidated, failures)
+	ic.report.Hosting.StaleCache = stale
+
+	if plan.config != nil {
+		if err := stitchClient.UpdateHostingConfig(app.GroupID, app.ID, plan.config); err != nil {
+			return failures, fmt.Errorf("failed to import hosting config: %s", err)
+		}
+		ic.report.Hosting.ConfigUpdated = true
+	}
+	ic.Log().Info("Done.")
+
+	return failures, nil
+}
+
+// importHostingOnly imports the hosting assets and settings of the app at appPath, for --hosting-only, leaving
+// the rest of its config as deployed. The app must already exist
+func (ic *ImportCommand) importHostingOnly(appPath string, appInstanceData models.AppInstanceData) error {
+	stitchClient, err := ic.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	ic.report.ClientAppID = appInstanceData.AppID()
+
+	app, err := ic.fetchAppByClientAppID(appInstanceData.AppID())
+	if err != nil {
+		if _, ok := err.(api.ErrAppNotFound); ok {
+			return fmt.Errorf("%s, and --%s cannot create it", err, importFlagHostingOnly)
+		}
+		return err
+	}
+
+	if err := ic.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	ic.report.GroupID = app.GroupID
+	ic.report.AppID = app.ID
+
+	lock, err := ic.acquireDeployLock(stitchClient, app)
+	if err != nil {
+		return err
+	}
+	defer ic.releaseDeployLock(stitchClient, app, lock)
+
+	plan, cleanup, err := ic.planHosting(stitchClient, app, appPath, appInstanceData.AppID(), ic.fetchLimits(stitchClient))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if !ic.flagYes {
+		diffs := plan.diff()
+		ic.report.Diff = append(ic.report.Diff, diffs...)
+
+		if len(diffs) == 0 {
+			ic.Log().Info("Deployed hosting assets and settings are identical to the local ones, nothing to do.")
+			ic.report.skip(importOutcomeNoChanges)
+			return nil
+		}
+
+		for _, diff := range diffs {
+			ic.Diff(diff)
+		}
+
+		ic.describeTarget(stitchClient, app)
+
+		confirm, askErr := ic.AskYesNo("Please confirm the changes shown above:")
+		if askErr != nil {
+			return askErr
+		}
+
+		if !confirm {
+			ic.report.skip(importOutcomeCancelled)
+			return nil
+		}
+	}
+
+	failures, err := ic.applyHosting(stitchClient, app, plan)
+	if err != nil {
+		return err
+	}
+
+	if ic.flagSaveManifest != "" {
+		if err := saveAssetManifest(stitchClient, app, ic.flagSaveManifest); err != nil {
+			return fmt.Errorf("imported hosting assets but failed to save asset manifest: %s", err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return ic.reportHostingFailures(app, failures)
+	}
+
+	if ic.smokeTests != nil {
+		smokeTestStart := time.Now()
+		if err := ic.runSmokeTests(stitchClient, app, ic.smokeTests); err != nil {
+			return err
+		}
+		ic.report.timeSince("smoke_test", smokeTestStart)
+	}
+
+	ic.Success(fmt.Sprintf("Successfully imported the hosting assets of '%s'", app.ClientAppID))
+
+	return nil
 }
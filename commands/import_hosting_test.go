@@ -1,15 +1,25 @@
 package commands
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/utils"
 	u "github.com/10gen/stitch-cli/utils/test"
 	gc "github.com/smartystreets/goconvey/convey"
 
@@ -52,7 +62,7 @@ func TestImportHosting(t *testing.T) {
 		}
 		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
 		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
-		u.So(t, ImportHosting("groupID", "appID", rootDir, assetMetadataDiffs, false, testClient, cli.NewMockUi()), gc.ShouldBeNil)
+		u.So(t, ImportHosting("groupID", "appID", rootDir, assetMetadataDiffs, testClient, logging.New(cli.NewMockUi(), logging.LevelInfo, nil)), gc.ShouldBeNil)
 	})
 
 	t.Run("should log errors correctly", func(t *testing.T) {
@@ -63,11 +73,150 @@ func TestImportHosting(t *testing.T) {
 		testClient := api.NewStitchClient(api.NewClient(testServer.URL))
 
 		mockUI := cli.NewMockUi()
-		importErr := ImportHosting("groupID", "appID", rootDir, assetMetadataDiffs, false, testClient, mockUI)
+		importErr := ImportHosting("groupID", "appID", rootDir, assetMetadataDiffs, testClient, logging.New(mockUI, logging.LevelInfo, nil))
 		u.So(t, importErr, gc.ShouldNotBeNil)
 		u.So(t, importErr.Error(), gc.ShouldContainSubstring, "3")
 		u.So(t, len(strings.Split(mockUI.ErrorWriter.String(), "\n"))-1, gc.ShouldEqual, 3)
 	})
+
+	t.Run("should collect the failed operations after attempting the rest", func(t *testing.T) {
+		client := &u.MockStitchClient{
+			UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+				if path == "/ships/nostromo.json" {
+					return fmt.Errorf("oh noes")
+				}
+				return nil
+			},
+			DeleteAssetFn: func(groupID, appID, path string) error {
+				return nil
+			},
+		}
+
+		mockUI := cli.NewMockUi()
+		importErr := ImportHosting("groupID", "appID", rootDir, assetMetadataDiffs, client, logging.New(mockUI, logging.LevelInfo, nil))
+		u.So(t, importErr, gc.ShouldNotBeNil)
+		u.So(t, importErr.(*hostingImportError).failures, gc.ShouldResemble, []hosting.FailedOperation{
+			{Operation: hosting.OperationUpload, FilePath: "/ships/nostromo.json", Reason: "oh noes"},
+		})
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "deleted '/deleteMe'")
+	})
+
+	t.Run("with several assets of the same content", func(t *testing.T) {
+		dupDir, dirErr := ioutil.TempDir("", "stitch-dedup")
+		u.So(t, dirErr, gc.ShouldBeNil)
+		defer os.RemoveAll(dupDir)
+
+		large := bytes.Repeat([]byte("a"), minDedupSize)
+		small := []byte("tiny")
+		for _, file := range []struct {
+			name string
+			data []byte
+		}{{"a.js", large}, {"b.js", large}, {"c.js", large}, {"d.txt", small}, {"e.txt", small}} {
+			u.So(t, ioutil.WriteFile(filepath.Join(dupDir, file.name), file.data, 0644), gc.ShouldBeNil)
+		}
+
+		attrs := []hosting.AssetAttribute{{Name: "Content-Type", Value: "application/javascript"}}
+		dupDiffs := &hosting.AssetMetadataDiffs{
+			AddedLocally: []hosting.AssetMetadata{
+				{FilePath: "/a.js", FileHash: "large", FileSize: int64(len(large)), Attrs: attrs},
+				{FilePath: "/b.js", FileHash: "large", FileSize: int64(len(large)), Attrs: attrs},
+				{FilePath: "/d.txt", FileHash: "small", FileSize: int64(len(small))},
+				{FilePath: "/e.txt", FileHash: "small", FileSize: int64(len(small))},
+			},
+			DeletedLocally: []hosting.AssetMetadata{},
+			ModifiedLocally: []hosting.ModifiedAssetMetadata{
+				{
+					AssetMetadata: hosting.AssetMetadata{FilePath: "/c.js", FileHash: "large", FileSize: int64(len(large)), Attrs: attrs},
+					BodyModified:  true,
+				},
+			},
+		}
+
+		newDedupClient := func(failedUploads ...string) (*u.MockStitchClient, *[]string, *[]string, *[]string) {
+			var mu sync.Mutex
+			var uploaded, copied, attributed []string
+			return &u.MockStitchClient{
+				UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+					mu.Lock()
+					defer mu.Unlock()
+					uploaded = append(uploaded, path)
+					for _, failedUpload := range failedUploads {
+						if path == failedUpload {
+							return fmt.Errorf("oh noes")
+						}
+					}
+					return nil
+				},
+				CopyAssetFn: func(groupID, appID, fromPath, toPath string) error {
+					mu.Lock()
+					defer mu.Unlock()
+					copied = append(copied, fromPath+" -> "+toPath)
+					return nil
+				},
+				SetAssetAttributesFn: func(groupID, appID, path string, attributes ...hosting.AssetAttribute) error {
+					mu.Lock()
+					defer mu.Unlock()
+					u.So(t, attributes, gc.ShouldResemble, attrs)
+					attributed = append(attributed, path)
+					return nil
+				},
+			}, &uploaded, &copied, &attributed
+		}
+
+		t.Run("should upload the content once and copy it to the other paths", func(t *testing.T) {
+			client, uploaded, copied, attributed := newDedupClient()
+
+			mockUI := cli.NewMockUi()
+			u.So(t, ImportHosting("groupID", "appID", dupDir, dupDiffs, client, logging.New(mockUI, logging.LevelInfo, nil)), gc.ShouldBeNil)
+
+			sort.Strings(*uploaded)
+			sort.Strings(*copied)
+			sort.Strings(*attributed)
+			u.So(t, *uploaded, gc.ShouldResemble, []string{"/a.js", "/d.txt", "/e.txt"})
+			u.So(t, *copied, gc.ShouldResemble, []string{"/a.js -> /b.js", "/a.js -> /c.js"})
+			u.So(t, *attributed, gc.ShouldResemble, []string{"/b.js", "/c.js"})
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "uploaded '/b.js' as a copy of '/a.js'")
+		})
+
+		t.Run("should upload the content to the other paths if its first upload fails", func(t *testing.T) {
+			client, uploaded, copied, _ := newDedupClient("/a.js")
+
+			importErr := ImportHosting("groupID", "appID", dupDir, dupDiffs, client, logging.New(cli.NewMockUi(), logging.LevelInfo, nil))
+			u.So(t, importErr, gc.ShouldNotBeNil)
+			u.So(t, importErr.(*hostingImportError).failures, gc.ShouldResemble, []hosting.FailedOperation{
+				{Operation: hosting.OperationUpload, FilePath: "/a.js", Reason: "oh noes"},
+			})
+
+			sort.Strings(*uploaded)
+			u.So(t, *uploaded, gc.ShouldResemble, []string{"/a.js", "/b.js", "/c.js", "/d.txt", "/e.txt"})
+			u.So(t, *copied, gc.ShouldBeEmpty)
+		})
+	})
+}
+
+func TestInvalidateCache(t *testing.T) {
+	defer func(delay time.Duration) { invalidateRetryDelay = delay }(invalidateRetryDelay)
+	invalidateRetryDelay = time.Millisecond
+
+	attempts := map[string]int{}
+	client := &u.MockStitchClient{
+		InvalidateCacheFn: func(groupID, appID, path string) error {
+			attempts[path]++
+			if path == "/flaky.html" && attempts[path] < 3 {
+				return errors.New("gateway timeout")
+			}
+			if path == "/down.html" {
+				return errors.New("service unavailable")
+			}
+			return nil
+		},
+	}
+
+	mockUI := cli.NewMockUi()
+	stale := invalidateCache("groupID", "appID", []string{"/index.html", "/flaky.html", "/down.html"}, client, logging.New(mockUI, logging.LevelInfo, nil))
+	u.So(t, stale, gc.ShouldResemble, []string{"/down.html"})
+	u.So(t, attempts, gc.ShouldResemble, map[string]int{"/index.html": 1, "/flaky.html": 3, "/down.html": invalidateMaxAttempts})
+	u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed to invalidate the CDN cache of '/down.html' => service unavailable")
 }
 
 func TestHostingOp(t *testing.T) {
@@ -118,6 +267,29 @@ func TestHostingOp(t *testing.T) {
 			add.client = testClient
 			u.So(t, add.Do(), gc.ShouldBeNil)
 		})
+
+		t.Run("Do should upload a directory without a body", func(t *testing.T) {
+			var uploadedPath string
+			var uploadedSize int64
+			var uploadedBody []byte
+			addDir := addOp{
+				baseHostingOp{
+					"groupID", "appID", rootDir, &u.MockStitchClient{
+						UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+							uploadedPath, uploadedSize = path, size
+							uploadedBody, _ = ioutil.ReadAll(body)
+							return nil
+						},
+					},
+				},
+				hosting.AssetMetadata{FilePath: "/empty/"},
+			}
+
+			u.So(t, addDir.Do(), gc.ShouldBeNil)
+			u.So(t, uploadedPath, gc.ShouldEqual, "/empty/")
+			u.So(t, uploadedSize, gc.ShouldEqual, 0)
+			u.So(t, uploadedBody, gc.ShouldBeEmpty)
+		})
 	})
 
 	t.Run("deleteOp", func(t *testing.T) {
@@ -241,3 +413,20 @@ func TestHostingOp(t *testing.T) {
 	})
 
 }
+
+func TestRateLimitedClient(t *testing.T) {
+	var uploaded []byte
+	client := &rateLimitedClient{
+		&u.MockStitchClient{
+			UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+				var err error
+				uploaded, err = ioutil.ReadAll(body)
+				return err
+			},
+		},
+		utils.NewRateLimiter(1024 * 1024),
+	}
+
+	u.So(t, client.UploadAsset("groupID", "appID", "/index.html", "hash", 5, strings.NewReader("hello")), gc.ShouldBeNil)
+	u.So(t, string(uploaded), gc.ShouldEqual, "hello")
+}
@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// deployLockTTL is how long a deploy lock is held at most, so that the lock of an import that never
+// released it, e.g. because its CI job was killed, does not block later imports forever
+const deployLockTTL = time.Hour
+
+// defaultDeployLockWaitTimeout is how long --wait waits for the deploy lock at most, unless --wait-timeout is given
+const defaultDeployLockWaitTimeout = deployLockTTL
+
+// deployLockPollInterval is how long to wait between attempts to take a deploy lock held by another import
+var deployLockPollInterval = 10 * time.Second
+
+// deployLock is the contents of the value holding the deploy lock of an app
+type deployLock struct {
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// acquireDeployLock takes the deploy lock of the app, so that no other import deploys to it at the same time,
+// returning the value holding it. A lock that has expired is taken over. If the lock is held by another import,
+// it fails, unless --wait is set, in which case it waits up to --wait-timeout for the lock to be released
+func (ic *ImportCommand) acquireDeployLock(client api.StitchClient, app *models.App) (*models.Value, error) {
+	if ic.flagForceUnlock {
+		if err := ic.removeDeployLock(client, app, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	waitTimeout := ic.flagWaitTimeout
+	if waitTimeout == 0 {
+		waitTimeout = defaultDeployLockWaitTimeout
+	}
+	deadline := time.Now().Add(waitTimeout)
+
+	waiting := false
+	for {
+		now := time.Now().UTC()
+		contents, err := json.Marshal(deployLock{
+			Holder:     ic.deployLockHolder(),
+			AcquiredAt: now,
+			ExpiresAt:  now.Add(deployLockTTL),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := client.CreateValue(app.GroupID, app.ID, models.Value{
+			Name:    utils.DeployLockValueName,
+			Value:   contents,
+			Private: true,
+		})
+		if err == nil {
+			return value, nil
+		}
+		if err != api.ErrValueExists {
+			return nil, fmt.Errorf("failed to take the deploy lock of '%s': %s", app.ClientAppID, err)
+		}
+
+		held, lock, err := findDeployLock(client, app)
+		if err != nil {
+			return nil, err
+		}
+		if held == nil {
+			// the lock was released in the meantime
+			continue
+		}
+
+		if now.After(lock.ExpiresAt) {
+			ic.Log().Warn(fmt.Sprintf("Taking over the deploy lock of '%s' held by %s, which expired at %s", app.ClientAppID, lock.Holder, lock.ExpiresAt.Format(time.RFC3339)))
+			if err := ic.removeDeployLock(client, app, held); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !ic.flagWait {
+			return nil, fmt.Errorf(
+				"'%s' is being imported by %s since %s; run with --%s to wait until it is done, or with --%s if that import is no longer running",
+				app.ClientAppID,
+				lock.Holder,
+				lock.AcquiredAt.Format(time.RFC3339),
+				importFlagWait,
+				importFlagForceUnlock,
+			)
+		}
+
+		if now.After(deadline) {
+			return nil, fmt.Errorf(
+				"'%s' is still being imported by %s after waiting %s; run with --%s if that import is no longer running",
+				app.ClientAppID,
+				lock.Holder,
+				waitTimeout,
+				importFlagForceUnlock,
+			)
+		}
+
+		if !waiting {
+			ic.Log().Info(fmt.Sprintf("Waiting for the import by %s to finish...", lock.Holder))
+			waiting = true
+		}
+		time.Sleep(deployLockPollInterval)
+	}
+}
+
+// releaseDeployLock releases the deploy lock held in value, only warning if it cannot
+func (ic *ImportCommand) releaseDeployLock(client api.StitchClient, app *models.App, value *models.Value) {
+	if err := client.DeleteValue(app.GroupID, app.ID, value.ID); err != nil {
+		ic.Log().Warn(fmt.Sprintf("failed to release the deploy lock of '%s', which expires on its own in %s: %s", app.ClientAppID, deployLockTTL, err))
+	}
+}
+
+// removeDeployLock removes the deploy lock of the app, looking it up unless it is given
+func (ic *ImportCommand) removeDeployLock(client api.StitchClient, app *models.App, held *models.Value) error {
+	if held == nil {
+		var err error
+		var lock deployLock
+		if held, lock, err = findDeployLock(client, app); err != nil || held == nil {
+			return err
+		}
+		ic.Log().Warn(fmt.Sprintf("Removing the deploy lock of '%s' held by %s", app.ClientAppID, lock.Holder))
+	}
+
+	if err := client.DeleteValue(app.GroupID, app.ID, held.ID); err != nil {
+		return fmt.Errorf("failed to remove the deploy lock of '%s': %s", app.ClientAppID, err)
+	}
+	return nil
+}
+
+// deployLockHolder describes this import to other imports waiting on its lock
+func (ic *ImportCommand) deployLockHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+
+	if user, err := ic.User(); err == nil && user.Username != "" {
+		return fmt.Sprintf("%s on %s (pid %d)", user.Username, host, os.Getpid())
+	}
+	return fmt.Sprintf("%s (pid %d)", host, os.Getpid())
+}
+
+// findDeployLock returns the value holding the deploy lock of the app, and the lock itself, or nil if
+// the lock is not held
+func findDeployLock(client api.StitchClient, app *models.App) (*models.Value, deployLock, error) {
+	values, err := client.FetchValues(app.GroupID, app.ID)
+	if err != nil {
+		return nil, deployLock{}, fmt.Errorf("failed to check the deploy lock of '%s': %s", app.ClientAppID, err)
+	}
+
+	for i, value := range values {
+		if value.Name != utils.DeployLockValueName {
+			continue
+		}
+
+		var lock deployLock
+		if err := json.Unmarshal(value.Value, &lock); err != nil {
+			return nil, deployLock{}, fmt.Errorf("failed to read the deploy lock of '%s': %s", app.ClientAppID, err)
+		}
+		return &values[i], lock, nil
+	}
+
+	return nil, deployLock{}, nil
+}
+
+// withoutDeployLock returns the diffs without those of the value holding the deploy lock, which belongs to
+// the deployed app rather than to its configuration
+func withoutDeployLock(diffs []string) []string {
+	var kept []string
+	for _, diff := range diffs {
+		if !strings.Contains(diff, utils.DeployLockValueName) {
+			kept = append(kept, diff)
+		}
+	}
+	return kept
+}
+
+// withDeployLock returns a copy of the loaded app that includes the value holding the deploy lock, so that
+// a replacing import does not remove the lock it is holding
+func withDeployLock(loadedApp map[string]interface{}, value *models.Value) map[string]interface{} {
+	lockedApp := make(map[string]interface{}, len(loadedApp))
+	for key, field := range loadedApp {
+		lockedApp[key] = field
+	}
+
+	values, _ := loadedApp["values"].([]interface{})
+	lockedApp["values"] = append(append([]interface{}{}, values...), map[string]interface{}{
+		"id":      value.ID,
+		"name":    value.Name,
+		"value":   value.Value,
+		"private": value.Private,
+	})
+	return lockedApp
+}
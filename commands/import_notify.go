@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+const (
+	notifyTimeout = 20 * time.Second
+
+	defaultNotifyTemplate = `{{if .Success}}Successfully imported {{.ClientAppID}}` +
+		`{{else if eq .Outcome "cancelled"}}Cancelled the import of {{.ClientAppID}}` +
+		`{{else if eq .Outcome "no_changes"}}Nothing to import to {{.ClientAppID}}, which is up to date` +
+		`{{else}}Failed to import {{.ClientAppID}}: {{.Error}}{{end}}`
+)
+
+// importNotification is the body POSTed to a notification webhook. The "text" field is understood by
+// Slack-compatible incoming webhooks, while the full report is available to anything else
+type importNotification struct {
+	Text   string        `json:"text"`
+	Report *importReport `json:"report"`
+}
+
+// notifyWebhook returns the URL that should be notified of the import's outcome, if any
+func (ic *ImportCommand) notifyWebhook() string {
+	if ic.flagNotifyWebhook != "" {
+		return ic.flagNotifyWebhook
+	}
+
+	if ic.projectConfig != nil {
+		return ic.projectConfig.Notify.Webhook
+	}
+
+	return ""
+}
+
+func (ic *ImportCommand) notifyTemplate() string {
+	if ic.flagNotifyTemplate != "" {
+		return ic.flagNotifyTemplate
+	}
+
+	if ic.projectConfig != nil && ic.projectConfig.Notify.Template != "" {
+		return ic.projectConfig.Notify.Template
+	}
+
+	return defaultNotifyTemplate
+}
+
+// notify sends the finished import report to the configured webhook, if any
+func (ic *ImportCommand) notify() error {
+	url := ic.notifyWebhook()
+	if url == "" {
+		return nil
+	}
+
+	return postNotification(url, ic.notifyTemplate(), ic.report)
+}
+
+func postNotification(url, messageTemplate string, report *importReport) error {
+	tmpl, err := template.New("notification").Parse(messageTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid template: %s", err)
+	}
+
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, report); err != nil {
+		return fmt.Errorf("invalid template: %s", err)
+	}
+
+	body, err := json.Marshal(importNotification{Text: text.String(), Report: report})
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: notifyTimeout}
+	res, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with %s", res.Status)
+	}
+
+	return nil
+}
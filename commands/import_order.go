@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// importStages imports the new entities of loadedApp that others refer to in their config ahead of the rest of it,
+// in as many imports as it takes for each entity to be created after those it refers to, e.g. a service before its
+// incoming webhooks and rules. The server creates the entities of a single import in no particular order, so
+// that importing them together may fail with the service or function they refer to not being found. It is done
+// for a new app, and with --ordered for an existing one, whose new entities are found by exporting it
+func (ic *ImportCommand) importStages(stitchClient api.StitchClient, app *models.App, loadedApp map[string]interface{}, newApp bool) error {
+	if !newApp && !ic.flagOrdered {
+		return nil
+	}
+
+	var deployedApp map[string]interface{}
+	if !newApp {
+		var err error
+		if deployedApp, err = loadDeployedApp(stitchClient, app); err != nil {
+			return err
+		}
+	}
+
+	stages := utils.ImportStages(loadedApp, deployedApp)
+
+	var names []string
+	for i, stage := range stages {
+		names = append(names, stage...)
+		stageData, err := json.Marshal(utils.SelectEntities(loadedApp, names))
+		if err != nil {
+			return err
+		}
+
+		ic.Log().Info(fmt.Sprintf("Importing %s ahead of the entities referring to them (stage %d of %d)...", strings.Join(stage, ", "), i+1, len(stages)+1))
+		reportProgress := func(deployment *models.Deployment) {
+			ic.Log().Info(fmt.Sprintf("Deployment %s is %s...", deployment.ID, deployment.Status))
+		}
+		if err := stitchClient.ImportWithProgress(app.GroupID, app.ID, stageData, importStrategyMerge, ic.flagDeployTimeout, reportProgress); err != nil {
+			return fmt.Errorf("failed to import %s: %s", strings.Join(stage, ", "), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// composeOwnedApp returns the app to import with --owner: the deployed app with the entities of the owner
+// replaced by those of loadedApp. As it holds every entity of the app, it is imported with the replace strategy,
+// so that the owner's entities missing from loadedApp are removed. The secrets of loadedApp are kept, as they
+// are not exported
+func (ic *ImportCommand) composeOwnedApp(stitchClient api.StitchClient, app *models.App, loadedApp map[string]interface{}) (map[string]interface{}, error) {
+	deployedApp, err := loadDeployedApp(stitchClient, app)
+	if err != nil {
+		return nil, err
+	}
+
+	composed, err := utils.ComposeOwnedApp(deployedApp, loadedApp, ic.flagOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import the entities of %s: %s", ic.flagOwner, err)
+	}
+
+	if appSecrets, ok := loadedApp[secretsKey]; ok {
+		composed[secretsKey] = appSecrets
+	}
+
+	ic.flagStrategy = importStrategyReplace
+	ic.Log().Info(fmt.Sprintf("Importing the entities of %s, leaving the rest of '%s' as deployed", ic.flagOwner, app.ClientAppID))
+
+	return composed, nil
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/10gen/stitch-cli/secrets"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// redactedEnvPrefix starts the name of the environment variable, and --secrets-file entry, that supplies the
+// value of a field of a redacted app
+const redactedEnvPrefix = "STITCH_REDACTED_"
+
+// redactedFieldKey returns the name the value of a redacted field is looked up by, e.g.
+// STITCH_REDACTED_AUTH_PROVIDERS_OAUTH2_GOOGLE_CONFIG_CLIENTSECRET for ".config.clientSecret" in
+// "auth_providers/oauth2-google.json"
+func redactedFieldKey(field utils.RedactedField) string {
+	name := strings.TrimSuffix(field.Path, ".json") + field.Field
+
+	var key []rune
+	for _, r := range strings.ToUpper(name) {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			r = '_'
+		}
+		if r == '_' && (len(key) == 0 || key[len(key)-1] == '_') {
+			continue
+		}
+		key = append(key, r)
+	}
+
+	return redactedEnvPrefix + strings.TrimSuffix(string(key), "_")
+}
+
+// resolveRedactions copies the config of the app at appPath, which was exported with "export --redact", to a
+// temporary directory with its placeholders replaced by values from the environment, the --secrets-file
+// or, unless --yes is given, prompts. Values from the environment and the file may be secret references,
+// as described by "help import". It fails listing the fields that are left without a value
+func (ic *ImportCommand) resolveRedactions(appPath string) (string, error) {
+	fileValues, err := ic.readSecretsFile()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-import-resolved")
+	if err != nil {
+		return "", err
+	}
+
+	var promptErr, referenceErr error
+	unresolved, err := utils.ResolveRedactions(appPath, dir, func(field utils.RedactedField) (string, bool) {
+		key := redactedFieldKey(field)
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			value, ok = fileValues[key]
+		}
+		if ok {
+			resolved, err := secrets.Resolve(value)
+			if err != nil && referenceErr == nil {
+				referenceErr = fmt.Errorf("%s: %s", key, err)
+			}
+			return resolved, err == nil
+		}
+
+		if ic.flagYes || promptErr != nil {
+			return "", false
+		}
+
+		value, err := ic.AskSecret(fmt.Sprintf("Value for %s in %s (%s):", field.Field, field.Path, key), "the "+key+" environment variable or --"+importFlagSecretsFile)
+		if err != nil {
+			promptErr = err
+			return "", false
+		}
+		return value, value != ""
+	})
+	if err == nil {
+		err = referenceErr
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to resolve redacted fields: %s", err)
+	}
+
+	if len(unresolved) > 0 {
+		os.RemoveAll(dir)
+
+		lines := make([]string, 0, len(unresolved))
+		for _, field := range unresolved {
+			lines = append(lines, fmt.Sprintf("  %s in %s (%s)", field.Field, field.Path, redactedFieldKey(field)))
+		}
+
+		return "", fmt.Errorf(
+			"the app at %s was exported with --redact and %d redacted field(s) have no value; set them in the environment or --%s:\n%s",
+			appPath,
+			len(unresolved),
+			importFlagSecretsFile,
+			strings.Join(lines, "\n"),
+		)
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// the outcomes of an import, as recorded in its report
+const (
+	importOutcomeImported  = "imported"
+	importOutcomeFailed    = "failed"
+	importOutcomeCancelled = "cancelled"
+	importOutcomeNoChanges = "no_changes"
+)
+
+// importReport is a structured record of everything an import did, written out with --report-file
+type importReport struct {
+	mu sync.Mutex
+
+	ClientAppID  string               `json:"client_app_id"`
+	GroupID      string               `json:"group_id,omitempty"`
+	AppID        string               `json:"app_id,omitempty"`
+	Strategy     string               `json:"strategy"`
+	Outcome      string               `json:"outcome"`
+	Success      bool                 `json:"success"`
+	Error        string               `json:"error,omitempty"`
+	NewApp       bool                 `json:"new_app"`
+	Diff         []string             `json:"diff"`
+	DeploymentID string               `json:"deployment_id,omitempty"`
+	Hosting      *hostingReport       `json:"hosting,omitempty"`
+	Canary       *canaryReport        `json:"canary,omitempty"`
+	SharedCode   []utils.InjectedLibs `json:"shared_code,omitempty"`
+	StartedAt    time.Time            `json:"started_at"`
+	Durations    map[string]float64   `json:"durations_seconds"`
+	Warnings     []string             `json:"warnings"`
+}
+
+// hostingReport records the hosting asset operations performed by an import
+type hostingReport struct {
+	Uploaded          []string                  `json:"uploaded"`
+	AttributesUpdated []string                  `json:"attributes_updated"`
+	Deleted           []string                  `json:"deleted"`
+	Invalidated       []string                  `json:"invalidated"`
+	StaleCache        []string                  `json:"stale_cache,omitempty"`
+	Failed            []hosting.FailedOperation `json:"failed"`
+	ConfigUpdated     bool                      `json:"config_updated"`
+}
+
+func newImportReport() *importReport {
+	return &importReport{
+		StartedAt: time.Now(),
+		Diff:      []string{},
+		Durations: map[string]float64{},
+		Warnings:  []string{},
+	}
+}
+
+// timeSince records the time elapsed since start as the duration of the named phase
+func (r *importReport) timeSince(phase string, start time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Durations[phase] = time.Since(start).Seconds()
+}
+
+func (r *importReport) warn(message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Warnings = append(r.Warnings, message)
+}
+
+// recordHosting records the hosting operations described by the diffs as having been performed, except
+// for those that failed
+func (r *importReport) recordHosting(diffs *hosting.AssetMetadataDiffs, invalidated []string, failures []hosting.FailedOperation) {
+	report := &hostingReport{
+		Uploaded:          []string{},
+		AttributesUpdated: []string{},
+		Deleted:           []string{},
+		Invalidated:       invalidated,
+		Failed:            []hosting.FailedOperation{},
+	}
+
+	failed := map[string]bool{}
+	for _, failure := range failures {
+		failed[failure.FilePath] = true
+		report.Failed = append(report.Failed, failure)
+	}
+
+	for _, added := range diffs.AddedLocally {
+		if failed[added.FilePath] {
+			continue
+		}
+		report.Uploaded = append(report.Uploaded, added.FilePath)
+	}
+
+	for _, modified := range diffs.ModifiedLocally {
+		if failed[modified.AssetMetadata.FilePath] {
+			continue
+		}
+		if modified.BodyModified {
+			report.Uploaded = append(report.Uploaded, modified.AssetMetadata.FilePath)
+		} else {
+			report.AttributesUpdated = append(report.AttributesUpdated, modified.AssetMetadata.FilePath)
+		}
+	}
+
+	for _, deleted := range diffs.DeletedLocally {
+		if failed[deleted.FilePath] {
+			continue
+		}
+		report.Deleted = append(report.Deleted, deleted.FilePath)
+	}
+
+	r.Hosting = report
+}
+
+// skip records that the import ended without changing anything, with the given outcome, such as having
+// been cancelled
+func (r *importReport) skip(outcome string) {
+	r.Outcome = outcome
+}
+
+// finish records the outcome of the import and its total duration. An import that was skipped keeps the
+// outcome it was skipped with, and is not a success
+func (r *importReport) finish(importErr error) {
+	r.timeSince("total", r.StartedAt)
+
+	switch {
+	case importErr != nil:
+		r.Outcome = importOutcomeFailed
+		r.Error = importErr.Error()
+	case r.Outcome == "":
+		r.Outcome = importOutcomeImported
+	}
+	r.Success = r.Outcome == importOutcomeImported
+}
+
+// changed returns whether the finished import changed the app, or may have done so before failing
+func (r *importReport) changed() bool {
+	return r.Outcome == importOutcomeImported || r.Outcome == importOutcomeFailed
+}
+
+// writeFile writes the report as JSON to the file at path
+func (r *importReport) writeFile(path string) error {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// reportingLogger is a logging.Logger that records warnings in an importReport as they are logged
+type reportingLogger struct {
+	logging.Logger
+	report *importReport
+}
+
+// Warn logs the warning and records it in the report
+func (l *reportingLogger) Warn(message string) {
+	l.report.warn(message)
+	l.Logger.Warn(message)
+}
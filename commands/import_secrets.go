@@ -0,0 +1,315 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/secrets"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// secretExpansionPrefix starts a string in app config that expands to the value of a secret
+const secretExpansionPrefix = "%%secrets."
+
+// secretConfigEntities are the keys of the loaded app holding the entities with a secret_config
+var secretConfigEntities = []string{"auth_providers", "services"}
+
+// referencedSecrets returns the names of the secrets the loaded app refers to, in order: those named by the
+// secret_config of its auth providers and services, by values that come from a secret, and by
+// "%%secrets.<name>" expansions anywhere in its config. Secrets whose value the app's secrets.json gives are
+// left out, as the import creates them
+func referencedSecrets(loadedApp map[string]interface{}) []string {
+	names := map[string]bool{}
+
+	for _, key := range secretConfigEntities {
+		entities, _ := loadedApp[key].([]interface{})
+		for _, rawEntity := range entities {
+			secretConfig, _ := entityConfig(key, rawEntity)["secret_config"].(map[string]interface{})
+			for _, secretNames := range secretConfig {
+				addSecretNames(names, secretNames)
+			}
+		}
+	}
+
+	values, _ := loadedApp["values"].([]interface{})
+	for _, rawValue := range values {
+		value, _ := rawValue.(map[string]interface{})
+		if fromSecret, _ := value["from_secret"].(bool); fromSecret {
+			addSecretNames(names, value["value"])
+		}
+	}
+
+	addSecretExpansions(names, loadedApp)
+
+	bundled := bundledSecrets(loadedApp)
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		if !bundled[name] {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// bundledSecrets returns the names of the secrets whose value the app's secrets.json gives: those named by the
+// secret_config fields of the auth providers and services it has a value for, and the secrets it has a value
+// for by name under "values"
+func bundledSecrets(loadedApp map[string]interface{}) map[string]bool {
+	names := map[string]bool{}
+
+	appSecrets, _ := loadedApp["secrets"].(map[string]interface{})
+	if appSecrets == nil {
+		return names
+	}
+
+	for _, key := range secretConfigEntities {
+		entitySecrets, _ := appSecrets[key].(map[string]interface{})
+		entities, _ := loadedApp[key].([]interface{})
+		for _, rawEntity := range entities {
+			config := entityConfig(key, rawEntity)
+			name, _ := config["name"].(string)
+			fields, _ := entitySecrets[name].(map[string]interface{})
+			secretConfig, _ := config["secret_config"].(map[string]interface{})
+			for field := range fields {
+				addSecretNames(names, secretConfig[field])
+			}
+		}
+	}
+
+	values, _ := appSecrets["values"].(map[string]interface{})
+	for name := range values {
+		names[name] = true
+	}
+
+	return names
+}
+
+// entityConfig returns the config of an auth provider or service of the loaded app, under key. That of a service
+// is nested under "config", beside its incoming webhooks and rules
+func entityConfig(key string, rawEntity interface{}) map[string]interface{} {
+	entity, _ := rawEntity.(map[string]interface{})
+	if key == "services" {
+		config, _ := entity["config"].(map[string]interface{})
+		return config
+	}
+	return entity
+}
+
+// addSecretNames adds the secret named by v, or by each element of v if it is a list, to names
+func addSecretNames(names map[string]bool, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		if v != "" {
+			names[v] = true
+		}
+	case []interface{}:
+		for _, element := range v {
+			addSecretNames(names, element)
+		}
+	}
+}
+
+// addSecretExpansions adds the names of the secrets expanded by strings anywhere in v to names
+func addSecretExpansions(names map[string]bool, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		if strings.HasPrefix(v, secretExpansionPrefix) {
+			names[strings.TrimPrefix(v, secretExpansionPrefix)] = true
+		}
+	case []interface{}:
+		for _, element := range v {
+			addSecretExpansions(names, element)
+		}
+	case map[string]interface{}:
+		for _, value := range v {
+			addSecretExpansions(names, value)
+		}
+	}
+}
+
+// resolveValueReferences replaces the secret references within the values of the loaded app, other than
+// those that come from a secret, with the secrets they refer to. It returns the values as they were before,
+// by name, for those it changed
+func resolveValueReferences(loadedApp map[string]interface{}) (map[string]interface{}, error) {
+	references := map[string]interface{}{}
+
+	values, _ := loadedApp["values"].([]interface{})
+	for _, rawValue := range values {
+		value, ok := rawValue.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if fromSecret, _ := value["from_secret"].(bool); fromSecret {
+			continue
+		}
+
+		name, _ := value["name"].(string)
+		if s, ok := value["value"].(string); ok {
+			if !secrets.IsReference(s) {
+				continue
+			}
+
+			resolved, err := secrets.Resolve(s)
+			if err != nil {
+				return nil, fmt.Errorf("value '%s': %s", name, err)
+			}
+			value["value"] = resolved
+			references[name] = s
+			continue
+		}
+
+		// the references are only found within a copy, so that the value is kept as it was if there are none
+		raw, err := json.Marshal(value["value"])
+		if err != nil {
+			return nil, err
+		}
+		var original, resolved interface{}
+		if err := json.Unmarshal(raw, &original); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &resolved); err != nil {
+			return nil, err
+		}
+
+		if err := secrets.ResolveAll(resolved); err != nil {
+			return nil, fmt.Errorf("value '%s': %s", name, err)
+		}
+		if !reflect.DeepEqual(original, resolved) {
+			value["value"] = resolved
+			references[name] = original
+		}
+	}
+
+	return references, nil
+}
+
+// checkSecrets makes sure that every secret the loaded app refers to exists in the app it is imported to,
+// so that the app does not break at runtime. Missing secrets are created from the --secrets-file, or
+// prompted for unless --yes is given, in which case the import fails listing them. If the secrets of the
+// app cannot be fetched, the check is skipped
+func (ic *ImportCommand) checkSecrets(stitchClient api.StitchClient, app *models.App, loadedApp map[string]interface{}) error {
+	referenced := referencedSecrets(loadedApp)
+	if len(referenced) == 0 {
+		return nil
+	}
+
+	appSecrets, err := stitchClient.FetchSecrets(app.GroupID, app.ID)
+	if err != nil {
+		ic.Log().Warn(fmt.Sprintf("Skipping the check for missing secrets, as the secrets of '%s' could not be fetched: %s", app.ClientAppID, err))
+		return nil
+	}
+
+	existing := make(map[string]bool, len(appSecrets))
+	for _, secret := range appSecrets {
+		existing[secret.Name] = true
+	}
+
+	var missing []string
+	for _, name := range referenced {
+		if !existing[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// secrets with a value in the --secrets-file are created without asking, so that a new environment
+	// can be set up by a single import
+	fileValues, err := ic.readSecretsFile()
+	if err != nil {
+		return err
+	}
+
+	var unsupplied []string
+	for _, name := range missing {
+		value, ok := fileValues[name]
+		if !ok {
+			unsupplied = append(unsupplied, name)
+			continue
+		}
+
+		resolved, err := secrets.Resolve(value)
+		if err != nil {
+			return fmt.Errorf("--%s error: %s: %s", importFlagSecretsFile, name, err)
+		}
+
+		if err := stitchClient.CreateSecret(app.GroupID, app.ID, name, resolved); err != nil {
+			return fmt.Errorf("failed to create secret '%s': %s", name, err)
+		}
+		ic.Log().Info(fmt.Sprintf("Created secret '%s' from --%s", name, importFlagSecretsFile))
+	}
+
+	missing = unsupplied
+	if len(missing) == 0 {
+		return nil
+	}
+
+	errMissing := fmt.Errorf(
+		"the app refers to %d secret(s) that do not exist in '%s': %s",
+		len(missing),
+		app.ClientAppID,
+		strings.Join(missing, ", "),
+	)
+
+	if ic.flagYes {
+		return errMissing
+	}
+
+	ic.Log().Warn(errMissing.Error())
+	create, err := ic.AskYesNo("Create them now?")
+	if err != nil {
+		return err
+	}
+	if !create {
+		return errMissing
+	}
+
+	for _, name := range missing {
+		value, err := ic.AskSecret(fmt.Sprintf("Value for secret '%s':", name), "--"+importFlagSecretsFile)
+		if err != nil {
+			return err
+		}
+
+		if err := stitchClient.CreateSecret(app.GroupID, app.ID, name, value); err != nil {
+			return fmt.Errorf("failed to create secret '%s': %s", name, err)
+		}
+		ic.Log().Info(fmt.Sprintf("Created secret '%s'", name))
+	}
+
+	return nil
+}
+
+// readSecretsFile reads the values in the --secrets-file, if one is given
+func (ic *ImportCommand) readSecretsFile() (map[string]string, error) {
+	return readSecretsFile(ic.flagSecretsFile)
+}
+
+// readSecretsFile reads the values in the secrets file at path, which holds a "NAME=value" line for each, or none
+// if path is empty
+func readSecretsFile(path string) (map[string]string, error) {
+	if path == "" {
+		return map[string]string{}, nil
+	}
+
+	secretsPath, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := utils.ReadEnvFile(secretsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", secretsPath, err)
+	}
+
+	return values, nil
+}
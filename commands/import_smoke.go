@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	smokeTestTimeout = 30 * time.Second
+
+	incomingWebhookRoute = "/api/client/v2.0/app/%s/service/%s/incoming_webhook/%s"
+)
+
+// smokeTests is a set of checks, read from the file given by --smoke-test, run against an app once it is imported
+type smokeTests struct {
+	Checks []smokeCheck `json:"checks"`
+}
+
+// smokeCheck calls either a function, with the given arguments, or an incoming webhook (as "service/webhook"),
+// with a GET and the given query parameters, and checks the outcome against the expectation
+type smokeCheck struct {
+	Name     string            `json:"name"`
+	Function string            `json:"function,omitempty"`
+	Args     []interface{}     `json:"args,omitempty"`
+	Webhook  string            `json:"webhook,omitempty"`
+	Query    map[string]string `json:"query,omitempty"`
+	Expect   smokeExpectation  `json:"expect"`
+}
+
+// smokeExpectation describes a passing check. Status only applies to webhooks, and defaults to 200
+type smokeExpectation struct {
+	Status   int             `json:"status,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Contains string          `json:"contains,omitempty"`
+}
+
+func (sc smokeCheck) String() string {
+	if sc.Name != "" {
+		return sc.Name
+	}
+	if sc.Function != "" {
+		return "function " + sc.Function
+	}
+	return "webhook " + sc.Webhook
+}
+
+// readSmokeTests reads and validates the smoke tests in the file at path
+func readSmokeTests(path string) (*smokeTests, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tests smokeTests
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return nil, err
+	}
+
+	if len(tests.Checks) == 0 {
+		return nil, errors.New("no checks are defined")
+	}
+
+	for i, check := range tests.Checks {
+		if (check.Function == "") == (check.Webhook == "") {
+			return nil, fmt.Errorf("check %d must name exactly one of a function or a webhook", i+1)
+		}
+
+		if check.Webhook != "" && len(strings.Split(check.Webhook, "/")) != 2 {
+			return nil, fmt.Errorf("check %d must name its webhook as \"service/webhook\", got %q", i+1, check.Webhook)
+		}
+
+		if check.Function != "" && check.Expect.Status != 0 {
+			return nil, fmt.Errorf("check %d expects a status, which only applies to webhooks", i+1)
+		}
+	}
+
+	return &tests, nil
+}
+
+// runSmokeTests runs each of the checks against the imported app, reporting every failure before returning
+func (ic *ImportCommand) runSmokeTests(client api.StitchClient, app *models.App, tests *smokeTests) error {
+	ic.Log().Info(fmt.Sprintf("Running %d smoke check(s)...", len(tests.Checks)))
+
+	var failed int
+	for _, check := range tests.Checks {
+		var err error
+		if check.Function != "" {
+			err = checkFunction(client, app, check)
+		} else {
+			err = checkWebhook(ic.flagBaseURL, app, check)
+		}
+
+		if err != nil {
+			failed++
+			ic.Log().Error(fmt.Sprintf("smoke check '%s' failed => %s", check, err))
+			continue
+		}
+		ic.Log().Info(fmt.Sprintf("smoke check '%s' passed", check))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d smoke check(s) failed against '%s'", failed, len(tests.Checks), app.ClientAppID)
+	}
+
+	return nil
+}
+
+func checkFunction(client api.StitchClient, app *models.App, check smokeCheck) error {
+	execution, err := client.ExecuteFunction(app.GroupID, app.ID, check.Function, check.Args)
+	if err != nil {
+		return err
+	}
+
+	return check.Expect.match(execution.Result)
+}
+
+func checkWebhook(baseURL string, app *models.App, check smokeCheck) error {
+	parts := strings.Split(check.Webhook, "/")
+	webhookURL := strings.TrimRight(baseURL, "/") + fmt.Sprintf(
+		incomingWebhookRoute,
+		url.PathEscape(app.ClientAppID),
+		url.PathEscape(parts[0]),
+		url.PathEscape(parts[1]),
+	)
+
+	if len(check.Query) > 0 {
+		query := url.Values{}
+		for key, value := range check.Query {
+			query.Set(key, value)
+		}
+		webhookURL += "?" + query.Encode()
+	}
+
+	client := http.Client{Timeout: smokeTestTimeout}
+	res, err := client.Get(webhookURL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	expectedStatus := check.Expect.Status
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+
+	if res.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d, got %s", expectedStatus, res.Status)
+	}
+
+	return check.Expect.match(body)
+}
+
+// match checks the JSON result of a function, or the body of a webhook response, against the expectation
+func (se smokeExpectation) match(actual []byte) error {
+	if se.Contains != "" && !strings.Contains(string(actual), se.Contains) {
+		return fmt.Errorf("expected %q to be in the response, got %s", se.Contains, abbreviate(actual))
+	}
+
+	if len(se.Result) == 0 {
+		return nil
+	}
+
+	var expectedValue, actualValue interface{}
+	if err := json.Unmarshal(se.Result, &expectedValue); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(actual, &actualValue); err != nil {
+		return fmt.Errorf("expected %s, got a response that is not JSON: %s", se.Result, abbreviate(actual))
+	}
+
+	if !reflect.DeepEqual(expectedValue, actualValue) {
+		return fmt.Errorf("expected %s, got %s", se.Result, abbreviate(actual))
+	}
+
+	return nil
+}
+
+// abbreviate shortens a response for display in an error
+func abbreviate(data []byte) string {
+	const maxLength = 200
+
+	s := strings.TrimSpace(string(data))
+	if len(s) > maxLength {
+		return s[:maxLength] + "..."
+	}
+	return s
+}
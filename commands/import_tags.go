@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// selectTagged returns the app to import with --tag: loadedApp holding only the entities with the tag and those
+// they depend on. It is imported with the merge strategy, which leaves the entities missing from it as deployed
+func (ic *ImportCommand) selectTagged(loadedApp map[string]interface{}) (map[string]interface{}, error) {
+	names := utils.TaggedEntities(loadedApp, ic.flagTag)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no functions, triggers or services are tagged %s", ic.flagTag)
+	}
+
+	for _, name := range names {
+		ic.Log().Debug(fmt.Sprintf("Selected %s", name))
+	}
+	ic.Log().Info(fmt.Sprintf("Importing %d entities tagged %s or depended on by them, leaving the rest as deployed", len(names), ic.flagTag))
+
+	return utils.SelectEntities(loadedApp, names), nil
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+)
+
+// describeTarget shows the app changes are about to be imported to, so that it can be checked to be the intended
+// one before they are confirmed. Details that cannot be fetched are left out rather than failing the import
+func (ic *ImportCommand) describeTarget(stitchClient api.StitchClient, app *models.App) {
+	lines := []string{
+		"Importing to:",
+		fmt.Sprintf("  App:              %s (%s)", app.Name, app.ClientAppID),
+		fmt.Sprintf("  Project:          %s", ic.projectName(app.GroupID)),
+	}
+
+	if app.DeploymentModel != "" {
+		deploymentModel := app.DeploymentModel
+		if app.Location != "" {
+			deploymentModel += " (" + app.Location + ")"
+		}
+		lines = append(lines, fmt.Sprintf("  Deployment model: %s", deploymentModel))
+	}
+
+	deployment, err := stitchClient.FetchLatestDeployment(app.GroupID, app.ID)
+	switch {
+	case err != nil:
+		ic.Log().Debug(fmt.Sprintf("Leaving out the last deployment of '%s', as it could not be fetched: %s", app.ClientAppID, err))
+	case deployment == nil:
+		lines = append(lines, "  Last deployed:    never")
+	default:
+		lastDeployed := time.Unix(deployment.DeployedAt, 0).UTC().Format(time.RFC3339)
+		if deployment.UserID != "" {
+			lastDeployed += " by " + deployment.UserID
+		}
+		lines = append(lines, fmt.Sprintf("  Last deployed:    %s", lastDeployed))
+	}
+
+	ic.Log().Info(strings.Join(lines, "\n"))
+}
+
+// projectName describes the Atlas Project with the given ID by its name and ID, or by its ID alone if its name
+// cannot be fetched
+func (ic *ImportCommand) projectName(groupID string) string {
+	atlasClient, err := ic.AtlasClient()
+	if err != nil {
+		return groupID
+	}
+
+	groups, err := atlasClient.Groups()
+	if err != nil {
+		ic.Log().Debug(fmt.Sprintf("Leaving out the name of Project %s, as the projects could not be fetched: %s", groupID, err))
+		return groupID
+	}
+
+	for _, group := range groups {
+		if group.ID == groupID {
+			return fmt.Sprintf("%s (%s)", group.Name, group.ID)
+		}
+	}
+
+	return groupID
+}
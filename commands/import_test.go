@@ -1,20 +1,27 @@
 package commands
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/api/mdbcloud"
 	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/logging"
 	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/selector"
 	"github.com/10gen/stitch-cli/user"
 	"github.com/10gen/stitch-cli/utils"
 	u "github.com/10gen/stitch-cli/utils/test"
@@ -38,6 +45,9 @@ func setUpBasicCommand() (*ImportCommand, *cli.MockUi) {
 	importCommand.writeAppConfigToFile = func(dest string, app models.AppInstanceData) error {
 		return nil
 	}
+	importCommand.writeProjectConfig = func(dest string, config *models.ProjectConfig) error {
+		return nil
+	}
 
 	mockStitchClient := &u.MockStitchClient{
 		ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
@@ -57,6 +67,7 @@ func setUpBasicCommand() (*ImportCommand, *cli.MockUi) {
 		},
 	}
 	importCommand.stitchClient = mockStitchClient
+	importCommand.atlasClient = &u.MockMDBClient{}
 	return importCommand, mockUI
 }
 
@@ -335,6 +346,203 @@ func TestImportNewApp(t *testing.T) {
 			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "no project found with name "+enterProjectName)
 		})
 
+		t.Run("prompts to select a project when running in a terminal", func(t *testing.T) {
+			var createdInGroupID string
+			stitchClient := u.MockStitchClient{
+				ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+					return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+				},
+				ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+					return nil
+				},
+				CreateEmptyAppFn: func(groupID, appName, locationName, deploymentModelName string) (*models.App, error) {
+					createdInGroupID = groupID
+					return &models.App{GroupID: groupID, Name: appName, ClientAppID: appName + "-abcdef"}, nil
+				},
+				FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+					return []*models.App{}, nil
+				},
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
+				},
+			}
+			atlasClient := u.MockMDBClient{
+				GroupsFn: func() ([]mdbcloud.Group, error) {
+					return []mdbcloud.Group{{ID: "59dbcb07127ab4131c54e810", Name: "My-Group"}, {ID: "59dbcb07127ab4131c54e811", Name: "Other-Group"}}, nil
+				},
+			}
+
+			importCommand, mockUI := setup()
+			mockUI.InputReader = strings.NewReader("y\nMy-Test-app\nUS-VA\nGLOBAL\n")
+			importCommand.stitchClient = &stitchClient
+			importCommand.atlasClient = &atlasClient
+
+			var selectOptions []selector.Option
+			importCommand.selectOption = func(prompt string, options []selector.Option) (selector.Option, error) {
+				selectOptions = options
+				return options[1], nil
+			}
+
+			exitCode := importCommand.Run([]string{"--path=../testdata/new_app"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, selectOptions, gc.ShouldResemble, []selector.Option{
+				{Label: "My-Group - 59dbcb07127ab4131c54e810", Value: "59dbcb07127ab4131c54e810"},
+				{Label: "Other-Group - 59dbcb07127ab4131c54e811", Value: "59dbcb07127ab4131c54e811"},
+			})
+			u.So(t, createdInGroupID, gc.ShouldEqual, "59dbcb07127ab4131c54e811")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "Available Projects")
+		})
+
+		t.Run("lists available projects grouped by organization", func(t *testing.T) {
+			var createdInGroupID string
+			stitchClient := u.MockStitchClient{
+				ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+					return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+				},
+				ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+					return nil
+				},
+				CreateEmptyAppFn: func(groupID, appName, locationName, deploymentModelName string) (*models.App, error) {
+					createdInGroupID = groupID
+					return &models.App{GroupID: groupID, Name: appName, ClientAppID: appName + "-abcdef"}, nil
+				},
+				FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+					return []*models.App{}, nil
+				},
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
+				},
+			}
+			atlasClient := u.MockMDBClient{
+				GroupsFn: func() ([]mdbcloud.Group, error) {
+					return []mdbcloud.Group{
+						{ID: "59dbcb07127ab4131c54e810", Name: "Web", OrgID: "org-2"},
+						{ID: "59dbcb07127ab4131c54e811", Name: "Api", OrgID: "org-1"},
+						{ID: "59dbcb07127ab4131c54e812", Name: "Site", OrgID: "org-2"},
+					}, nil
+				},
+				OrgsFn: func() ([]mdbcloud.Org, error) {
+					return []mdbcloud.Org{{ID: "org-1", Name: "Engineering"}, {ID: "org-2", Name: "Marketing"}}, nil
+				},
+			}
+
+			for _, tc := range []struct {
+				description     string
+				args            []string
+				expectedList    string
+				expectedGroupID string
+			}{
+				{
+					description:     "with no organization filter",
+					expectedList:    "Available Projects:\nEngineering (org-1):\n  Api - 59dbcb07127ab4131c54e811\nMarketing (org-2):\n  Web - 59dbcb07127ab4131c54e810\n  Site - 59dbcb07127ab4131c54e812\n",
+					expectedGroupID: "59dbcb07127ab4131c54e811",
+				},
+				{
+					description:     "filtered by organization name",
+					args:            []string{"--org=Marketing"},
+					expectedList:    "Available Projects:\nMarketing (org-2):\n  Web - 59dbcb07127ab4131c54e810\n  Site - 59dbcb07127ab4131c54e812\n",
+					expectedGroupID: "59dbcb07127ab4131c54e810",
+				},
+				{
+					description:     "filtered by organization ID",
+					args:            []string{"--org=org-1"},
+					expectedList:    "Available Projects:\nEngineering (org-1):\n  Api - 59dbcb07127ab4131c54e811\n",
+					expectedGroupID: "59dbcb07127ab4131c54e811",
+				},
+			} {
+				t.Run(tc.description, func(t *testing.T) {
+					createdInGroupID = ""
+					importCommand, mockUI := setup()
+					importCommand.stitchClient = &stitchClient
+					importCommand.atlasClient = &atlasClient
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/new_app", "--app-name=My-Test-app", "--yes"}, tc.args...))
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, tc.expectedList)
+					u.So(t, createdInGroupID, gc.ShouldEqual, tc.expectedGroupID)
+				})
+			}
+
+			t.Run("failing when no projects are in the organization", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				importCommand.stitchClient = &stitchClient
+				importCommand.atlasClient = &atlasClient
+
+				exitCode := importCommand.Run([]string{"--path=../testdata/new_app", "--app-name=My-Test-app", "--yes", "--org=Sales"})
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `no available Projects in Organization "Sales"`)
+			})
+		})
+
+		t.Run("remembers answers to prompts for the app directory", func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "stitch-import-defaults")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			appConfig, err := ioutil.ReadFile("../testdata/new_app/stitch.json")
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), appConfig, 0600), gc.ShouldBeNil)
+
+			previous := &models.ProjectConfig{Defaults: models.PromptDefaults{
+				ProjectID:       "59dbcb07127ab4131c54e811",
+				Location:        "IE",
+				DeploymentModel: "LOCAL",
+			}}
+			u.So(t, previous.Save(dir), gc.ShouldBeNil)
+
+			var createdGroupID, createdLocation, createdDeploymentModel string
+			stitchClient := u.MockStitchClient{
+				ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+					return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+				},
+				ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+					return nil
+				},
+				CreateEmptyAppFn: func(groupID, appName, locationName, deploymentModelName string) (*models.App, error) {
+					createdGroupID, createdLocation, createdDeploymentModel = groupID, locationName, deploymentModelName
+					return &models.App{GroupID: groupID, Name: appName, ClientAppID: appName + "-abcdef"}, nil
+				},
+				FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+					return []*models.App{}, nil
+				},
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
+				},
+			}
+			atlasClient := u.MockMDBClient{
+				GroupsFn: func() ([]mdbcloud.Group, error) {
+					return []mdbcloud.Group{{ID: "59dbcb07127ab4131c54e810", Name: "My-Group"}, {ID: "59dbcb07127ab4131c54e811", Name: "Other-Group"}}, nil
+				},
+			}
+
+			importCommand, mockUI := setup()
+
+			importCommand.stitchClient = &stitchClient
+			importCommand.atlasClient = &atlasClient
+
+			var saved *models.ProjectConfig
+			importCommand.writeProjectConfig = func(dest string, config *models.ProjectConfig) error {
+				u.So(t, dest, gc.ShouldEqual, dir)
+				saved = config
+				return nil
+			}
+
+			// accept the default for every prompt
+			exitCode := importCommand.Run([]string{"--path=" + dir, "--app-name=My-Test-app", "--yes"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Atlas Project Name or ID [Other-Group]: Other-Group")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Location [IE]: IE")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Deployment Model [LOCAL]: LOCAL")
+
+			u.So(t, createdGroupID, gc.ShouldEqual, "59dbcb07127ab4131c54e811")
+			u.So(t, createdLocation, gc.ShouldEqual, "IE")
+			u.So(t, createdDeploymentModel, gc.ShouldEqual, "LOCAL")
+			u.So(t, saved.Defaults, gc.ShouldResemble, previous.Defaults)
+		})
+
 		//include multi-region
 		t.Run("supports creating app with non-default location and deployment model", func(t *testing.T) {
 			stitchClient := u.MockStitchClient{
@@ -566,6 +774,12 @@ func TestImportCommand(t *testing.T) {
 				ExpectedExitCode: 1,
 				ExpectedError:    "directory does not exist",
 			},
+			{
+				Description:      "it fails in strict mode if the app config contains unrecognized fields",
+				Args:             append([]string{"--path=../testdata/app_with_unknown_fields", "--strict"}, validArgs...),
+				ExpectedExitCode: 1,
+				ExpectedError:    "functions/greet/config.json: .run_as_sytem is not a recognized field",
+			},
 			{
 				Description:      "it succeeds if given a valid flagAppPath",
 				Args:             append([]string{"--path=../testdata/full_app"}, validArgs...),
@@ -842,72 +1056,2241 @@ func TestImportCommand(t *testing.T) {
 			})
 		}
 
-		t.Run("syncing data after a successful import", func(t *testing.T) {
-			t.Run("on success", func(t *testing.T) {
-				type testCase struct {
-					Description       string
-					Args              []string
-					WorkingDirectory  string
-					ExpectedDirectory string
+		t.Run("importing an app exported with --redact", func(t *testing.T) {
+			appDir, err := ioutil.TempDir("", "stitch-import-redacted")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(appDir)
+
+			providerDir := filepath.Join(appDir, "auth_providers")
+			u.So(t, os.MkdirAll(providerDir, 0755), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(
+				filepath.Join(providerDir, "oauth2-google.json"),
+				[]byte(`{"name": "oauth2-google", "type": "oauth2-google", "config": {"clientId": "my-client", "clientSecret": "shh"}}`),
+				0644,
+			), gc.ShouldBeNil)
+
+			redact := func(t *testing.T) {
+				_, err := utils.RedactAppDir(appDir)
+				u.So(t, err, gc.ShouldBeNil)
+			}
+
+			const secretKey = "STITCH_REDACTED_AUTH_PROVIDERS_OAUTH2_GOOGLE_CONFIG_CLIENTSECRET"
+
+			importedSecret := func(t *testing.T, appData []byte) string {
+				var app struct {
+					AuthProviders []struct {
+						Config map[string]string `json:"config"`
+					} `json:"auth_providers"`
 				}
+				u.So(t, json.Unmarshal(appData, &app), gc.ShouldBeNil)
+				u.So(t, app.AuthProviders, gc.ShouldHaveLength, 1)
+				return app.AuthProviders[0].Config["clientSecret"]
+			}
 
-				for _, tc := range []testCase{
-					{
-						Description:       "it writes data to the provided directory",
-						Args:              append([]string{"--path=../testdata/simple_app"}, validArgs...),
-						WorkingDirectory:  "",
-						ExpectedDirectory: abs("../testdata/simple_app"),
-					},
-					{
-						Description:       "it writes data to the working directory when using app config file data",
-						Args:              []string{},
-						WorkingDirectory:  "../testdata/simple_app_with_instance_data",
-						ExpectedDirectory: abs("../testdata/simple_app_with_instance_data"),
-					},
-				} {
-					t.Run(tc.Description, func(t *testing.T) {
-						importCommand, mockUI := setup()
-						mockUI.InputReader = strings.NewReader("y\n")
-						importCommand.workingDirectory = tc.WorkingDirectory
-						mockStitchClient := &u.MockStitchClient{
-							ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
-								return "", u.NewResponseBody(strings.NewReader("export response")), nil
-							},
-							ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
-								return nil
-							},
-							DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
-								return []string{"sample-diff-contents"}, nil
-							},
-							FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
-								return &models.App{
-									GroupID: "group-id",
-									ID:      "app-id",
-								}, nil
-							},
-						}
-						importCommand.stitchClient = mockStitchClient
+			t.Run("it fails listing the fields without a value", func(t *testing.T) {
+				redact(t)
 
-						destinationDirectory := ""
-						writeContent := ""
+				importCommand, mockUI := setup()
+				exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "1 redacted field(s) have no value")
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, ".config.clientSecret in auth_providers/oauth2-google.json ("+secretKey+")")
+			})
 
-						importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
-							b, err := ioutil.ReadAll(zipData)
-							if err != nil {
-								return err
-							}
-							destinationDirectory = dest
-							writeContent = string(b)
-							return nil
-						}
+			t.Run("it resolves fields from the secrets file", func(t *testing.T) {
+				redact(t)
 
-						exitCode := importCommand.Run(tc.Args)
-						u.So(t, exitCode, gc.ShouldEqual, 0)
-						u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
-						u.So(t, abs(destinationDirectory), gc.ShouldEqual, tc.ExpectedDirectory)
-						u.So(t, writeContent, gc.ShouldEqual, "export response")
-					})
+				secretsFile := filepath.Join(appDir, ".env.redacted")
+				u.So(t, ioutil.WriteFile(secretsFile, []byte(secretKey+"=from-file\n"), 0600), gc.ShouldBeNil)
+				defer os.Remove(secretsFile)
+
+				var appData []byte
+				importCommand, mockUI := setup()
+				importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+					appData = data
+					return nil
+				}
+
+				exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes", "--secrets-file=" + secretsFile}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, importedSecret(t, appData), gc.ShouldEqual, "from-file")
+				u.So(t, utils.IsRedacted(appDir), gc.ShouldBeFalse)
+			})
+
+			t.Run("it prefers the environment and prompts for the rest", func(t *testing.T) {
+				redact(t)
+
+				var appData []byte
+				importCommand, mockUI := setup()
+				importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+					appData = data
+					return nil
+				}
+				mockUI.InputReader = strings.NewReader("from-prompt\ny\n")
+
+				exitCode := importCommand.Run(append([]string{"--path=" + appDir}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, importedSecret(t, appData), gc.ShouldEqual, "from-prompt")
+
+				redact(t)
+				u.So(t, os.Setenv(secretKey, "from-env"), gc.ShouldBeNil)
+				defer os.Unsetenv(secretKey)
+
+				importCommand, _ = setup()
+				importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+					appData = data
+					return nil
+				}
+
+				exitCode = importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, importedSecret(t, appData), gc.ShouldEqual, "from-env")
+			})
+		})
+
+		t.Run("it builds TypeScript function sources with --build-functions", func(t *testing.T) {
+			appDir, err := ioutil.TempDir("", "stitch-import-build-functions")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(appDir)
+
+			functionDir := filepath.Join(appDir, "functions", "sum")
+			u.So(t, os.MkdirAll(functionDir, 0755), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.ProjectConfigFileName), []byte("functions:\n  build: \"sed 's/: number//g'\"\n"), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "config.json"), []byte(`{"name": "sum"}`), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "source.ts"), []byte("exports = (a: number, b: number) => a + b;\n"), 0644), gc.ShouldBeNil)
+
+			t.Run("it fails to load the app without it", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--build-functions")
+			})
+
+			t.Run("it imports the built source with the command from the project config", func(t *testing.T) {
+				var appData []byte
+				importCommand, mockUI := setup()
+				importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+					appData = data
+					return nil
+				}
+
+				exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes", "--build-functions"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Built functions/sum")
+
+				var app struct {
+					Functions []struct {
+						Source string `json:"source"`
+					} `json:"functions"`
+				}
+				u.So(t, json.Unmarshal(appData, &app), gc.ShouldBeNil)
+				u.So(t, app.Functions, gc.ShouldHaveLength, 1)
+				u.So(t, app.Functions[0].Source, gc.ShouldEqual, "exports = (a, b) => a + b;\n")
+
+				_, err := os.Stat(filepath.Join(functionDir, "source.js"))
+				u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+			})
+		})
+
+		t.Run("it injects shared modules into the functions requiring them", func(t *testing.T) {
+			appDir, err := ioutil.TempDir("", "stitch-import-function-lib")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(appDir)
+
+			functionDir := filepath.Join(appDir, "functions", "greet")
+			libDir := filepath.Join(appDir, "functions", utils.FunctionLibDir)
+			u.So(t, os.MkdirAll(functionDir, 0755), gc.ShouldBeNil)
+			u.So(t, os.MkdirAll(libDir, 0755), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(libDir, "strings.js"), []byte("module.exports.upper = (s) => s.toUpperCase();\n"), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "config.json"), []byte(`{"name": "greet"}`), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "source.js"), []byte("const strings = require('_lib/strings');\nexports = (name) => strings.upper(name);\n"), 0644), gc.ShouldBeNil)
+
+			var appData []byte
+			importCommand, mockUI := setup()
+			importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+				appData = data
+				return nil
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Injected strings into functions/greet")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Kept the local source of functions/greet, which its deployed source is built from")
+
+			var app struct {
+				Functions []struct {
+					Source string `json:"source"`
+				} `json:"functions"`
+			}
+			u.So(t, json.Unmarshal(appData, &app), gc.ShouldBeNil)
+			u.So(t, app.Functions, gc.ShouldHaveLength, 1)
+			u.So(t, app.Functions[0].Source, gc.ShouldContainSubstring, "module.exports.upper = (s) => s.toUpperCase();")
+			u.So(t, app.Functions[0].Source, gc.ShouldContainSubstring, `const strings = __stitchLib("strings");`)
+
+			u.So(t, importCommand.report.SharedCode, gc.ShouldHaveLength, 1)
+			u.So(t, importCommand.report.SharedCode[0].Dir, gc.ShouldEqual, "functions/greet")
+
+			manifest, err := ioutil.ReadFile(filepath.Join(appDir, utils.FunctionLibManifestFileName))
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, string(manifest), gc.ShouldContainSubstring, `"name": "strings"`)
+		})
+
+		t.Run("it only imports the entities of the owner with --owner", func(t *testing.T) {
+			appDir, err := ioutil.TempDir("", "stitch-import-owner")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(appDir)
+
+			for path, data := range map[string]string{
+				models.AppConfigFileName:       `{"name": "my-app"}`,
+				"functions/charge/config.json": `{"name": "charge", "private": true, "x-owner": "payments"}`,
+				"functions/charge/source.js":   `exports = () => 2;`,
+				"functions/greet/config.json":  `{"name": "greet", "x-owner": "web"}`,
+				"functions/greet/source.js":    `exports = () => "hello";`,
+			} {
+				u.So(t, os.MkdirAll(filepath.Dir(filepath.Join(appDir, path)), 0755), gc.ShouldBeNil)
+				u.So(t, ioutil.WriteFile(filepath.Join(appDir, path), []byte(data), 0644), gc.ShouldBeNil)
+			}
+
+			deployed := newAppZip(t, map[string]string{
+				"stitch.json":                  `{"app_id": "my-app-abcdef", "name": "my-app"}`,
+				"functions/charge/config.json": `{"name": "charge", "private": false, "x-owner": "payments"}`,
+				"functions/charge/source.js":   `exports = () => 1;`,
+				"functions/greet/config.json":  `{"name": "greet", "x-owner": "web"}`,
+				"functions/greet/source.js":    `exports = () => "hi";`,
+				"values/fee.json":              `{"name": "fee", "value": 1, "x-owner": "payments"}`,
+				"values/siteName.json":         `{"name": "siteName", "value": "Shop"}`,
+			})
+
+			var appData []byte
+			var importStrategy string
+			importCommand, mockUI := setup()
+			mockStitchClient := importCommand.stitchClient.(*u.MockStitchClient)
+			mockStitchClient.ExportFn = func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+				return "my-app.zip", u.NewResponseBody(bytes.NewReader(deployed)), nil
+			}
+			mockStitchClient.ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+				appData, importStrategy = data, strategy
+				return nil
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes", "--owner=payments"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Importing the entities of payments")
+			u.So(t, importStrategy, gc.ShouldEqual, importStrategyReplace)
+
+			var app struct {
+				Functions []struct {
+					Config map[string]interface{} `json:"config"`
+					Source string                 `json:"source"`
+				} `json:"functions"`
+				Values []map[string]interface{} `json:"values"`
+			}
+			u.So(t, json.Unmarshal(appData, &app), gc.ShouldBeNil)
+			u.So(t, app.Functions, gc.ShouldHaveLength, 2)
+			u.So(t, app.Functions[0].Config["name"], gc.ShouldEqual, "charge")
+			u.So(t, app.Functions[0].Source, gc.ShouldEqual, "exports = () => 2;")
+			u.So(t, app.Functions[1].Config["name"], gc.ShouldEqual, "greet")
+			u.So(t, app.Functions[1].Source, gc.ShouldEqual, `exports = () => "hi";`)
+			var valueNames []interface{}
+			for _, value := range app.Values {
+				valueNames = append(valueNames, value["name"])
+			}
+			u.So(t, valueNames, gc.ShouldContain, "siteName")
+			u.So(t, valueNames, gc.ShouldNotContain, "fee")
+
+			// only the entities of the owner are synced to the local directory
+			_, err = os.Stat(filepath.Join(appDir, "functions", "greet"))
+			u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+			_, err = os.Stat(filepath.Join(appDir, "functions", "charge", "config.json"))
+			u.So(t, err, gc.ShouldBeNil)
+
+			t.Run("it fails for an app that does not exist yet", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				importCommand.stitchClient.(*u.MockStitchClient).FetchAppByClientAppIDFn = func(clientAppID string) (*models.App, error) {
+					return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
 				}
+
+				exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes", "--owner=payments"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--owner only imports into an existing app")
+			})
+		})
+
+		t.Run("it only imports the entities with the tag and their dependencies with --tag", func(t *testing.T) {
+			var appData []byte
+			var importStrategy string
+			importCommand, mockUI := setup()
+			mockStitchClient := importCommand.stitchClient.(*u.MockStitchClient)
+			mockStitchClient.ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+				appData, importStrategy = data, strategy
+				return nil
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=../testdata/app_with_tags", "--yes", "--tag=web"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Importing 3 entities tagged web or depended on by them")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "The local directory was not synced")
+			u.So(t, importStrategy, gc.ShouldEqual, importStrategyMerge)
+			u.So(t, mockStitchClient.ExportFnCalls, gc.ShouldBeEmpty)
+
+			var app struct {
+				Functions []struct {
+					Config map[string]interface{} `json:"config"`
+				} `json:"functions"`
+				Services []struct {
+					Config map[string]interface{}   `json:"config"`
+					Rules  []map[string]interface{} `json:"rules"`
+				} `json:"services"`
+				Triggers []interface{} `json:"triggers"`
+				Values   []interface{} `json:"values"`
+			}
+			u.So(t, json.Unmarshal(appData, &app), gc.ShouldBeNil)
+			u.So(t, app.Functions, gc.ShouldHaveLength, 1)
+			u.So(t, app.Functions[0].Config["name"], gc.ShouldEqual, "greet")
+			u.So(t, app.Services, gc.ShouldHaveLength, 1)
+			u.So(t, app.Services[0].Config["name"], gc.ShouldEqual, "mongodb")
+			u.So(t, app.Services[0].Rules, gc.ShouldHaveLength, 1)
+			u.So(t, app.Triggers, gc.ShouldBeEmpty)
+			u.So(t, app.Values, gc.ShouldBeEmpty)
+
+			t.Run("it fails for a tag that no entity has", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/app_with_tags", "--yes", "--tag=search"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "no functions, triggers or services are tagged search")
+			})
+
+			t.Run("it fails when combined with the replace strategy", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/app_with_tags", "--yes", "--tag=web", "--strategy=replace"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--tag cannot be combined with --owner or --strategy=replace")
+			})
+		})
+
+		t.Run("it imports new entities referred to by others first with --ordered", func(t *testing.T) {
+			deployed := newAppZip(t, map[string]string{
+				"stitch.json":                  `{"app_id": "my-app-abcdef", "name": "tagged-app"}`,
+				"services/mongodb/config.json": `{"name": "mongodb", "type": "mongodb-atlas", "config": {"clusterName": "Cluster0"}}`,
+			})
+
+			type importCall struct {
+				strategy string
+				app      map[string]interface{}
+			}
+			var calls []importCall
+			importCommand, mockUI := setup()
+			mockStitchClient := importCommand.stitchClient.(*u.MockStitchClient)
+			mockStitchClient.ExportFn = func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+				return "my-app.zip", u.NewResponseBody(bytes.NewReader(deployed)), nil
+			}
+			mockStitchClient.ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+				var app map[string]interface{}
+				u.So(t, json.Unmarshal(data, &app), gc.ShouldBeNil)
+				calls = append(calls, importCall{strategy, app})
+				return nil
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=../testdata/app_with_tags", "--yes", "--ordered"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, calls, gc.ShouldHaveLength, 2)
+
+			// the new service is created before its incoming webhook, and the new rule along with the deployed
+			// service holding it
+			u.So(t, calls[0].strategy, gc.ShouldEqual, importStrategyMerge)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Importing functions/authenticate, functions/chargeCard, functions/formatAmount, functions/greet, functions/invoice, services/mongodb/rules/shop.payments, services/stripe, values/currency, values/siteName ahead of the entities referring to them (stage 1 of 2)...")
+			u.So(t, calls[0].app["triggers"], gc.ShouldBeEmpty)
+			u.So(t, calls[0].app["auth_providers"], gc.ShouldBeEmpty)
+			services := calls[0].app["services"].([]interface{})
+			u.So(t, services, gc.ShouldHaveLength, 2)
+			for _, service := range services {
+				u.So(t, service.(map[string]interface{})["incoming_webhooks"], gc.ShouldBeEmpty)
+			}
+
+			u.So(t, calls[1].app["triggers"], gc.ShouldHaveLength, 1)
+			u.So(t, calls[1].app["services"], gc.ShouldHaveLength, 2)
+		})
+
+		t.Run("it resolves secret references in the secrets file", func(t *testing.T) {
+			appDir, err := ioutil.TempDir("", "stitch-import-secret-refs")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(appDir)
+
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(
+				filepath.Join(appDir, "secrets.json"),
+				[]byte(`{"services": {"svc": {"auth_token": "env:STITCH_IMPORT_TEST_TOKEN"}}}`),
+				0644,
+			), gc.ShouldBeNil)
+
+			var appData []byte
+			importCommand, mockUI := setup()
+			importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+				appData = data
+				return nil
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the environment variable STITCH_IMPORT_TEST_TOKEN is not set")
+
+			u.So(t, os.Setenv("STITCH_IMPORT_TEST_TOKEN", "shh"), gc.ShouldBeNil)
+			defer os.Unsetenv("STITCH_IMPORT_TEST_TOKEN")
+
+			importCommand, mockUI = setup()
+			importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+				appData = data
+				return nil
+			}
+
+			exitCode = importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, string(appData), gc.ShouldContainSubstring, `"auth_token":"shh"`)
+		})
+
+		t.Run("it resolves secret references in values and keeps them in the synced directory", func(t *testing.T) {
+			appDir, err := ioutil.TempDir("", "stitch-import-value-refs")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(appDir)
+
+			valuesDir := filepath.Join(appDir, "values")
+			u.So(t, os.MkdirAll(valuesDir, 0755), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+			for name, contents := range map[string]string{
+				"apiKey.json":    `{"name": "apiKey", "value": "env:STITCH_IMPORT_TEST_API_KEY"}`,
+				"payments.json":  `{"name": "payments", "value": {"account": "acct-1", "token": "env:STITCH_IMPORT_TEST_API_KEY"}}`,
+				"fromStore.json": `{"name": "fromStore", "value": "env:STITCH_IMPORT_TEST_SECRET_NAME", "from_secret": true}`,
+			} {
+				u.So(t, ioutil.WriteFile(filepath.Join(valuesDir, name), []byte(contents), 0644), gc.ShouldBeNil)
+			}
+
+			u.So(t, os.Setenv("STITCH_IMPORT_TEST_API_KEY", "shh"), gc.ShouldBeNil)
+			defer os.Unsetenv("STITCH_IMPORT_TEST_API_KEY")
+
+			var appData []byte
+			importCommand, mockUI := setup()
+			importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, data []byte, strategy string) error {
+				appData = data
+				return nil
+			}
+			importCommand.stitchClient.(*u.MockStitchClient).FetchSecretsFn = func(groupID, appID string) ([]models.Secret, error) {
+				return []models.Secret{{Name: "env:STITCH_IMPORT_TEST_SECRET_NAME"}}, nil
+			}
+			// the sync writes the values as deployed, with their secrets
+			importCommand.writeToDirectory = func(dest string, r io.Reader, overwrite bool) error {
+				return ioutil.WriteFile(filepath.Join(dest, "values", "apiKey.json"), []byte(`{"name": "apiKey", "value": "shh"}`), 0644)
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			var app map[string]interface{}
+			u.So(t, json.Unmarshal(appData, &app), gc.ShouldBeNil)
+			deployed := localValues(app)
+			u.So(t, deployed["apiKey"], gc.ShouldEqual, "shh")
+			u.So(t, deployed["payments"], gc.ShouldResemble, map[string]interface{}{"account": "acct-1", "token": "shh"})
+			u.So(t, string(appData), gc.ShouldContainSubstring, `"value":"env:STITCH_IMPORT_TEST_SECRET_NAME"`)
+
+			synced, err := ioutil.ReadFile(filepath.Join(valuesDir, "apiKey.json"))
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, string(synced), gc.ShouldContainSubstring, `"env:STITCH_IMPORT_TEST_API_KEY"`)
+		})
+
+		t.Run("it warns when rule roles are deployed in a different order than declared", func(t *testing.T) {
+			appDir, err := ioutil.TempDir("", "stitch-import-order")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(appDir)
+
+			writeRule := func(roles string) error {
+				return ioutil.WriteFile(
+					filepath.Join(appDir, "services", "mongodb-atlas", "rules", "db.coll.json"),
+					[]byte(`{"name": "db.coll", "roles": `+roles+`}`),
+					0644,
+				)
+			}
+
+			u.So(t, os.MkdirAll(filepath.Join(appDir, "services", "mongodb-atlas", "rules"), 0755), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(filepath.Join(appDir, "services", "mongodb-atlas", "config.json"), []byte(`{"name": "mongodb-atlas"}`), 0644), gc.ShouldBeNil)
+			u.So(t, writeRule(`[{"name": "owner"}, {"name": "admin"}]`), gc.ShouldBeNil)
+
+			importCommand, mockUI := setup()
+			importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+				return writeRule(`[{"name": "admin"}, {"name": "owner"}]`)
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring,
+				"services/mongodb-atlas/rules/db.coll: .roles was deployed in a different order than declared")
+		})
+
+		t.Run("it fails for an invalid upload rate limit", func(t *testing.T) {
+			importCommand, mockUI := setup()
+
+			exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--include-hosting", "--upload-rate-limit=fast"}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `--upload-rate-limit error: invalid rate "fast"`)
+		})
+
+		t.Run("it saves a manifest of the deployed assets", func(t *testing.T) {
+			manifestDir, err := ioutil.TempDir("", "stitch-import-manifest")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(manifestDir)
+
+			importCommand, mockUI := setup()
+			mockUI.InputReader = strings.NewReader("y\n")
+			importCommand.stitchClient = &u.MockStitchClient{
+				ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+					return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+				},
+				ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+					return nil
+				},
+				DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+					return []string{"sample-diff-contents"}, nil
+				},
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return &models.App{GroupID: "group-id", ID: "app-id"}, nil
+				},
+			}
+
+			manifestPath := filepath.Join(manifestDir, "manifest.json")
+			exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--save-manifest=" + manifestPath}, validArgs...))
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+
+			expected, err := importCommand.stitchClient.ListAssetsForAppID("group-id", "app-id")
+			u.So(t, err, gc.ShouldBeNil)
+
+			manifest, err := hosting.ManifestFileToAssetMetadata(manifestPath)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, manifest, gc.ShouldResemble, expected)
+		})
+
+		t.Run("with an encrypted archive", func(t *testing.T) {
+			archiveDir, err := ioutil.TempDir("", "stitch-import-archive")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(archiveDir)
+
+			archivePath := filepath.Join(archiveDir, "my_app.zip.age")
+			u.So(t, ioutil.WriteFile(archivePath, []byte("age-encryption.org/v1\nencrypted"), 0600), gc.ShouldBeNil)
+
+			var zipData bytes.Buffer
+			zipWriter := zip.NewWriter(&zipData)
+			stitchJSON, err := ioutil.ReadFile("../testdata/simple_app/stitch.json")
+			u.So(t, err, gc.ShouldBeNil)
+			f, err := zipWriter.Create(models.AppConfigFileName)
+			u.So(t, err, gc.ShouldBeNil)
+			_, err = f.Write(stitchJSON)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, zipWriter.Close(), gc.ShouldBeNil)
+
+			t.Run("it decrypts and imports the archive", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+
+				var identity, decrypted string
+				importCommand.decryptArchive = func(identityPath, src string) ([]byte, error) {
+					identity, decrypted = identityPath, src
+					return zipData.Bytes(), nil
+				}
+
+				var importedData []byte
+				importCommand.stitchClient = &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						importedData = appData
+						return nil
+					},
+					DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+						return []string{"sample-diff-contents"}, nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+					},
+				}
+
+				var writeToDirectoryCallCount int
+				importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+					writeToDirectoryCallCount++
+					return nil
+				}
+
+				exitCode := importCommand.Run(append([]string{"--path=" + archivePath, "--decrypt-identity=/keys/identity.txt"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, identity, gc.ShouldEqual, "/keys/identity.txt")
+				u.So(t, decrypted, gc.ShouldEqual, archivePath)
+				u.So(t, writeToDirectoryCallCount, gc.ShouldEqual, 0)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, archivePath+" was not synced with")
+
+				var app map[string]interface{}
+				u.So(t, json.Unmarshal(importedData, &app), gc.ShouldBeNil)
+				u.So(t, app["name"], gc.ShouldEqual, "simple-app")
+			})
+
+			t.Run("it does not write the App ID of a new app to the archive", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\nnew-app\nUS-VA\nGLOBAL\n")
+				importCommand.decryptArchive = func(identityPath, src string) ([]byte, error) {
+					return zipData.Bytes(), nil
+				}
+				importCommand.stitchClient = &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						return nil
+					},
+					CreateEmptyAppFn: func(groupID, appName, locationName, deploymentModelName string) (*models.App, error) {
+						return &models.App{Name: appName, ClientAppID: appName + "-abcdef"}, nil
+					},
+					FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+						return []*models.App{}, nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
+					},
+				}
+
+				var writeAppConfigCallCount, writeProjectConfigCallCount int
+				importCommand.writeAppConfigToFile = func(dest string, app models.AppInstanceData) error {
+					writeAppConfigCallCount++
+					return nil
+				}
+				importCommand.writeProjectConfig = func(dest string, config *models.ProjectConfig) error {
+					writeProjectConfigCallCount++
+					return nil
+				}
+
+				exitCode := importCommand.Run([]string{"--project-id=59dbcb07127ab4131c54e810", "--path=" + archivePath, "--decrypt-identity=/keys/identity.txt"})
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, writeAppConfigCallCount, gc.ShouldEqual, 0)
+				u.So(t, writeProjectConfigCallCount, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "The App ID of the new app was not saved to "+archivePath)
+			})
+
+			t.Run("it requires an identity", func(t *testing.T) {
+				importCommand, mockUI := setup()
+
+				exitCode := importCommand.Run(append([]string{"--path=" + archivePath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "an identity (--decrypt-identity=[string]) must be supplied")
+			})
+
+			t.Run("it rejects a file that is not an encrypted archive", func(t *testing.T) {
+				importCommand, mockUI := setup()
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app/stitch.json"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "is neither a directory nor an encrypted app archive")
+			})
+		})
+
+		t.Run("with a hosting config file", func(t *testing.T) {
+			configPath := filepath.Join("../testdata/full_app", utils.HostingConfig)
+			u.So(t, ioutil.WriteFile(configPath, []byte(`{"enabled": true, "rewrites": [{"from": "/*", "to": "/index.html"}]}`), 0644), gc.ShouldBeNil)
+			defer os.Remove(configPath)
+
+			localConfig := &hosting.Config{
+				Enabled:  true,
+				Rewrites: []hosting.Rewrite{{From: "/*", To: "/index.html"}},
+			}
+
+			for _, tc := range []struct {
+				Description    string
+				Args           []string
+				RemoteConfig   *hosting.Config
+				ExpectedDiff   []string
+				ExpectedUpdate *hosting.Config
+			}{
+				{
+					Description:  "it diffs and imports the config, keeping deployed settings it leaves unset",
+					RemoteConfig: &hosting.Config{Enabled: true, CustomDomain: "www.example.com"},
+					ExpectedDiff: []string{"Hosting Config:", `+ rewrites: [{"from":"/*","to":"/index.html"}]`},
+					ExpectedUpdate: &hosting.Config{
+						Enabled:      true,
+						CustomDomain: "www.example.com",
+						Rewrites:     []hosting.Rewrite{{From: "/*", To: "/index.html"}},
+					},
+				},
+				{
+					Description:    "it diffs and imports the config, removing deployed settings it leaves unset when replacing",
+					Args:           []string{"--strategy=replace"},
+					RemoteConfig:   &hosting.Config{Enabled: true, CustomDomain: "www.example.com"},
+					ExpectedDiff:   []string{"Hosting Config:", `- custom_domain: "www.example.com"`, `+ rewrites: [{"from":"/*","to":"/index.html"}]`},
+					ExpectedUpdate: localConfig,
+				},
+				{
+					Description:  "it does not update the config when it matches the deployed one",
+					RemoteConfig: localConfig,
+				},
+			} {
+				t.Run(tc.Description, func(t *testing.T) {
+					importCommand, mockUI := setup()
+					mockUI.InputReader = strings.NewReader("y\n")
+
+					var updated *hosting.Config
+					importCommand.stitchClient = &u.MockStitchClient{
+						ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+							return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+						},
+						ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+							return nil
+						},
+						DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+							return []string{"sample-diff-contents"}, nil
+						},
+						UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+							return nil
+						},
+						FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+							return &models.App{GroupID: "group-id", ID: "app-id"}, nil
+						},
+						FetchHostingConfigFn: func(groupID, appID string) (*hosting.Config, error) {
+							return tc.RemoteConfig, nil
+						},
+						UpdateHostingConfigFn: func(groupID, appID string, config *hosting.Config) error {
+							updated = config
+							return nil
+						},
+					}
+
+					args := append([]string{"--path=../testdata/full_app", "--include-hosting", "--config-path=../testdata/configs/tmp/stitch.json"}, validArgs...)
+					args = append(args, tc.Args...)
+					exitCode := importCommand.Run(args)
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+
+					os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+					for _, line := range tc.ExpectedDiff {
+						u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, line)
+					}
+					if tc.ExpectedDiff == nil {
+						u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "Hosting Config:")
+					}
+					u.So(t, updated, gc.ShouldResemble, tc.ExpectedUpdate)
+				})
+			}
+		})
+
+		t.Run("it lists every hosting asset over the size limit before uploading any", func(t *testing.T) {
+			importCommand, mockUI := setup()
+			importCommand.stitchClient = &u.MockStitchClient{
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return &models.App{GroupID: "group-id", ID: "app-id"}, nil
+				},
+				FetchLimitsFn: func() (*models.Limits, error) {
+					return &models.Limits{MaxAssetSizeBytes: 20}, nil
+				},
+				UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+					return errors.New("should not be uploaded")
+				},
+				ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+					return errors.New("should not be imported")
+				},
+			}
+
+			exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--include-hosting", "--config-path=../testdata/configs/tmp/stitch.json"}, validArgs...))
+			os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the hosting asset /asset_file0.json is 29 B, more than the limit of 20 B")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the hosting asset /ships/nostromo.json is 71 B, more than the limit of 20 B")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldNotContainSubstring, "/asset_file1.html")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--include-hosting error: the app exceeds 2 limit(s) enforced by the server")
+		})
+
+		t.Run("with hosting operations failing", func(t *testing.T) {
+			retryDir, err := ioutil.TempDir("", "stitch-import-retry")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(retryDir)
+
+			for _, tc := range []struct {
+				Description    string
+				Args           []string
+				ExpectedSynced bool
+			}{
+				{
+					Description: "it stops after the hosting assets and writes the failures to the retry file",
+				},
+				{
+					Description:    "it finishes the import with --keep-going and writes the failures to the retry file",
+					Args:           []string{"--keep-going"},
+					ExpectedSynced: true,
+				},
+			} {
+				t.Run(tc.Description, func(t *testing.T) {
+					importCommand, mockUI := setup()
+					retryPath := filepath.Join(retryDir, "retry.json")
+
+					var synced bool
+					importCommand.stitchClient = &u.MockStitchClient{
+						ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+							synced = true
+							return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+						},
+						ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+							return nil
+						},
+						UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+							if path == "/ships/nostromo.json" {
+								return errors.New("oh noes")
+							}
+							return nil
+						},
+						FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+							return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+						},
+					}
+
+					args := append([]string{"--path=../testdata/full_app", "--include-hosting", "--yes", "--retry-file=" + retryPath, "--config-path=../testdata/configs/tmp/stitch.json"}, validArgs...)
+					exitCode := importCommand.Run(append(args, tc.Args...))
+					os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "uploading '/ships/nostromo.json' failed => oh noes")
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `1 hosting operation(s) failed; run "hosting retry --from `+retryPath+`" to retry them`)
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Failed hosting operations:\nOPERATION  PATH                  REASON\nupload     /ships/nostromo.json  oh noes\n")
+					u.So(t, synced, gc.ShouldEqual, tc.ExpectedSynced)
+
+					retryList, err := hosting.RetryFileToRetryList(retryPath)
+					u.So(t, err, gc.ShouldBeNil)
+					u.So(t, retryList, gc.ShouldResemble, &hosting.RetryList{
+						GroupID:     "group-id",
+						AppID:       "app-id",
+						ClientAppID: "my-app-abcdef",
+						Failures:    []hosting.FailedOperation{{Operation: hosting.OperationUpload, FilePath: "/ships/nostromo.json", Reason: "oh noes"}},
+					})
+				})
+			}
+		})
+
+		t.Run("syncing data after a successful import", func(t *testing.T) {
+			t.Run("on success", func(t *testing.T) {
+				type testCase struct {
+					Description       string
+					Args              []string
+					WorkingDirectory  string
+					ExpectedDirectory string
+				}
+
+				for _, tc := range []testCase{
+					{
+						Description:       "it writes data to the provided directory",
+						Args:              append([]string{"--path=../testdata/simple_app"}, validArgs...),
+						WorkingDirectory:  "",
+						ExpectedDirectory: abs("../testdata/simple_app"),
+					},
+					{
+						Description:       "it writes data to the working directory when using app config file data",
+						Args:              []string{},
+						WorkingDirectory:  "../testdata/simple_app_with_instance_data",
+						ExpectedDirectory: abs("../testdata/simple_app_with_instance_data"),
+					},
+				} {
+					t.Run(tc.Description, func(t *testing.T) {
+						importCommand, mockUI := setup()
+						mockUI.InputReader = strings.NewReader("y\n")
+						importCommand.workingDirectory = tc.WorkingDirectory
+						mockStitchClient := &u.MockStitchClient{
+							ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+								return "", u.NewResponseBody(strings.NewReader("export response")), nil
+							},
+							ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+								return nil
+							},
+							DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+								return []string{"sample-diff-contents"}, nil
+							},
+							FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+								return &models.App{
+									GroupID: "group-id",
+									ID:      "app-id",
+								}, nil
+							},
+						}
+						importCommand.stitchClient = mockStitchClient
+
+						destinationDirectory := ""
+						writeContent := ""
+
+						importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+							b, err := ioutil.ReadAll(zipData)
+							if err != nil {
+								return err
+							}
+							destinationDirectory = dest
+							writeContent = string(b)
+							return nil
+						}
+
+						exitCode := importCommand.Run(tc.Args)
+						u.So(t, exitCode, gc.ShouldEqual, 0)
+						u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+						u.So(t, abs(destinationDirectory), gc.ShouldEqual, tc.ExpectedDirectory)
+						u.So(t, writeContent, gc.ShouldEqual, "export response")
+					})
+				}
+			})
+		})
+
+		t.Run("writing a report file", func(t *testing.T) {
+			newReportClient := func() *u.MockStitchClient {
+				return &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(strings.NewReader("export response")), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						return nil
+					},
+					DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+						return []string{"sample-diff-contents"}, nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{
+							GroupID: "group-id",
+							ID:      "app-id",
+						}, nil
+					},
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						return &models.Deployment{ID: "deployment-id", Status: "successful"}, nil
+					},
+				}
+			}
+
+			readReport := func(t *testing.T, path string) map[string]interface{} {
+				data, err := ioutil.ReadFile(path)
+				u.So(t, err, gc.ShouldBeNil)
+
+				var report map[string]interface{}
+				u.So(t, json.Unmarshal(data, &report), gc.ShouldBeNil)
+				return report
+			}
+
+			t.Run("it records the diff and deployment of a successful import", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-import-report")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				reportPath := filepath.Join(dir, "report.json")
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newReportClient()
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--report-file=" + reportPath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+
+				report := readReport(t, reportPath)
+				u.So(t, report["success"], gc.ShouldEqual, true)
+				u.So(t, report["client_app_id"], gc.ShouldEqual, "my-app-abcdef")
+				u.So(t, report["group_id"], gc.ShouldEqual, "group-id")
+				u.So(t, report["app_id"], gc.ShouldEqual, "app-id")
+				u.So(t, report["strategy"], gc.ShouldEqual, importStrategyMerge)
+				u.So(t, report["diff"], gc.ShouldResemble, []interface{}{"sample-diff-contents"})
+				u.So(t, report["deployment_id"], gc.ShouldEqual, "deployment-id")
+				u.So(t, report["hosting"], gc.ShouldBeNil)
+
+				durations := report["durations_seconds"].(map[string]interface{})
+				for _, phase := range []string{"diff", "import", "total"} {
+					_, ok := durations[phase]
+					u.So(t, ok, gc.ShouldBeTrue)
+				}
+			})
+
+			t.Run("it records the error of an unsuccessful import", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-import-report")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				reportPath := filepath.Join(dir, "report.json")
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				stitchClient := newReportClient()
+				stitchClient.ImportFn = func(groupID, appID string, appData []byte, strategy string) error {
+					return errors.New("oopsies")
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--report-file=" + reportPath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+
+				report := readReport(t, reportPath)
+				u.So(t, report["success"], gc.ShouldEqual, false)
+				u.So(t, report["error"], gc.ShouldEqual, "failed to import app: oopsies")
+				u.So(t, report["deployment_id"], gc.ShouldBeNil)
+			})
+
+			t.Run("it shows the progress of an import deployed in the background", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-import-report")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				reportPath := filepath.Join(dir, "report.json")
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				stitchClient := newReportClient()
+				stitchClient.ImportWithProgressFn = func(groupID, appID string, appData []byte, strategy string, timeout time.Duration, progress func(*models.Deployment)) error {
+					progress(&models.Deployment{ID: "background-id", Status: models.DeploymentStatusPending})
+					progress(&models.Deployment{ID: "background-id", Status: models.DeploymentStatusSuccessful})
+					return nil
+				}
+				stitchClient.FetchLatestDeploymentFn = func(groupID, appID string) (*models.Deployment, error) {
+					return nil, errors.New("should not be fetched")
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--report-file=" + reportPath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Deployment background-id is pending...\nDeployment background-id is successful...\nDone.")
+
+				report := readReport(t, reportPath)
+				u.So(t, report["deployment_id"], gc.ShouldEqual, "background-id")
+				u.So(t, report["warnings"], gc.ShouldBeEmpty)
+			})
+
+			t.Run("it appends the import and its deployment to the audit log", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-import-audit")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				auditPath := filepath.Join(dir, "audit.jsonl")
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newReportClient()
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--audit-log=" + auditPath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+
+				data, err := ioutil.ReadFile(auditPath)
+				u.So(t, err, gc.ShouldBeNil)
+
+				var record logging.AuditRecord
+				u.So(t, json.Unmarshal(data, &record), gc.ShouldBeNil)
+				u.So(t, record.Command, gc.ShouldEqual, "import")
+				u.So(t, record.ClientAppID, gc.ShouldEqual, "my-app-abcdef")
+				u.So(t, record.Result, gc.ShouldEqual, logging.AuditResultSuccess)
+				u.So(t, record.DeploymentID, gc.ShouldEqual, "deployment-id")
+			})
+
+			t.Run("it does not audit an import that is declined or has nothing to change", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-import-audit")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				auditPath := filepath.Join(dir, "audit.jsonl")
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("n\n")
+				importCommand.stitchClient = newReportClient()
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--audit-log=" + auditPath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+
+				importCommand, _ = setup()
+				stitchClient := newReportClient()
+				stitchClient.DiffFn = func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+					return []string{}, nil
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode = importCommand.Run(append([]string{"--path=../testdata/simple_app", "--audit-log=" + auditPath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+
+				_, err = os.Stat(auditPath)
+				u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+			})
+
+			t.Run("it fails before changing anything with read-only project access", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				stitchClient := newReportClient()
+				stitchClient.FetchUserProfileFn = func() (*models.UserProfile, error) {
+					var profile models.UserProfile
+					err := json.Unmarshal([]byte(`{"roles": [{"role_name": "GROUP_READ_ONLY", "group_id": "group-id"}]}`), &profile)
+					return &profile, err
+				}
+				stitchClient.CreateValueFn = func(groupID, appID string, value models.Value) (*models.Value, error) {
+					return nil, errors.New("should not be locked")
+				}
+				stitchClient.ImportFn = func(groupID, appID string, appData []byte, strategy string) error {
+					return errors.New("should not be imported")
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "your API key has read-only project access (GROUP_READ_ONLY) to project group-id")
+			})
+
+			t.Run("it fails before changing anything when the app exceeds a limit", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				stitchClient := newReportClient()
+				stitchClient.FetchLimitsFn = func() (*models.Limits, error) {
+					return &models.Limits{MaxRequestSizeBytes: 16}, nil
+				}
+				stitchClient.ImportFn = func(groupID, appID string, appData []byte, strategy string) error {
+					return errors.New("should not be imported")
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "more than the limit of 16 B on the size of an import")
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the app exceeds 1 limit(s) enforced by the server")
+			})
+
+			t.Run("it fails before changing anything when a value does not match its declared type", func(t *testing.T) {
+				projectConfig := &models.ProjectConfig{ValueTypes: map[string]string{"b": "object"}}
+				u.So(t, projectConfig.Save("../testdata/full_app"), gc.ShouldBeNil)
+				defer os.Remove(filepath.Join("../testdata/full_app", models.ProjectConfigFileName))
+
+				importCommand, mockUI := setup()
+				stitchClient := newReportClient()
+				stitchClient.ImportFn = func(groupID, appID string, appData []byte, strategy string) error {
+					return errors.New("should not be imported")
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "local app: value 'b' is string, but is declared as object")
+			})
+
+			t.Run("checking that the secrets the app refers to exist", func(t *testing.T) {
+				newSecretsClient := func(created map[string]string) *u.MockStitchClient {
+					stitchClient := newReportClient()
+					stitchClient.FetchAppByClientAppIDFn = func(clientAppID string) (*models.App, error) {
+						return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+					}
+					stitchClient.FetchSecretsFn = func(groupID, appID string) ([]models.Secret, error) {
+						return []models.Secret{{ID: "secret-id", Name: "signing-key"}}, nil
+					}
+					stitchClient.CreateSecretFn = func(groupID, appID, name, value string) error {
+						created[name] = value
+						return nil
+					}
+					return stitchClient
+				}
+
+				t.Run("it fails listing the missing secrets with --yes", func(t *testing.T) {
+					created := map[string]string{}
+					importCommand, mockUI := setup()
+					stitchClient := newSecretsClient(created)
+					stitchClient.ImportFn = func(groupID, appID string, appData []byte, strategy string) error {
+						return errors.New("should not be imported")
+					}
+					importCommand.stitchClient = stitchClient
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app_with_secrets", "--yes"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the app refers to 2 secret(s) that do not exist in 'my-app-abcdef': api-key, email-claim")
+					u.So(t, created, gc.ShouldBeEmpty)
+				})
+
+				t.Run("it does not count the secrets given by the app's secrets.json as missing", func(t *testing.T) {
+					appDir, err := ioutil.TempDir("", "stitch-import-bundled-secrets")
+					u.So(t, err, gc.ShouldBeNil)
+					defer os.RemoveAll(appDir)
+
+					for path, data := range map[string]string{
+						models.AppConfigFileName:                        `{"name": "my-app"}`,
+						filepath.Join("services", "svc", "config.json"): `{"name": "svc", "type": "twilio", "config": {"sid": "sid"}, "secret_config": {"auth_token": "svc-token"}}`,
+						filepath.Join("values", "apiKey.json"):          `{"name": "apiKey", "value": "api-key", "from_secret": true}`,
+						filepath.Join("values", "claim.json"):           `{"name": "claim", "value": "%%secrets.email-claim"}`,
+						"secrets.json":                                  `{"services": {"svc": {"auth_token": "shh"}}, "values": {"api-key": "key"}}`,
+					} {
+						u.So(t, os.MkdirAll(filepath.Dir(filepath.Join(appDir, path)), 0755), gc.ShouldBeNil)
+						u.So(t, ioutil.WriteFile(filepath.Join(appDir, path), []byte(data), 0644), gc.ShouldBeNil)
+					}
+
+					created := map[string]string{}
+					importCommand, mockUI := setup()
+					stitchClient := newSecretsClient(created)
+					stitchClient.ImportFn = func(groupID, appID string, appData []byte, strategy string) error {
+						return errors.New("should not be imported")
+					}
+					importCommand.stitchClient = stitchClient
+
+					exitCode := importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the app refers to 1 secret(s) that do not exist in 'my-app-abcdef': email-claim")
+
+					u.So(t, os.Remove(filepath.Join(appDir, "values", "claim.json")), gc.ShouldBeNil)
+					importCommand, mockUI = setup()
+					importCommand.stitchClient = newSecretsClient(created)
+
+					exitCode = importCommand.Run(append([]string{"--path=" + appDir, "--yes"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldNotContainSubstring, "secret(s) that do not exist")
+					u.So(t, created, gc.ShouldBeEmpty)
+				})
+
+				t.Run("it prompts for the missing secrets and creates them", func(t *testing.T) {
+					created := map[string]string{}
+					importCommand, mockUI := setup()
+					mockUI.InputReader = strings.NewReader("y\ny\nshh\nclaim\n")
+					importCommand.stitchClient = newSecretsClient(created)
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app_with_secrets"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, created, gc.ShouldResemble, map[string]string{"api-key": "shh", "email-claim": "claim"})
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Created secret 'api-key'")
+				})
+
+				t.Run("it creates the missing secrets given by --secrets-file and prompts for the rest", func(t *testing.T) {
+					secretsFile, err := ioutil.TempFile("", "secrets")
+					u.So(t, err, gc.ShouldBeNil)
+					defer os.Remove(secretsFile.Name())
+
+					os.Setenv("STITCH_TEST_API_KEY", "from-env")
+					defer os.Unsetenv("STITCH_TEST_API_KEY")
+
+					_, err = secretsFile.WriteString("api-key=env:STITCH_TEST_API_KEY\n")
+					u.So(t, err, gc.ShouldBeNil)
+					u.So(t, secretsFile.Close(), gc.ShouldBeNil)
+
+					created := map[string]string{}
+					importCommand, mockUI := setup()
+					mockUI.InputReader = strings.NewReader("y\ny\nclaim\n")
+					importCommand.stitchClient = newSecretsClient(created)
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app_with_secrets", "--secrets-file=" + secretsFile.Name()}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, created, gc.ShouldResemble, map[string]string{"api-key": "from-env", "email-claim": "claim"})
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Created secret 'api-key' from --secrets-file")
+				})
+
+				t.Run("it creates the missing secrets given by --secrets-file without prompting with --yes", func(t *testing.T) {
+					secretsFile, err := ioutil.TempFile("", "secrets")
+					u.So(t, err, gc.ShouldBeNil)
+					defer os.Remove(secretsFile.Name())
+
+					_, err = secretsFile.WriteString("api-key=shh\nemail-claim=claim\n")
+					u.So(t, err, gc.ShouldBeNil)
+					u.So(t, secretsFile.Close(), gc.ShouldBeNil)
+
+					created := map[string]string{}
+					importCommand, _ := setup()
+					importCommand.stitchClient = newSecretsClient(created)
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app_with_secrets", "--secrets-file=" + secretsFile.Name(), "--yes"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, created, gc.ShouldResemble, map[string]string{"api-key": "shh", "email-claim": "claim"})
+				})
+
+				t.Run("it fails when the missing secrets are not to be created", func(t *testing.T) {
+					created := map[string]string{}
+					importCommand, mockUI := setup()
+					mockUI.InputReader = strings.NewReader("y\nn\n")
+					importCommand.stitchClient = newSecretsClient(created)
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app_with_secrets"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, created, gc.ShouldBeEmpty)
+				})
+			})
+
+			t.Run("suggesting apps for an App ID that is not found", func(t *testing.T) {
+				newTypoClient := func(created *bool) *u.MockStitchClient {
+					var profile models.UserProfile
+					u.So(t, json.Unmarshal([]byte(`{"roles": [{"role_name": "GROUP_OWNER", "group_id": "group-id"}]}`), &profile), gc.ShouldBeNil)
+
+					return &u.MockStitchClient{
+						FetchUserProfileFn: func() (*models.UserProfile, error) {
+							return &profile, nil
+						},
+						FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+							return []*models.App{{GroupID: groupID, ID: "app-id", ClientAppID: "my-app-abcdeg"}}, nil
+						},
+						CreateEmptyAppFn: func(groupID, appName, locationName, deploymentModelName string) (*models.App, error) {
+							*created = true
+							return &models.App{Name: appName, ClientAppID: appName + "-abcdef"}, nil
+						},
+					}
+				}
+
+				t.Run("it fails suggesting close matches rather than creating an app with --yes", func(t *testing.T) {
+					var created bool
+					importCommand, mockUI := setup()
+					importCommand.stitchClient = newTypoClient(&created)
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `Unable to find app with ID: "my-app-abcdef" (did you mean "my-app-abcdeg"?)`)
+					u.So(t, created, gc.ShouldBeFalse)
+				})
+
+				t.Run("it shows the close matches when asking whether to create an app", func(t *testing.T) {
+					var created bool
+					importCommand, mockUI := setup()
+					mockUI.InputReader = strings.NewReader("n\n")
+					importCommand.stitchClient = newTypoClient(&created)
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, `(did you mean "my-app-abcdeg"?): would you like to create a new app?`)
+					u.So(t, created, gc.ShouldBeFalse)
+				})
+			})
+
+			t.Run("it fails without prompting when the app does not exist with --no-create", func(t *testing.T) {
+				var created bool
+				importCommand, mockUI := setup()
+				importCommand.stitchClient = &u.MockStitchClient{
+					CreateEmptyAppFn: func(groupID, appName, locationName, deploymentModelName string) (*models.App, error) {
+						created = true
+						return &models.App{Name: appName, ClientAppID: appName + "-abcdef"}, nil
+					},
+				}
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--no-create"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `Unable to find app with ID: "my-app-abcdef", and --no-create was given`)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "would you like to create a new app?")
+				u.So(t, created, gc.ShouldBeFalse)
+			})
+
+			t.Run("with --hosting-only", func(t *testing.T) {
+				t.Run("it only imports the hosting assets, leaving the config and the local directory as they are", func(t *testing.T) {
+					importCommand, mockUI := setup()
+
+					var uploaded []string
+					importCommand.stitchClient = &u.MockStitchClient{
+						FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+							return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+						},
+						DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+							return nil, errors.New("should not be diffed")
+						},
+						ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+							return errors.New("should not be imported")
+						},
+						ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+							return "", nil, errors.New("should not be synced")
+						},
+						UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+							uploaded = append(uploaded, path)
+							return nil
+						},
+					}
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--hosting-only", "--yes", "--config-path=../testdata/configs/tmp/stitch.json"}, validArgs...))
+					os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, uploaded, gc.ShouldContain, "/asset_file0.json")
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully imported the hosting assets of 'my-app-abcdef'")
+				})
+
+				t.Run("it shows only the hosting changes before asking to confirm them", func(t *testing.T) {
+					importCommand, mockUI := setup()
+					mockUI.InputReader = strings.NewReader("n\n")
+
+					importCommand.stitchClient = &u.MockStitchClient{
+						FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+							return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+						},
+						DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+							return []string{"config-diff"}, nil
+						},
+						UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+							return errors.New("should not be uploaded")
+						},
+					}
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--hosting-only", "--config-path=../testdata/configs/tmp/stitch.json"}, validArgs...))
+					os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "/asset_file0.json")
+					u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "config-diff")
+				})
+
+				t.Run("it fails without offering to create an app that does not exist", func(t *testing.T) {
+					importCommand, mockUI := setup()
+					importCommand.stitchClient = &u.MockStitchClient{}
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--hosting-only"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `Unable to find app with ID: "my-app-abcdef", and --hosting-only cannot create it`)
+				})
+
+				t.Run("it cannot be combined with --verify", func(t *testing.T) {
+					importCommand, mockUI := setup()
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--hosting-only", "--verify"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--hosting-only cannot be combined with --canary-app-id or --verify")
+				})
+			})
+
+			t.Run("including hosting by default in .stitchrc", func(t *testing.T) {
+				projectConfig := &models.ProjectConfig{Hosting: models.HostingOptions{Include: true}}
+				u.So(t, projectConfig.Save("../testdata/full_app"), gc.ShouldBeNil)
+				defer os.Remove(filepath.Join("../testdata/full_app", models.ProjectConfigFileName))
+
+				newHostingClient := func(uploaded *[]string) *u.MockStitchClient {
+					return &u.MockStitchClient{
+						FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+							return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+						},
+						ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+							return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
+						},
+						ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+							return nil
+						},
+						UploadAssetFn: func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+							*uploaded = append(*uploaded, path)
+							return nil
+						},
+					}
+				}
+
+				t.Run("it imports the hosting assets without --include-hosting", func(t *testing.T) {
+					var uploaded []string
+					importCommand, mockUI := setup()
+					importCommand.stitchClient = newHostingClient(&uploaded)
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--yes", "--config-path=../testdata/configs/tmp/stitch.json"}, validArgs...))
+					os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, uploaded, gc.ShouldNotBeEmpty)
+				})
+
+				t.Run("it makes no hosting changes with --config-only", func(t *testing.T) {
+					var uploaded []string
+					importCommand, mockUI := setup()
+					stitchClient := newHostingClient(&uploaded)
+					stitchClient.ListAssetsForAppIDFn = func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+						return nil, errors.New("should not be listed")
+					}
+					importCommand.stitchClient = stitchClient
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--yes", "--config-only"}, validArgs...))
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+					u.So(t, exitCode, gc.ShouldEqual, 0)
+					u.So(t, uploaded, gc.ShouldBeEmpty)
+				})
+
+				t.Run("it cannot combine --config-only with hosting flags", func(t *testing.T) {
+					importCommand, mockUI := setup()
+
+					exitCode := importCommand.Run(append([]string{"--path=../testdata/full_app", "--config-only", "--reset-cdn-cache"}, validArgs...))
+					u.So(t, exitCode, gc.ShouldEqual, 1)
+					u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--config-only cannot be combined with --include-hosting, --hosting-only, --reset-cdn-cache or --invalidate-path")
+				})
+			})
+
+			t.Run("it shows the app it imports to before asking to confirm the changes", func(t *testing.T) {
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("n\n")
+				importCommand.atlasClient = &u.MockMDBClient{
+					GroupsFn: func() ([]mdbcloud.Group, error) {
+						return []mdbcloud.Group{{ID: "group-id", Name: "Production"}}, nil
+					},
+				}
+				importCommand.stitchClient = &u.MockStitchClient{
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{
+							GroupID:         "group-id",
+							ID:              "app-id",
+							ClientAppID:     clientAppID,
+							Name:            "my-app",
+							Location:        "US-VA",
+							DeploymentModel: "GLOBAL",
+						}, nil
+					},
+					DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+						return []string{"sample-diff-contents"}, nil
+					},
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						return &models.Deployment{ID: "deployment-id", UserID: "user-id", DeployedAt: 1500000000}, nil
+					},
+				}
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+
+				output := mockUI.OutputWriter.String()
+				u.So(t, output, gc.ShouldContainSubstring, "App:              my-app (my-app-abcdef)")
+				u.So(t, output, gc.ShouldContainSubstring, "Project:          Production (group-id)")
+				u.So(t, output, gc.ShouldContainSubstring, "Deployment model: GLOBAL (US-VA)")
+				u.So(t, output, gc.ShouldContainSubstring, "Last deployed:    2017-07-14T02:40:00Z by user-id")
+				u.So(t, strings.Index(output, "sample-diff-contents"), gc.ShouldBeLessThan, strings.Index(output, "Importing to:"))
+			})
+
+			t.Run("it finishes the import listing the paths whose CDN cache could not be invalidated", func(t *testing.T) {
+				defer func(delay time.Duration) { invalidateRetryDelay = delay }(invalidateRetryDelay)
+				invalidateRetryDelay = time.Millisecond
+
+				dir, err := ioutil.TempDir("", "stitch-import-report")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				reportPath := filepath.Join(dir, "report.json")
+
+				importCommand, mockUI := setup()
+				stitchClient := newReportClient()
+				stitchClient.UploadAssetFn = func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
+					return nil
+				}
+				stitchClient.InvalidateCacheFn = func(groupID, appID, path string) error {
+					if path == "/asset_file0.json" {
+						return errors.New("service unavailable")
+					}
+					return nil
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{
+					"--path=../testdata/full_app", "--include-hosting", "--reset-cdn-cache", "--invalidate-path=/asset_*", "--yes", "--report-file=" + reportPath, "--config-path=../testdata/configs/tmp/stitch.json",
+				}, validArgs...))
+				os.Remove(filepath.Join("../testdata/configs/tmp", utils.HostingCacheFileName))
+
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the CDN cache of 1 path(s) may still be stale, as it could not be invalidated: /asset_file0.json")
+
+				hostingReport := readReport(t, reportPath)["hosting"].(map[string]interface{})
+				u.So(t, hostingReport["stale_cache"], gc.ShouldResemble, []interface{}{"/asset_file0.json"})
+				u.So(t, hostingReport["invalidated"], gc.ShouldResemble, []interface{}{"/asset_file1.html"})
+			})
+
+			t.Run("mapping git branches to apps", func(t *testing.T) {
+				projectConfig := &models.ProjectConfig{Branches: []models.BranchApp{
+					{Branch: "main", AppID: "prod-app-abcde"},
+					{Branch: "release/*", AppID: "staging-app-abcde"},
+				}}
+				u.So(t, projectConfig.Save("../testdata/simple_app"), gc.ShouldBeNil)
+				defer os.Remove(filepath.Join("../testdata/simple_app", models.ProjectConfigFileName))
+
+				for _, tc := range []struct {
+					Description     string
+					Args            []string
+					Branch          string
+					BranchErr       error
+					ExpectedAppID   string
+					ExpectedError   string
+					ExpectedWarning string
+				}{
+					{
+						Description:   "it imports to the app mapped to the current branch",
+						Branch:        "release/1.2",
+						ExpectedAppID: "staging-app-abcde",
+					},
+					{
+						Description:   "it imports to the app given by --app-id over the mapped one",
+						Args:          validArgs,
+						Branch:        "release/1.2",
+						ExpectedAppID: "my-app-abcdef",
+					},
+					{
+						Description:     "it warns that it imports to the app in stitch.json when no app is mapped to the current branch",
+						Branch:          "feature/search",
+						ExpectedWarning: "No app is mapped to the git branch 'feature/search' in .stitchrc, so the App ID in stitch.json is used",
+					},
+					{
+						Description:   "it fails when the current branch cannot be determined",
+						BranchErr:     errors.New("HEAD is detached"),
+						ExpectedError: "failed to determine the git branch that .stitchrc maps to an app: HEAD is detached; use --app-id to choose the app to import to",
+					},
+					{
+						Description:   "it imports to the app given by --app-id when the current branch cannot be determined",
+						Args:          validArgs,
+						BranchErr:     errors.New("HEAD is detached"),
+						ExpectedAppID: "my-app-abcdef",
+					},
+				} {
+					t.Run(tc.Description, func(t *testing.T) {
+						importCommand, mockUI := setup()
+						mockUI.InputReader = strings.NewReader("y\n")
+						importCommand.currentBranch = func(dir string) (string, error) {
+							return tc.Branch, tc.BranchErr
+						}
+
+						var fetchedAppID string
+						stitchClient := newReportClient()
+						stitchClient.FetchAppByClientAppIDFn = func(clientAppID string) (*models.App, error) {
+							fetchedAppID = clientAppID
+							return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+						}
+						importCommand.stitchClient = stitchClient
+
+						exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app"}, tc.Args...))
+						if tc.ExpectedError != "" {
+							u.So(t, exitCode, gc.ShouldEqual, 1)
+							u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, tc.ExpectedError)
+							u.So(t, fetchedAppID, gc.ShouldBeEmpty)
+							return
+						}
+
+						u.So(t, exitCode, gc.ShouldEqual, 0)
+						if tc.ExpectedAppID != "" {
+							u.So(t, fetchedAppID, gc.ShouldEqual, tc.ExpectedAppID)
+						}
+						if tc.ExpectedWarning != "" {
+							u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, tc.ExpectedWarning)
+						}
+					})
+				}
+			})
+
+			t.Run("it records a warning if the deployment cannot be fetched", func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "stitch-import-report")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+				reportPath := filepath.Join(dir, "report.json")
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				stitchClient := newReportClient()
+				stitchClient.FetchLatestDeploymentFn = func(groupID, appID string) (*models.Deployment, error) {
+					return nil, errors.New("oopsies")
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--report-file=" + reportPath}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed to fetch latest deployment: oopsies")
+
+				report := readReport(t, reportPath)
+				u.So(t, report["success"], gc.ShouldEqual, true)
+				u.So(t, report["warnings"], gc.ShouldResemble, []interface{}{"failed to fetch latest deployment: oopsies"})
+			})
+		})
+
+		t.Run("verifying the deployment", func(t *testing.T) {
+			originalInterval := deploymentPollInterval
+			deploymentPollInterval = time.Millisecond
+			defer func() { deploymentPollInterval = originalInterval }()
+
+			newVerifyClient := func(deployments ...*models.Deployment) *u.MockStitchClient {
+				var polls int
+				return &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(strings.NewReader("export response")), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						return nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+					},
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						return &models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusPending}, nil
+					},
+					FetchDeploymentFn: func(groupID, appID, deploymentID string) (*models.Deployment, error) {
+						u.So(t, deploymentID, gc.ShouldEqual, "deployment-id")
+						deployment := deployments[polls]
+						if polls < len(deployments)-1 {
+							polls++
+						}
+						return deployment, nil
+					},
+				}
+			}
+
+			setupVerify := func(stitchClient *u.MockStitchClient) (*ImportCommand, *cli.MockUi) {
+				importCommand, mockUI := setup()
+				importCommand.stitchClient = stitchClient
+				importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+					return nil
+				}
+				return importCommand, mockUI
+			}
+
+			t.Run("it waits until the deployment is live in every region", func(t *testing.T) {
+				importCommand, mockUI := setupVerify(newVerifyClient(
+					&models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusPending, Regions: []models.DeploymentRegion{
+						{Region: "US-VA", Status: models.DeploymentStatusSuccessful},
+						{Region: "IE", Status: models.DeploymentStatusPending},
+					}},
+					&models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusSuccessful, Regions: []models.DeploymentRegion{
+						{Region: "US-VA", Status: models.DeploymentStatusSuccessful},
+						{Region: "IE", Status: models.DeploymentStatusSuccessful},
+					}},
+				))
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes", "--verify"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Deployment deployment-id is live in 2 region(s).")
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully imported 'my-app-abcdef'")
+			})
+
+			t.Run("it fails if the deployment fails in a region", func(t *testing.T) {
+				importCommand, mockUI := setupVerify(newVerifyClient(
+					&models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusPending, Regions: []models.DeploymentRegion{
+						{Region: "US-VA", Status: models.DeploymentStatusSuccessful},
+						{Region: "IE", Status: models.DeploymentStatusFailed, StatusErrorMessage: "something went wrong"},
+					}},
+				))
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes", "--verify"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "deployment deployment-id failed in region IE: something went wrong")
+			})
+
+			t.Run("it fails if the deployment does not go live in time", func(t *testing.T) {
+				importCommand, mockUI := setupVerify(newVerifyClient(
+					&models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusPending, Regions: []models.DeploymentRegion{
+						{Region: "US-VA", Status: models.DeploymentStatusSuccessful},
+						{Region: "IE", Status: models.DeploymentStatusPending},
+					}},
+				))
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes", "--verify", "--verify-timeout=10ms"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "deployment deployment-id did not go live in every region within 10ms; still waiting on IE (pending)")
+			})
+		})
+
+		t.Run("running smoke tests", func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				u.So(t, r.Method, gc.ShouldEqual, http.MethodGet)
+				switch r.URL.Path {
+				case "/api/client/v2.0/app/my-app-abcdef/service/http1/incoming_webhook/status":
+					w.Write([]byte(`{"status": "ok", "verbose": "` + r.URL.Query().Get("verbose") + `"}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			dir, err := ioutil.TempDir("", "stitch-import-smoke-test")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			writeSmokeTests := func(t *testing.T, data string) string {
+				path := filepath.Join(dir, "smoke.json")
+				u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+				return path
+			}
+
+			setupSmokeTest := func() (*ImportCommand, *cli.MockUi, *[]string) {
+				var called []string
+				importCommand, mockUI := setup()
+				importCommand.stitchClient = &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(strings.NewReader("export response")), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						return nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+					},
+					ExecuteFunctionFn: func(groupID, appID, name string, args []interface{}) (*models.FunctionExecution, error) {
+						called = append(called, name)
+						if name != "sum" {
+							return nil, errors.New("function not found")
+						}
+						return &models.FunctionExecution{Result: json.RawMessage(fmt.Sprintf("%v", args[0].(float64)+args[1].(float64)))}, nil
+					},
+				}
+				importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+					return nil
+				}
+				return importCommand, mockUI, &called
+			}
+
+			t.Run("it passes when every check passes", func(t *testing.T) {
+				path := writeSmokeTests(t, `{"checks": [
+					{"name": "adds", "function": "sum", "args": [1, 2], "expect": {"result": 3}},
+					{"webhook": "http1/status", "query": {"verbose": "true"}, "expect": {"result": {"status": "ok", "verbose": "true"}}}
+				]}`)
+
+				importCommand, mockUI, called := setupSmokeTest()
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes", "--base-url=" + server.URL, "--smoke-test=" + path}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, *called, gc.ShouldResemble, []string{"sum"})
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "smoke check 'adds' passed")
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "smoke check 'webhook http1/status' passed")
+			})
+
+			t.Run("it fails when any check fails", func(t *testing.T) {
+				path := writeSmokeTests(t, `{"checks": [
+					{"name": "adds", "function": "sum", "args": [1, 2], "expect": {"result": 4}},
+					{"function": "missing", "expect": {}},
+					{"webhook": "http1/status", "expect": {"contains": "ok"}},
+					{"webhook": "http1/gone", "expect": {"status": 200}}
+				]}`)
+
+				importCommand, mockUI, _ := setupSmokeTest()
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes", "--base-url=" + server.URL, "--smoke-test=" + path}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+
+				errOutput := mockUI.ErrorWriter.String()
+				u.So(t, errOutput, gc.ShouldContainSubstring, "smoke check 'adds' failed => expected 4, got 3")
+				u.So(t, errOutput, gc.ShouldContainSubstring, "smoke check 'function missing' failed => function not found")
+				u.So(t, errOutput, gc.ShouldContainSubstring, "smoke check 'webhook http1/gone' failed => expected status 200, got 404 Not Found")
+				u.So(t, errOutput, gc.ShouldContainSubstring, "3 of 4 smoke check(s) failed against 'my-app-abcdef'")
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "smoke check 'webhook http1/status' passed")
+			})
+
+			t.Run("it fails before importing when the checks are invalid", func(t *testing.T) {
+				path := writeSmokeTests(t, `{"checks": [{"function": "sum", "webhook": "http1/status"}]}`)
+
+				importCommand, mockUI, _ := setupSmokeTest()
+				importCommand.stitchClient.(*u.MockStitchClient).ImportFn = func(groupID, appID string, appData []byte, strategy string) error {
+					panic("should not import")
+				}
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes", "--smoke-test=" + path}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "check 1 must name exactly one of a function or a webhook")
+			})
+		})
+
+		t.Run("importing to a canary first", func(t *testing.T) {
+			type importCall struct {
+				appID string
+				what  string
+			}
+
+			setupCanary := func(failCanarySmokeTest bool) (*ImportCommand, *cli.MockUi, *[]importCall) {
+				var calls []importCall
+				importCommand, mockUI := setup()
+				importCommand.stitchClient = &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(strings.NewReader("export response")), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						calls = append(calls, importCall{appID, "import"})
+						return nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{GroupID: "group-id", ID: clientAppID + "-id", ClientAppID: clientAppID}, nil
+					},
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						return &models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusSuccessful}, nil
+					},
+					ExecuteFunctionFn: func(groupID, appID, name string, args []interface{}) (*models.FunctionExecution, error) {
+						calls = append(calls, importCall{appID, "smoke test"})
+						if failCanarySmokeTest && appID == "my-canary-abcdef-id" {
+							return &models.FunctionExecution{Result: json.RawMessage(`"unhealthy"`)}, nil
+						}
+						return &models.FunctionExecution{Result: json.RawMessage(`"healthy"`)}, nil
+					},
+				}
+				importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+					return nil
+				}
+				return importCommand, mockUI, &calls
+			}
+
+			dir, err := ioutil.TempDir("", "stitch-import-canary")
+			u.So(t, err, gc.ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			smokeTestPath := filepath.Join(dir, "smoke.json")
+			u.So(t, ioutil.WriteFile(smokeTestPath, []byte(`{"checks": [{"function": "health", "expect": {"result": "healthy"}}]}`), 0644), gc.ShouldBeNil)
+			reportPath := filepath.Join(dir, "report.json")
+
+			t.Run("it imports the target app once the canary passes its smoke tests", func(t *testing.T) {
+				importCommand, mockUI, calls := setupCanary(false)
+				exitCode := importCommand.Run(append([]string{
+					"--path=../testdata/simple_app", "--yes", "--canary-app-id=my-canary-abcdef", "--smoke-test=" + smokeTestPath, "--report-file=" + reportPath,
+				}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+				u.So(t, *calls, gc.ShouldResemble, []importCall{
+					{"my-canary-abcdef-id", "import"},
+					{"my-canary-abcdef-id", "smoke test"},
+					{"my-app-abcdef-id", "import"},
+					{"my-app-abcdef-id", "smoke test"},
+				})
+
+				data, err := ioutil.ReadFile(reportPath)
+				u.So(t, err, gc.ShouldBeNil)
+
+				var report importReport
+				u.So(t, json.Unmarshal(data, &report), gc.ShouldBeNil)
+				u.So(t, report.Success, gc.ShouldBeTrue)
+				u.So(t, report.Canary, gc.ShouldResemble, &canaryReport{
+					ClientAppID: "my-canary-abcdef",
+					GroupID:     "group-id",
+					AppID:       "my-canary-abcdef-id",
+					Success:     true,
+					SmokeTested: true,
+				})
+			})
+
+			t.Run("it does not import the target app if the canary fails", func(t *testing.T) {
+				importCommand, mockUI, calls := setupCanary(true)
+				exitCode := importCommand.Run(append([]string{
+					"--path=../testdata/simple_app", "--yes", "--canary-app-id=my-canary-abcdef", "--smoke-test=" + smokeTestPath, "--report-file=" + reportPath,
+				}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring,
+					"import to canary 'my-canary-abcdef' failed, so 'my-app-abcdef' was not imported: 1 of 1 smoke check(s) failed against 'my-canary-abcdef'")
+				u.So(t, *calls, gc.ShouldResemble, []importCall{
+					{"my-canary-abcdef-id", "import"},
+					{"my-canary-abcdef-id", "smoke test"},
+				})
+
+				data, err := ioutil.ReadFile(reportPath)
+				u.So(t, err, gc.ShouldBeNil)
+
+				var report importReport
+				u.So(t, json.Unmarshal(data, &report), gc.ShouldBeNil)
+				u.So(t, report.Success, gc.ShouldBeFalse)
+				u.So(t, report.Canary.Success, gc.ShouldBeFalse)
+				u.So(t, report.Canary.Error, gc.ShouldEqual, "1 of 1 smoke check(s) failed against 'my-canary-abcdef'")
+			})
+
+			t.Run("it rejects the target app as its own canary", func(t *testing.T) {
+				importCommand, mockUI, calls := setupCanary(false)
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--yes", "--canary-app-id=my-app-abcdef"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the canary app must be a different app than 'my-app-abcdef'")
+				u.So(t, *calls, gc.ShouldBeEmpty)
+			})
+		})
+
+		t.Run("sending a notification", func(t *testing.T) {
+			type notification struct {
+				Text   string                 `json:"text"`
+				Report map[string]interface{} `json:"report"`
+			}
+
+			newWebhook := func(status int) (*httptest.Server, *[]notification) {
+				var received []notification
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var n notification
+					if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+						panic(err)
+					}
+					received = append(received, n)
+					w.WriteHeader(status)
+				}))
+				return server, &received
+			}
+
+			newNotifyClient := func(importErr error) *u.MockStitchClient {
+				return &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(strings.NewReader("export response")), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						return importErr
+					},
+					DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+						return []string{"sample-diff-contents"}, nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{
+							GroupID: "group-id",
+							ID:      "app-id",
+						}, nil
+					},
+					FetchLatestDeploymentFn: func(groupID, appID string) (*models.Deployment, error) {
+						return &models.Deployment{ID: "deployment-id"}, nil
+					},
+				}
+			}
+
+			t.Run("it posts a templated summary of a successful import", func(t *testing.T) {
+				server, received := newWebhook(http.StatusOK)
+				defer server.Close()
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newNotifyClient(nil)
+
+				exitCode := importCommand.Run(append([]string{
+					"--path=../testdata/simple_app",
+					"--notify-webhook=" + server.URL,
+					"--notify-template={{.ClientAppID}} deployed as {{.DeploymentID}}",
+				}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+
+				u.So(t, *received, gc.ShouldHaveLength, 1)
+				u.So(t, (*received)[0].Text, gc.ShouldEqual, "my-app-abcdef deployed as deployment-id")
+				u.So(t, (*received)[0].Report["success"], gc.ShouldEqual, true)
+				u.So(t, (*received)[0].Report["outcome"], gc.ShouldEqual, importOutcomeImported)
+				u.So(t, (*received)[0].Report["diff"], gc.ShouldResemble, []interface{}{"sample-diff-contents"})
+			})
+
+			t.Run("it posts a summary of a failed import", func(t *testing.T) {
+				server, received := newWebhook(http.StatusOK)
+				defer server.Close()
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newNotifyClient(errors.New("oopsies"))
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--notify-webhook=" + server.URL}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+
+				u.So(t, *received, gc.ShouldHaveLength, 1)
+				u.So(t, (*received)[0].Text, gc.ShouldEqual, "Failed to import my-app-abcdef: failed to import app: oopsies")
+				u.So(t, (*received)[0].Report["success"], gc.ShouldEqual, false)
+			})
+
+			t.Run("it posts that a declined import was cancelled", func(t *testing.T) {
+				server, received := newWebhook(http.StatusOK)
+				defer server.Close()
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("n\n")
+				importCommand.stitchClient = newNotifyClient(nil)
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--notify-webhook=" + server.URL}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+
+				u.So(t, *received, gc.ShouldHaveLength, 1)
+				u.So(t, (*received)[0].Text, gc.ShouldEqual, "Cancelled the import of my-app-abcdef")
+				u.So(t, (*received)[0].Report["outcome"], gc.ShouldEqual, importOutcomeCancelled)
+				u.So(t, (*received)[0].Report["success"], gc.ShouldEqual, false)
+			})
+
+			t.Run("it posts that an import with no changes did nothing", func(t *testing.T) {
+				server, received := newWebhook(http.StatusOK)
+				defer server.Close()
+
+				importCommand, _ := setup()
+				stitchClient := newNotifyClient(nil)
+				stitchClient.DiffFn = func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+					return []string{}, nil
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--notify-webhook=" + server.URL}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, stitchClient.ImportFnCalls, gc.ShouldBeEmpty)
+
+				u.So(t, *received, gc.ShouldHaveLength, 1)
+				u.So(t, (*received)[0].Text, gc.ShouldEqual, "Nothing to import to my-app-abcdef, which is up to date")
+				u.So(t, (*received)[0].Report["outcome"], gc.ShouldEqual, importOutcomeNoChanges)
+				u.So(t, (*received)[0].Report["success"], gc.ShouldEqual, false)
+			})
+
+			t.Run("it uses the webhook configured for the app directory", func(t *testing.T) {
+				server, received := newWebhook(http.StatusOK)
+				defer server.Close()
+
+				dir, err := ioutil.TempDir("", "stitch-import-notify")
+				u.So(t, err, gc.ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				appConfig, err := ioutil.ReadFile("../testdata/simple_app/stitch.json")
+				u.So(t, err, gc.ShouldBeNil)
+				u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), appConfig, 0600), gc.ShouldBeNil)
+
+				projectConfig := &models.ProjectConfig{Notify: models.NotifyConfig{Webhook: server.URL}}
+				u.So(t, projectConfig.Save(dir), gc.ShouldBeNil)
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newNotifyClient(nil)
+
+				exitCode := importCommand.Run(append([]string{"--path=" + dir}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+
+				u.So(t, *received, gc.ShouldHaveLength, 1)
+				u.So(t, (*received)[0].Text, gc.ShouldEqual, "Successfully imported my-app-abcdef")
+			})
+
+			t.Run("it warns without failing if the webhook rejects the notification", func(t *testing.T) {
+				server, _ := newWebhook(http.StatusInternalServerError)
+				defer server.Close()
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newNotifyClient(nil)
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--notify-webhook=" + server.URL}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "failed to send import notification: webhook responded with 500 Internal Server Error")
+			})
+		})
+
+		t.Run("locking the app while importing", func(t *testing.T) {
+			defer func(interval time.Duration) { deployLockPollInterval = interval }(deployLockPollInterval)
+			deployLockPollInterval = time.Millisecond
+
+			heldLock := func(expiresAt time.Time) models.Value {
+				contents, err := json.Marshal(deployLock{Holder: "someone else", AcquiredAt: expiresAt.Add(-deployLockTTL), ExpiresAt: expiresAt})
+				u.So(t, err, gc.ShouldBeNil)
+				return models.Value{ID: "held-lock-id", Name: utils.DeployLockValueName, Value: contents, Private: true}
+			}
+
+			// newLockClient returns a client for an app whose lock is held by held, if given, until it is deleted
+			newLockClient := func(held *models.Value, imported *[]byte, deleted *[]string) *u.MockStitchClient {
+				return &u.MockStitchClient{
+					ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+						return "", u.NewResponseBody(strings.NewReader("export response")), nil
+					},
+					ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+						*imported = appData
+						return nil
+					},
+					DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+						return []string{"sample-diff-contents"}, nil
+					},
+					FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+						return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+					},
+					FetchValuesFn: func(groupID, appID string) ([]models.Value, error) {
+						if held == nil {
+							return nil, nil
+						}
+						return []models.Value{*held}, nil
+					},
+					CreateValueFn: func(groupID, appID string, value models.Value) (*models.Value, error) {
+						if held != nil {
+							return nil, api.ErrValueExists
+						}
+						value.ID = "lock-id"
+						return &value, nil
+					},
+					DeleteValueFn: func(groupID, appID, valueID string) error {
+						*deleted = append(*deleted, valueID)
+						if held != nil && valueID == held.ID {
+							held = nil
+						}
+						return nil
+					},
+				}
+			}
+
+			t.Run("it holds the lock during the import and releases it afterwards", func(t *testing.T) {
+				var imported []byte
+				var deleted []string
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newLockClient(nil, &imported, &deleted)
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--strategy=replace"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, deleted, gc.ShouldResemble, []string{"lock-id"})
+
+				// a replacing import keeps the lock it holds
+				var app map[string]interface{}
+				u.So(t, json.Unmarshal(imported, &app), gc.ShouldBeNil)
+				values := app["values"].([]interface{})
+				u.So(t, values[len(values)-1].(map[string]interface{})["id"], gc.ShouldEqual, "lock-id")
+			})
+
+			t.Run("it fails if another import holds the lock", func(t *testing.T) {
+				var imported []byte
+				var deleted []string
+				held := heldLock(time.Now().Add(time.Hour))
+
+				importCommand, mockUI := setup()
+				importCommand.stitchClient = newLockClient(&held, &imported, &deleted)
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "'my-app-abcdef' is being imported by someone else since")
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "run with --wait to wait until it is done, or with --force-unlock")
+				u.So(t, imported, gc.ShouldBeNil)
+				u.So(t, deleted, gc.ShouldBeEmpty)
+			})
+
+			t.Run("it waits for the lock to be released with --wait", func(t *testing.T) {
+				var imported []byte
+				var deleted []string
+				held := heldLock(time.Now().Add(time.Hour))
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				stitchClient := newLockClient(&held, &imported, &deleted)
+				polls := 0
+				fetchValues := stitchClient.FetchValuesFn
+				stitchClient.FetchValuesFn = func(groupID, appID string) ([]models.Value, error) {
+					if polls++; polls == 3 {
+						stitchClient.DeleteValueFn(groupID, appID, held.ID)
+					}
+					return fetchValues(groupID, appID)
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--wait"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, strings.Count(mockUI.OutputWriter.String(), "Waiting for the import by someone else to finish..."), gc.ShouldEqual, 1)
+				u.So(t, imported, gc.ShouldNotBeNil)
+			})
+
+			t.Run("it gives up waiting for the lock after --wait-timeout", func(t *testing.T) {
+				var imported []byte
+				var deleted []string
+				held := heldLock(time.Now().Add(time.Hour))
+
+				importCommand, mockUI := setup()
+				importCommand.stitchClient = newLockClient(&held, &imported, &deleted)
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--wait", "--wait-timeout=10ms"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 1)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "'my-app-abcdef' is still being imported by someone else after waiting 10ms")
+				u.So(t, imported, gc.ShouldBeNil)
+				u.So(t, deleted, gc.ShouldBeEmpty)
+			})
+
+			t.Run("it leaves the lock out of the diff", func(t *testing.T) {
+				var imported []byte
+				var deleted []string
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				stitchClient := newLockClient(nil, &imported, &deleted)
+				stitchClient.DiffFn = func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+					return []string{"Added value '" + utils.DeployLockValueName + "'", "sample-diff-contents"}, nil
+				}
+				importCommand.stitchClient = stitchClient
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--strategy=replace"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "sample-diff-contents")
+				u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, utils.DeployLockValueName)
+			})
+
+			t.Run("it takes over an expired lock", func(t *testing.T) {
+				var imported []byte
+				var deleted []string
+				held := heldLock(time.Now().Add(-time.Minute))
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newLockClient(&held, &imported, &deleted)
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "Taking over the deploy lock of 'my-app-abcdef' held by someone else")
+				u.So(t, deleted, gc.ShouldResemble, []string{"held-lock-id", "lock-id"})
+			})
+
+			t.Run("it removes the lock of another import with --force-unlock", func(t *testing.T) {
+				var imported []byte
+				var deleted []string
+				held := heldLock(time.Now().Add(time.Hour))
+
+				importCommand, mockUI := setup()
+				mockUI.InputReader = strings.NewReader("y\n")
+				importCommand.stitchClient = newLockClient(&held, &imported, &deleted)
+
+				exitCode := importCommand.Run(append([]string{"--path=../testdata/simple_app", "--force-unlock"}, validArgs...))
+				u.So(t, exitCode, gc.ShouldEqual, 0)
+				u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "Removing the deploy lock of 'my-app-abcdef' held by someone else")
+				u.So(t, deleted, gc.ShouldResemble, []string{"held-lock-id", "lock-id"})
 			})
 		})
 	})
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+)
+
+const defaultVerifyTimeout = 5 * time.Minute
+
+// deploymentPollInterval is how long to wait between checks of a deployment's rollout while verifying it
+var deploymentPollInterval = 2 * time.Second
+
+// verifyDeployment waits until the deployment has gone live in every region the app is served from, failing
+// if its rollout fails anywhere or does not finish within the --verify-timeout
+func (ic *ImportCommand) verifyDeployment(client api.StitchClient, app *models.App, deploymentID string) error {
+	if deploymentID == "" {
+		return fmt.Errorf("failed to verify deployment of '%s': no deployment was found", app.ClientAppID)
+	}
+
+	ic.Log().Info(fmt.Sprintf("Waiting for deployment %s to go live in every region...", deploymentID))
+	deadline := time.Now().Add(ic.flagVerifyTimeout)
+	for {
+		deployment, err := client.FetchDeployment(app.GroupID, app.ID, deploymentID)
+		if err != nil {
+			return fmt.Errorf("failed to verify deployment %s: %s", deploymentID, err)
+		}
+
+		waitingOn, err := pendingRollout(deployment)
+		if err != nil {
+			return err
+		}
+
+		if len(waitingOn) == 0 {
+			ic.Log().Info(fmt.Sprintf("Deployment %s is live in %d region(s).", deploymentID, len(deployment.Regions)))
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf(
+				"deployment %s did not go live in every region within %s; still waiting on %s",
+				deploymentID,
+				ic.flagVerifyTimeout,
+				strings.Join(waitingOn, ", "),
+			)
+		}
+
+		time.Sleep(deploymentPollInterval)
+	}
+}
+
+// pendingRollout returns what the deployment is still waiting on before it is live everywhere: the regions
+// it has not finished rolling out to, or the deployment as a whole if it reports no regions. It fails if the
+// deployment has failed, in any region
+func pendingRollout(deployment *models.Deployment) ([]string, error) {
+	if deployment.Status == models.DeploymentStatusFailed {
+		return nil, fmt.Errorf("deployment %s failed: %s", deployment.ID, deployment.StatusErrorMessage)
+	}
+
+	var pending []string
+	for _, region := range deployment.Regions {
+		switch region.Status {
+		case models.DeploymentStatusSuccessful:
+		case models.DeploymentStatusFailed:
+			return nil, fmt.Errorf("deployment %s failed in region %s: %s", deployment.ID, region.Region, region.StatusErrorMessage)
+		default:
+			pending = append(pending, fmt.Sprintf("%s (%s)", region.Region, region.Status))
+		}
+	}
+
+	if len(pending) == 0 && deployment.Status != models.DeploymentStatusSuccessful {
+		pending = append(pending, fmt.Sprintf("the deployment (%s)", deployment.Status))
+	}
+
+	return pending, nil
+}
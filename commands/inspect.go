@@ -0,0 +1,267 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	inspectFlagPath = "path"
+	inspectFlagTop  = "top"
+
+	defaultInspectTop = 5
+)
+
+// NewInspectCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewInspectCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &InspectCommand{
+			BaseCommand: &BaseCommand{
+				Name: "inspect",
+				UI:   ui,
+			},
+			workingDirectory:  workingDirectory,
+			exportToDirectory: utils.WriteAppToDir,
+		}, nil
+	}
+}
+
+// InspectCommand is used to report the size of an app and what makes it up
+type InspectCommand struct {
+	*BaseCommand
+
+	workingDirectory  string
+	exportToDirectory func(dest string, zipData io.Reader, overwrite bool) error
+
+	flagProjectID string
+	flagAppID     string
+	flagAppPath   string
+	flagTop       int
+}
+
+// Help returns long-form help information for this command
+func (inc *InspectCommand) Help() string {
+	return `Report how many entities of each kind an app has, the size of their configuration, the number and total size of its hosting assets, and its largest entities and assets, e.g. to see what to trim as the app nears a size limit.
+
+The local app is inspected unless --app-id is given. Rules and incoming webhooks are counted towards the size of their service as well as on their own.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --app-id [string]
+	The App ID of a deployed app to inspect instead of the local app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja").
+
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.
+
+  --top [int] (default: ` + fmt.Sprint(defaultInspectTop) + `)
+	How many of the largest entities and hosting assets to list.` +
+		inc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (inc *InspectCommand) Synopsis() string {
+	return `Report the size and composition of an app.`
+}
+
+// Run executes the command
+func (inc *InspectCommand) Run(args []string) int {
+	flags := inc.NewFlagSet()
+
+	flags.StringVar(&inc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&inc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&inc.flagAppPath, inspectFlagPath, "", "")
+	flags.IntVar(&inc.flagTop, inspectFlagTop, defaultInspectTop, "")
+
+	if err := inc.BaseCommand.run(args); err != nil {
+		inc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := inc.inspect(); err != nil {
+		inc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (inc *InspectCommand) inspect() error {
+	if inc.flagTop < 0 {
+		return fmt.Errorf("--%s must not be negative", inspectFlagTop)
+	}
+
+	var name string
+	var composition *utils.AppComposition
+	var assets []hosting.AssetMetadata
+	var err error
+	if inc.flagAppID != "" {
+		name, composition, assets, err = inc.inspectRemote()
+	} else {
+		name, composition, assets, err = inc.inspectLocal()
+	}
+	if err != nil {
+		return err
+	}
+
+	inc.UI.Output(formatAppComposition(name, composition, assets, inc.flagTop))
+	return nil
+}
+
+// inspectLocal inspects the local app, including the hosting assets that would be imported with it
+func (inc *InspectCommand) inspectLocal() (string, *utils.AppComposition, []hosting.AssetMetadata, error) {
+	appPath, err := inc.resolveAppDirectory(inc.flagAppPath, inc.workingDirectory)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	composition, err := utils.InspectApp(appPath)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to inspect %s: %s", appPath, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(appPath, utils.HostingAttributes)); os.IsNotExist(err) {
+		return appPath, composition, nil, nil
+	}
+
+	projectConfig, err := models.LoadProjectConfig(appPath)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+	}
+
+	appInstanceData := models.AppInstanceData{}
+	if err := appInstanceData.UnmarshalFile(appPath); err != nil {
+		return "", nil, nil, err
+	}
+
+	_, assets, cleanup, err := inc.listLocalAssets(appPath, appInstanceData.AppID(), projectConfig.Hosting)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer cleanup()
+
+	return appPath, composition, assets, nil
+}
+
+// inspectRemote inspects the deployed app given by --app-id, by exporting it to a temporary directory
+func (inc *InspectCommand) inspectRemote() (string, *utils.AppComposition, []hosting.AssetMetadata, error) {
+	user, err := inc.User()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if !user.LoggedIn() {
+		return "", nil, nil, u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := inc.StitchClient()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	app, err := fetchApp(stitchClient, inc.flagProjectID, inc.flagAppID)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	_, body, err := stitchClient.Export(app.GroupID, app.ID, false)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to export '%s': %s", app.ClientAppID, err)
+	}
+	defer body.Close()
+
+	dir, err := ioutil.TempDir("", "stitch-inspect")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	appPath := filepath.Join(dir, app.ClientAppID)
+	if err := inc.exportToDirectory(appPath, body, false); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to export '%s': %s", app.ClientAppID, err)
+	}
+
+	composition, err := utils.InspectApp(appPath)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to inspect '%s': %s", app.ClientAppID, err)
+	}
+
+	assets, err := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to list the hosting assets of '%s': %s", app.ClientAppID, err)
+	}
+
+	return fmt.Sprintf("'%s'", app.ClientAppID), composition, assets, nil
+}
+
+// formatAppComposition renders the composition of the named app, along with its hosting assets, listing
+// up to top of its largest entities and assets
+func formatAppComposition(name string, composition *utils.AppComposition, assets []hosting.AssetMetadata, top int) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Composition of %s\n\n", name)
+	fmt.Fprintln(w, "ENTITY\tCOUNT\tSIZE")
+	for _, kind := range composition.Kinds {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", kind.Kind, kind.Count, utils.FormatSize(kind.Size))
+	}
+	fmt.Fprintf(w, "total configuration\t\t%s\n", utils.FormatSize(composition.TotalSize()))
+
+	var assetsSize int64
+	sortedAssets := make([]hosting.AssetMetadata, 0, len(assets))
+	for _, asset := range assets {
+		if strings.HasSuffix(asset.FilePath, "/") {
+			continue
+		}
+		assetsSize += asset.FileSize
+		sortedAssets = append(sortedAssets, asset)
+	}
+	fmt.Fprintf(w, "hosting assets\t%d\t%s\n", len(sortedAssets), utils.FormatSize(assetsSize))
+
+	if top > 0 && len(composition.Entities) > 0 {
+		fmt.Fprintln(w, "\nLARGEST ENTITIES\t\tSIZE")
+		for i, entity := range composition.Entities {
+			if i == top {
+				break
+			}
+			fmt.Fprintf(w, "%s\t\t%s\n", entity.Path, utils.FormatSize(entity.Size))
+		}
+	}
+
+	if top > 0 && len(sortedAssets) > 0 {
+		sort.SliceStable(sortedAssets, func(i, j int) bool {
+			return sortedAssets[i].FileSize > sortedAssets[j].FileSize
+		})
+
+		fmt.Fprintln(w, "\nLARGEST HOSTING ASSETS\t\tSIZE")
+		for i, asset := range sortedAssets {
+			if i == top {
+				break
+			}
+			fmt.Fprintf(w, "%s\t\t%s\n", asset.FilePath, utils.FormatSize(asset.FileSize))
+		}
+	}
+
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
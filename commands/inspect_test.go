@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestInspectCommand(t *testing.T) {
+	setup := func() (*InspectCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewInspectCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		inspectCommand := cmd.(*InspectCommand)
+		inspectCommand.storage = u.NewEmptyStorage()
+		return inspectCommand, mockUI
+	}
+
+	t.Run("it reports the composition of the local app", func(t *testing.T) {
+		inspectCommand, mockUI := setup()
+
+		exitCode := inspectCommand.Run([]string{"--path=../testdata/full_app", "--top=2"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		output := mockUI.OutputWriter.String()
+		u.So(t, output, gc.ShouldContainSubstring, "Composition of ../testdata/full_app")
+		u.So(t, output, gc.ShouldContainSubstring, "functions            2      258 B\n")
+		u.So(t, output, gc.ShouldContainSubstring, "hosting assets       3      116 B\n")
+		u.So(t, output, gc.ShouldContainSubstring, "LARGEST ENTITIES      SIZE\nservices/service_b    757 B\n")
+		u.So(t, output, gc.ShouldContainSubstring, "LARGEST HOSTING ASSETS    SIZE\n/ships/nostromo.json      71 B\n/asset_file0.json         29 B\n")
+	})
+
+	t.Run("it reports the composition of a deployed app", func(t *testing.T) {
+		inspectCommand, mockUI := setup()
+		inspectCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+
+		appZip := newAppZip(t, map[string]string{
+			"stitch.json":               `{"app_id": "my-app-abcde", "name": "my-app"}`,
+			"functions/sum/config.json": `{"name": "sum"}`,
+			"functions/sum/source.js":   `exports = (a, b) => a + b;`,
+			"values/limit.json":         `{"name": "limit", "value": 10}`,
+		})
+		inspectCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+			ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+				return "my-app-abcde.zip", u.NewResponseBody(bytes.NewReader(appZip)), nil
+			},
+			ListAssetsForAppIDFn: func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+				return []hosting.AssetMetadata{
+					{FilePath: "/index.html", FileSize: 2048},
+					{FilePath: "/app.js", FileSize: 1024 * 1024},
+				}, nil
+			},
+		}
+
+		exitCode := inspectCommand.Run([]string{"--app-id=my-app-abcde"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		output := mockUI.OutputWriter.String()
+		u.So(t, output, gc.ShouldContainSubstring, "Composition of 'my-app-abcde'")
+		u.So(t, output, gc.ShouldContainSubstring, "functions            1      48 B\n")
+		u.So(t, output, gc.ShouldContainSubstring, "values               1      41 B\n")
+		u.So(t, output, gc.ShouldContainSubstring, "hosting assets       2      1.0 MiB\n")
+		u.So(t, output, gc.ShouldContainSubstring, "/app.js                   1.0 MiB\n/index.html               2.0 KiB")
+	})
+
+	t.Run("it rejects a negative --top", func(t *testing.T) {
+		inspectCommand, mockUI := setup()
+
+		exitCode := inspectCommand.Run([]string{"--path=../testdata/full_app", "--top=-1"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "--top must not be negative")
+	})
+}
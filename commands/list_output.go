@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	listFlagOutput  = "output"
+	listFlagColumns = "columns"
+	listFlagSort    = "sort"
+)
+
+// The formats list commands can write their results in
+const (
+	listOutputText = "text"
+	listOutputCSV  = "csv"
+)
+
+// listOutput writes the results of a list command in the format chosen by its --output, --columns and
+// --sort flags
+type listOutput struct {
+	format  string
+	columns string
+	sort    string
+
+	// width returns the width text output is truncated to fit, or 0 to leave it as is. It defaults to the
+	// width of the terminal
+	width func() int
+}
+
+// registerFlags adds the --output, --columns and --sort flags to the flags of a list command
+func (lo *listOutput) registerFlags(flags *flag.FlagSet) {
+	flags.StringVar(&lo.format, listFlagOutput, listOutputText, "")
+	flags.StringVar(&lo.columns, listFlagColumns, "", "")
+	flags.StringVar(&lo.sort, listFlagSort, "", "")
+}
+
+// listOutputHelp describes the --output, --columns and --sort flags of a list command whose results have the
+// given columns
+func listOutputHelp(columns []string) string {
+	return `
+  --output [text|csv] (default: text)
+	Write the results as a table, or as CSV with a header row, e.g. for pasting into a spreadsheet. Tables are truncated to fit the terminal.
+
+  --columns [string]
+	A comma-separated list of the columns to write, in order, out of: ` + strings.Join(columns, ", ") + `. Defaults to every column.
+
+  --sort [column[:asc|desc]]
+	Sort the results by a column, e.g. "` + columns[0] + `:desc". Columns of numbers are sorted numerically.`
+}
+
+// write outputs the rows, which hold a value for each of columns, to ui
+func (lo *listOutput) write(ui cli.Ui, columns []string, rows [][]string) error {
+	if lo.format != listOutputText && lo.format != listOutputCSV {
+		return fmt.Errorf("--%s must be one of %s or %s, got %q", listFlagOutput, listOutputText, listOutputCSV, lo.format)
+	}
+
+	selected, err := selectColumns(columns, lo.columns)
+	if err != nil {
+		return err
+	}
+
+	if lo.sort != "" {
+		if err := sortRows(columns, rows, lo.sort); err != nil {
+			return err
+		}
+	}
+
+	headers := make([]string, len(selected))
+	for i, idx := range selected {
+		headers[i] = columns[idx]
+	}
+
+	if lo.format == listOutputText {
+		width := stdoutWidth
+		if lo.width != nil {
+			width = lo.width
+		}
+
+		picked := make([][]string, len(rows))
+		for i, row := range rows {
+			picked[i] = pickColumns(row, selected)
+		}
+
+		ui.Output(renderTable(headers, picked, width()))
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(headers)
+	for _, row := range rows {
+		w.Write(pickColumns(row, selected))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	ui.Output(strings.TrimSuffix(buf.String(), "\n"))
+	return nil
+}
+
+// selectColumns returns the indexes in columns of the comma-separated column names in list, or of every
+// column if list is empty
+func selectColumns(columns []string, list string) ([]int, error) {
+	if list == "" {
+		selected := make([]int, len(columns))
+		for i := range columns {
+			selected[i] = i
+		}
+		return selected, nil
+	}
+
+	var selected []int
+	for _, name := range strings.Split(list, ",") {
+		idx := columnIndex(columns, strings.TrimSpace(name))
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown column %q for --%s, expected one of: %s", strings.TrimSpace(name), listFlagColumns, strings.Join(columns, ", "))
+		}
+		selected = append(selected, idx)
+	}
+
+	return selected, nil
+}
+
+// columnIndex returns the index of the named column in columns, or -1 if there is none
+func columnIndex(columns []string, name string) int {
+	for i, column := range columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// pickColumns returns the values of row at the selected indexes
+func pickColumns(row []string, selected []int) []string {
+	picked := make([]string, len(selected))
+	for i, idx := range selected {
+		picked[i] = row[idx]
+	}
+	return picked
+}
@@ -2,6 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/auth"
@@ -13,6 +17,15 @@ const (
 	flagLoginAPIKeyName        = "api-key"
 	flagLoginPrivateAPIKeyName = "private-api-key"
 	flagLoginUsernameName      = "username"
+	flagLoginMFACodeName       = "mfa-code"
+	flagLoginMFAMethodName     = "mfa-method"
+	flagLoginMFATimeoutName    = "mfa-timeout"
+
+	// defaultMFATimeout is how long to wait for a login to be verified with a security key in the browser
+	defaultMFATimeout = 2 * time.Minute
+
+	// maxMFACodeAttempts is how many times a mistyped MFA code can be entered again
+	maxMFACodeAttempts = 3
 )
 
 // NewLoginCommandFactory returns a new cli.CommandFactory given a cli.Ui
@@ -20,9 +33,11 @@ func NewLoginCommandFactory(ui cli.Ui) cli.CommandFactory {
 	return func() (cli.Command, error) {
 		return &LoginCommand{
 			BaseCommand: &BaseCommand{
-				Name: "login",
-				UI:   ui,
+				Name:             "login",
+				UI:               ui,
+				allowNewIdentity: true,
 			},
+			openBrowser: openInBrowser,
 		}, nil
 	}
 }
@@ -31,11 +46,17 @@ func NewLoginCommandFactory(ui cli.Ui) cli.CommandFactory {
 type LoginCommand struct {
 	*BaseCommand
 
+	// openBrowser opens a URL in the user's browser, for verifying the login with a security key
+	openBrowser func(url string) error
+
 	flagAPIKey        string
 	flagPrivateAPIKey string
 	flagUsername      string
 	flagAuthProvider  string
 	flagPassword      string
+	flagMFACode       string
+	flagMFAMethod     string
+	flagMFATimeout    time.Duration
 }
 
 // Synopsis returns a one-liner description for this command
@@ -47,6 +68,9 @@ func (lc *LoginCommand) Synopsis() string {
 func (lc *LoginCommand) Help() string {
 	return `Authenticate as an administrator.
 
+With --as, the credentials are stored as a separate identity under the given name, which other commands use when
+given the same --as, leaving the default identity logged in.
+
 Programmatic API Key:
   --api-key [string]
 	The Public API key for a MongoDB Cloud account.
@@ -61,6 +85,19 @@ Personal API Key (DEPRECATED):
   --username [string]
 	The username for a MongoDB Cloud account.
 
+Multi-factor authentication:
+  If your account requires logins to be verified with a second factor, you are prompted for a code from your authenticator app,
+  or a browser is opened to verify the login with your security key.
+
+  --` + flagLoginMFAMethodName + ` [totp|webauthn]
+	How to verify the login: with a code from an authenticator app (totp) or a security key (webauthn). Asked for if the account allows both.
+
+  --` + flagLoginMFACodeName + ` [string]
+	The code from your authenticator app, rather than prompting for it.
+
+  --` + flagLoginMFATimeoutName + ` [duration] (default: ` + defaultMFATimeout.String() + `)
+	How long to wait for the login to be verified with a security key.
+
 OPTIONS:` +
 		lc.BaseCommand.Help()
 }
@@ -74,14 +111,17 @@ func (lc *LoginCommand) Run(args []string) int {
 	set.StringVar(&lc.flagAuthProvider, "auth-provider", string(auth.ProviderTypeAPIKey), "")
 	set.StringVar(&lc.flagPassword, "password", "", "")
 	set.StringVar(&lc.flagUsername, flagLoginUsernameName, "", "")
+	set.StringVar(&lc.flagMFACode, flagLoginMFACodeName, "", "")
+	set.StringVar(&lc.flagMFAMethod, flagLoginMFAMethodName, "", "")
+	set.DurationVar(&lc.flagMFATimeout, flagLoginMFATimeoutName, defaultMFATimeout, "")
 
 	if err := lc.BaseCommand.run(args); err != nil {
-		lc.UI.Error(err.Error())
+		lc.Log().Error(err.Error())
 		return 1
 	}
 
 	if err := lc.logIn(); err != nil {
-		lc.UI.Error(err.Error())
+		lc.Log().Error(err.Error())
 		return 1
 	}
 
@@ -118,7 +158,7 @@ func (lc *LoginCommand) validateAuthCredentials() (auth.AuthenticationProvider,
 		provider = auth.NewAPIKeyProvider(apiKey, privateAPIKey)
 	case auth.ProviderTypeUsernamePassword:
 		if lc.flagPassword == "" {
-			password, err := lc.UI.AskSecret("Password:")
+			password, err := lc.AskSecret("Password:", "--password")
 			if err != nil {
 				return nil, err
 			}
@@ -164,12 +204,20 @@ func (lc *LoginCommand) logIn() error {
 		}
 	}
 
-	client, err := lc.Client()
-	if err != nil {
-		return err
+	// the stitch client of a command is authenticated as the current user, so logging in uses its own
+	stitchClient := lc.stitchClient
+	if stitchClient == nil {
+		client, err := lc.Client()
+		if err != nil {
+			return err
+		}
+		stitchClient = api.NewStitchClient(client)
 	}
 
-	authResponse, err := api.NewStitchClient(client).Authenticate(authProvider)
+	authResponse, err := stitchClient.Authenticate(authProvider)
+	if mfaRequired, ok := err.(*api.MFARequiredError); ok {
+		authResponse, err = lc.verifyMFA(stitchClient, mfaRequired)
+	}
 	if err != nil {
 		return err
 	}
@@ -183,14 +231,134 @@ func (lc *LoginCommand) logIn() error {
 		user.PrivateAPIKey = lc.flagPrivateAPIKey
 	}
 
-	user.AccessToken = authResponse.AccessToken
-	user.RefreshToken = authResponse.RefreshToken
+	user.SetAccessToken(authResponse.AccessToken)
+	user.SetRefreshToken(authResponse.RefreshToken)
 
-	if err := lc.storage.WriteUserConfig(user); err != nil {
+	if err := lc.saveUser(user); err != nil {
 		return err
 	}
 
-	lc.UI.Info(fmt.Sprintf("you have successfully logged in as %s", user.PublicAPIKey))
+	if lc.flagAs != "" {
+		lc.Success(fmt.Sprintf("you have successfully logged in as %s, stored as the identity '%s'", user.PublicAPIKey, lc.flagAs))
+		return nil
+	}
+
+	lc.Success(fmt.Sprintf("you have successfully logged in as %s", user.PublicAPIKey))
 
 	return nil
 }
+
+// verifyMFA completes a login that the account requires to be verified with a second factor
+func (lc *LoginCommand) verifyMFA(stitchClient api.StitchClient, mfaRequired *api.MFARequiredError) (*auth.Response, error) {
+	method, err := lc.mfaMethod(mfaRequired)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == api.MFAFactorWebAuthn {
+		return lc.verifyMFAWebAuthn(stitchClient, mfaRequired.Token)
+	}
+	return lc.verifyMFACode(stitchClient, mfaRequired.Token)
+}
+
+// mfaMethod returns the factor to verify the login with: the one given by --mfa-method, or a code if --mfa-code
+// is given, or else the only one the account allows or the one the user chooses
+func (lc *LoginCommand) mfaMethod(mfaRequired *api.MFARequiredError) (string, error) {
+	method := lc.flagMFAMethod
+	if method == "" && lc.flagMFACode != "" {
+		method = api.MFAFactorTOTP
+	}
+
+	if method == "" {
+		if mfaRequired.Supports(api.MFAFactorTOTP) && mfaRequired.Supports(api.MFAFactorWebAuthn) {
+			answer, err := lc.Ask(
+				fmt.Sprintf("Verify the login with a code from your authenticator app (%s) or your security key (%s)", api.MFAFactorTOTP, api.MFAFactorWebAuthn),
+				api.MFAFactorTOTP,
+				"--"+flagLoginMFAMethodName,
+			)
+			if err != nil {
+				return "", err
+			}
+			method = strings.ToLower(strings.TrimSpace(answer))
+		} else if len(mfaRequired.Factors) > 0 {
+			method = mfaRequired.Factors[0]
+		}
+	}
+
+	if method != api.MFAFactorTOTP && method != api.MFAFactorWebAuthn {
+		return "", fmt.Errorf("--%s must be one of %s or %s, got %q", flagLoginMFAMethodName, api.MFAFactorTOTP, api.MFAFactorWebAuthn, method)
+	}
+
+	if !mfaRequired.Supports(method) {
+		return "", fmt.Errorf("your account does not allow verifying logins with %s, only with %s", method, strings.Join(mfaRequired.Factors, ", "))
+	}
+
+	return method, nil
+}
+
+// verifyMFACode verifies the login with a code from the user's authenticator app, prompting for it again if it
+// is rejected, unless it was given by --mfa-code
+func (lc *LoginCommand) verifyMFACode(stitchClient api.StitchClient, mfaToken string) (*auth.Response, error) {
+	for attempt := 1; ; attempt++ {
+		code := lc.flagMFACode
+		if code == "" {
+			var err error
+			if code, err = lc.Ask("Code from your authenticator app", "", "--"+flagLoginMFACodeName); err != nil {
+				return nil, err
+			}
+		}
+
+		authResponse, err := stitchClient.VerifyMFACode(mfaToken, strings.TrimSpace(code))
+		if err == nil {
+			return authResponse, nil
+		}
+
+		if lc.flagMFACode != "" || attempt == maxMFACodeAttempts {
+			return nil, err
+		}
+		lc.Log().Warn(fmt.Sprintf("%s, try again", err))
+	}
+}
+
+// verifyMFAWebAuthn has the user verify the login with their security key in their browser, and waits for them
+// to, for up to --mfa-timeout
+func (lc *LoginCommand) verifyMFAWebAuthn(stitchClient api.StitchClient, mfaToken string) (*auth.Response, error) {
+	session, err := stitchClient.StartMFAWebAuthn(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.UI.Output(fmt.Sprintf("Verify the login with your security key at %s", session.URL))
+	if err := lc.openBrowser(session.URL); err != nil {
+		lc.Log().Debug(fmt.Sprintf("Could not open a browser, the URL has to be opened by hand: %s", err))
+	}
+	lc.Log().Info("Waiting for the login to be verified...")
+
+	deadline := time.Now().Add(lc.flagMFATimeout)
+	for {
+		time.Sleep(session.PollInterval)
+
+		authResponse, err := stitchClient.PollMFAWebAuthn(mfaToken)
+		if err != api.ErrMFAPending {
+			return authResponse, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for the login to be verified with a security key", lc.flagMFATimeout)
+		}
+	}
+}
+
+// openInBrowser opens url in the user's default browser
+func openInBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -1,10 +1,13 @@
 package commands
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/10gen/stitch-cli/api"
 	"github.com/10gen/stitch-cli/auth"
 	"github.com/10gen/stitch-cli/user"
 	u "github.com/10gen/stitch-cli/utils/test"
@@ -189,3 +192,153 @@ func TestLoginCommand(t *testing.T) {
 		})
 	})
 }
+
+func TestLoginCommandAs(t *testing.T) {
+	mockUI := cli.NewMockUi()
+	cmd, err := NewLoginCommandFactory(mockUI)()
+	u.So(t, err, gc.ShouldBeNil)
+
+	loginCommand := cmd.(*LoginCommand)
+	loginCommand.client = u.NewMockClient([]*http.Response{
+		{
+			StatusCode: http.StatusOK,
+			Body: u.NewAuthResponseBody(auth.Response{
+				AccessToken:  "bot.access.token",
+				RefreshToken: "bot.refresh.token",
+			}),
+		},
+	})
+	loginCommand.storage = u.NewPopulatedStorage("my-existing-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+	exitCode := loginCommand.Run([]string{"--api-key=bot-public", "--private-api-key=bot-private-key", "--as=deploy-bot"})
+	u.So(t, exitCode, gc.ShouldEqual, 0)
+	u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "you are already logged in")
+	u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "you have successfully logged in as bot-public, stored as the identity 'deploy-bot'")
+
+	identity, err := loginCommand.storage.ReadIdentity("deploy-bot")
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, identity, gc.ShouldResemble, &user.User{
+		PublicAPIKey:  "bot-public",
+		PrivateAPIKey: "bot-private-key",
+		AccessToken:   "bot.access.token",
+		RefreshToken:  "bot.refresh.token",
+	})
+
+	// the default identity stays logged in
+	storedUser, err := loginCommand.storage.ReadUserConfig()
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, storedUser.PublicAPIKey, gc.ShouldEqual, "user.name")
+	u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "my-existing-api-key")
+}
+
+func TestLoginCommandMFA(t *testing.T) {
+	mfaRequired := &api.MFARequiredError{Token: "mfa-token", Factors: []string{api.MFAFactorTOTP, api.MFAFactorWebAuthn}}
+	verified := &auth.Response{AccessToken: "mfa.access.token", RefreshToken: "mfa.refresh.token"}
+
+	setup := func(stitchClient *u.MockStitchClient) (*LoginCommand, *cli.MockUi, *[]string) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewLoginCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		var opened []string
+		loginCommand := cmd.(*LoginCommand)
+		loginCommand.storage = u.NewEmptyStorage()
+		loginCommand.openBrowser = func(url string) error {
+			opened = append(opened, url)
+			return nil
+		}
+		stitchClient.AuthenticateFn = func(authProvider auth.AuthenticationProvider) (*auth.Response, error) {
+			return nil, mfaRequired
+		}
+		loginCommand.stitchClient = stitchClient
+		return loginCommand, mockUI, &opened
+	}
+
+	loginArgs := []string{"--api-key=my-public-key", "--private-api-key=my-private-key"}
+
+	t.Run("it verifies the login with the code given by --mfa-code", func(t *testing.T) {
+		var codes []string
+		loginCommand, _, _ := setup(&u.MockStitchClient{
+			VerifyMFACodeFn: func(mfaToken, code string) (*auth.Response, error) {
+				codes = append(codes, mfaToken+":"+code)
+				return verified, nil
+			},
+		})
+
+		exitCode := loginCommand.Run(append(loginArgs, "--mfa-code=123456"))
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, codes, gc.ShouldResemble, []string{"mfa-token:123456"})
+
+		storedUser, err := loginCommand.storage.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.AccessToken, gc.ShouldEqual, "mfa.access.token")
+		u.So(t, storedUser.RefreshToken, gc.ShouldEqual, "mfa.refresh.token")
+	})
+
+	t.Run("it prompts for the code again if it is rejected", func(t *testing.T) {
+		var codes []string
+		loginCommand, mockUI, _ := setup(&u.MockStitchClient{
+			VerifyMFACodeFn: func(mfaToken, code string) (*auth.Response, error) {
+				codes = append(codes, code)
+				if code != "123456" {
+					return nil, errors.New("401 Unauthorized: failed to verify the MFA code: error: invalid code")
+				}
+				return verified, nil
+			},
+		})
+
+		mockUI.InputReader = strings.NewReader("totp\n111111\n123456\n")
+		exitCode := loginCommand.Run(loginArgs)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, codes, gc.ShouldResemble, []string{"111111", "123456"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "invalid code, try again")
+	})
+
+	t.Run("it verifies the login with a security key in the browser", func(t *testing.T) {
+		polls := 0
+		loginCommand, mockUI, opened := setup(&u.MockStitchClient{
+			StartMFAWebAuthnFn: func(mfaToken string) (*api.MFAWebAuthnSession, error) {
+				return &api.MFAWebAuthnSession{URL: "https://example.com/webauthn?token=" + mfaToken, PollInterval: time.Millisecond}, nil
+			},
+			PollMFAWebAuthnFn: func(mfaToken string) (*auth.Response, error) {
+				if polls++; polls < 3 {
+					return nil, api.ErrMFAPending
+				}
+				return verified, nil
+			},
+		})
+
+		exitCode := loginCommand.Run(append(loginArgs, "--mfa-method=webauthn"))
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, *opened, gc.ShouldResemble, []string{"https://example.com/webauthn?token=mfa-token"})
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Verify the login with your security key at https://example.com/webauthn?token=mfa-token")
+		u.So(t, polls, gc.ShouldEqual, 3)
+	})
+
+	t.Run("it gives up waiting for the security key after --mfa-timeout", func(t *testing.T) {
+		loginCommand, mockUI, _ := setup(&u.MockStitchClient{
+			StartMFAWebAuthnFn: func(mfaToken string) (*api.MFAWebAuthnSession, error) {
+				return &api.MFAWebAuthnSession{URL: "https://example.com/webauthn", PollInterval: time.Millisecond}, nil
+			},
+			PollMFAWebAuthnFn: func(mfaToken string) (*auth.Response, error) {
+				return nil, api.ErrMFAPending
+			},
+		})
+
+		exitCode := loginCommand.Run(append(loginArgs, "--mfa-method=webauthn", "--mfa-timeout=10ms"))
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "timed out after 10ms waiting for the login to be verified with a security key")
+	})
+
+	t.Run("it rejects a factor the account does not allow", func(t *testing.T) {
+		loginCommand, mockUI, _ := setup(&u.MockStitchClient{})
+		mfaRequired.Factors = []string{api.MFAFactorTOTP}
+		defer func() { mfaRequired.Factors = []string{api.MFAFactorTOTP, api.MFAFactorWebAuthn} }()
+
+		exitCode := loginCommand.Run(append(loginArgs, "--mfa-method=webauthn"))
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "your account does not allow verifying logins with webauthn, only with totp")
+	})
+}
@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"fmt"
+
+	"github.com/10gen/stitch-cli/storage"
+
 	"github.com/mitchellh/cli"
 )
 
@@ -30,6 +34,8 @@ func (lc *LogoutCommand) Synopsis() string {
 func (lc *LogoutCommand) Help() string {
 	return lc.Synopsis() + `
 
+With --as, only the identity stored under the given name is logged out.
+
 OPTIONS:` +
 		lc.BaseCommand.Help()
 }
@@ -37,14 +43,28 @@ OPTIONS:` +
 // Run executes the command
 func (lc *LogoutCommand) Run(args []string) int {
 	if err := lc.BaseCommand.run(args); err != nil {
-		lc.UI.Error(err.Error())
+		lc.Log().Error(err.Error())
 		return 1
 	}
 
-	if err := lc.storage.Clear(); err != nil {
-		lc.UI.Error(err.Error())
+	if err := lc.logOut(); err != nil {
+		lc.Log().Error(err.Error())
 		return 1
 	}
 
 	return 0
 }
+
+func (lc *LogoutCommand) logOut() error {
+	if lc.flagAs == "" {
+		return lc.storage.Clear()
+	}
+
+	if err := lc.storage.ClearIdentity(lc.flagAs); err != nil {
+		if err == storage.ErrIdentityNotFound {
+			return fmt.Errorf("no identity named '%s' is stored", lc.flagAs)
+		}
+		return err
+	}
+	return nil
+}
@@ -36,6 +36,29 @@ func TestLogoutCommand(t *testing.T) {
 		u.So(t, storedUser, gc.ShouldResemble, &user.User{})
 	})
 
+	t.Run("with --as clears out only that identity", func(t *testing.T) {
+		logoutCommand, _ := setup(u.NewPopulatedStorage("apikey", "refresh", "access"))
+		u.So(t, logoutCommand.storage.WriteIdentity("deploy-bot", &user.User{PublicAPIKey: "bot-public"}), gc.ShouldBeNil)
+
+		res := logoutCommand.Run([]string{"--as=deploy-bot"})
+		u.So(t, res, gc.ShouldEqual, 0)
+
+		_, err := logoutCommand.storage.ReadIdentity("deploy-bot")
+		u.So(t, err, gc.ShouldEqual, storage.ErrIdentityNotFound)
+
+		storedUser, err := logoutCommand.storage.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "apikey")
+	})
+
+	t.Run("with --as fails for an identity that is not stored", func(t *testing.T) {
+		logoutCommand, mockUI := setup(u.NewEmptyStorage())
+
+		res := logoutCommand.Run([]string{"--as=deploy-bot"})
+		u.So(t, res, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "no identity named 'deploy-bot' is stored")
+	})
+
 	t.Run("plays nicely when the user is not logged in", func(t *testing.T) {
 		logoutCommand, _ := setup(u.NewEmptyStorage())
 
@@ -0,0 +1,359 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	logsFlagType         = "type"
+	logsFlagErrorsOnly   = "errors-only"
+	logsFlagFilter       = "filter"
+	logsFlagLimit        = "limit"
+	logsFlagTail         = "tail"
+	logsFlagPollInterval = "poll-interval"
+
+	defaultLogsLimit = 20
+
+	// defaultLogsPollInterval is how long to wait between fetches of new log entries with --tail, when the server
+	// does not stream them
+	defaultLogsPollInterval = 5 * time.Second
+)
+
+var errLogsAppIDRequired = fmt.Errorf("an App ID (--%s=[string]) must be supplied to read logs", flagAppIDName)
+
+// NewLogsCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewLogsCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &LogsCommand{
+			BaseCommand: &BaseCommand{
+				Name: "logs",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// LogsCommand is used to read the logs of a Stitch App
+type LogsCommand struct {
+	*BaseCommand
+
+	flagProjectID    string
+	flagAppID        string
+	flagType         string
+	flagErrorsOnly   bool
+	flagFilter       string
+	flagLimit        int
+	flagTail         bool
+	flagPollInterval time.Duration
+}
+
+// Help returns long-form help information for this command
+func (lc *LogsCommand) Help() string {
+	return `Show the most recent entries of a stitch application's logs, oldest first, and optionally follow the new ones as they are logged.
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja").
+
+OPTIONS:
+  --` + logsFlagType + ` [string]
+	Only show the entries of this type, e.g. "FUNCTION", "WEBHOOK" or "TRIGGER".
+
+  --` + logsFlagErrorsOnly + `
+	Only show the entries of requests that failed.
+
+  --` + logsFlagFilter + ` [string]
+	Only show the entries matching this expression, e.g. 'status>=400 && function=="checkout"'. Fields are compared to quoted strings with ==, != and =~ (a regular expression), or to numbers with ==, !=, <, <=, > and >=, and comparisons are combined with &&, || and ! and grouped in parentheses. A field on its own matches the entries where it is set. The fields are ` + strings.Join(models.LogFilterFields(), ", ") + `, where duration is in milliseconds.
+
+  --` + logsFlagLimit + ` [int] (default: ` + fmt.Sprint(defaultLogsLimit) + `)
+	The number of the most recent entries to show.
+
+  --` + logsFlagTail + `
+	Keep showing new entries as they are logged, until interrupted with Ctrl+C. The entries are streamed if the server supports it, and polled for otherwise.
+
+  --` + logsFlagPollInterval + ` [duration] (default: ` + defaultLogsPollInterval.String() + `)
+	How long to wait between fetches of new entries with --tail, when they are polled for.
+
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.` +
+		lc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (lc *LogsCommand) Synopsis() string {
+	return `Show or follow the logs of a stitch application.`
+}
+
+// Run executes the command
+func (lc *LogsCommand) Run(args []string) int {
+	flags := lc.NewFlagSet()
+
+	flags.StringVar(&lc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&lc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&lc.flagType, logsFlagType, "", "")
+	flags.BoolVar(&lc.flagErrorsOnly, logsFlagErrorsOnly, false, "")
+	flags.StringVar(&lc.flagFilter, logsFlagFilter, "", "")
+	flags.IntVar(&lc.flagLimit, logsFlagLimit, defaultLogsLimit, "")
+	flags.BoolVar(&lc.flagTail, logsFlagTail, false, "")
+	flags.DurationVar(&lc.flagPollInterval, logsFlagPollInterval, defaultLogsPollInterval, "")
+
+	if err := lc.BaseCommand.run(args); err != nil {
+		lc.Log().Error(err.Error())
+		return 1
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+
+	if err := lc.logs(stop); err != nil {
+		lc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (lc *LogsCommand) logs(stop <-chan os.Signal) error {
+	if lc.flagAppID == "" {
+		return errLogsAppIDRequired
+	}
+
+	if lc.flagPollInterval <= 0 {
+		return fmt.Errorf("--%s must be positive", logsFlagPollInterval)
+	}
+
+	var filter *models.LogFilter
+	if lc.flagFilter != "" {
+		var err error
+		if filter, err = models.ParseLogFilter(lc.flagFilter); err != nil {
+			return fmt.Errorf("invalid --%s: %s", logsFlagFilter, err)
+		}
+	}
+
+	stitchClient, app, err := lc.resolveHostingApp(lc.flagProjectID, lc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	query := models.LogQuery{Type: lc.flagType, ErrorsOnly: lc.flagErrorsOnly}
+
+	entries, err := fetchLogEntries(stitchClient, app, query, filter, lc.flagLimit)
+	if err != nil {
+		return err
+	}
+
+	follower := newLogFollower()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if follower.add(entries[i]) {
+			lc.writeLogEntry(entries[i])
+		}
+	}
+
+	if !lc.flagTail {
+		if len(entries) == 0 {
+			if filter != nil {
+				lc.Log().Info(fmt.Sprintf("'%s' has logged nothing matching the filter", app.ClientAppID))
+			} else {
+				lc.Log().Info(fmt.Sprintf("'%s' has logged nothing", app.ClientAppID))
+			}
+		}
+		return nil
+	}
+
+	if follower.since.IsZero() {
+		follower.since = time.Now()
+	}
+
+	lc.Log().Info(fmt.Sprintf("Following the logs of '%s', press Ctrl+C to stop", app.ClientAppID))
+	return lc.tail(stitchClient, app, query, filter, follower, stop)
+}
+
+// tail writes the entries matching filter logged after those seen by follower until stop receives. They are streamed if the
+// server supports it, reconnecting after --poll-interval whenever it ends the stream, and are polled for otherwise. Failing to read
+// them is only logged, so that a temporary outage does not end the tail
+func (lc *LogsCommand) tail(stitchClient api.StitchClient, app *models.App, query models.LogQuery, filter *models.LogFilter, follower *logFollower, stop <-chan os.Signal) error {
+	for {
+		query.Start = follower.since
+		stream, err := stitchClient.StreamLogs(app.GroupID, app.ID, query)
+		if err == api.ErrLogStreamingUnsupported {
+			lc.Log().Debug(fmt.Sprintf("Polling for new log entries every %s, as the server does not stream them", lc.flagPollInterval))
+			return lc.poll(stitchClient, app, query, filter, follower, stop)
+		}
+
+		if err == nil {
+			var stopped bool
+			if stopped, err = lc.readStream(stream, filter, follower, stop); stopped {
+				return nil
+			}
+		}
+
+		// a stream the server ended is reopened after a while, as is one that failed
+		if err != nil {
+			lc.Log().Warn(fmt.Sprintf("failed to stream the logs of '%s': %s", app.ClientAppID, err))
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(lc.flagPollInterval):
+		}
+	}
+}
+
+// readStream writes the entries of stream matching filter until it ends, returning whether it was ended by stop receiving, or the
+// error that interrupted it
+func (lc *LogsCommand) readStream(stream *api.LogStream, filter *models.LogFilter, follower *logFollower, stop <-chan os.Signal) (bool, error) {
+	defer stream.Close()
+
+	type next struct {
+		entry *models.LogEntry
+		err   error
+	}
+
+	// entries are read in the background, so that a stream waiting for one can be closed by stop
+	entries := make(chan next)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			entry, err := stream.Next()
+			select {
+			case entries <- next{entry, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return true, nil
+		case n := <-entries:
+			if n.err == io.EOF {
+				return false, nil
+			}
+			if n.err != nil {
+				return false, n.err
+			}
+			if follower.add(*n.entry) && filter.Match(*n.entry) {
+				lc.writeLogEntry(*n.entry)
+			}
+		}
+	}
+}
+
+// poll fetches the entries matching filter logged since those seen by follower every --poll-interval, writing
+// the new ones, until stop receives
+func (lc *LogsCommand) poll(stitchClient api.StitchClient, app *models.App, query models.LogQuery, filter *models.LogFilter, follower *logFollower, stop <-chan os.Signal) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(lc.flagPollInterval):
+		}
+
+		query.Start = follower.since
+		entries, err := fetchLogEntries(stitchClient, app, query, filter, 0)
+		if err != nil {
+			lc.Log().Warn(err.Error())
+			continue
+		}
+
+		for i := len(entries) - 1; i >= 0; i-- {
+			if follower.add(entries[i]) {
+				lc.writeLogEntry(entries[i])
+			}
+		}
+	}
+}
+
+// writeLogEntry outputs a log entry as its heading followed by its error and messages, indented
+func (lc *LogsCommand) writeLogEntry(entry models.LogEntry) {
+	heading := logEntryHeading(entry)
+	if entry.Status != 0 {
+		heading += fmt.Sprintf(" %d", entry.Status)
+	}
+	lc.UI.Output(heading)
+
+	for _, message := range entry.Messages {
+		lc.UI.Output("  " + message)
+	}
+	if entry.Error != "" {
+		for _, line := range strings.Split(strings.TrimRight(entry.Error, "\n"), "\n") {
+			lc.UI.Output("  " + line)
+		}
+	}
+}
+
+// logFollower tracks the log entries already written while following logs, as fetching those logged since a
+// time returns the ones logged at that very time again
+type logFollower struct {
+	// since is when the newest entry written was logged
+	since time.Time
+
+	// seen holds the IDs of the entries written that were logged at since
+	seen map[string]bool
+}
+
+func newLogFollower() *logFollower {
+	return &logFollower{seen: map[string]bool{}}
+}
+
+// add records that entry is written, returning false if it already was
+func (lf *logFollower) add(entry models.LogEntry) bool {
+	if lf.seen[entry.ID] {
+		return false
+	}
+
+	switch {
+	case entry.Started.After(lf.since):
+		lf.since = entry.Started
+		lf.seen = map[string]bool{entry.ID: true}
+	case entry.Started.Equal(lf.since):
+		lf.seen[entry.ID] = true
+	}
+	return true
+}
+
+// fetchLogEntries fetches up to limit of the newest log entries of app matching query and filter, following the
+// pages of the logs until it has enough. A nil filter matches every entry, and a limit of 0 fetches every matching
+// entry
+func fetchLogEntries(stitchClient api.StitchClient, app *models.App, query models.LogQuery, filter *models.LogFilter, limit int) ([]models.LogEntry, error) {
+	var entries []models.LogEntry
+	for {
+		page, err := stitchClient.FetchLogs(app.GroupID, app.ID, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the logs of '%s': %s", app.ClientAppID, err)
+		}
+
+		for _, entry := range page.Logs {
+			if filter.Match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		if limit > 0 && len(entries) >= limit {
+			return entries[:limit], nil
+		}
+		if page.NextEndDate == nil || len(page.Logs) == 0 {
+			return entries, nil
+		}
+
+		query.End = *page.NextEndDate
+		query.Skip = page.NextSkip
+	}
+}
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	logsResolveFlagPath     = "path"
+	logsResolveFlagInput    = "input"
+	logsResolveFlagFunction = "function"
+	logsResolveFlagLimit    = "limit"
+
+	defaultLogsResolveLimit = 20
+)
+
+// NewLogsResolveCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewLogsResolveCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &LogsResolveCommand{
+			BaseCommand: &BaseCommand{
+				Name: "logs resolve",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+			stdin:            os.Stdin,
+		}, nil
+	}
+}
+
+// LogsResolveCommand is used to map the stack traces in the logs of a Stitch App back to its local sources
+type LogsResolveCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+	stdin            io.Reader
+
+	flagProjectID string
+	flagAppID     string
+	flagAppPath   string
+	flagInput     string
+	flagFunction  string
+	flagLimit     int
+}
+
+// Help returns long-form help information for this command
+func (lrc *LogsResolveCommand) Help() string {
+	return `Map the positions in the stack traces of a stitch application's function errors back to its local sources.
+
+The source of a function as deployed may differ from its local source: the shared modules of "functions/` + utils.FunctionLibDir + `" are injected into it, and
+"import --build-functions" builds it from TypeScript. Positions in the injected modules are mapped to "functions/` + utils.FunctionLibDir + `", using the
+"` + utils.FunctionLibManifestFileName + `" written by the last import, and positions in built sources to their TypeScript, using the source maps kept in
+"` + utils.FunctionSourceMapsDir + `". Other positions are left as they are.
+
+By default, the errors of the app's most recent failed requests are fetched from its logs and resolved.
+
+OPTIONS:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja"). Required unless --input is given.
+
+  --path [string]
+	A path to the local directory containing your app, as it was last imported. Defaults to the directory containing the working directory.
+
+  --input [string]
+	Resolve the stack trace in this file, or on stdin if it is "-", rather than fetching errors from the app's logs.
+
+  --function [string]
+	The name of the function that positions not naming the source.js of a function are in. Defaults to the function of each log entry.
+
+  --limit [int] (default: ` + fmt.Sprint(defaultLogsResolveLimit) + `)
+	The number of the most recent errors to resolve.
+
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.` +
+		lrc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (lrc *LogsResolveCommand) Synopsis() string {
+	return `Map stack traces in function errors back to local sources.`
+}
+
+// Run executes the command
+func (lrc *LogsResolveCommand) Run(args []string) int {
+	flags := lrc.NewFlagSet()
+
+	flags.StringVar(&lrc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&lrc.flagAppID, flagAppIDName, "", "")
+	flags.StringVar(&lrc.flagAppPath, logsResolveFlagPath, "", "")
+	flags.StringVar(&lrc.flagInput, logsResolveFlagInput, "", "")
+	flags.StringVar(&lrc.flagFunction, logsResolveFlagFunction, "", "")
+	flags.IntVar(&lrc.flagLimit, logsResolveFlagLimit, defaultLogsResolveLimit, "")
+
+	if err := lrc.BaseCommand.run(args); err != nil {
+		lrc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := lrc.resolve(); err != nil {
+		lrc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (lrc *LogsResolveCommand) resolve() error {
+	appPath, err := lrc.resolveAppDirectory(lrc.flagAppPath, lrc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := utils.NewTraceResolver(appPath)
+	if err != nil {
+		return err
+	}
+
+	if lrc.flagInput != "" {
+		trace, err := lrc.readInput()
+		if err != nil {
+			return err
+		}
+
+		resolved, err := resolver.Resolve(trace, lrc.flagFunction)
+		if err != nil {
+			return err
+		}
+		lrc.UI.Output(strings.TrimRight(resolved, "\n"))
+		return nil
+	}
+
+	if lrc.flagAppID == "" {
+		return errLogsAppIDRequired
+	}
+
+	stitchClient, app, err := lrc.resolveHostingApp(lrc.flagProjectID, lrc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := fetchLogEntries(stitchClient, app, models.LogQuery{ErrorsOnly: true}, nil, lrc.flagLimit)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		lrc.Log().Info(fmt.Sprintf("'%s' has logged no errors", app.ClientAppID))
+		return nil
+	}
+
+	for i, entry := range entries {
+		function := lrc.flagFunction
+		if function == "" {
+			function = entry.FunctionName
+		}
+
+		resolved, err := resolver.Resolve(entry.Error, function)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			lrc.UI.Output("")
+		}
+		lrc.UI.Output(logEntryHeading(entry))
+		for _, line := range strings.Split(strings.TrimRight(resolved, "\n"), "\n") {
+			lrc.UI.Output("  " + line)
+		}
+	}
+
+	return nil
+}
+
+// logEntryHeading describes a log entry in a single line, e.g.
+// "2018-03-02T10:04:05Z FUNCTION sum (5a9a2c3d4e5f6a7b8c9d0e1f)"
+func logEntryHeading(entry models.LogEntry) string {
+	parts := []string{entry.Started.UTC().Format(time.RFC3339), entry.Type}
+	if entry.FunctionName != "" {
+		parts = append(parts, entry.FunctionName)
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(parts, " "), entry.ID)
+}
+
+func (lrc *LogsResolveCommand) readInput() (string, error) {
+	if lrc.flagInput == "-" {
+		data, err := ioutil.ReadAll(lrc.stdin)
+		return string(data), err
+	}
+
+	path, err := homedir.Expand(lrc.flagInput)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %s", lrc.flagInput, err)
+	}
+	return string(data), nil
+}
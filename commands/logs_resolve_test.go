@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestLogsResolveCommand(t *testing.T) {
+	appDir, err := ioutil.TempDir("", "stitch-logs-resolve")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(appDir)
+
+	u.So(t, ioutil.WriteFile(filepath.Join(appDir, models.AppConfigFileName), []byte(`{"name": "my-app"}`), 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(
+		filepath.Join(appDir, utils.FunctionLibManifestFileName),
+		[]byte(`{"functions": [{"dir": "functions/sum", "lines": 10, "modules": [{"name": "math", "line": 4}]}]}`),
+		0644,
+	), gc.ShouldBeNil)
+
+	setup := func() (*LogsResolveCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewLogsResolveCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		resolveCommand := cmd.(*LogsResolveCommand)
+		resolveCommand.storage = u.NewEmptyStorage()
+		resolveCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		resolveCommand.workingDirectory = appDir
+		resolveCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+		}
+		return resolveCommand, mockUI
+	}
+
+	t.Run("it resolves a stack trace on stdin", func(t *testing.T) {
+		resolveCommand, mockUI := setup()
+		resolveCommand.stdin = strings.NewReader("Error: boom\n    at add (functions/sum/source.js:5:3)\n    at functions/sum/source.js:12:1\n")
+
+		exitCode := resolveCommand.Run([]string{"--input=-"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Error: boom\n    at add (functions/_lib/math.js:2:3)\n    at functions/sum/source.js:2:1\n")
+	})
+
+	t.Run("it requires an App ID to resolve the errors in the logs", func(t *testing.T) {
+		resolveCommand, mockUI := setup()
+		exitCode := resolveCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errLogsAppIDRequired.Error())
+	})
+
+	t.Run("it resolves the errors of the most recent failed requests", func(t *testing.T) {
+		started := time.Date(2018, time.March, 2, 10, 4, 5, 0, time.UTC)
+		nextEnd := started.Add(-time.Hour)
+
+		var queries []models.LogQuery
+		resolveCommand, mockUI := setup()
+		resolveCommand.stitchClient.(*u.MockStitchClient).FetchLogsFn = func(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+			queries = append(queries, query)
+			if len(queries) == 1 {
+				return &models.LogPage{
+					Logs:        []models.LogEntry{{ID: "log1", Type: "FUNCTION", FunctionName: "sum", Started: started, Error: "Error: boom\n    at <anonymous>:5:3"}},
+					NextEndDate: &nextEnd,
+					NextSkip:    1,
+				}, nil
+			}
+			return &models.LogPage{
+				Logs: []models.LogEntry{{ID: "log2", Type: "WEBHOOK", Started: nextEnd, Error: "no position"}},
+			}, nil
+		}
+
+		exitCode := resolveCommand.Run([]string{"--app-id=my-app-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, queries, gc.ShouldResemble, []models.LogQuery{{ErrorsOnly: true}, {ErrorsOnly: true, End: nextEnd, Skip: 1}})
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "2018-03-02T10:04:05Z FUNCTION sum (log1)\n"+
+			"  Error: boom\n"+
+			"      at functions/_lib/math.js:2:3\n"+
+			"\n"+
+			"2018-03-02T09:04:05Z WEBHOOK (log2)\n"+
+			"  no position\n")
+	})
+
+	t.Run("it stops at the limit", func(t *testing.T) {
+		calls := 0
+		resolveCommand, mockUI := setup()
+		resolveCommand.stitchClient.(*u.MockStitchClient).FetchLogsFn = func(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+			calls++
+			return &models.LogPage{Logs: []models.LogEntry{{ID: "a", Error: "one"}, {ID: "b", Error: "two"}}}, nil
+		}
+
+		exitCode := resolveCommand.Run([]string{"--app-id=my-app-abcde", "--limit=1"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, calls, gc.ShouldEqual, 1)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "one")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "two")
+	})
+}
@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/10gen/stitch-cli/models"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	logsSummarizeFlagSince  = "since"
+	logsSummarizeFlagOutput = "output"
+
+	defaultLogsSummarizeSince = time.Hour
+)
+
+// The formats a logs summary can be written in
+const (
+	logsSummarizeOutputText = "text"
+	logsSummarizeOutputJSON = "json"
+)
+
+var errLogsSummarizeAppIDRequired = fmt.Errorf("an App ID (--%s=[string]) must be supplied to summarize logs", flagAppIDName)
+
+// NewLogsSummarizeCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewLogsSummarizeCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &LogsSummarizeCommand{
+			BaseCommand: &BaseCommand{
+				Name: "logs summarize",
+				UI:   ui,
+			},
+			now: time.Now,
+		}, nil
+	}
+}
+
+// LogsSummarizeCommand is used to report the request counts, failure rates and execution times of the functions
+// of a Stitch App from its logs
+type LogsSummarizeCommand struct {
+	*BaseCommand
+
+	now func() time.Time
+
+	flagProjectID string
+	flagAppID     string
+	flagSince     time.Duration
+	flagType      string
+	flagFilter    string
+	flagOutput    string
+}
+
+// logsSummary is the summary of an app's logs over a time range as written by --output json
+type logsSummary struct {
+	AppID     string            `json:"app_id"`
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	Total     logsSummaryStats  `json:"total"`
+	Functions []logsSummaryStat `json:"functions"`
+}
+
+// logsSummaryStat is the summary of the log entries of a single function
+type logsSummaryStat struct {
+	Function string `json:"function"`
+	logsSummaryStats
+}
+
+// logsSummaryStats summarizes a set of log entries. The 95th percentile of their execution times only counts
+// those that completed
+type logsSummaryStats struct {
+	Requests      int     `json:"requests"`
+	Errors        int     `json:"errors"`
+	ErrorRate     float64 `json:"error_rate"`
+	P95DurationMS float64 `json:"p95_duration_ms"`
+}
+
+// Synopsis returns a one-liner description for this command
+func (lsc *LogsSummarizeCommand) Synopsis() string {
+	return "Summarize the failure rates and execution times of a stitch application's functions."
+}
+
+// Help returns long-form help information for this command
+func (lsc *LogsSummarizeCommand) Help() string {
+	return `Summarize the logs of a stitch application over a recent time range: the number of requests to each of its functions, how many
+of them failed and the 95th percentile of their execution times, e.g. to track error budgets. A request failed if it logged an
+error or its status is 500 or above.
+
+REQUIRED:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja").
+
+OPTIONS:
+  --` + logsSummarizeFlagSince + ` [duration] (default: ` + defaultLogsSummarizeSince.String() + `)
+	How far back to summarize the logs, e.g. "30m" or "24h".
+
+  --` + logsFlagType + ` [string]
+	Only summarize the entries of this type, e.g. "FUNCTION", "WEBHOOK" or "TRIGGER".
+
+  --` + logsFlagFilter + ` [string]
+	Only summarize the entries matching this expression, as with "logs --` + logsFlagFilter + `".
+
+  --` + logsSummarizeFlagOutput + ` [text|json] (default: text)
+	How to write the summary: as a table, or as JSON for loading into dashboards.
+
+  --project-id [string]
+	Lookup apps associated with this project id, as opposed to ids associated with the current user profile.` +
+		lsc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (lsc *LogsSummarizeCommand) Run(args []string) int {
+	flags := lsc.NewFlagSet()
+
+	flags.StringVar(&lsc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&lsc.flagAppID, flagAppIDName, "", "")
+	flags.DurationVar(&lsc.flagSince, logsSummarizeFlagSince, defaultLogsSummarizeSince, "")
+	flags.StringVar(&lsc.flagType, logsFlagType, "", "")
+	flags.StringVar(&lsc.flagFilter, logsFlagFilter, "", "")
+	flags.StringVar(&lsc.flagOutput, logsSummarizeFlagOutput, logsSummarizeOutputText, "")
+
+	if err := lsc.BaseCommand.run(args); err != nil {
+		lsc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := lsc.summarize(); err != nil {
+		lsc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (lsc *LogsSummarizeCommand) summarize() error {
+	if lsc.flagAppID == "" {
+		return errLogsSummarizeAppIDRequired
+	}
+
+	if lsc.flagSince <= 0 {
+		return fmt.Errorf("--%s must be positive", logsSummarizeFlagSince)
+	}
+
+	switch lsc.flagOutput {
+	case logsSummarizeOutputText, logsSummarizeOutputJSON:
+	default:
+		return fmt.Errorf("--%s must be one of %s or %s, got %q", logsSummarizeFlagOutput, logsSummarizeOutputText, logsSummarizeOutputJSON, lsc.flagOutput)
+	}
+
+	var filter *models.LogFilter
+	if lsc.flagFilter != "" {
+		var err error
+		if filter, err = models.ParseLogFilter(lsc.flagFilter); err != nil {
+			return fmt.Errorf("invalid --%s: %s", logsFlagFilter, err)
+		}
+	}
+
+	stitchClient, app, err := lsc.resolveHostingApp(lsc.flagProjectID, lsc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	end := lsc.now().UTC()
+	start := end.Add(-lsc.flagSince)
+
+	entries, err := fetchLogEntries(stitchClient, app, models.LogQuery{Start: start, End: end, Type: lsc.flagType}, filter, 0)
+	if err != nil {
+		return err
+	}
+
+	output, err := formatLogsSummary(summarizeLogEntries(app.ClientAppID, start, end, entries), lsc.flagOutput)
+	if err != nil {
+		return err
+	}
+
+	lsc.UI.Output(output)
+	return nil
+}
+
+// summarizeLogEntries summarizes the entries in total and for each function they name, in order of name
+func summarizeLogEntries(appID string, start, end time.Time, entries []models.LogEntry) logsSummary {
+	byFunction := map[string][]models.LogEntry{}
+	for _, entry := range entries {
+		if entry.FunctionName != "" {
+			byFunction[entry.FunctionName] = append(byFunction[entry.FunctionName], entry)
+		}
+	}
+
+	summary := logsSummary{
+		AppID:     appID,
+		Start:     start,
+		End:       end,
+		Total:     summarizeLogStats(entries),
+		Functions: make([]logsSummaryStat, 0, len(byFunction)),
+	}
+	for function, functionEntries := range byFunction {
+		summary.Functions = append(summary.Functions, logsSummaryStat{function, summarizeLogStats(functionEntries)})
+	}
+	sort.Slice(summary.Functions, func(i, j int) bool {
+		return summary.Functions[i].Function < summary.Functions[j].Function
+	})
+
+	return summary
+}
+
+func summarizeLogStats(entries []models.LogEntry) logsSummaryStats {
+	stats := logsSummaryStats{Requests: len(entries)}
+
+	var durations []time.Duration
+	for _, entry := range entries {
+		if entry.Error != "" || entry.Status >= 500 {
+			stats.Errors++
+		}
+		if !entry.Completed.IsZero() {
+			durations = append(durations, entry.Completed.Sub(entry.Started))
+		}
+	}
+
+	if stats.Requests > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+	}
+
+	// the 95th percentile is by nearest rank, so that it is one of the durations logged
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		rank := int(math.Ceil(0.95 * float64(len(durations))))
+		stats.P95DurationMS = float64(durations[rank-1].Nanoseconds()) / 1e6
+	}
+
+	return stats
+}
+
+// formatLogsSummary writes the summary in the given format
+func formatLogsSummary(summary logsSummary, format string) (string, error) {
+	if format == logsSummarizeOutputJSON {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Logs of '%s' from %s to %s:\n", summary.AppID, summary.Start.Format(time.RFC3339), summary.End.Format(time.RFC3339))
+
+	if summary.Total.Requests == 0 {
+		buf.WriteString("  nothing was logged in this time range")
+		return buf.String(), nil
+	}
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  FUNCTION\tREQUESTS\tERRORS\tERROR RATE\tP95")
+	for _, function := range summary.Functions {
+		fmt.Fprintf(w, "  %s\t%s\n", function.Function, formatLogsSummaryStats(function.logsSummaryStats))
+	}
+	fmt.Fprintf(w, "  total\t%s\n", formatLogsSummaryStats(summary.Total))
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func formatLogsSummaryStats(stats logsSummaryStats) string {
+	p95 := "-"
+	if stats.P95DurationMS > 0 {
+		p95 = (time.Duration(stats.P95DurationMS * float64(time.Millisecond))).Round(time.Millisecond).String()
+	}
+	return fmt.Sprintf("%d\t%d\t%.1f%%\t%s", stats.Requests, stats.Errors, 100*stats.ErrorRate, p95)
+}
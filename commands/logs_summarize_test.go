@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestLogsSummarizeCommand(t *testing.T) {
+	now := time.Date(2018, time.March, 2, 11, 0, 0, 0, time.UTC)
+	entry := func(function string, status int, duration time.Duration) models.LogEntry {
+		started := now.Add(-10 * time.Minute)
+		return models.LogEntry{Type: "FUNCTION", FunctionName: function, Status: status, Started: started, Completed: started.Add(duration)}
+	}
+
+	var entries []models.LogEntry
+	for i := 1; i <= 20; i++ {
+		status := 200
+		if i <= 2 {
+			status = 500
+		}
+		entries = append(entries, entry("checkout", status, time.Duration(i)*10*time.Millisecond))
+	}
+	entries = append(entries,
+		entry("cart", 200, 30*time.Millisecond),
+		models.LogEntry{Type: "FUNCTION", FunctionName: "cart", Started: now.Add(-time.Minute), Error: "TypeError: boom"},
+		models.LogEntry{Type: "AUTH", Status: 200, Started: now.Add(-time.Minute)},
+	)
+
+	setup := func(queries *[]models.LogQuery) (*LogsSummarizeCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewLogsSummarizeCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		summarizeCommand := cmd.(*LogsSummarizeCommand)
+		summarizeCommand.now = func() time.Time { return now }
+		summarizeCommand.storage = u.NewEmptyStorage()
+		summarizeCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		summarizeCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+			},
+			FetchLogsFn: func(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+				*queries = append(*queries, query)
+				return &models.LogPage{Logs: entries}, nil
+			},
+		}
+		return summarizeCommand, mockUI
+	}
+
+	t.Run("it requires an app id", func(t *testing.T) {
+		summarizeCommand, mockUI := setup(&[]models.LogQuery{})
+		exitCode := summarizeCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errLogsSummarizeAppIDRequired.Error())
+	})
+
+	t.Run("it rejects invalid flags", func(t *testing.T) {
+		for _, tc := range []struct {
+			args          []string
+			expectedError string
+		}{
+			{[]string{"--app-id=my-app-abcde", "--output=csv"}, `--output must be one of text or json, got "csv"`},
+			{[]string{"--app-id=my-app-abcde", "--since=-1h"}, "--since must be positive"},
+			{[]string{"--app-id=my-app-abcde", "--filter=status>"}, "invalid --filter"},
+		} {
+			summarizeCommand, mockUI := setup(&[]models.LogQuery{})
+			exitCode := summarizeCommand.Run(tc.args)
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, tc.expectedError)
+		}
+	})
+
+	t.Run("it summarizes each function's entries since the given time as text", func(t *testing.T) {
+		var queries []models.LogQuery
+		summarizeCommand, mockUI := setup(&queries)
+
+		exitCode := summarizeCommand.Run([]string{"--app-id=my-app-abcde", "--since=1h", "--type=FUNCTION"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, queries, gc.ShouldResemble, []models.LogQuery{{Start: now.Add(-time.Hour), End: now, Type: "FUNCTION"}})
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"Logs of 'my-app-abcde' from 2018-03-02T10:00:00Z to 2018-03-02T11:00:00Z:",
+			"  FUNCTION  REQUESTS  ERRORS  ERROR RATE  P95",
+			"  cart      2         1       50.0%       30ms",
+			"  checkout  20        2       10.0%       190ms",
+			"  total     23        3       13.0%       190ms",
+			"",
+		}, "\n"))
+	})
+
+	t.Run("it summarizes the entries matching the filter as JSON", func(t *testing.T) {
+		summarizeCommand, mockUI := setup(&[]models.LogQuery{})
+
+		exitCode := summarizeCommand.Run([]string{"--app-id=my-app-abcde", "--output=json", `--filter=function=="checkout"`})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var summary logsSummary
+		u.So(t, json.Unmarshal([]byte(mockUI.OutputWriter.String()), &summary), gc.ShouldBeNil)
+		u.So(t, summary.Total, gc.ShouldResemble, logsSummaryStats{Requests: 20, Errors: 2, ErrorRate: 0.1, P95DurationMS: 190})
+		u.So(t, summary.Functions, gc.ShouldResemble, []logsSummaryStat{
+			{"checkout", logsSummaryStats{Requests: 20, Errors: 2, ErrorRate: 0.1, P95DurationMS: 190}},
+		})
+	})
+
+	t.Run("it says when nothing was logged", func(t *testing.T) {
+		summarizeCommand, mockUI := setup(&[]models.LogQuery{})
+
+		exitCode := summarizeCommand.Run([]string{"--app-id=my-app-abcde", `--filter=function=="search"`})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "nothing was logged in this time range")
+	})
+}
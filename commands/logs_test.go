@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestLogsCommand(t *testing.T) {
+	start := time.Date(2018, time.March, 2, 10, 0, 0, 0, time.UTC)
+	recent := []models.LogEntry{
+		{ID: "log2", Type: "FUNCTION", FunctionName: "checkout", Status: 500, Started: start.Add(time.Minute), Error: "TypeError: boom"},
+		{ID: "log1", Type: "WEBHOOK", Started: start, Messages: []string{"received order"}},
+	}
+
+	setup := func(stitchClient *u.MockStitchClient) (*LogsCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewLogsCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		logsCommand := cmd.(*LogsCommand)
+		logsCommand.storage = u.NewEmptyStorage()
+		logsCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		stitchClient.FetchAppByClientAppIDFn = func(clientAppID string) (*models.App, error) {
+			return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+		}
+		logsCommand.stitchClient = stitchClient
+		return logsCommand, mockUI
+	}
+
+	t.Run("it requires an App ID", func(t *testing.T) {
+		logsCommand, mockUI := setup(&u.MockStitchClient{})
+		exitCode := logsCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errLogsAppIDRequired.Error())
+	})
+
+	t.Run("it shows the most recent entries oldest first", func(t *testing.T) {
+		var queries []models.LogQuery
+		logsCommand, mockUI := setup(&u.MockStitchClient{
+			FetchLogsFn: func(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+				queries = append(queries, query)
+				return &models.LogPage{Logs: recent}, nil
+			},
+		})
+
+		exitCode := logsCommand.Run([]string{"--app-id=my-app-abcde", "--errors-only", "--type=FUNCTION"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, queries, gc.ShouldResemble, []models.LogQuery{{Type: "FUNCTION", ErrorsOnly: true}})
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"2018-03-02T10:00:00Z WEBHOOK (log1)",
+			"  received order",
+			"2018-03-02T10:01:00Z FUNCTION checkout (log2) 500",
+			"  TypeError: boom",
+			"",
+		}, "\n"))
+	})
+
+	t.Run("it shows the most recent entries matching the filter", func(t *testing.T) {
+		logsCommand, mockUI := setup(&u.MockStitchClient{
+			FetchLogsFn: func(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+				return &models.LogPage{Logs: recent}, nil
+			},
+		})
+
+		exitCode := logsCommand.Run([]string{"--app-id=my-app-abcde", `--filter=status>=400 && function=="checkout"`})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"2018-03-02T10:01:00Z FUNCTION checkout (log2) 500",
+			"  TypeError: boom",
+			"",
+		}, "\n"))
+	})
+
+	t.Run("it rejects an invalid filter", func(t *testing.T) {
+		logsCommand, mockUI := setup(&u.MockStitchClient{})
+		exitCode := logsCommand.Run([]string{"--app-id=my-app-abcde", "--filter=status>="})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "invalid --filter: unexpected end of filter")
+	})
+
+	t.Run("with --tail", func(t *testing.T) {
+		tailCommand := func(stitchClient *u.MockStitchClient) (*LogsCommand, *cli.MockUi) {
+			logsCommand, mockUI := setup(stitchClient)
+			logsCommand.flagAppID = "my-app-abcde"
+			logsCommand.flagLimit = defaultLogsLimit
+			logsCommand.flagTail = true
+			logsCommand.flagPollInterval = time.Millisecond
+			return logsCommand, mockUI
+		}
+
+		t.Run("it follows the entries the server streams, skipping those already shown", func(t *testing.T) {
+			stop := make(chan os.Signal)
+			var streamed []time.Time
+			logsCommand, mockUI := tailCommand(&u.MockStitchClient{
+				FetchLogsFn: func(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+					return &models.LogPage{Logs: recent}, nil
+				},
+				StreamLogsFn: func(groupID, appID string, query models.LogQuery) (*api.LogStream, error) {
+					streamed = append(streamed, query.Start)
+					if len(streamed) > 1 {
+						close(stop)
+						return api.NewLogStream(ioutil.NopCloser(strings.NewReader(""))), nil
+					}
+					return api.NewLogStream(ioutil.NopCloser(strings.NewReader(
+						`data: {"_id": "log2", "type": "FUNCTION", "started": "2018-03-02T10:01:00Z"}` + "\n\n" +
+							`data: {"_id": "log3", "type": "TRIGGER", "started": "2018-03-02T10:02:00Z"}` + "\n\n",
+					))), nil
+				},
+			})
+
+			u.So(t, logsCommand.logs(stop), gc.ShouldBeNil)
+			u.So(t, streamed, gc.ShouldResemble, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)})
+
+			output := mockUI.OutputWriter.String()
+			u.So(t, strings.Count(output, "(log2)"), gc.ShouldEqual, 1)
+			u.So(t, output, gc.ShouldEndWith, "2018-03-02T10:02:00Z TRIGGER (log3)\n")
+		})
+
+		t.Run("it polls for new entries if the server does not stream them", func(t *testing.T) {
+			stop := make(chan os.Signal)
+			var polled []models.LogQuery
+			logsCommand, mockUI := tailCommand(&u.MockStitchClient{
+				FetchLogsFn: func(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+					if query.Start.IsZero() {
+						return &models.LogPage{Logs: recent}, nil
+					}
+
+					polled = append(polled, query)
+					if len(polled) > 1 {
+						close(stop)
+						return &models.LogPage{}, nil
+					}
+					return &models.LogPage{Logs: []models.LogEntry{
+						{ID: "log3", Type: "TRIGGER", Started: start.Add(2 * time.Minute)},
+						recent[0],
+					}}, nil
+				},
+			})
+
+			u.So(t, logsCommand.logs(stop), gc.ShouldBeNil)
+			u.So(t, polled, gc.ShouldHaveLength, 2)
+			u.So(t, polled[0].Start, gc.ShouldResemble, start.Add(time.Minute))
+			u.So(t, polled[1].Start, gc.ShouldResemble, start.Add(2*time.Minute))
+
+			output := mockUI.OutputWriter.String()
+			u.So(t, strings.Count(output, "(log2)"), gc.ShouldEqual, 1)
+			u.So(t, output, gc.ShouldEndWith, "2018-03-02T10:02:00Z TRIGGER (log3)\n")
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+
+	u "github.com/10gen/stitch-cli/user"
+
+	"github.com/mitchellh/cli"
+)
+
+// NewOrgsListCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewOrgsListCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &OrgsListCommand{
+			BaseCommand: &BaseCommand{
+				Name: "orgs list",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// orgsListColumns are the columns of the Organizations listed by OrgsListCommand
+var orgsListColumns = []string{"name", "id", "projects"}
+
+// OrgsListCommand is used to list the Atlas Organizations available to the current user
+type OrgsListCommand struct {
+	*BaseCommand
+
+	output listOutput
+}
+
+// Synopsis returns a one-liner description for this command
+func (olc *OrgsListCommand) Synopsis() string {
+	return "List the Atlas Organizations available to you."
+}
+
+// Help returns long-form help information for this command
+func (olc *OrgsListCommand) Help() string {
+	return `List the Atlas Organizations available to the current user, along with how many of their Projects are available.
+The name or ID of an Organization can be passed to "import --org" or "export --org" to only be offered its Projects.
+
+OPTIONS:` + listOutputHelp(orgsListColumns) + olc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (olc *OrgsListCommand) Run(args []string) int {
+	flags := olc.NewFlagSet()
+
+	olc.output.registerFlags(flags)
+
+	if err := olc.BaseCommand.run(args); err != nil {
+		olc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := olc.list(); err != nil {
+		olc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (olc *OrgsListCommand) list() error {
+	user, err := olc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	atlasClient, err := olc.AtlasClient()
+	if err != nil {
+		return err
+	}
+
+	orgs, err := atlasClient.Orgs()
+	if err != nil {
+		return err
+	}
+
+	if len(orgs) == 0 {
+		return errors.New("no available Organizations")
+	}
+
+	groups, err := atlasClient.Groups()
+	if err != nil {
+		return err
+	}
+
+	projectCounts := map[string]int{}
+	for _, group := range groups {
+		projectCounts[group.OrgID]++
+	}
+
+	rows := make([][]string, len(orgs))
+	for i, org := range orgs {
+		rows[i] = []string{org.Name, org.ID, strconv.Itoa(projectCounts[org.ID])}
+	}
+
+	return olc.output.write(olc.UI, orgsListColumns, rows)
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrgsListCommand(t *testing.T) {
+	setup := func(atlasClient *u.MockMDBClient) (*OrgsListCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewOrgsListCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		orgsListCommand := cmd.(*OrgsListCommand)
+		orgsListCommand.storage = u.NewEmptyStorage()
+		orgsListCommand.user = &user.User{
+			APIKey:      "my-api-key",
+			AccessToken: u.GenerateValidAccessToken(),
+		}
+		orgsListCommand.atlasClient = atlasClient
+
+		return orgsListCommand, mockUI
+	}
+
+	t.Run("should require the user to be logged in", func(t *testing.T) {
+		orgsListCommand, mockUI := setup(&u.MockMDBClient{})
+		orgsListCommand.user = &user.User{}
+
+		exitCode := orgsListCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, user.ErrNotLoggedIn.Error())
+	})
+
+	t.Run("should report an error fetching organizations", func(t *testing.T) {
+		orgsListCommand, mockUI := setup(&u.MockMDBClient{
+			OrgsFn: func() ([]mdbcloud.Org, error) {
+				return nil, errors.New("oh noes")
+			},
+		})
+
+		exitCode := orgsListCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "oh noes")
+	})
+
+	t.Run("should list each organization with how many projects it has", func(t *testing.T) {
+		orgsListCommand, mockUI := setup(&u.MockMDBClient{
+			OrgsFn: func() ([]mdbcloud.Org, error) {
+				return []mdbcloud.Org{{ID: "org-1", Name: "Engineering"}, {ID: "org-2", Name: "Marketing"}, {ID: "org-3", Name: "Sales"}}, nil
+			},
+			GroupsFn: func() ([]mdbcloud.Group, error) {
+				return []mdbcloud.Group{
+					{ID: "group-1", Name: "api", OrgID: "org-1"},
+					{ID: "group-2", Name: "web", OrgID: "org-1"},
+					{ID: "group-3", Name: "site", OrgID: "org-2"},
+				}, nil
+			},
+		})
+
+		exitCode := orgsListCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, `NAME         ID     PROJECTS
+Engineering  org-1  2
+Marketing    org-2  1
+Sales        org-3  0
+`)
+	})
+	t.Run("with --output csv", func(t *testing.T) {
+		newOrgsListCommand := func() (*OrgsListCommand, *cli.MockUi) {
+			return setup(&u.MockMDBClient{
+				OrgsFn: func() ([]mdbcloud.Org, error) {
+					return []mdbcloud.Org{{ID: "org-1", Name: "Engineering, Inc."}, {ID: "org-2", Name: "Marketing"}}, nil
+				},
+				GroupsFn: func() ([]mdbcloud.Group, error) {
+					return []mdbcloud.Group{{ID: "group-1", Name: "api", OrgID: "org-1"}}, nil
+				},
+			})
+		}
+
+		t.Run("it writes every column with a header row", func(t *testing.T) {
+			orgsListCommand, mockUI := newOrgsListCommand()
+
+			exitCode := orgsListCommand.Run([]string{"--output=csv"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "name,id,projects\n\"Engineering, Inc.\",org-1,1\nMarketing,org-2,0\n")
+		})
+
+		t.Run("it writes the columns given by --columns in order", func(t *testing.T) {
+			orgsListCommand, mockUI := newOrgsListCommand()
+
+			exitCode := orgsListCommand.Run([]string{"--output=csv", "--columns=id, name"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "id,name\norg-1,\"Engineering, Inc.\"\norg-2,Marketing\n")
+		})
+
+		t.Run("it fails for an unknown column", func(t *testing.T) {
+			orgsListCommand, mockUI := newOrgsListCommand()
+
+			exitCode := orgsListCommand.Run([]string{"--output=csv", "--columns=name,size"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `unknown column "size" for --columns, expected one of: name, id, projects`)
+		})
+
+		t.Run("it fails for an unknown format", func(t *testing.T) {
+			orgsListCommand, mockUI := newOrgsListCommand()
+
+			exitCode := orgsListCommand.Run([]string{"--output=xml"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `--output must be one of text or csv, got "xml"`)
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+)
+
+// projectOwnerRole is the project role that allows changing the apps in a project
+const projectOwnerRole = "GROUP_OWNER"
+
+// The organization roles that grant access to every project in the organization
+const (
+	orgOwnerRole    = "ORG_OWNER"
+	orgReadOnlyRole = "ORG_READ_ONLY"
+)
+
+// readOnlyProjectRoles are the project roles known not to allow changing the apps in a project. Roles not
+// listed here may have been added since, so credentials holding them are given the benefit of the doubt
+var readOnlyProjectRoles = map[string]bool{
+	"GROUP_READ_ONLY":              true,
+	"GROUP_DATA_ACCESS_READ_ONLY":  true,
+	"GROUP_DATA_ACCESS_READ_WRITE": true,
+	"GROUP_DATA_ACCESS_ADMIN":      true,
+	"GROUP_CLUSTER_MANAGER":        true,
+}
+
+// canWriteWithProjectRoles returns whether any of the project roles may allow changing the apps in the project
+func canWriteWithProjectRoles(roleNames []string) bool {
+	for _, roleName := range roleNames {
+		if roleName == projectOwnerRole || !readOnlyProjectRoles[roleName] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWriteAccess fails if the current credentials are known to only have read-only access to the project
+// of the app, so that commands fail before changing anything rather than on a 403 partway through. Access
+// that cannot be determined, e.g. because it is granted through the organization, is assumed to be enough
+func (c *BaseCommand) checkWriteAccess(stitchClient api.StitchClient, app *models.App) error {
+	profile, err := stitchClient.FetchUserProfile()
+	if err != nil {
+		c.Log().Debug(fmt.Sprintf("Skipping the permission check, as the user profile could not be fetched: %s", err))
+		return nil
+	}
+
+	roleNames := profile.GroupRoleNames(app.GroupID)
+	if len(roleNames) == 0 || canWriteWithProjectRoles(roleNames) {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"your API key has read-only project access (%s) to project %s, but changing '%s' requires the Project Owner role",
+		strings.Join(roleNames, ", "),
+		app.GroupID,
+		app.ClientAppID,
+	)
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestCheckWriteAccess(t *testing.T) {
+	app := &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: "my-app-abcde"}
+
+	newProfile := func(roles string) func() (*models.UserProfile, error) {
+		return func() (*models.UserProfile, error) {
+			var profile models.UserProfile
+			err := json.Unmarshal([]byte(`{"roles": `+roles+`}`), &profile)
+			return &profile, err
+		}
+	}
+
+	for _, tc := range []struct {
+		description   string
+		profile       func() (*models.UserProfile, error)
+		expectedError string
+	}{
+		{
+			description: "it allows a project owner",
+			profile:     newProfile(`[{"role_name": "GROUP_READ_ONLY", "group_id": "group-id"}, {"role_name": "GROUP_OWNER", "group_id": "group-id"}]`),
+		},
+		{
+			description:   "it rejects read-only project access",
+			profile:       newProfile(`[{"role_name": "GROUP_OWNER", "group_id": "other-group-id"}, {"role_name": "GROUP_READ_ONLY", "group_id": "group-id"}]`),
+			expectedError: "your API key has read-only project access (GROUP_READ_ONLY) to project group-id, but changing 'my-app-abcde' requires the Project Owner role",
+		},
+		{
+			description: "it allows roles it does not know",
+			profile:     newProfile(`[{"role_name": "GROUP_SOMETHING_NEW", "group_id": "group-id"}]`),
+		},
+		{
+			description: "it allows access not granted in the project",
+			profile:     newProfile(`[{"role_name": "ORG_OWNER"}]`),
+		},
+		{
+			description: "it allows access when the profile cannot be fetched",
+			profile: func() (*models.UserProfile, error) {
+				return nil, errors.New("oopsies")
+			},
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			baseCommand := &BaseCommand{Name: "test", UI: cli.NewMockUi()}
+
+			err := baseCommand.checkWriteAccess(&u.MockStitchClient{FetchUserProfileFn: tc.profile}, app)
+			if tc.expectedError == "" {
+				u.So(t, err, gc.ShouldBeNil)
+			} else {
+				u.So(t, err, gc.ShouldNotBeNil)
+				u.So(t, err.Error(), gc.ShouldEqual, tc.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	previewFlagPR           = "pr"
+	previewFlagBaseAppID    = "base-app-id"
+	previewFlagNameTemplate = "name-template"
+	previewFlagPath         = "path"
+	previewFlagSecretsFile  = "secrets-file"
+
+	// defaultPreviewNameTemplate names the preview app of a pull request after the base app
+	defaultPreviewNameTemplate = "{app}-pr-{pr}"
+)
+
+var errPreviewFlagsRequired = fmt.Errorf("the number of the pull request (--%s=[int]) and the App ID of the app to base its preview on (--%s=[string]) must be supplied", previewFlagPR, previewFlagBaseAppID)
+
+// previewCommand holds what creating and deleting the preview app of a pull request share: finding it by the name
+// derived from its base app
+type previewCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagProjectID    string
+	flagPR           int
+	flagBaseAppID    string
+	flagNameTemplate string
+}
+
+func (pc *previewCommand) newFlagSet() *flag.FlagSet {
+	flags := pc.NewFlagSet()
+
+	flags.StringVar(&pc.flagProjectID, flagProjectIDName, "", "")
+	flags.IntVar(&pc.flagPR, previewFlagPR, 0, "")
+	flags.StringVar(&pc.flagBaseAppID, previewFlagBaseAppID, "", "")
+	flags.StringVar(&pc.flagNameTemplate, previewFlagNameTemplate, defaultPreviewNameTemplate, "")
+	return flags
+}
+
+func (pc *previewCommand) previewHelp() string {
+	return `REQUIRED:
+  --` + previewFlagPR + ` [int]
+	The number of the pull request.
+
+  --` + previewFlagBaseAppID + ` [string]
+	The App ID of the app the preview is based on, e.g. that of staging. The preview app is kept in its project.
+
+OPTIONS:
+  --project-id [string]
+	Lookup the base app in this project, as opposed to the apps associated with the current user profile.
+
+  --` + previewFlagNameTemplate + ` [string]
+	The name of the preview app, in which {app} stands for the name of the base app and {pr} for the number of the pull request. Defaults to "` + defaultPreviewNameTemplate + `".`
+}
+
+// previewApp returns the base app, the name of the preview app and the preview app itself, which is nil if it
+// does not exist
+func (pc *previewCommand) previewApp(stitchClient api.StitchClient) (*models.App, string, *models.App, error) {
+	if pc.flagPR <= 0 || pc.flagBaseAppID == "" {
+		return nil, "", nil, errPreviewFlagsRequired
+	}
+
+	base, err := fetchApp(stitchClient, pc.flagProjectID, pc.flagBaseAppID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	name := strings.NewReplacer("{app}", base.Name, "{pr}", strconv.Itoa(pc.flagPR)).Replace(pc.flagNameTemplate)
+
+	apps, err := stitchClient.FetchAppsByGroupID(base.GroupID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, app := range apps {
+		if app.Name == name {
+			return base, name, app, nil
+		}
+	}
+
+	return base, name, nil, nil
+}
+
+func (pc *previewCommand) loggedInStitchClient() (api.StitchClient, error) {
+	user, err := pc.User()
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.LoggedIn() {
+		return nil, u.ErrNotLoggedIn
+	}
+
+	return pc.StitchClient()
+}
+
+// NewPreviewCreateCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewPreviewCreateCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &PreviewCreateCommand{
+			previewCommand: &previewCommand{
+				BaseCommand: &BaseCommand{
+					Name: "preview create",
+					UI:   ui,
+				},
+				workingDirectory: workingDirectory,
+			},
+		}, nil
+	}
+}
+
+// PreviewCreateCommand is used to deploy the app of a branch to the preview app of its pull request
+type PreviewCreateCommand struct {
+	*previewCommand
+
+	flagAppPath     string
+	flagSecretsFile string
+}
+
+// Synopsis returns a one-liner description for this command
+func (pcc *PreviewCreateCommand) Synopsis() string {
+	return "Deploy the local app to the preview app of a pull request."
+}
+
+// Help returns long-form help information for this command
+func (pcc *PreviewCreateCommand) Help() string {
+	return `Deploy the local app, e.g. as checked out from the branch of a pull request in CI, to the preview app of the pull request, and print the URLs it can be previewed at.
+The preview app is created like the base app, in its project and with its location and deployment model, unless it already exists. Its MongoDB Atlas services are linked to the clusters of the services of the same name in the base app.
+The local config and hosted assets replace those of the preview app.
+
+` + pcc.previewHelp() + `
+
+  --` + previewFlagPath + ` [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --` + previewFlagSecretsFile + ` [string]
+	A file with a "NAME=value" line for each secret the app refers to, which are created in the preview app if it does not have them.` +
+		pcc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (pcc *PreviewCreateCommand) Run(args []string) int {
+	flags := pcc.newFlagSet()
+
+	flags.StringVar(&pcc.flagAppPath, previewFlagPath, "", "")
+	flags.StringVar(&pcc.flagSecretsFile, previewFlagSecretsFile, "", "")
+
+	if err := pcc.BaseCommand.run(args); err != nil {
+		pcc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := pcc.create(); err != nil {
+		pcc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (pcc *PreviewCreateCommand) create() error {
+	stitchClient, err := pcc.loggedInStitchClient()
+	if err != nil {
+		return err
+	}
+
+	base, name, preview, err := pcc.previewApp(stitchClient)
+	if err != nil {
+		return err
+	}
+
+	appPath, err := pcc.resolveAppDirectory(pcc.flagAppPath, pcc.workingDirectory)
+	if err != nil {
+		if pcc.flagAppPath == "" {
+			return fmt.Errorf("the working directory is not within an app, so --%s must be given", previewFlagPath)
+		}
+		return err
+	}
+
+	created := preview == nil
+	if created {
+		location, deploymentModel := base.Location, base.DeploymentModel
+		if location == "" {
+			location = models.DefaultLocation
+		}
+		if deploymentModel == "" {
+			deploymentModel = models.DefaultDeploymentModel
+		}
+
+		if preview, err = stitchClient.CreateEmptyApp(base.GroupID, name, location, deploymentModel); err != nil {
+			return fmt.Errorf("failed to create the preview app '%s': %s", name, err)
+		}
+		pcc.Log().Info(fmt.Sprintf("Created the preview app '%s' (%s)", preview.Name, preview.ClientAppID))
+	} else {
+		pcc.Log().Info(fmt.Sprintf("Updating the preview app '%s' (%s)", preview.Name, preview.ClientAppID))
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-preview")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	clusters, err := pcc.baseClusters(base, filepath.Join(dir, base.ClientAppID))
+	if err != nil {
+		return err
+	}
+
+	previewPath := filepath.Join(dir, preview.ClientAppID)
+	if err := copyAppDirectory(appPath, previewPath, preview, func(service string) string { return clusters[service] }); err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(filepath.Join(appPath, utils.HostingFilesDirectory))
+	includeHosting := statErr == nil
+
+	ic := &ImportCommand{
+		BaseCommand:      pcc.BaseCommand,
+		workingDirectory: pcc.workingDirectory,
+		writeToDirectory: utils.WriteAppToDir,
+		writeAppConfigToFile: func(dest string, app models.AppInstanceData) error {
+			return app.MarshalFile(dest)
+		},
+		writeProjectConfig: func(dest string, config *models.ProjectConfig) error {
+			return config.Save(dest)
+		},
+		report:             newImportReport(),
+		flagAppID:          preview.ClientAppID,
+		flagAppPath:        previewPath,
+		flagGroupID:        preview.GroupID,
+		flagStrategy:       importStrategyReplace,
+		flagIncludeHosting: includeHosting,
+		flagSecretsFile:    pcc.flagSecretsFile,
+		flagRetryFile:      defaultHostingRetryFile,
+		flagOrdered:        created,
+	}
+
+	if err := ic.auditedImportApp(); err != nil {
+		return fmt.Errorf("failed to deploy to the preview app '%s': %s", preview.ClientAppID, err)
+	}
+
+	pcc.UI.Output(fmt.Sprintf("Preview of pull request #%d:", pcc.flagPR))
+	pcc.UI.Output(fmt.Sprintf("  App:     %s (%s)", preview.Name, preview.ClientAppID))
+	pcc.UI.Output(fmt.Sprintf("  Console: %s/groups/%s/apps/%s/dashboard", strings.TrimSuffix(pcc.flagBaseURL, "/"), preview.GroupID, preview.ID))
+	if includeHosting {
+		pcc.UI.Output(fmt.Sprintf("  Hosting: %s", hosting.AppURL(preview.ClientAppID, "")))
+	}
+	return nil
+}
+
+// baseClusters exports the base app to exportPath, returning the clusters its MongoDB Atlas services are linked
+// to, keyed by the name of the service
+func (pcc *PreviewCreateCommand) baseClusters(base *models.App, exportPath string) (map[string]string, error) {
+	// the working directory is usually within the local app, which nothing may be exported into
+	ec := &ExportCommand{
+		BaseCommand:          pcc.BaseCommand,
+		workingDirectory:     filepath.Dir(exportPath),
+		exportToDirectory:    utils.WriteAppToDir,
+		writeFileToDirectory: utils.WriteFileToDir,
+		getAssetAtURL:        getAssetAtURL,
+		flagProjectID:        base.GroupID,
+		flagAppID:            base.ClientAppID,
+		flagOutput:           exportPath,
+		flagConcurrency:      numWorkers,
+	}
+	if err := ec.run(); err != nil {
+		return nil, fmt.Errorf("failed to export the base app '%s': %s", base.ClientAppID, err)
+	}
+
+	baseApp, err := utils.UnmarshalFromDir(exportPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the export of the base app '%s': %s", base.ClientAppID, err)
+	}
+
+	return atlasServices(baseApp), nil
+}
+
+// NewPreviewDeleteCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewPreviewDeleteCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &PreviewDeleteCommand{
+			previewCommand: &previewCommand{
+				BaseCommand: &BaseCommand{
+					Name: "preview delete",
+					UI:   ui,
+				},
+				workingDirectory: workingDirectory,
+			},
+		}, nil
+	}
+}
+
+// PreviewDeleteCommand is used to tear down the preview app of a pull request
+type PreviewDeleteCommand struct {
+	*previewCommand
+
+	flagDeleteDatabaseUsers bool
+}
+
+// Synopsis returns a one-liner description for this command
+func (pdc *PreviewDeleteCommand) Synopsis() string {
+	return "Delete the preview app of a pull request."
+}
+
+// Help returns long-form help information for this command
+func (pdc *PreviewDeleteCommand) Help() string {
+	return `Delete the preview app of a pull request along with its hosted assets, e.g. once it is merged or closed, as teardown does.
+Nothing is done if the pull request has no preview app, so that it can be run whether or not one was created.
+
+` + pdc.previewHelp() + `
+
+  --` + teardownFlagDeleteDatabaseUsers + `
+	Also delete the Atlas database user that the preview app got for the clusters its MongoDB Atlas services are linked to.` +
+		pdc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (pdc *PreviewDeleteCommand) Run(args []string) int {
+	flags := pdc.newFlagSet()
+
+	flags.BoolVar(&pdc.flagDeleteDatabaseUsers, teardownFlagDeleteDatabaseUsers, false, "")
+
+	if err := pdc.BaseCommand.run(args); err != nil {
+		pdc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := pdc.delete(); err != nil {
+		pdc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (pdc *PreviewDeleteCommand) delete() error {
+	stitchClient, err := pdc.loggedInStitchClient()
+	if err != nil {
+		return err
+	}
+
+	_, name, preview, err := pdc.previewApp(stitchClient)
+	if err != nil {
+		return err
+	}
+
+	if preview == nil {
+		pdc.Log().Info(fmt.Sprintf("Pull request #%d has no preview app named '%s', nothing to delete", pdc.flagPR, name))
+		return nil
+	}
+
+	tc := &TeardownCommand{
+		BaseCommand:             pdc.BaseCommand,
+		workingDirectory:        pdc.workingDirectory,
+		flagProjectID:           preview.GroupID,
+		flagAppID:               preview.ClientAppID,
+		flagDeleteDatabaseUsers: pdc.flagDeleteDatabaseUsers,
+	}
+	return tc.auditedTeardown()
+}
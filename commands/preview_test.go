@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestPreviewCommands(t *testing.T) {
+	appDir, err := ioutil.TempDir("", "stitch-preview-branch")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(appDir)
+
+	for name, data := range map[string]string{
+		"stitch.json":                        `{"app_id": "shop-staging-abcde", "name": "shop-staging"}`,
+		"services/mongodb-atlas/config.json": `{"name": "mongodb-atlas", "type": "mongodb-atlas", "config": {"clusterName": "Local"}}`,
+		"values/banner.json":                 `{"name": "banner", "value": "new checkout"}`,
+	} {
+		path := filepath.Join(appDir, name)
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	base := &models.App{GroupID: "group-id", ID: "staging-id", ClientAppID: "shop-staging-abcde", Name: "shop-staging", Location: "IE", DeploymentModel: "LOCAL"}
+	baseExport := newAppZip(t, map[string]string{
+		"stitch.json":                        `{"app_id": "shop-staging-abcde", "name": "shop-staging"}`,
+		"services/mongodb-atlas/config.json": `{"name": "mongodb-atlas", "type": "mongodb-atlas", "config": {"clusterName": "Staging"}}`,
+	})
+
+	type previewCalls struct {
+		created     []string
+		imports     []map[string]interface{}
+		deletedApps []string
+	}
+
+	newStitchClient := func(calls *previewCalls, apps ...*models.App) *u.MockStitchClient {
+		preview := &models.App{GroupID: "group-id", ID: "preview-id", ClientAppID: "shop-staging-pr-42-fghij", Name: "shop-staging-pr-42"}
+		findApp := func(clientAppID string) (*models.App, error) {
+			if clientAppID == preview.ClientAppID {
+				return preview, nil
+			}
+			return base, nil
+		}
+
+		return &u.MockStitchClient{
+			FetchAppByClientAppIDFn: findApp,
+			FetchAppByGroupIDAndClientAppIDFn: func(groupID, clientAppID string) (*models.App, error) {
+				return findApp(clientAppID)
+			},
+			FetchAppsByGroupIDFn: func(groupID string) ([]*models.App, error) {
+				return append([]*models.App{base}, apps...), nil
+			},
+			CreateEmptyAppFn: func(groupID, appName, location, deploymentModel string) (*models.App, error) {
+				calls.created = append(calls.created, groupID+"/"+appName+"/"+location+"/"+deploymentModel)
+				return preview, nil
+			},
+			ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+				return appID + ".zip", u.NewResponseBody(bytes.NewReader(baseExport)), nil
+			},
+			DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+				return []string{"values/banner: added"}, nil
+			},
+			ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+				var imported map[string]interface{}
+				if err := json.Unmarshal(appData, &imported); err != nil {
+					return err
+				}
+				calls.imports = append(calls.imports, imported)
+				return nil
+			},
+			ListAssetsForAppIDFn: func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+				return nil, nil
+			},
+			DeleteAppFn: func(groupID, appID string) error {
+				calls.deletedApps = append(calls.deletedApps, appID)
+				return nil
+			},
+		}
+	}
+
+	setupCreate := func(stitchClient *u.MockStitchClient) (*PreviewCreateCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewPreviewCreateCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		previewCreateCommand := cmd.(*PreviewCreateCommand)
+		previewCreateCommand.workingDirectory = appDir
+		previewCreateCommand.storage = u.NewEmptyStorage()
+		previewCreateCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		previewCreateCommand.stitchClient = stitchClient
+		return previewCreateCommand, mockUI
+	}
+
+	setupDelete := func(stitchClient *u.MockStitchClient) (*PreviewDeleteCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewPreviewDeleteCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		previewDeleteCommand := cmd.(*PreviewDeleteCommand)
+		previewDeleteCommand.workingDirectory = appDir
+		previewDeleteCommand.storage = u.NewEmptyStorage()
+		previewDeleteCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		previewDeleteCommand.stitchClient = stitchClient
+		return previewDeleteCommand, mockUI
+	}
+
+	t.Run("creating a preview requires the pull request and base app", func(t *testing.T) {
+		previewCreateCommand, mockUI := setupCreate(newStitchClient(&previewCalls{}))
+		exitCode := previewCreateCommand.Run([]string{"--base-app-id=shop-staging-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errPreviewFlagsRequired.Error())
+	})
+
+	t.Run("creating a preview creates an app like the base app and deploys the local app to it", func(t *testing.T) {
+		calls := &previewCalls{}
+		previewCreateCommand, mockUI := setupCreate(newStitchClient(calls))
+
+		exitCode := previewCreateCommand.Run([]string{"--pr=42", "--base-app-id=shop-staging-abcde", "--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, calls.created, gc.ShouldResemble, []string{"group-id/shop-staging-pr-42/IE/LOCAL"})
+
+		u.So(t, calls.imports, gc.ShouldNotBeEmpty)
+		imported := calls.imports[len(calls.imports)-1]
+		u.So(t, imported[models.AppIDField], gc.ShouldEqual, "shop-staging-pr-42-fghij")
+		u.So(t, imported[models.AppNameField], gc.ShouldEqual, "shop-staging-pr-42")
+
+		// the Atlas services are linked to the clusters of the base app
+		services := imported["services"].([]interface{})
+		config := services[0].(map[string]interface{})["config"].(map[string]interface{})
+		u.So(t, config["config"], gc.ShouldResemble, map[string]interface{}{"clusterName": "Staging"})
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Preview of pull request #42:")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "App:     shop-staging-pr-42 (shop-staging-pr-42-fghij)")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Console: https://stitch.mongodb.com/groups/group-id/apps/preview-id/dashboard")
+	})
+
+	t.Run("creating a preview that exists updates it", func(t *testing.T) {
+		calls := &previewCalls{}
+		existing := &models.App{GroupID: "group-id", ID: "preview-id", ClientAppID: "shop-staging-pr-42-fghij", Name: "shop-staging-pr-42"}
+		previewCreateCommand, mockUI := setupCreate(newStitchClient(calls, existing))
+
+		exitCode := previewCreateCommand.Run([]string{"--pr=42", "--base-app-id=shop-staging-abcde", "--path=" + appDir, "--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Updating the preview app 'shop-staging-pr-42'")
+		u.So(t, calls.created, gc.ShouldBeEmpty)
+		u.So(t, calls.imports, gc.ShouldHaveLength, 1)
+	})
+
+	t.Run("the name of the preview follows the --name-template", func(t *testing.T) {
+		calls := &previewCalls{}
+		previewCreateCommand, _ := setupCreate(newStitchClient(calls))
+
+		exitCode := previewCreateCommand.Run([]string{"--pr=42", "--base-app-id=shop-staging-abcde", "--name-template=pr{pr}-{app}", "--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, calls.created, gc.ShouldResemble, []string{"group-id/pr42-shop-staging/IE/LOCAL"})
+	})
+
+	t.Run("deleting a preview that does not exist does nothing", func(t *testing.T) {
+		calls := &previewCalls{}
+		previewDeleteCommand, mockUI := setupDelete(newStitchClient(calls))
+
+		exitCode := previewDeleteCommand.Run([]string{"--pr=42", "--base-app-id=shop-staging-abcde", "--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Pull request #42 has no preview app named 'shop-staging-pr-42', nothing to delete")
+		u.So(t, calls.deletedApps, gc.ShouldBeEmpty)
+	})
+
+	t.Run("deleting a preview tears it down", func(t *testing.T) {
+		calls := &previewCalls{}
+		existing := &models.App{GroupID: "group-id", ID: "preview-id", ClientAppID: "shop-staging-pr-42-fghij", Name: "shop-staging-pr-42"}
+		previewDeleteCommand, mockUI := setupDelete(newStitchClient(calls, existing))
+
+		exitCode := previewDeleteCommand.Run([]string{"--pr=42", "--base-app-id=shop-staging-abcde", "--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully tore down 'shop-staging-pr-42-fghij'")
+		u.So(t, calls.deletedApps, gc.ShouldResemble, []string{"preview-id"})
+	})
+
+	t.Run("creating and deleting a preview are recorded in the audit log", func(t *testing.T) {
+		logDir, err := ioutil.TempDir("", "stitch-preview-audit")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(logDir)
+		logPath := filepath.Join(logDir, "audit.jsonl")
+
+		existing := &models.App{GroupID: "group-id", ID: "preview-id", ClientAppID: "shop-staging-pr-42-fghij", Name: "shop-staging-pr-42"}
+		previewCreateCommand, _ := setupCreate(newStitchClient(&previewCalls{}, existing))
+		exitCode := previewCreateCommand.Run([]string{"--pr=42", "--base-app-id=shop-staging-abcde", "--path=" + appDir, "--yes", "--audit-log=" + logPath})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		previewDeleteCommand, _ := setupDelete(newStitchClient(&previewCalls{}, existing))
+		exitCode = previewDeleteCommand.Run([]string{"--pr=42", "--base-app-id=shop-staging-abcde", "--yes", "--audit-log=" + logPath})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		contents, err := ioutil.ReadFile(logPath)
+		u.So(t, err, gc.ShouldBeNil)
+
+		var records []logging.AuditRecord
+		for _, line := range strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n") {
+			var record logging.AuditRecord
+			u.So(t, json.Unmarshal([]byte(line), &record), gc.ShouldBeNil)
+			records = append(records, record)
+		}
+		u.So(t, records, gc.ShouldHaveLength, 2)
+		u.So(t, records[0].Command, gc.ShouldEqual, "preview create")
+		u.So(t, records[0].ClientAppID, gc.ShouldEqual, "shop-staging-pr-42-fghij")
+		u.So(t, records[0].Result, gc.ShouldEqual, logging.AuditResultSuccess)
+		u.So(t, records[1].Command, gc.ShouldEqual, "preview delete")
+		u.So(t, records[1].ClientAppID, gc.ShouldEqual, "shop-staging-pr-42-fghij")
+		u.So(t, records[1].Result, gc.ShouldEqual, logging.AuditResultSuccess)
+	})
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	promoteFlagFrom    = "from"
+	promoteFlagTo      = "to"
+	promoteFlagInclude = "include"
+
+	promoteIncludeHosting = "hosting"
+)
+
+var errPromoteAppIDsRequired = fmt.Errorf("the App IDs of the app to promote from (--%s=[string]) and to (--%s=[string]) must be supplied", promoteFlagFrom, promoteFlagTo)
+
+// NewPromoteCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewPromoteCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &PromoteCommand{
+			BaseCommand: &BaseCommand{
+				Name: "promote",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// PromoteCommand is used to copy the configuration of one deployed app to another
+type PromoteCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	// report records the import into the target app
+	report *importReport
+
+	flagProjectID string
+	flagFrom      string
+	flagTo        string
+	flagInclude   string
+}
+
+// Synopsis returns a one-liner description for this command
+func (pc *PromoteCommand) Synopsis() string {
+	return "Copy the configuration of one deployed app to another."
+}
+
+// Help returns long-form help information for this command
+func (pc *PromoteCommand) Help() string {
+	return `Copy the configuration of one deployed app to another, e.g. from staging to production, by exporting the first and importing it into the second with the replace strategy.
+The changes to the second app are shown and must be confirmed before they are made. The second app keeps its own name and App ID.
+
+REQUIRED:
+  --` + promoteFlagFrom + ` [string]
+	The App ID of the app to promote (i.e. the name of your app followed by a unique suffix, like "my-app-staging-nysja").
+
+  --` + promoteFlagTo + ` [string]
+	The App ID of the app to promote to. It must already exist.
+
+OPTIONS:
+  --project-id [string]
+	Lookup both apps in this project, as opposed to the apps associated with the current user profile.
+
+  --` + promoteFlagInclude + ` [string]
+	Also copy the given parts of the app, separated by commas. Accepted values are [` + promoteIncludeHosting + `], which copies the hosted assets and hosting settings.` +
+		pc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (pc *PromoteCommand) Run(args []string) int {
+	flags := pc.NewFlagSet()
+
+	flags.StringVar(&pc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&pc.flagFrom, promoteFlagFrom, "", "")
+	flags.StringVar(&pc.flagTo, promoteFlagTo, "", "")
+	flags.StringVar(&pc.flagInclude, promoteFlagInclude, "", "")
+
+	if err := pc.BaseCommand.run(args); err != nil {
+		pc.Log().Error(err.Error())
+		return 1
+	}
+
+	pc.report = newImportReport()
+	err := pc.promote()
+	pc.report.finish(err)
+	if pc.report.changed() {
+		pc.audit(pc.flagTo, pc.report.DeploymentID, err)
+	}
+	if err != nil {
+		pc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (pc *PromoteCommand) promote() error {
+	if pc.flagFrom == "" || pc.flagTo == "" {
+		return errPromoteAppIDsRequired
+	}
+
+	if pc.flagFrom == pc.flagTo {
+		return fmt.Errorf("cannot promote '%s' to itself", pc.flagFrom)
+	}
+
+	includeHosting, err := pc.includeHosting()
+	if err != nil {
+		return err
+	}
+
+	user, err := pc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := pc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	target, err := fetchApp(stitchClient, pc.flagProjectID, pc.flagTo)
+	if err != nil {
+		return fmt.Errorf("cannot promote to '%s': %s", pc.flagTo, err)
+	}
+
+	if err := pc.checkWriteAccess(stitchClient, target); err != nil {
+		return err
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-promote")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	appPath := filepath.Join(dir, pc.flagFrom)
+
+	pc.Log().Info(fmt.Sprintf("Exporting '%s'...", pc.flagFrom))
+	ec := &ExportCommand{
+		BaseCommand:          pc.BaseCommand,
+		workingDirectory:     pc.workingDirectory,
+		exportToDirectory:    utils.WriteAppToDir,
+		writeFileToDirectory: utils.WriteFileToDir,
+		getAssetAtURL:        getAssetAtURL,
+		flagProjectID:        pc.flagProjectID,
+		flagAppID:            pc.flagFrom,
+		flagOutput:           appPath,
+		flagIncludeHosting:   includeHosting,
+		flagConcurrency:      numWorkers,
+	}
+	if err := ec.run(); err != nil {
+		return fmt.Errorf("failed to export '%s': %s", pc.flagFrom, err)
+	}
+
+	// the exported config names the source app, which the target must not be renamed to
+	appInstanceData := models.AppInstanceData{}
+	if err := appInstanceData.UnmarshalFile(appPath); err != nil {
+		return fmt.Errorf("failed to read the export of '%s': %s", pc.flagFrom, err)
+	}
+	appInstanceData[models.AppIDField] = target.ClientAppID
+	appInstanceData[models.AppNameField] = target.Name
+	if err := appInstanceData.MarshalFile(appPath); err != nil {
+		return err
+	}
+
+	ic := &ImportCommand{
+		BaseCommand:      pc.BaseCommand,
+		workingDirectory: pc.workingDirectory,
+		writeToDirectory: utils.WriteAppToDir,
+		writeAppConfigToFile: func(dest string, app models.AppInstanceData) error {
+			return app.MarshalFile(dest)
+		},
+		writeProjectConfig: func(dest string, config *models.ProjectConfig) error {
+			return config.Save(dest)
+		},
+		report:             pc.report,
+		flagAppID:          target.ClientAppID,
+		flagAppPath:        appPath,
+		flagGroupID:        target.GroupID,
+		flagStrategy:       importStrategyReplace,
+		flagIncludeHosting: includeHosting,
+		flagRetryFile:      defaultHostingRetryFile,
+	}
+
+	pc.Log().Info(fmt.Sprintf("Promoting '%s' to '%s'...", pc.flagFrom, pc.flagTo))
+	return ic.importApp()
+}
+
+// includeHosting parses --include, returning whether hosting is to be promoted too
+func (pc *PromoteCommand) includeHosting() (bool, error) {
+	var includeHosting bool
+	for _, part := range strings.Split(pc.flagInclude, ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case promoteIncludeHosting:
+			includeHosting = true
+		default:
+			return false, fmt.Errorf("unknown --%s value %q; accepted values are [%s]", promoteFlagInclude, part, promoteIncludeHosting)
+		}
+	}
+
+	return includeHosting, nil
+}
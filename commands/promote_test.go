@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestPromoteCommand(t *testing.T) {
+	setup := func() (*PromoteCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewPromoteCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		promoteCommand := cmd.(*PromoteCommand)
+		promoteCommand.storage = u.NewEmptyStorage()
+		promoteCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+		return promoteCommand, mockUI
+	}
+
+	staging := newAppZip(t, map[string]string{
+		"stitch.json":       `{"app_id": "staging-abcde", "name": "staging", "location": "US-VA"}`,
+		"values/limit.json": `{"name": "limit", "value": 10}`,
+	})
+
+	type importCall struct {
+		appID    string
+		strategy string
+		app      map[string]interface{}
+	}
+
+	newStitchClient := func(imports *[]importCall) *u.MockStitchClient {
+		fetchApp := func(clientAppID string) (*models.App, error) {
+			if clientAppID == "missing-abcde" {
+				return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
+			}
+			name := strings.Split(clientAppID, "-")[0]
+			return &models.App{GroupID: "group-id", ID: clientAppID + "-id", ClientAppID: clientAppID, Name: name}, nil
+		}
+
+		return &u.MockStitchClient{
+			FetchAppByClientAppIDFn: fetchApp,
+			FetchAppByGroupIDAndClientAppIDFn: func(groupID, clientAppID string) (*models.App, error) {
+				return fetchApp(clientAppID)
+			},
+			ExportFn: func(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
+				return appID + ".zip", u.NewResponseBody(bytes.NewReader(staging)), nil
+			},
+			DiffFn: func(groupID, appID string, appData []byte, strategy string) ([]string, error) {
+				return []string{"values/limit: value changed"}, nil
+			},
+			ImportFn: func(groupID, appID string, appData []byte, strategy string) error {
+				var app map[string]interface{}
+				if err := json.Unmarshal(appData, &app); err != nil {
+					return err
+				}
+				*imports = append(*imports, importCall{appID, strategy, app})
+				return nil
+			},
+		}
+	}
+
+	t.Run("it requires both app IDs", func(t *testing.T) {
+		promoteCommand, mockUI := setup()
+		exitCode := promoteCommand.Run([]string{"--from=staging-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errPromoteAppIDsRequired.Error())
+	})
+
+	t.Run("it rejects unknown --include values", func(t *testing.T) {
+		promoteCommand, mockUI := setup()
+		exitCode := promoteCommand.Run([]string{"--from=staging-abcde", "--to=prod-fghij", "--include=functions"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `unknown --include value "functions"`)
+	})
+
+	t.Run("it does not create the target app", func(t *testing.T) {
+		var imports []importCall
+		promoteCommand, mockUI := setup()
+		promoteCommand.stitchClient = newStitchClient(&imports)
+
+		exitCode := promoteCommand.Run([]string{"--from=staging-abcde", "--to=missing-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "cannot promote to 'missing-abcde'")
+		u.So(t, imports, gc.ShouldBeEmpty)
+	})
+
+	t.Run("it imports the source app into the target once confirmed", func(t *testing.T) {
+		var imports []importCall
+		promoteCommand, mockUI := setup()
+		promoteCommand.stitchClient = newStitchClient(&imports)
+		mockUI.InputReader = strings.NewReader("y\n")
+
+		exitCode := promoteCommand.Run([]string{"--from=staging-abcde", "--to=prod-fghij"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "values/limit: value changed")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully imported 'prod-fghij'")
+
+		u.So(t, imports, gc.ShouldHaveLength, 1)
+		u.So(t, imports[0].appID, gc.ShouldEqual, "prod-fghij-id")
+		u.So(t, imports[0].strategy, gc.ShouldEqual, importStrategyReplace)
+		u.So(t, imports[0].app[models.AppIDField], gc.ShouldEqual, "prod-fghij")
+		u.So(t, imports[0].app[models.AppNameField], gc.ShouldEqual, "prod")
+
+		// the replacing import keeps the deploy lock it holds on the target
+		values := imports[0].app["values"].([]interface{})
+		u.So(t, values, gc.ShouldHaveLength, 2)
+		u.So(t, values[1].(map[string]interface{})["name"], gc.ShouldEqual, utils.DeployLockValueName)
+	})
+
+	t.Run("it waits for a deployment the target imports in the background", func(t *testing.T) {
+		defer func(interval time.Duration) { api.ImportPollInterval = interval }(api.ImportPollInterval)
+		api.ImportPollInterval = time.Millisecond
+
+		polls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(models.Deployment{ID: "deployment-id", Status: models.DeploymentStatusCreated})
+				return
+			}
+
+			polls++
+			status := models.DeploymentStatusPending
+			if polls == 2 {
+				status = models.DeploymentStatusSuccessful
+			}
+			json.NewEncoder(w).Encode(models.Deployment{ID: "deployment-id", Status: status})
+		}))
+		defer testServer.Close()
+
+		var imports []importCall
+		stitchClient := newStitchClient(&imports)
+		deployClient := api.NewStitchClient(api.NewClient(testServer.URL))
+		stitchClient.ImportWithProgressFn = func(groupID, appID string, appData []byte, strategy string, timeout time.Duration, progress func(*models.Deployment)) error {
+			if appID != "prod-fghij-id" {
+				return stitchClient.Import(groupID, appID, appData, strategy)
+			}
+			return deployClient.ImportWithProgress(groupID, appID, appData, strategy, timeout, progress)
+		}
+
+		promoteCommand, mockUI := setup()
+		promoteCommand.stitchClient = stitchClient
+		mockUI.InputReader = strings.NewReader("y\n")
+
+		exitCode := promoteCommand.Run([]string{"--from=staging-abcde", "--to=prod-fghij"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully imported 'prod-fghij'")
+		u.So(t, polls, gc.ShouldEqual, 2)
+	})
+
+	t.Run("it does not import when the changes are declined", func(t *testing.T) {
+		var imports []importCall
+		promoteCommand, mockUI := setup()
+		promoteCommand.stitchClient = newStitchClient(&imports)
+		mockUI.InputReader = strings.NewReader("n\n")
+
+		dir, err := ioutil.TempDir("", "stitch-promote-audit")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+		auditPath := filepath.Join(dir, "audit.jsonl")
+
+		exitCode := promoteCommand.Run([]string{"--from=staging-abcde", "--to=prod-fghij", "--audit-log=" + auditPath})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, imports, gc.ShouldBeEmpty)
+
+		// nothing was promoted, so nothing is audited
+		_, err = os.Stat(auditPath)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+	})
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/selector"
+
+	"github.com/mattn/go-isatty"
+)
+
+// canSelectInteractively reports whether stdin and stdout are a terminal that can display a selector
+func canSelectInteractively() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd()) && selector.Supported(int(os.Stdin.Fd()))
+}
+
+// selectInTerminal displays an interactive selector using stdin and stdout
+func selectInTerminal(prompt string, options []selector.Option) (selector.Option, error) {
+	restore, err := selector.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return selector.Option{}, err
+	}
+	defer restore()
+
+	return selector.Select(os.Stdin, os.Stdout, prompt, options)
+}
+
+// project is an Atlas project along with the name of the organization it belongs to, if known
+type project struct {
+	mdbcloud.Group
+	OrgName string
+}
+
+// String describes the project as it is displayed in prompts
+func (p project) String() string {
+	if p.OrgName == "" {
+		return fmt.Sprintf("%s - %s", p.Name, p.ID)
+	}
+	return fmt.Sprintf("%s / %s - %s", p.OrgName, p.Name, p.ID)
+}
+
+// listProjects returns the Atlas projects available to the user grouped by organization. If org is set,
+// only the projects in the organization with that name or ID are returned
+func (c *BaseCommand) listProjects(org string) ([]project, error) {
+	atlasClient, err := c.AtlasClient()
+	if err != nil {
+		return nil, fmt.Errorf("an unexpected error occurred: %s", err)
+	}
+
+	groups, err := atlasClient.Groups()
+	if err != nil {
+		return nil, err
+	}
+
+	orgNames := map[string]string{}
+	orgs, err := atlasClient.Orgs()
+	if err != nil {
+		// organizations only help to tell projects apart, so they are not needed unless filtering by one
+		if org != "" {
+			return nil, err
+		}
+	}
+	for _, o := range orgs {
+		orgNames[o.ID] = o.Name
+	}
+
+	projects := make([]project, 0, len(groups))
+	for _, group := range groups {
+		p := project{group, orgNames[group.OrgID]}
+		if org != "" && org != p.OrgID && org != p.OrgName {
+			continue
+		}
+		projects = append(projects, p)
+	}
+
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projects[i].OrgName < projects[j].OrgName
+	})
+
+	if len(projects) == 0 {
+		if org != "" {
+			return nil, fmt.Errorf("no available Projects in Organization %q", org)
+		}
+		return nil, errors.New("no available Projects")
+	}
+
+	return projects, nil
+}
+
+// selectProject prompts the user to choose one of the Atlas projects available to them, returning its ID.
+// The project with ID defaultGroupID, if any, is listed first, and only projects in org are listed if it is set.
+// It must only be called when c.selectOption is set
+func (c *BaseCommand) selectProject(defaultGroupID, org string) (string, error) {
+	projects, err := c.listProjects(org)
+	if err != nil {
+		return "", err
+	}
+
+	options := make([]selector.Option, 0, len(projects))
+	for _, p := range projects {
+		option := selector.Option{Label: p.String(), Value: p.ID}
+		if p.ID == defaultGroupID {
+			options = append([]selector.Option{option}, options...)
+			continue
+		}
+		options = append(options, option)
+	}
+
+	selected, err := c.selectOption("Atlas Project", options)
+	if err != nil {
+		return "", err
+	}
+
+	return selected.Value, nil
+}
+
+// selectApp prompts the user to choose one of the apps in the project with the given ID, or in a
+// project in org they choose if groupID is empty. It must only be called when c.selectOption is set
+func (c *BaseCommand) selectApp(groupID, org string, stitchClient api.StitchClient) (*models.App, error) {
+	if groupID == "" {
+		selectedGroupID, err := c.selectProject("", org)
+		if err != nil {
+			return nil, err
+		}
+		groupID = selectedGroupID
+	}
+
+	apps, err := stitchClient.FetchAppsByGroupID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("no apps found in project %s", groupID)
+	}
+
+	options := make([]selector.Option, len(apps))
+	for i, app := range apps {
+		options[i] = selector.Option{Label: fmt.Sprintf("%s (%s)", app.ClientAppID, app.Name), Value: app.ClientAppID}
+	}
+
+	selected, err := c.selectOption("App", options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, app := range apps {
+		if app.ClientAppID == selected.Value {
+			return app, nil
+		}
+	}
+
+	return nil, api.ErrAppNotFound{ClientAppID: selected.Value}
+}
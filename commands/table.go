@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/10gen/stitch-cli/selector"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	// tableColumnGap separates the columns of a table
+	tableColumnGap = "  "
+
+	// minTruncatedColumnWidth is the narrowest a column is truncated to so that a table fits the terminal
+	minTruncatedColumnWidth = 8
+
+	// truncationMarker ends a value that was truncated to fit its column
+	truncationMarker = "…"
+)
+
+// The directions rows can be sorted in by --sort
+const (
+	sortAscending  = "asc"
+	sortDescending = "desc"
+)
+
+// stdoutWidth returns the width of the terminal stdout is written to, or 0 if it is not a terminal
+func stdoutWidth() int {
+	if !isatty.IsTerminal(os.Stdout.Fd()) {
+		return 0
+	}
+	return selector.Width(int(os.Stdout.Fd()))
+}
+
+// renderTable lays out the rows under a header of the column names, aligning each column. When maxWidth is
+// positive, the widest columns are truncated until each line fits in it, as far as they can be
+func renderTable(columns []string, rows [][]string, maxWidth int) string {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	lines := append([][]string{header}, rows...)
+
+	widths := make([]int, len(columns))
+	for _, line := range lines {
+		for i, value := range line {
+			if width := utf8.RuneCountInString(value); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	if maxWidth > 0 {
+		fitColumns(widths, maxWidth)
+	}
+
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		cells := make([]string, len(line))
+		for j, value := range line {
+			value = truncate(value, widths[j])
+			if j < len(line)-1 {
+				value += strings.Repeat(" ", widths[j]-utf8.RuneCountInString(value))
+			}
+			cells[j] = value
+		}
+		rendered[i] = strings.TrimRight(strings.Join(cells, tableColumnGap), " ")
+	}
+
+	return strings.Join(rendered, "\n")
+}
+
+// fitColumns narrows the widest of the column widths, one character at a time, until the columns and the gaps
+// between them fit in maxWidth or every column is at minTruncatedColumnWidth
+func fitColumns(widths []int, maxWidth int) {
+	total := len(tableColumnGap) * (len(widths) - 1)
+	for _, width := range widths {
+		total += width
+	}
+
+	for total > maxWidth {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+
+		if widths[widest] <= minTruncatedColumnWidth {
+			return
+		}
+
+		widths[widest]--
+		total--
+	}
+}
+
+// truncate shortens s to width characters, ending it with truncationMarker if it was cut
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + truncationMarker
+}
+
+// sortRows sorts the rows, which hold a value for each of columns, by the column named in spec:
+// "<column>[:asc|desc]". A column whose values are all numbers is sorted numerically
+func sortRows(columns []string, rows [][]string, spec string) error {
+	name, direction := spec, sortAscending
+	if idx := strings.LastIndex(spec, ":"); idx != -1 {
+		name, direction = spec[:idx], spec[idx+1:]
+	}
+
+	if direction != sortAscending && direction != sortDescending {
+		return fmt.Errorf("invalid --%s %q: the direction must be %s or %s", listFlagSort, spec, sortAscending, sortDescending)
+	}
+
+	column := columnIndex(columns, name)
+	if column == -1 {
+		return fmt.Errorf("unknown column %q for --%s, expected one of: %s", name, listFlagSort, strings.Join(columns, ", "))
+	}
+
+	numeric := true
+	numbers := make([]float64, len(rows))
+	for i, row := range rows {
+		number, err := strconv.ParseFloat(row[column], 64)
+		if err != nil {
+			numeric = false
+			break
+		}
+		numbers[i] = number
+	}
+
+	less := func(i, j int) bool {
+		if numeric {
+			return numbers[i] < numbers[j]
+		}
+		return rows[i][column] < rows[j][column]
+	}
+
+	indexes := make([]int, len(rows))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		if direction == sortDescending {
+			return less(indexes[b], indexes[a])
+		}
+		return less(indexes[a], indexes[b])
+	})
+
+	sorted := make([][]string, len(rows))
+	for i, idx := range indexes {
+		sorted[i] = rows[idx]
+	}
+	copy(rows, sorted)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"testing"
+
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenderTable(t *testing.T) {
+	columns := []string{"path", "size"}
+	rows := [][]string{
+		{"/static/js/a-very-long-bundle-name.js", "10"},
+		{"/index.html", "2"},
+	}
+
+	t.Run("it aligns each column under its header", func(t *testing.T) {
+		u.So(t, renderTable(columns, rows, 0), gc.ShouldEqual, `PATH                                   SIZE
+/static/js/a-very-long-bundle-name.js  10
+/index.html                            2`)
+	})
+
+	t.Run("it truncates the widest column to fit the width", func(t *testing.T) {
+		u.So(t, renderTable(columns, rows, 20), gc.ShouldEqual, `PATH            SIZE
+/static/js/a-…  10
+/index.html     2`)
+	})
+
+	t.Run("it does not truncate columns below a minimum width", func(t *testing.T) {
+		u.So(t, renderTable(columns, rows, 5), gc.ShouldEqual, `PATH      SIZE
+/static…  10
+/index.…  2`)
+	})
+}
+
+func TestSortRows(t *testing.T) {
+	columns := []string{"name", "size"}
+	newRows := func() [][]string {
+		return [][]string{{"b", "10"}, {"a", "9"}, {"c", "100"}}
+	}
+
+	for _, tc := range []struct {
+		description string
+		spec        string
+		expected    [][]string
+	}{
+		{"it sorts by a column in ascending order", "name", [][]string{{"a", "9"}, {"b", "10"}, {"c", "100"}}},
+		{"it sorts by a column in descending order", "name:desc", [][]string{{"c", "100"}, {"b", "10"}, {"a", "9"}}},
+		{"it sorts numbers numerically", "size:asc", [][]string{{"a", "9"}, {"b", "10"}, {"c", "100"}}},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			rows := newRows()
+			u.So(t, sortRows(columns, rows, tc.spec), gc.ShouldBeNil)
+			u.So(t, rows, gc.ShouldResemble, tc.expected)
+		})
+	}
+
+	t.Run("it fails for an unknown column or direction", func(t *testing.T) {
+		u.So(t, sortRows(columns, newRows(), "id"), gc.ShouldNotBeNil)
+		u.So(t, sortRows(columns, newRows(), "size:up"), gc.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+
+	"github.com/mitchellh/cli"
+)
+
+const teardownFlagDeleteDatabaseUsers = "delete-database-users"
+
+// atlasDatabaseUserPrefix starts the name of the database user that an app gets for the Atlas clusters its
+// MongoDB Atlas services are linked to, which is followed by its Client App ID
+const atlasDatabaseUserPrefix = "mongodb-stitch-"
+
+var errTeardownAppIDRequired = fmt.Errorf("an App ID (--%s=[string]) must be supplied to tear down an app", flagAppIDName)
+
+// NewTeardownCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewTeardownCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &TeardownCommand{
+			BaseCommand: &BaseCommand{
+				Name: "teardown",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// TeardownCommand is used to delete an app along with everything created for it
+type TeardownCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagProjectID           string
+	flagAppID               string
+	flagDeleteDatabaseUsers bool
+}
+
+// Synopsis returns a one-liner description for this command
+func (tc *TeardownCommand) Synopsis() string {
+	return "Delete an app along with its hosted assets."
+}
+
+// Help returns long-form help information for this command
+func (tc *TeardownCommand) Help() string {
+	return `Delete an app along with its hosted assets, e.g. an ephemeral environment set up by bootstrap for a pull request.
+The deletion must be confirmed unless --yes is given, and cannot be undone. The bootstrap checkpoint of the app in the working directory, if any, is removed too.
+
+REQUIRED:
+  --app-id [string]
+	The App ID of the app to delete (i.e. the name of your app followed by a unique suffix, like "my-app-pr-123-nysja").
+
+OPTIONS:
+  --project-id [string]
+	Lookup the app in this project, as opposed to the apps associated with the current user profile.
+
+  --` + teardownFlagDeleteDatabaseUsers + `
+	Also delete the Atlas database user that the app got for the clusters its MongoDB Atlas services are linked to.` +
+		tc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (tc *TeardownCommand) Run(args []string) int {
+	flags := tc.NewFlagSet()
+
+	flags.StringVar(&tc.flagProjectID, flagProjectIDName, "", "")
+	flags.StringVar(&tc.flagAppID, flagAppIDName, "", "")
+	flags.BoolVar(&tc.flagDeleteDatabaseUsers, teardownFlagDeleteDatabaseUsers, false, "")
+
+	if err := tc.BaseCommand.run(args); err != nil {
+		tc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := tc.auditedTeardown(); err != nil {
+		tc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// auditedTeardown tears the app down, recording the deletion in the audit log
+func (tc *TeardownCommand) auditedTeardown() error {
+	err := tc.teardown()
+	tc.audit(tc.flagAppID, "", err)
+	return err
+}
+
+func (tc *TeardownCommand) teardown() error {
+	if tc.flagAppID == "" {
+		return errTeardownAppIDRequired
+	}
+
+	user, err := tc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := tc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	app, err := fetchApp(stitchClient, tc.flagProjectID, tc.flagAppID)
+	if err != nil {
+		return err
+	}
+
+	if err := tc.checkWriteAccess(stitchClient, app); err != nil {
+		return err
+	}
+
+	confirm, err := tc.AskYesNo(fmt.Sprintf("Delete '%s' (%s) along with its hosted assets? This cannot be undone", app.Name, app.ClientAppID))
+	if err != nil {
+		return err
+	}
+	if !confirm {
+		return nil
+	}
+
+	if err := tc.deleteAssets(stitchClient, app); err != nil {
+		return err
+	}
+
+	if err := stitchClient.DeleteApp(app.GroupID, app.ID); err != nil {
+		return err
+	}
+	tc.Log().Info(fmt.Sprintf("Deleted '%s'", app.ClientAppID))
+
+	if tc.flagDeleteDatabaseUsers {
+		if err := tc.deleteDatabaseUser(app); err != nil {
+			return fmt.Errorf("deleted '%s' but failed to delete its database user: %s", app.ClientAppID, err)
+		}
+	}
+
+	tc.removeBootstrapCheckpoints(app)
+
+	tc.Success(fmt.Sprintf("Successfully tore down '%s'", app.ClientAppID))
+	return nil
+}
+
+// deleteAssets deletes the hosted assets of the app, which are not deleted along with it
+func (tc *TeardownCommand) deleteAssets(stitchClient api.StitchClient, app *models.App) error {
+	assets, err := stitchClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list the hosted assets of '%s': %s", app.ClientAppID, err)
+	}
+
+	// the assets in a directory are deleted ahead of it
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].FilePath > assets[j].FilePath
+	})
+
+	for _, asset := range assets {
+		if err := stitchClient.DeleteAsset(app.GroupID, app.ID, asset.FilePath); err != nil {
+			return fmt.Errorf("failed to delete hosted asset %s: %s", asset.FilePath, err)
+		}
+	}
+
+	if len(assets) > 0 {
+		tc.Log().Info(fmt.Sprintf("Deleted %d hosted asset(s)", len(assets)))
+	}
+	return nil
+}
+
+// deleteDatabaseUser deletes the Atlas database user of the app
+func (tc *TeardownCommand) deleteDatabaseUser(app *models.App) error {
+	atlasClient, err := tc.AtlasClient()
+	if err != nil {
+		return err
+	}
+
+	username := atlasDatabaseUserPrefix + app.ClientAppID
+	if err := atlasClient.DeleteDatabaseUser(app.GroupID, username); err != nil {
+		return err
+	}
+
+	tc.Log().Info(fmt.Sprintf("Deleted database user '%s'", username))
+	return nil
+}
+
+// removeBootstrapCheckpoints removes the checkpoints in the working directory that record bootstrapping the app,
+// so that bootstrapping its environment again starts over
+func (tc *TeardownCommand) removeBootstrapCheckpoints(app *models.App) {
+	paths, _ := filepath.Glob(filepath.Join(tc.workingDirectory, fmt.Sprintf(bootstrapCheckpointFileFormat, "*")))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var checkpoint bootstrapCheckpoint
+		if json.Unmarshal(data, &checkpoint) != nil || checkpoint.ClientAppID != app.ClientAppID {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			tc.Log().Warn(fmt.Sprintf("failed to remove the bootstrap checkpoint %s: %s", path, err))
+			continue
+		}
+		tc.Log().Info(fmt.Sprintf("Removed the bootstrap checkpoint %s", path))
+	}
+}
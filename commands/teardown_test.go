@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestTeardownCommand(t *testing.T) {
+	workingDirectory, err := ioutil.TempDir("", "stitch-teardown")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(workingDirectory)
+
+	type teardownCalls struct {
+		deletedAssets []string
+		deletedApps   []string
+		deletedUsers  []string
+	}
+
+	setup := func(calls *teardownCalls) (*TeardownCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewTeardownCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		teardownCommand := cmd.(*TeardownCommand)
+		teardownCommand.workingDirectory = workingDirectory
+		teardownCommand.storage = u.NewEmptyStorage()
+		teardownCommand.user = &user.User{APIKey: "my-api-key", AccessToken: u.GenerateValidAccessToken()}
+
+		app := &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: "shop-pr-123-abcde", Name: "shop-pr-123"}
+		teardownCommand.stitchClient = &u.MockStitchClient{
+			FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+				return app, nil
+			},
+			ListAssetsForAppIDFn: func(groupID, appID string) ([]hosting.AssetMetadata, error) {
+				return []hosting.AssetMetadata{
+					{FilePath: "/css/"},
+					{FilePath: "/index.html"},
+					{FilePath: "/css/site.css"},
+				}, nil
+			},
+			DeleteAssetFn: func(groupID, appID, path string) error {
+				calls.deletedAssets = append(calls.deletedAssets, path)
+				return nil
+			},
+			DeleteAppFn: func(groupID, appID string) error {
+				calls.deletedApps = append(calls.deletedApps, groupID+"/"+appID)
+				return nil
+			},
+		}
+		teardownCommand.atlasClient = &u.MockMDBClient{
+			DeleteDatabaseUserFn: func(groupID, username string) error {
+				calls.deletedUsers = append(calls.deletedUsers, groupID+"/"+username)
+				return nil
+			},
+		}
+		return teardownCommand, mockUI
+	}
+
+	t.Run("it requires an app ID", func(t *testing.T) {
+		teardownCommand, mockUI := setup(&teardownCalls{})
+		exitCode := teardownCommand.Run([]string{"--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errTeardownAppIDRequired.Error())
+	})
+
+	t.Run("it fails without waiting for a confirmation when stdin is not a terminal", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		u.So(t, err, gc.ShouldBeNil)
+		defer r.Close()
+		w.Close()
+
+		calls := &teardownCalls{}
+		teardownCommand, _ := setup(calls)
+		var errorOutput bytes.Buffer
+		teardownCommand.UI = &cli.BasicUi{Reader: r, Writer: ioutil.Discard, ErrorWriter: &errorOutput}
+
+		exitCode := teardownCommand.Run([]string{"--app-id=shop-pr-123-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, errorOutput.String(), gc.ShouldContainSubstring, "as stdin is not a terminal, use --yes instead")
+		u.So(t, calls.deletedApps, gc.ShouldBeEmpty)
+	})
+
+	t.Run("it deletes nothing when the deletion is declined", func(t *testing.T) {
+		calls := &teardownCalls{}
+		teardownCommand, mockUI := setup(calls)
+		mockUI.InputReader = strings.NewReader("n\n")
+
+		exitCode := teardownCommand.Run([]string{"--app-id=shop-pr-123-abcde"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, calls.deletedAssets, gc.ShouldBeEmpty)
+		u.So(t, calls.deletedApps, gc.ShouldBeEmpty)
+	})
+
+	t.Run("it deletes the hosted assets and then the app", func(t *testing.T) {
+		calls := &teardownCalls{}
+		teardownCommand, mockUI := setup(calls)
+
+		exitCode := teardownCommand.Run([]string{"--app-id=shop-pr-123-abcde", "--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully tore down 'shop-pr-123-abcde'")
+
+		u.So(t, calls.deletedAssets, gc.ShouldResemble, []string{"/index.html", "/css/site.css", "/css/"})
+		u.So(t, calls.deletedApps, gc.ShouldResemble, []string{"group-id/app-id"})
+		u.So(t, calls.deletedUsers, gc.ShouldBeEmpty)
+	})
+
+	t.Run("it deletes the database user of the app and its bootstrap checkpoint", func(t *testing.T) {
+		ownCheckpoint := filepath.Join(workingDirectory, ".stitch-bootstrap-pr-123.json")
+		otherCheckpoint := filepath.Join(workingDirectory, ".stitch-bootstrap-staging.json")
+		u.So(t, ioutil.WriteFile(ownCheckpoint, []byte(`{"env": "pr-123", "client_app_id": "shop-pr-123-abcde"}`), 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(otherCheckpoint, []byte(`{"env": "staging", "client_app_id": "shop-staging-fghij"}`), 0600), gc.ShouldBeNil)
+
+		calls := &teardownCalls{}
+		teardownCommand, _ := setup(calls)
+
+		exitCode := teardownCommand.Run([]string{"--app-id=shop-pr-123-abcde", "--delete-database-users", "--yes"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, calls.deletedUsers, gc.ShouldResemble, []string{"group-id/mongodb-stitch-shop-pr-123-abcde"})
+
+		_, err := os.Stat(ownCheckpoint)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+		_, err = os.Stat(otherCheckpoint)
+		u.So(t, err, gc.ShouldBeNil)
+	})
+}
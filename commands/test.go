@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/functiontest"
+	"github.com/10gen/stitch-cli/utils"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	testFlagPath    = "path"
+	testFlagEnvFile = "env-file"
+	testFlagReport  = "report"
+
+	testReportTAP   = "tap"
+	testReportJUnit = "junit"
+)
+
+// NewTestCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewTestCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &TestCommand{
+			BaseCommand: &BaseCommand{
+				Name: "test",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// TestCommand is used to run the unit tests of an app's functions locally
+type TestCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagAppPath string
+	flagEnvFile string
+	flagReport  string
+}
+
+// Synopsis returns a one-liner description for this command
+func (tc *TestCommand) Synopsis() string {
+	return "Run the unit tests of an app's functions locally."
+}
+
+// Help returns long-form help information for this command
+func (tc *TestCommand) Help() string {
+	return `Run the unit tests of an app's functions locally, reporting the results in TAP or JUnit XML.
+
+The tests are run with Node.js, so the node command (https://nodejs.org), version ` + functiontest.MinNodeVersion.String() + ` or later, must be installed and on the PATH. Node.js is used rather than an engine built into the CLI so that functions run with the modern JavaScript and npm dependencies they use when deployed.
+
+Tests are the "functions/**/*` + functiontest.TestFileSuffix + `" files of the app. Each one registers its tests with test(name, fn), where fn may be async, and has these globals:
+  assert                  Node's assert module.
+  loadFunction(name)      Returns the named function of the app, as exported by its source.js.
+  context                 context.values.get returns the app's values, resolved like "dev values" does; context.services.get returns
+                          a mock whose methods do nothing, for services defined in the app; and context.functions.execute calls other functions.
+  mockService(name, obj)  Makes context.services.get(name) return obj for the rest of the current test.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app. Defaults to the directory containing the working directory.
+
+  --env-file [string] (default: ` + defaultDevEnvFile + ` in the app directory, if it exists)
+	The .env file to resolve values and secrets from, as described in "help dev values".
+
+  --report [string] (default: ` + testReportTAP + `)
+	The format to print the results in: "` + testReportTAP + `" for the Test Anything Protocol, or "` + testReportJUnit + `" for a JUnit XML report CI systems can read.` +
+		tc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (tc *TestCommand) Run(args []string) int {
+	flags := tc.NewFlagSet()
+
+	flags.StringVar(&tc.flagAppPath, testFlagPath, "", "")
+	flags.StringVar(&tc.flagEnvFile, testFlagEnvFile, "", "")
+	flags.StringVar(&tc.flagReport, testFlagReport, testReportTAP, "")
+
+	if err := tc.BaseCommand.run(args); err != nil {
+		tc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := tc.test(); err != nil {
+		tc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (tc *TestCommand) test() error {
+	if tc.flagReport != testReportTAP && tc.flagReport != testReportJUnit {
+		return fmt.Errorf("--%s must be %q or %q", testFlagReport, testReportTAP, testReportJUnit)
+	}
+
+	if err := functiontest.CheckNode(); err != nil {
+		return err
+	}
+
+	appPath, err := tc.resolveAppDirectory(tc.flagAppPath, tc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	files, err := functiontest.Discover(appPath)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		tc.Log().Info(fmt.Sprintf("No function tests (functions/**/*%s) were found.", functiontest.TestFileSuffix))
+		return nil
+	}
+
+	context, err := tc.testContext(appPath)
+	if err != nil {
+		return err
+	}
+
+	report, err := functiontest.Run(appPath, files, *context)
+	if err != nil {
+		return err
+	}
+
+	if err := tc.outputReport(report); err != nil {
+		return err
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d function test(s) failed", failed, len(report.Results))
+	}
+
+	// a JUnit report is all that is written to stdout, so that it can be redirected to a file
+	if tc.flagReport == testReportTAP {
+		tc.Success(fmt.Sprintf("All %d function test(s) passed", len(report.Results)))
+	}
+	return nil
+}
+
+// outputReport prints the report in the --report format
+func (tc *TestCommand) outputReport(report *functiontest.Report) error {
+	if tc.flagReport != testReportJUnit {
+		tc.UI.Output(functiontest.FormatTAP(report))
+		return nil
+	}
+
+	data, err := utils.MarshalJUnit(functiontest.JUnitSuite(report))
+	if err != nil {
+		return fmt.Errorf("failed to write JUnit report: %s", err)
+	}
+
+	tc.UI.Output(strings.TrimSuffix(string(data), "\n"))
+	return nil
+}
+
+// testContext builds the context the tests run against from the app's values, resolved from the .env
+// file, and services
+func (tc *TestCommand) testContext(appPath string) (*functiontest.Context, error) {
+	env := map[string]string{}
+	envPath := filepath.Join(appPath, defaultDevEnvFile)
+	if tc.flagEnvFile != "" {
+		var err error
+		if envPath, err = homedir.Expand(tc.flagEnvFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := os.Stat(envPath); err == nil || tc.flagEnvFile != "" {
+		if env, err = utils.ReadEnvFile(envPath); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", envPath, err)
+		}
+	}
+
+	app, err := utils.UnmarshalFromDir(appPath)
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := app["values"].([]interface{})
+	resolved, err := resolveDevValues(values, env)
+	if err != nil {
+		return nil, fmt.Errorf("%s (in %s)", err, envPath)
+	}
+
+	context := &functiontest.Context{Values: resolved.Values}
+
+	services, _ := app["services"].([]interface{})
+	for _, s := range services {
+		svc, _ := s.(map[string]interface{})
+		config, _ := svc["config"].(map[string]interface{})
+		if name, ok := config["name"].(string); ok {
+			context.Services = append(context.Services, name)
+		}
+	}
+
+	return context, nil
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestTestCommand(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		u.MustSkipf(t, "node is not installed")
+		return
+	}
+
+	setup := func() (*TestCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewTestCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		testCommand := cmd.(*TestCommand)
+		testCommand.storage = u.NewEmptyStorage()
+		return testCommand, mockUI
+	}
+
+	dir, err := ioutil.TempDir("", "stitch-test-command")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	for name, data := range map[string]string{
+		"stitch.json":                      `{"name": "my-app", "app_id": "my-app-abcdef"}`,
+		"values/greeting.json":             `{"name": "greeting", "value": "greetingSecret", "from_secret": true}`,
+		"functions/greet/config.json":      `{"name": "greet"}`,
+		"functions/greet/source.js":        `exports = function(name) { return context.values.get("greeting") + ", " + name; };`,
+		"functions/greet/greet.test.js":    `test("greets", () => { assert.strictEqual(loadFunction("greet")("Ripley"), "Hello, Ripley"); });`,
+		"services/http1/config.json":       `{"name": "http1", "type": "http"}`,
+		"services/http1/rules/.keep":       ``,
+		".env":                             "greetingSecret=Hello\n",
+		"other.env":                        "greetingSecret=Goodbye\n",
+		"functions/greet/no-tests-here.js": ``,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	t.Run("it reports passing tests in TAP", func(t *testing.T) {
+		testCommand, mockUI := setup()
+		exitCode := testCommand.Run([]string{"--path=" + dir})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "TAP version 13\n1..1\nok 1 - functions/greet/greet.test.js > greets\n")
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "All 1 function test(s) passed")
+	})
+
+	t.Run("it fails when a test fails", func(t *testing.T) {
+		testCommand, mockUI := setup()
+		exitCode := testCommand.Run([]string{"--path=" + dir, "--env-file=" + filepath.Join(dir, "other.env")})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "not ok 1 - functions/greet/greet.test.js > greets\n")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "1 of 1 function test(s) failed")
+	})
+
+	t.Run("it reports the tests as JUnit XML", func(t *testing.T) {
+		testCommand, mockUI := setup()
+		exitCode := testCommand.Run([]string{"--path=" + dir, "--env-file=" + filepath.Join(dir, "other.env"), "--report=junit"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "1 of 1 function test(s) failed")
+
+		var report utils.JUnitTestSuites
+		u.So(t, xml.Unmarshal(mockUI.OutputWriter.Bytes(), &report), gc.ShouldBeNil)
+		u.So(t, report.Suites, gc.ShouldHaveLength, 1)
+		u.So(t, report.Suites[0].Tests, gc.ShouldEqual, 1)
+		u.So(t, report.Suites[0].Failures, gc.ShouldEqual, 1)
+		u.So(t, report.Suites[0].Cases[0].Name, gc.ShouldEqual, "greets")
+		u.So(t, report.Suites[0].Cases[0].File, gc.ShouldEqual, "functions/greet/greet.test.js")
+		u.So(t, report.Suites[0].Cases[0].Failure, gc.ShouldNotBeNil)
+	})
+
+	t.Run("it fails before running anything when node is not installed", func(t *testing.T) {
+		defer os.Setenv("PATH", os.Getenv("PATH"))
+		os.Setenv("PATH", "")
+
+		testCommand, mockUI := setup()
+		exitCode := testCommand.Run([]string{"--path=" + dir})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the node command (https://nodejs.org) must be installed to run function tests")
+	})
+
+	t.Run("it fails on an unknown report format", func(t *testing.T) {
+		testCommand, mockUI := setup()
+		exitCode := testCommand.Run([]string{"--path=" + dir, "--report=xunit"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `--report must be "tap" or "junit"`)
+	})
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/10gen/stitch-cli/api"
+)
+
+// userAgentSuffixEnvVar names the environment variable setting the User-Agent suffix when --user-agent-suffix is
+// not given, so that it can be set once for every command run by a CI job
+const userAgentSuffixEnvVar = "STITCH_USER_AGENT_SUFFIX"
+
+// newCorrelationID returns a random ID for the requests of a command run
+func newCorrelationID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// traceHeaders returns the headers sent with every API request to trace it back to this run of the command:
+// the User-Agent, with any configured suffix, and the correlation ID of the run
+func (c *BaseCommand) traceHeaders() http.Header {
+	userAgent := api.UserAgent
+	if c.flagUserAgent != "" {
+		userAgent += " " + c.flagUserAgent
+	}
+
+	headers := http.Header{}
+	headers.Set("User-Agent", userAgent)
+	if c.correlationID != "" {
+		headers.Set(api.CorrelationIDHeader, c.correlationID)
+	}
+	return headers
+}
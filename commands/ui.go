@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// terminalUi is the cli.Ui shared by all commands. It colorizes errors, warnings, successes, and diffs
+// when color is enabled, and discards informational messages and warnings when quiet, leaving only
+// errors and output that was explicitly requested (written with Output)
+type terminalUi struct {
+	cli.Ui
+
+	colorEnabled bool
+	quiet        bool
+}
+
+func newTerminalUi(ui cli.Ui, colorEnabled, quiet bool) *terminalUi {
+	return &terminalUi{
+		Ui:           ui,
+		colorEnabled: colorEnabled,
+		quiet:        quiet,
+	}
+}
+
+// Info displays the message unless quiet
+func (u *terminalUi) Info(message string) {
+	if u.quiet {
+		return
+	}
+	u.Ui.Info(message)
+}
+
+// Warn displays the message in yellow unless quiet
+func (u *terminalUi) Warn(message string) {
+	if u.quiet {
+		return
+	}
+	u.Ui.Warn(u.colorize(cli.UiColorYellow, message))
+}
+
+// Error displays the message in red
+func (u *terminalUi) Error(message string) {
+	u.Ui.Error(u.colorize(cli.UiColorRed, message))
+}
+
+// Success displays the message in green unless quiet
+func (u *terminalUi) Success(message string) {
+	u.Info(u.colorize(cli.UiColorGreen, message))
+}
+
+// Diff displays each line of the diff, coloring additions green, removals red, and modifications yellow
+func (u *terminalUi) Diff(diff string) {
+	for _, line := range strings.Split(diff, "\n") {
+		u.Ui.Output(u.colorize(diffLineColor(line), line))
+	}
+}
+
+func (u *terminalUi) colorize(color cli.UiColor, message string) string {
+	if !u.colorEnabled || color.Code == cli.UiColorNone.Code {
+		return message
+	}
+
+	attr := 0
+	if color.Bold {
+		attr = 1
+	}
+
+	return fmt.Sprintf("\033[%d;%dm%s\033[0m", attr, color.Code, message)
+}
+
+func diffLineColor(line string) cli.UiColor {
+	trimmed := strings.TrimLeft(line, " \t")
+	switch {
+	case strings.HasPrefix(trimmed, "---"), strings.HasPrefix(trimmed, "+++"):
+		return cli.UiColorNone
+	case strings.HasPrefix(trimmed, "+"):
+		return cli.UiColorGreen
+	case strings.HasPrefix(trimmed, "-"):
+		return cli.UiColorRed
+	case strings.HasPrefix(trimmed, "*"):
+		return cli.UiColorYellow
+	}
+
+	return cli.UiColorNone
+}
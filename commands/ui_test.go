@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"testing"
+
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestTerminalUi(t *testing.T) {
+	t.Run("with color enabled", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		ui := newTerminalUi(mockUI, true, false)
+
+		ui.Info("some info")
+		ui.Success("it worked")
+		ui.Error("it broke")
+		ui.Warn("careful")
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "some info\n\033[0;32mit worked\033[0m\n")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "\033[0;31mit broke\033[0m\n\033[0;33mcareful\033[0m\n")
+	})
+
+	t.Run("should color each line of a diff", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		ui := newTerminalUi(mockUI, true, false)
+
+		ui.Diff("--- functions/greet ---\n+ added\n- removed\n  unchanged")
+		ui.Diff("\t* /modified.html")
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "--- functions/greet ---\n"+
+			"\033[0;32m+ added\033[0m\n"+
+			"\033[0;31m- removed\033[0m\n"+
+			"  unchanged\n"+
+			"\033[0;33m\t* /modified.html\033[0m\n")
+	})
+
+	t.Run("with color disabled", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		ui := newTerminalUi(mockUI, false, false)
+
+		ui.Success("it worked")
+		ui.Diff("+ added")
+		ui.Error("it broke")
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "it worked\n+ added\n")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "it broke\n")
+	})
+
+	t.Run("when quiet", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		ui := newTerminalUi(mockUI, false, true)
+
+		ui.Info("some info")
+		ui.Success("it worked")
+		ui.Warn("careful")
+		ui.Diff("+ added")
+		ui.Error("it broke")
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "+ added\n")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "it broke\n")
+	})
+}
@@ -0,0 +1,428 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/api"
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+	"github.com/10gen/stitch-cli/validation"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	validateFlagPath         = "path"
+	validateFlagStrict       = "strict"
+	validateFlagFetchSchemas = "fetch-schemas"
+	validateFlagJUnitFile    = "junit-file"
+	validateFlagCheckLimits  = "check-limits"
+
+	validateFlagCheckRemoteValues = "check-remote-values"
+)
+
+func errValidationFailed(count int) error {
+	return fmt.Errorf("validation failed: %d problem(s) found in app configuration", count)
+}
+
+// NewValidateCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewValidateCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ValidateCommand{
+			BaseCommand: &BaseCommand{
+				Name: "validate",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// ValidateCommand is used to check the configuration of a local Stitch App without importing it
+type ValidateCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagAppPath      string
+	flagStrict       bool
+	flagFetchSchemas bool
+	flagJUnitFile    string
+	flagCheckLimits  bool
+
+	flagCheckRemoteValues bool
+}
+
+// Help returns long-form help information for this command
+func (vc *ValidateCommand) Help() string {
+	return `Validate the configuration of a stitch application in a local directory.
+
+The config of auth providers is also checked for the fields their type requires, e.g. the client ID and secret of OAuth providers, so that a misconfigured provider fails here rather than at the first login.
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app.
+
+  --strict
+	Treat unrecognized fields in entity configuration as errors rather than warnings.
+
+  --fetch-schemas
+	Validate against the latest entity schemas provided by the server instead of those bundled with the CLI. Requires login.
+
+  --junit-file [string]
+	Also write the results as a JUnit XML report to the given file, with a test case for each config file, so that CI systems show problems as test failures.
+
+  --check-limits
+	Also check the app, including its hosting assets, against the limits the server enforces on the number of functions and on the size of an import and of a hosting asset, warning when it approaches them and failing when it exceeds them. Requires login.
+
+  --check-remote-values
+	Also check the values of the deployed app against the types declared for them in the "value_types" setting of the app's .stitchrc file (e.g. "maxRetries: number"),
+	which the local values are always checked against. The deployed app is the one with the App ID in stitch.json. Requires login.` +
+		vc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (vc *ValidateCommand) Synopsis() string {
+	return `Validate the configuration of a stitch application in a local directory.`
+}
+
+// Run executes the command
+func (vc *ValidateCommand) Run(args []string) int {
+	flags := vc.NewFlagSet()
+
+	flags.StringVar(&vc.flagAppPath, validateFlagPath, "", "")
+	flags.BoolVar(&vc.flagStrict, validateFlagStrict, false, "")
+	flags.BoolVar(&vc.flagFetchSchemas, validateFlagFetchSchemas, false, "")
+	flags.StringVar(&vc.flagJUnitFile, validateFlagJUnitFile, "", "")
+	flags.BoolVar(&vc.flagCheckLimits, validateFlagCheckLimits, false, "")
+	flags.BoolVar(&vc.flagCheckRemoteValues, validateFlagCheckRemoteValues, false, "")
+
+	if err := vc.BaseCommand.run(args); err != nil {
+		vc.Log().Error(err.Error())
+		return 1
+	}
+
+	if err := vc.validate(); err != nil {
+		vc.Log().Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (vc *ValidateCommand) validate() error {
+	appPath, err := vc.resolveAppDirectory(vc.flagAppPath, vc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	loadedApp, err := utils.UnmarshalFromDir(appPath)
+	if err != nil {
+		if junitErr := vc.writeJUnitFile(loadFailureJUnitSuite(err)); junitErr != nil {
+			return junitErr
+		}
+		return err
+	}
+
+	schemas := validation.DefaultSchemas
+	if vc.flagFetchSchemas {
+		remoteSchemas, err := vc.fetchSchemas()
+		if err != nil {
+			return err
+		}
+		schemas = schemas.Merge(remoteSchemas)
+	}
+
+	validationErrs, err := validation.Validate(appPath, schemas)
+	if err != nil {
+		return err
+	}
+
+	// the report is only written once every check has run, so that it includes the failures of all of them
+	suite := vc.validationJUnitSuite(appPath, validationErrs)
+	checkErr := vc.check(&suite, appPath, loadedApp, validationErrs)
+	if err := vc.writeJUnitFile(suite); err != nil {
+		return err
+	}
+
+	if checkErr != nil {
+		return checkErr
+	}
+
+	vc.Success(fmt.Sprintf("Successfully validated app at %s", appPath))
+	return nil
+}
+
+// check reports the problems validation found in the config files of the app, then runs the checks of the
+// app beyond its config files, stopping at the first that fails. Each of those checks that runs adds a test
+// case to the suite
+func (vc *ValidateCommand) check(suite *utils.JUnitTestSuite, appPath string, loadedApp map[string]interface{}, validationErrs []validation.Error) error {
+	if err := reportValidationErrors(vc.Log(), validationErrs, vc.flagStrict); err != nil {
+		return err
+	}
+
+	projectConfig, err := models.LoadProjectConfig(appPath)
+	if err != nil {
+		err = fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+		suite.AddCase(checkJUnitCase(models.ProjectConfigFileName, err, nil))
+		return err
+	}
+
+	if len(projectConfig.ValueTypes) > 0 {
+		if err := vc.runJUnitCheck(suite, "value types of the local app", func(log logging.Logger) error {
+			return checkValueTypes(log, "local app", projectConfig.ValueTypes, localValues(loadedApp))
+		}); err != nil {
+			return err
+		}
+	}
+
+	if vc.flagCheckRemoteValues {
+		if err := vc.runJUnitCheck(suite, "value types of the deployed app", func(log logging.Logger) error {
+			return vc.checkRemoteValueTypes(log, appPath, projectConfig.ValueTypes)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if vc.flagCheckLimits {
+		if err := vc.runJUnitCheck(suite, "limits", func(log logging.Logger) error {
+			return vc.checkLimits(log, appPath, loadedApp)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (vc *ValidateCommand) fetchSchemas() (validation.Schemas, error) {
+	user, err := vc.User()
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.LoggedIn() {
+		return nil, u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := vc.StitchClient()
+	if err != nil {
+		return nil, err
+	}
+
+	rawSchemas, err := stitchClient.FetchConfigSchemas()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schemas: %s", err)
+	}
+
+	docs := make(map[utils.ConfigKind]string, len(rawSchemas))
+	for kind, rawSchema := range rawSchemas {
+		docs[utils.ConfigKind(kind)] = string(rawSchema)
+	}
+
+	return validation.ParseSchemas(docs)
+}
+
+// checkLimits checks the app at appPath, along with its hosting assets, against the limits the server enforces,
+// reporting the problems found to log
+func (vc *ValidateCommand) checkLimits(log logging.Logger, appPath string, loadedApp map[string]interface{}) error {
+	user, err := vc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := vc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	limits, err := stitchClient.FetchLimits()
+	if err != nil {
+		return fmt.Errorf("failed to fetch limits: %s", err)
+	}
+
+	usage, err := appUsage(loadedApp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(appPath, utils.HostingAttributes)); err == nil {
+		projectConfig, err := models.LoadProjectConfig(appPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %s", models.ProjectConfigFileName, err)
+		}
+
+		appInstanceData := models.AppInstanceData{}
+		if err := appInstanceData.UnmarshalFile(appPath); err != nil {
+			return err
+		}
+
+		_, assets, cleanup, err := vc.listLocalAssets(appPath, appInstanceData.AppID(), projectConfig.Hosting)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		usage.AssetSizes = assetSizes(assets)
+	}
+
+	return reportLimitProblems(log, validation.CheckLimits(withDefaultLimits(*limits), usage))
+}
+
+// checkRemoteValueTypes checks the values of the deployed app with the App ID of the app at appPath against types,
+// reporting the mismatches found to log
+func (vc *ValidateCommand) checkRemoteValueTypes(log logging.Logger, appPath string, types map[string]string) error {
+	if len(types) == 0 {
+		return fmt.Errorf("--%s requires value_types to be declared in %s", validateFlagCheckRemoteValues, models.ProjectConfigFileName)
+	}
+
+	appInstanceData := models.AppInstanceData{}
+	if err := appInstanceData.UnmarshalFile(appPath); err != nil {
+		return err
+	}
+
+	if appInstanceData.AppID() == "" {
+		return fmt.Errorf("--%s requires an app_id in %s", validateFlagCheckRemoteValues, models.AppConfigFileName)
+	}
+
+	user, err := vc.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	stitchClient, err := vc.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	app, err := stitchClient.FetchAppByClientAppID(appInstanceData.AppID())
+	if err != nil {
+		return err
+	}
+
+	appValues, err := stitchClient.FetchValues(app.GroupID, app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the values of '%s': %s", app.ClientAppID, err)
+	}
+
+	values, err := remoteValues(appValues)
+	if err != nil {
+		return err
+	}
+
+	return checkValueTypes(log, fmt.Sprintf("deployed app '%s'", appInstanceData.AppID()), types, values)
+}
+
+// checkAppConfig validates the app at appPath against the given schemas and reports any problems found.
+// Unrecognized fields are reported as errors, failing the check, if strict is true and as warnings otherwise
+func checkAppConfig(log logging.Logger, appPath string, schemas validation.Schemas, strict bool) error {
+	validationErrs, err := validation.Validate(appPath, schemas)
+	if err != nil {
+		return err
+	}
+
+	return reportValidationErrors(log, validationErrs, strict)
+}
+
+// reportValidationErrors reports the problems found by validating an app, failing if there are any errors
+func reportValidationErrors(log logging.Logger, validationErrs []validation.Error, strict bool) error {
+	var failures int
+	for _, validationErr := range validationErrs {
+		if validationErr.Unrecognized && !strict {
+			log.Warn(validationErr.Error())
+			continue
+		}
+
+		log.Error(validationErr.Error())
+		failures++
+	}
+
+	if failures > 0 {
+		return errValidationFailed(failures)
+	}
+
+	return nil
+}
+
+// fetchLimits fetches the limits the server enforces on apps. If they cannot be fetched, only the size of
+// hosting assets is checked, against the limit Stitch is known to enforce
+func (c *BaseCommand) fetchLimits(stitchClient api.StitchClient) models.Limits {
+	limits, err := stitchClient.FetchLimits()
+	if err != nil {
+		c.Log().Debug(fmt.Sprintf("Only checking the size of hosting assets, as the limits could not be fetched: %s", err))
+		return withDefaultLimits(models.Limits{})
+	}
+	return withDefaultLimits(*limits)
+}
+
+// withDefaultLimits returns the limits with the known limit on the size of hosting assets filled in, if the
+// server does not report it
+func withDefaultLimits(limits models.Limits) models.Limits {
+	if limits.MaxAssetSizeBytes <= 0 {
+		limits.MaxAssetSizeBytes = hosting.MaxAssetSizeBytes
+	}
+	return limits
+}
+
+// appUsage returns how much of the resources the server limits the loaded app uses, other than hosting assets
+func appUsage(loadedApp map[string]interface{}) (validation.Usage, error) {
+	appData, err := json.Marshal(loadedApp)
+	if err != nil {
+		return validation.Usage{}, err
+	}
+
+	functions, _ := loadedApp["functions"].([]interface{})
+	return validation.Usage{Functions: len(functions), RequestSize: int64(len(appData))}, nil
+}
+
+// assetSizes returns the sizes of the hosting assets, keyed by path
+func assetSizes(assets []hosting.AssetMetadata) map[string]int64 {
+	sizes := make(map[string]int64, len(assets))
+	for _, asset := range assets {
+		if !strings.HasSuffix(asset.FilePath, "/") {
+			sizes[asset.FilePath] = asset.FileSize
+		}
+	}
+	return sizes
+}
+
+// reportLimitProblems warns about the limits an app approaches, and reports those it exceeds as errors,
+// failing if there are any
+func reportLimitProblems(log logging.Logger, problems []validation.LimitProblem) error {
+	var exceeded int
+	for _, problem := range problems {
+		if !problem.Exceeded {
+			log.Warn(problem.Error())
+			continue
+		}
+
+		log.Error(problem.Error())
+		exceeded++
+	}
+
+	if exceeded > 0 {
+		return fmt.Errorf("the app exceeds %d limit(s) enforced by the server", exceeded)
+	}
+
+	return nil
+}
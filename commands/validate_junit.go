@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/utils"
+	"github.com/10gen/stitch-cli/validation"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	validateJUnitSuiteName = "stitch-cli validate"
+	validateJUnitClassName = "validate"
+
+	validateJUnitFailureType = "ValidationError"
+)
+
+// writeJUnitFile writes the suite to the --junit-file, if one was given
+func (vc *ValidateCommand) writeJUnitFile(suite utils.JUnitTestSuite) error {
+	if vc.flagJUnitFile == "" {
+		return nil
+	}
+
+	path, err := homedir.Expand(vc.flagJUnitFile)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteJUnitFile(path, suite); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %s", err)
+	}
+
+	return nil
+}
+
+// validationJUnitSuite builds a test suite with a case for every config file of the app, which fails if
+// validation found errors in the file. Unrecognized fields fail it only when validating strictly
+func (vc *ValidateCommand) validationJUnitSuite(appPath string, validationErrs []validation.Error) utils.JUnitTestSuite {
+	errsByPath := map[string][]validation.Error{}
+	for _, validationErr := range validationErrs {
+		errsByPath[validationErr.Path] = append(errsByPath[validationErr.Path], validationErr)
+	}
+
+	suite := utils.JUnitTestSuite{Name: validateJUnitSuiteName}
+	for _, file := range utils.ListConfigFiles(appPath) {
+		testCase := utils.JUnitTestCase{
+			Name:      filepath.ToSlash(file.Path),
+			ClassName: validateJUnitClassName,
+			File:      vc.annotationPath(filepath.Join(appPath, file.Path)),
+		}
+
+		var failures, warnings []string
+		for _, validationErr := range errsByPath[file.Path] {
+			if validationErr.Unrecognized && !vc.flagStrict {
+				warnings = append(warnings, validationErr.Message)
+				continue
+			}
+			failures = append(failures, validationErr.Message)
+		}
+
+		if len(failures) > 0 {
+			testCase.Failure = &utils.JUnitFailure{
+				Message: fmt.Sprintf("%d problem(s) found in %s", len(failures), testCase.Name),
+				Type:    validateJUnitFailureType,
+				Details: strings.Join(failures, "\n"),
+			}
+		}
+		if len(warnings) > 0 {
+			testCase.SystemOut = "warning: " + strings.Join(warnings, "\nwarning: ")
+		}
+
+		suite.AddCase(testCase)
+	}
+
+	return suite
+}
+
+// runJUnitCheck runs check, adding a test case with the given name to the suite. The case fails if the check
+// does, with the errors the check logged as the details of the failure and its warnings as its output
+func (vc *ValidateCommand) runJUnitCheck(suite *utils.JUnitTestSuite, name string, check func(log logging.Logger) error) error {
+	log := &junitCheckLogger{Logger: vc.Log()}
+	err := check(log)
+	suite.AddCase(checkJUnitCase(name, err, log))
+	return err
+}
+
+// checkJUnitCase builds the test case of a check that failed with err, if it is not nil, having logged to log,
+// if it is not nil
+func checkJUnitCase(name string, err error, log *junitCheckLogger) utils.JUnitTestCase {
+	testCase := utils.JUnitTestCase{Name: name, ClassName: validateJUnitClassName}
+	if log != nil && len(log.warnings) > 0 {
+		testCase.SystemOut = "warning: " + strings.Join(log.warnings, "\nwarning: ")
+	}
+	if err != nil {
+		testCase.Failure = &utils.JUnitFailure{Message: err.Error(), Type: validateJUnitFailureType}
+		if log != nil {
+			testCase.Failure.Details = strings.Join(log.errors, "\n")
+		}
+	}
+	return testCase
+}
+
+// junitCheckLogger is a logging.Logger that records the errors and warnings logged by a check as they are
+// logged, for its test case
+type junitCheckLogger struct {
+	logging.Logger
+	errors   []string
+	warnings []string
+}
+
+// Error logs the error and records it
+func (l *junitCheckLogger) Error(message string) {
+	l.errors = append(l.errors, message)
+	l.Logger.Error(message)
+}
+
+// Warn logs the warning and records it
+func (l *junitCheckLogger) Warn(message string) {
+	l.warnings = append(l.warnings, message)
+	l.Logger.Warn(message)
+}
+
+// loadFailureJUnitSuite builds a test suite with a single failed case for an app that could not be loaded
+// at all, so that CI systems still report the failure
+func loadFailureJUnitSuite(err error) utils.JUnitTestSuite {
+	suite := utils.JUnitTestSuite{Name: validateJUnitSuiteName}
+	suite.AddCase(utils.JUnitTestCase{
+		Name:      "load app",
+		ClassName: validateJUnitClassName,
+		Failure: &utils.JUnitFailure{
+			Message: err.Error(),
+			Type:    validateJUnitFailureType,
+		},
+	})
+	return suite
+}
+
+// annotationPath returns path relative to the working directory where possible, since that is what CI
+// systems resolve annotated files against
+func (vc *ValidateCommand) annotationPath(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		if relPath, err := filepath.Rel(vc.workingDirectory, absPath); err == nil && !strings.HasPrefix(relPath, "..") {
+			return filepath.ToSlash(relPath)
+		}
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,300 @@
+package commands
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/user"
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestValidateCommand(t *testing.T) {
+	setup := func() (*ValidateCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewValidateCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		validateCommand := cmd.(*ValidateCommand)
+		validateCommand.storage = u.NewEmptyStorage()
+		return validateCommand, mockUI
+	}
+
+	t.Run("should succeed for a valid app", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+		exitCode := validateCommand.Run([]string{"--path=../testdata/full_app"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Successfully validated app")
+	})
+
+	t.Run("should warn about unrecognized fields", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+		exitCode := validateCommand.Run([]string{"--path=../testdata/app_with_unknown_fields"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, ".run_as_sytem is not a recognized field")
+	})
+
+	t.Run("should fail on unrecognized fields in strict mode", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+		exitCode := validateCommand.Run([]string{"--path=../testdata/app_with_unknown_fields", "--strict"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "functions/greet/config.json: .run_as_sytem is not a recognized field")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errValidationFailed(1).Error())
+	})
+
+	t.Run("should fail on schema violations regardless of strict mode", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+		exitCode := validateCommand.Run([]string{"--path=../testdata/app_with_invalid_config"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "functions/greet/config.json: .private must be boolean")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errValidationFailed(3).Error())
+	})
+
+	t.Run("should validate against schemas fetched from the server", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+		validateCommand.user = &user.User{
+			APIKey:      "my-api-key",
+			AccessToken: u.GenerateValidAccessToken(),
+		}
+		validateCommand.stitchClient = &u.MockStitchClient{
+			FetchConfigSchemasFn: func() (map[string]json.RawMessage, error) {
+				return map[string]json.RawMessage{
+					"function": json.RawMessage(`{"type": "object", "properties": {"private": {"type": "string"}}}`),
+				}, nil
+			},
+		}
+
+		exitCode := validateCommand.Run([]string{"--path=../testdata/full_app", "--fetch-schemas"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "functions/function_a/config.json: .private must be string")
+	})
+
+	t.Run("checking values against their declared types", func(t *testing.T) {
+		writeValueTypes := func(t *testing.T, appPath string, types map[string]string) {
+			projectConfig := &models.ProjectConfig{ValueTypes: types}
+			u.So(t, projectConfig.Save(appPath), gc.ShouldBeNil)
+		}
+		defer os.Remove(filepath.Join("../testdata/full_app", models.ProjectConfigFileName))
+		defer os.Remove(filepath.Join("../testdata/simple_app_with_instance_data", models.ProjectConfigFileName))
+
+		t.Run("should fail when a local value does not match its declared type", func(t *testing.T) {
+			writeValueTypes(t, "../testdata/full_app", map[string]string{"a": "string", "b": "number"})
+
+			validateCommand, mockUI := setup()
+			exitCode := validateCommand.Run([]string{"--path=../testdata/full_app"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "local app: value 'b' is string, but is declared as number")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "1 value(s) of the local app do not match the types declared in .stitchrc")
+		})
+
+		t.Run("should fail when a type is not one that can be declared", func(t *testing.T) {
+			writeValueTypes(t, "../testdata/full_app", map[string]string{"a": "text"})
+
+			validateCommand, mockUI := setup()
+			exitCode := validateCommand.Run([]string{"--path=../testdata/full_app"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `invalid value_types in .stitchrc: value 'a' is declared as "text"`)
+		})
+
+		t.Run("should check the values of the deployed app with --check-remote-values", func(t *testing.T) {
+			writeValueTypes(t, "../testdata/simple_app_with_instance_data", map[string]string{"a": "string", "b": "string"})
+
+			validateCommand, mockUI := setup()
+			validateCommand.user = &user.User{
+				APIKey:      "my-api-key",
+				AccessToken: u.GenerateValidAccessToken(),
+			}
+			validateCommand.stitchClient = &u.MockStitchClient{
+				FetchAppByClientAppIDFn: func(clientAppID string) (*models.App, error) {
+					return &models.App{GroupID: "group-id", ID: "app-id", ClientAppID: clientAppID}, nil
+				},
+				FetchValuesFn: func(groupID, appID string) ([]models.Value, error) {
+					return []models.Value{
+						{Name: "a", Value: json.RawMessage(`"AAAAAA"`)},
+						{Name: "b", Value: json.RawMessage(`5`)},
+						{Name: "c", Value: json.RawMessage(`"my-secret"`), FromSecret: true},
+					}, nil
+				},
+			}
+
+			exitCode := validateCommand.Run([]string{"--path=../testdata/simple_app_with_instance_data", "--check-remote-values"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "deployed app 'my-app-abcdef': value 'b' is number, but is declared as string")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldNotContainSubstring, "local app")
+		})
+	})
+
+	t.Run("checking the app against the limits of the server", func(t *testing.T) {
+		setupWithLimits := func(limits models.Limits) (*ValidateCommand, *cli.MockUi) {
+			validateCommand, mockUI := setup()
+			validateCommand.user = &user.User{
+				APIKey:      "my-api-key",
+				AccessToken: u.GenerateValidAccessToken(),
+			}
+			validateCommand.stitchClient = &u.MockStitchClient{
+				FetchLimitsFn: func() (*models.Limits, error) {
+					return &limits, nil
+				},
+			}
+			return validateCommand, mockUI
+		}
+
+		t.Run("should warn when the app approaches a limit", func(t *testing.T) {
+			validateCommand, mockUI := setupWithLimits(models.Limits{MaxFunctions: 2, MaxAssetSizeBytes: 1024})
+			exitCode := validateCommand.Run([]string{"--path=../testdata/full_app", "--check-limits"})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the app has 2 functions, approaching the limit of 2")
+		})
+
+		t.Run("should fail when the app exceeds a limit", func(t *testing.T) {
+			validateCommand, mockUI := setupWithLimits(models.Limits{MaxFunctions: 10, MaxAssetSizeBytes: 64})
+			exitCode := validateCommand.Run([]string{"--path=../testdata/full_app", "--check-limits"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the hosting asset /ships/nostromo.json is 71 B, more than the limit of 64 B on the size of an asset")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "the app exceeds 1 limit(s) enforced by the server")
+		})
+
+		t.Run("should check the size of hosting assets against the known limit when the server does not report it", func(t *testing.T) {
+			u.So(t, withDefaultLimits(models.Limits{MaxFunctions: 10}), gc.ShouldResemble, models.Limits{MaxFunctions: 10, MaxAssetSizeBytes: hosting.MaxAssetSizeBytes})
+			u.So(t, withDefaultLimits(models.Limits{MaxAssetSizeBytes: 64}), gc.ShouldResemble, models.Limits{MaxAssetSizeBytes: 64})
+		})
+	})
+
+	t.Run("should fail when the directory does not exist", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+		exitCode := validateCommand.Run([]string{"--path=../testdata/does_not_exist"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "directory does not exist")
+	})
+
+	t.Run("writing a JUnit report", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "stitch-validate-junit")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		junitPath := filepath.Join(dir, "validate.xml")
+
+		readReport := func(t *testing.T) utils.JUnitTestSuites {
+			data, err := ioutil.ReadFile(junitPath)
+			u.So(t, err, gc.ShouldBeNil)
+
+			var report utils.JUnitTestSuites
+			u.So(t, xml.Unmarshal(data, &report), gc.ShouldBeNil)
+			u.So(t, report.Suites, gc.ShouldHaveLength, 1)
+			return report
+		}
+
+		findCase := func(suite utils.JUnitTestSuite, name string) *utils.JUnitTestCase {
+			for i := range suite.Cases {
+				if suite.Cases[i].Name == name {
+					return &suite.Cases[i]
+				}
+			}
+			return nil
+		}
+
+		t.Run("should report every config file as passing for a valid app", func(t *testing.T) {
+			validateCommand, _ := setup()
+			exitCode := validateCommand.Run([]string{"--path=../testdata/full_app", "--junit-file=" + junitPath})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			suite := readReport(t).Suites[0]
+			u.So(t, suite.Tests, gc.ShouldEqual, len(utils.ListConfigFiles("../testdata/full_app")))
+			u.So(t, suite.Failures, gc.ShouldEqual, 0)
+
+			appCase := findCase(suite, "stitch.json")
+			u.So(t, appCase, gc.ShouldNotBeNil)
+			u.So(t, appCase.File, gc.ShouldEqual, "../testdata/full_app/stitch.json")
+		})
+
+		t.Run("should report schema violations as failures of their file", func(t *testing.T) {
+			validateCommand, _ := setup()
+			exitCode := validateCommand.Run([]string{"--path=../testdata/app_with_invalid_config", "--junit-file=" + junitPath})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+
+			suite := readReport(t).Suites[0]
+			u.So(t, suite.Failures, gc.ShouldBeGreaterThan, 0)
+
+			functionCase := findCase(suite, "functions/greet/config.json")
+			u.So(t, functionCase, gc.ShouldNotBeNil)
+			u.So(t, functionCase.Failure, gc.ShouldNotBeNil)
+			u.So(t, functionCase.Failure.Details, gc.ShouldContainSubstring, ".private must be boolean")
+		})
+
+		t.Run("should only fail on unrecognized fields in strict mode", func(t *testing.T) {
+			validateCommand, _ := setup()
+			exitCode := validateCommand.Run([]string{"--path=../testdata/app_with_unknown_fields", "--junit-file=" + junitPath})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			functionCase := findCase(readReport(t).Suites[0], "functions/greet/config.json")
+			u.So(t, functionCase, gc.ShouldNotBeNil)
+			u.So(t, functionCase.Failure, gc.ShouldBeNil)
+			u.So(t, functionCase.SystemOut, gc.ShouldContainSubstring, ".run_as_sytem is not a recognized field")
+
+			validateCommand, _ = setup()
+			exitCode = validateCommand.Run([]string{"--path=../testdata/app_with_unknown_fields", "--strict", "--junit-file=" + junitPath})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+
+			functionCase = findCase(readReport(t).Suites[0], "functions/greet/config.json")
+			u.So(t, functionCase, gc.ShouldNotBeNil)
+			u.So(t, functionCase.Failure, gc.ShouldNotBeNil)
+		})
+
+		t.Run("should report a value that does not match its declared type as a failure", func(t *testing.T) {
+			projectConfig := &models.ProjectConfig{ValueTypes: map[string]string{"a": "string", "b": "number"}}
+			u.So(t, projectConfig.Save("../testdata/full_app"), gc.ShouldBeNil)
+			defer os.Remove(filepath.Join("../testdata/full_app", models.ProjectConfigFileName))
+
+			validateCommand, _ := setup()
+			exitCode := validateCommand.Run([]string{"--path=../testdata/full_app", "--junit-file=" + junitPath})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+
+			suite := readReport(t).Suites[0]
+			u.So(t, suite.Failures, gc.ShouldEqual, 1)
+
+			valueTypesCase := findCase(suite, "value types of the local app")
+			u.So(t, valueTypesCase, gc.ShouldNotBeNil)
+			u.So(t, valueTypesCase.Failure, gc.ShouldNotBeNil)
+			u.So(t, valueTypesCase.Failure.Message, gc.ShouldEqual, "1 value(s) of the local app do not match the types declared in .stitchrc")
+			u.So(t, valueTypesCase.Failure.Details, gc.ShouldEqual, "local app: value 'b' is string, but is declared as number")
+		})
+
+		t.Run("should report an exceeded limit as a failure", func(t *testing.T) {
+			validateCommand, _ := setup()
+			validateCommand.user = &user.User{
+				APIKey:      "my-api-key",
+				AccessToken: u.GenerateValidAccessToken(),
+			}
+			validateCommand.stitchClient = &u.MockStitchClient{
+				FetchLimitsFn: func() (*models.Limits, error) {
+					return &models.Limits{MaxFunctions: 2, MaxAssetSizeBytes: 64}, nil
+				},
+			}
+
+			exitCode := validateCommand.Run([]string{"--path=../testdata/full_app", "--check-limits", "--junit-file=" + junitPath})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+
+			suite := readReport(t).Suites[0]
+			u.So(t, suite.Failures, gc.ShouldEqual, 1)
+
+			limitsCase := findCase(suite, "limits")
+			u.So(t, limitsCase, gc.ShouldNotBeNil)
+			u.So(t, limitsCase.Failure, gc.ShouldNotBeNil)
+			u.So(t, limitsCase.Failure.Message, gc.ShouldEqual, "the app exceeds 1 limit(s) enforced by the server")
+			u.So(t, limitsCase.Failure.Details, gc.ShouldContainSubstring, "the hosting asset /ships/nostromo.json is 71 B")
+			u.So(t, limitsCase.SystemOut, gc.ShouldContainSubstring, "warning: the app has 2 functions, approaching the limit of 2")
+		})
+	})
+}
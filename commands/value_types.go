@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/10gen/stitch-cli/logging"
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/validation"
+)
+
+// localValues returns the values of the loaded app by name. Values that come from a secret are left out, as
+// their value is the name of the secret rather than the value functions see
+func localValues(loadedApp map[string]interface{}) map[string]interface{} {
+	values := map[string]interface{}{}
+
+	rawValues, _ := loadedApp["values"].([]interface{})
+	for _, rawValue := range rawValues {
+		value, ok := rawValue.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if fromSecret, _ := value["from_secret"].(bool); fromSecret {
+			continue
+		}
+
+		if name, ok := value["name"].(string); ok {
+			values[name] = value["value"]
+		}
+	}
+
+	return values
+}
+
+// remoteValues returns the values of a deployed app by name, leaving out those that come from a secret
+func remoteValues(appValues []models.Value) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	for _, appValue := range appValues {
+		if appValue.FromSecret {
+			continue
+		}
+
+		var value interface{}
+		if len(appValue.Value) > 0 {
+			if err := json.Unmarshal(appValue.Value, &value); err != nil {
+				return nil, fmt.Errorf("failed to read value '%s': %s", appValue.Name, err)
+			}
+		}
+		values[appValue.Name] = value
+	}
+
+	return values, nil
+}
+
+// checkValueTypes checks the values, of the app described by where, against the types declared in .stitchrc,
+// reporting each mismatch as an error and failing if there are any
+func checkValueTypes(log logging.Logger, where string, types map[string]string, values map[string]interface{}) error {
+	mismatches, err := validation.CheckValueTypes(types, values)
+	if err != nil {
+		return fmt.Errorf("invalid value_types in %s: %s", models.ProjectConfigFileName, err)
+	}
+
+	for _, mismatch := range mismatches {
+		log.Error(fmt.Sprintf("%s: %s", where, mismatch))
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d value(s) of the %s do not match the types declared in %s", len(mismatches), where, models.ProjectConfigFileName)
+	}
+
+	return nil
+}
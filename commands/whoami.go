@@ -2,10 +2,29 @@ package commands
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/user"
 
 	"github.com/mitchellh/cli"
 )
 
+const whoamiFlagProjects = "projects"
+
+// whoamiProjectsColumns are the columns of the projects listed by whoami --projects
+var whoamiProjectsColumns = []string{"project", "id", "role", "access"}
+
+// The access to the apps of a project that whoami --projects reports
+const (
+	projectAccessReadWrite = "read-write"
+	projectAccessReadOnly  = "read-only"
+	projectAccessUnknown   = "unknown"
+)
+
 // NewWhoamiCommandFactory returns a new cli.CommandFactory given a cli.Ui
 func NewWhoamiCommandFactory(ui cli.Ui) cli.CommandFactory {
 	return func() (cli.Command, error) {
@@ -21,6 +40,9 @@ func NewWhoamiCommandFactory(ui cli.Ui) cli.CommandFactory {
 // WhoamiCommand is used to print the name and API key of the current user
 type WhoamiCommand struct {
 	*BaseCommand
+
+	flagProjects bool
+	output       listOutput
 }
 
 // Synopsis returns a one-liner description for this command
@@ -30,29 +52,158 @@ func (whoami *WhoamiCommand) Synopsis() string {
 
 // Help returns long-form help information for this command
 func (whoami *WhoamiCommand) Help() string {
-	return `Print the name and API key associated with the current user.
+	return `Print the name and API key associated with the current user, and when their session expires. With --as, the
+identity stored under that name is printed instead. The names of the other stored identities are listed too.
 
-OPTIONS:` + whoami.BaseCommand.Help()
+OPTIONS:
+  --` + whoamiFlagProjects + `
+	Also list each Atlas project the API key can access, with its role in the project and whether that allows
+	changing the project's apps, e.g. to find out why an import was refused. The list is written as the
+	--output, --columns and --sort flags below choose, and is all that is written with --output csv.
+` + listOutputHelp(whoamiProjectsColumns) +
+		whoami.BaseCommand.Help()
 }
 
 // Run executes the command
 func (whoami *WhoamiCommand) Run(args []string) int {
+	flags := whoami.NewFlagSet()
+
+	flags.BoolVar(&whoami.flagProjects, whoamiFlagProjects, false, "")
+	whoami.output.registerFlags(flags)
+
 	if err := whoami.BaseCommand.run(args); err != nil {
-		whoami.UI.Error(err.Error())
+		whoami.Log().Error(err.Error())
 		return 1
 	}
 
 	user, err := whoami.User()
 	if err != nil {
-		whoami.UI.Error(err.Error())
+		whoami.Log().Error(err.Error())
 		return 1
 	}
 
+	// CSV holds nothing but the projects, so that it can be read as it is
+	if whoami.flagProjects && whoami.output.format == listOutputCSV {
+		if err := whoami.projects(user); err != nil {
+			whoami.Log().Error(err.Error())
+			return 1
+		}
+		return 0
+	}
+
 	message := "no user info available"
 	if publicAPIKey := user.PublicAPIKey; publicAPIKey != "" {
 		message = fmt.Sprintf("%s [API Key: %s]", publicAPIKey, user.RedactedAPIKey())
 	}
 
-	whoami.UI.Info(message)
+	if whoami.flagAs != "" {
+		message += fmt.Sprintf(" (identity '%s')", whoami.flagAs)
+	}
+	whoami.UI.Output(message)
+
+	if identities, err := whoami.storage.IdentityNames(); err == nil && len(identities) > 0 {
+		whoami.UI.Output("Stored identities, selected with --as: " + strings.Join(identities, ", "))
+	}
+
+	if sessionExpiry, ok := user.SessionExpiry(); ok && user.LoggedIn() {
+		if remaining := time.Until(sessionExpiry); remaining > 0 {
+			whoami.UI.Output(fmt.Sprintf("Session expires at %s (in %s)", sessionExpiry.UTC().Format(time.RFC3339), remaining.Round(time.Minute)))
+		} else {
+			whoami.UI.Output(fmt.Sprintf("Session expired at %s, run 'stitch-cli login' to log in again", sessionExpiry.UTC().Format(time.RFC3339)))
+		}
+	}
+
+	if whoami.flagProjects {
+		if err := whoami.projects(user); err != nil {
+			whoami.Log().Error(err.Error())
+			return 1
+		}
+	}
+
 	return 0
 }
+
+// projects lists the projects the user can access, with their roles in them
+func (whoami *WhoamiCommand) projects(user *u.User) error {
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	atlasClient, err := whoami.AtlasClient()
+	if err != nil {
+		return err
+	}
+
+	groups, err := atlasClient.Groups()
+	if err != nil {
+		return err
+	}
+
+	if len(groups) == 0 {
+		whoami.UI.Output("Your API key cannot access any project")
+		return nil
+	}
+
+	stitchClient, err := whoami.StitchClient()
+	if err != nil {
+		return err
+	}
+
+	profile, err := stitchClient.FetchUserProfile()
+	if err != nil {
+		return fmt.Errorf("failed to fetch the roles of your API key: %s", err)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	rows := make([][]string, len(groups))
+	var readOnly bool
+	for i, group := range groups {
+		role, access := projectAccess(profile, group)
+		readOnly = readOnly || access == projectAccessReadOnly
+		rows[i] = []string{group.Name, group.ID, role, access}
+	}
+
+	if whoami.output.format == listOutputCSV {
+		return whoami.output.write(whoami.UI, whoamiProjectsColumns, rows)
+	}
+
+	whoami.UI.Output("")
+	if err := whoami.output.write(whoami.UI, whoamiProjectsColumns, rows); err != nil {
+		return err
+	}
+
+	if readOnly {
+		whoami.UI.Output("")
+		whoami.UI.Output("Changing the apps of a read-only project, e.g. by importing them, requires the Project Owner role")
+	}
+
+	return nil
+}
+
+// projectAccess returns the roles the profile has in the group, or the organization roles that grant it access
+// if it has none there, and whether they allow changing the group's apps
+func projectAccess(profile *models.UserProfile, group mdbcloud.Group) (string, string) {
+	if roleNames := profile.GroupRoleNames(group.ID); len(roleNames) > 0 {
+		if canWriteWithProjectRoles(roleNames) {
+			return strings.Join(roleNames, ", "), projectAccessReadWrite
+		}
+		return strings.Join(roleNames, ", "), projectAccessReadOnly
+	}
+
+	orgRoles := map[string]bool{}
+	for _, roleName := range profile.OrgRoleNames(group.OrgID) {
+		orgRoles[roleName] = true
+	}
+
+	switch {
+	case orgRoles[orgOwnerRole]:
+		return orgOwnerRole + " (organization)", projectAccessReadWrite
+	case orgRoles[orgReadOnlyRole]:
+		return orgReadOnlyRole + " (organization)", projectAccessReadOnly
+	}
+
+	return "-", projectAccessUnknown
+}
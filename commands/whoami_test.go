@@ -1,9 +1,14 @@
 package commands
 
 import (
+	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/10gen/stitch-cli/api/mdbcloud"
+	"github.com/10gen/stitch-cli/models"
 	"github.com/10gen/stitch-cli/storage"
 	"github.com/10gen/stitch-cli/user"
 	u "github.com/10gen/stitch-cli/utils/test"
@@ -82,3 +87,124 @@ func TestWhoamiCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestWhoamiProjects(t *testing.T) {
+	setup := func(atlasClient *u.MockMDBClient, profile string) (*WhoamiCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewWhoamiCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		whoamiCommand := cmd.(*WhoamiCommand)
+		whoamiCommand.storage = u.NewEmptyStorage()
+		whoamiCommand.user = &user.User{
+			PublicAPIKey:  "storage.username",
+			PrivateAPIKey: "storage-api-key",
+			APIKey:        "my-api-key",
+			AccessToken:   u.GenerateValidAccessToken(),
+		}
+		whoamiCommand.atlasClient = atlasClient
+		whoamiCommand.stitchClient = &u.MockStitchClient{
+			FetchUserProfileFn: func() (*models.UserProfile, error) {
+				var userProfile models.UserProfile
+				err := json.Unmarshal([]byte(profile), &userProfile)
+				return &userProfile, err
+			},
+		}
+		return whoamiCommand, mockUI
+	}
+
+	t.Run("it lists the projects the API key can access with its roles in them", func(t *testing.T) {
+		whoamiCommand, mockUI := setup(&u.MockMDBClient{
+			GroupsFn: func() ([]mdbcloud.Group, error) {
+				return []mdbcloud.Group{
+					{ID: "group-4", Name: "Sandbox", OrgID: "org-3"},
+					{ID: "group-1", Name: "Production", OrgID: "org-1"},
+					{ID: "group-2", Name: "Analytics", OrgID: "org-1"},
+					{ID: "group-3", Name: "Marketing", OrgID: "org-2"},
+				}, nil
+			},
+		}, `{"roles": [
+			{"role_name": "GROUP_OWNER", "group_id": "group-1"},
+			{"role_name": "GROUP_READ_ONLY", "group_id": "group-2"},
+			{"role_name": "GROUP_DATA_ACCESS_READ_WRITE", "group_id": "group-2"},
+			{"role_name": "ORG_READ_ONLY", "org_id": "org-2"},
+			{"role_name": "ORG_OWNER", "org_id": "org-2"},
+			{"role_name": "ORG_MEMBER", "org_id": "org-3"}
+		]}`)
+
+		exitCode := whoamiCommand.Run([]string{"--projects"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"storage.username [API Key: *******-***-key]",
+			"",
+			"PROJECT     ID       ROLE                                           ACCESS",
+			"Analytics   group-2  GROUP_READ_ONLY, GROUP_DATA_ACCESS_READ_WRITE  read-only",
+			"Marketing   group-3  ORG_OWNER (organization)                       read-write",
+			"Production  group-1  GROUP_OWNER                                    read-write",
+			"Sandbox     group-4  -                                              unknown",
+			"",
+			"Changing the apps of a read-only project, e.g. by importing them, requires the Project Owner role",
+			"",
+		}, "\n"))
+	})
+
+	t.Run("it writes only the projects as CSV, with the columns and order asked for", func(t *testing.T) {
+		whoamiCommand, mockUI := setup(&u.MockMDBClient{
+			GroupsFn: func() ([]mdbcloud.Group, error) {
+				return []mdbcloud.Group{
+					{ID: "group-1", Name: "Production", OrgID: "org-1"},
+					{ID: "group-2", Name: "Analytics", OrgID: "org-1"},
+				}, nil
+			},
+		}, `{"roles": [
+			{"role_name": "GROUP_OWNER", "group_id": "group-1"},
+			{"role_name": "GROUP_READ_ONLY", "group_id": "group-2"}
+		]}`)
+
+		exitCode := whoamiCommand.Run([]string{"--projects", "--output=csv", "--columns=project,access", "--sort=project:desc"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "project,access\nProduction,read-write\nAnalytics,read-only\n")
+	})
+
+	t.Run("it says when the API key cannot access any project", func(t *testing.T) {
+		whoamiCommand, mockUI := setup(&u.MockMDBClient{
+			GroupsFn: func() ([]mdbcloud.Group, error) {
+				return nil, nil
+			},
+		}, `{"roles": []}`)
+
+		exitCode := whoamiCommand.Run([]string{"--projects"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Your API key cannot access any project")
+	})
+
+	t.Run("it requires being logged in", func(t *testing.T) {
+		whoamiCommand, mockUI := setup(&u.MockMDBClient{}, `{"roles": []}`)
+		whoamiCommand.user = &user.User{}
+
+		exitCode := whoamiCommand.Run([]string{"--projects"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, user.ErrNotLoggedIn.Error())
+	})
+}
+
+func TestWhoamiSession(t *testing.T) {
+	mockUI := cli.NewMockUi()
+	cmd, err := NewWhoamiCommandFactory(mockUI)()
+	u.So(t, err, gc.ShouldBeNil)
+
+	sessionExpiry := time.Now().Add(48 * time.Hour)
+	usr := &user.User{PublicAPIKey: "storage.username", PrivateAPIKey: "storage-api-key"}
+	usr.SetAccessToken(u.GenerateValidAccessToken())
+	usr.SetRefreshToken(u.GenerateToken(sessionExpiry))
+
+	whoamiCommand := cmd.(*WhoamiCommand)
+	whoamiCommand.user = usr
+	whoamiCommand.storage = u.NewEmptyStorage()
+
+	exitCode := whoamiCommand.Run([]string{})
+	u.So(t, exitCode, gc.ShouldEqual, 0)
+	u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Session expires at "+sessionExpiry.UTC().Format(time.RFC3339)+" (in 48h0m0s)")
+}
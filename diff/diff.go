@@ -0,0 +1,214 @@
+// Package diff compares stitch apps entity by entity, as the diff command does, returning their differences
+// as data so that other tools can build on them rather than on the command's output
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// The kinds of change a Change describes
+const (
+	Added    = "added"
+	Removed  = "removed"
+	Modified = "modified"
+	Renamed  = "renamed"
+)
+
+// The keys of an app, as loaded by utils.UnmarshalFromDir, that hold its functions and the config of each
+const (
+	functionsName = "functions"
+	configName    = "config"
+)
+
+// ignoredDiffFields are never compared, since they identify an entity within one app rather than describe it
+var ignoredDiffFields = map[string]bool{
+	"_id":         true,
+	"id":          true,
+	"app_id":      true,
+	"group_id":    true,
+	"function_id": true,
+	"service_id":  true,
+}
+
+// Change is a difference in a single entity, such as "functions/sum", between two apps
+type Change struct {
+	Entity string `json:"entity"`
+	Kind   string `json:"kind"`
+
+	// From is the name a renamed entity had in the first app
+	From string `json:"from,omitempty"`
+
+	// Fields are the paths of the fields that differ in a modified entity, in order
+	Fields []string `json:"fields,omitempty"`
+}
+
+// String formats the difference as a line of a diff, prefixed with "+", "-", "~" or "*"
+func (d Change) String() string {
+	switch d.Kind {
+	case Added:
+		return "+ " + d.Entity
+	case Removed:
+		return "- " + d.Entity
+	case Renamed:
+		return fmt.Sprintf("~ %s -> %s", d.From, d.Entity)
+	}
+	return fmt.Sprintf("* %s: %s", d.Entity, strings.Join(d.Fields, ", "))
+}
+
+// Apps compares two apps, as loaded by utils.UnmarshalFromDir, entity by entity, returning how to get from
+// the first to the second in order of entity. Entities are matched by name, and fields that identify them
+// within an app, such as IDs, are ignored. A function that is only in the first app and has the same
+// content as one only in the second is reported as renamed, in place of its removal
+func Apps(from, to map[string]interface{}) []Change {
+	fromEntities, toEntities := utils.AppEntities(from), utils.AppEntities(to)
+	renames := renamedFunctions(fromEntities, toEntities)
+
+	renamedTo := map[string]bool{}
+	for _, newName := range renames {
+		renamedTo[newName] = true
+	}
+
+	names := make([]string, 0, len(fromEntities)+len(toEntities))
+	for name := range fromEntities {
+		names = append(names, name)
+	}
+	for name := range toEntities {
+		if _, ok := fromEntities[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []Change
+	for _, name := range names {
+		fromEntity, inFrom := fromEntities[name]
+		toEntity, inTo := toEntities[name]
+
+		switch {
+		case renamedTo[name]:
+		case renames[name] != "":
+			diffs = append(diffs, Change{Entity: renames[name], Kind: Renamed, From: name})
+		case !inFrom:
+			diffs = append(diffs, Change{Entity: name, Kind: Added})
+		case !inTo:
+			diffs = append(diffs, Change{Entity: name, Kind: Removed})
+		default:
+			if fields := diffFields(fromEntity, toEntity, ""); len(fields) > 0 {
+				diffs = append(diffs, Change{Entity: name, Kind: Modified, Fields: fields})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// Dirs compares the apps in two local directories, as Apps does
+func Dirs(fromDir, toDir string) ([]Change, error) {
+	from, err := utils.UnmarshalFromDir(fromDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the app in %s: %s", fromDir, err)
+	}
+
+	to, err := utils.UnmarshalFromDir(toDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the app in %s: %s", toDir, err)
+	}
+
+	return Apps(from, to), nil
+}
+
+// renamedFunctions pairs the functions only in from with those only in to that have the same config, other
+// than their name, and source, returning the new name of each renamed function by its old name
+func renamedFunctions(from, to map[string]interface{}) map[string]string {
+	var removed, added []string
+	for name := range from {
+		if _, ok := to[name]; !ok && strings.HasPrefix(name, functionsName+"/") {
+			removed = append(removed, name)
+		}
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok && strings.HasPrefix(name, functionsName+"/") {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	renames := map[string]string{}
+	paired := map[string]bool{}
+	for _, oldName := range removed {
+		for _, newName := range added {
+			if !paired[newName] && len(diffFields(unnamedFunction(from[oldName]), unnamedFunction(to[newName]), "")) == 0 {
+				renames[oldName] = newName
+				paired[newName] = true
+				break
+			}
+		}
+	}
+
+	return renames
+}
+
+// unnamedFunction returns a copy of a function, as loaded from its directory, without the name in its config
+func unnamedFunction(fn interface{}) map[string]interface{} {
+	m, _ := fn.(map[string]interface{})
+
+	config := map[string]interface{}{}
+	if c, ok := m[configName].(map[string]interface{}); ok {
+		for key, value := range c {
+			if key != "name" {
+				config[key] = value
+			}
+		}
+	}
+
+	unnamed := map[string]interface{}{}
+	for key, value := range m {
+		unnamed[key] = value
+	}
+	unnamed[configName] = config
+
+	return unnamed
+}
+
+// diffFields returns the paths of the fields that differ between from and to, in order. Objects are compared
+// field by field, and anything else as a whole
+func diffFields(from, to interface{}, path string) []string {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if !fromIsMap || !toIsMap {
+		if reflect.DeepEqual(from, to) {
+			return nil
+		}
+		if path == "" {
+			path = "."
+		}
+		return []string{path}
+	}
+
+	keys := make([]string, 0, len(fromMap)+len(toMap))
+	for key := range fromMap {
+		keys = append(keys, key)
+	}
+	for key := range toMap {
+		if _, ok := fromMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var fields []string
+	for _, key := range keys {
+		if ignoredDiffFields[key] {
+			continue
+		}
+		fields = append(fields, diffFields(fromMap[key], toMap[key], path+"."+key)...)
+	}
+
+	return fields
+}
@@ -0,0 +1,88 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/diff"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestApps(t *testing.T) {
+	from := map[string]interface{}{
+		"app_id":   "from-abcde",
+		"location": "US-VA",
+		"functions": []interface{}{
+			map[string]interface{}{"config": map[string]interface{}{"id": "1", "name": "sum"}, "source": "exports = (a, b) => a + b;"},
+		},
+		"services": []interface{}{
+			map[string]interface{}{
+				"config":            map[string]interface{}{"id": "2", "name": "http1", "type": "http"},
+				"rules":             []interface{}{map[string]interface{}{"id": "3", "name": "get", "actions": []interface{}{"get"}}},
+				"incoming_webhooks": []interface{}{},
+			},
+		},
+	}
+	to := map[string]interface{}{
+		"app_id":   "to-fghij",
+		"location": "IE",
+		"functions": []interface{}{
+			map[string]interface{}{"config": map[string]interface{}{"id": "4", "name": "sum"}, "source": "exports = (a, b) => b + a;"},
+		},
+		"services": []interface{}{
+			map[string]interface{}{
+				"config":            map[string]interface{}{"id": "5", "name": "http1", "type": "http"},
+				"rules":             []interface{}{map[string]interface{}{"id": "6", "name": "get", "actions": []interface{}{"get", "post"}}},
+				"incoming_webhooks": []interface{}{},
+			},
+		},
+		"values": []interface{}{map[string]interface{}{"name": "limit", "value": 10.0}},
+	}
+
+	diffs := diff.Apps(from, to)
+	u.So(t, diffs, gc.ShouldResemble, []diff.Change{
+		{Entity: "functions/sum", Kind: diff.Modified, Fields: []string{".source"}},
+		{Entity: "services/http1/rules/get", Kind: diff.Modified, Fields: []string{".actions"}},
+		{Entity: "stitch.json", Kind: diff.Modified, Fields: []string{".location"}},
+		{Entity: "values/limit", Kind: diff.Added},
+	})
+
+	u.So(t, diff.Apps(to, from)[3], gc.ShouldResemble, diff.Change{Entity: "values/limit", Kind: diff.Removed})
+	u.So(t, diff.Apps(from, from), gc.ShouldBeEmpty)
+}
+
+func TestAppsRenamedFunctions(t *testing.T) {
+	newApp := func(functions ...map[string]interface{}) map[string]interface{} {
+		fns := make([]interface{}, 0, len(functions))
+		for _, fn := range functions {
+			fns = append(fns, fn)
+		}
+		return map[string]interface{}{"functions": fns}
+	}
+	newFunction := func(id, name, source string) map[string]interface{} {
+		return map[string]interface{}{
+			"config": map[string]interface{}{"id": id, "name": name, "private": true},
+			"source": source,
+		}
+	}
+
+	from := newApp(newFunction("1", "sum", "exports = (a, b) => a + b;"), newFunction("2", "old", "exports = () => 1;"))
+	to := newApp(newFunction("3", "add", "exports = (a, b) => a + b;"), newFunction("4", "new", "exports = () => 2;"))
+
+	u.So(t, diff.Apps(from, to), gc.ShouldResemble, []diff.Change{
+		{Entity: "functions/new", Kind: diff.Added},
+		{Entity: "functions/old", Kind: diff.Removed},
+		{Entity: "functions/add", Kind: diff.Renamed, From: "functions/sum"},
+	})
+	u.So(t, diff.Apps(from, to)[2].String(), gc.ShouldEqual, "~ functions/sum -> functions/add")
+}
+
+func TestDirs(t *testing.T) {
+	changes, err := diff.Dirs("../testdata/simple_app", "../testdata/simple_app_with_cluster")
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, changes, gc.ShouldResemble, []diff.Change{{Entity: "services/mongodb-atlas", Kind: diff.Added}})
+
+	_, err = diff.Dirs("../testdata/simple_app", "../testdata/does_not_exist")
+	u.So(t, err, gc.ShouldNotBeNil)
+}
@@ -0,0 +1,211 @@
+// Package functiontest discovers and runs the unit tests of an app's functions with Node.js, against a
+// context whose values and services are mocked from the app's local configuration.
+//
+// Node.js is used rather than a JavaScript engine embedded in the CLI because functions are written for a
+// runtime with modern JavaScript (async functions, classes, spread arguments) that can require npm
+// dependencies, neither of which the engines that can be vendored into a Go program support.
+package functiontest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+const (
+	// TestFileSuffix ends the name of every function test file
+	TestFileSuffix = ".test.js"
+
+	nodeCommand  = "node"
+	functionsDir = "functions"
+)
+
+// MinNodeVersion is the oldest version of Node.js the tests run with, being the first to provide the
+// module.createRequire the harness loads functions' dependencies with
+var MinNodeVersion = semver.MustParse("12.2.0")
+
+// Context is what the tests see as the "context" global: Values are returned by context.values.get, and
+// Services names the services that context.services.get returns a mock of, unless a test supplies its own
+// with mockService
+type Context struct {
+	Values   map[string]interface{} `json:"values"`
+	Services []string               `json:"services"`
+}
+
+// Result is the outcome of a single test
+type Result struct {
+	File     string  `json:"file"`
+	Name     string  `json:"name"`
+	OK       bool    `json:"ok"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration"`
+}
+
+// Report holds the results of a test run, along with anything the tests printed
+type Report struct {
+	Results []Result
+	Logs    []string
+}
+
+// Failed returns the number of tests that failed
+func (r *Report) Failed() int {
+	var failed int
+	for _, result := range r.Results {
+		if !result.OK {
+			failed++
+		}
+	}
+	return failed
+}
+
+type harnessInput struct {
+	AppPath string   `json:"appPath"`
+	Files   []string `json:"files"`
+	Context
+}
+
+// Discover returns the paths, relative to appPath and with forward slashes, of the test files under the
+// app's functions directory, in order
+func Discover(appPath string) ([]string, error) {
+	root := filepath.Join(appPath, functionsDir)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(info.Name(), TestFileSuffix) {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(appPath, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// CheckNode returns an error if the node command the tests are run with is not installed, or is older
+// than MinNodeVersion
+func CheckNode() error {
+	if _, err := exec.LookPath(nodeCommand); err != nil {
+		return fmt.Errorf("the %s command (https://nodejs.org) must be installed to run function tests", nodeCommand)
+	}
+
+	output, err := exec.Command(nodeCommand, "--version").Output()
+	if err != nil {
+		return fmt.Errorf("failed to find the version of %s: %s", nodeCommand, err)
+	}
+
+	return checkNodeVersion(strings.TrimSpace(string(output)))
+}
+
+// checkNodeVersion returns an error if the version printed by node --version is older than MinNodeVersion
+func checkNodeVersion(version string) error {
+	parsed, err := semver.ParseTolerant(version)
+	if err != nil {
+		return fmt.Errorf("failed to read the version of %s %q: %s", nodeCommand, version, err)
+	}
+
+	if parsed.LT(MinNodeVersion) {
+		return fmt.Errorf("function tests need Node.js %s or later, but %s is %s", MinNodeVersion, nodeCommand, version)
+	}
+	return nil
+}
+
+// Run runs the tests in the given files, relative to appPath, using the node command
+func Run(appPath string, files []string, context Context) (*Report, error) {
+	if err := CheckNode(); err != nil {
+		return nil, err
+	}
+
+	absAppPath, err := filepath.Abs(appPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if context.Values == nil {
+		context.Values = map[string]interface{}{}
+	}
+	if context.Services == nil {
+		context.Services = []string{}
+	}
+
+	input, err := json.Marshal(harnessInput{AppPath: absAppPath, Files: files, Context: context})
+	if err != nil {
+		return nil, err
+	}
+
+	harnessFile, err := ioutil.TempFile("", "stitch-function-test")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(harnessFile.Name())
+
+	if _, err := harnessFile.WriteString(harness); err != nil {
+		harnessFile.Close()
+		return nil, err
+	}
+	if err := harnessFile.Close(); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(nodeCommand, harnessFile.Name())
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+
+	report := &Report{}
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, resultPrefix) {
+			report.Logs = append(report.Logs, line)
+			continue
+		}
+
+		var result Result
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, resultPrefix)), &result); err != nil {
+			return nil, fmt.Errorf("failed to read test result: %s", err)
+		}
+		report.Results = append(report.Results, result)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if runErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s", nodeCommand, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %s", nodeCommand, runErr)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,177 @@
+package functiontest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/functiontest"
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func writeApp(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "stitch-function-test")
+	u.So(t, err, gc.ShouldBeNil)
+
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeApp(t, map[string]string{
+		"functions/sum/source.js":          "exports = function(a, b) { return a + b; };",
+		"functions/sum/sum.test.js":        "",
+		"functions/sum/more/edge.test.js":  "",
+		"functions/greet/greet.test.js":    "",
+		"functions/greet/helpers.js":       "",
+		"hosting/files/not-a-fn.test.js":   "",
+		"services/http1/incoming.test.js":  "",
+		"functions/weird/test.js":          "",
+		"functions/weird/config.test.json": "",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := functiontest.Discover(dir)
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, files, gc.ShouldResemble, []string{
+		"functions/greet/greet.test.js",
+		"functions/sum/more/edge.test.js",
+		"functions/sum/sum.test.js",
+	})
+
+	t.Run("it finds nothing in an app without functions", func(t *testing.T) {
+		emptyDir := writeApp(t, map[string]string{"stitch.json": "{}"})
+		defer os.RemoveAll(emptyDir)
+
+		files, err := functiontest.Discover(emptyDir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, files, gc.ShouldBeEmpty)
+	})
+}
+
+func TestFormatTAP(t *testing.T) {
+	report := &functiontest.Report{
+		Results: []functiontest.Result{
+			{File: "functions/sum/sum.test.js", Name: "adds", OK: true},
+			{File: "functions/sum/sum.test.js", Name: "subtracts", Error: "expected 1 to equal \"2\""},
+		},
+		Logs: []string{"debugging"},
+	}
+
+	u.So(t, functiontest.FormatTAP(report), gc.ShouldEqual, `TAP version 13
+1..2
+ok 1 - functions/sum/sum.test.js > adds
+not ok 2 - functions/sum/sum.test.js > subtracts
+  ---
+  message: "expected 1 to equal \"2\""
+  ...
+# debugging`)
+	u.So(t, report.Failed(), gc.ShouldEqual, 1)
+}
+
+func TestJUnitSuite(t *testing.T) {
+	report := &functiontest.Report{
+		Results: []functiontest.Result{
+			{File: "functions/sum/sum.test.js", Name: "adds", OK: true, Duration: 0.5},
+			{File: "functions/sum/sum.test.js", Name: "subtracts", Error: "expected 1 to equal 2", Duration: 0.25},
+		},
+		Logs: []string{"debugging"},
+	}
+
+	u.So(t, functiontest.JUnitSuite(report), gc.ShouldResemble, utils.JUnitTestSuite{
+		Name:     "stitch-cli test",
+		Tests:    2,
+		Failures: 1,
+		Time:     0.75,
+		Cases: []utils.JUnitTestCase{
+			{Name: "adds", ClassName: "functions/sum/sum.test.js", File: "functions/sum/sum.test.js", Time: 0.5},
+			{
+				Name:      "subtracts",
+				ClassName: "functions/sum/sum.test.js",
+				File:      "functions/sum/sum.test.js",
+				Time:      0.25,
+				Failure:   &utils.JUnitFailure{Message: "expected 1 to equal 2", Type: "AssertionError"},
+			},
+		},
+		SystemOut: "debugging\n",
+	})
+}
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		u.MustSkipf(t, "node is not installed")
+		return
+	}
+
+	dir := writeApp(t, map[string]string{
+		"functions/sum/source.js": "exports = function(a, b) { return a + b; };",
+		"functions/notify/source.js": `exports = async function(message) {
+  const http = context.services.get("http1");
+  await http.post({ url: context.values.get("webhookUrl"), body: message });
+  return context.functions.execute("sum", 1, 2);
+};`,
+		"functions/sum/sum.test.js": `test("adds", () => {
+  assert.strictEqual(loadFunction("sum")(1, 2), 3);
+});
+
+test("fails", () => {
+  console.log("about to fail");
+  assert.strictEqual(loadFunction("sum")(1, 2), 4);
+});`,
+		"functions/notify/notify.test.js": `test("posts to the webhook", async () => {
+  const posts = [];
+  mockService("http1", { post: async (req) => { posts.push(req); } });
+  const result = await loadFunction("notify")("hi");
+  assert.deepStrictEqual(posts, [{ url: "https://example.com/hook", body: "hi" }]);
+  assert.strictEqual(result, 3);
+});
+
+test("uses a default mock of services", async () => {
+  assert.strictEqual(await loadFunction("notify")("hi"), 3);
+});
+
+test("rejects unknown services", () => {
+  assert.throws(() => context.services.get("mongodb-atlas"), /not defined in the app/);
+});`,
+		"functions/broken/broken.test.js": "this is not javascript",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := functiontest.Discover(dir)
+	u.So(t, err, gc.ShouldBeNil)
+
+	report, err := functiontest.Run(dir, files, functiontest.Context{
+		Values:   map[string]interface{}{"webhookUrl": "https://example.com/hook"},
+		Services: []string{"http1"},
+	})
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, report.Logs, gc.ShouldResemble, []string{"about to fail"})
+	u.So(t, report.Failed(), gc.ShouldEqual, 2)
+
+	var summary []string
+	for _, result := range report.Results {
+		status := "ok"
+		if !result.OK {
+			status = "not ok"
+		}
+		summary = append(summary, status+" "+result.File+" > "+result.Name)
+	}
+	u.So(t, summary, gc.ShouldResemble, []string{
+		"not ok functions/broken/broken.test.js > (loading the file)",
+		"ok functions/notify/notify.test.js > posts to the webhook",
+		"ok functions/notify/notify.test.js > uses a default mock of services",
+		"ok functions/notify/notify.test.js > rejects unknown services",
+		"ok functions/sum/sum.test.js > adds",
+		"not ok functions/sum/sum.test.js > fails",
+	})
+	u.So(t, report.Results[5].Error, gc.ShouldContainSubstring, "3 !== 4")
+}
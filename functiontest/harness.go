@@ -0,0 +1,109 @@
+package functiontest
+
+// resultPrefix marks the lines of the harness's output that are test results, telling them apart from
+// anything the tests and functions themselves print
+const resultPrefix = "##stitch-test "
+
+// harness is the Node.js script that runs the test files. It reads the app path, test files, and context
+// as JSON from stdin, and prints a result line for each test
+const harness = `'use strict';
+const fs = require('fs');
+const path = require('path');
+const vm = require('vm');
+const assert = require('assert');
+const { createRequire } = require('module');
+
+const input = JSON.parse(fs.readFileSync(0, 'utf8'));
+
+function report(result) {
+  process.stdout.write('` + resultPrefix + `' + JSON.stringify(result) + '\n');
+}
+
+function describe(err) {
+  return err && err.message ? err.message : String(err);
+}
+
+function newContext(mocks) {
+  const context = {
+    values: {
+      get: (name) => input.values[name],
+    },
+    services: {
+      get: (name) => {
+        if (Object.prototype.hasOwnProperty.call(mocks, name)) {
+          return mocks[name];
+        }
+        if (input.services.indexOf(name) < 0) {
+          throw new Error('service "' + name + '" is not defined in the app');
+        }
+        return new Proxy({}, {
+          get: (target, prop) => (prop === 'then' ? undefined : () => undefined),
+        });
+      },
+    },
+    functions: {
+      execute: (name, ...args) => loadFunction(name, context)(...args),
+    },
+    environment: { tag: '' },
+  };
+  return context;
+}
+
+function loadFunction(name, context) {
+  const sourcePath = path.join(input.appPath, 'functions', name, 'source.js');
+  const source = fs.readFileSync(sourcePath, 'utf8');
+  const load = vm.compileFunction(
+    'var exports, module = {}; ' + source + '\nreturn exports || module.exports;',
+    ['context', 'require'],
+    { filename: sourcePath }
+  );
+  const fn = load(context, createRequire(sourcePath));
+  if (typeof fn !== 'function') {
+    throw new Error('function "' + name + '" does not export a function');
+  }
+  return fn;
+}
+
+async function runFile(file) {
+  const tests = [];
+  const mocks = {};
+  const context = newContext(mocks);
+  const filePath = path.join(input.appPath, file);
+  const globals = {
+    test: (name, fn) => tests.push({ name, fn }),
+    assert,
+    context,
+    mockService: (name, impl) => { mocks[name] = impl; },
+    loadFunction: (name) => loadFunction(name, context),
+    require: createRequire(filePath),
+  };
+
+  try {
+    const load = vm.compileFunction(fs.readFileSync(filePath, 'utf8'), Object.keys(globals), { filename: filePath });
+    load(...Object.values(globals));
+  } catch (err) {
+    report({ file, name: '(loading the file)', ok: false, error: describe(err), duration: 0 });
+    return;
+  }
+
+  for (const t of tests) {
+    Object.keys(mocks).forEach((name) => delete mocks[name]);
+    const start = Date.now();
+    try {
+      await t.fn();
+      report({ file, name: t.name, ok: true, duration: (Date.now() - start) / 1000 });
+    } catch (err) {
+      report({ file, name: t.name, ok: false, error: describe(err), duration: (Date.now() - start) / 1000 });
+    }
+  }
+}
+
+(async () => {
+  for (const file of input.files) {
+    await runFile(file);
+  }
+})().catch((err) => {
+  process.stderr.write(String((err && err.stack) || err));
+  process.exit(1);
+});
+`
@@ -0,0 +1,33 @@
+package functiontest
+
+import "github.com/10gen/stitch-cli/utils"
+
+const (
+	junitSuiteName   = "stitch-cli test"
+	junitFailureType = "AssertionError"
+)
+
+// JUnitSuite builds a JUnit test suite with a case for every test of the report, named after the file it is in.
+// Anything the tests printed is kept as the output of the suite
+func JUnitSuite(report *Report) utils.JUnitTestSuite {
+	suite := utils.JUnitTestSuite{Name: junitSuiteName}
+	for _, result := range report.Results {
+		testCase := utils.JUnitTestCase{
+			Name:      result.Name,
+			ClassName: result.File,
+			File:      result.File,
+			Time:      result.Duration,
+		}
+		if !result.OK {
+			testCase.Failure = &utils.JUnitFailure{Message: result.Error, Type: junitFailureType}
+		}
+
+		suite.AddCase(testCase)
+	}
+
+	for _, log := range report.Logs {
+		suite.SystemOut += log + "\n"
+	}
+
+	return suite
+}
@@ -0,0 +1,22 @@
+package functiontest
+
+import (
+	"testing"
+
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckNodeVersion(t *testing.T) {
+	for _, version := range []string{"v12.2.0", "v20.19.5"} {
+		u.So(t, checkNodeVersion(version), gc.ShouldBeNil)
+	}
+
+	err := checkNodeVersion("v10.24.1")
+	u.So(t, err, gc.ShouldNotBeNil)
+	u.So(t, err.Error(), gc.ShouldEqual, "function tests need Node.js 12.2.0 or later, but node is v10.24.1")
+
+	err = checkNodeVersion("not a version")
+	u.So(t, err, gc.ShouldNotBeNil)
+	u.So(t, err.Error(), gc.ShouldStartWith, `failed to read the version of node "not a version"`)
+}
@@ -0,0 +1,34 @@
+package functiontest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatTAP renders the report in the Test Anything Protocol, version 13
+func FormatTAP(report *Report) string {
+	lines := []string{"TAP version 13", fmt.Sprintf("1..%d", len(report.Results))}
+
+	for i, result := range report.Results {
+		status := "ok"
+		if !result.OK {
+			status = "not ok"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d - %s > %s", status, i+1, result.File, result.Name))
+
+		if !result.OK {
+			lines = append(lines,
+				"  ---",
+				"  message: "+strconv.Quote(result.Error),
+				"  ...",
+			)
+		}
+	}
+
+	for _, log := range report.Logs {
+		lines = append(lines, "# "+log)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,163 @@
+package hosting
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// Cache-Control values given to assets by default, according to their file extensions
+const (
+	CacheControlNoCache = "no-cache"
+	CacheControlOneDay  = "public, max-age=86400"
+)
+
+// defaultCacheControl maps file extensions to the Cache-Control given to assets with them by default. Pages are
+// always revalidated so that they pick up new versions of the assets they reference, which may be cached for longer
+var defaultCacheControl = map[string]string{
+	"html": CacheControlNoCache,
+	"htm":  CacheControlNoCache,
+
+	"css":   CacheControlOneDay,
+	"js":    CacheControlOneDay,
+	"mjs":   CacheControlOneDay,
+	"map":   CacheControlOneDay,
+	"png":   CacheControlOneDay,
+	"jpg":   CacheControlOneDay,
+	"jpeg":  CacheControlOneDay,
+	"gif":   CacheControlOneDay,
+	"webp":  CacheControlOneDay,
+	"svg":   CacheControlOneDay,
+	"ico":   CacheControlOneDay,
+	"woff":  CacheControlOneDay,
+	"woff2": CacheControlOneDay,
+	"ttf":   CacheControlOneDay,
+	"otf":   CacheControlOneDay,
+	"eot":   CacheControlOneDay,
+	"mp4":   CacheControlOneDay,
+	"webm":  CacheControlOneDay,
+}
+
+// GeneratedAssetDescriptions is the result of generating the entries of a metadata file
+type GeneratedAssetDescriptions struct {
+	// Descriptions are the entries of the metadata file, sorted by path
+	Descriptions []AssetDescription
+
+	// Updated are the paths of the assets whose entries were added or given new attributes
+	Updated []string
+
+	// Stale are the paths of the entries for assets that no longer exist. They are kept in Descriptions
+	// unless they are pruned
+	Stale []string
+}
+
+// DefaultAssetAttributes returns the attributes an asset at assetPath is given by default: the Content-Type
+// and Cache-Control of its file extension, where they are known
+func DefaultAssetAttributes(assetPath string) []AssetAttribute {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(assetPath), "."))
+	if ext == "" {
+		return nil
+	}
+
+	var attrs []AssetAttribute
+	if contentType, ok := utils.GetContentTypeByExtension(ext); ok {
+		attrs = append(attrs, AssetAttribute{Name: AttributeContentType, Value: contentType})
+	}
+	if cacheControl, ok := defaultCacheControl[ext]; ok {
+		attrs = append(attrs, AssetAttribute{Name: AttributeCacheControl, Value: cacheControl})
+	}
+
+	return attrs
+}
+
+// GenerateAssetDescriptions returns the metadata file entries for the assets under rootDir. Existing entries
+// keep their attributes, and are given the default attributes they do not set. Entries for assets that no longer
+// exist are reported as stale, and dropped if prune is set
+func GenerateAssetDescriptions(rootDir string, existing map[string]AssetDescription, prune bool) (*GeneratedAssetDescriptions, error) {
+	generated := &GeneratedAssetDescriptions{}
+	onDisk := map[string]bool{}
+
+	walkErr := filepath.Walk(rootDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == rootDir {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rootDir, filePath)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			onDisk[AssetPath(relPath)+"/"] = true
+			return nil
+		}
+		if info.Name() == utils.HostingDirectoryPlaceholder {
+			return nil
+		}
+
+		assetPath := AssetPath(relPath)
+		onDisk[assetPath] = true
+
+		desc := AssetDescription{FilePath: assetPath}
+		if existingDesc, ok := existing[assetPath]; ok {
+			desc.Attrs = append(desc.Attrs, existingDesc.Attrs...)
+		}
+
+		added := false
+		for _, attr := range DefaultAssetAttributes(assetPath) {
+			if !hasAttribute(desc.Attrs, attr.Name) {
+				desc.Attrs = append(desc.Attrs, attr)
+				added = true
+			}
+		}
+
+		if len(desc.Attrs) > 0 {
+			generated.Descriptions = append(generated.Descriptions, desc)
+		}
+		if added {
+			generated.Updated = append(generated.Updated, assetPath)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	for assetPath, desc := range existing {
+		if onDisk[assetPath] {
+			if strings.HasSuffix(assetPath, "/") {
+				generated.Descriptions = append(generated.Descriptions, desc)
+			}
+			continue
+		}
+
+		generated.Stale = append(generated.Stale, assetPath)
+		if !prune {
+			generated.Descriptions = append(generated.Descriptions, desc)
+		}
+	}
+
+	sort.Slice(generated.Descriptions, func(i, j int) bool {
+		return generated.Descriptions[i].FilePath < generated.Descriptions[j].FilePath
+	})
+	sort.Strings(generated.Updated)
+	sort.Strings(generated.Stale)
+
+	return generated, nil
+}
+
+func hasAttribute(attrs []AssetAttribute, name string) bool {
+	for _, attr := range attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package hosting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDefaultAssetAttributes(t *testing.T) {
+	u.So(t, hosting.DefaultAssetAttributes("/index.HTML"), gc.ShouldResemble, []hosting.AssetAttribute{
+		{Name: hosting.AttributeContentType, Value: "text/html"},
+		{Name: hosting.AttributeCacheControl, Value: hosting.CacheControlNoCache},
+	})
+	u.So(t, hosting.DefaultAssetAttributes("/js/app.js"), gc.ShouldResemble, []hosting.AssetAttribute{
+		{Name: hosting.AttributeContentType, Value: "application/x-javascript"},
+		{Name: hosting.AttributeCacheControl, Value: hosting.CacheControlOneDay},
+	})
+	u.So(t, hosting.DefaultAssetAttributes("/LICENSE"), gc.ShouldBeNil)
+}
+
+func TestGenerateAssetDescriptions(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "stitch-attrs")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(rootDir)
+
+	for _, name := range []string{"index.html", "js/app.js", "LICENSE"} {
+		path := filepath.Join(rootDir, filepath.FromSlash(name))
+		u.So(t, os.MkdirAll(filepath.Dir(path), os.ModePerm), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(name), 0644), gc.ShouldBeNil)
+	}
+
+	existing := map[string]hosting.AssetDescription{
+		"/index.html": {FilePath: "/index.html", Attrs: []hosting.AssetAttribute{
+			{Name: hosting.AttributeCacheControl, Value: "max-age=60"},
+		}},
+		"/old.html": {FilePath: "/old.html", Attrs: []hosting.AssetAttribute{
+			{Name: hosting.AttributeContentLanguage, Value: "en"},
+		}},
+	}
+
+	t.Run("it keeps existing attributes, adds missing defaults and reports stale entries", func(t *testing.T) {
+		generated, err := hosting.GenerateAssetDescriptions(rootDir, existing, false)
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, generated.Descriptions, gc.ShouldResemble, []hosting.AssetDescription{
+			{FilePath: "/index.html", Attrs: []hosting.AssetAttribute{
+				{Name: hosting.AttributeCacheControl, Value: "max-age=60"},
+				{Name: hosting.AttributeContentType, Value: "text/html"},
+			}},
+			{FilePath: "/js/app.js", Attrs: []hosting.AssetAttribute{
+				{Name: hosting.AttributeContentType, Value: "application/x-javascript"},
+				{Name: hosting.AttributeCacheControl, Value: hosting.CacheControlOneDay},
+			}},
+			existing["/old.html"],
+		})
+		u.So(t, generated.Updated, gc.ShouldResemble, []string{"/index.html", "/js/app.js"})
+		u.So(t, generated.Stale, gc.ShouldResemble, []string{"/old.html"})
+	})
+
+	t.Run("it drops stale entries when pruning", func(t *testing.T) {
+		generated, err := hosting.GenerateAssetDescriptions(rootDir, existing, true)
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, generated.Descriptions, gc.ShouldHaveLength, 2)
+		u.So(t, generated.Stale, gc.ShouldResemble, []string{"/old.html"})
+	})
+}
@@ -0,0 +1,203 @@
+package hosting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// DefaultDomain is the domain whose subdomain named after the Client App ID of an app serves its hosted assets,
+// unless it has a custom domain
+const DefaultDomain = "mongodbstitch.com"
+
+// AppURL returns the URL that the hosted assets of an app are served at
+func AppURL(clientAppID, customDomain string) string {
+	if customDomain != "" {
+		return "https://" + customDomain
+	}
+	return fmt.Sprintf("https://%s.%s", clientAppID, DefaultDomain)
+}
+
+// Config represents the app-wide static hosting settings, as opposed to those of individual assets
+type Config struct {
+	Enabled          bool       `json:"enabled"`
+	CustomDomain     string     `json:"custom_domain,omitempty"`
+	DefaultErrorPath string     `json:"default_error_path,omitempty"`
+	DefaultErrorCode int        `json:"default_error_code,omitempty"`
+	Redirects        []Redirect `json:"redirects,omitempty"`
+	Rewrites         []Rewrite  `json:"rewrites,omitempty"`
+
+	// DefaultHeaders are applied to every asset that does not set the same attribute itself
+	DefaultHeaders []AssetAttribute `json:"default_headers,omitempty"`
+}
+
+// Redirect responds to requests for paths matching From with a redirect to To
+type Redirect struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// Rewrite serves the asset at To for requests for paths matching From, without redirecting
+type Rewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ConfigSettings are the names of the settings in a Config
+var ConfigSettings = []string{
+	"custom_domain",
+	"default_error_code",
+	"default_error_path",
+	"default_headers",
+	"enabled",
+	"redirects",
+	"rewrites",
+}
+
+// Get returns the JSON value of the named setting, or null if it is unset
+func (c *Config) Get(setting string) (json.RawMessage, error) {
+	if !isConfigSetting(setting) {
+		return nil, errUnknownSetting(setting)
+	}
+
+	value, ok := configToMap(c)[setting]
+	if !ok {
+		return json.RawMessage("null"), nil
+	}
+
+	return value, nil
+}
+
+// Set changes the named setting to value, which may be JSON or, for string settings, plain text
+func (c *Config) Set(setting, value string) error {
+	if !isConfigSetting(setting) {
+		return errUnknownSetting(setting)
+	}
+
+	raw := json.RawMessage(value)
+	if !json.Valid(raw) {
+		quoted, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		raw = quoted
+	}
+
+	settings := configToMap(c)
+	settings[setting] = raw
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	var updated Config
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return fmt.Errorf("invalid value for %s: %s", setting, err)
+	}
+
+	*c = updated
+	return nil
+}
+
+// ParseConfig parses a JSON Config, rejecting unrecognized settings
+func ParseConfig(data []byte) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var config Config
+	if err := dec.Decode(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// ConfigFileToConfig reads and parses the Config in the file at path
+func ConfigFileToConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseConfig(data)
+}
+
+// MergeConfig returns local with any settings it leaves unset taken from remote
+func MergeConfig(local, remote *Config) *Config {
+	settings := configToMap(remote)
+	for setting, value := range configToMap(local) {
+		settings[setting] = value
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		panic(err)
+	}
+
+	var merged Config
+	if err := json.Unmarshal(data, &merged); err != nil {
+		// both sides came from a Config, so the result is always a valid one
+		panic(err)
+	}
+
+	return &merged
+}
+
+// DiffConfig returns a list of strings representing the changes needed to make remote match local
+func DiffConfig(local, remote *Config) []string {
+	localSettings := configToMap(local)
+	remoteSettings := configToMap(remote)
+
+	var diff []string
+	for _, setting := range ConfigSettings {
+		localValue, inLocal := localSettings[setting]
+		remoteValue, inRemote := remoteSettings[setting]
+
+		switch {
+		case inLocal && !inRemote:
+			diff = append(diff, fmt.Sprintf("\t+ %s: %s", setting, localValue))
+		case !inLocal && inRemote:
+			diff = append(diff, fmt.Sprintf("\t- %s: %s", setting, remoteValue))
+		case inLocal && !bytes.Equal(localValue, remoteValue):
+			diff = append(diff, fmt.Sprintf("\t* %s: %s => %s", setting, remoteValue, localValue))
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil
+	}
+
+	return append([]string{"Hosting Config:"}, diff...)
+}
+
+func configToMap(c *Config) map[string]json.RawMessage {
+	data, err := json.Marshal(c)
+	if err != nil {
+		// a Config always marshals
+		panic(err)
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		panic(err)
+	}
+
+	return settings
+}
+
+func isConfigSetting(setting string) bool {
+	for _, s := range ConfigSettings {
+		if s == setting {
+			return true
+		}
+	}
+
+	return false
+}
+
+func errUnknownSetting(setting string) error {
+	return fmt.Errorf("unknown hosting setting %q; valid settings are %v", setting, ConfigSettings)
+}
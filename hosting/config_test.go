@@ -0,0 +1,137 @@
+package hosting_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConfigGet(t *testing.T) {
+	config := &hosting.Config{
+		Enabled:      true,
+		CustomDomain: "www.example.com",
+		Redirects:    []hosting.Redirect{{From: "/old", To: "/new", StatusCode: 301}},
+	}
+
+	for _, tc := range []struct {
+		setting  string
+		expected string
+	}{
+		{"enabled", `true`},
+		{"custom_domain", `"www.example.com"`},
+		{"redirects", `[{"from":"/old","to":"/new","status_code":301}]`},
+		{"default_error_path", `null`},
+	} {
+		t.Run("should get "+tc.setting, func(t *testing.T) {
+			value, err := config.Get(tc.setting)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, string(value), gc.ShouldEqual, tc.expected)
+		})
+	}
+
+	t.Run("should fail for an unknown setting", func(t *testing.T) {
+		_, err := config.Get("custom_domains")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `unknown hosting setting "custom_domains"`)
+	})
+}
+
+func TestConfigSet(t *testing.T) {
+	t.Run("should set a string setting from plain text", func(t *testing.T) {
+		config := &hosting.Config{Enabled: true}
+		u.So(t, config.Set("default_error_path", "/404.html"), gc.ShouldBeNil)
+		u.So(t, config, gc.ShouldResemble, &hosting.Config{Enabled: true, DefaultErrorPath: "/404.html"})
+	})
+
+	t.Run("should set settings from JSON", func(t *testing.T) {
+		config := &hosting.Config{}
+		u.So(t, config.Set("default_error_code", "404"), gc.ShouldBeNil)
+		u.So(t, config.Set("rewrites", `[{"from": "/app/*", "to": "/index.html"}]`), gc.ShouldBeNil)
+		u.So(t, config, gc.ShouldResemble, &hosting.Config{
+			DefaultErrorCode: 404,
+			Rewrites:         []hosting.Rewrite{{From: "/app/*", To: "/index.html"}},
+		})
+	})
+
+	t.Run("should unset a setting set to null", func(t *testing.T) {
+		config := &hosting.Config{CustomDomain: "www.example.com"}
+		u.So(t, config.Set("custom_domain", "null"), gc.ShouldBeNil)
+		u.So(t, config, gc.ShouldResemble, &hosting.Config{})
+	})
+
+	t.Run("should fail for a value of the wrong type", func(t *testing.T) {
+		config := &hosting.Config{}
+		err := config.Set("default_error_code", "not found")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldStartWith, "invalid value for default_error_code")
+	})
+
+	t.Run("should fail for an unknown setting", func(t *testing.T) {
+		config := &hosting.Config{}
+		u.So(t, config.Set("bogus", "true"), gc.ShouldNotBeNil)
+	})
+}
+
+func TestParseConfig(t *testing.T) {
+	t.Run("should parse a config", func(t *testing.T) {
+		config, err := hosting.ParseConfig([]byte(`{"enabled": true, "default_error_path": "/404.html"}`))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, config, gc.ShouldResemble, &hosting.Config{Enabled: true, DefaultErrorPath: "/404.html"})
+	})
+
+	t.Run("should reject unknown settings", func(t *testing.T) {
+		_, err := hosting.ParseConfig([]byte(`{"enabled": true, "custom_domains": "www.example.com"}`))
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+}
+
+func TestMergeConfig(t *testing.T) {
+	local := &hosting.Config{
+		Enabled:  true,
+		Rewrites: []hosting.Rewrite{{From: "/*", To: "/index.html"}},
+	}
+	remote := &hosting.Config{
+		CustomDomain: "www.example.com",
+		Rewrites:     []hosting.Rewrite{{From: "/app/*", To: "/app.html"}},
+	}
+
+	u.So(t, hosting.MergeConfig(local, remote), gc.ShouldResemble, &hosting.Config{
+		Enabled:      true,
+		CustomDomain: "www.example.com",
+		Rewrites:     []hosting.Rewrite{{From: "/*", To: "/index.html"}},
+	})
+}
+
+func TestDiffConfig(t *testing.T) {
+	t.Run("identical configs should have no diff", func(t *testing.T) {
+		config := &hosting.Config{Enabled: true, DefaultErrorPath: "/404.html"}
+		u.So(t, hosting.DiffConfig(config, config), gc.ShouldBeNil)
+	})
+
+	t.Run("should list added, removed, and changed settings", func(t *testing.T) {
+		local := &hosting.Config{
+			Enabled:          true,
+			DefaultErrorPath: "/404.html",
+			DefaultHeaders:   []hosting.AssetAttribute{{Name: "Cache-Control", Value: "no-cache"}},
+		}
+		remote := &hosting.Config{
+			Enabled:          true,
+			CustomDomain:     "www.example.com",
+			DefaultErrorPath: "/error.html",
+		}
+
+		u.So(t, hosting.DiffConfig(local, remote), gc.ShouldResemble, []string{
+			"Hosting Config:",
+			`	- custom_domain: "www.example.com"`,
+			`	* default_error_path: "/error.html" => "/404.html"`,
+			`	+ default_headers: [{"name":"Cache-Control","value":"no-cache"}]`,
+		})
+	})
+}
+
+func TestAppURL(t *testing.T) {
+	u.So(t, hosting.AppURL("my-app-abcde", ""), gc.ShouldEqual, "https://my-app-abcde.mongodbstitch.com")
+	u.So(t, hosting.AppURL("my-app-abcde", "www.example.com"), gc.ShouldEqual, "https://www.example.com")
+}
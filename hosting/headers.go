@@ -0,0 +1,41 @@
+package hosting
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Header is a response header an asset is served with
+type Header struct {
+	Name  string
+	Value string
+
+	// Default is true if the header comes from the default headers of the hosting config rather than the
+	// attributes of the asset
+	Default bool
+}
+
+// EffectiveHeaders returns the headers the asset is served with, sorted by name: its attributes, along with
+// each of the default headers of config that it does not set itself. config may be nil
+func EffectiveHeaders(asset AssetMetadata, config *Config) []Header {
+	var headers []Header
+	set := map[string]bool{}
+	for _, attr := range asset.Attrs {
+		headers = append(headers, Header{Name: attr.Name, Value: attr.Value})
+		set[http.CanonicalHeaderKey(attr.Name)] = true
+	}
+
+	if config != nil {
+		for _, attr := range config.DefaultHeaders {
+			if !set[http.CanonicalHeaderKey(attr.Name)] {
+				headers = append(headers, Header{Name: attr.Name, Value: attr.Value, Default: true})
+			}
+		}
+	}
+
+	sort.SliceStable(headers, func(i, j int) bool {
+		return http.CanonicalHeaderKey(headers[i].Name) < http.CanonicalHeaderKey(headers[j].Name)
+	})
+
+	return headers
+}
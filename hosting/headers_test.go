@@ -0,0 +1,39 @@
+package hosting_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEffectiveHeaders(t *testing.T) {
+	asset := hosting.AssetMetadata{
+		FilePath: "/index.html",
+		Attrs: []hosting.AssetAttribute{
+			{Name: hosting.AttributeContentType, Value: "text/html"},
+			{Name: hosting.AttributeCacheControl, Value: "no-cache"},
+		},
+	}
+
+	t.Run("should only return the attributes of the asset without a config", func(t *testing.T) {
+		u.So(t, hosting.EffectiveHeaders(asset, nil), gc.ShouldResemble, []hosting.Header{
+			{Name: hosting.AttributeCacheControl, Value: "no-cache"},
+			{Name: hosting.AttributeContentType, Value: "text/html"},
+		})
+	})
+
+	t.Run("should add the default headers the asset does not set itself", func(t *testing.T) {
+		config := &hosting.Config{DefaultHeaders: []hosting.AssetAttribute{
+			{Name: "cache-control", Value: "public, max-age=3600"},
+			{Name: "Content-Language", Value: "en"},
+		}}
+
+		u.So(t, hosting.EffectiveHeaders(asset, config), gc.ShouldResemble, []hosting.Header{
+			{Name: hosting.AttributeCacheControl, Value: "no-cache"},
+			{Name: "Content-Language", Value: "en", Default: true},
+			{Name: hosting.AttributeContentType, Value: "text/html"},
+		})
+	})
+}
@@ -3,8 +3,11 @@ package hosting
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/10gen/stitch-cli/utils"
 )
@@ -28,6 +31,9 @@ func ListLocalAssetMetadata(appID, rootDirectory string, assetDescriptions map[s
 
 	for key := range assetDescriptions {
 		if _, ok := metadataOnDisk[key]; !ok {
+			if strings.HasSuffix(key, "/") {
+				return nil, fmt.Errorf("directory '%s' has an entry in metadata file, but does not appear in files directory", key)
+			}
 			return nil, fmt.Errorf("file '%s' has an entry in metadata file, but does not appear in files directory", key)
 		}
 	}
@@ -40,12 +46,40 @@ func buildAssetMetadata(appID string, assetMetadata *[]AssetMetadata, rootDir st
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
+		if info.IsDir() {
+			if path == rootDir {
+				return nil
+			}
+
 			relPath, pathErr := filepath.Rel(rootDir, path)
 			if pathErr != nil {
 				return pathErr
 			}
-			assetPath := fmt.Sprintf("/%s", relPath)
+			assetPath := AssetPath(relPath) + "/"
+
+			desc, hasDesc := assetDescriptions[assetPath]
+			if !hasDesc {
+				empty, emptyErr := isEmptyDirectory(path)
+				if emptyErr != nil || !empty {
+					return emptyErr
+				}
+			}
+
+			attrs := []AssetAttribute{}
+			if hasDesc {
+				attrs = desc.Attrs
+			}
+
+			*assetMetadata = append(*assetMetadata, *NewAssetMetadata(appID, assetPath, "", 0, attrs, info.ModTime().Unix()))
+			return nil
+		}
+
+		if info.Name() != utils.HostingDirectoryPlaceholder {
+			relPath, pathErr := filepath.Rel(rootDir, path)
+			if pathErr != nil {
+				return pathErr
+			}
+			assetPath := AssetPath(relPath)
 
 			var desc *AssetDescription
 			if assetDescriptions != nil {
@@ -66,6 +100,29 @@ func buildAssetMetadata(appID string, assetMetadata *[]AssetMetadata, rootDir st
 	}
 }
 
+// AssetPath returns the path an asset is hosted at given its path relative to the hosting files directory.
+// Separators are made forward slashes and unicode characters are composed, so that the asset is found at
+// the URL browsers request for it whatever the local filesystem's conventions
+func AssetPath(relPath string) string {
+	return "/" + utils.ComposeUnicode(filepath.ToSlash(relPath))
+}
+
+// isEmptyDirectory reports whether the directory at path contains nothing but, possibly, a placeholder
+func isEmptyDirectory(path string) (bool, error) {
+	fileInfos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	for _, fileInfo := range fileInfos {
+		if fileInfo.Name() != utils.HostingDirectoryPlaceholder {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 // FileToAssetMetadata generates a file hash for the given file
 // and generates the assetAttributes and creates an AssetMetadata from these
 // if the file hash has changed this will update the assetCache
@@ -127,6 +184,7 @@ func MetadataFileToAssetDescriptions(path string) (map[string]AssetDescription,
 
 	descM := make(map[string]AssetDescription, len(descs))
 	for _, desc := range descs {
+		desc.FilePath = utils.ComposeUnicode(desc.FilePath)
 		descM[desc.FilePath] = desc
 	}
 
@@ -172,6 +230,34 @@ func UpdateCacheFile(path string, assetCache AssetCache) error {
 	return f.Close()
 }
 
+// ManifestFileToAssetMetadata attempts to open the asset manifest at the path given, as written
+// by WriteManifestFile, and return the AssetMetadata it lists
+func ManifestFileToAssetMetadata(path string) ([]AssetMetadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	assetMetadata := []AssetMetadata{}
+	if decErr := json.NewDecoder(f).Decode(&assetMetadata); decErr != nil {
+		return nil, decErr
+	}
+
+	return assetMetadata, nil
+}
+
+// WriteManifestFile writes a snapshot of the given AssetMetadata to the file at path so that
+// it can later be diffed against without access to Stitch
+func WriteManifestFile(path string, assetMetadata []AssetMetadata) error {
+	data, err := json.MarshalIndent(assetMetadata, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
 // DiffAssetMetadata compares a local and remote []AssetMetadata and returns a AssetMetadataDiffs
 // which contains information about the differences between the two
 // if the merge paramater is true than me ignore deleted assets
@@ -183,11 +269,18 @@ func DiffAssetMetadata(local, remote []AssetMetadata, merge bool) *AssetMetadata
 	// Ignore the root directory
 	delete(remoteAM, "/")
 
+	// Directories that still hold local assets are kept even though they have no entries of their own
+	for dir := range AncestorDirectories(local) {
+		delete(remoteAM, dir)
+	}
+
 	for _, lAM := range local {
 		if rAM, ok := remoteAM[lAM.FilePath]; !ok {
 			addedLocally = append(addedLocally, lAM)
 		} else {
 			modifiedAM := GetModifiedAssetMetadata(lAM, rAM)
+			// directories have no contents to compare
+			modifiedAM.BodyModified = modifiedAM.BodyModified && !lAM.IsDir()
 			if modifiedAM.BodyModified || modifiedAM.AttrModified {
 				modifiedLocally = append(modifiedLocally, modifiedAM)
 			}
@@ -207,30 +300,139 @@ func DiffAssetMetadata(local, remote []AssetMetadata, merge bool) *AssetMetadata
 	return NewAssetMetadataDiffs(addedLocally, deletedLocally, modifiedLocally)
 }
 
-// Diff returns a list of strings representing the diff
+// AncestorDirectories returns the paths, with trailing slashes, of the directories containing the assets,
+// other than the root directory
+func AncestorDirectories(assetMetadata []AssetMetadata) map[string]bool {
+	dirs := map[string]bool{}
+	for _, am := range assetMetadata {
+		for dir := path.Dir(strings.TrimSuffix(am.FilePath, "/")); dir != "/" && dir != "."; dir = path.Dir(dir) {
+			dirs[dir+"/"] = true
+		}
+	}
+	return dirs
+}
+
+// Diff returns a list of strings representing the diff. A removed file with the same content as an added
+// one is listed as renamed, though it is still deleted and uploaded again. Files whose attributes alone were
+// modified are listed apart from those whose contents were
 func (amd *AssetMetadataDiffs) Diff() []string {
 	var diff []string
 
-	if len(amd.AddedLocally) > 0 {
+	added, deleted, renamed := amd.renamedAssets()
+
+	if len(added) > 0 {
 		diff = append(diff, "New Files:")
 	}
-	for _, added := range amd.AddedLocally {
-		diff = append(diff, fmt.Sprintf("\t+ %s", added.FilePath))
+	for _, asset := range added {
+		diff = append(diff, fmt.Sprintf("\t+ %s", asset.FilePath))
 	}
 
-	if len(amd.DeletedLocally) > 0 {
+	if len(deleted) > 0 {
 		diff = append(diff, "Removed Files:")
 	}
-	for _, deleted := range amd.DeletedLocally {
-		diff = append(diff, fmt.Sprintf("\t- %s", deleted.FilePath))
+	for _, asset := range deleted {
+		diff = append(diff, fmt.Sprintf("\t- %s", asset.FilePath))
 	}
 
-	if len(amd.ModifiedLocally) > 0 {
-		diff = append(diff, "Modified Files:")
+	if len(renamed) > 0 {
+		diff = append(diff, "Renamed Files:")
+	}
+	for _, rename := range renamed {
+		diff = append(diff, fmt.Sprintf("\t~ %s -> %s", rename[0], rename[1]))
 	}
+
+	// assets whose attributes alone changed have only their attributes updated, rather than being uploaded again
+	var modifiedBodies, modifiedAttrs []ModifiedAssetMetadata
 	for _, modified := range amd.ModifiedLocally {
+		if modified.AttrModified && !modified.BodyModified {
+			modifiedAttrs = append(modifiedAttrs, modified)
+		} else {
+			modifiedBodies = append(modifiedBodies, modified)
+		}
+	}
+
+	if len(modifiedBodies) > 0 {
+		diff = append(diff, "Modified Files:")
+	}
+	for _, modified := range modifiedBodies {
+		diff = append(diff, fmt.Sprintf("\t* %s", modified.AssetMetadata.FilePath))
+	}
+
+	if len(modifiedAttrs) > 0 {
+		diff = append(diff, "Modified Attributes (not uploaded again):")
+	}
+	for _, modified := range modifiedAttrs {
 		diff = append(diff, fmt.Sprintf("\t* %s", modified.AssetMetadata.FilePath))
 	}
 
 	return diff
 }
+
+// renamedAssets pairs each deleted file with an added file of the same content, returning the added and
+// deleted assets that are left along with the old and new paths of each pair
+func (amd *AssetMetadataDiffs) renamedAssets() ([]AssetMetadata, []AssetMetadata, [][2]string) {
+	var added, deleted []AssetMetadata
+	var renamed [][2]string
+
+	paired := make([]bool, len(amd.AddedLocally))
+	for _, d := range amd.DeletedLocally {
+		match := -1
+		if d.FileHash != "" && !d.IsDir() {
+			for i, a := range amd.AddedLocally {
+				if !paired[i] && a.FileHash == d.FileHash && a.FileSize == d.FileSize {
+					match = i
+					break
+				}
+			}
+		}
+
+		if match == -1 {
+			deleted = append(deleted, d)
+			continue
+		}
+
+		paired[match] = true
+		renamed = append(renamed, [2]string{d.FilePath, amd.AddedLocally[match].FilePath})
+	}
+
+	for i, a := range amd.AddedLocally {
+		if !paired[i] {
+			added = append(added, a)
+		}
+	}
+
+	return added, deleted, renamed
+}
+
+// OpenAsset opens the file of the asset at assetPath under rootDir. Since asset paths are composed by
+// AssetPath, the file may be named differently on disk, in which case each directory along the way is
+// searched for the entry whose composed name matches
+func OpenAsset(rootDir, assetPath string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(rootDir, filepath.FromSlash(assetPath)))
+	if err == nil || !os.IsNotExist(err) {
+		return f, err
+	}
+
+	filePath := rootDir
+	for _, segment := range strings.Split(strings.TrimPrefix(assetPath, "/"), "/") {
+		fileInfos, readErr := ioutil.ReadDir(filePath)
+		if readErr != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, fileInfo := range fileInfos {
+			if utils.ComposeUnicode(fileInfo.Name()) == segment {
+				filePath = filepath.Join(filePath, fileInfo.Name())
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, err
+		}
+	}
+
+	return os.Open(filePath)
+}
@@ -3,6 +3,7 @@ package hosting_test
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -685,4 +686,146 @@ func TestAssetMetadataDiff(t *testing.T) {
 		amd := hosting.NewAssetMetadataDiffs(added, deleted, modified)
 		u.So(t, amd.Diff(), gc.ShouldResemble, append(append(addDiff, deleteDiff...), modifyDiff...))
 	})
+
+	t.Run("with a removed file that has the same content as an added file", func(t *testing.T) {
+		amd := hosting.NewAssetMetadataDiffs(
+			[]hosting.AssetMetadata{{FilePath: "/new.js", FileHash: "abc", FileSize: 3}, {FilePath: "/other.js", FileHash: "def", FileSize: 3}},
+			[]hosting.AssetMetadata{{FilePath: "/old.js", FileHash: "abc", FileSize: 3}, {FilePath: "/gone.js", FileHash: "ghi", FileSize: 3}},
+			[]hosting.ModifiedAssetMetadata{},
+		)
+		u.So(t, amd.Diff(), gc.ShouldResemble, []string{
+			"New Files:",
+			"\t+ /other.js",
+			"Removed Files:",
+			"\t- /gone.js",
+			"Renamed Files:",
+			"\t~ /old.js -> /new.js",
+		})
+	})
+
+	t.Run("with files whose attributes alone were modified", func(t *testing.T) {
+		amd := hosting.NewAssetMetadataDiffs(
+			[]hosting.AssetMetadata{},
+			[]hosting.AssetMetadata{},
+			[]hosting.ModifiedAssetMetadata{
+				{AssetMetadata: hosting.AssetMetadata{FilePath: "/app.js"}, BodyModified: true, AttrModified: true},
+				{AssetMetadata: hosting.AssetMetadata{FilePath: "/video.mp4"}, AttrModified: true},
+			},
+		)
+		u.So(t, amd.Diff(), gc.ShouldResemble, []string{
+			"Modified Files:",
+			"\t* /app.js",
+			"Modified Attributes (not uploaded again):",
+			"\t* /video.mp4",
+		})
+	})
+}
+
+func TestListLocalAssetMetadataDirectories(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "stitch-hosting-dirs")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(rootDir)
+
+	for _, dir := range []string{"empty", "kept", "described", "full/nested"} {
+		u.So(t, os.MkdirAll(filepath.Join(rootDir, dir), os.ModePerm), gc.ShouldBeNil)
+	}
+	u.So(t, ioutil.WriteFile(filepath.Join(rootDir, "kept", utils.HostingDirectoryPlaceholder), []byte{}, 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(rootDir, "described", "index.html"), []byte("hi"), 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(rootDir, "full", "nested", utils.HostingDirectoryPlaceholder), []byte{}, 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(rootDir, "full", "nested", "app.js"), []byte("js"), 0644), gc.ShouldBeNil)
+
+	cacheControl := hosting.AssetAttribute{Name: hosting.AttributeCacheControl, Value: "no-cache"}
+	assetDescriptions := map[string]hosting.AssetDescription{
+		"/described/": {FilePath: "/described/", Attrs: []hosting.AssetAttribute{cacheControl}},
+	}
+
+	assetMetadata, err := hosting.ListLocalAssetMetadata("3720", rootDir, assetDescriptions, hosting.NewAssetCache())
+	u.So(t, err, gc.ShouldBeNil)
+
+	var paths []string
+	attrsByPath := map[string][]hosting.AssetAttribute{}
+	for _, am := range assetMetadata {
+		paths = append(paths, am.FilePath)
+		attrsByPath[am.FilePath] = am.Attrs
+	}
+
+	t.Run("it lists empty directories, directories holding only a placeholder, and described directories", func(t *testing.T) {
+		u.So(t, paths, gc.ShouldResemble, []string{
+			"/described/",
+			"/described/index.html",
+			"/empty/",
+			"/full/nested/app.js",
+			"/kept/",
+		})
+		u.So(t, attrsByPath["/described/"], gc.ShouldResemble, []hosting.AssetAttribute{cacheControl})
+		u.So(t, attrsByPath["/empty/"], gc.ShouldResemble, []hosting.AssetAttribute{})
+	})
+
+	t.Run("it errors when a described directory does not exist", func(t *testing.T) {
+		_, err := hosting.ListLocalAssetMetadata("3720", rootDir, map[string]hosting.AssetDescription{
+			"/missing/": {FilePath: "/missing/"},
+		}, hosting.NewAssetCache())
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, "directory '/missing/' has an entry in metadata file, but does not appear in files directory")
+	})
+}
+
+func TestDiffAssetMetadataDirectories(t *testing.T) {
+	emptyDir := hosting.AssetMetadata{FilePath: "/empty/", Attrs: []hosting.AssetAttribute{}}
+	nestedFile := hosting.AssetMetadata{FilePath: "/ships/nostromo.json", FileHash: "abc", Attrs: []hosting.AssetAttribute{}}
+
+	t.Run("it does not remove directories that still hold local assets when replacing", func(t *testing.T) {
+		remote := []hosting.AssetMetadata{
+			{FilePath: "/", FileHash: "root"},
+			{FilePath: "/ships/", FileHash: "dir"},
+			{FilePath: "/gone/", FileHash: "dir"},
+			nestedFile,
+		}
+
+		diffs := hosting.DiffAssetMetadata([]hosting.AssetMetadata{nestedFile}, remote, false)
+		u.So(t, diffs.DeletedLocally, gc.ShouldResemble, []hosting.AssetMetadata{{FilePath: "/gone/", FileHash: "dir"}})
+	})
+
+	t.Run("it only compares the attributes of directories", func(t *testing.T) {
+		remote := []hosting.AssetMetadata{{FilePath: "/empty/", FileHash: "dir", Attrs: []hosting.AssetAttribute{}}}
+		u.So(t, hosting.DiffAssetMetadata([]hosting.AssetMetadata{emptyDir}, remote, true), gc.ShouldResemble, hosting.NewAssetMetadataDiffs(nil, nil, nil))
+	})
+
+	t.Run("it adds directories missing remotely", func(t *testing.T) {
+		diffs := hosting.DiffAssetMetadata([]hosting.AssetMetadata{emptyDir}, nil, true)
+		u.So(t, diffs.AddedLocally, gc.ShouldResemble, []hosting.AssetMetadata{emptyDir})
+	})
+}
+
+func TestAssetPaths(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "stitch-hosting-unicode")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(rootDir)
+
+	// named in decomposed form, as macOS does
+	decomposedDir := filepath.Join(rootDir, "re\u0301sume\u0301s")
+	u.So(t, os.MkdirAll(decomposedDir, os.ModePerm), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(decomposedDir, "my cv #1?.html"), []byte("cv"), 0644), gc.ShouldBeNil)
+
+	t.Run("local asset paths should be composed", func(t *testing.T) {
+		assetMetadata, err := hosting.ListLocalAssetMetadata("3720", rootDir, nil, hosting.NewAssetCache())
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, assetMetadata, gc.ShouldHaveLength, 1)
+		u.So(t, assetMetadata[0].FilePath, gc.ShouldEqual, "/r\u00e9sum\u00e9s/my cv #1?.html")
+	})
+
+	t.Run("OpenAsset should find files by their composed paths", func(t *testing.T) {
+		f, err := hosting.OpenAsset(rootDir, "/r\u00e9sum\u00e9s/my cv #1?.html")
+		u.So(t, err, gc.ShouldBeNil)
+		defer f.Close()
+
+		data, err := ioutil.ReadAll(f)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(data), gc.ShouldEqual, "cv")
+	})
+
+	t.Run("OpenAsset should report missing files", func(t *testing.T) {
+		_, err := hosting.OpenAsset(rootDir, "/r\u00e9sum\u00e9s/missing.html")
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+	})
 }
@@ -0,0 +1,58 @@
+package hosting
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// InvalidateAllPath is the CDN cache invalidation path covering every asset
+const InvalidateAllPath = "/*"
+
+// CheckInvalidationGlobs returns an error for the first glob that is not a valid pattern
+func CheckInvalidationGlobs(globs []string) error {
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid invalidation path %q: %s", glob, err)
+		}
+	}
+
+	return nil
+}
+
+// InvalidationPaths returns the paths whose CDN cache should be invalidated after the changes in diffs are made:
+// every path when no globs are given, or else the path of each added, deleted or modified asset that matches
+// one of the globs, as path.Match would
+func InvalidationPaths(diffs *AssetMetadataDiffs, globs []string) ([]string, error) {
+	if len(globs) == 0 {
+		return []string{InvalidateAllPath}, nil
+	}
+
+	if err := CheckInvalidationGlobs(globs); err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, asset := range diffs.AddedLocally {
+		changed = append(changed, asset.FilePath)
+	}
+	for _, asset := range diffs.DeletedLocally {
+		changed = append(changed, asset.FilePath)
+	}
+	for _, modified := range diffs.ModifiedLocally {
+		changed = append(changed, modified.AssetMetadata.FilePath)
+	}
+
+	var paths []string
+	for _, assetPath := range changed {
+		for _, glob := range globs {
+			if matched, _ := path.Match(glob, assetPath); matched {
+				paths = append(paths, assetPath)
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
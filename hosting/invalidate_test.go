@@ -0,0 +1,40 @@
+package hosting_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInvalidationPaths(t *testing.T) {
+	diffs := hosting.NewAssetMetadataDiffs(
+		[]hosting.AssetMetadata{{FilePath: "/index.html"}, {FilePath: "/js/app.js"}},
+		[]hosting.AssetMetadata{{FilePath: "/old.html"}},
+		[]hosting.ModifiedAssetMetadata{{AssetMetadata: hosting.AssetMetadata{FilePath: "/docs/guide.html"}}},
+	)
+
+	t.Run("it invalidates every path without globs", func(t *testing.T) {
+		paths, err := hosting.InvalidationPaths(diffs, nil)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, paths, gc.ShouldResemble, []string{hosting.InvalidateAllPath})
+	})
+
+	t.Run("it invalidates the changed paths matching a glob", func(t *testing.T) {
+		paths, err := hosting.InvalidationPaths(diffs, []string{"/*.html"})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, paths, gc.ShouldResemble, []string{"/index.html", "/old.html"})
+
+		paths, err = hosting.InvalidationPaths(diffs, []string{"/*/*.html", "/js/app.js", "/missing.css"})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, paths, gc.ShouldResemble, []string{"/docs/guide.html", "/js/app.js"})
+	})
+
+	t.Run("it rejects invalid globs", func(t *testing.T) {
+		_, err := hosting.InvalidationPaths(diffs, []string{"/[.html"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `invalid invalidation path "/[.html"`)
+	})
+}
@@ -0,0 +1,111 @@
+package hosting
+
+import (
+	"bufio"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// linkPattern matches the href and src attributes of HTML tags, capturing their double-quoted, single-quoted or
+// unquoted values
+var linkPattern = regexp.MustCompile(`(?i)\s(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// BrokenLink is a reference in an HTML asset to a path that no asset is hosted at
+type BrokenLink struct {
+	// Asset is the path of the HTML asset the reference is in
+	Asset string
+
+	// Line is the line of the asset the reference is on, counting from 1
+	Line int
+
+	// Reference is the link as written in the asset
+	Reference string
+
+	// Path is the asset path the reference resolves to
+	Path string
+}
+
+// IsHTML is true if the asset at assetPath is an HTML page, judging by its file extension
+func IsHTML(assetPath string) bool {
+	ext := strings.ToLower(path.Ext(assetPath))
+	return ext == ".html" || ext == ".htm"
+}
+
+// CheckLinks reads the HTML assets among assets from rootDir and returns the references in them to paths that
+// hosted does not report as having an asset. Links to other sites, fragments of the same page and non-HTTP URLs
+// are not checked
+func CheckLinks(rootDir string, assets []AssetMetadata, hosted func(assetPath string) bool) ([]BrokenLink, error) {
+	var broken []BrokenLink
+	for _, asset := range assets {
+		if asset.IsDir() || !IsHTML(asset.FilePath) {
+			continue
+		}
+
+		links, err := checkAssetLinks(rootDir, asset.FilePath, hosted)
+		if err != nil {
+			return nil, err
+		}
+		broken = append(broken, links...)
+	}
+
+	return broken, nil
+}
+
+func checkAssetLinks(rootDir, assetPath string, hosted func(assetPath string) bool) ([]BrokenLink, error) {
+	f, err := OpenAsset(rootDir, assetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var broken []BrokenLink
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), MaxAssetSizeBytes)
+	for line := 1; scanner.Scan(); line++ {
+		for _, match := range linkPattern.FindAllStringSubmatch(scanner.Text(), -1) {
+			reference := match[1] + match[2] + match[3]
+
+			linkedPath, ok := ResolveLink(assetPath, reference)
+			if !ok || hosted(linkedPath) {
+				continue
+			}
+
+			broken = append(broken, BrokenLink{Asset: assetPath, Line: line, Reference: reference, Path: linkedPath})
+		}
+	}
+
+	return broken, scanner.Err()
+}
+
+// ResolveLink returns the asset path a reference in the asset at assetPath resolves to, without its query or
+// fragment. A reference to a directory resolves to its index.html. It returns false for references that do not
+// point at an asset of the same site
+func ResolveLink(assetPath, reference string) (string, bool) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" || strings.HasPrefix(reference, "#") || strings.HasPrefix(reference, "//") {
+		return "", false
+	}
+
+	u, err := url.Parse(reference)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+
+	linkedPath := u.Path
+	if !strings.HasPrefix(linkedPath, "/") {
+		linkedPath = path.Join(path.Dir(assetPath), linkedPath)
+		if strings.HasSuffix(u.Path, "/") || u.Path == "." || u.Path == ".." {
+			linkedPath += "/"
+		}
+	}
+
+	if strings.HasSuffix(linkedPath, "/") {
+		linkedPath = path.Clean(linkedPath + "index.html")
+	} else {
+		linkedPath = path.Clean(linkedPath)
+	}
+
+	return linkedPath, true
+}
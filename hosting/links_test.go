@@ -0,0 +1,61 @@
+package hosting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolveLink(t *testing.T) {
+	for _, tc := range []struct {
+		reference string
+		expected  string
+		ok        bool
+	}{
+		{"/app.js", "/app.js", true},
+		{"style.css?v=2", "/docs/style.css", true},
+		{"../img/logo%20dark.png#top", "/img/logo dark.png", true},
+		{"./", "/docs/index.html", true},
+		{"/", "/index.html", true},
+		{"guide/", "/docs/guide/index.html", true},
+		{"#section", "", false},
+		{"https://example.com/app.js", "", false},
+		{"//cdn.example.com/app.js", "", false},
+		{"mailto:someone@example.com", "", false},
+		{"", "", false},
+	} {
+		t.Run("should resolve "+tc.reference, func(t *testing.T) {
+			resolved, ok := hosting.ResolveLink("/docs/page.html", tc.reference)
+			u.So(t, ok, gc.ShouldEqual, tc.ok)
+			u.So(t, resolved, gc.ShouldEqual, tc.expected)
+		})
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "stitch-check-links")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(rootDir)
+
+	u.So(t, ioutil.WriteFile(filepath.Join(rootDir, "index.html"), []byte(`<html>
+<link rel="stylesheet" href="style.css">
+<script src='/missing.js'></script>
+<a href=about.html>About</a> <a href="https://example.com">Elsewhere</a>
+</html>`), 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(rootDir, "style.css"), []byte(`body { background: url("/gone.png"); }`), 0644), gc.ShouldBeNil)
+
+	assets := []hosting.AssetMetadata{{FilePath: "/index.html"}, {FilePath: "/style.css"}}
+	hosted := map[string]bool{"/index.html": true, "/style.css": true}
+
+	broken, err := hosting.CheckLinks(rootDir, assets, func(assetPath string) bool { return hosted[assetPath] })
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, broken, gc.ShouldResemble, []hosting.BrokenLink{
+		{Asset: "/index.html", Line: 3, Reference: "/missing.js", Path: "/missing.js"},
+		{Asset: "/index.html", Line: 4, Reference: "about.html", Path: "/about.html"},
+	})
+}
@@ -29,6 +29,10 @@ var ValidAttributeNames = map[string]bool{
 	AttributeWebsiteRedirectLocation: true,
 }
 
+// MaxAssetSizeBytes is the largest hosting asset Stitch accepts, which is checked against when the server
+// does not report its limit
+const MaxAssetSizeBytes = 25 * 1024 * 1024
+
 // AssetMetadata represents the metadata of a static hosted asset
 type AssetMetadata struct {
 	AppID        string           `json:"appId,omitempty"`
@@ -0,0 +1,86 @@
+package hosting
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// Kinds of hosting asset operations
+const (
+	OperationUpload        = "upload"
+	OperationDelete        = "delete"
+	OperationSetAttributes = "set_attributes"
+)
+
+// FailedOperation describes a hosting asset operation that failed during an import
+type FailedOperation struct {
+	Operation string `json:"operation"`
+	FilePath  string `json:"path"`
+	Reason    string `json:"reason"`
+}
+
+// RetryList records the hosting asset operations that failed while importing an app so that they can be retried
+type RetryList struct {
+	GroupID     string            `json:"group_id"`
+	AppID       string            `json:"app_id"`
+	ClientAppID string            `json:"client_app_id"`
+	Failures    []FailedOperation `json:"failures"`
+}
+
+// AssetMetadataDiffs returns the diffs that would redo the failed operations, given the metadata of the
+// local assets. Failed uploads and attribute updates of assets that no longer exist locally are returned
+// separately since they can not be retried
+func (rl *RetryList) AssetMetadataDiffs(local []AssetMetadata) (*AssetMetadataDiffs, []FailedOperation) {
+	localAM := AssetsMetadata(local).MapByPath()
+
+	var added, deleted []AssetMetadata
+	var modified []ModifiedAssetMetadata
+	var missing []FailedOperation
+	for _, failure := range rl.Failures {
+		if failure.Operation == OperationDelete {
+			deleted = append(deleted, AssetMetadata{FilePath: failure.FilePath})
+			continue
+		}
+
+		am, ok := localAM[failure.FilePath]
+		if !ok {
+			missing = append(missing, FailedOperation{failure.Operation, failure.FilePath, "the file no longer exists locally"})
+			continue
+		}
+
+		if failure.Operation == OperationSetAttributes {
+			modified = append(modified, ModifiedAssetMetadata{AssetMetadata: am, AttrModified: true})
+		} else {
+			added = append(added, am)
+		}
+	}
+
+	return NewAssetMetadataDiffs(added, deleted, modified), missing
+}
+
+// RetryFileToRetryList attempts to open the retry list at the path given, as written by WriteRetryFile
+func RetryFileToRetryList(path string) (*RetryList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var retryList RetryList
+	if decErr := json.NewDecoder(f).Decode(&retryList); decErr != nil {
+		return nil, decErr
+	}
+
+	return &retryList, nil
+}
+
+// WriteRetryFile writes the retry list to the file at path
+func WriteRetryFile(path string, retryList *RetryList) error {
+	data, err := json.MarshalIndent(retryList, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
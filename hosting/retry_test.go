@@ -0,0 +1,52 @@
+package hosting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetryList(t *testing.T) {
+	retryList := &hosting.RetryList{
+		GroupID:     "group-id",
+		AppID:       "app-id",
+		ClientAppID: "my-app-abcdef",
+		Failures: []hosting.FailedOperation{
+			{Operation: hosting.OperationUpload, FilePath: "/index.html", Reason: "timeout"},
+			{Operation: hosting.OperationDelete, FilePath: "/old.html", Reason: "timeout"},
+			{Operation: hosting.OperationSetAttributes, FilePath: "/app.js", Reason: "timeout"},
+			{Operation: hosting.OperationUpload, FilePath: "/gone.css", Reason: "timeout"},
+		},
+	}
+
+	t.Run("it round trips through a file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "stitch-retry-list")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "retry.json")
+		u.So(t, hosting.WriteRetryFile(path, retryList), gc.ShouldBeNil)
+
+		read, err := hosting.RetryFileToRetryList(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, read, gc.ShouldResemble, retryList)
+	})
+
+	t.Run("it builds the diffs that redo the failed operations", func(t *testing.T) {
+		indexHTML := hosting.AssetMetadata{FilePath: "/index.html", FileHash: "abc"}
+		appJS := hosting.AssetMetadata{FilePath: "/app.js", FileHash: "def", Attrs: []hosting.AssetAttribute{{Name: hosting.AttributeCacheControl, Value: "no-cache"}}}
+
+		diffs, missing := retryList.AssetMetadataDiffs([]hosting.AssetMetadata{indexHTML, appJS})
+		u.So(t, diffs.AddedLocally, gc.ShouldResemble, []hosting.AssetMetadata{indexHTML})
+		u.So(t, diffs.DeletedLocally, gc.ShouldResemble, []hosting.AssetMetadata{{FilePath: "/old.html"}})
+		u.So(t, diffs.ModifiedLocally, gc.ShouldResemble, []hosting.ModifiedAssetMetadata{{AssetMetadata: appJS, AttrModified: true}})
+		u.So(t, missing, gc.ShouldResemble, []hosting.FailedOperation{
+			{Operation: hosting.OperationUpload, FilePath: "/gone.css", Reason: "the file no longer exists locally"},
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package hosting
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// MergeRoots links the assets of each of the roots, whose directories are relative to appPath, into a new
+// staging directory under the root's prefix, so that they can be listed and uploaded as if they came from a
+// single hosting files directory. It fails if two roots provide the same asset. The caller is responsible for
+// removing the returned directory
+func MergeRoots(appPath string, roots []models.HostingRoot) (string, error) {
+	stagingDir, err := ioutil.TempDir("", "stitch-hosting-roots")
+	if err != nil {
+		return "", err
+	}
+
+	if err := mergeRoots(stagingDir, appPath, roots); err != nil {
+		os.RemoveAll(stagingDir)
+		return "", err
+	}
+
+	return stagingDir, nil
+}
+
+func mergeRoots(stagingDir, appPath string, roots []models.HostingRoot) error {
+	// providers maps each asset path to the directory of the root that provides it
+	providers := map[string]string{}
+
+	for _, root := range roots {
+		if root.Dir == "" {
+			return fmt.Errorf("hosting root with prefix %q has no dir", root.Prefix)
+		}
+		if !strings.HasPrefix(root.Prefix, "/") {
+			return fmt.Errorf("invalid prefix %q for hosting root '%s': prefixes must start with \"/\"", root.Prefix, root.Dir)
+		}
+
+		rootDir := filepath.Join(appPath, filepath.FromSlash(root.Dir))
+		prefixDir := filepath.Join(stagingDir, filepath.FromSlash(path.Clean(root.Prefix)))
+
+		walkErr := filepath.Walk(rootDir, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(rootDir, filePath)
+			if err != nil {
+				return err
+			}
+
+			// directories are recreated so that empty ones are still uploaded
+			if info.IsDir() {
+				return os.MkdirAll(filepath.Join(prefixDir, relPath), os.ModePerm)
+			}
+			if info.Name() == utils.HostingDirectoryPlaceholder {
+				return nil
+			}
+
+			assetPath := path.Join(path.Clean(root.Prefix), AssetPath(relPath))
+			if other, ok := providers[assetPath]; ok {
+				return fmt.Errorf("asset '%s' is provided by both hosting roots '%s' and '%s'", assetPath, other, root.Dir)
+			}
+			providers[assetPath] = root.Dir
+
+			return linkOrCopyFile(filePath, filepath.Join(prefixDir, relPath))
+		})
+		if walkErr != nil {
+			return fmt.Errorf("failed to read hosting root '%s': %s", root.Dir, walkErr)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package hosting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMergeRoots(t *testing.T) {
+	setup := func(t *testing.T, files map[string]string) string {
+		appPath, err := ioutil.TempDir("", "stitch-roots")
+		u.So(t, err, gc.ShouldBeNil)
+
+		for name, contents := range files {
+			path := filepath.Join(appPath, filepath.FromSlash(name))
+			u.So(t, os.MkdirAll(filepath.Dir(path), os.ModePerm), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(path, []byte(contents), 0644), gc.ShouldBeNil)
+		}
+
+		return appPath
+	}
+
+	t.Run("it hosts the assets of each root under its prefix", func(t *testing.T) {
+		appPath := setup(t, map[string]string{
+			"web/dist/index.html":   "<html>web</html>",
+			"web/dist/js/app.js":    "var app;",
+			"docs/build/index.html": "<html>docs</html>",
+		})
+		defer os.RemoveAll(appPath)
+		u.So(t, os.MkdirAll(filepath.Join(appPath, "docs", "build", "empty"), os.ModePerm), gc.ShouldBeNil)
+
+		stagingDir, err := hosting.MergeRoots(appPath, []models.HostingRoot{
+			{Dir: "web/dist", Prefix: "/"},
+			{Dir: "docs/build", Prefix: "/docs"},
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(stagingDir)
+
+		assetMetadata, err := hosting.ListLocalAssetMetadata("my-app", stagingDir, nil, hosting.NewAssetCache())
+		u.So(t, err, gc.ShouldBeNil)
+
+		var paths []string
+		for _, am := range assetMetadata {
+			paths = append(paths, am.FilePath)
+		}
+		u.So(t, paths, gc.ShouldResemble, []string{"/docs/empty/", "/docs/index.html", "/index.html", "/js/app.js"})
+
+		data, err := ioutil.ReadFile(filepath.Join(stagingDir, "docs", "index.html"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(data), gc.ShouldEqual, "<html>docs</html>")
+	})
+
+	t.Run("it fails if two roots provide the same asset", func(t *testing.T) {
+		appPath := setup(t, map[string]string{
+			"web/dist/docs/index.html": "<html>web</html>",
+			"docs/build/index.html":    "<html>docs</html>",
+		})
+		defer os.RemoveAll(appPath)
+
+		_, err := hosting.MergeRoots(appPath, []models.HostingRoot{
+			{Dir: "web/dist", Prefix: "/"},
+			{Dir: "docs/build", Prefix: "/docs"},
+		})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "asset '/docs/index.html' is provided by both hosting roots 'web/dist' and 'docs/build'")
+	})
+
+	t.Run("it rejects a prefix that is not absolute", func(t *testing.T) {
+		appPath := setup(t, map[string]string{"docs/build/index.html": "<html>docs</html>"})
+		defer os.RemoveAll(appPath)
+
+		_, err := hosting.MergeRoots(appPath, []models.HostingRoot{{Dir: "docs/build", Prefix: "docs"}})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, `invalid prefix "docs" for hosting root 'docs/build': prefixes must start with "/"`)
+	})
+
+	t.Run("it fails if a root does not exist", func(t *testing.T) {
+		appPath := setup(t, nil)
+		defer os.RemoveAll(appPath)
+
+		_, err := hosting.MergeRoots(appPath, []models.HostingRoot{{Dir: "missing", Prefix: "/"}})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldStartWith, "failed to read hosting root 'missing'")
+	})
+}
@@ -0,0 +1,286 @@
+package hosting
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// fingerprintLength is the number of hash characters inserted into the name of a fingerprinted asset
+const fingerprintLength = 8
+
+// TransformAssets copies the assets under rootDir into a new staging directory, running each asset through
+// the transforms that match it on the way. References to fingerprinted assets in HTML, CSS and JavaScript
+// assets are rewritten to their new paths, and so are the entries of assetDescriptions. The caller is
+// responsible for removing the returned directory
+func TransformAssets(rootDir string, transforms []models.AssetTransform, assetDescriptions map[string]AssetDescription) (string, error) {
+	stagingDir, err := ioutil.TempDir("", "stitch-hosting")
+	if err != nil {
+		return "", err
+	}
+
+	fingerprinting := false
+	for _, transform := range transforms {
+		fingerprinting = fingerprinting || transform.Fingerprint
+	}
+
+	// assets that are transformed, or that may refer to fingerprinted assets, are written once all are read
+	f := &fingerprinter{assets: map[string]*stagedAsset{}, names: map[string]string{}, visiting: map[string]bool{}, cyclic: map[string]bool{}}
+	walkErr := filepath.Walk(rootDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(rootDir, filePath)
+		if err != nil {
+			return err
+		}
+
+		// directories are recreated so that empty ones are still uploaded
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(stagingDir, relPath), os.ModePerm)
+		}
+		assetPath := AssetPath(relPath)
+
+		matching, err := matchingTransforms(assetPath, transforms)
+		if err != nil {
+			return err
+		}
+
+		if len(matching) == 0 && !(fingerprinting && refersToAssets(assetPath)) {
+			return linkOrCopyFile(filePath, filepath.Join(stagingDir, relPath))
+		}
+
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		asset := &stagedAsset{mode: info.Mode()}
+		for _, transform := range matching {
+			if data, err = utils.RunFilter(transform.Command, []string{"ASSET_PATH=" + assetPath}, data); err != nil {
+				return fmt.Errorf("transforming '%s' with %q failed => %s", assetPath, transform.Command, err)
+			}
+			asset.fingerprint = asset.fingerprint || transform.Fingerprint
+		}
+		asset.data = data
+		f.assets[assetPath] = asset
+
+		return nil
+	})
+
+	if walkErr == nil {
+		walkErr = f.write(stagingDir, assetDescriptions)
+	}
+
+	if walkErr != nil {
+		os.RemoveAll(stagingDir)
+		return "", walkErr
+	}
+
+	return stagingDir, nil
+}
+
+// stagedAsset is an asset read by TransformAssets, whose data has been transformed
+type stagedAsset struct {
+	data        []byte
+	mode        os.FileMode
+	fingerprint bool
+}
+
+// fingerprinter names fingerprinted assets by a hash of their contents once the references in them to other
+// fingerprinted assets have been rewritten, so that an asset is renamed whenever an asset it refers to is
+type fingerprinter struct {
+	assets   map[string]*stagedAsset
+	names    map[string]string
+	visiting map[string]bool
+
+	// cyclic assets refer to themselves through other assets, and are named by a hash of their contents before
+	// the references are rewritten, as the hash of the rewritten contents would depend on itself
+	cyclic map[string]bool
+}
+
+// write writes each asset to its path in stagingDir, with the references in it rewritten, and moves the
+// entries of assetDescriptions for fingerprinted assets to their new paths
+func (f *fingerprinter) write(stagingDir string, assetDescriptions map[string]AssetDescription) error {
+	assetPaths := make([]string, 0, len(f.assets))
+	for assetPath := range f.assets {
+		assetPaths = append(assetPaths, assetPath)
+	}
+	sort.Strings(assetPaths)
+
+	for _, assetPath := range assetPaths {
+		newPath := f.name(assetPath)
+		if desc, ok := assetDescriptions[assetPath]; ok && newPath != assetPath {
+			delete(assetDescriptions, assetPath)
+			desc.FilePath = newPath
+			assetDescriptions[newPath] = desc
+		}
+
+		dest := filepath.Join(stagingDir, filepath.FromSlash(newPath))
+		if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			return err
+		}
+
+		asset := f.assets[assetPath]
+		if err := ioutil.WriteFile(dest, asset.data, asset.mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// name rewrites the references in the asset at assetPath to the fingerprinted assets, naming those first, and
+// returns the path the asset is written to
+func (f *fingerprinter) name(assetPath string) string {
+	if name, ok := f.names[assetPath]; ok {
+		return name
+	}
+
+	asset := f.assets[assetPath]
+	if f.visiting[assetPath] {
+		f.cyclic[assetPath] = true
+		if !asset.fingerprint {
+			return assetPath
+		}
+		return fingerprintPath(assetPath, asset.data)
+	}
+
+	f.visiting[assetPath] = true
+	original := asset.data
+	if refersToAssets(assetPath) {
+		asset.data = f.rewriteReferences(assetPath, asset.data)
+	}
+	delete(f.visiting, assetPath)
+
+	name := assetPath
+	switch {
+	case asset.fingerprint && f.cyclic[assetPath]:
+		name = fingerprintPath(assetPath, original)
+	case asset.fingerprint:
+		name = fingerprintPath(assetPath, asset.data)
+	}
+	f.names[assetPath] = name
+
+	return name
+}
+
+// rewriteReferences replaces the references in data, the contents of the asset at assetPath, to fingerprinted
+// assets with references to their new paths
+func (f *fingerprinter) rewriteReferences(assetPath string, data []byte) []byte {
+	referenced := make([]string, 0, len(f.assets))
+	for other, asset := range f.assets {
+		if other != assetPath && asset.fingerprint && bytes.Contains(data, []byte(path.Base(other))) {
+			referenced = append(referenced, other)
+		}
+	}
+	sort.Strings(referenced)
+
+	for _, other := range referenced {
+		newPath := f.name(other)
+		newRefs := assetReferences(assetPath, newPath)
+		for i, ref := range assetReferences(assetPath, other) {
+			pattern := regexp.MustCompile(`(^|[\s"'(=,])` + regexp.QuoteMeta(ref) + `([\s"')?#,]|$)`)
+			data = pattern.ReplaceAll(data, []byte("${1}"+newRefs[i]+"${2}"))
+		}
+	}
+
+	return data
+}
+
+// refersToAssets is true if the asset at assetPath is an HTML, CSS or JavaScript asset, whose references to
+// fingerprinted assets are rewritten
+func refersToAssets(assetPath string) bool {
+	switch strings.ToLower(path.Ext(assetPath)) {
+	case ".css", ".js", ".mjs":
+		return true
+	}
+	return IsHTML(assetPath)
+}
+
+// assetReferences returns the ways the asset at from may refer to the asset at to: by its path, by its path
+// relative to from, and by that prefixed with "./" if it does not start with "../"
+func assetReferences(from, to string) []string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return []string{to}
+	}
+	rel = filepath.ToSlash(rel)
+
+	refs := []string{to, rel}
+	if !strings.HasPrefix(rel, "../") {
+		refs = append(refs, "./"+rel)
+	}
+	return refs
+}
+
+// matchingTransforms returns the transforms whose globs match the asset path, in the order they are defined
+func matchingTransforms(assetPath string, transforms []models.AssetTransform) ([]models.AssetTransform, error) {
+	var matching []models.AssetTransform
+	for _, transform := range transforms {
+		name := assetPath
+		if !strings.Contains(transform.Glob, "/") {
+			name = path.Base(assetPath)
+		}
+
+		matched, err := path.Match(transform.Glob, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transform glob %q: %s", transform.Glob, err)
+		}
+
+		if matched {
+			matching = append(matching, transform)
+		}
+	}
+
+	return matching, nil
+}
+
+// fingerprintPath inserts a hash of data before the extension of assetPath, e.g. /app.js becomes /app.0cc175b9.js
+func fingerprintPath(assetPath string, data []byte) string {
+	hash := fmt.Sprintf("%x", md5.Sum(data))[:fingerprintLength]
+	ext := path.Ext(assetPath)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(assetPath, ext), hash, ext)
+}
+
+// linkOrCopyFile makes the file at src available at dest, preferring a hard link so that untransformed
+// assets are not duplicated on disk
+func linkOrCopyFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,147 @@
+package hosting_test
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/hosting"
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTransformAssets(t *testing.T) {
+	setup := func(t *testing.T) string {
+		rootDir, err := ioutil.TempDir("", "stitch-transform")
+		u.So(t, err, gc.ShouldBeNil)
+
+		for name, contents := range map[string]string{
+			"index.html":   "<html>hello</html>",
+			"js/app.js":    "var greeting = 'hello';",
+			"js/vendor.js": "var vendor = 'hello';",
+		} {
+			path := filepath.Join(rootDir, name)
+			u.So(t, os.MkdirAll(filepath.Dir(path), os.ModePerm), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(path, []byte(contents), 0644), gc.ShouldBeNil)
+		}
+
+		return rootDir
+	}
+
+	readFile := func(t *testing.T, path string) string {
+		b, err := ioutil.ReadFile(path)
+		u.So(t, err, gc.ShouldBeNil)
+		return string(b)
+	}
+
+	t.Run("it runs matching assets through the transforms in order", func(t *testing.T) {
+		rootDir := setup(t)
+		defer os.RemoveAll(rootDir)
+
+		stagingDir, err := hosting.TransformAssets(rootDir, []models.AssetTransform{
+			{Glob: "/js/app.js", Command: "tr a-z A-Z"},
+			{Glob: "*.js", Command: `sed "s/;/; \/\/ $(basename $ASSET_PATH)/"`},
+		}, map[string]hosting.AssetDescription{})
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(stagingDir)
+
+		u.So(t, readFile(t, filepath.Join(stagingDir, "index.html")), gc.ShouldEqual, "<html>hello</html>")
+		u.So(t, readFile(t, filepath.Join(stagingDir, "js/app.js")), gc.ShouldEqual, "VAR GREETING = 'HELLO'; // app.js")
+		u.So(t, readFile(t, filepath.Join(stagingDir, "js/vendor.js")), gc.ShouldEqual, "var vendor = 'hello'; // vendor.js")
+
+		// the originals are left alone
+		u.So(t, readFile(t, filepath.Join(rootDir, "js/app.js")), gc.ShouldEqual, "var greeting = 'hello';")
+	})
+
+	t.Run("it fingerprints assets and moves their descriptions", func(t *testing.T) {
+		rootDir := setup(t)
+		defer os.RemoveAll(rootDir)
+
+		descs := map[string]hosting.AssetDescription{
+			"/js/app.js": {FilePath: "/js/app.js", Attrs: []hosting.AssetAttribute{{Name: "Cache-Control", Value: "max-age=31536000"}}},
+		}
+
+		stagingDir, err := hosting.TransformAssets(rootDir, []models.AssetTransform{
+			{Glob: "app.js", Command: "cat", Fingerprint: true},
+		}, descs)
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(stagingDir)
+
+		// md5 of "var greeting = 'hello';"
+		fingerprinted := "/js/app.1d9a9e77.js"
+		u.So(t, readFile(t, filepath.Join(stagingDir, fingerprinted)), gc.ShouldEqual, "var greeting = 'hello';")
+
+		_, err = os.Stat(filepath.Join(stagingDir, "js/app.js"))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		u.So(t, descs, gc.ShouldResemble, map[string]hosting.AssetDescription{
+			fingerprinted: {FilePath: fingerprinted, Attrs: []hosting.AssetAttribute{{Name: "Cache-Control", Value: "max-age=31536000"}}},
+		})
+	})
+
+	t.Run("it rewrites the references to fingerprinted assets", func(t *testing.T) {
+		rootDir := setup(t)
+		defer os.RemoveAll(rootDir)
+
+		for name, contents := range map[string]string{
+			"index.html":   `<script src="/js/app.js"></script><link rel="stylesheet" href="css/site.css?v=1">`,
+			"css/site.css": "body { background: url(../img/bg.png); }",
+			"img/bg.png":   "png",
+		} {
+			path := filepath.Join(rootDir, name)
+			u.So(t, os.MkdirAll(filepath.Dir(path), os.ModePerm), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(path, []byte(contents), 0644), gc.ShouldBeNil)
+		}
+
+		stagingDir, err := hosting.TransformAssets(rootDir, []models.AssetTransform{
+			{Glob: "app.js", Command: "cat", Fingerprint: true},
+			{Glob: "*.css", Command: "cat", Fingerprint: true},
+			{Glob: "*.png", Command: "cat", Fingerprint: true},
+		}, map[string]hosting.AssetDescription{})
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(stagingDir)
+
+		fingerprint := func(data string) string {
+			return fmt.Sprintf("%x", md5.Sum([]byte(data)))[:8]
+		}
+
+		// the stylesheet is named by its contents once the reference to the image is rewritten
+		css := "body { background: url(../img/bg." + fingerprint("png") + ".png); }"
+		cssPath := "css/site." + fingerprint(css) + ".css"
+		u.So(t, readFile(t, filepath.Join(stagingDir, cssPath)), gc.ShouldEqual, css)
+
+		u.So(t, readFile(t, filepath.Join(stagingDir, "index.html")), gc.ShouldEqual,
+			`<script src="/js/app.1d9a9e77.js"></script><link rel="stylesheet" href="`+cssPath+`?v=1">`)
+
+		// the originals are left alone
+		u.So(t, readFile(t, filepath.Join(rootDir, "index.html")), gc.ShouldEqual,
+			`<script src="/js/app.js"></script><link rel="stylesheet" href="css/site.css?v=1">`)
+	})
+
+	t.Run("it fails when a transform fails", func(t *testing.T) {
+		rootDir := setup(t)
+		defer os.RemoveAll(rootDir)
+
+		_, err := hosting.TransformAssets(rootDir, []models.AssetTransform{
+			{Glob: "*.html", Command: "echo bad markup >&2; exit 1"},
+		}, map[string]hosting.AssetDescription{})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "transforming '/index.html'")
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "bad markup")
+	})
+
+	t.Run("it fails for an invalid glob", func(t *testing.T) {
+		rootDir := setup(t)
+		defer os.RemoveAll(rootDir)
+
+		_, err := hosting.TransformAssets(rootDir, []models.AssetTransform{
+			{Glob: "[", Command: "cat"},
+		}, map[string]hosting.AssetDescription{})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `invalid transform glob "["`)
+	})
+}
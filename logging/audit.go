@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Results of an audited command
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// AuditRecord records that a command changing the state of an app was run, and with what result
+type AuditRecord struct {
+	Time         time.Time `json:"time"`
+	User         string    `json:"user,omitempty"`
+	OSUser       string    `json:"os_user,omitempty"`
+	Host         string    `json:"host,omitempty"`
+	Command      string    `json:"command"`
+	ClientAppID  string    `json:"client_app_id,omitempty"`
+	Result       string    `json:"result"`
+	Error        string    `json:"error,omitempty"`
+	DeploymentID string    `json:"deployment_id,omitempty"`
+}
+
+// AppendAuditRecord appends the record to the audit log at path as a line of JSON, creating the log if
+// it does not exist yet. Each record is written at once, so records of commands run at the same time
+// are not interleaved
+func AppendAuditRecord(path string, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
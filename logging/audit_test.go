@@ -0,0 +1,41 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/logging"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAppendAuditRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-audit")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "audit.jsonl")
+	now := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	u.So(t, logging.AppendAuditRecord(logPath, logging.AuditRecord{Time: now, Command: "import", Result: logging.AuditResultSuccess}), gc.ShouldBeNil)
+	u.So(t, logging.AppendAuditRecord(logPath, logging.AuditRecord{Time: now, Command: "app rename", Result: logging.AuditResultFailure, Error: "oopsies"}), gc.ShouldBeNil)
+
+	contents, err := ioutil.ReadFile(logPath)
+	u.So(t, err, gc.ShouldBeNil)
+
+	lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+	u.So(t, lines, gc.ShouldResemble, []string{
+		`{"time":"2019-03-04T05:06:07Z","command":"import","result":"success"}`,
+		`{"time":"2019-03-04T05:06:07Z","command":"app rename","result":"failure","error":"oopsies"}`,
+	})
+
+	var record logging.AuditRecord
+	u.So(t, json.Unmarshal([]byte(lines[1]), &record), gc.ShouldBeNil)
+	u.So(t, record.Error, gc.ShouldEqual, "oopsies")
+}
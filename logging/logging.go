@@ -0,0 +1,139 @@
+// Package logging provides the leveled logger that commands report their progress through.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a logged message
+type Level int
+
+// The set of Levels, from least to most severe
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// LevelNames lists the names of the Levels, from least to most severe
+func LevelNames() []string {
+	return append([]string{}, levelNames...)
+}
+
+// String returns the name of the level
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the Level with the given name
+func ParseLevel(name string) (Level, error) {
+	for i, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(i), nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level %q; accepted values are [%s]", name, strings.Join(levelNames, "|"))
+}
+
+// Console is where a Logger displays messages, such as a cli.Ui
+type Console interface {
+	Info(message string)
+	Warn(message string)
+	Error(message string)
+}
+
+// successConsole is a Console that displays successes differently from other messages
+type successConsole interface {
+	Success(message string)
+}
+
+// Logger logs messages at a Level
+type Logger interface {
+	Debug(message string)
+	Info(message string)
+	Warn(message string)
+	Error(message string)
+
+	// Success logs that an operation succeeded, at info level
+	Success(message string)
+}
+
+// New returns a Logger that displays the messages at or above level on console and, if file is not nil,
+// also writes them to file, one per line, with the time and level
+func New(console Console, level Level, file io.Writer) Logger {
+	return &leveledLogger{console: console, level: level, file: file}
+}
+
+type leveledLogger struct {
+	console Console
+	level   Level
+
+	fileMu sync.Mutex
+	file   io.Writer
+}
+
+func (l *leveledLogger) Debug(message string) {
+	if l.log(LevelDebug, message) {
+		l.console.Info(message)
+	}
+}
+
+func (l *leveledLogger) Info(message string) {
+	if l.log(LevelInfo, message) {
+		l.console.Info(message)
+	}
+}
+
+func (l *leveledLogger) Warn(message string) {
+	if l.log(LevelWarn, message) {
+		l.console.Warn(message)
+	}
+}
+
+func (l *leveledLogger) Error(message string) {
+	if l.log(LevelError, message) {
+		l.console.Error(message)
+	}
+}
+
+func (l *leveledLogger) Success(message string) {
+	if !l.log(LevelInfo, message) {
+		return
+	}
+
+	if console, ok := l.console.(successConsole); ok {
+		console.Success(message)
+		return
+	}
+	l.console.Info(message)
+}
+
+// log writes the message to the file, returning whether it is to be displayed at all
+func (l *leveledLogger) log(level Level, message string) bool {
+	if level < l.level {
+		return false
+	}
+
+	if l.file != nil {
+		l.fileMu.Lock()
+		defer l.fileMu.Unlock()
+
+		timestamp := time.Now().UTC().Format(time.RFC3339)
+		for _, line := range strings.Split(message, "\n") {
+			fmt.Fprintf(l.file, "%s %-5s %s\n", timestamp, strings.ToUpper(level.String()), line)
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,67 @@
+package logging_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/logging"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, name := range logging.LevelNames() {
+		level, err := logging.ParseLevel(strings.ToUpper(name))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, level.String(), gc.ShouldEqual, name)
+	}
+
+	_, err := logging.ParseLevel("verbose")
+	u.So(t, err, gc.ShouldNotBeNil)
+	u.So(t, err.Error(), gc.ShouldEqual, `unknown log level "verbose"; accepted values are [debug|info|warn|error]`)
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("should drop messages below the level", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		logger := logging.New(mockUI, logging.LevelWarn, nil)
+
+		logger.Debug("some debug")
+		logger.Info("some info")
+		logger.Success("some success")
+		logger.Warn("some warning")
+		logger.Error("some error")
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "some warning\nsome error\n")
+	})
+
+	t.Run("should display debug messages as info when the level is debug", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		logger := logging.New(mockUI, logging.LevelDebug, nil)
+
+		logger.Debug("some debug")
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "some debug\n")
+	})
+
+	t.Run("should write each line of a message to the file with its level", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		file := new(bytes.Buffer)
+		logger := logging.New(mockUI, logging.LevelInfo, file)
+
+		logger.Debug("some debug")
+		logger.Info("some info")
+		logger.Error("first line\nsecond line")
+
+		lines := strings.Split(strings.TrimSuffix(file.String(), "\n"), "\n")
+		u.So(t, lines, gc.ShouldHaveLength, 3)
+		u.So(t, lines[0], gc.ShouldEndWith, " INFO  some info")
+		u.So(t, lines[1], gc.ShouldEndWith, " ERROR first line")
+		u.So(t, lines[2], gc.ShouldEndWith, " ERROR second line")
+	})
+}
@@ -21,13 +21,7 @@ func main() {
 		ErrorWriter: os.Stderr,
 	}
 
-	c.Commands = map[string]cli.CommandFactory{
-		"whoami": commands.NewWhoamiCommandFactory(ui),
-		"login":  commands.NewLoginCommandFactory(ui),
-		"logout": commands.NewLogoutCommandFactory(ui),
-		"export": commands.NewExportCommandFactory(ui),
-		"import": commands.NewImportCommandFactory(ui),
-	}
+	c.Commands = commands.NewCommandFactories(ui)
 
 	exitStatus, err := c.Run()
 	if err != nil {
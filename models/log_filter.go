@@ -0,0 +1,325 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LogFilter is a parsed filter expression selecting log entries by their fields, such as
+// `status>=400 && function=="checkout"`. Comparisons of a field to a value can be combined with &&, || and !,
+// and grouped in parentheses. A field on its own matches the entries where it is set
+type LogFilter struct {
+	match func(entry LogEntry) bool
+}
+
+// Match returns whether entry is selected by the filter
+func (lf *LogFilter) Match(entry LogEntry) bool {
+	return lf == nil || lf.match(entry)
+}
+
+// logFilterStringFields are the string fields of a log entry that filters can compare, by name
+var logFilterStringFields = map[string]func(LogEntry) string{
+	"id":         func(e LogEntry) string { return e.ID },
+	"request_id": func(e LogEntry) string { return e.RequestID },
+	"type":       func(e LogEntry) string { return e.Type },
+	"user":       func(e LogEntry) string { return e.UserID },
+	"function":   func(e LogEntry) string { return e.FunctionName },
+	"url":        func(e LogEntry) string { return e.RequestURL },
+	"method":     func(e LogEntry) string { return e.RequestMethod },
+	"error":      func(e LogEntry) string { return e.Error },
+	"error_code": func(e LogEntry) string { return e.ErrorCode },
+	"messages":   func(e LogEntry) string { return strings.Join(e.Messages, "\n") },
+}
+
+// logFilterNumberFields are the numeric fields of a log entry that filters can compare, by name. The duration
+// of an entry is in milliseconds
+var logFilterNumberFields = map[string]func(LogEntry) float64{
+	"status": func(e LogEntry) float64 { return float64(e.Status) },
+	"duration": func(e LogEntry) float64 {
+		if e.Completed.IsZero() {
+			return 0
+		}
+		return float64(e.Completed.Sub(e.Started).Nanoseconds()) / 1e6
+	},
+}
+
+// LogFilterFields returns the names of the fields that filters can compare, in order
+func LogFilterFields() []string {
+	return []string{"id", "request_id", "type", "user", "function", "url", "method", "status", "duration", "error", "error_code", "messages"}
+}
+
+// ParseLogFilter parses a filter expression, e.g. `status>=400 && function=="checkout"`. Strings are quoted and
+// compared with == and != or matched against a regular expression with =~, while numbers are compared with
+// ==, !=, <, <=, > and >=
+func ParseLogFilter(expr string) (*LogFilter, error) {
+	tokens, err := tokenizeLogFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &logFilterParser{tokens: tokens}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s", p.tokens[p.pos])
+	}
+
+	return &LogFilter{match: match}, nil
+}
+
+type logFilterTokenKind int
+
+const (
+	logFilterIdent logFilterTokenKind = iota
+	logFilterString
+	logFilterNumber
+	logFilterOperator
+)
+
+type logFilterToken struct {
+	kind  logFilterTokenKind
+	value string
+	pos   int
+}
+
+func (t logFilterToken) String() string {
+	if t.kind == logFilterString {
+		return fmt.Sprintf("%q at position %d", t.value, t.pos+1)
+	}
+	return fmt.Sprintf("'%s' at position %d", t.value, t.pos+1)
+}
+
+// logFilterOperators are the operators of filter expressions, longest first so that they are tokenized greedily
+var logFilterOperators = []string{"&&", "||", "==", "!=", ">=", "<=", "=~", ">", "<", "!", "(", ")"}
+
+func tokenizeLogFilter(expr string) ([]logFilterToken, error) {
+	var tokens []logFilterToken
+
+	for pos := 0; pos < len(expr); {
+		c := rune(expr[pos])
+
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+		case c == '"' || c == '\'':
+			end := pos + 1
+			var value strings.Builder
+			for ; end < len(expr) && rune(expr[end]) != c; end++ {
+				if expr[end] == '\\' && end+1 < len(expr) {
+					end++
+				}
+				value.WriteByte(expr[end])
+			}
+			if end == len(expr) {
+				return nil, fmt.Errorf("unterminated string at position %d", pos+1)
+			}
+			tokens = append(tokens, logFilterToken{logFilterString, value.String(), pos})
+			pos = end + 1
+		case unicode.IsDigit(c) || c == '-' || c == '.':
+			end := pos + 1
+			for end < len(expr) && (unicode.IsDigit(rune(expr[end])) || expr[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, logFilterToken{logFilterNumber, expr[pos:end], pos})
+			pos = end
+		case unicode.IsLetter(c) || c == '_':
+			end := pos + 1
+			for end < len(expr) && (unicode.IsLetter(rune(expr[end])) || unicode.IsDigit(rune(expr[end])) || expr[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, logFilterToken{logFilterIdent, expr[pos:end], pos})
+			pos = end
+		default:
+			var operator string
+			for _, op := range logFilterOperators {
+				if strings.HasPrefix(expr[pos:], op) {
+					operator = op
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected '%c' at position %d", c, pos+1)
+			}
+			tokens = append(tokens, logFilterToken{logFilterOperator, operator, pos})
+			pos += len(operator)
+		}
+	}
+
+	return tokens, nil
+}
+
+type logFilterParser struct {
+	tokens []logFilterToken
+	pos    int
+}
+
+func (p *logFilterParser) peekOperator(operator string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == logFilterOperator && p.tokens[p.pos].value == operator
+}
+
+func (p *logFilterParser) next() (logFilterToken, error) {
+	if p.pos == len(p.tokens) {
+		return logFilterToken{}, fmt.Errorf("unexpected end of filter")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *logFilterParser) parseOr() (func(LogEntry) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekOperator("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e LogEntry) bool { return l(e) || right(e) }
+	}
+	return left, nil
+}
+
+func (p *logFilterParser) parseAnd() (func(LogEntry) bool, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekOperator("&&") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e LogEntry) bool { return l(e) && right(e) }
+	}
+	return left, nil
+}
+
+func (p *logFilterParser) parseNot() (func(LogEntry) bool, error) {
+	if p.peekOperator("!") {
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(e LogEntry) bool { return !operand(e) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *logFilterParser) parsePrimary() (func(LogEntry) bool, error) {
+	if p.peekOperator("(") {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekOperator(")") {
+			if p.pos == len(p.tokens) {
+				return nil, fmt.Errorf("missing ')'")
+			}
+			return nil, fmt.Errorf("expected ')' but got %s", p.tokens[p.pos])
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	field, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if field.kind != logFilterIdent {
+		return nil, fmt.Errorf("expected a field but got %s", field)
+	}
+
+	stringField, isString := logFilterStringFields[field.value]
+	numberField, isNumber := logFilterNumberFields[field.value]
+	if !isString && !isNumber {
+		return nil, fmt.Errorf("unknown field '%s', expected one of %s", field.value, strings.Join(LogFilterFields(), ", "))
+	}
+
+	// a field without a comparison matches the entries where it is set
+	if p.pos == len(p.tokens) || p.tokens[p.pos].kind != logFilterOperator || !isComparison(p.tokens[p.pos].value) {
+		if isString {
+			return func(e LogEntry) bool { return stringField(e) != "" }, nil
+		}
+		return func(e LogEntry) bool { return numberField(e) != 0 }, nil
+	}
+
+	operator, _ := p.next()
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if isNumber {
+		return compareNumberField(field, numberField, operator, value)
+	}
+	return compareStringField(field, stringField, operator, value)
+}
+
+func isComparison(operator string) bool {
+	switch operator {
+	case "==", "!=", ">=", "<=", ">", "<", "=~":
+		return true
+	}
+	return false
+}
+
+func compareNumberField(field logFilterToken, get func(LogEntry) float64, operator, value logFilterToken) (func(LogEntry) bool, error) {
+	if value.kind != logFilterNumber {
+		return nil, fmt.Errorf("'%s' is a number, so it must be compared to one, not %s", field.value, value)
+	}
+
+	n, err := strconv.ParseFloat(value.value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s", value)
+	}
+
+	switch operator.value {
+	case "==":
+		return func(e LogEntry) bool { return get(e) == n }, nil
+	case "!=":
+		return func(e LogEntry) bool { return get(e) != n }, nil
+	case ">=":
+		return func(e LogEntry) bool { return get(e) >= n }, nil
+	case "<=":
+		return func(e LogEntry) bool { return get(e) <= n }, nil
+	case ">":
+		return func(e LogEntry) bool { return get(e) > n }, nil
+	case "<":
+		return func(e LogEntry) bool { return get(e) < n }, nil
+	}
+	return nil, fmt.Errorf("'%s' is a number, so it cannot be compared with %s", field.value, operator)
+}
+
+func compareStringField(field logFilterToken, get func(LogEntry) string, operator, value logFilterToken) (func(LogEntry) bool, error) {
+	if value.kind != logFilterString {
+		return nil, fmt.Errorf("'%s' is a string, so it must be compared to a quoted one, not %s", field.value, value)
+	}
+
+	switch operator.value {
+	case "==":
+		return func(e LogEntry) bool { return get(e) == value.value }, nil
+	case "!=":
+		return func(e LogEntry) bool { return get(e) != value.value }, nil
+	case "=~":
+		re, err := regexp.Compile(value.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %s", value, err)
+		}
+		return func(e LogEntry) bool { return re.MatchString(get(e)) }, nil
+	}
+	return nil, fmt.Errorf("'%s' is a string, so it cannot be compared with %s", field.value, operator)
+}
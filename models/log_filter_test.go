@@ -0,0 +1,82 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLogFilter(t *testing.T) {
+	started := time.Date(2018, time.March, 2, 10, 0, 0, 0, time.UTC)
+	checkoutFailure := models.LogEntry{
+		ID:           "log1",
+		Type:         "FUNCTION",
+		FunctionName: "checkout",
+		Status:       500,
+		Started:      started,
+		Completed:    started.Add(1500 * time.Millisecond),
+		Error:        "TypeError: boom",
+	}
+	cartSuccess := models.LogEntry{
+		ID:           "log2",
+		Type:         "FUNCTION",
+		FunctionName: "cart",
+		Status:       200,
+		Started:      started,
+		Completed:    started.Add(20 * time.Millisecond),
+		Messages:     []string{"added item 42"},
+	}
+
+	t.Run("it matches the entries for which the expression holds", func(t *testing.T) {
+		for _, tc := range []struct {
+			expr     string
+			expected []bool
+		}{
+			{`status>=400 && function=="checkout"`, []bool{true, false}},
+			{`status >= 400 && function == "cart"`, []bool{false, false}},
+			{`function=="cart" || status==500`, []bool{true, true}},
+			{`!(status<400)`, []bool{true, false}},
+			{`function != 'checkout'`, []bool{false, true}},
+			{`error =~ "^TypeError"`, []bool{true, false}},
+			{`messages =~ "item \\d+"`, []bool{false, true}},
+			{`duration > 1000`, []bool{true, false}},
+			{`error`, []bool{true, false}},
+			{`!error && type=="FUNCTION"`, []bool{false, true}},
+			{`status==200 || status==500 && function=="cart"`, []bool{false, true}},
+		} {
+			filter, err := models.ParseLogFilter(tc.expr)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, []bool{filter.Match(checkoutFailure), filter.Match(cartSuccess)}, gc.ShouldResemble, tc.expected)
+		}
+	})
+
+	t.Run("a nil filter matches every entry", func(t *testing.T) {
+		var filter *models.LogFilter
+		u.So(t, filter.Match(checkoutFailure), gc.ShouldBeTrue)
+	})
+
+	t.Run("it rejects invalid expressions", func(t *testing.T) {
+		for _, tc := range []struct {
+			expr     string
+			expected string
+		}{
+			{`status>=`, "unexpected end of filter"},
+			{`latency > 5`, "unknown field 'latency'"},
+			{`status == "500"`, "'status' is a number, so it must be compared to one"},
+			{`function > "a"`, "'function' is a string, so it cannot be compared with '>'"},
+			{`function == checkout`, "'function' is a string, so it must be compared to a quoted one"},
+			{`(status==500`, "missing ')'"},
+			{`status==500 status==200`, "unexpected 'status' at position 13"},
+			{`function == "checkout`, "unterminated string at position 13"},
+			{`error =~ "("`, "invalid regular expression"},
+			{`status # 5`, "unexpected '#' at position 8"},
+		} {
+			_, err := models.ParseLogFilter(tc.expr)
+			u.So(t, err, gc.ShouldNotBeNil)
+			u.So(t, err.Error(), gc.ShouldContainSubstring, tc.expected)
+		}
+	})
+}
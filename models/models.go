@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"path/filepath"
+	"time"
 
 	"github.com/10gen/stitch-cli/utils"
 )
@@ -106,14 +107,179 @@ func (pd *UserProfile) AllGroupIDs() []string {
 	return groupIDs
 }
 
+// GroupRoleNames returns the names of the roles the user has in the given group
+func (pd *UserProfile) GroupRoleNames(groupID string) []string {
+	var roleNames []string
+
+	for _, role := range pd.Roles {
+		if role.GroupID == groupID {
+			roleNames = append(roleNames, role.RoleName)
+		}
+	}
+
+	return roleNames
+}
+
+// OrgRoleNames returns the names of the roles the user has in the given organization, which apply to each of
+// its groups
+func (pd *UserProfile) OrgRoleNames(orgID string) []string {
+	var roleNames []string
+
+	for _, role := range pd.Roles {
+		if role.OrgID != "" && role.OrgID == orgID {
+			roleNames = append(roleNames, role.RoleName)
+		}
+	}
+
+	return roleNames
+}
+
 type role struct {
-	GroupID string `json:"group_id"`
+	RoleName string `json:"role_name"`
+	GroupID  string `json:"group_id"`
+	OrgID    string `json:"org_id,omitempty"`
 }
 
 // App represents basic Stitch App data
 type App struct {
-	ID          string `json:"_id"`
-	GroupID     string `json:"group_id"`
-	ClientAppID string `json:"client_app_id"`
-	Name        string `json:"name"`
+	ID              string `json:"_id"`
+	GroupID         string `json:"group_id"`
+	ClientAppID     string `json:"client_app_id"`
+	Name            string `json:"name"`
+	Location        string `json:"location,omitempty"`
+	DeploymentModel string `json:"deployment_model,omitempty"`
+}
+
+// Statuses of a Deployment, and of its rollout to each region
+const (
+	DeploymentStatusCreated    = "created"
+	DeploymentStatusPending    = "pending"
+	DeploymentStatusSuccessful = "successful"
+	DeploymentStatusFailed     = "failed"
+)
+
+// Limits are the limits the server enforces on apps. A limit of zero is not reported by the server
+type Limits struct {
+	MaxFunctions        int   `json:"max_functions"`
+	MaxRequestSizeBytes int64 `json:"max_request_size_bytes"`
+	MaxAssetSizeBytes   int64 `json:"max_asset_size_bytes"`
+}
+
+// Deployment represents a single deployment of a Stitch App
+type Deployment struct {
+	ID                 string             `json:"_id"`
+	Status             string             `json:"status"`
+	StatusErrorMessage string             `json:"status_error_message,omitempty"`
+	UserID             string             `json:"user_id,omitempty"`
+	DeployedAt         int64              `json:"deployed_at"`
+	Regions            []DeploymentRegion `json:"regions,omitempty"`
+}
+
+// DeploymentRegion describes the rollout of a Deployment to one of the regions the app is served from
+type DeploymentRegion struct {
+	Region             string `json:"region"`
+	Status             string `json:"status"`
+	StatusErrorMessage string `json:"status_error_message,omitempty"`
+}
+
+// Value is a named constant of a Stitch App. A private value can only be read by functions and the admin API
+type Value struct {
+	ID         string          `json:"_id,omitempty"`
+	Name       string          `json:"name"`
+	Value      json.RawMessage `json:"value"`
+	Private    bool            `json:"private,omitempty"`
+	FromSecret bool            `json:"from_secret,omitempty"`
+}
+
+// Secret is a named secret of a Stitch App, which config refers to by name. Its value is never returned
+type Secret struct {
+	ID    string `json:"_id,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// AuthProvider is a way for the users of a Stitch App to log in. Its config is kept as it is returned, so that
+// an update only changes the fields that are meant to change
+type AuthProvider struct {
+	ID                 string          `json:"_id,omitempty"`
+	Name               string          `json:"name"`
+	Type               string          `json:"type"`
+	Disabled           bool            `json:"disabled"`
+	Config             json.RawMessage `json:"config,omitempty"`
+	SecretConfig       json.RawMessage `json:"secret_config,omitempty"`
+	MetadataFields     json.RawMessage `json:"metadata_fields,omitempty"`
+	RedirectURIs       []string        `json:"redirect_uris"`
+	DomainRestrictions []string        `json:"domain_restrictions,omitempty"`
+}
+
+// FunctionExecution is the outcome of calling one of an app's functions through the admin API
+type FunctionExecution struct {
+	Result    json.RawMessage `json:"result"`
+	Logs      []string        `json:"logs,omitempty"`
+	ErrorLogs []string        `json:"error_logs,omitempty"`
+}
+
+// LogQuery selects the entries of an app's logs that FetchLogs returns. Zero fields are not filtered on, and
+// Skip skips as many of the newest matching entries, as given by the NextSkip of the previous page
+type LogQuery struct {
+	Start      time.Time
+	End        time.Time
+	Type       string
+	ErrorsOnly bool
+	Skip       int
+}
+
+// LogPage is a page of an app's log entries, newest first. If there are more, NextEndDate and NextSkip are
+// the End and Skip of the query for the next page
+type LogPage struct {
+	Logs        []LogEntry `json:"logs"`
+	NextEndDate *time.Time `json:"nextEndDate,omitempty"`
+	NextSkip    int        `json:"nextSkip,omitempty"`
+}
+
+// LogEntry is a request to an app, or an event it handled, as recorded in its logs
+type LogEntry struct {
+	ID            string    `json:"_id"`
+	RequestID     string    `json:"co_id,omitempty"`
+	Type          string    `json:"type"`
+	UserID        string    `json:"user_id,omitempty"`
+	FunctionName  string    `json:"function_name,omitempty"`
+	RequestURL    string    `json:"request_url,omitempty"`
+	RequestMethod string    `json:"request_method,omitempty"`
+	Status        int       `json:"status,omitempty"`
+	Started       time.Time `json:"started"`
+	Completed     time.Time `json:"completed"`
+	Error         string    `json:"error,omitempty"`
+	ErrorCode     string    `json:"error_code,omitempty"`
+	Messages      []string  `json:"messages,omitempty"`
+}
+
+// Measurements are the usage metrics of an app over a time range, as reported by the admin API
+type Measurements struct {
+	Start        time.Time     `json:"start"`
+	End          time.Time     `json:"end"`
+	Granularity  string        `json:"granularity"`
+	Measurements []Measurement `json:"measurements"`
+}
+
+// Measurement is a single usage metric of an app, such as its request count, over the reported time range
+type Measurement struct {
+	Name       string                 `json:"name"`
+	Units      string                 `json:"units"`
+	DataPoints []MeasurementDataPoint `json:"data_points"`
+}
+
+// Total returns the sum of the measurement over its time range
+func (m Measurement) Total() float64 {
+	var total float64
+	for _, point := range m.DataPoints {
+		total += point.Value
+	}
+	return total
+}
+
+// MeasurementDataPoint is the value of a Measurement over the period starting at Timestamp
+type MeasurementDataPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
 }
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectConfigFileName is the name of the optional file holding CLI settings for a local app directory.
+// Unlike AppConfigFileName, it is never sent to Stitch
+const ProjectConfigFileName string = ".stitchrc"
+
+// ProjectConfig defines CLI settings that apply to a single local app directory
+type ProjectConfig struct {
+	Notify    NotifyConfig     `yaml:"notify,omitempty"`
+	Defaults  PromptDefaults   `yaml:"defaults,omitempty"`
+	Hosting   HostingOptions   `yaml:"hosting,omitempty"`
+	Functions FunctionsOptions `yaml:"functions,omitempty"`
+	Hooks     HooksConfig      `yaml:"hooks,omitempty"`
+	Branches  []BranchApp      `yaml:"branches,omitempty"`
+
+	// ValueTypes declares the type ("string", "number", "bool", "object" or "array") of values by name
+	ValueTypes map[string]string `yaml:"value_types,omitempty"`
+}
+
+// NotifyConfig defines where and how the outcome of an import is announced
+type NotifyConfig struct {
+	Webhook  string `yaml:"webhook,omitempty"`
+	Template string `yaml:"template,omitempty"`
+}
+
+// PromptDefaults holds the most recent answers to interactive prompts, which are offered as the
+// defaults the next time the same prompts are shown
+type PromptDefaults struct {
+	ProjectID       string `yaml:"project_id,omitempty"`
+	Location        string `yaml:"location,omitempty"`
+	DeploymentModel string `yaml:"deployment_model,omitempty"`
+}
+
+// HooksConfig defines the git hook installed by "hooks install": Hook names the git hook to install it as
+// ("pre-commit" or "pre-push"), Strict validates strictly, and Diff also shows the hosting changes an
+// import would make
+type HooksConfig struct {
+	Hook   string `yaml:"hook,omitempty"`
+	Strict bool   `yaml:"strict,omitempty"`
+	Diff   bool   `yaml:"diff,omitempty"`
+}
+
+// HostingOptions defines how local hosting assets are prepared before they are imported. Include imports them
+// with every import of the app, as if --include-hosting were given
+type HostingOptions struct {
+	Include    bool             `yaml:"include,omitempty"`
+	Roots      []HostingRoot    `yaml:"roots,omitempty"`
+	Transforms []AssetTransform `yaml:"transforms,omitempty"`
+}
+
+// HostingRoot maps a local directory of hosting assets, relative to the app directory, to the path prefix they
+// are hosted under. When any are defined, they replace the hosting files directory of the app
+type HostingRoot struct {
+	Dir    string `yaml:"dir"`
+	Prefix string `yaml:"prefix"`
+}
+
+// AssetTransform replaces the contents of every asset matching Glob with the output of Command, which
+// is run with the original contents on stdin. A Glob without a "/" is matched against file names only.
+// If Fingerprint is set, a hash of the transformed contents is also inserted into the asset's file name, and the
+// references to the asset in HTML, CSS and JavaScript assets are rewritten to it
+type AssetTransform struct {
+	Glob        string `yaml:"glob"`
+	Command     string `yaml:"command"`
+	Fingerprint bool   `yaml:"fingerprint,omitempty"`
+}
+
+// FunctionsOptions defines how the sources of functions and incoming webhooks are prepared before they are
+// imported. Build is the command --build-functions compiles each source.ts into a source.js with
+type FunctionsOptions struct {
+	Build string `yaml:"build,omitempty"`
+}
+
+// BranchApp maps the git branches matching Branch, a pattern as accepted by path.Match (e.g. "release/*"),
+// to the App ID of the app they are imported to
+type BranchApp struct {
+	Branch string `yaml:"branch"`
+	AppID  string `yaml:"app_id"`
+}
+
+// AppIDForBranch returns the App ID that the first of Branches matching the git branch maps it to
+func (pc *ProjectConfig) AppIDForBranch(branch string) (string, bool) {
+	for _, mapping := range pc.Branches {
+		if matched, err := path.Match(mapping.Branch, branch); err == nil && matched {
+			return mapping.AppID, true
+		}
+	}
+	return "", false
+}
+
+// LoadProjectConfig reads the ProjectConfig from the app directory at path. A missing file results in an empty ProjectConfig
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	var config ProjectConfig
+
+	raw, err := ioutil.ReadFile(filepath.Join(path, ProjectConfigFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &config, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Save writes the ProjectConfig to the app directory at path. When the file exists, only the top-level settings
+// that differ from those it holds are rewritten, so that its comments, the order of its settings, and any
+// settings this version of the CLI does not know of are kept. A file those settings cannot be rewritten in, such
+// as one written in flow style, is rewritten whole, keeping only the order of its settings
+func (pc *ProjectConfig) Save(path string) error {
+	filePath := filepath.Join(path, ProjectConfigFileName)
+
+	raw, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		if raw, err = yaml.Marshal(pc); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filePath, raw, 0600)
+	}
+	if err != nil {
+		return err
+	}
+
+	var saved ProjectConfig
+	if err := yaml.Unmarshal(raw, &saved); err != nil {
+		return err
+	}
+
+	savedSettings, err := topLevelSettings(&saved)
+	if err != nil {
+		return err
+	}
+	settings, err := topLevelSettings(pc)
+	if err != nil {
+		return err
+	}
+
+	var file yaml.MapSlice
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return err
+	}
+	expected := updatedSettings(file, savedSettings, settings)
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n") {
+		// an empty config is written as "{}", which no setting can be added to
+		if strings.TrimSpace(line) != "{}" {
+			lines = append(lines, line)
+		}
+	}
+	for _, setting := range savedSettings {
+		if _, ok := settingValue(settings, setting.Key); !ok {
+			lines = replaceTopLevelSetting(lines, setting.Key.(string), nil)
+		}
+	}
+	for _, setting := range settings {
+		if value, ok := settingValue(savedSettings, setting.Key); ok && reflect.DeepEqual(value, setting.Value) {
+			continue
+		}
+
+		block, err := yaml.Marshal(yaml.MapSlice{setting})
+		if err != nil {
+			return err
+		}
+		lines = replaceTopLevelSetting(lines, setting.Key.(string), strings.Split(strings.TrimSuffix(string(block), "\n"), "\n"))
+	}
+	updated := []byte(strings.Join(lines, "\n") + "\n")
+
+	// the lines are only edited correctly when every setting is a block mapping entry with a plain key, so
+	// the whole file is rewritten, in the order of its settings, if any other form made the edit go wrong
+	var written yaml.MapSlice
+	if err := yaml.Unmarshal(updated, &written); err != nil || !reflect.DeepEqual(written, expected) {
+		if updated, err = yaml.Marshal(expected); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(filePath, updated, 0600)
+}
+
+// updatedSettings returns the top-level settings of the file with those of saved that are not in settings
+// removed, and those of settings that differ from saved replaced in place or appended
+func updatedSettings(file, saved, settings yaml.MapSlice) yaml.MapSlice {
+	updated := yaml.MapSlice{}
+	for _, setting := range file {
+		if _, known := settingValue(saved, setting.Key); known {
+			if _, ok := settingValue(settings, setting.Key); !ok {
+				continue
+			}
+		}
+		if value, ok := settingValue(settings, setting.Key); ok {
+			setting.Value = value
+		}
+		updated = append(updated, setting)
+	}
+	for _, setting := range settings {
+		if _, ok := settingValue(updated, setting.Key); !ok {
+			updated = append(updated, setting)
+		}
+	}
+	return updated
+}
+
+// topLevelSettings returns the top-level settings of the config as they are written to the file, in order
+func topLevelSettings(config *ProjectConfig) (yaml.MapSlice, error) {
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings yaml.MapSlice
+	if err := yaml.Unmarshal(raw, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func settingValue(settings yaml.MapSlice, key interface{}) (interface{}, bool) {
+	for _, setting := range settings {
+		if setting.Key == key {
+			return setting.Value, true
+		}
+	}
+	return nil, false
+}
+
+// replaceTopLevelSetting replaces the lines of the top-level setting named key with block, removing the setting
+// if block is empty and appending block if the setting is not there. The comments and blank lines that precede
+// the next setting are left to it
+func replaceTopLevelSetting(lines []string, key string, block []string) []string {
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, key+":") {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return append(lines, block...)
+	}
+
+	end := start + 1
+	for end < len(lines) && !isTopLevelLine(lines[end]) {
+		end++
+	}
+	for end > start+1 && !isSettingLine(lines[end-1]) {
+		end--
+	}
+
+	replaced := append(append([]string{}, lines[:start]...), block...)
+	return append(replaced, lines[end:]...)
+}
+
+// isTopLevelLine returns whether the line starts a top-level setting or ends the document
+func isTopLevelLine(line string) bool {
+	return line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "#")
+}
+
+// isSettingLine returns whether the line holds part of a setting, rather than only a comment or nothing
+func isSettingLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && !strings.HasPrefix(trimmed, "#")
+}
@@ -0,0 +1,131 @@
+package models_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProjectConfigSave(t *testing.T) {
+	setup := func(contents string) string {
+		dir, err := ioutil.TempDir("", "stitch-project-config")
+		u.So(t, err, gc.ShouldBeNil)
+		if contents != "" {
+			u.So(t, ioutil.WriteFile(filepath.Join(dir, models.ProjectConfigFileName), []byte(contents), 0600), gc.ShouldBeNil)
+		}
+		return dir
+	}
+
+	read := func(dir string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, models.ProjectConfigFileName))
+		u.So(t, err, gc.ShouldBeNil)
+		return string(data)
+	}
+
+	t.Run("it writes a new file", func(t *testing.T) {
+		dir := setup("")
+		defer os.RemoveAll(dir)
+
+		config := &models.ProjectConfig{Defaults: models.PromptDefaults{ProjectID: "project-id"}}
+		u.So(t, config.Save(dir), gc.ShouldBeNil)
+
+		loaded, err := models.LoadProjectConfig(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, loaded, gc.ShouldResemble, config)
+	})
+
+	t.Run("it rewrites only the settings that changed, keeping comments, order and unknown settings", func(t *testing.T) {
+		dir := setup(`# settings for the shop app
+branches:
+  # production deploys from main only
+  - {branch: main, app_id: prod-app-abcde}
+
+# answers to the last prompts
+defaults:
+  project_id: old-project-id
+experimental: true
+notify:
+  webhook: https://example.com/hook
+`)
+		defer os.RemoveAll(dir)
+
+		config, err := models.LoadProjectConfig(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		config.Defaults.ProjectID = "new-project-id"
+		config.Notify = models.NotifyConfig{}
+		config.ValueTypes = map[string]string{"banner": "string"}
+		u.So(t, config.Save(dir), gc.ShouldBeNil)
+
+		u.So(t, read(dir), gc.ShouldEqual, `# settings for the shop app
+branches:
+  # production deploys from main only
+  - {branch: main, app_id: prod-app-abcde}
+
+# answers to the last prompts
+defaults:
+  project_id: new-project-id
+experimental: true
+value_types:
+  banner: string
+`)
+
+		loaded, err := models.LoadProjectConfig(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, loaded, gc.ShouldResemble, config)
+	})
+
+	t.Run("it adds settings to an empty config", func(t *testing.T) {
+		dir := setup("{}\n")
+		defer os.RemoveAll(dir)
+
+		config := &models.ProjectConfig{Defaults: models.PromptDefaults{ProjectID: "project-id"}}
+		u.So(t, config.Save(dir), gc.ShouldBeNil)
+		u.So(t, read(dir), gc.ShouldEqual, "defaults:\n  project_id: project-id\n")
+	})
+
+	t.Run("it rewrites a flow-style config in the order of its settings", func(t *testing.T) {
+		dir := setup("{experimental: true, defaults: {project_id: old-project-id}, notify: {webhook: \"https://example.com/hook\"}}\n")
+		defer os.RemoveAll(dir)
+
+		config, err := models.LoadProjectConfig(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		config.Defaults.ProjectID = "new-project-id"
+		config.Notify = models.NotifyConfig{}
+		config.Hooks.Strict = true
+		u.So(t, config.Save(dir), gc.ShouldBeNil)
+
+		u.So(t, read(dir), gc.ShouldEqual, `experimental: true
+defaults:
+  project_id: new-project-id
+hooks:
+  strict: true
+`)
+	})
+
+	t.Run("it rewrites a config with quoted keys without repeating a setting", func(t *testing.T) {
+		dir := setup(`"defaults":
+  project_id: old-project-id
+'experimental': true
+`)
+		defer os.RemoveAll(dir)
+
+		config, err := models.LoadProjectConfig(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		config.Defaults.ProjectID = "new-project-id"
+		u.So(t, config.Save(dir), gc.ShouldBeNil)
+
+		u.So(t, read(dir), gc.ShouldEqual, `defaults:
+  project_id: new-project-id
+experimental: true
+`)
+
+		loaded, err := models.LoadProjectConfig(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, loaded, gc.ShouldResemble, config)
+	})
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	vaultCommand = "vault"
+	awsCommand   = "aws"
+)
+
+// resolveVault reads a field of a Vault KV secret, referenced as "<path>#<field>", with the vault command,
+// which takes its address and token from the usual VAULT_ADDR and VAULT_TOKEN
+func resolveVault(ref string) (string, error) {
+	path, field := splitField(ref)
+	if path == "" || field == "" {
+		return "", errors.New("expected <path>#<field>")
+	}
+
+	secret, err := runCommand(vaultCommand, "https://www.vaultproject.io", "kv", "get", "-field="+field, path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(secret, "\n"), nil
+}
+
+// resolveAWSSecretsManager reads an AWS Secrets Manager secret, referenced as "<secret-id>", or a key of it if
+// it holds a JSON object, as "<secret-id>#<key>", with the aws command and its usual credentials
+func resolveAWSSecretsManager(ref string) (string, error) {
+	secretID, key := splitField(ref)
+	if secretID == "" {
+		return "", errors.New("expected <secret-id> or <secret-id>#<key>")
+	}
+
+	secret, err := runCommand(awsCommand, "https://aws.amazon.com/cli", "secretsmanager", "get-secret-value",
+		"--secret-id", secretID, "--query", "SecretString", "--output", "text")
+	if err != nil {
+		return "", err
+	}
+	secret = strings.TrimSuffix(secret, "\n")
+
+	if key == "" {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, so it has no key %q", secretID, key)
+	}
+
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %q", secretID, key)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// splitField splits a reference at its last "#"
+func splitField(ref string) (string, string) {
+	idx := strings.LastIndex(ref, "#")
+	if idx == -1 {
+		return ref, ""
+	}
+	return ref[:idx], ref[idx+1:]
+}
+
+func runCommand(command, homepage string, args ...string) (string, error) {
+	if _, err := exec.LookPath(command); err != nil {
+		return "", fmt.Errorf("the %s command (%s) must be installed to resolve these secrets", command, homepage)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s failed: %s", command, msg)
+		}
+		return "", fmt.Errorf("%s failed: %s", command, err)
+	}
+
+	return stdout.String(), nil
+}
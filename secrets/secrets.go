@@ -0,0 +1,139 @@
+// Package secrets resolves references to secrets kept outside of an app's files, such as "env:DB_PASSWORD",
+// "vault:secret/my-app#clientSecret" or "aws-sm:my-app/oauth#clientSecret", into the secrets themselves.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Resolver looks up the secret identified by a reference, given without its scheme
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to a Resolver
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref)
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// The schemes of the built-in resolvers
+const (
+	SchemeEnv   = "env"
+	SchemeVault = "vault"
+	SchemeAWSSM = "aws-sm"
+)
+
+var resolvers = map[string]Resolver{
+	SchemeEnv:   ResolverFunc(resolveEnv),
+	SchemeVault: ResolverFunc(resolveVault),
+	SchemeAWSSM: ResolverFunc(resolveAWSSecretsManager),
+}
+
+// Register makes the resolver handle references with the given scheme, replacing any resolver already
+// registered for it
+func Register(scheme string, resolver Resolver) {
+	resolvers[scheme] = resolver
+}
+
+// Schemes returns the schemes that have a resolver, in order
+func Schemes() []string {
+	schemes := make([]string, 0, len(resolvers))
+	for scheme := range resolvers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// parseReference splits s into the scheme of a registered resolver and the reference it resolves
+func parseReference(s string) (Resolver, string, string, bool) {
+	idx := strings.Index(s, ":")
+	if idx <= 0 {
+		return nil, "", "", false
+	}
+
+	resolver, ok := resolvers[s[:idx]]
+	if !ok {
+		return nil, "", "", false
+	}
+
+	return resolver, s[:idx], s[idx+1:], true
+}
+
+// IsReference returns whether s refers to a secret with the scheme of a registered resolver
+func IsReference(s string) bool {
+	_, _, _, ok := parseReference(s)
+	return ok
+}
+
+// Resolve returns the secret that s refers to, or s itself if it is not a reference
+func Resolve(s string) (string, error) {
+	resolver, scheme, ref, ok := parseReference(s)
+	if !ok {
+		return s, nil
+	}
+
+	if ref == "" {
+		return "", fmt.Errorf("invalid %s reference %q: nothing follows the scheme", scheme, s)
+	}
+
+	secret, err := resolver.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %q: %s", s, err)
+	}
+
+	return secret, nil
+}
+
+// ResolveAll replaces every string within doc, a document decoded from JSON, that is a reference with the
+// secret it refers to
+func ResolveAll(doc interface{}) error {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok {
+				secret, err := Resolve(s)
+				if err != nil {
+					return err
+				}
+				v[key] = secret
+				continue
+			}
+
+			if err := ResolveAll(value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			if s, ok := value.(string); ok {
+				secret, err := Resolve(s)
+				if err != nil {
+					return err
+				}
+				v[i] = secret
+				continue
+			}
+
+			if err := ResolveAll(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("the environment variable %s is not set", name)
+	}
+	return value, nil
+}
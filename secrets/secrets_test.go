@@ -0,0 +1,140 @@
+package secrets_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/10gen/stitch-cli/secrets"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolve(t *testing.T) {
+	t.Run("it leaves strings that are not references alone", func(t *testing.T) {
+		for _, s := range []string{"hunter2", "", "https://example.com", "unknown:scheme"} {
+			resolved, err := secrets.Resolve(s)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, resolved, gc.ShouldEqual, s)
+		}
+	})
+
+	t.Run("it resolves environment variables", func(t *testing.T) {
+		u.So(t, os.Setenv("STITCH_SECRETS_TEST", "shh"), gc.ShouldBeNil)
+		defer os.Unsetenv("STITCH_SECRETS_TEST")
+
+		resolved, err := secrets.Resolve("env:STITCH_SECRETS_TEST")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "shh")
+
+		_, err = secrets.Resolve("env:STITCH_SECRETS_TEST_UNSET")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "the environment variable STITCH_SECRETS_TEST_UNSET is not set")
+	})
+
+	t.Run("it uses registered resolvers", func(t *testing.T) {
+		secrets.Register("test", secrets.ResolverFunc(func(ref string) (string, error) {
+			if ref == "missing" {
+				return "", errors.New("no such secret")
+			}
+			return "secret for " + ref, nil
+		}))
+
+		u.So(t, secrets.IsReference("test:thing"), gc.ShouldBeTrue)
+
+		resolved, err := secrets.Resolve("test:thing")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "secret for thing")
+
+		_, err = secrets.Resolve("test:missing")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, `failed to resolve "test:missing": no such secret`)
+
+		_, err = secrets.Resolve("test:")
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+
+	t.Run("it resolves every reference in a document", func(t *testing.T) {
+		u.So(t, os.Setenv("STITCH_SECRETS_TEST", "shh"), gc.ShouldBeNil)
+		defer os.Unsetenv("STITCH_SECRETS_TEST")
+
+		doc := map[string]interface{}{
+			"services": map[string]interface{}{
+				"svc": map[string]interface{}{"auth_token": "env:STITCH_SECRETS_TEST", "plain": "value"},
+			},
+			"list": []interface{}{"env:STITCH_SECRETS_TEST", 3.0},
+		}
+
+		u.So(t, secrets.ResolveAll(doc), gc.ShouldBeNil)
+		u.So(t, doc, gc.ShouldResemble, map[string]interface{}{
+			"services": map[string]interface{}{
+				"svc": map[string]interface{}{"auth_token": "shh", "plain": "value"},
+			},
+			"list": []interface{}{"shh", 3.0},
+		})
+	})
+}
+
+func TestCommandResolvers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		u.MustSkipf(t, "fake commands are shell scripts")
+	}
+
+	binDir, err := ioutil.TempDir("", "stitch-secrets-bin")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(binDir)
+
+	// the fake commands print the arguments they were given, so that the tests can check them
+	for name, script := range map[string]string{
+		"vault": `echo "$@"`,
+		"aws":   `if [ "$4" = "json-secret" ]; then echo '{"clientSecret": "shh", "port": 5432}'; else echo "$@"; fi`,
+	} {
+		u.So(t, ioutil.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755), gc.ShouldBeNil)
+	}
+
+	path := os.Getenv("PATH")
+	u.So(t, os.Setenv("PATH", binDir), gc.ShouldBeNil)
+	defer os.Setenv("PATH", path)
+
+	t.Run("vault reads a field of a KV secret", func(t *testing.T) {
+		resolved, err := secrets.Resolve("vault:secret/my-app#clientSecret")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "kv get -field=clientSecret secret/my-app")
+
+		_, err = secrets.Resolve("vault:secret/my-app")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "expected <path>#<field>")
+	})
+
+	t.Run("aws-sm reads a secret", func(t *testing.T) {
+		resolved, err := secrets.Resolve("aws-sm:my-secret")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "secretsmanager get-secret-value --secret-id my-secret --query SecretString --output text")
+	})
+
+	t.Run("aws-sm reads a key of a JSON secret", func(t *testing.T) {
+		resolved, err := secrets.Resolve("aws-sm:json-secret#clientSecret")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "shh")
+
+		resolved, err = secrets.Resolve("aws-sm:json-secret#port")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "5432")
+
+		_, err = secrets.Resolve("aws-sm:json-secret#missing")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `has no key "missing"`)
+	})
+
+	t.Run("it fails when the command is not installed", func(t *testing.T) {
+		u.So(t, os.Setenv("PATH", ""), gc.ShouldBeNil)
+
+		_, err := secrets.Resolve("vault:secret/my-app#clientSecret")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "the vault command (https://www.vaultproject.io) must be installed")
+	})
+}
@@ -0,0 +1,238 @@
+// Package selector provides an interactive, filterable list for choosing one of a set of options
+// in a terminal.
+package selector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxVisibleOptions is the number of matching options displayed at once
+const maxVisibleOptions = 10
+
+// ErrInterrupted is returned when the user cancels a selection
+var ErrInterrupted = errors.New("selection cancelled")
+
+// ErrNoOptions is returned when there is nothing to select from
+var ErrNoOptions = errors.New("no options to select from")
+
+// Option is a single choice in a selector
+type Option struct {
+	// Label is what is displayed and matched against the filter
+	Label string
+	Value string
+}
+
+// key codes read from a terminal in raw mode
+const (
+	keyCtrlC     = 3
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyEnter     = '\r'
+	keyNewline   = '\n'
+	keyEscape    = 27
+	keyBackspace = 127
+	keyCtrlH     = 8
+)
+
+// Select displays the options to out and reads keystrokes from in until one is chosen. Typing filters
+// the options by fuzzy match, the arrow keys move between matches, and enter chooses the highlighted one.
+// in is expected to be a terminal in raw mode (see MakeRaw)
+func Select(in io.Reader, out io.Writer, prompt string, options []Option) (Option, error) {
+	if len(options) == 0 {
+		return Option{}, ErrNoOptions
+	}
+
+	s := &selection{
+		out:     out,
+		prompt:  prompt,
+		options: options,
+		matches: options,
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		s.render()
+
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			s.clear()
+			if err == io.EOF {
+				return Option{}, ErrInterrupted
+			}
+			return Option{}, err
+		}
+
+		switch r {
+		case keyCtrlC:
+			s.clear()
+			return Option{}, ErrInterrupted
+		case keyEnter, keyNewline:
+			if len(s.matches) == 0 {
+				continue
+			}
+			chosen := s.matches[s.cursor]
+			s.clear()
+			fmt.Fprintf(out, "%s: %s\r\n", prompt, chosen.Label)
+			return chosen, nil
+		case keyBackspace, keyCtrlH:
+			if len(s.filter) > 0 {
+				_, size := utf8.DecodeLastRuneInString(s.filter)
+				s.setFilter(s.filter[:len(s.filter)-size])
+			}
+		case keyCtrlP:
+			s.move(-1)
+		case keyCtrlN:
+			s.move(1)
+		case keyEscape:
+			// arrow keys are sent as ESC [ A (up) and ESC [ B (down)
+			if next, _, err := reader.ReadRune(); err != nil || next != '[' {
+				continue
+			}
+			switch code, _, _ := reader.ReadRune(); code {
+			case 'A':
+				s.move(-1)
+			case 'B':
+				s.move(1)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				s.setFilter(s.filter + string(r))
+			}
+		}
+	}
+}
+
+type selection struct {
+	out     io.Writer
+	prompt  string
+	options []Option
+	matches []Option
+	filter  string
+	cursor  int
+
+	// rendered is the number of lines written by the last render
+	rendered int
+}
+
+func (s *selection) setFilter(filter string) {
+	s.filter = filter
+	s.matches = Filter(s.options, filter)
+	s.cursor = 0
+}
+
+func (s *selection) move(delta int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	s.cursor = (s.cursor + delta + len(s.matches)) % len(s.matches)
+}
+
+// clear erases everything written by the last render
+func (s *selection) clear() {
+	if s.rendered > 1 {
+		fmt.Fprintf(s.out, "\033[%dA", s.rendered-1)
+	}
+	fmt.Fprint(s.out, "\r\033[J")
+	s.rendered = 0
+}
+
+func (s *selection) render() {
+	s.clear()
+
+	lines := []string{fmt.Sprintf("%s (type to filter, arrows to move, enter to select): %s", s.prompt, s.filter)}
+
+	// keep the cursor within the visible window of matches
+	start := 0
+	if s.cursor >= maxVisibleOptions {
+		start = s.cursor - maxVisibleOptions + 1
+	}
+	end := start + maxVisibleOptions
+	if end > len(s.matches) {
+		end = len(s.matches)
+	}
+
+	for i := start; i < end; i++ {
+		marker := "  "
+		if i == s.cursor {
+			marker = "> "
+		}
+		lines = append(lines, marker+s.matches[i].Label)
+	}
+
+	if len(s.matches) == 0 {
+		lines = append(lines, "  (no matches)")
+	} else if hidden := len(s.matches) - (end - start); hidden > 0 {
+		lines = append(lines, fmt.Sprintf("  ... %d more", hidden))
+	}
+
+	fmt.Fprint(s.out, strings.Join(lines, "\r\n"))
+	s.rendered = len(lines)
+}
+
+// Filter returns the options whose labels fuzzy match the filter, best matches first. An option matches
+// if every character of the filter appears in its label in order, ignoring case
+func Filter(options []Option, filter string) []Option {
+	if filter == "" {
+		return options
+	}
+
+	type scored struct {
+		option Option
+		score  int
+	}
+
+	var matches []scored
+	for _, option := range options {
+		if score, ok := fuzzyMatch(option.Label, filter); ok {
+			matches = append(matches, scored{option, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+
+	filtered := make([]Option, len(matches))
+	for i, match := range matches {
+		filtered[i] = match.option
+	}
+
+	return filtered
+}
+
+// fuzzyMatch reports whether pattern is a subsequence of text, ignoring case. Lower scores are better
+// matches: the score is the position of the first matched character plus the number of characters
+// skipped between the first and last matched characters
+func fuzzyMatch(text, pattern string) (int, bool) {
+	textRunes := []rune(strings.ToLower(text))
+	patternRunes := []rune(strings.ToLower(pattern))
+
+	first, last := -1, -1
+	p := 0
+	for i, r := range textRunes {
+		if p == len(patternRunes) {
+			break
+		}
+		if r == patternRunes[p] {
+			if first == -1 {
+				first = i
+			}
+			last = i
+			p++
+		}
+	}
+
+	if p < len(patternRunes) {
+		return 0, false
+	}
+
+	gaps := (last - first + 1) - len(patternRunes)
+	return first + gaps, true
+}
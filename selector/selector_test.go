@@ -0,0 +1,125 @@
+package selector
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+var testOptions = []Option{
+	{Label: "Production - 5a1", Value: "5a1"},
+	{Label: "Staging - 5b2", Value: "5b2"},
+	{Label: "Project Sandbox - 5c3", Value: "5c3"},
+}
+
+func TestFilter(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		filter      string
+		expected    []string
+	}{
+		{
+			description: "an empty filter matches everything in order",
+			filter:      "",
+			expected:    []string{"5a1", "5b2", "5c3"},
+		},
+		{
+			description: "matches are case insensitive subsequences",
+			filter:      "STG",
+			expected:    []string{"5b2"},
+		},
+		{
+			description: "earlier and tighter matches come first",
+			filter:      "pro",
+			expected:    []string{"5a1", "5c3"},
+		},
+		{
+			description: "a tight match beats an earlier loose one",
+			filter:      "sand",
+			expected:    []string{"5c3"},
+		},
+		{
+			description: "nothing matches a filter that is not a subsequence",
+			filter:      "xyz",
+			expected:    []string{},
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			values := []string{}
+			for _, option := range Filter(testOptions, tc.filter) {
+				values = append(values, option.Value)
+			}
+			u.So(t, values, gc.ShouldResemble, tc.expected)
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		input       string
+		expected    string
+	}{
+		{
+			description: "enter selects the first option",
+			input:       "\r",
+			expected:    "5a1",
+		},
+		{
+			description: "the down arrow moves to the next option",
+			input:       "\033[B\033[B\r",
+			expected:    "5c3",
+		},
+		{
+			description: "the up arrow wraps around to the last option",
+			input:       "\033[A\r",
+			expected:    "5c3",
+		},
+		{
+			description: "ctrl-n and ctrl-p move between options",
+			input:       "\x0e\x0e\x10\r",
+			expected:    "5b2",
+		},
+		{
+			description: "typing filters the options",
+			input:       "stag\r",
+			expected:    "5b2",
+		},
+		{
+			description: "backspace widens the filter again",
+			input:       "stag\x7f\x7f\x7f\x7f\033[B\r",
+			expected:    "5b2",
+		},
+		{
+			description: "enter is ignored when nothing matches",
+			input:       "xyz\r\x7f\x7f\x7f\r",
+			expected:    "5a1",
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			selected, err := Select(strings.NewReader(tc.input), out, "Atlas Project", testOptions)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, selected.Value, gc.ShouldEqual, tc.expected)
+			u.So(t, out.String(), gc.ShouldEndWith, "Atlas Project: "+selected.Label+"\r\n")
+		})
+	}
+
+	t.Run("ctrl-c cancels the selection", func(t *testing.T) {
+		_, err := Select(strings.NewReader("st\x03"), new(bytes.Buffer), "Atlas Project", testOptions)
+		u.So(t, err, gc.ShouldEqual, ErrInterrupted)
+	})
+
+	t.Run("running out of input cancels the selection", func(t *testing.T) {
+		_, err := Select(strings.NewReader("st"), new(bytes.Buffer), "Atlas Project", testOptions)
+		u.So(t, err, gc.ShouldEqual, ErrInterrupted)
+	})
+
+	t.Run("it fails without any options", func(t *testing.T) {
+		_, err := Select(strings.NewReader("\r"), new(bytes.Buffer), "Atlas Project", nil)
+		u.So(t, err, gc.ShouldEqual, ErrNoOptions)
+	})
+}
@@ -0,0 +1,10 @@
+package selector
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ioctlReadTermios  = unix.TCGETS
+	ioctlWriteTermios = unix.TCSETS
+)
@@ -0,0 +1,23 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package selector
+
+import (
+	"errors"
+)
+
+// Supported reports whether an interactive selector can be displayed on the terminal with the given file descriptor
+func Supported(fd int) bool {
+	return false
+}
+
+// MakeRaw is not supported on this platform
+func MakeRaw(fd int) (func() error, error) {
+	return nil, errors.New("interactive selection is not supported on this platform")
+}
+
+// Width is not supported on this platform, so it always returns 0
+func Width(fd int) int {
+	return 0
+}
@@ -0,0 +1,47 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package selector
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Supported reports whether an interactive selector can be displayed on the terminal with the given file descriptor
+func Supported(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	return err == nil
+}
+
+// MakeRaw puts the terminal with the given file descriptor into raw mode so that keystrokes can be read
+// as they are typed, returning a func that restores its previous state
+func MakeRaw(fd int) (func() error, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *termios
+
+	termios.Iflag &^= unix.ICRNL | unix.IXON
+	termios.Lflag &^= unix.ECHO | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, termios); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return unix.IoctlSetTermios(fd, ioctlWriteTermios, &previous)
+	}, nil
+}
+
+// Width returns the number of columns of the terminal with the given file descriptor, or 0 if it is not a terminal
+func Width(fd int) int {
+	winsize, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(winsize.Col)
+}
@@ -0,0 +1,159 @@
+// Package sourcemap reads version 3 source maps, as output by JavaScript bundlers and compilers, and maps
+// positions in the generated source back to the original ones.
+package sourcemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// Position is a place in a source, with a 1-based Line and Column
+type Position struct {
+	Source string
+	Line   int
+	Column int
+	Name   string
+}
+
+// Map maps the positions of a generated source to those of the sources it was generated from
+type Map struct {
+	// lines holds the segments of each generated line, in order of their generated column
+	lines   [][]segment
+	sources []string
+	names   []string
+}
+
+type segment struct {
+	column       int
+	source       int
+	sourceLine   int
+	sourceColumn int
+	name         int
+}
+
+type rawMap struct {
+	Version    int      `json:"version"`
+	SourceRoot string   `json:"sourceRoot"`
+	Sources    []string `json:"sources"`
+	Names      []string `json:"names"`
+	Mappings   string   `json:"mappings"`
+}
+
+// Parse reads a source map from its JSON
+func Parse(data []byte) (*Map, error) {
+	var raw rawMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", raw.Version)
+	}
+
+	m := &Map{sources: raw.Sources, names: raw.Names}
+	if raw.SourceRoot != "" {
+		m.sources = make([]string, len(raw.Sources))
+		for i, source := range raw.Sources {
+			m.sources[i] = path.Join(raw.SourceRoot, source)
+		}
+	}
+
+	// every field but the generated column is relative to its value in the previous segment of any line
+	var source, sourceLine, sourceColumn, name int
+	for _, line := range strings.Split(raw.Mappings, ";") {
+		var segments []segment
+		column := 0
+		for _, encoded := range strings.Split(line, ",") {
+			if encoded == "" {
+				continue
+			}
+
+			fields, err := decodeVLQ(encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			column += fields[0]
+			if len(fields) < 4 {
+				continue
+			}
+
+			source += fields[1]
+			sourceLine += fields[2]
+			sourceColumn += fields[3]
+			seg := segment{column: column, source: source, sourceLine: sourceLine, sourceColumn: sourceColumn, name: -1}
+			if len(fields) >= 5 {
+				name += fields[4]
+				seg.name = name
+			}
+			segments = append(segments, seg)
+		}
+
+		sort.SliceStable(segments, func(i, j int) bool { return segments[i].column < segments[j].column })
+		m.lines = append(m.lines, segments)
+	}
+
+	return m, nil
+}
+
+// Resolve returns the original position of the given 1-based line and column of the generated source, which
+// is that of the closest mapped position at or before it on the same line
+func (m *Map) Resolve(line, column int) (Position, bool) {
+	if line < 1 || line > len(m.lines) {
+		return Position{}, false
+	}
+
+	segments := m.lines[line-1]
+	i := sort.Search(len(segments), func(i int) bool { return segments[i].column > column-1 }) - 1
+	if i < 0 {
+		if len(segments) == 0 {
+			return Position{}, false
+		}
+		i = 0
+	}
+
+	seg := segments[i]
+	if seg.source < 0 || seg.source >= len(m.sources) {
+		return Position{}, false
+	}
+
+	position := Position{Source: m.sources[seg.source], Line: seg.sourceLine + 1, Column: seg.sourceColumn + 1}
+	if seg.name >= 0 && seg.name < len(m.names) {
+		position.Name = m.names[seg.name]
+	}
+	return position, true
+}
+
+// decodeVLQ decodes the base64 VLQ encoded fields of a mapping segment
+func decodeVLQ(encoded string) ([]int, error) {
+	var fields []int
+	var value, shift uint
+	for i := 0; i < len(encoded); i++ {
+		digit := strings.IndexByte(base64Digits, encoded[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid source map mapping %q", encoded)
+		}
+
+		value += uint(digit&31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+
+		field := int(value >> 1)
+		if value&1 != 0 {
+			field = -field
+		}
+		fields = append(fields, field)
+		value, shift = 0, 0
+	}
+
+	if shift != 0 {
+		return nil, fmt.Errorf("truncated source map mapping %q", encoded)
+	}
+	return fields, nil
+}
@@ -0,0 +1,62 @@
+package sourcemap_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/sourcemap"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolve(t *testing.T) {
+	// line 1 maps to line 3 of source.ts, and line 2 to line 1, with its column 5 onwards named "sum"
+	m, err := sourcemap.Parse([]byte(`{
+		"version": 3,
+		"sources": ["source.ts"],
+		"names": ["sum"],
+		"mappings": "AAEA;AAFA,IAAIA"
+	}`))
+	u.So(t, err, gc.ShouldBeNil)
+
+	for _, tc := range []struct {
+		line, column int
+		expected     sourcemap.Position
+	}{
+		{1, 1, sourcemap.Position{Source: "source.ts", Line: 3, Column: 1}},
+		{1, 20, sourcemap.Position{Source: "source.ts", Line: 3, Column: 1}},
+		{2, 4, sourcemap.Position{Source: "source.ts", Line: 1, Column: 1}},
+		{2, 5, sourcemap.Position{Source: "source.ts", Line: 1, Column: 5, Name: "sum"}},
+		{2, 9, sourcemap.Position{Source: "source.ts", Line: 1, Column: 5, Name: "sum"}},
+	} {
+		position, ok := m.Resolve(tc.line, tc.column)
+		u.So(t, ok, gc.ShouldBeTrue)
+		u.So(t, position, gc.ShouldResemble, tc.expected)
+	}
+
+	_, ok := m.Resolve(3, 1)
+	u.So(t, ok, gc.ShouldBeFalse)
+}
+
+func TestParse(t *testing.T) {
+	t.Run("it prefixes sources with the source root", func(t *testing.T) {
+		m, err := sourcemap.Parse([]byte(`{"version": 3, "sourceRoot": "functions/sum", "sources": ["source.ts"], "mappings": "AAAA"}`))
+		u.So(t, err, gc.ShouldBeNil)
+
+		position, ok := m.Resolve(1, 1)
+		u.So(t, ok, gc.ShouldBeTrue)
+		u.So(t, position.Source, gc.ShouldEqual, "functions/sum/source.ts")
+	})
+
+	t.Run("it fails on an unsupported version", func(t *testing.T) {
+		_, err := sourcemap.Parse([]byte(`{"version": 2, "mappings": ""}`))
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+
+	t.Run("it fails on invalid mappings", func(t *testing.T) {
+		_, err := sourcemap.Parse([]byte(`{"version": 3, "sources": ["source.ts"], "mappings": "AA!A"}`))
+		u.So(t, err, gc.ShouldNotBeNil)
+
+		_, err = sourcemap.Parse([]byte(`{"version": 3, "sources": ["source.ts"], "mappings": "AAAg"}`))
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+}
@@ -1,15 +1,27 @@
 package storage
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/10gen/stitch-cli/user"
 
 	"gopkg.in/yaml.v2"
 )
 
+// ErrIdentityNotFound is returned when reading an identity that has not been stored
+var ErrIdentityNotFound = errors.New("no identity is stored under this name")
+
+// config is the stored user data: the default user, along with any other identities stored by name
+type config struct {
+	user.User `yaml:",inline"`
+
+	Identities map[string]*user.User `yaml:"identities,omitempty"`
+}
+
 // New returns a new Storage given a Strategy
 func New(strategy Strategy) *Storage {
 	return &Storage{
@@ -22,54 +34,149 @@ type Storage struct {
 	strategy Strategy
 }
 
-// WriteUserConfig writes the user data to Storage
+// WriteUserConfig writes the user data to Storage, keeping the identities stored by name
 func (s *Storage) WriteUserConfig(u *user.User) error {
-	// TODO remove after personal API key support has been fully removed
-	if u.PublicAPIKey != "" {
-		u.Username = ""
+	c, err := s.read()
+	if err != nil {
+		return err
 	}
 
-	if u.PrivateAPIKey != "" {
-		u.APIKey = ""
+	migrateOnWrite(u)
+	c.User = *u
+
+	return s.write(c)
+}
+
+// ReadUserConfig reads the user data from Storage
+func (s *Storage) ReadUserConfig() (*user.User, error) {
+	c, err := s.read()
+	if err != nil {
+		return nil, err
 	}
 
-	raw, err := yaml.Marshal(u)
+	return &c.User, nil
+}
+
+// WriteIdentity writes the data of the user to Storage as the identity stored under name, alongside the
+// default user
+func (s *Storage) WriteIdentity(name string, u *user.User) error {
+	c, err := s.read()
 	if err != nil {
 		return err
 	}
 
-	return s.strategy.Write(raw)
+	migrateOnWrite(u)
+	if c.Identities == nil {
+		c.Identities = map[string]*user.User{}
+	}
+	c.Identities[name] = u
+
+	return s.write(c)
 }
 
-// ReadUserConfig reads the user data from Storage
-func (s *Storage) ReadUserConfig() (*user.User, error) {
-	b, err := s.strategy.Read()
+// ReadIdentity reads the data of the identity stored under name, returning ErrIdentityNotFound if there is none
+func (s *Storage) ReadIdentity(name string) (*user.User, error) {
+	c, err := s.read()
 	if err != nil {
 		return nil, err
 	}
 
-	var user user.User
-	if err := yaml.Unmarshal(b, &user); err != nil {
+	u, ok := c.Identities[name]
+	if !ok || u == nil {
+		return nil, ErrIdentityNotFound
+	}
+
+	return u, nil
+}
+
+// IdentityNames returns the names of the identities stored besides the default user, in order
+func (s *Storage) IdentityNames() ([]string, error) {
+	c, err := s.read()
+	if err != nil {
 		return nil, err
 	}
 
-	// TODO remove after personal API key support has been fully removed
-	if user.Username != "" && user.PublicAPIKey == "" {
-		user.PublicAPIKey = user.Username
+	names := make([]string, 0, len(c.Identities))
+	for name := range c.Identities {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	return names, nil
+}
 
-	if user.APIKey != "" && user.PrivateAPIKey == "" {
-		user.PrivateAPIKey = user.APIKey
+// ClearIdentity removes the identity stored under name from Storage
+func (s *Storage) ClearIdentity(name string) error {
+	c, err := s.read()
+	if err != nil {
+		return err
 	}
 
-	return &user, nil
+	if _, ok := c.Identities[name]; !ok {
+		return ErrIdentityNotFound
+	}
+	delete(c.Identities, name)
+
+	return s.write(c)
 }
 
-// Clear clears out a user's data from Storage
+// Clear clears out a user's data from Storage, keeping the identities stored by name
 func (s *Storage) Clear() error {
 	return s.WriteUserConfig(&user.User{})
 }
 
+func (s *Storage) read() (*config, error) {
+	b, err := s.strategy.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var c config
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	migrateOnRead(&c.User)
+	for _, u := range c.Identities {
+		if u != nil {
+			migrateOnRead(u)
+		}
+	}
+
+	return &c, nil
+}
+
+func (s *Storage) write(c *config) error {
+	raw, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return s.strategy.Write(raw)
+}
+
+// TODO remove after personal API key support has been fully removed
+func migrateOnWrite(u *user.User) {
+	if u.PublicAPIKey != "" {
+		u.Username = ""
+	}
+
+	if u.PrivateAPIKey != "" {
+		u.APIKey = ""
+	}
+}
+
+// TODO remove after personal API key support has been fully removed
+func migrateOnRead(u *user.User) {
+	if u.Username != "" && u.PublicAPIKey == "" {
+		u.PublicAPIKey = u.Username
+	}
+
+	if u.APIKey != "" && u.PrivateAPIKey == "" {
+		u.PrivateAPIKey = u.APIKey
+	}
+}
+
 // FileStrategy is a Storage that reads/persists data to/from a file at the provided path
 type FileStrategy struct {
 	path string
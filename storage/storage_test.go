@@ -3,6 +3,7 @@ package storage_test
 import (
 	"testing"
 
+	"github.com/10gen/stitch-cli/storage"
 	"github.com/10gen/stitch-cli/user"
 	u "github.com/10gen/stitch-cli/utils/test"
 
@@ -37,3 +38,45 @@ func TestStorageMigration(t *testing.T) {
 		u.So(t, migratedUser.PrivateAPIKey, gc.ShouldEqual, "my-api-key")
 	})
 }
+
+func TestStorageIdentities(t *testing.T) {
+	s := u.NewPopulatedStorage("my-api-key", "my.refresh.token", "my.access.token")
+
+	u.So(t, s.WriteIdentity("deploy-bot", &user.User{PublicAPIKey: "bot-public", PrivateAPIKey: "bot-private-key"}), gc.ShouldBeNil)
+	u.So(t, s.WriteIdentity("alice", &user.User{PublicAPIKey: "alice-public", PrivateAPIKey: "alice-private-key"}), gc.ShouldBeNil)
+
+	t.Run("it reads the identities stored by name", func(t *testing.T) {
+		identity, err := s.ReadIdentity("deploy-bot")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, identity, gc.ShouldResemble, &user.User{PublicAPIKey: "bot-public", PrivateAPIKey: "bot-private-key"})
+
+		names, err := s.IdentityNames()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, names, gc.ShouldResemble, []string{"alice", "deploy-bot"})
+
+		_, err = s.ReadIdentity("bob")
+		u.So(t, err, gc.ShouldEqual, storage.ErrIdentityNotFound)
+	})
+
+	t.Run("writing and clearing the default user keeps the identities", func(t *testing.T) {
+		u.So(t, s.WriteUserConfig(&user.User{PublicAPIKey: "other-public", PrivateAPIKey: "other-private-key"}), gc.ShouldBeNil)
+		u.So(t, s.Clear(), gc.ShouldBeNil)
+
+		defaultUser, err := s.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, defaultUser, gc.ShouldResemble, &user.User{})
+
+		names, err := s.IdentityNames()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, names, gc.ShouldResemble, []string{"alice", "deploy-bot"})
+	})
+
+	t.Run("clearing an identity removes only it", func(t *testing.T) {
+		u.So(t, s.ClearIdentity("alice"), gc.ShouldBeNil)
+		u.So(t, s.ClearIdentity("alice"), gc.ShouldEqual, storage.ErrIdentityNotFound)
+
+		names, err := s.IdentityNames()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, names, gc.ShouldResemble, []string{"deploy-bot"})
+	})
+}
@@ -0,0 +1,3 @@
+exports = function(amount) {
+  return context.services.get("stripe").charge(amount);
+};
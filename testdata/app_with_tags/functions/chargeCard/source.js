@@ -0,0 +1,3 @@
+exports = function(event) {
+  return context.services.get('stripe').post({url: 'https://api.stripe.com/v1/charges', body: event.fullDocument});
+};
@@ -0,0 +1,3 @@
+exports = function(total) {
+  return total.toFixed(2);
+};
@@ -0,0 +1,3 @@
+exports = function(name) {
+  return context.services.get("mongodb").db("shop").collection("users").findOne({name: name});
+};
@@ -0,0 +1,4 @@
+exports = function(order) {
+  const amount = context.functions.execute("formatAmount", order.total);
+  return amount + " " + context.values.get("currency");
+};
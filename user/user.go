@@ -3,13 +3,15 @@ package user
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/10gen/stitch-cli/auth"
 )
 
 // Errors related to user configuration.
 var (
-	ErrNotLoggedIn = errors.New("you are not logged in")
+	ErrNotLoggedIn    = errors.New("you are not logged in")
+	ErrSessionExpired = errors.New("your session has expired, run 'stitch-cli login' to log in again")
 )
 
 // User stores the user's login credentials and some metadata.
@@ -22,6 +24,11 @@ type User struct {
 
 	RefreshToken string `yaml:"refresh_token"`
 	AccessToken  string `yaml:"access_token"`
+
+	// The expiry of the tokens, as Unix times, so that it is known without decoding them. Tokens written by
+	// versions of the CLI that did not record it are decoded instead
+	AccessTokenExpiresAt  int64 `yaml:"access_token_expires_at,omitempty"`
+	RefreshTokenExpiresAt int64 `yaml:"refresh_token_expires_at,omitempty"`
 }
 
 // LoggedIn returns a boolean representing whether the user is logged in or not
@@ -40,6 +47,63 @@ func (u *User) TokenIsExpired() (bool, error) {
 	return token.Expired(), nil
 }
 
+// TokenExpiresWithin returns whether the access token expires within d, or an error if the token is invalid
+func (u *User) TokenExpiresWithin(d time.Duration) (bool, error) {
+	expiry, err := tokenExpiry(u.AccessToken, u.AccessTokenExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	return time.Now().Add(d).After(expiry), nil
+}
+
+// SetAccessToken records a new access token along with its expiry
+func (u *User) SetAccessToken(accessToken string) {
+	u.AccessToken = accessToken
+	u.AccessTokenExpiresAt = decodeTokenExpiry(accessToken)
+}
+
+// SetRefreshToken records a new refresh token along with its expiry
+func (u *User) SetRefreshToken(refreshToken string) {
+	u.RefreshToken = refreshToken
+	u.RefreshTokenExpiresAt = decodeTokenExpiry(refreshToken)
+}
+
+// SessionExpiry returns when the session ends, i.e. when the refresh token expires and the user has to log in
+// again, and false if that is unknown
+func (u *User) SessionExpiry() (time.Time, bool) {
+	expiry, err := tokenExpiry(u.RefreshToken, u.RefreshTokenExpiresAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return expiry, true
+}
+
+// tokenExpiry returns the recorded expiry of a token, decoding the token if none was recorded
+func tokenExpiry(token string, expiresAt int64) (time.Time, error) {
+	if expiresAt != 0 {
+		return time.Unix(expiresAt, 0), nil
+	}
+
+	jwt, err := auth.NewJWT(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(jwt.Exp, 0), nil
+}
+
+// decodeTokenExpiry returns the expiry of a token as a Unix time, or 0 if the token cannot be decoded
+func decodeTokenExpiry(token string) int64 {
+	jwt, err := auth.NewJWT(token)
+	if err != nil {
+		return 0
+	}
+
+	return jwt.Exp
+}
+
 // RedactedAPIKey returns a string representing the user's API key
 // with everything but the last portion of the key displayed as "*"
 func (u *User) RedactedAPIKey() string {
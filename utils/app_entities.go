@@ -0,0 +1,66 @@
+package utils
+
+// appSettingsEntity names the app-wide settings of stitch.json among the entities of an app
+const appSettingsEntity = "stitch.json"
+
+// AppEntities flattens an app, as loaded by UnmarshalFromDir, into its entities, keyed by a path-like name such
+// as "services/http/rules/r". The app-wide settings of stitch.json are keyed by "stitch.json"
+func AppEntities(app map[string]interface{}) map[string]interface{} {
+	entities := map[string]interface{}{}
+
+	settings := map[string]interface{}{}
+	for key, value := range app {
+		switch key {
+		case valuesName, authProvidersName, triggersName, functionsName, servicesName, secretsName, "name":
+		default:
+			settings[key] = value
+		}
+	}
+	entities[appSettingsEntity] = settings
+
+	if appSecrets, ok := app[secretsName]; ok {
+		entities[secretsName] = appSecrets
+	}
+
+	for _, kind := range []string{valuesName, authProvidersName, triggersName} {
+		for _, entity := range asSlice(app[kind]) {
+			entities[kind+"/"+entityName(entity)] = entity
+		}
+	}
+
+	for _, fn := range asSlice(app[functionsName]) {
+		entities[functionsName+"/"+directoryEntityName(fn)] = fn
+	}
+
+	for _, s := range asSlice(app[servicesName]) {
+		svc, _ := s.(map[string]interface{})
+		svcName := servicesName + "/" + directoryEntityName(svc)
+		entities[svcName] = svc[configName]
+
+		for _, webhook := range asSlice(svc[incomingWebhooksName]) {
+			entities[svcName+"/"+incomingWebhooksName+"/"+directoryEntityName(webhook)] = webhook
+		}
+		for _, rule := range asSlice(svc[rulesName]) {
+			entities[svcName+"/"+rulesName+"/"+entityName(rule)] = rule
+		}
+	}
+
+	return entities
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func entityName(entity interface{}) string {
+	m, _ := entity.(map[string]interface{})
+	name, _ := m["name"].(string)
+	return name
+}
+
+// directoryEntityName returns the name of an entity loaded from a directory, which is in its config
+func directoryEntityName(entity interface{}) string {
+	m, _ := entity.(map[string]interface{})
+	return entityName(m[configName])
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// orderedRuleFields are the fields of a rule whose elements are evaluated in order, e.g. the first of its
+// roles that applies to a document is the one used
+var orderedRuleFields = []string{"roles", "filters"}
+
+// NormalizeConfigFiles rewrites every JSON config file of the app in the directory at appPath with its object
+// keys sorted, so that exporting the same app twice gives the same files. Arrays keep their order, since it
+// is significant for fields such as the roles of a rule
+func NormalizeConfigFiles(appPath string) error {
+	for _, file := range ListConfigFiles(appPath) {
+		if err := rewriteJSONFile(filepath.Join(appPath, file.Path), func(interface{}) bool { return true }); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OrderDifferences compares an app as it was declared and as it was deployed, both loaded by UnmarshalFromDir,
+// returning the fields, such as "services/mongodb-atlas/rules/db.coll: .roles", whose elements are evaluated
+// in order and were deployed in a different order than they were declared
+func OrderDifferences(declared, deployed map[string]interface{}) []string {
+	declaredEntities, deployedEntities := AppEntities(declared), AppEntities(deployed)
+
+	var differences []string
+	for name, entity := range declaredEntities {
+		if !strings.Contains(name, "/"+rulesName+"/") {
+			continue
+		}
+
+		declaredRule, _ := entity.(map[string]interface{})
+		deployedRule, _ := deployedEntities[name].(map[string]interface{})
+		if deployedRule == nil {
+			continue
+		}
+
+		for _, field := range orderedRuleFields {
+			if isReordered(asSlice(declaredRule[field]), asSlice(deployedRule[field])) {
+				differences = append(differences, name+": ."+field)
+			}
+		}
+	}
+	sort.Strings(differences)
+
+	return differences
+}
+
+// isReordered returns whether a and b hold the same elements in a different order
+func isReordered(a, b []interface{}) bool {
+	if len(a) != len(b) || reflect.DeepEqual(a, b) {
+		return false
+	}
+
+	matched := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for i, y := range b {
+			if !matched[i] && reflect.DeepEqual(x, y) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,87 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNormalizeConfigFiles(t *testing.T) {
+	appDir, err := ioutil.TempDir("", "stitch-normalize")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(appDir)
+
+	rulePath := filepath.Join(appDir, "services", "mongodb-atlas", "rules", "db.coll.json")
+	u.So(t, os.MkdirAll(filepath.Dir(rulePath), 0755), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(appDir, "stitch.json"), []byte(`{"name": "my-app", "app_id": "my-app-abcde"}`), 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(appDir, "services", "mongodb-atlas", "config.json"), []byte(`{"type": "mongodb-atlas", "name": "mongodb-atlas"}`), 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(rulePath, []byte(`{"roles": [{"name": "owner"}, {"name": "admin"}], "database": "db", "collection": "coll"}`), 0644), gc.ShouldBeNil)
+
+	u.So(t, utils.NormalizeConfigFiles(appDir), gc.ShouldBeNil)
+
+	appConfig, err := ioutil.ReadFile(filepath.Join(appDir, "stitch.json"))
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, string(appConfig), gc.ShouldEqual, "{\n    \"app_id\": \"my-app-abcde\",\n    \"name\": \"my-app\"\n}\n")
+
+	rule, err := ioutil.ReadFile(rulePath)
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, string(rule), gc.ShouldEqual, `{
+    "collection": "coll",
+    "database": "db",
+    "roles": [
+        {
+            "name": "owner"
+        },
+        {
+            "name": "admin"
+        }
+    ]
+}
+`)
+
+	t.Run("it is stable", func(t *testing.T) {
+		u.So(t, utils.NormalizeConfigFiles(appDir), gc.ShouldBeNil)
+
+		again, err := ioutil.ReadFile(rulePath)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(again), gc.ShouldEqual, string(rule))
+	})
+}
+
+func TestOrderDifferences(t *testing.T) {
+	newApp := func(roles ...string) map[string]interface{} {
+		var ruleRoles []interface{}
+		for _, role := range roles {
+			ruleRoles = append(ruleRoles, map[string]interface{}{"name": role})
+		}
+
+		return map[string]interface{}{
+			"services": []interface{}{
+				map[string]interface{}{
+					"config": map[string]interface{}{"name": "mongodb-atlas"},
+					"rules":  []interface{}{map[string]interface{}{"name": "db.coll", "roles": ruleRoles}},
+				},
+			},
+		}
+	}
+
+	t.Run("it reports roles deployed in a different order", func(t *testing.T) {
+		differences := utils.OrderDifferences(newApp("owner", "admin"), newApp("admin", "owner"))
+		u.So(t, differences, gc.ShouldResemble, []string{"services/mongodb-atlas/rules/db.coll: .roles"})
+	})
+
+	t.Run("it ignores roles deployed in the same order", func(t *testing.T) {
+		u.So(t, utils.OrderDifferences(newApp("owner", "admin"), newApp("owner", "admin")), gc.ShouldBeEmpty)
+	})
+
+	t.Run("it ignores roles that changed rather than moved", func(t *testing.T) {
+		u.So(t, utils.OrderDifferences(newApp("owner", "admin"), newApp("admin", "reader")), gc.ShouldBeEmpty)
+		u.So(t, utils.OrderDifferences(newApp("owner", "admin"), newApp("admin")), gc.ShouldBeEmpty)
+	})
+}
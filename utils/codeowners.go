@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// CodeOwners maps paths to the owners given to them by a CODEOWNERS-style file: each line holds a pattern
+// followed by owners, the last line whose pattern matches a path decides its owners, and blank lines and
+// lines starting with "#" are ignored
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern string
+	owners  []string
+}
+
+// ParseCodeOwners reads a CODEOWNERS-style file
+func ParseCodeOwners(r io.Reader) (*CodeOwners, error) {
+	codeOwners := &CodeOwners{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if _, err := path.Match(strings.Trim(fields[0], "/"), ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q on line %d: %s", fields[0], line, err)
+		}
+
+		codeOwners.rules = append(codeOwners.rules, codeOwnersRule{pattern: fields[0], owners: fields[1:]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return codeOwners, nil
+}
+
+// CodeOwnersFileToCodeOwners reads the CODEOWNERS-style file at the path given
+func CodeOwnersFileToCodeOwners(filePath string) (*CodeOwners, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseCodeOwners(f)
+}
+
+// Owners returns the owners of the slash-separated path relative to the root of the file, or nil if none
+// are given to it. A pattern matching a directory matches everything under it, a pattern starting with "/"
+// is matched from the root, and one without any other "/" is matched against names at any depth
+func (co *CodeOwners) Owners(filePath string) []string {
+	filePath = strings.Trim(filePath, "/")
+
+	var owners []string
+	for _, rule := range co.rules {
+		if matchCodeOwnersPattern(rule.pattern, filePath) {
+			owners = rule.owners
+		}
+	}
+
+	return owners
+}
+
+func matchCodeOwnersPattern(pattern, filePath string) bool {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+
+	byName := !anchored && !strings.Contains(pattern, "/")
+
+	// the path matches if it, or any directory it is in, matches
+	segments := strings.Split(filePath, "/")
+	for i := range segments {
+		candidate := strings.Join(segments[:i+1], "/")
+		if byName {
+			candidate = segments[i]
+		}
+
+		if matched, _ := path.Match(pattern, candidate); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCodeOwners(t *testing.T) {
+	codeOwners, err := utils.ParseCodeOwners(strings.NewReader(strings.Join([]string{
+		"# default owners",
+		"*                 @everyone",
+		"",
+		"/functions/       @fns",
+		"/functions/legacy",
+		"rules             @data",
+		"/services/*/rules/db.users @data @users",
+	}, "\n")))
+	u.So(t, err, gc.ShouldBeNil)
+
+	for _, tc := range []struct {
+		path   string
+		owners []string
+	}{
+		{"stitch.json", []string{"@everyone"}},
+		{"functions/sum", []string{"@fns"}},
+		{"functions/legacy", []string{}},
+		{"services/http1/rules/get", []string{"@data"}},
+		{"services/mongodb-atlas/rules/db.users", []string{"@data", "@users"}},
+		{"apps/functions/sum", []string{"@everyone"}},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			u.So(t, codeOwners.Owners(tc.path), gc.ShouldResemble, tc.owners)
+		})
+	}
+
+	t.Run("it rejects invalid patterns", func(t *testing.T) {
+		_, err := utils.ParseCodeOwners(strings.NewReader("* @everyone\n[ @nobody"))
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldStartWith, `invalid pattern "[" on line 2`)
+	})
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// ConfigFileName is the name of the file the CLI stores user configuration in, within its config directory
+const ConfigFileName = "stitch"
+
+// configDirName is the name of the CLI's directory within the platform's config directory
+const configDirName = "stitch"
+
+// LegacyConfigDir returns the directory the CLI kept its config and cache files in before XDG_CONFIG_HOME
+// and APPDATA were honored
+func LegacyConfigDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", configDirName), nil
+}
+
+// DefaultConfigDir returns the directory the CLI keeps its config and cache files in when no --config-path
+// is given: $XDG_CONFIG_HOME/stitch if XDG_CONFIG_HOME is set, %APPDATA%\stitch on Windows, and
+// ~/.config/stitch otherwise
+func DefaultConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, configDirName), nil
+	}
+
+	if dir := os.Getenv("APPDATA"); runtime.GOOS == "windows" && dir != "" {
+		return filepath.Join(dir, configDirName), nil
+	}
+
+	return LegacyConfigDir()
+}
+
+// ResolveConfigPath returns the path of the CLI's config file: configPath, with "~" expanded, if one is
+// given, and the config file in DefaultConfigDir otherwise. Cache files are kept in the same directory
+func ResolveConfigPath(configPath string) (string, error) {
+	if configPath != "" {
+		return homedir.Expand(configPath)
+	}
+
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, ConfigFileName), nil
+}
+
+// MigrateLegacyConfig moves the config and cache files from the legacy config directory into dir, unless
+// dir already has a config file. It returns whether any files were moved
+func MigrateLegacyConfig(dir string) (bool, error) {
+	legacyDir, err := LegacyConfigDir()
+	if err != nil {
+		return false, err
+	}
+
+	if filepath.Clean(legacyDir) == filepath.Clean(dir) {
+		return false, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ConfigFileName)); !os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(legacyDir, ConfigFileName)); err != nil {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, err
+	}
+
+	// the config file goes last, so that a failed migration is attempted again next time
+	for _, name := range []string{HostingCacheFileName, GroupsCacheFileName, ConfigFileName} {
+		if err := os.Rename(filepath.Join(legacyDir, name), filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestConfigDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "stitch-config-dir")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(tmpDir)
+
+	home := filepath.Join(tmpDir, "home")
+	xdgConfigHome := filepath.Join(tmpDir, "xdg")
+
+	setEnv := func(key, value string) func() {
+		original, ok := os.LookupEnv(key)
+		os.Setenv(key, value)
+		return func() {
+			if ok {
+				os.Setenv(key, original)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}
+
+	defer setEnv("HOME", home)()
+	homedir.DisableCache = true
+	defer func() { homedir.DisableCache = false }()
+
+	t.Run("it defaults to the legacy directory without XDG_CONFIG_HOME", func(t *testing.T) {
+		defer setEnv("XDG_CONFIG_HOME", "")()
+
+		dir, err := utils.DefaultConfigDir()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, dir, gc.ShouldEqual, filepath.Join(home, ".config", "stitch"))
+	})
+
+	t.Run("it honors XDG_CONFIG_HOME", func(t *testing.T) {
+		defer setEnv("XDG_CONFIG_HOME", xdgConfigHome)()
+
+		configPath, err := utils.ResolveConfigPath("")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, configPath, gc.ShouldEqual, filepath.Join(xdgConfigHome, "stitch", "stitch"))
+	})
+
+	t.Run("it prefers a config path that is given", func(t *testing.T) {
+		defer setEnv("XDG_CONFIG_HOME", xdgConfigHome)()
+
+		configPath, err := utils.ResolveConfigPath("~/elsewhere/stitch.yml")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, configPath, gc.ShouldEqual, filepath.Join(home, "elsewhere", "stitch.yml"))
+	})
+
+	t.Run("it migrates config and cache files from the legacy directory", func(t *testing.T) {
+		legacyDir := filepath.Join(home, ".config", "stitch")
+		u.So(t, os.MkdirAll(legacyDir, 0700), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(legacyDir, utils.ConfigFileName), []byte("api_key: my-api-key"), 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(legacyDir, utils.GroupsCacheFileName), []byte("{}"), 0600), gc.ShouldBeNil)
+
+		newDir := filepath.Join(xdgConfigHome, "stitch")
+
+		migrated, err := utils.MigrateLegacyConfig(newDir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, migrated, gc.ShouldBeTrue)
+
+		data, err := ioutil.ReadFile(filepath.Join(newDir, utils.ConfigFileName))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(data), gc.ShouldEqual, "api_key: my-api-key")
+
+		_, err = os.Stat(filepath.Join(newDir, utils.GroupsCacheFileName))
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = os.Stat(filepath.Join(legacyDir, utils.ConfigFileName))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		// a second attempt finds the config already in place
+		migrated, err = utils.MigrateLegacyConfig(newDir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, migrated, gc.ShouldBeFalse)
+	})
+
+	t.Run("it does not migrate over an existing config", func(t *testing.T) {
+		legacyDir := filepath.Join(home, ".config", "stitch")
+		u.So(t, ioutil.WriteFile(filepath.Join(legacyDir, utils.ConfigFileName), []byte("api_key: old-api-key"), 0600), gc.ShouldBeNil)
+
+		newDir := filepath.Join(xdgConfigHome, "stitch")
+		migrated, err := utils.MigrateLegacyConfig(newDir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, migrated, gc.ShouldBeFalse)
+
+		data, err := ioutil.ReadFile(filepath.Join(newDir, utils.ConfigFileName))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(data), gc.ShouldEqual, "api_key: my-api-key")
+	})
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ConfigKind identifies the type of entity described by a file in an app directory
+type ConfigKind string
+
+// The set of known ConfigKinds
+const (
+	ConfigKindApp             ConfigKind = "app"
+	ConfigKindSecrets         ConfigKind = "secrets"
+	ConfigKindValue           ConfigKind = "value"
+	ConfigKindAuthProvider    ConfigKind = "auth_provider"
+	ConfigKindFunction        ConfigKind = "function"
+	ConfigKindTrigger         ConfigKind = "trigger"
+	ConfigKindService         ConfigKind = "service"
+	ConfigKindRule            ConfigKind = "rule"
+	ConfigKindIncomingWebhook ConfigKind = "incoming_webhook"
+)
+
+// ConfigFile is a single JSON config file within an app directory
+type ConfigFile struct {
+	// Path is relative to the root of the app directory
+	Path string
+	Kind ConfigKind
+}
+
+// ListConfigFiles returns every JSON config file that makes up the Stitch app in the given directory,
+// following the same layout used by UnmarshalFromDir
+func ListConfigFiles(path string) []ConfigFile {
+	files := []ConfigFile{{Path: appConfigName + jsonExt, Kind: ConfigKindApp}}
+
+	if _, err := os.Stat(filepath.Join(path, secretsName+jsonExt)); err == nil {
+		files = append(files, ConfigFile{Path: secretsName + jsonExt, Kind: ConfigKindSecrets})
+	}
+
+	files = append(files, listJSONFiles(path, valuesName, ConfigKindValue)...)
+	files = append(files, listJSONFiles(path, authProvidersName, ConfigKindAuthProvider)...)
+	files = append(files, listDirectoryConfigFiles(path, functionsName, ConfigKindFunction)...)
+	files = append(files, listJSONFiles(path, triggersName, ConfigKindTrigger)...)
+
+	for _, svcDir := range listDirectories(filepath.Join(path, servicesName)) {
+		svcPath := filepath.Join(servicesName, svcDir)
+		files = append(files, ConfigFile{Path: filepath.Join(svcPath, configName+jsonExt), Kind: ConfigKindService})
+		files = append(files, listDirectoryConfigFiles(path, filepath.Join(svcPath, incomingWebhooksName), ConfigKindIncomingWebhook)...)
+		files = append(files, listJSONFiles(path, filepath.Join(svcPath, rulesName), ConfigKindRule)...)
+	}
+
+	return files
+}
+
+// listJSONFiles lists the .json files directly inside of root/dir
+func listJSONFiles(root, dir string, kind ConfigKind) []ConfigFile {
+	fileInfos, _ := ioutil.ReadDir(filepath.Join(root, dir))
+
+	var files []ConfigFile
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() || filepath.Ext(fileInfo.Name()) != jsonExt {
+			continue
+		}
+
+		files = append(files, ConfigFile{Path: filepath.Join(dir, fileInfo.Name()), Kind: kind})
+	}
+
+	return files
+}
+
+// listDirectoryConfigFiles lists the config.json files of each directory inside of root/dir
+func listDirectoryConfigFiles(root, dir string, kind ConfigKind) []ConfigFile {
+	var files []ConfigFile
+	for _, name := range listDirectories(filepath.Join(root, dir)) {
+		if dir == functionsName && name == FunctionLibDir {
+			continue
+		}
+		files = append(files, ConfigFile{Path: filepath.Join(dir, name, configName+jsonExt), Kind: kind})
+	}
+
+	return files
+}
+
+func listDirectories(path string) []string {
+	fileInfos, _ := ioutil.ReadDir(path)
+
+	var names []string
+	for _, fileInfo := range fileInfos {
+		if info, err := os.Stat(filepath.Join(path, fileInfo.Name())); err != nil || !info.IsDir() {
+			continue
+		}
+
+		names = append(names, fileInfo.Name())
+	}
+
+	return names
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DeployLockValueName is the name of the private value that holds the lock taken on an app while it is
+// imported. It belongs to the deployed app rather than to its configuration, so it is left out of exports
+const DeployLockValueName = "stitch_cli_deploy_lock"
+
+// RemoveDeployLock removes the value holding the deploy lock from the app directory at appPath, if it is there
+func RemoveDeployLock(appPath string) error {
+	valuesPath := filepath.Join(appPath, valuesName)
+
+	fileInfos, _ := ioutil.ReadDir(valuesPath)
+	for _, fileInfo := range fileInfos {
+		valuePath := filepath.Join(valuesPath, fileInfo.Name())
+		if filepath.Ext(valuePath) != jsonExt {
+			continue
+		}
+
+		var value map[string]interface{}
+		if err := readAndUnmarshalJSONInto(valuePath, &value); err != nil {
+			return err
+		}
+
+		if value["name"] == DeployLockValueName {
+			return os.Remove(valuePath)
+		}
+	}
+
+	return nil
+}
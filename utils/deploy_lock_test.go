@@ -0,0 +1,38 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRemoveDeployLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-deploy-lock")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	valuesPath := filepath.Join(dir, "values")
+	u.So(t, os.MkdirAll(valuesPath, 0755), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(valuesPath, "limit.json"), []byte(`{"name": "limit", "value": 10}`), 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(
+		filepath.Join(valuesPath, utils.DeployLockValueName+".json"),
+		[]byte(`{"name": "`+utils.DeployLockValueName+`", "value": {"holder": "me"}, "private": true}`),
+		0644,
+	), gc.ShouldBeNil)
+
+	u.So(t, utils.RemoveDeployLock(dir), gc.ShouldBeNil)
+
+	infos, err := ioutil.ReadDir(valuesPath)
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, infos, gc.ShouldHaveLength, 1)
+	u.So(t, infos[0].Name(), gc.ShouldEqual, "limit.json")
+
+	t.Run("it does nothing for an app without values", func(t *testing.T) {
+		u.So(t, utils.RemoveDeployLock(filepath.Join(dir, "missing")), gc.ShouldBeNil)
+	})
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DisabledEntity identifies a disabled trigger, auth provider or function in an app directory
+type DisabledEntity struct {
+	Kind ConfigKind
+	Name string
+
+	// Path is the location of the entity relative to the root of the app directory: its config file, or the
+	// directory of a function
+	Path string
+}
+
+// ListDisabled returns the triggers, auth providers and functions of the app directory at appPath that have
+// "disabled": true in their config
+func ListDisabled(appPath string) ([]DisabledEntity, error) {
+	var disabled []DisabledEntity
+	for _, file := range ListConfigFiles(appPath) {
+		switch file.Kind {
+		case ConfigKindTrigger, ConfigKindAuthProvider, ConfigKindFunction:
+		default:
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(appPath, file.Path))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		var config struct {
+			Name     string `json:"name"`
+			Disabled bool   `json:"disabled"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, err
+		}
+
+		if !config.Disabled {
+			continue
+		}
+
+		entityPath := file.Path
+		if file.Kind == ConfigKindFunction {
+			entityPath = filepath.Dir(file.Path)
+		}
+
+		disabled = append(disabled, DisabledEntity{Kind: file.Kind, Name: config.Name, Path: filepath.ToSlash(entityPath)})
+	}
+
+	return disabled, nil
+}
+
+// RemoveDisabled deletes the disabled triggers, auth providers and functions from the app directory at
+// appPath, returning those it deleted
+func RemoveDisabled(appPath string) ([]DisabledEntity, error) {
+	disabled, err := ListDisabled(appPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range disabled {
+		if err := os.RemoveAll(filepath.Join(appPath, filepath.FromSlash(entity.Path))); err != nil {
+			return nil, err
+		}
+	}
+
+	return disabled, nil
+}
@@ -0,0 +1,65 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRemoveDisabled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-disabled")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"stitch.json":                   `{"name": "my-app"}`,
+		"auth_providers/anon-user.json": `{"name": "anon-user", "type": "anon-user", "disabled": true}`,
+		"auth_providers/api-key.json":   `{"name": "api-key", "type": "api-key", "disabled": false}`,
+		"functions/old/config.json":     `{"name": "old", "disabled": true}`,
+		"functions/old/source.js":       `exports = function() {};`,
+		"functions/current/config.json": `{"name": "current"}`,
+		"triggers/onInsert.json":        `{"name": "onInsert", "type": "DATABASE", "disabled": true}`,
+		"triggers/onLogin.json":         `{"name": "onLogin", "type": "AUTHENTICATION"}`,
+		"services/svc/config.json":      `{"name": "svc", "type": "http", "disabled": true}`,
+		"values/disabled.json":          `{"name": "disabled", "value": true}`,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	expected := []utils.DisabledEntity{
+		{Kind: utils.ConfigKindAuthProvider, Name: "anon-user", Path: "auth_providers/anon-user.json"},
+		{Kind: utils.ConfigKindFunction, Name: "old", Path: "functions/old"},
+		{Kind: utils.ConfigKindTrigger, Name: "onInsert", Path: "triggers/onInsert.json"},
+	}
+
+	t.Run("it lists the disabled triggers, auth providers and functions", func(t *testing.T) {
+		disabled, err := utils.ListDisabled(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, disabled, gc.ShouldResemble, expected)
+	})
+
+	t.Run("it removes them and leaves everything else alone", func(t *testing.T) {
+		removed, err := utils.RemoveDisabled(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, removed, gc.ShouldResemble, expected)
+
+		for _, entity := range expected {
+			_, err := os.Stat(filepath.Join(dir, entity.Path))
+			u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+		}
+
+		for _, name := range []string{"auth_providers/api-key.json", "functions/current/config.json", "triggers/onLogin.json", "services/svc/config.json", "values/disabled.json"} {
+			_, err := os.Stat(filepath.Join(dir, name))
+			u.So(t, err, gc.ShouldBeNil)
+		}
+	})
+}
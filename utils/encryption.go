@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// AgeEncryptedArchiveExtension is appended to the names of app archives encrypted with age
+	AgeEncryptedArchiveExtension = ".zip.age"
+
+	ageCommand = "age"
+	ageScheme  = "age"
+)
+
+// ageHeader begins every file encrypted by age
+var ageHeader = []byte("age-encryption.org/v1")
+
+// ParseEncryptionRecipient parses an encryption target of the form "age:<recipient>" and returns the recipient
+func ParseEncryptionRecipient(target string) (string, error) {
+	parts := strings.SplitN(target, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("invalid encryption target %q: expected %s:<recipient>", target, ageScheme)
+	}
+
+	if parts[0] != ageScheme {
+		return "", fmt.Errorf("unsupported encryption scheme %q; the only supported scheme is %q", parts[0], ageScheme)
+	}
+
+	return parts[1], nil
+}
+
+// EncryptWithAge encrypts the data read from r to recipient using the age command line tool, writing the result to dest
+func EncryptWithAge(recipient, dest string, r io.Reader) error {
+	return runAge(r, nil, "--encrypt", "--recipient", recipient, "--output", dest)
+}
+
+// DecryptWithAge decrypts the file at src with the age identity in the file at identityPath using the age
+// command line tool, and returns the decrypted data
+func DecryptWithAge(identityPath, src string) ([]byte, error) {
+	var decrypted bytes.Buffer
+	if err := runAge(nil, &decrypted, "--decrypt", "--identity", identityPath, src); err != nil {
+		return nil, err
+	}
+
+	return decrypted.Bytes(), nil
+}
+
+// IsAgeEncrypted reports whether the file at path was encrypted with age
+func IsAgeEncrypted(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header, err := bufio.NewReader(f).Peek(len(ageHeader))
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	return bytes.Equal(header, ageHeader), nil
+}
+
+func runAge(stdin io.Reader, stdout io.Writer, args ...string) error {
+	if _, err := exec.LookPath(ageCommand); err != nil {
+		return fmt.Errorf("the %s command (https://age-encryption.org) must be installed to encrypt or decrypt app archives", ageCommand)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(ageCommand, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %s", ageCommand, msg)
+		}
+		return fmt.Errorf("%s failed: %s", ageCommand, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseEncryptionRecipient(t *testing.T) {
+	t.Run("should parse an age recipient", func(t *testing.T) {
+		recipient, err := utils.ParseEncryptionRecipient("age:age1recipient")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, recipient, gc.ShouldEqual, "age1recipient")
+	})
+
+	for _, target := range []string{"age1recipient", "age:", "gpg:someone@example.com"} {
+		t.Run("should fail to parse "+target, func(t *testing.T) {
+			_, err := utils.ParseEncryptionRecipient(target)
+			u.So(t, err, gc.ShouldNotBeNil)
+		})
+	}
+}
+
+func TestIsAgeEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-encryption")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		contents string
+		expected bool
+	}{
+		{"age-encryption.org/v1\n-> X25519 abc\n", true},
+		{"PK\x03\x04", false},
+		{"", false},
+	} {
+		path := filepath.Join(dir, "archive")
+		u.So(t, ioutil.WriteFile(path, []byte(tc.contents), 0600), gc.ShouldBeNil)
+
+		encrypted, err := utils.IsAgeEncrypted(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, encrypted, gc.ShouldEqual, tc.expected)
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var envFileKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ReadEnvFile reads the .env-style file at path into a map of its keys to values
+func ReadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseEnvFile(f)
+}
+
+// ParseEnvFile parses a .env-style file of KEY=VALUE lines. Blank lines and lines starting with "#" are
+// ignored, as is an "export " prefix. Values may be single-quoted to be taken literally, or double-quoted to
+// allow escapes such as "\n"; a " #" starts a comment after an unquoted value
+func ParseEnvFile(r io.Reader) (map[string]string, error) {
+	env := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+
+		key := strings.TrimSpace(line[:eq])
+		if !envFileKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNum, key)
+		}
+
+		value, err := parseEnvFileValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNum, err)
+		}
+
+		env[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func parseEnvFileValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote in %s", raw)
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		for i := 1; i < len(raw); i++ {
+			if raw[i] == '\\' {
+				i++
+				continue
+			}
+			if raw[i] == '"' {
+				value, err := strconv.Unquote(raw[:i+1])
+				if err != nil {
+					return "", fmt.Errorf("invalid quoted value %s", raw[:i+1])
+				}
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("unterminated quote in %s", raw)
+	}
+
+	if comment := strings.Index(raw, " #"); comment >= 0 {
+		raw = raw[:comment]
+	}
+
+	return strings.TrimSpace(raw), nil
+}
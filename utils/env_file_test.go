@@ -0,0 +1,47 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	t.Run("it parses keys and values", func(t *testing.T) {
+		env, err := utils.ParseEnvFile(strings.NewReader(`
+# credentials for local testing
+API_KEY=abc123
+export REGION = us-east-1   # where it runs
+GREETING="hello\n\"world\""
+LITERAL='no \n escapes # here'
+EMPTY=
+with.dots-and-dashes=ok
+`))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, env, gc.ShouldResemble, map[string]string{
+			"API_KEY":              "abc123",
+			"REGION":               "us-east-1",
+			"GREETING":             "hello\n\"world\"",
+			"LITERAL":              `no \n escapes # here`,
+			"EMPTY":                "",
+			"with.dots-and-dashes": "ok",
+		})
+	})
+
+	t.Run("it reports the line of an invalid entry", func(t *testing.T) {
+		for _, tc := range []struct {
+			data, err string
+		}{
+			{"A=1\nNOT_AN_ENTRY", "line 2: expected KEY=VALUE"},
+			{"1BAD=1", `line 1: invalid key "1BAD"`},
+			{"\n\nA=\"unterminated", `line 3: unterminated quote in "unterminated`},
+		} {
+			_, err := utils.ParseEnvFile(strings.NewReader(tc.data))
+			u.So(t, err, gc.ShouldNotBeNil)
+			u.So(t, err.Error(), gc.ShouldEqual, tc.err)
+		}
+	})
+}
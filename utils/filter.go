@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// RunFilter runs command through the shell with data on stdin, along with env on top of the environment of the
+// CLI, and returns what it writes to stdout. A failing command's error includes what it wrote to stderr
+func RunFilter(command string, env []string, data []byte) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultFunctionBuildCommand compiles the TypeScript source of a function given on stdin into the CommonJS
+	// the platform runs, with an inline source map that BuildFunctionSources moves out of the source
+	DefaultFunctionBuildCommand = `esbuild --loader=ts --format=cjs --target=es2017 --sourcemap=inline --sourcefile="$FUNCTION_SOURCE"`
+
+	// FunctionSourceMapsDir is the directory of an app in which BuildFunctionSources keeps the source maps of the
+	// functions it builds, so that stack traces of the deployed functions can be mapped back to their sources
+	FunctionSourceMapsDir = ".stitch-sourcemaps"
+
+	tsExt = ".ts"
+)
+
+// inlineSourceMapPrefix starts the comment holding an inline, base64-encoded source map
+var inlineSourceMapPrefix = []byte("//# sourceMappingURL=data:application/json;base64,")
+
+// BuiltFunction is a function or incoming webhook whose source.js BuildFunctionSources built from its source.ts
+type BuiltFunction struct {
+	// Dir is the directory of the function, relative to the app directory
+	Dir string
+
+	// SourceMap is the path of the source map kept for the built source, or "" if the build did not output one
+	SourceMap string
+}
+
+// BuildFunctionSources copies the configuration of the app directory at appPath to dest, building a source.js
+// for each function and incoming webhook that has a source.ts. The TypeScript is given to command on stdin, with
+// its path relative to the app directory in $FUNCTION_SOURCE, and the command writes the JavaScript to stdout.
+// An inline source map at the end of the output is moved to FunctionSourceMapsDir under mapsDir, rather than
+// uploaded along with the source
+func BuildFunctionSources(appPath, dest, mapsDir, command string) ([]BuiltFunction, error) {
+	if err := copyAppConfig(appPath, dest); err != nil {
+		return nil, err
+	}
+
+	dirs, err := functionDirectories(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	var built []BuiltFunction
+	for _, dir := range dirs {
+		tsPath := filepath.Join(dir, sourceName+tsExt)
+		ts, err := ioutil.ReadFile(tsPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		relDir, err := filepath.Rel(dest, dir)
+		if err != nil {
+			return nil, err
+		}
+		relSource := filepath.ToSlash(filepath.Join(relDir, sourceName+tsExt))
+
+		js, err := RunFilter(command, []string{"FUNCTION_SOURCE=" + relSource}, ts)
+		if err != nil {
+			return nil, fmt.Errorf("building %s with %q failed => %s", relSource, command, err)
+		}
+
+		js, sourceMap, err := extractInlineSourceMap(js)
+		if err != nil {
+			return nil, fmt.Errorf("building %s: %s", relSource, err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(dir, sourceName+jsExt), js, 0644); err != nil {
+			return nil, err
+		}
+
+		function := BuiltFunction{Dir: filepath.ToSlash(relDir)}
+		if sourceMap != nil {
+			function.SourceMap = filepath.Join(mapsDir, FunctionSourceMapsDir, relDir, sourceName+jsExt+".map")
+			if err := os.MkdirAll(filepath.Dir(function.SourceMap), 0755); err != nil {
+				return nil, err
+			}
+			if err := ioutil.WriteFile(function.SourceMap, sourceMap, 0644); err != nil {
+				return nil, err
+			}
+		}
+		built = append(built, function)
+	}
+
+	return built, nil
+}
+
+// extractInlineSourceMap splits the inline source map comment off the end of js, returning the source without
+// it and the decoded map, which is nil if there is none
+func extractInlineSourceMap(js []byte) ([]byte, []byte, error) {
+	trimmed := bytes.TrimRight(js, "\r\n")
+	lineStart := bytes.LastIndexByte(trimmed, '\n') + 1
+	if !bytes.HasPrefix(trimmed[lineStart:], inlineSourceMapPrefix) {
+		return js, nil, nil
+	}
+
+	sourceMap, err := base64.StdEncoding.DecodeString(string(trimmed[lineStart+len(inlineSourceMapPrefix):]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid inline source map: %s", err)
+	}
+
+	return trimmed[:lineStart], sourceMap, nil
+}
@@ -0,0 +1,116 @@
+package utils_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBuildFunctionSources(t *testing.T) {
+	writeFile := func(path, data string) {
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		u.So(t, err, gc.ShouldBeNil)
+		return string(data)
+	}
+
+	setup := func() (string, string) {
+		appDir, err := ioutil.TempDir("", "stitch-function-build-app")
+		u.So(t, err, gc.ShouldBeNil)
+		dest, err := ioutil.TempDir("", "stitch-function-build-dest")
+		u.So(t, err, gc.ShouldBeNil)
+
+		writeFile(filepath.Join(appDir, "stitch.json"), `{"name": "my-app"}`)
+		writeFile(filepath.Join(appDir, "functions", "sum", "config.json"), `{"name": "sum"}`)
+		writeFile(filepath.Join(appDir, "functions", "sum", "source.ts"), "exports = function(a: number, b: number) { return a + b; };\n")
+		writeFile(filepath.Join(appDir, "functions", "plain", "config.json"), `{"name": "plain"}`)
+		writeFile(filepath.Join(appDir, "functions", "plain", "source.js"), "exports = function() { return 1; };")
+		writeFile(filepath.Join(appDir, "services", "http1", "config.json"), `{"name": "http1", "type": "http"}`)
+		writeFile(filepath.Join(appDir, "services", "http1", "incoming_webhooks", "hook", "config.json"), `{"name": "hook"}`)
+		writeFile(filepath.Join(appDir, "services", "http1", "incoming_webhooks", "hook", "source.ts"), "exports = function(payload: any) { return payload; };\n")
+		return appDir, dest
+	}
+
+	t.Run("it builds a source.js from each source.ts, leaving the app directory as it is", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		built, err := utils.BuildFunctionSources(appDir, dest, appDir, `sed 's/: [a-z]*//g'`)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, built, gc.ShouldResemble, []utils.BuiltFunction{
+			{Dir: "functions/sum"},
+			{Dir: "services/http1/incoming_webhooks/hook"},
+		})
+
+		u.So(t, readFile(filepath.Join(dest, "functions", "sum", "source.js")), gc.ShouldEqual, "exports = function(a, b) { return a + b; };\n")
+		u.So(t, readFile(filepath.Join(dest, "services", "http1", "incoming_webhooks", "hook", "source.js")), gc.ShouldEqual, "exports = function(payload) { return payload; };\n")
+		u.So(t, readFile(filepath.Join(dest, "functions", "plain", "source.js")), gc.ShouldEqual, "exports = function() { return 1; };")
+
+		_, err = os.Stat(filepath.Join(appDir, "functions", "sum", "source.js"))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		app, err := utils.UnmarshalFromDir(dest)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, app["functions"], gc.ShouldHaveLength, 2)
+	})
+
+	t.Run("it moves an inline source map out of the built source", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		sourceMap := `{"version":3,"sources":["source.ts"],"mappings":"AAAA"}`
+		command := `sed 's/: [a-z]*//g'; echo '//# sourceMappingURL=data:application/json;base64,` + base64.StdEncoding.EncodeToString([]byte(sourceMap)) + `'`
+
+		built, err := utils.BuildFunctionSources(appDir, dest, appDir, command)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, built, gc.ShouldHaveLength, 2)
+
+		mapPath := filepath.Join(appDir, utils.FunctionSourceMapsDir, "functions", "sum", "source.js.map")
+		u.So(t, built[0].SourceMap, gc.ShouldEqual, mapPath)
+		u.So(t, readFile(mapPath), gc.ShouldEqual, sourceMap)
+		u.So(t, readFile(filepath.Join(dest, "functions", "sum", "source.js")), gc.ShouldEqual, "exports = function(a, b) { return a + b; };\n")
+	})
+
+	t.Run("it gives the command the path of the source it builds", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		_, err := utils.BuildFunctionSources(appDir, dest, appDir, `echo "// $FUNCTION_SOURCE"`)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, readFile(filepath.Join(dest, "functions", "sum", "source.js")), gc.ShouldEqual, "// functions/sum/source.ts\n")
+	})
+
+	t.Run("it fails with the output of a failed build", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		_, err := utils.BuildFunctionSources(appDir, dest, appDir, `echo "unexpected token" >&2; exit 1`)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "building functions/")
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "unexpected token")
+	})
+
+	t.Run("loading an app with an unbuilt source.ts suggests building it", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		_, err := utils.UnmarshalFromDir(appDir)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "--build-functions")
+	})
+}
@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FunctionLibDir is the directory under the functions of an app holding the modules shared between them,
+	// which BundleFunctionLibs injects into each function and incoming webhook that requires them
+	FunctionLibDir = "_lib"
+
+	// FunctionLibManifestFileName is the file in the app directory listing the shared modules injected into
+	// each function by the last import
+	FunctionLibManifestFileName = ".stitch-lib-manifest.json"
+
+	// functionLibRequire is what the requires of shared modules are rewritten to call
+	functionLibRequire = "__stitchLib"
+
+	// functionLibHeader begins the source of a function that shared modules were injected into
+	functionLibHeader = "// Shared modules injected by stitch-cli from " + functionsName + "/" + FunctionLibDir + ": "
+)
+
+// requirePattern matches a require of a module by a string literal, capturing the module
+var requirePattern = regexp.MustCompile(`\brequire\(\s*(?:'([^']+)'|"([^"]+)")\s*\)`)
+
+// LibModule is a shared module injected into a function, named by its path under FunctionLibDir without ".js".
+// Its first line is Line of the injected source
+type LibModule struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+	Line int    `json:"line"`
+}
+
+// InjectedLibs lists the shared modules injected into the function or incoming webhook in Dir, relative to
+// the app directory. The first Lines lines of the injected source come before its own
+type InjectedLibs struct {
+	Dir     string      `json:"dir"`
+	Lines   int         `json:"lines"`
+	Modules []LibModule `json:"modules"`
+}
+
+// LibManifest records what BundleFunctionLibs injected into the functions of an app
+type LibManifest struct {
+	Functions []InjectedLibs `json:"functions"`
+}
+
+// HasFunctionLib returns whether the app directory at appPath has shared modules for its functions
+func HasFunctionLib(appPath string) bool {
+	info, err := os.Stat(filepath.Join(appPath, functionsName, FunctionLibDir))
+	return err == nil && info.IsDir()
+}
+
+// BundleFunctionLibs copies the configuration of the app directory at appPath to dest, injecting the shared
+// modules of FunctionLibDir that the source.js of each function and incoming webhook requires, as
+// require("_lib/<name>") or require("../_lib/<name>"), into its source. A shared module may require another by
+// a path relative to it, e.g. require("./dates"). Modules are evaluated once per function call, the first time
+// they are required, and see "module" and "exports" as in Node.js. Functions whose source is embedded in their
+// config are left as they are
+func BundleFunctionLibs(appPath, dest string) (*LibManifest, error) {
+	if err := copyAppConfig(appPath, dest); err != nil {
+		return nil, err
+	}
+
+	libDir := filepath.Join(dest, functionsName, FunctionLibDir)
+	dirs, err := functionDirectories(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &LibManifest{}
+	for _, dir := range dirs {
+		sourcePath := filepath.Join(dir, sourceName+jsExt)
+		source, err := ioutil.ReadFile(sourcePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		relDir, err := filepath.Rel(dest, dir)
+		if err != nil {
+			return nil, err
+		}
+		relDir = filepath.ToSlash(relDir)
+
+		bundle := libBundle{dir: libDir, sources: map[string]string{}}
+		rewritten, err := bundle.rewrite(string(source), func(module string) (string, bool) {
+			for _, prefix := range []string{FunctionLibDir + "/", "../" + FunctionLibDir + "/"} {
+				if strings.HasPrefix(module, prefix) {
+					return path.Clean(strings.TrimPrefix(module, prefix)), true
+				}
+			}
+			return "", false
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %s", relDir, sourceName+jsExt, err)
+		}
+		if len(bundle.order) == 0 {
+			continue
+		}
+
+		prelude, moduleLines := bundle.prelude()
+		if err := ioutil.WriteFile(sourcePath, []byte(prelude+rewritten), 0644); err != nil {
+			return nil, err
+		}
+
+		injected := InjectedLibs{Dir: relDir, Lines: strings.Count(prelude, "\n")}
+		for _, name := range bundle.order {
+			injected.Modules = append(injected.Modules, LibModule{
+				Name: name,
+				Hash: fmt.Sprintf("%x", md5.Sum([]byte(bundle.sources[name]))),
+				Line: moduleLines[name],
+			})
+		}
+		manifest.Functions = append(manifest.Functions, injected)
+	}
+
+	return manifest, nil
+}
+
+// libBundle collects the shared modules required by a function, along with the ones they require in turn
+type libBundle struct {
+	dir     string
+	sources map[string]string
+	order   []string
+}
+
+// rewrite replaces each require in source of a shared module, as resolved by resolve, with a call to
+// functionLibRequire, adding the module to the bundle
+func (b *libBundle) rewrite(source string, resolve func(module string) (string, bool)) (string, error) {
+	var err error
+	rewritten := requirePattern.ReplaceAllStringFunc(source, func(match string) string {
+		groups := requirePattern.FindStringSubmatch(match)
+		module := groups[1] + groups[2]
+
+		name, ok := resolve(module)
+		if !ok || err != nil {
+			return match
+		}
+		name = strings.TrimSuffix(name, jsExt)
+
+		if addErr := b.add(name); addErr != nil {
+			err = addErr
+			return match
+		}
+		return functionLibRequire + "(" + strconv.Quote(name) + ")"
+	})
+
+	return rewritten, err
+}
+
+// add adds the named shared module, and those it requires, to the bundle
+func (b *libBundle) add(name string) error {
+	if _, ok := b.sources[name]; ok {
+		return nil
+	}
+	if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+		return fmt.Errorf("%q is not in %s/%s", name, functionsName, FunctionLibDir)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(b.dir, filepath.FromSlash(name)+jsExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("there is no shared module %s/%s/%s", functionsName, FunctionLibDir, name+jsExt)
+		}
+		return err
+	}
+
+	// the module is registered before its requires are, so that modules requiring each other resolve
+	b.sources[name] = ""
+	b.order = append(b.order, name)
+
+	source, err := b.rewrite(string(data), func(module string) (string, bool) {
+		if strings.HasPrefix(module, "./") || strings.HasPrefix(module, "../") {
+			return path.Join(path.Dir(name), module), true
+		}
+		if strings.HasPrefix(module, FunctionLibDir+"/") {
+			return path.Clean(strings.TrimPrefix(module, FunctionLibDir+"/")), true
+		}
+		return "", false
+	})
+	if err != nil {
+		return fmt.Errorf("%s%s: %s", name, jsExt, err)
+	}
+	b.sources[name] = source
+
+	return nil
+}
+
+// prelude returns the code defining functionLibRequire with the modules of the bundle, which goes before
+// the source of the function, along with the line of it each module starts on
+func (b *libBundle) prelude() (string, map[string]int) {
+	names := append([]string(nil), b.order...)
+	sort.Strings(names)
+
+	var sb bytes.Buffer
+	sb.WriteString(functionLibHeader + strings.Join(names, ", ") + "\n")
+	sb.WriteString("var " + functionLibRequire + " = (function() {\n")
+	sb.WriteString("  var definitions = {\n")
+	moduleLines := map[string]int{}
+	for _, name := range names {
+		sb.WriteString("    " + strconv.Quote(name) + ": function(module, exports) {\n")
+		moduleLines[name] = bytes.Count(sb.Bytes(), []byte("\n")) + 1
+		sb.WriteString(b.sources[name])
+		if !strings.HasSuffix(b.sources[name], "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("    },\n")
+	}
+	sb.WriteString("  };\n")
+	sb.WriteString("  var modules = {};\n")
+	sb.WriteString("  return function(name) {\n")
+	sb.WriteString("    if (!modules[name]) {\n")
+	sb.WriteString("      modules[name] = { exports: {} };\n")
+	sb.WriteString("      definitions[name](modules[name], modules[name].exports);\n")
+	sb.WriteString("    }\n")
+	sb.WriteString("    return modules[name].exports;\n")
+	sb.WriteString("  };\n")
+	sb.WriteString("})();\n")
+
+	return sb.String(), moduleLines
+}
@@ -0,0 +1,127 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBundleFunctionLibs(t *testing.T) {
+	writeFile := func(path, data string) {
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		u.So(t, err, gc.ShouldBeNil)
+		return string(data)
+	}
+
+	setup := func() (string, string) {
+		appDir, err := ioutil.TempDir("", "stitch-function-lib-app")
+		u.So(t, err, gc.ShouldBeNil)
+		dest, err := ioutil.TempDir("", "stitch-function-lib-dest")
+		u.So(t, err, gc.ShouldBeNil)
+
+		writeFile(filepath.Join(appDir, "stitch.json"), `{"name": "my-app"}`)
+		writeFile(filepath.Join(appDir, "functions", "_lib", "math.js"), "const round = require('./round');\nmodule.exports.sum = (a, b) => round(a + b);\n")
+		writeFile(filepath.Join(appDir, "functions", "_lib", "round.js"), "module.exports = (n) => Math.round(n);\n")
+		writeFile(filepath.Join(appDir, "functions", "_lib", "unused.js"), "module.exports = 1;\n")
+		writeFile(filepath.Join(appDir, "functions", "sum", "config.json"), `{"name": "sum"}`)
+		writeFile(filepath.Join(appDir, "functions", "sum", "source.js"), "const math = require(\"../_lib/math\");\nexports = (a, b) => math.sum(a, b);\n")
+		writeFile(filepath.Join(appDir, "functions", "plain", "config.json"), `{"name": "plain"}`)
+		writeFile(filepath.Join(appDir, "functions", "plain", "source.js"), "const moment = require('moment');\nexports = () => moment().year();\n")
+		return appDir, dest
+	}
+
+	t.Run("it injects the shared modules a function requires into its source", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		manifest, err := utils.BundleFunctionLibs(appDir, dest)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, manifest.Functions, gc.ShouldHaveLength, 1)
+		u.So(t, manifest.Functions[0].Dir, gc.ShouldEqual, "functions/sum")
+		u.So(t, manifest.Functions[0].Modules, gc.ShouldHaveLength, 2)
+		u.So(t, manifest.Functions[0].Modules[0].Name, gc.ShouldEqual, "math")
+		u.So(t, manifest.Functions[0].Modules[1].Name, gc.ShouldEqual, "round")
+		u.So(t, manifest.Functions[0].Modules[0].Hash, gc.ShouldHaveLength, 32)
+
+		source := readFile(filepath.Join(dest, "functions", "sum", "source.js"))
+		u.So(t, source, gc.ShouldStartWith, "// Shared modules injected by stitch-cli from functions/_lib: math, round\n")
+		u.So(t, source, gc.ShouldContainSubstring, `const math = __stitchLib("math");`)
+		u.So(t, source, gc.ShouldContainSubstring, `const round = __stitchLib("round");`)
+		u.So(t, source, gc.ShouldNotContainSubstring, "unused")
+
+		u.So(t, readFile(filepath.Join(dest, "functions", "plain", "source.js")), gc.ShouldEqual, "const moment = require('moment');\nexports = () => moment().year();\n")
+		u.So(t, readFile(filepath.Join(appDir, "functions", "sum", "source.js")), gc.ShouldStartWith, "const math = require(\"../_lib/math\");")
+
+		app, err := utils.UnmarshalFromDir(dest)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, app["functions"], gc.ShouldHaveLength, 2)
+	})
+
+	t.Run("the injected modules run as the function requires them", func(t *testing.T) {
+		if _, err := exec.LookPath("node"); err != nil {
+			u.MustSkipf(t, "node is not installed")
+		}
+
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		_, err := utils.BundleFunctionLibs(appDir, dest)
+		u.So(t, err, gc.ShouldBeNil)
+
+		source := readFile(filepath.Join(dest, "functions", "sum", "source.js"))
+		script := "var exports;\n" + source + "\nconsole.log(exports(1.2, 2.5));\n"
+		out, err := exec.Command("node", "-e", script).CombinedOutput()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, strings.TrimSpace(string(out)), gc.ShouldEqual, "4")
+	})
+
+	t.Run("it fails on a require of a missing shared module", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		writeFile(filepath.Join(appDir, "functions", "plain", "source.js"), "const dates = require('_lib/dates');\nexports = () => dates.now();\n")
+
+		_, err := utils.BundleFunctionLibs(appDir, dest)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "functions/plain/source.js: there is no shared module functions/_lib/dates.js")
+	})
+
+	t.Run("it fails on a require outside of the shared modules", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		writeFile(filepath.Join(appDir, "functions", "_lib", "round.js"), "module.exports = require('../sum/source');\n")
+
+		_, err := utils.BundleFunctionLibs(appDir, dest)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `"../sum/source" is not in functions/_lib`)
+	})
+
+	t.Run("the shared modules are not loaded as a function", func(t *testing.T) {
+		appDir, dest := setup()
+		defer os.RemoveAll(appDir)
+		defer os.RemoveAll(dest)
+
+		app, err := utils.UnmarshalFromDir(appDir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, app["functions"], gc.ShouldHaveLength, 2)
+		u.So(t, utils.HasFunctionLib(appDir), gc.ShouldBeTrue)
+		u.So(t, utils.HasFunctionLib(dest), gc.ShouldBeFalse)
+	})
+}
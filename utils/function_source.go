@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteAppToDir unpacks an exported app into dest like WriteZipToDir, then moves any function or incoming
+// webhook source embedded in a config.json out into the source.js beside it and normalizes the config files
+// with NormalizeConfigFiles
+func WriteAppToDir(dest string, zipData io.Reader, overwrite bool) error {
+	if err := WriteZipToDir(dest, zipData, overwrite); err != nil {
+		return err
+	}
+
+	if err := SplitFunctionSources(dest); err != nil {
+		return err
+	}
+
+	if err := SplitServiceRules(dest); err != nil {
+		return err
+	}
+
+	if err := RemoveDeployLock(dest); err != nil {
+		return err
+	}
+
+	return NormalizeConfigFiles(dest)
+}
+
+// SplitFunctionSources moves the source embedded as a "source" string in the config.json of each function
+// and incoming webhook in the app directory at appPath into a source.js beside it, so that the source can
+// be read and reviewed as code. An existing source.js is replaced, unless the embedded source was built from
+// local sources, in which case those are kept as they are (see LocalFunctionSource)
+func SplitFunctionSources(appPath string) error {
+	dirs, err := functionDirectories(appPath)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if err := splitFunctionSource(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// functionDirectories returns the directories of the functions and incoming webhooks of the app directory at appPath
+func functionDirectories(appPath string) ([]string, error) {
+	var dirs []string
+
+	functionInfos, _ := ioutil.ReadDir(filepath.Join(appPath, functionsName))
+	if err := iterDirectories(func(info os.FileInfo, path string) error {
+		if info.Name() != FunctionLibDir {
+			dirs = append(dirs, path)
+		}
+		return nil
+	}, filepath.Join(appPath, functionsName), functionInfos); err != nil {
+		return nil, err
+	}
+
+	serviceInfos, _ := ioutil.ReadDir(filepath.Join(appPath, servicesName))
+	if err := iterDirectories(func(info os.FileInfo, path string) error {
+		webhooksPath := filepath.Join(path, incomingWebhooksName)
+		webhookInfos, _ := ioutil.ReadDir(webhooksPath)
+		return iterDirectories(func(info os.FileInfo, path string) error {
+			dirs = append(dirs, path)
+			return nil
+		}, webhooksPath, webhookInfos)
+	}, filepath.Join(appPath, servicesName), serviceInfos); err != nil {
+		return nil, err
+	}
+
+	return dirs, nil
+}
+
+func splitFunctionSource(dir string) error {
+	configPath := filepath.Join(dir, configName+jsonExt)
+
+	var config map[string]json.RawMessage
+	if err := readAndUnmarshalJSONInto(configPath, &config); err != nil {
+		return err
+	}
+
+	source, ok, err := embeddedSource(config)
+	if err != nil || !ok {
+		return err
+	}
+
+	if _, ok := localFunctionSource(dir, source); !ok {
+		if err := ioutil.WriteFile(filepath.Join(dir, sourceName+jsExt), []byte(source), 0644); err != nil {
+			return err
+		}
+	}
+
+	delete(config, sourceName)
+
+	data, err := marshalIndentNoEscape(config)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(configPath, data, 0644)
+}
+
+// LocalFunctionSource returns the local source that source, exported to path relative to the app directory at
+// appPath, was built from on import, if path is the source.js of a function or incoming webhook and there is
+// one. Writing the exported source would replace that local source with its build output
+func LocalFunctionSource(appPath, path string, source []byte) (string, bool) {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	if parts[len(parts)-1] != sourceName+jsExt {
+		return "", false
+	}
+
+	isFunction := len(parts) == 3 && parts[0] == functionsName
+	isWebhook := len(parts) == 5 && parts[0] == servicesName && parts[2] == incomingWebhooksName
+	if !isFunction && !isWebhook {
+		return "", false
+	}
+
+	return localFunctionSource(filepath.Join(appPath, filepath.Dir(path)), string(source))
+}
+
+// localFunctionSource returns the local source in dir that source, the deployed source of the function or
+// incoming webhook in dir, was built from on import, if there is one: a source.ts beside it, or a source.js
+// that shared modules of FunctionLibDir were injected into
+func localFunctionSource(dir, source string) (string, bool) {
+	if _, err := os.Stat(filepath.Join(dir, sourceName+tsExt)); err == nil {
+		return sourceName + tsExt, true
+	}
+
+	if strings.HasPrefix(source, functionLibHeader) {
+		if _, err := os.Stat(filepath.Join(dir, sourceName+jsExt)); err == nil {
+			return sourceName + jsExt, true
+		}
+	}
+
+	return "", false
+}
+
+// embeddedSource returns the "source" string of a function config, if it has one
+func embeddedSource(config map[string]json.RawMessage) (string, bool, error) {
+	raw, ok := config[sourceName]
+	if !ok {
+		return "", false, nil
+	}
+
+	var source string
+	if err := json.Unmarshal(raw, &source); err != nil {
+		return "", false, err
+	}
+
+	return source, true, nil
+}
+
+// marshalIndentNoEscape marshals v as indented JSON without escaping characters such as '<' and '&',
+// which are common in function configs and would make them harder to read
+func marshalIndentNoEscape(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,219 @@
+package utils_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSplitFunctionSources(t *testing.T) {
+	writeFile := func(path, data string) {
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	readConfig := func(path string) map[string]interface{} {
+		data, err := ioutil.ReadFile(path)
+		u.So(t, err, gc.ShouldBeNil)
+
+		var config map[string]interface{}
+		u.So(t, json.Unmarshal(data, &config), gc.ShouldBeNil)
+		return config
+	}
+
+	setup := func() string {
+		dir, err := ioutil.TempDir("", "stitch-function-sources")
+		u.So(t, err, gc.ShouldBeNil)
+
+		writeFile(
+			filepath.Join(dir, "functions", "embedded", "config.json"),
+			`{"name": "embedded", "private": false, "source": "exports = function() { return a < b && c; };"}`,
+		)
+		writeFile(
+			filepath.Join(dir, "functions", "split", "config.json"),
+			`{"name": "split", "private": true}`,
+		)
+		writeFile(filepath.Join(dir, "functions", "split", "source.js"), "exports = function() { return 1; };")
+		writeFile(
+			filepath.Join(dir, "services", "http1", "incoming_webhooks", "hook", "config.json"),
+			`{"name": "hook", "source": "exports = function(payload) { return payload; };"}`,
+		)
+		return dir
+	}
+
+	t.Run("it moves embedded function sources into source.js", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		u.So(t, utils.SplitFunctionSources(dir), gc.ShouldBeNil)
+
+		source, err := ioutil.ReadFile(filepath.Join(dir, "functions", "embedded", "source.js"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(source), gc.ShouldEqual, "exports = function() { return a < b && c; };")
+		u.So(t, readConfig(filepath.Join(dir, "functions", "embedded", "config.json")), gc.ShouldResemble, map[string]interface{}{
+			"name":    "embedded",
+			"private": false,
+		})
+
+		source, err = ioutil.ReadFile(filepath.Join(dir, "functions", "split", "source.js"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(source), gc.ShouldEqual, "exports = function() { return 1; };")
+	})
+
+	t.Run("it moves embedded incoming webhook sources into source.js", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		u.So(t, utils.SplitFunctionSources(dir), gc.ShouldBeNil)
+
+		hookDir := filepath.Join(dir, "services", "http1", "incoming_webhooks", "hook")
+		source, err := ioutil.ReadFile(filepath.Join(hookDir, "source.js"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(source), gc.ShouldEqual, "exports = function(payload) { return payload; };")
+		u.So(t, readConfig(filepath.Join(hookDir, "config.json")), gc.ShouldResemble, map[string]interface{}{"name": "hook"})
+	})
+
+	t.Run("it writes an embedded source over an existing source.js", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		fnDir := filepath.Join(dir, "functions", "embedded")
+		writeFile(filepath.Join(fnDir, "source.js"), "exports = function() { return 2; };")
+
+		u.So(t, utils.SplitFunctionSources(dir), gc.ShouldBeNil)
+
+		source, err := ioutil.ReadFile(filepath.Join(fnDir, "source.js"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(source), gc.ShouldEqual, "exports = function() { return a < b && c; };")
+		u.So(t, readConfig(filepath.Join(fnDir, "config.json")), gc.ShouldNotContainKey, "source")
+	})
+
+	t.Run("it keeps a source.js that shared modules were injected into", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		fnDir := filepath.Join(dir, "functions", "shared")
+		writeFile(
+			filepath.Join(fnDir, "config.json"),
+			`{"name": "shared", "source": "// Shared modules injected by stitch-cli from functions/_lib: dates\nexports = function() {};"}`,
+		)
+		writeFile(filepath.Join(fnDir, "source.js"), `const dates = require("../_lib/dates"); exports = function() {};`)
+
+		u.So(t, utils.SplitFunctionSources(dir), gc.ShouldBeNil)
+
+		source, err := ioutil.ReadFile(filepath.Join(fnDir, "source.js"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(source), gc.ShouldEqual, `const dates = require("../_lib/dates"); exports = function() {};`)
+		u.So(t, readConfig(filepath.Join(fnDir, "config.json")), gc.ShouldNotContainKey, "source")
+	})
+
+	t.Run("it writes no source.js beside a source.ts", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		fnDir := filepath.Join(dir, "functions", "embedded")
+		writeFile(filepath.Join(fnDir, "source.ts"), "exports = function(): boolean { return a < b && c; };")
+
+		u.So(t, utils.SplitFunctionSources(dir), gc.ShouldBeNil)
+
+		_, err := os.Stat(filepath.Join(fnDir, "source.js"))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+		u.So(t, readConfig(filepath.Join(fnDir, "config.json")), gc.ShouldNotContainKey, "source")
+	})
+}
+
+func TestLocalFunctionSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-local-function-source")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	for _, path := range []string{
+		filepath.Join("functions", "typed", "source.ts"),
+		filepath.Join("functions", "shared", "source.js"),
+		filepath.Join("services", "http1", "incoming_webhooks", "hook", "source.js"),
+		filepath.Join("hosting", "files", "source.ts"),
+	} {
+		u.So(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, path), []byte("exports = function() {};"), 0644), gc.ShouldBeNil)
+	}
+
+	injected := []byte("// Shared modules injected by stitch-cli from functions/_lib: dates\nvar __stitchLib = {};")
+	for _, tc := range []struct {
+		path           string
+		source         []byte
+		expectedSource string
+	}{
+		{filepath.Join("functions", "typed", "source.js"), []byte("exports = function() {};"), "source.ts"},
+		{filepath.Join("functions", "shared", "source.js"), injected, "source.js"},
+		{filepath.Join("services", "http1", "incoming_webhooks", "hook", "source.js"), injected, "source.js"},
+		{filepath.Join("functions", "shared", "source.js"), []byte("exports = function() {};"), ""},
+		{filepath.Join("functions", "new", "source.js"), injected, ""},
+		{filepath.Join("functions", "typed", "config.json"), []byte("{}"), ""},
+		{filepath.Join("hosting", "files", "source.js"), []byte("exports = function() {};"), ""},
+	} {
+		source, ok := utils.LocalFunctionSource(dir, tc.path, tc.source)
+		u.So(t, source, gc.ShouldEqual, tc.expectedSource)
+		u.So(t, ok, gc.ShouldEqual, tc.expectedSource != "")
+	}
+}
+
+func TestWebhookSourceRoundTrip(t *testing.T) {
+	webhookSource := "exports = function(payload, response) {\n  response.setBody(\"<ok>\");\n};\n"
+
+	var zipData bytes.Buffer
+	zw := zip.NewWriter(&zipData)
+	for _, entry := range []struct{ name, data string }{
+		{"stitch.json", `{"name": "my-app"}`},
+		{"services/", ""},
+		{"services/http1/", ""},
+		{"services/http1/config.json", `{"name": "http1", "type": "http"}`},
+		{"services/http1/incoming_webhooks/", ""},
+		{"services/http1/incoming_webhooks/hook/", ""},
+		{"services/http1/incoming_webhooks/hook/config.json", `{"name": "hook", "source": ` + mustMarshal(t, webhookSource) + `}`},
+	} {
+		w, err := zw.Create(entry.name)
+		u.So(t, err, gc.ShouldBeNil)
+		_, err = w.Write([]byte(entry.data))
+		u.So(t, err, gc.ShouldBeNil)
+	}
+	u.So(t, zw.Close(), gc.ShouldBeNil)
+
+	dir, err := ioutil.TempDir("", "stitch-webhook-source")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	u.So(t, utils.WriteAppToDir(dir, &zipData, true), gc.ShouldBeNil)
+
+	hookDir := filepath.Join(dir, "services", "http1", "incoming_webhooks", "hook")
+	source, err := ioutil.ReadFile(filepath.Join(hookDir, "source.js"))
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, string(source), gc.ShouldEqual, webhookSource)
+
+	app, err := utils.UnmarshalFromDir(dir)
+	u.So(t, err, gc.ShouldBeNil)
+
+	services := app["services"].([]interface{})
+	u.So(t, services, gc.ShouldHaveLength, 1)
+
+	webhooks := services[0].(map[string]interface{})["incoming_webhooks"].([]interface{})
+	u.So(t, webhooks, gc.ShouldResemble, []interface{}{
+		map[string]interface{}{
+			"config": map[string]interface{}{"name": "hook"},
+			"source": webhookSource,
+		},
+	})
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	u.So(t, err, gc.ShouldBeNil)
+	return string(data)
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/10gen/stitch-cli/sourcemap"
+)
+
+// framePattern matches the "<file>:<line>:<column>" position of a stack frame
+var framePattern = regexp.MustCompile(`([^\s()]+):(\d+):(\d+)`)
+
+// TraceResolver maps the positions in the stack traces of an app's deployed functions back to its local
+// sources, undoing the shared modules injected on import and, with the source maps kept in FunctionSourceMapsDir,
+// the build of its TypeScript functions
+type TraceResolver struct {
+	appPath  string
+	injected map[string]InjectedLibs
+	maps     map[string]*sourcemap.Map
+}
+
+// NewTraceResolver returns a TraceResolver for the app directory at appPath
+func NewTraceResolver(appPath string) (*TraceResolver, error) {
+	r := &TraceResolver{
+		appPath:  appPath,
+		injected: map[string]InjectedLibs{},
+		maps:     map[string]*sourcemap.Map{},
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(appPath, FunctionLibManifestFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, err
+	}
+
+	var manifest LibManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", FunctionLibManifestFileName, err)
+	}
+	for _, function := range manifest.Functions {
+		r.injected[function.Dir] = function
+	}
+
+	return r, nil
+}
+
+// Resolve rewrites the positions in trace that it can map back to a local source. A position names the function
+// it is in by the path of its source.js, e.g. "functions/sum/source.js:3:12", or is taken to be in the named
+// function if it names none
+func (r *TraceResolver) Resolve(trace, function string) (string, error) {
+	var resolveErr error
+	resolved := framePattern.ReplaceAllStringFunc(trace, func(match string) string {
+		groups := framePattern.FindStringSubmatch(match)
+		line, _ := strconv.Atoi(groups[2])
+		column, _ := strconv.Atoi(groups[3])
+
+		dir := functionSourceDir(groups[1])
+		if dir == "" {
+			if function == "" {
+				return match
+			}
+			dir = path.Join(functionsName, function)
+		}
+
+		file, line, column, ok, err := r.resolvePosition(dir, line, column)
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = err
+			}
+			return match
+		}
+		if !ok {
+			return match
+		}
+		return fmt.Sprintf("%s:%d:%d", file, line, column)
+	})
+
+	return resolved, resolveErr
+}
+
+// resolvePosition maps a position in the deployed source of the function in dir to its local source
+func (r *TraceResolver) resolvePosition(dir string, line, column int) (string, int, int, bool, error) {
+	mapped := false
+	if injected, ok := r.injected[dir]; ok {
+		if line <= injected.Lines {
+			var module *LibModule
+			for i := range injected.Modules {
+				if injected.Modules[i].Line <= line && (module == nil || injected.Modules[i].Line > module.Line) {
+					module = &injected.Modules[i]
+				}
+			}
+			if module == nil {
+				return "", 0, 0, false, nil
+			}
+			return path.Join(functionsName, FunctionLibDir, module.Name+jsExt), line - module.Line + 1, column, true, nil
+		}
+		line -= injected.Lines
+		mapped = true
+	}
+
+	sourceMap, err := r.sourceMap(dir)
+	if err != nil {
+		return "", 0, 0, false, err
+	}
+	if sourceMap != nil {
+		if position, ok := sourceMap.Resolve(line, column); ok {
+			source := position.Source
+			if !strings.Contains(source, "/") {
+				source = path.Join(dir, source)
+			}
+			return source, position.Line, position.Column, true, nil
+		}
+	}
+
+	return path.Join(dir, sourceName+jsExt), line, column, mapped, nil
+}
+
+// sourceMap returns the source map kept for the built source of the function in dir, or nil if there is none
+func (r *TraceResolver) sourceMap(dir string) (*sourcemap.Map, error) {
+	if m, ok := r.maps[dir]; ok {
+		return m, nil
+	}
+
+	mapPath := filepath.Join(r.appPath, FunctionSourceMapsDir, filepath.FromSlash(dir), sourceName+jsExt+".map")
+	data, err := ioutil.ReadFile(mapPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.maps[dir] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	m, err := sourcemap.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", mapPath, err)
+	}
+	r.maps[dir] = m
+	return m, nil
+}
+
+// functionSourceDir returns the directory, relative to the app directory, of the function or incoming webhook
+// whose source.js is at file, which may have any prefix, or "" if file is not the source of one
+func functionSourceDir(file string) string {
+	file = filepath.ToSlash(file)
+	if path.Base(file) != sourceName+jsExt {
+		return ""
+	}
+
+	dir := path.Dir(file)
+	for _, marker := range []string{servicesName + "/", functionsName + "/"} {
+		if i := strings.LastIndex("/"+dir, "/"+marker); i >= 0 {
+			return dir[i:]
+		}
+	}
+	return ""
+}
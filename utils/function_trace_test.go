@@ -0,0 +1,73 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTraceResolver(t *testing.T) {
+	writeFile := func(path, data string) {
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	appDir, err := ioutil.TempDir("", "stitch-trace-resolver")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(appDir)
+
+	// "sum" has math injected on lines 4-5 of a 10 line prelude, and was built from TypeScript whose line 2
+	// became line 1 of the built source
+	manifest, err := json.Marshal(utils.LibManifest{Functions: []utils.InjectedLibs{
+		{Dir: "functions/sum", Lines: 10, Modules: []utils.LibModule{{Name: "math", Line: 4}, {Name: "round", Line: 6}}},
+	}})
+	u.So(t, err, gc.ShouldBeNil)
+	writeFile(filepath.Join(appDir, utils.FunctionLibManifestFileName), string(manifest))
+	writeFile(
+		filepath.Join(appDir, utils.FunctionSourceMapsDir, "functions", "sum", "source.js.map"),
+		`{"version": 3, "sources": ["functions/sum/source.ts"], "mappings": "AACA"}`,
+	)
+
+	resolver, err := utils.NewTraceResolver(appDir)
+	u.So(t, err, gc.ShouldBeNil)
+
+	t.Run("it maps positions in injected modules and built sources back to them", func(t *testing.T) {
+		resolved, err := resolver.Resolve("TypeError: x is undefined\n"+
+			"    at round (functions/sum/source.js:7:3)\n"+
+			"    at exports (functions/sum/source.js:11:9)\n"+
+			"    at other (functions/other/source.js:4:2)", "")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "TypeError: x is undefined\n"+
+			"    at round (functions/_lib/round.js:2:3)\n"+
+			"    at exports (functions/sum/source.ts:2:1)\n"+
+			"    at other (functions/other/source.js:4:2)")
+	})
+
+	t.Run("it takes positions without a source to be in the given function", func(t *testing.T) {
+		resolved, err := resolver.Resolve("at <anonymous>:5:1", "sum")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "at functions/_lib/math.js:2:1")
+
+		resolved, err = resolver.Resolve("at <anonymous>:5:1", "")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "at <anonymous>:5:1")
+	})
+
+	t.Run("it maps positions in a function with only injected modules to its source.js", func(t *testing.T) {
+		writeFile(filepath.Join(appDir, utils.FunctionLibManifestFileName), `{"functions": [{"dir": "services/http1/incoming_webhooks/hook", "lines": 3, "modules": [{"name": "math", "line": 2}]}]}`)
+		defer os.Remove(filepath.Join(appDir, utils.FunctionLibManifestFileName))
+
+		resolver, err := utils.NewTraceResolver(appDir)
+		u.So(t, err, gc.ShouldBeNil)
+
+		resolved, err := resolver.Resolve("at /var/app/services/http1/incoming_webhooks/hook/source.js:8:4", "")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, resolved, gc.ShouldEqual, "at services/http1/incoming_webhooks/hook/source.js:5:4")
+	})
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"sort"
+)
+
+// ImportStages returns the entities of app that are not in deployed, keyed as by AppEntities, grouped into the
+// stages they must be imported in so that each is created after the entities its config refers to, e.g. a service
+// before its incoming webhooks and rules, or a function before the triggers calling it. A nil deployed app has no
+// entities. Only the stages ahead of the last are returned, as the last is imported along with the rest of app,
+// so an app whose new entities can all be created at once has none
+func ImportStages(app, deployed map[string]interface{}) [][]string {
+	entities := AppEntities(app)
+	deployedEntities := AppEntities(deployed)
+	dependencies := CreationDependencies(app)
+
+	isNew := func(name string) bool {
+		_, deployed := deployedEntities[name]
+		return !deployed && name != appSettingsEntity && name != secretsName
+	}
+
+	// the level of an entity is the length of the longest chain of new entities it depends on. A cycle of
+	// dependencies is broken where it is entered, as such entities can only be created together
+	levels := map[string]int{}
+	visiting := map[string]bool{}
+	var level func(name string) int
+	level = func(name string) int {
+		if l, ok := levels[name]; ok {
+			return l
+		}
+		visiting[name] = true
+		l := 0
+		for _, dependency := range dependencies[name] {
+			if !isNew(dependency) || visiting[dependency] {
+				continue
+			}
+			if dependencyLevel := level(dependency) + 1; dependencyLevel > l {
+				l = dependencyLevel
+			}
+		}
+		visiting[name] = false
+		levels[name] = l
+		return l
+	}
+
+	var stages [][]string
+	for name := range entities {
+		if !isNew(name) {
+			continue
+		}
+		l := level(name)
+		for len(stages) <= l {
+			stages = append(stages, nil)
+		}
+		stages[l] = append(stages[l], name)
+	}
+
+	if len(stages) < 2 {
+		return nil
+	}
+	for _, stage := range stages {
+		sort.Strings(stage)
+	}
+	return stages[:len(stages)-1]
+}
@@ -0,0 +1,54 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestImportStages(t *testing.T) {
+	app, err := utils.UnmarshalFromDir(tagsAppPath)
+	u.So(t, err, gc.ShouldBeNil)
+
+	t.Run("it imports the entities referred to by others first into a new app", func(t *testing.T) {
+		u.So(t, utils.ImportStages(app, nil), gc.ShouldResemble, [][]string{{
+			"functions/authenticate",
+			"functions/chargeCard",
+			"functions/formatAmount",
+			"functions/greet",
+			"functions/invoice",
+			"services/mongodb",
+			"services/stripe",
+			"values/currency",
+			"values/siteName",
+		}})
+	})
+
+	t.Run("it only stages the entities that are not deployed", func(t *testing.T) {
+		deployed, err := utils.UnmarshalFromDir(tagsAppPath)
+		u.So(t, err, gc.ShouldBeNil)
+		deployed["triggers"] = []interface{}{}
+		services := deployed["services"].([]interface{})
+		for _, s := range services {
+			s.(map[string]interface{})["incoming_webhooks"] = []interface{}{}
+		}
+
+		// the new trigger and incoming webhook refer to entities that are already deployed
+		u.So(t, utils.ImportStages(app, deployed), gc.ShouldBeEmpty)
+
+		var remaining []interface{}
+		for _, s := range services {
+			if s.(map[string]interface{})["config"].(map[string]interface{})["name"] != "stripe" {
+				remaining = append(remaining, s)
+			}
+		}
+		deployed["services"] = remaining
+		u.So(t, utils.ImportStages(app, deployed), gc.ShouldResemble, [][]string{{"services/stripe", "triggers/onPayment"}})
+	})
+
+	t.Run("it imports an app whose new entities refer to none of each other at once", func(t *testing.T) {
+		u.So(t, utils.ImportStages(app, app), gc.ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// EntitySize is the size of the configuration of an entity of an app, such as a function or a service
+type EntitySize struct {
+	// Path is the slash-separated path of the entity in the app directory, e.g. "functions/sum"
+	Path string
+	Size int64
+}
+
+// EntityKindSummary sums up the entities of a kind in an app
+type EntityKindSummary struct {
+	Kind  string
+	Count int
+	Size  int64
+}
+
+// AppComposition describes the size of an app's configuration and what makes it up
+type AppComposition struct {
+	// Kinds sums up the entities of each kind in the app, in the order of entityKinds
+	Kinds []EntityKindSummary
+
+	// Entities are the sizes of every entity in the app, largest first
+	Entities []EntitySize
+
+	// ConfigSize is the size of the app config file
+	ConfigSize int64
+}
+
+// TotalSize returns the size of the app config file and of every entity
+func (ac *AppComposition) TotalSize() int64 {
+	total := ac.ConfigSize
+	for _, kind := range ac.Kinds {
+		if kind.Kind != rulesName && kind.Kind != incomingWebhooksName {
+			total += kind.Size
+		}
+	}
+	return total
+}
+
+// entityKinds are the directories holding the entities of an app, each entry of which is an entity. Rules
+// and incoming webhooks are held in the directory of their service, and counted towards its size too
+var entityKinds = []string{
+	authProvidersName,
+	functionsName,
+	servicesName,
+	rulesName,
+	incomingWebhooksName,
+	triggersName,
+	valuesName,
+}
+
+// InspectApp sums up the configuration of the app directory at appPath. Hosting assets are not included
+func InspectApp(appPath string) (*AppComposition, error) {
+	composition := &AppComposition{}
+
+	configInfo, err := os.Stat(filepath.Join(appPath, appConfigName+jsonExt))
+	if err != nil {
+		return nil, err
+	}
+	composition.ConfigSize = configInfo.Size()
+
+	entityDirs := map[string][]string{}
+	for _, kind := range entityKinds {
+		if kind != rulesName && kind != incomingWebhooksName {
+			entityDirs[kind] = []string{kind}
+		}
+	}
+
+	serviceInfos, _ := ioutil.ReadDir(filepath.Join(appPath, servicesName))
+	for _, serviceInfo := range serviceInfos {
+		if serviceInfo.IsDir() {
+			for _, kind := range []string{rulesName, incomingWebhooksName} {
+				entityDirs[kind] = append(entityDirs[kind], filepath.Join(servicesName, serviceInfo.Name(), kind))
+			}
+		}
+	}
+
+	for _, kind := range entityKinds {
+		summary := EntityKindSummary{Kind: kind}
+
+		for _, dir := range entityDirs[kind] {
+			infos, err := ioutil.ReadDir(filepath.Join(appPath, dir))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+
+			for _, info := range infos {
+				size, err := sizeOfPath(filepath.Join(appPath, dir, info.Name()))
+				if err != nil {
+					return nil, err
+				}
+
+				summary.Count++
+				summary.Size += size
+				composition.Entities = append(composition.Entities, EntitySize{
+					Path: filepath.ToSlash(filepath.Join(dir, info.Name())),
+					Size: size,
+				})
+			}
+		}
+
+		composition.Kinds = append(composition.Kinds, summary)
+	}
+
+	sort.SliceStable(composition.Entities, func(i, j int) bool {
+		return composition.Entities[i].Size > composition.Entities[j].Size
+	})
+
+	return composition, nil
+}
+
+// sizeOfPath returns the size of the file at path, or the total size of the files under it if it is a directory
+func sizeOfPath(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// FormatSize formats a number of bytes in the largest binary unit it holds at least one of, e.g. "1.5 KiB"
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGT"[exp])
+}
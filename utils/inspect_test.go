@@ -0,0 +1,55 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInspectApp(t *testing.T) {
+	composition, err := utils.InspectApp("../testdata/full_app")
+	u.So(t, err, gc.ShouldBeNil)
+
+	counts := map[string]int{}
+	for _, kind := range composition.Kinds {
+		counts[kind.Kind] = kind.Count
+	}
+	u.So(t, counts, gc.ShouldResemble, map[string]int{
+		"auth_providers":    2,
+		"functions":         2,
+		"services":          3,
+		"rules":             3,
+		"incoming_webhooks": 3,
+		"triggers":          2,
+		"values":            2,
+	})
+
+	u.So(t, composition.Entities, gc.ShouldHaveLength, 17)
+	for i := 1; i < len(composition.Entities); i++ {
+		u.So(t, composition.Entities[i-1].Size, gc.ShouldBeGreaterThanOrEqualTo, composition.Entities[i].Size)
+	}
+	u.So(t, composition.Entities[0].Path, gc.ShouldEqual, "services/service_b")
+	u.So(t, composition.ConfigSize, gc.ShouldBeGreaterThan, 0)
+
+	t.Run("it fails for a directory without an app", func(t *testing.T) {
+		_, err := utils.InspectApp("../testdata")
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	for _, tc := range []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	} {
+		u.So(t, utils.FormatSize(tc.bytes), gc.ShouldEqual, tc.expected)
+	}
+}
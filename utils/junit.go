@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+)
+
+// JUnitTestSuites is the root of a JUnit XML report, the format CI systems read test results from
+type JUnitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite is a named group of test cases in a JUnit XML report
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      float64         `xml:"time,attr"`
+	Cases     []JUnitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+// JUnitTestCase is a single test in a JUnit XML report. File, when set, lets CI systems annotate the file
+// the test case is about
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure describes why a JUnit test case failed
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Details string `xml:",chardata"`
+}
+
+// AddCase adds the test case to the suite, counting it
+func (s *JUnitTestSuite) AddCase(testCase JUnitTestCase) {
+	s.Cases = append(s.Cases, testCase)
+	s.Tests++
+	s.Time += testCase.Time
+	if testCase.Failure != nil {
+		s.Failures++
+	}
+}
+
+// MarshalJUnit renders the suites as a JUnit XML report
+func MarshalJUnit(suites ...JUnitTestSuite) ([]byte, error) {
+	data, err := xml.MarshalIndent(JUnitTestSuites{Suites: suites}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// WriteJUnitFile writes the suites as a JUnit XML report to the file at path
+func WriteJUnitFile(path string, suites ...JUnitTestSuite) error {
+	data, err := MarshalJUnit(suites...)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OwnerField annotates the config of an entity with the team that owns it, e.g. "x-owner": "payments". The
+// incoming webhooks and rules of a service belong to the owner of the service unless they are annotated themselves
+const OwnerField = "x-owner"
+
+// EntityOwners returns the owner of each entity of an app, as loaded by UnmarshalFromDir, keyed as by AppEntities.
+// Entities without an owner are left out
+func EntityOwners(app map[string]interface{}) map[string]string {
+	entities := AppEntities(app)
+
+	owners := map[string]string{}
+	for name, entity := range entities {
+		if owner := annotatedOwner(entity); owner != "" {
+			owners[name] = owner
+		}
+	}
+
+	for name := range entities {
+		if _, ok := owners[name]; ok {
+			continue
+		}
+		if service := serviceOfEntity(name); service != "" {
+			if owner, ok := owners[service]; ok {
+				owners[name] = owner
+			}
+		}
+	}
+
+	return owners
+}
+
+// ComposeOwnedApp returns the app that importing only the entities of owner in local would deploy: the deployed
+// app with the entities of owner replaced by those of local. Entities are owned as the app they are in annotates
+// them, so an entity of owner that is deployed but no longer in local is removed
+func ComposeOwnedApp(deployed, local map[string]interface{}, owner string) (map[string]interface{}, error) {
+	composed := AppEntities(deployed)
+	for name, entityOwner := range EntityOwners(deployed) {
+		if entityOwner == owner {
+			delete(composed, name)
+		}
+	}
+
+	localEntities := AppEntities(local)
+	for name, entityOwner := range EntityOwners(local) {
+		if entityOwner == owner {
+			composed[name] = localEntities[name]
+		}
+	}
+
+	for name := range composed {
+		if service := serviceOfEntity(name); service != "" {
+			if _, ok := composed[service]; !ok {
+				return nil, fmt.Errorf("%s belongs to %s, which is not deployed and not owned by %s", name, service, owner)
+			}
+		}
+	}
+
+	app := appFromEntities(composed)
+	if name, ok := deployed["name"]; ok {
+		app["name"] = name
+	}
+	return app, nil
+}
+
+// appFromEntities reassembles an app from its entities, keyed as by AppEntities
+func appFromEntities(entities map[string]interface{}) map[string]interface{} {
+	app := map[string]interface{}{}
+	if settings, ok := entities[appSettingsEntity].(map[string]interface{}); ok {
+		for key, value := range settings {
+			app[key] = value
+		}
+	}
+	if appSecrets, ok := entities[secretsName]; ok {
+		app[secretsName] = appSecrets
+	}
+
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := map[string]map[string]interface{}{}
+	var serviceNames []string
+	for _, name := range names {
+		if parts := strings.Split(name, "/"); len(parts) == 2 && parts[0] == servicesName {
+			services[name] = map[string]interface{}{
+				configName:           entities[name],
+				incomingWebhooksName: []interface{}{},
+				rulesName:            []interface{}{},
+			}
+			serviceNames = append(serviceNames, name)
+		}
+	}
+
+	for _, name := range names {
+		if service := serviceOfEntity(name); service != "" {
+			kind := strings.SplitN(strings.TrimPrefix(name, service+"/"), "/", 2)[0]
+			list, _ := services[service][kind].([]interface{})
+			services[service][kind] = append(list, entities[name])
+			continue
+		}
+
+		if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && parts[0] != servicesName {
+			list, _ := app[parts[0]].([]interface{})
+			app[parts[0]] = append(list, entities[name])
+		}
+	}
+
+	serviceList := []interface{}{}
+	for _, name := range serviceNames {
+		serviceList = append(serviceList, services[name])
+	}
+	app[servicesName] = serviceList
+
+	return app
+}
+
+// RemoveUnownedEntities removes the config of the entities that owner does not own, as annotated, from the app
+// directory at appPath, returning the paths it removed. The config of a service is kept if any of its incoming
+// webhooks or rules are owned, so that they can be loaded
+func RemoveUnownedEntities(appPath, owner string) ([]string, error) {
+	owners, err := ConfigFileOwners(appPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := ListConfigFiles(appPath)
+	keepService := map[string]bool{}
+	for _, file := range files {
+		if service := serviceDirOfFile(file.Path); service != "" && owners[file.Path] == owner {
+			keepService[service] = true
+		}
+	}
+
+	var removed []string
+	removedServices := map[string]bool{}
+	for _, file := range files {
+		if owners[file.Path] == owner || removedServices[serviceDirOfFile(file.Path)] {
+			continue
+		}
+
+		unit := file.Path
+		switch file.Kind {
+		case ConfigKindApp, ConfigKindSecrets:
+			continue
+		case ConfigKindFunction, ConfigKindIncomingWebhook:
+			unit = filepath.Dir(file.Path)
+		case ConfigKindService:
+			if keepService[filepath.Dir(file.Path)] {
+				continue
+			}
+			unit = filepath.Dir(file.Path)
+			removedServices[unit] = true
+		}
+
+		if err := os.RemoveAll(filepath.Join(appPath, unit)); err != nil {
+			return nil, err
+		}
+		removed = append(removed, filepath.ToSlash(unit))
+	}
+
+	return removed, nil
+}
+
+// ConfigFileOwners returns the owner of each config file of the app directory at appPath, keyed by its path as
+// listed by ListConfigFiles. Files without an owner are left out
+func ConfigFileOwners(appPath string) (map[string]string, error) {
+	owners := map[string]string{}
+	files := ListConfigFiles(appPath)
+	for _, file := range files {
+		if file.Kind == ConfigKindApp || file.Kind == ConfigKindSecrets {
+			continue
+		}
+
+		var config interface{}
+		if err := readAndUnmarshalJSONInto(filepath.Join(appPath, file.Path), &config); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if owner := annotatedOwner(config); owner != "" {
+			owners[file.Path] = owner
+		}
+	}
+
+	for _, file := range files {
+		if _, ok := owners[file.Path]; ok {
+			continue
+		}
+		if service := serviceDirOfFile(file.Path); service != "" {
+			if owner, ok := owners[filepath.Join(service, configName+jsonExt)]; ok {
+				owners[file.Path] = owner
+			}
+		}
+	}
+
+	return owners, nil
+}
+
+// annotatedOwner returns the owner an entity is annotated with, in its config for those loaded from a directory
+func annotatedOwner(entity interface{}) string {
+	m, _ := entity.(map[string]interface{})
+	if owner, ok := m[OwnerField].(string); ok {
+		return owner
+	}
+	config, _ := m[configName].(map[string]interface{})
+	owner, _ := config[OwnerField].(string)
+	return owner
+}
+
+// serviceOfEntity returns the name of the service that the incoming webhook or rule with the given entity name
+// belongs to, or "" for other entities
+func serviceOfEntity(name string) string {
+	parts := strings.SplitN(name, "/", 4)
+	if len(parts) != 4 || parts[0] != servicesName {
+		return ""
+	}
+	return servicesName + "/" + parts[1]
+}
+
+// serviceDirOfFile returns the directory of the service that the incoming webhook or rule config at path
+// belongs to, or "" for other config files
+func serviceDirOfFile(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	if len(parts) < 4 || parts[0] != servicesName {
+		return ""
+	}
+	return filepath.Join(servicesName, parts[1])
+}
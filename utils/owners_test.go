@@ -0,0 +1,123 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+const ownersAppPath = "../testdata/app_with_owners"
+
+func TestEntityOwners(t *testing.T) {
+	app, err := utils.UnmarshalFromDir(ownersAppPath)
+	u.So(t, err, gc.ShouldBeNil)
+
+	u.So(t, utils.EntityOwners(app), gc.ShouldResemble, map[string]string{
+		"functions/charge": "payments",
+		"functions/greet":  "web",
+		"services/stripe":  "payments",
+		"services/stripe/incoming_webhooks/onCharge": "payments",
+		"services/mongodb/rules/payments.charges":    "payments",
+	})
+}
+
+func TestComposeOwnedApp(t *testing.T) {
+	load := func() map[string]interface{} {
+		app, err := utils.UnmarshalFromDir(ownersAppPath)
+		u.So(t, err, gc.ShouldBeNil)
+		return app
+	}
+
+	t.Run("it replaces the deployed entities of the owner with the local ones", func(t *testing.T) {
+		deployed := load()
+		local := load()
+
+		functions := local["functions"].([]interface{})
+		for _, fn := range functions {
+			config := fn.(map[string]interface{})["config"].(map[string]interface{})
+			config["private"] = !config["private"].(bool)
+		}
+		local["values"] = []interface{}{}
+
+		composed, err := utils.ComposeOwnedApp(deployed, local, "payments")
+		u.So(t, err, gc.ShouldBeNil)
+
+		entities := utils.AppEntities(composed)
+		deployedEntities := utils.AppEntities(deployed)
+		localEntities := utils.AppEntities(local)
+		u.So(t, entities, gc.ShouldHaveLength, len(deployedEntities))
+		u.So(t, entities["functions/charge"], gc.ShouldResemble, localEntities["functions/charge"])
+		u.So(t, entities["functions/greet"], gc.ShouldResemble, deployedEntities["functions/greet"])
+		u.So(t, entities["values/siteName"], gc.ShouldResemble, deployedEntities["values/siteName"])
+		u.So(t, composed["name"], gc.ShouldEqual, "shared-app")
+	})
+
+	t.Run("it removes the deployed entities of the owner that are no longer local", func(t *testing.T) {
+		deployed := load()
+		local := load()
+		local["functions"] = []interface{}{}
+		local["services"] = []interface{}{}
+
+		composed, err := utils.ComposeOwnedApp(deployed, local, "payments")
+		u.So(t, err, gc.ShouldBeNil)
+
+		entities := utils.AppEntities(composed)
+		u.So(t, entities, gc.ShouldNotContainKey, "functions/charge")
+		u.So(t, entities, gc.ShouldNotContainKey, "services/stripe")
+		u.So(t, entities, gc.ShouldNotContainKey, "services/mongodb/rules/payments.charges")
+		u.So(t, entities, gc.ShouldContainKey, "functions/greet")
+		u.So(t, entities, gc.ShouldContainKey, "services/mongodb")
+	})
+
+	t.Run("it fails if an owned entity belongs to a service that would not be deployed", func(t *testing.T) {
+		deployed := load()
+		deployed["services"] = []interface{}{}
+		local := load()
+
+		_, err := utils.ComposeOwnedApp(deployed, local, "payments")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEqual, "services/mongodb/rules/payments.charges belongs to services/mongodb, which is not deployed and not owned by payments")
+	})
+}
+
+func TestRemoveUnownedEntities(t *testing.T) {
+	appDir, err := ioutil.TempDir("", "stitch-owners-app")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(appDir)
+
+	u.So(t, filepath.Walk(ownersAppPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(ownersAppPath, path)
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(appDir, relPath), 0755)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(appDir, relPath), data, 0644)
+	}), gc.ShouldBeNil)
+
+	removed, err := utils.RemoveUnownedEntities(appDir, "payments")
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, removed, gc.ShouldResemble, []string{"values/siteName.json", "functions/greet"})
+
+	app, err := utils.UnmarshalFromDir(appDir)
+	u.So(t, err, gc.ShouldBeNil)
+
+	owners := utils.EntityOwners(app)
+	u.So(t, owners, gc.ShouldHaveLength, 4)
+	for _, owner := range owners {
+		u.So(t, owner, gc.ShouldEqual, "payments")
+	}
+
+	// the config of a service is kept for the entities of the owner that belong to it
+	u.So(t, utils.AppEntities(app), gc.ShouldContainKey, "services/mongodb")
+}
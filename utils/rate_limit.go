@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitChunkSize is the most data read through a RateLimitedReader at once, so that the
+// limit is applied smoothly rather than in bursts the size of the caller's buffer
+const rateLimitChunkSize = 32 * 1024
+
+var (
+	rateRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-zA-Z]*)(?:/s)?$`)
+
+	rateUnits = map[string]float64{
+		"":    1,
+		"b":   1,
+		"k":   1000,
+		"kb":  1000,
+		"kib": 1024,
+		"m":   1000 * 1000,
+		"mb":  1000 * 1000,
+		"mib": 1024 * 1024,
+		"g":   1000 * 1000 * 1000,
+		"gb":  1000 * 1000 * 1000,
+		"gib": 1024 * 1024 * 1024,
+	}
+)
+
+// ParseRate parses a transfer rate such as "5MB/s" or "512KiB" into bytes per second. KB, MB, and GB are
+// powers of 1000, while KiB, MiB, and GiB are powers of 1024
+func ParseRate(rate string) (int64, error) {
+	matches := rateRegex.FindStringSubmatch(strings.TrimSpace(rate))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid rate %q: expected a number of bytes per second, like 5MB/s", rate)
+	}
+
+	unit, ok := rateUnits[strings.ToLower(matches[2])]
+	if !ok {
+		return 0, fmt.Errorf("invalid rate %q: unknown unit %q", rate, matches[2])
+	}
+
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q: %s", rate, err)
+	}
+
+	bytesPerSecond := int64(value * unit)
+	if bytesPerSecond < 1 {
+		return 0, fmt.Errorf("invalid rate %q: must be at least 1 byte per second", rate)
+	}
+
+	return bytesPerSecond, nil
+}
+
+// RateLimiter paces data transfers so that, together, they do not exceed a number of bytes per second.
+// It is safe to share between goroutines
+type RateLimiter struct {
+	mu             sync.Mutex
+	bytesPerSecond int64
+	next           time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewRateLimiter returns a RateLimiter allowing bytesPerSecond
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	return &RateLimiter{
+		bytesPerSecond: bytesPerSecond,
+		now:            time.Now,
+		sleep:          time.Sleep,
+	}
+}
+
+// WaitN blocks until n more bytes may be transferred
+func (rl *RateLimiter) WaitN(n int) {
+	rl.mu.Lock()
+	now := rl.now()
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	wait := rl.next.Sub(now)
+	rl.next = rl.next.Add(time.Duration(float64(n) / float64(rl.bytesPerSecond) * float64(time.Second)))
+	rl.mu.Unlock()
+
+	if wait > 0 {
+		rl.sleep(wait)
+	}
+}
+
+// RateLimitedReader is an io.Reader whose reads are paced by a RateLimiter
+type RateLimitedReader struct {
+	reader  io.Reader
+	limiter *RateLimiter
+}
+
+// NewRateLimitedReader returns a RateLimitedReader reading from r at the pace allowed by limiter
+func NewRateLimitedReader(r io.Reader, limiter *RateLimiter) *RateLimitedReader {
+	return &RateLimitedReader{r, limiter}
+}
+
+func (r *RateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > rateLimitChunkSize {
+		p = p[:rateLimitChunkSize]
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.limiter.WaitN(n)
+	}
+
+	return n, err
+}
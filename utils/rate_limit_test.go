@@ -0,0 +1,64 @@
+package utils_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseRate(t *testing.T) {
+	for _, tc := range []struct {
+		rate     string
+		expected int64
+	}{
+		{"100", 100},
+		{"100B/s", 100},
+		{"5MB/s", 5000000},
+		{"5mb", 5000000},
+		{"512KiB/s", 524288},
+		{"1.5 GB/s", 1500000000},
+	} {
+		t.Run("should parse "+tc.rate, func(t *testing.T) {
+			rate, err := utils.ParseRate(tc.rate)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, rate, gc.ShouldEqual, tc.expected)
+		})
+	}
+
+	for _, rate := range []string{"", "fast", "5XB/s", "-1MB/s", "0.1B/s"} {
+		t.Run("should fail to parse "+rate, func(t *testing.T) {
+			_, err := utils.ParseRate(rate)
+			u.So(t, err, gc.ShouldNotBeNil)
+		})
+	}
+}
+
+func TestRateLimitedReader(t *testing.T) {
+	t.Run("readers sharing a limiter should be paced together", func(t *testing.T) {
+		limiter := utils.NewRateLimiter(1000)
+		data := bytes.Repeat([]byte("a"), 100)
+
+		start := time.Now()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				read, err := ioutil.ReadAll(utils.NewRateLimitedReader(bytes.NewReader(data), limiter))
+				u.So(t, err, gc.ShouldBeNil)
+				u.So(t, read, gc.ShouldResemble, data)
+			}()
+		}
+		wg.Wait()
+
+		// the first 100 bytes are free, and the other 200 take 100ms each at 1000 bytes per second
+		u.So(t, time.Since(start), gc.ShouldBeGreaterThanOrEqualTo, 190*time.Millisecond)
+	})
+}
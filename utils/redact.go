@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RedactedPlaceholder replaces the value of every field masked by RedactAppDir
+	RedactedPlaceholder = "<redacted>"
+
+	// RedactionsFileName is the file in a redacted app directory that lists the fields which were masked
+	RedactionsFileName = "redactions.json"
+)
+
+// sensitiveFieldSuffixes end the names, lowercased and without separators, of config fields that hold credentials
+var sensitiveFieldSuffixes = []string{"secret", "password", "token", "apikey", "accesskey", "privatekey"}
+
+// RedactedField identifies a field masked by RedactAppDir
+type RedactedField struct {
+	// Path is the location of the file relative to the root of the app directory
+	Path  string `json:"path"`
+	Field string `json:"field"`
+}
+
+// Redactions records what RedactAppDir masked in an app directory
+type Redactions struct {
+	Placeholder string          `json:"placeholder"`
+	Fields      []RedactedField `json:"fields"`
+}
+
+// RedactAppDir masks known-sensitive fields in the app directory at appPath with RedactedPlaceholder, so
+// that the app can be shared: credential-like fields in the config of auth providers and services, and
+// every secret in the secrets file. The masked fields are recorded in RedactionsFileName and returned
+func RedactAppDir(appPath string) ([]RedactedField, error) {
+	var fields []RedactedField
+	for _, file := range ListConfigFiles(appPath) {
+		var redact func(doc interface{}, path string) []string
+		switch file.Kind {
+		case ConfigKindSecrets:
+			redact = redactAllStrings
+		case ConfigKindAuthProvider, ConfigKindService:
+			redact = redactConfigCredentials
+		default:
+			continue
+		}
+
+		var redacted []string
+		if err := rewriteJSONFile(filepath.Join(appPath, file.Path), func(doc interface{}) bool {
+			redacted = redact(doc, "")
+			return len(redacted) > 0
+		}); err != nil {
+			return nil, err
+		}
+
+		for _, field := range redacted {
+			fields = append(fields, RedactedField{Path: filepath.ToSlash(file.Path), Field: field})
+		}
+	}
+
+	data, err := marshalIndentNoEscape(Redactions{Placeholder: RedactedPlaceholder, Fields: fields})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(appPath, RedactionsFileName), data, 0644); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// IsRedacted returns whether the app directory at appPath was written with its sensitive fields masked
+func IsRedacted(appPath string) bool {
+	_, err := os.Stat(filepath.Join(appPath, RedactionsFileName))
+	return err == nil
+}
+
+// ResolveRedactions copies the app at appPath to dest, leaving out its hosting assets, and replaces every
+// RedactedPlaceholder in its config files with the value resolve returns for the field. The fields that
+// resolve has no value for are left as placeholders and returned
+func ResolveRedactions(appPath, dest string, resolve func(field RedactedField) (string, bool)) ([]RedactedField, error) {
+	if err := copyAppConfig(appPath, dest); err != nil {
+		return nil, err
+	}
+
+	var unresolved []RedactedField
+	for _, file := range ListConfigFiles(dest) {
+		filePath := filepath.ToSlash(file.Path)
+		if err := rewriteJSONFile(filepath.Join(dest, file.Path), func(doc interface{}) bool {
+			var replaced bool
+			missing := replacePlaceholders(doc, "", func(fieldPath string) (string, bool) {
+				value, ok := resolve(RedactedField{Path: filePath, Field: fieldPath})
+				replaced = replaced || ok
+				return value, ok
+			})
+			for _, field := range missing {
+				unresolved = append(unresolved, RedactedField{Path: filePath, Field: field})
+			}
+			return replaced
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return unresolved, nil
+}
+
+// copyAppConfig copies everything in the app directory at appPath, apart from its hosting assets and the
+// record of redactions, to dest
+func copyAppConfig(appPath, dest string) error {
+	return filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(appPath, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if relPath == HostingRoot {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dest, relPath), 0755)
+		}
+
+		if relPath == RedactionsFileName || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(filepath.Join(dest, relPath), data, info.Mode().Perm())
+	})
+}
+
+// replacePlaceholders replaces each RedactedPlaceholder within value with the value lookup returns for its
+// path, returning the paths that lookup had no value for
+func replacePlaceholders(value interface{}, path string, lookup func(fieldPath string) (string, bool)) []string {
+	var missing []string
+	replace := func(fieldPath string, set func(string)) {
+		if resolved, ok := lookup(fieldPath); ok {
+			set(resolved)
+			return
+		}
+		missing = append(missing, fieldPath)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			key := key
+			fieldPath := path + "." + key
+			if s, ok := v[key].(string); ok && s == RedactedPlaceholder {
+				replace(fieldPath, func(resolved string) { v[key] = resolved })
+				continue
+			}
+			missing = append(missing, replacePlaceholders(v[key], fieldPath, lookup)...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			i := i
+			itemPath := path + "[" + strconv.Itoa(i) + "]"
+			if s, ok := item.(string); ok && s == RedactedPlaceholder {
+				replace(itemPath, func(resolved string) { v[i] = resolved })
+				continue
+			}
+			missing = append(missing, replacePlaceholders(item, itemPath, lookup)...)
+		}
+	}
+
+	return missing
+}
+
+// rewriteJSONFile applies update to the JSON document at path, rewriting the file if update reports that it
+// changed the document. A missing or empty file is left alone
+func rewriteJSONFile(path string, update func(doc interface{}) bool) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	// numbers are kept as written rather than round-tripped through float64
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+
+	if !update(doc) {
+		return nil
+	}
+
+	out, err := marshalIndentNoEscape(doc)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, out, 0644)
+}
+
+// redactConfigCredentials masks the credential-like fields anywhere within the "config" of an entity
+func redactConfigCredentials(doc interface{}, path string) []string {
+	entity, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	return redactMatching(entity["config"], path+".config", false)
+}
+
+// redactAllStrings masks every non-empty string in the document
+func redactAllStrings(doc interface{}, path string) []string {
+	return redactMatching(doc, path, true)
+}
+
+// redactMatching masks the non-empty strings within value whose field name is sensitive, or all of them
+// if all is true, returning the paths of the masked fields in order
+func redactMatching(value interface{}, path string, all bool) []string {
+	var redacted []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fieldPath := path + "." + key
+			if s, ok := v[key].(string); ok {
+				if s != "" && s != RedactedPlaceholder && (all || isSensitiveField(key)) {
+					v[key] = RedactedPlaceholder
+					redacted = append(redacted, fieldPath)
+				}
+				continue
+			}
+			redacted = append(redacted, redactMatching(v[key], fieldPath, all || isSensitiveField(key))...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			itemPath := path + "[" + strconv.Itoa(i) + "]"
+			if s, ok := item.(string); ok {
+				if s != "" && s != RedactedPlaceholder && all {
+					v[i] = RedactedPlaceholder
+					redacted = append(redacted, itemPath)
+				}
+				continue
+			}
+			redacted = append(redacted, redactMatching(item, itemPath, all)...)
+		}
+	}
+
+	return redacted
+}
+
+func isSensitiveField(name string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	for _, suffix := range sensitiveFieldSuffixes {
+		if strings.HasSuffix(normalized, suffix) {
+			return true
+		}
+	}
+	return false
+}
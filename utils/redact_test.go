@@ -0,0 +1,110 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRedactAppDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-redact")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"stitch.json":                  `{"name": "my-app", "app_id": "my-app-abcde"}`,
+		"secrets.json":                 `{"services": {"svc": {"auth_token": "abc"}}, "auth_providers": {"oauth2-google": {"clientSecret": "def"}}}`,
+		"auth_providers/google.json":   `{"name": "oauth2-google", "config": {"clientId": "my-client", "clientSecret": "ghi"}, "redirect_uris": ["https://example.com"]}`,
+		"auth_providers/api-key.json":  `{"name": "api-key", "type": "api-key", "disabled": false}`,
+		"services/svc/config.json":     `{"name": "svc", "type": "aws", "config": {"accessKeyId": "AKIA", "secretAccessKey": "jkl", "region": "us-east-1", "retries": 12345678901234567890}}`,
+		"services/mongodb/config.json": `{"name": "mongodb-atlas", "type": "mongodb-atlas", "config": {"clusterName": "Cluster0"}}`,
+		"values/password.json":         `{"name": "password", "value": "a value, not a credential"}`,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	readJSON := func(name string) map[string]interface{} {
+		var doc map[string]interface{}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, json.Unmarshal(data, &doc), gc.ShouldBeNil)
+		return doc
+	}
+
+	fields, err := utils.RedactAppDir(dir)
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, fields, gc.ShouldResemble, []utils.RedactedField{
+		{Path: "secrets.json", Field: ".auth_providers.oauth2-google.clientSecret"},
+		{Path: "secrets.json", Field: ".services.svc.auth_token"},
+		{Path: "auth_providers/google.json", Field: ".config.clientSecret"},
+		{Path: "services/svc/config.json", Field: ".config.secretAccessKey"},
+	})
+
+	t.Run("it masks credentials and leaves everything else alone", func(t *testing.T) {
+		provider := readJSON("auth_providers/google.json")
+		u.So(t, provider["config"], gc.ShouldResemble, map[string]interface{}{"clientId": "my-client", "clientSecret": utils.RedactedPlaceholder})
+		u.So(t, provider["redirect_uris"], gc.ShouldResemble, []interface{}{"https://example.com"})
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "services/svc/config.json"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(data), gc.ShouldContainSubstring, "12345678901234567890")
+		u.So(t, string(data), gc.ShouldNotContainSubstring, "jkl")
+
+		u.So(t, readJSON("values/password.json")["value"], gc.ShouldEqual, "a value, not a credential")
+		u.So(t, readJSON("stitch.json")["app_id"], gc.ShouldEqual, "my-app-abcde")
+	})
+
+	t.Run("it records the masked fields", func(t *testing.T) {
+		u.So(t, utils.IsRedacted(dir), gc.ShouldBeTrue)
+
+		var redactions utils.Redactions
+		data, err := ioutil.ReadFile(filepath.Join(dir, utils.RedactionsFileName))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, json.Unmarshal(data, &redactions), gc.ShouldBeNil)
+		u.So(t, redactions.Placeholder, gc.ShouldEqual, utils.RedactedPlaceholder)
+		u.So(t, redactions.Fields, gc.ShouldResemble, fields)
+	})
+
+	t.Run("it resolves placeholders into a copy of the app", func(t *testing.T) {
+		hostingFile := filepath.Join(dir, utils.HostingRoot, "files", "index.html")
+		u.So(t, os.MkdirAll(filepath.Dir(hostingFile), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(hostingFile, []byte("<html></html>"), 0644), gc.ShouldBeNil)
+
+		dest, err := ioutil.TempDir("", "stitch-redact-resolved")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dest)
+
+		unresolved, err := utils.ResolveRedactions(dir, dest, func(field utils.RedactedField) (string, bool) {
+			if field.Path == "secrets.json" {
+				return "", false
+			}
+			return "resolved " + field.Field, true
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, unresolved, gc.ShouldResemble, []utils.RedactedField{
+			{Path: "secrets.json", Field: ".auth_providers.oauth2-google.clientSecret"},
+			{Path: "secrets.json", Field: ".services.svc.auth_token"},
+		})
+
+		var provider map[string]interface{}
+		data, err := ioutil.ReadFile(filepath.Join(dest, "auth_providers/google.json"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, json.Unmarshal(data, &provider), gc.ShouldBeNil)
+		u.So(t, provider["config"], gc.ShouldResemble, map[string]interface{}{"clientId": "my-client", "clientSecret": "resolved .config.clientSecret"})
+
+		_, err = os.Stat(filepath.Join(dest, utils.HostingRoot))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+		u.So(t, utils.IsRedacted(dest), gc.ShouldBeFalse)
+		u.So(t, readJSON("auth_providers/google.json")["config"].(map[string]interface{})["clientSecret"], gc.ShouldEqual, utils.RedactedPlaceholder)
+	})
+}
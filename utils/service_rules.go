@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// unsafeFileNameChars matches the characters replaced in the file names rules are written to
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// SplitServiceRules moves the rules embedded as a "rules" array in the config.json of each service in the app
+// directory at appPath into files of their own under the service's rules directory, named after the rule, so
+// that each rule can be reviewed and owned separately. Existing rule files are replaced with the embedded rules
+func SplitServiceRules(appPath string) error {
+	serviceInfos, _ := ioutil.ReadDir(filepath.Join(appPath, servicesName))
+	return iterDirectories(func(info os.FileInfo, path string) error {
+		return splitServiceRules(path)
+	}, filepath.Join(appPath, servicesName), serviceInfos)
+}
+
+func splitServiceRules(dir string) error {
+	configPath := filepath.Join(dir, configName+jsonExt)
+
+	var config map[string]json.RawMessage
+	if err := readAndUnmarshalJSONInto(configPath, &config); err != nil {
+		return err
+	}
+
+	raw, ok := config[rulesName]
+	if !ok {
+		return nil
+	}
+
+	var rules []map[string]interface{}
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return fmt.Errorf("failed to parse the rules in %s: %s", configPath, err)
+	}
+
+	rulesDir := filepath.Join(dir, rulesName)
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for i, rule := range rules {
+		rulePath := filepath.Join(rulesDir, ruleFileName(rule, i, used)+jsonExt)
+
+		data, err := marshalIndentNoEscape(rule)
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(rulePath, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	delete(config, rulesName)
+
+	data, err := marshalIndentNoEscape(config)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(configPath, data, 0644)
+}
+
+// ruleFileName returns the name, without extension, of the file the rule at index i is written to: its name, or
+// the namespace it applies to for MongoDB rules, made safe for file systems and unique among the names in used
+func ruleFileName(rule map[string]interface{}, i int, used map[string]bool) string {
+	name, _ := rule["name"].(string)
+	if name == "" {
+		database, _ := rule["database"].(string)
+		collection, _ := rule["collection"].(string)
+		if database != "" && collection != "" {
+			name = database + "." + collection
+		}
+	}
+	if name == "" {
+		name = fmt.Sprintf("rule_%d", i)
+	}
+	name = unsafeFileNameChars.ReplaceAllString(name, "_")
+
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", name, n)
+	}
+	used[unique] = true
+
+	return unique
+}
@@ -0,0 +1,91 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSplitServiceRules(t *testing.T) {
+	writeFile := func(path, data string) {
+		u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(path, []byte(data), 0644), gc.ShouldBeNil)
+	}
+
+	setup := func() string {
+		dir, err := ioutil.TempDir("", "stitch-service-rules")
+		u.So(t, err, gc.ShouldBeNil)
+
+		writeFile(filepath.Join(dir, "stitch.json"), `{"name": "my-app"}`)
+		writeFile(
+			filepath.Join(dir, "services", "mongodb-atlas", "config.json"),
+			`{"name": "mongodb-atlas", "type": "mongodb-atlas", "rules": [
+				{"database": "db", "collection": "users", "roles": []},
+				{"database": "db", "collection": "users", "roles": [{"name": "owner"}]},
+				{"name": "a/b", "actions": ["get"]},
+				{"actions": ["post"]}
+			]}`,
+		)
+		return dir
+	}
+
+	t.Run("it moves embedded rules into files of their own", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		u.So(t, utils.SplitServiceRules(dir), gc.ShouldBeNil)
+
+		infos, err := ioutil.ReadDir(filepath.Join(dir, "services", "mongodb-atlas", "rules"))
+		u.So(t, err, gc.ShouldBeNil)
+
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		u.So(t, names, gc.ShouldResemble, []string{"a_b.json", "db.users.json", "db.users_2.json", "rule_3.json"})
+
+		config, err := ioutil.ReadFile(filepath.Join(dir, "services", "mongodb-atlas", "config.json"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(config), gc.ShouldNotContainSubstring, "rules")
+	})
+
+	t.Run("it writes embedded rules over existing rule files", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		rulePath := filepath.Join(dir, "services", "mongodb-atlas", "rules", "a_b.json")
+		writeFile(rulePath, `{"name": "a/b", "actions": ["put"]}`)
+
+		u.So(t, utils.SplitServiceRules(dir), gc.ShouldBeNil)
+
+		rule, err := ioutil.ReadFile(rulePath)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(rule), gc.ShouldContainSubstring, `"get"`)
+		u.So(t, string(rule), gc.ShouldNotContainSubstring, `"put"`)
+	})
+
+	t.Run("it imports embedded and split rules alike", func(t *testing.T) {
+		dir := setup()
+		defer os.RemoveAll(dir)
+
+		embedded, err := utils.UnmarshalFromDir(dir)
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, utils.SplitServiceRules(dir), gc.ShouldBeNil)
+
+		split, err := utils.UnmarshalFromDir(dir)
+		u.So(t, err, gc.ShouldBeNil)
+
+		embeddedService := embedded["services"].([]interface{})[0].(map[string]interface{})
+		splitService := split["services"].([]interface{})[0].(map[string]interface{})
+		u.So(t, embeddedService["config"], gc.ShouldResemble, splitService["config"])
+		u.So(t, embeddedService["config"], gc.ShouldNotContainKey, "rules")
+		u.So(t, embeddedService["rules"], gc.ShouldHaveLength, 4)
+		u.So(t, splitService["rules"], gc.ShouldHaveLength, 4)
+	})
+}
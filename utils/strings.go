@@ -101,3 +101,36 @@ func randomAlphaNumericSpecialString(length int, strict bool) string {
 		}
 	}
 }
+
+// EditDistance returns the Levenshtein distance between a and b: the fewest single-character insertions,
+// deletions and substitutions that turn one into the other
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
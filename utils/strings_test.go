@@ -0,0 +1,28 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"my-app-nysja", "my-app-nysja", 0},
+		{"my-app-nysia", "my-app-nysja", 1},
+		{"my-app-nysj", "my-app-nysja", 1},
+		{"my-ap-nysja", "my-app-nysja", 1},
+		{"my-app-ysnja", "my-app-nysja", 2},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+	} {
+		u.So(t, utils.EditDistance(tc.a, tc.b), gc.ShouldEqual, tc.expected)
+		u.So(t, utils.EditDistance(tc.b, tc.a), gc.ShouldEqual, tc.expected)
+	}
+}
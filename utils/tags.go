@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TagsField lists the tags of a function, trigger or service in its config, e.g. "tags": ["billing"], which
+// group it with the other entities deployed together by "import --tag"
+const TagsField = "tags"
+
+// sourceReferencePattern matches the functions, services and values that the source of a function or incoming
+// webhook refers to by name, e.g. context.functions.execute("sum")
+var sourceReferencePattern = regexp.MustCompile(`context\.(functions\.execute|services\.get|values\.get)\(\s*["'` + "`" + `]([^"'` + "`" + `]+)["'` + "`" + `]`)
+
+// sourceReferenceKinds maps the accessor of a source reference to the kind of entity it refers to
+var sourceReferenceKinds = map[string]string{
+	"functions.execute": functionsName,
+	"services.get":      servicesName,
+	"values.get":        valuesName,
+}
+
+// EntityTags returns the tags of each entity of an app, as loaded by UnmarshalFromDir, keyed as by AppEntities.
+// Entities without tags are left out
+func EntityTags(app map[string]interface{}) map[string][]string {
+	tags := map[string][]string{}
+	for name, entity := range AppEntities(app) {
+		m, _ := entity.(map[string]interface{})
+
+		// the config of functions and incoming webhooks is held beside their source
+		if _, ok := m[sourceName]; ok {
+			m, _ = m[configName].(map[string]interface{})
+		}
+
+		for _, tag := range asSlice(m[TagsField]) {
+			if s, ok := tag.(string); ok && s != "" {
+				tags[name] = append(tags[name], s)
+			}
+		}
+	}
+	return tags
+}
+
+// EntityDependencies returns the reference graph of an app, as loaded by UnmarshalFromDir: the entities each
+// entity refers to, keyed and named as by AppEntities. Triggers refer to their function and the service they
+// watch, custom function auth providers to their function, functions and incoming webhooks to the functions,
+// services and values their source gets from the context, and incoming webhooks and rules to their service.
+// References to entities that are not in the app are left out
+func EntityDependencies(app map[string]interface{}) map[string][]string {
+	return entityDependencies(app, true)
+}
+
+// CreationDependencies returns the entities each entity of an app refers to in its config, and so must be
+// created after, as EntityDependencies does but without the references made at runtime by sources
+func CreationDependencies(app map[string]interface{}) map[string][]string {
+	return entityDependencies(app, false)
+}
+
+func entityDependencies(app map[string]interface{}, withSources bool) map[string][]string {
+	entities := AppEntities(app)
+
+	dependencies := map[string][]string{}
+	addReference := func(name, kind, referenced string) {
+		target := kind + "/" + referenced
+		if _, ok := entities[target]; !ok || target == name || referenced == "" {
+			return
+		}
+		for _, existing := range dependencies[name] {
+			if existing == target {
+				return
+			}
+		}
+		dependencies[name] = append(dependencies[name], target)
+	}
+
+	for name, entity := range entities {
+		m, _ := entity.(map[string]interface{})
+		config, _ := m[configName].(map[string]interface{})
+
+		switch {
+		case strings.HasPrefix(name, triggersName+"/"):
+			functionName, _ := m["function_name"].(string)
+			addReference(name, functionsName, functionName)
+			serviceName, _ := config["service_name"].(string)
+			addReference(name, servicesName, serviceName)
+		case strings.HasPrefix(name, authProvidersName+"/"):
+			functionName, _ := config["authFunctionName"].(string)
+			addReference(name, functionsName, functionName)
+		case strings.HasPrefix(name, functionsName+"/") && withSources:
+			source, _ := m[sourceName].(string)
+			addSourceReferences(source, func(kind, referenced string) { addReference(name, kind, referenced) })
+		case serviceOfEntity(name) != "":
+			service := serviceOfEntity(name)
+			addReference(name, servicesName, strings.TrimPrefix(service, servicesName+"/"))
+			if withSources {
+				source, _ := m[sourceName].(string)
+				addSourceReferences(source, func(kind, referenced string) { addReference(name, kind, referenced) })
+			}
+		}
+	}
+
+	for name := range dependencies {
+		sort.Strings(dependencies[name])
+	}
+	return dependencies
+}
+
+// addSourceReferences calls add with the kind and name of each entity that source refers to
+func addSourceReferences(source string, add func(kind, name string)) {
+	for _, match := range sourceReferencePattern.FindAllStringSubmatch(source, -1) {
+		add(sourceReferenceKinds[match[1]], match[2])
+	}
+}
+
+// TaggedEntities returns the names, as keyed by AppEntities, of the entities of an app that are tagged with tag
+// along with every entity they depend on, directly or not, in order. A service is selected with its incoming
+// webhooks and rules, as they are deployed together
+func TaggedEntities(app map[string]interface{}, tag string) []string {
+	entities := AppEntities(app)
+	dependencies := EntityDependencies(app)
+
+	children := map[string][]string{}
+	for name := range entities {
+		if service := serviceOfEntity(name); service != "" {
+			children[service] = append(children[service], name)
+		}
+	}
+
+	selected := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if selected[name] {
+			return
+		}
+		selected[name] = true
+
+		for _, child := range children[name] {
+			visit(child)
+		}
+		for _, dependency := range dependencies[name] {
+			visit(dependency)
+		}
+	}
+
+	for name, tags := range EntityTags(app) {
+		for _, t := range tags {
+			if t == tag {
+				visit(name)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectEntities returns the app holding only the named entities of app, as keyed by AppEntities, along with its
+// settings, name and secrets. The incoming webhooks and rules of a service are held in its config, so that of the
+// service is kept for those that are named
+func SelectEntities(app map[string]interface{}, names []string) map[string]interface{} {
+	entities := AppEntities(app)
+
+	selected := map[string]interface{}{appSettingsEntity: entities[appSettingsEntity]}
+	if appSecrets, ok := entities[secretsName]; ok {
+		selected[secretsName] = appSecrets
+	}
+	for _, name := range names {
+		if entity, ok := entities[name]; ok {
+			selected[name] = entity
+		}
+		if service := serviceOfEntity(name); service != "" {
+			selected[service] = entities[service]
+		}
+	}
+
+	subApp := appFromEntities(selected)
+	if name, ok := app["name"]; ok {
+		subApp["name"] = name
+	}
+	return subApp
+}
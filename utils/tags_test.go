@@ -0,0 +1,81 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+const tagsAppPath = "../testdata/app_with_tags"
+
+func TestEntityTags(t *testing.T) {
+	app, err := utils.UnmarshalFromDir(tagsAppPath)
+	u.So(t, err, gc.ShouldBeNil)
+
+	u.So(t, utils.EntityTags(app), gc.ShouldResemble, map[string][]string{
+		"functions/invoice":  {"billing"},
+		"functions/greet":    {"web"},
+		"triggers/onPayment": {"billing"},
+	})
+}
+
+func TestEntityDependencies(t *testing.T) {
+	app, err := utils.UnmarshalFromDir(tagsAppPath)
+	u.So(t, err, gc.ShouldBeNil)
+
+	u.So(t, utils.EntityDependencies(app), gc.ShouldResemble, map[string][]string{
+		"auth_providers/custom-function":            {"functions/authenticate"},
+		"functions/chargeCard":                      {"services/stripe"},
+		"functions/greet":                           {"services/mongodb"},
+		"functions/invoice":                         {"functions/formatAmount", "values/currency"},
+		"services/mongodb/rules/shop.payments":      {"services/mongodb"},
+		"services/stripe/incoming_webhooks/onEvent": {"functions/formatAmount", "services/stripe"},
+		"triggers/onPayment":                        {"functions/chargeCard", "services/mongodb"},
+	})
+}
+
+func TestTaggedEntities(t *testing.T) {
+	app, err := utils.UnmarshalFromDir(tagsAppPath)
+	u.So(t, err, gc.ShouldBeNil)
+
+	t.Run("it selects the tagged entities along with everything they depend on", func(t *testing.T) {
+		u.So(t, utils.TaggedEntities(app, "billing"), gc.ShouldResemble, []string{
+			"functions/chargeCard",
+			"functions/formatAmount",
+			"functions/invoice",
+			"services/mongodb",
+			"services/mongodb/rules/shop.payments",
+			"services/stripe",
+			"services/stripe/incoming_webhooks/onEvent",
+			"triggers/onPayment",
+			"values/currency",
+		})
+	})
+
+	t.Run("it selects nothing for a tag that no entity has", func(t *testing.T) {
+		u.So(t, utils.TaggedEntities(app, "search"), gc.ShouldBeEmpty)
+	})
+}
+
+func TestSelectEntities(t *testing.T) {
+	app, err := utils.UnmarshalFromDir(tagsAppPath)
+	u.So(t, err, gc.ShouldBeNil)
+
+	selected := utils.SelectEntities(app, utils.TaggedEntities(app, "web"))
+	u.So(t, selected["name"], gc.ShouldEqual, "tagged-app")
+
+	entities := utils.AppEntities(selected)
+	appEntities := utils.AppEntities(app)
+	u.So(t, entities, gc.ShouldHaveLength, 4)
+	u.So(t, entities["stitch.json"], gc.ShouldResemble, appEntities["stitch.json"])
+	u.So(t, entities["functions/greet"], gc.ShouldResemble, appEntities["functions/greet"])
+	u.So(t, entities["services/mongodb"], gc.ShouldResemble, appEntities["services/mongodb"])
+	u.So(t, entities["services/mongodb/rules/shop.payments"], gc.ShouldResemble, appEntities["services/mongodb/rules/shop.payments"])
+
+	// an incoming webhook is held in the config of its service, which is kept for it
+	entities = utils.AppEntities(utils.SelectEntities(app, []string{"services/stripe/incoming_webhooks/onEvent"}))
+	u.So(t, entities, gc.ShouldHaveLength, 3)
+	u.So(t, entities["services/stripe"], gc.ShouldResemble, appEntities["services/stripe"])
+}
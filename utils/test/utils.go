@@ -185,8 +185,13 @@ func NewMemoryStrategy(data []byte) *MemoryStrategy {
 
 // GenerateValidAccessToken generates and returns a valid access token *from the future*
 func GenerateValidAccessToken() string {
+	return GenerateToken(time.Now().Add(time.Hour))
+}
+
+// GenerateToken generates and returns a token that expires at exp
+func GenerateToken(exp time.Time) string {
 	token := auth.JWT{
-		Exp: time.Now().Add(time.Hour).Unix(),
+		Exp: exp.Unix(),
 	}
 
 	tokenBytes, err := json.Marshal(token)
@@ -205,7 +210,8 @@ type MockStitchClient struct {
 	FetchAppByGroupIDAndClientAppIDFn func(groupID, clientAppID string) (*models.App, error)
 	FetchAppByClientAppIDFn           func(clientAppID string) (*models.App, error)
 	FetchAppsByGroupIDFn              func(groupID string) ([]*models.App, error)
-	ListAssetsForAppIDFn              func(groupID, appID string) ([]string, []hosting.AssetDescription, error)
+	ListAssetsForAppIDFn              func(groupID, appID string) ([]hosting.AssetMetadata, error)
+	ListAssetsForDeploymentFn         func(groupID, appID, deploymentID string) ([]hosting.AssetMetadata, error)
 	UploadAssetFn                     func(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error
 	CopyAssetFn                       func(groupID, appID, fromPath, toPath string) error
 	MoveAssetFn                       func(groupID, appID, fromPath, toPath string) error
@@ -215,15 +221,72 @@ type MockStitchClient struct {
 	ExportFnCalls                     [][]string
 	ImportFn                          func(groupID, appID string, appData []byte, strategy string) error
 	ImportFnCalls                     [][]string
+	ImportWithProgressFn              func(groupID, appID string, appData []byte, strategy string, timeout time.Duration, progress func(*models.Deployment)) error
 	DiffFn                            func(groupID, appID string, appData []byte, strategy string) ([]string, error)
 	InvalidateCacheFn                 func(groupID, appID, path string) error
+	FetchConfigSchemasFn              func() (map[string]json.RawMessage, error)
+	FetchLimitsFn                     func() (*models.Limits, error)
+	FetchLatestDeploymentFn           func(groupID, appID string) (*models.Deployment, error)
+	FetchDeploymentFn                 func(groupID, appID, deploymentID string) (*models.Deployment, error)
+	ExecuteFunctionFn                 func(groupID, appID, name string, args []interface{}) (*models.FunctionExecution, error)
+	RenameAppFn                       func(groupID, appID, name string) error
+	DeleteAppFn                       func(groupID, appID string) error
+	FetchHostingConfigFn              func(groupID, appID string) (*hosting.Config, error)
+	UpdateHostingConfigFn             func(groupID, appID string, config *hosting.Config) error
+	FetchValuesFn                     func(groupID, appID string) ([]models.Value, error)
+	CreateValueFn                     func(groupID, appID string, value models.Value) (*models.Value, error)
+	DeleteValueFn                     func(groupID, appID, valueID string) error
+	FetchSecretsFn                    func(groupID, appID string) ([]models.Secret, error)
+	CreateSecretFn                    func(groupID, appID, name, value string) error
+	FetchMeasurementsFn               func(groupID, appID string, start, end time.Time, granularity string) (*models.Measurements, error)
+	FetchUserProfileFn                func() (*models.UserProfile, error)
+	FetchAuthProvidersFn              func(groupID, appID string) ([]models.AuthProvider, error)
+	FetchAuthProviderFn               func(groupID, appID, providerID string) (*models.AuthProvider, error)
+	UpdateAuthProviderFn              func(groupID, appID string, provider *models.AuthProvider) error
+	FetchLogsFn                       func(groupID, appID string, query models.LogQuery) (*models.LogPage, error)
+	StreamLogsFn                      func(groupID, appID string, query models.LogQuery) (*api.LogStream, error)
+	AuthenticateFn                    func(authProvider auth.AuthenticationProvider) (*auth.Response, error)
+	VerifyMFACodeFn                   func(mfaToken, code string) (*auth.Response, error)
+	StartMFAWebAuthnFn                func(mfaToken string) (*api.MFAWebAuthnSession, error)
+	PollMFAWebAuthnFn                 func(mfaToken string) (*auth.Response, error)
 }
 
 // Authenticate will authenticate a user given an auth.AuthenticationProvider
 func (msc *MockStitchClient) Authenticate(authProvider auth.AuthenticationProvider) (*auth.Response, error) {
+	if msc.AuthenticateFn != nil {
+		return msc.AuthenticateFn(authProvider)
+	}
+
 	return nil, nil
 }
 
+// VerifyMFACode completes a login that requires MFA with a code from an authenticator app
+func (msc *MockStitchClient) VerifyMFACode(mfaToken, code string) (*auth.Response, error) {
+	if msc.VerifyMFACodeFn != nil {
+		return msc.VerifyMFACodeFn(mfaToken, code)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// StartMFAWebAuthn starts verifying a login that requires MFA with a security key
+func (msc *MockStitchClient) StartMFAWebAuthn(mfaToken string) (*api.MFAWebAuthnSession, error) {
+	if msc.StartMFAWebAuthnFn != nil {
+		return msc.StartMFAWebAuthnFn(mfaToken)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// PollMFAWebAuthn completes a login that requires MFA once it has been verified with a security key
+func (msc *MockStitchClient) PollMFAWebAuthn(mfaToken string) (*auth.Response, error) {
+	if msc.PollMFAWebAuthnFn != nil {
+		return msc.PollMFAWebAuthnFn(mfaToken)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
 // Export will download a Stitch app as a .zip
 func (msc *MockStitchClient) Export(groupID, appID string, isTemplated bool) (string, io.ReadCloser, error) {
 	if msc.ExportFn != nil {
@@ -270,13 +333,21 @@ func (msc *MockStitchClient) Import(groupID, appID string, appData []byte, strat
 	return nil
 }
 
+// ImportWithProgress will push a local Stitch app to the server, reporting the progress of its deployment
+func (msc *MockStitchClient) ImportWithProgress(groupID, appID string, appData []byte, strategy string, timeout time.Duration, progress func(*models.Deployment)) error {
+	if msc.ImportWithProgressFn != nil {
+		return msc.ImportWithProgressFn(groupID, appID, appData, strategy, timeout, progress)
+	}
+	return msc.Import(groupID, appID, appData, strategy)
+}
+
 // FetchAppByGroupIDAndClientAppID fetches a Stitch app given a groupID and clientAppID
 func (msc *MockStitchClient) FetchAppByGroupIDAndClientAppID(groupID, clientAppID string) (*models.App, error) {
 	if msc.FetchAppByGroupIDAndClientAppIDFn != nil {
 		return msc.FetchAppByGroupIDAndClientAppIDFn(groupID, clientAppID)
 	}
 
-	return nil, api.ErrAppNotFound{clientAppID}
+	return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
 }
 
 // FetchAppByClientAppID fetches a Stitch app given a clientAppID
@@ -285,7 +356,7 @@ func (msc *MockStitchClient) FetchAppByClientAppID(clientAppID string) (*models.
 		return msc.FetchAppByClientAppIDFn(clientAppID)
 	}
 
-	return nil, api.ErrAppNotFound{clientAppID}
+	return nil, api.ErrAppNotFound{ClientAppID: clientAppID}
 }
 
 // UploadAsset uploads an asset
@@ -335,6 +406,10 @@ func (msc *MockStitchClient) SetAssetAttributes(groupID, appID, path string, att
 
 // ListAssetsForAppID fetches a Stitch app given a clientAppID
 func (msc *MockStitchClient) ListAssetsForAppID(groupID, appID string) ([]hosting.AssetMetadata, error) {
+	if msc.ListAssetsForAppIDFn != nil {
+		return msc.ListAssetsForAppIDFn(groupID, appID)
+	}
+
 	assetMetadata := []hosting.AssetMetadata{
 		{
 			FilePath: "/bar/shouldRemainSame.txt",
@@ -396,11 +471,203 @@ func (msc *MockStitchClient) InvalidateCache(groupID, appID, path string) error
 	return nil
 }
 
+// FetchConfigSchemas fetches the latest entity config schemas
+func (msc *MockStitchClient) FetchConfigSchemas() (map[string]json.RawMessage, error) {
+	if msc.FetchConfigSchemasFn != nil {
+		return msc.FetchConfigSchemasFn()
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// FetchLimits fetches the limits the server enforces on apps
+func (msc *MockStitchClient) FetchLimits() (*models.Limits, error) {
+	if msc.FetchLimitsFn != nil {
+		return msc.FetchLimitsFn()
+	}
+
+	return &models.Limits{}, nil
+}
+
+// FetchLatestDeployment fetches the most recent deployment of an app
+func (msc *MockStitchClient) FetchLatestDeployment(groupID, appID string) (*models.Deployment, error) {
+	if msc.FetchLatestDeploymentFn != nil {
+		return msc.FetchLatestDeploymentFn(groupID, appID)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// ListAssetsForDeployment fetches the hosting assets of an app as of a deployment
+func (msc *MockStitchClient) ListAssetsForDeployment(groupID, appID, deploymentID string) ([]hosting.AssetMetadata, error) {
+	if msc.ListAssetsForDeploymentFn != nil {
+		return msc.ListAssetsForDeploymentFn(groupID, appID, deploymentID)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// FetchDeployment fetches a deployment of an app
+func (msc *MockStitchClient) FetchDeployment(groupID, appID, deploymentID string) (*models.Deployment, error) {
+	if msc.FetchDeploymentFn != nil {
+		return msc.FetchDeploymentFn(groupID, appID, deploymentID)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// ExecuteFunction calls a function of an app
+func (msc *MockStitchClient) ExecuteFunction(groupID, appID, name string, args []interface{}) (*models.FunctionExecution, error) {
+	if msc.ExecuteFunctionFn != nil {
+		return msc.ExecuteFunctionFn(groupID, appID, name, args)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// RenameApp renames an app
+func (msc *MockStitchClient) RenameApp(groupID, appID, name string) error {
+	if msc.RenameAppFn != nil {
+		return msc.RenameAppFn(groupID, appID, name)
+	}
+
+	return errors.New("someone should test me")
+}
+
+// DeleteApp deletes an app
+func (msc *MockStitchClient) DeleteApp(groupID, appID string) error {
+	if msc.DeleteAppFn != nil {
+		return msc.DeleteAppFn(groupID, appID)
+	}
+
+	return errors.New("someone should test me")
+}
+
+// FetchHostingConfig fetches the app-wide static hosting settings of an app
+func (msc *MockStitchClient) FetchHostingConfig(groupID, appID string) (*hosting.Config, error) {
+	if msc.FetchHostingConfigFn != nil {
+		return msc.FetchHostingConfigFn(groupID, appID)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// UpdateHostingConfig replaces the app-wide static hosting settings of an app
+func (msc *MockStitchClient) UpdateHostingConfig(groupID, appID string, config *hosting.Config) error {
+	if msc.UpdateHostingConfigFn != nil {
+		return msc.UpdateHostingConfigFn(groupID, appID, config)
+	}
+
+	return errors.New("someone should test me")
+}
+
+// FetchValues fetches the values of an app
+func (msc *MockStitchClient) FetchValues(groupID, appID string) ([]models.Value, error) {
+	if msc.FetchValuesFn != nil {
+		return msc.FetchValuesFn(groupID, appID)
+	}
+	return nil, nil
+}
+
+// CreateValue creates a value in an app
+func (msc *MockStitchClient) CreateValue(groupID, appID string, value models.Value) (*models.Value, error) {
+	if msc.CreateValueFn != nil {
+		return msc.CreateValueFn(groupID, appID, value)
+	}
+	return &value, nil
+}
+
+// DeleteValue deletes a value of an app
+func (msc *MockStitchClient) DeleteValue(groupID, appID, valueID string) error {
+	if msc.DeleteValueFn != nil {
+		return msc.DeleteValueFn(groupID, appID, valueID)
+	}
+	return nil
+}
+
+// FetchSecrets fetches the secrets of an app
+func (msc *MockStitchClient) FetchSecrets(groupID, appID string) ([]models.Secret, error) {
+	if msc.FetchSecretsFn != nil {
+		return msc.FetchSecretsFn(groupID, appID)
+	}
+	return nil, nil
+}
+
+// CreateSecret creates a secret in an app
+func (msc *MockStitchClient) CreateSecret(groupID, appID, name, value string) error {
+	if msc.CreateSecretFn != nil {
+		return msc.CreateSecretFn(groupID, appID, name, value)
+	}
+	return nil
+}
+
+// FetchMeasurements fetches the usage metrics of an app
+func (msc *MockStitchClient) FetchMeasurements(groupID, appID string, start, end time.Time, granularity string) (*models.Measurements, error) {
+	if msc.FetchMeasurementsFn != nil {
+		return msc.FetchMeasurementsFn(groupID, appID, start, end, granularity)
+	}
+	return &models.Measurements{}, nil
+}
+
+// FetchUserProfile fetches the profile of the current user
+func (msc *MockStitchClient) FetchUserProfile() (*models.UserProfile, error) {
+	if msc.FetchUserProfileFn != nil {
+		return msc.FetchUserProfileFn()
+	}
+	return &models.UserProfile{}, nil
+}
+
+// FetchAuthProviders fetches the auth providers of an app
+func (msc *MockStitchClient) FetchAuthProviders(groupID, appID string) ([]models.AuthProvider, error) {
+	if msc.FetchAuthProvidersFn != nil {
+		return msc.FetchAuthProvidersFn(groupID, appID)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// FetchAuthProvider fetches an auth provider of an app
+func (msc *MockStitchClient) FetchAuthProvider(groupID, appID, providerID string) (*models.AuthProvider, error) {
+	if msc.FetchAuthProviderFn != nil {
+		return msc.FetchAuthProviderFn(groupID, appID, providerID)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// UpdateAuthProvider replaces an auth provider of an app
+func (msc *MockStitchClient) UpdateAuthProvider(groupID, appID string, provider *models.AuthProvider) error {
+	if msc.UpdateAuthProviderFn != nil {
+		return msc.UpdateAuthProviderFn(groupID, appID, provider)
+	}
+
+	return errors.New("someone should test me")
+}
+
+// FetchLogs fetches a page of the log entries of an app
+func (msc *MockStitchClient) FetchLogs(groupID, appID string, query models.LogQuery) (*models.LogPage, error) {
+	if msc.FetchLogsFn != nil {
+		return msc.FetchLogsFn(groupID, appID, query)
+	}
+
+	return nil, errors.New("someone should test me")
+}
+
+// StreamLogs opens a stream of the log entries of an app, which is unsupported unless StreamLogsFn is set
+func (msc *MockStitchClient) StreamLogs(groupID, appID string, query models.LogQuery) (*api.LogStream, error) {
+	if msc.StreamLogsFn != nil {
+		return msc.StreamLogsFn(groupID, appID, query)
+	}
+
+	return nil, api.ErrLogStreamingUnsupported
+}
+
 // MockMDBClient satisfies a mdbcloud.Client
 type MockMDBClient struct {
 	WithAuthFn           func(username, apiKey string) mdbcloud.Client
 	GroupsFn             func() ([]mdbcloud.Group, error)
 	GroupByNameFn        func(string) (*mdbcloud.Group, error)
+	OrgsFn               func() ([]mdbcloud.Org, error)
 	DeleteDatabaseUserFn func(groupId, username string) error
 }
 
@@ -425,6 +692,14 @@ func (mmc *MockMDBClient) GroupByName(groupName string) (*mdbcloud.Group, error)
 	return nil, errors.New("someone should test me")
 }
 
+// Orgs will return a list of orgs available
+func (mmc *MockMDBClient) Orgs() ([]mdbcloud.Org, error) {
+	if mmc.OrgsFn != nil {
+		return mmc.OrgsFn()
+	}
+	return nil, errors.New("someone should test me")
+}
+
 // DeleteDatabaseUser does nothing
 func (mmc *MockMDBClient) DeleteDatabaseUser(groupID, username string) error {
 	if mmc.DeleteDatabaseUserFn != nil {
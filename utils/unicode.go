@@ -0,0 +1,10 @@
+package utils
+
+import "golang.org/x/text/unicode/norm"
+
+// ComposeUnicode returns s in Unicode Normalization Form C, with each character that is followed by combining
+// marks replaced by the precomposed character it is equivalent to. macOS, for one, names files in
+// decomposed form, which would otherwise not match the composed form browsers request
+func ComposeUnicode(s string) string {
+	return norm.NFC.String(s)
+}
@@ -0,0 +1,30 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestComposeUnicode(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		input       string
+		expected    string
+	}{
+		{"leaves ascii as it is", "/images/logo.png", "/images/logo.png"},
+		{"leaves composed characters as they are", "/caf\u00e9.html", "/caf\u00e9.html"},
+		{"composes a letter and a combining mark", "/cafe\u0301.html", "/caf\u00e9.html"},
+		{"composes a letter and several combining marks", "/vie\u0323\u0302t.html", "/vi\u1ec7t.html"},
+		{"composes greek and cyrillic letters", "/\u03b1\u0301/\u0438\u0306", "/\u03ac/\u0439"},
+		{"composes hangul and kana", "/\u1100\u1161/\u304b\u3099", "/\uac00/\u304c"},
+		{"composes combining marks given out of canonical order", "/a\u0302\u0323", "/\u1ead"},
+		{"leaves combining marks with no precomposed form", "/q\u0301", "/q\u0301"},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			u.So(t, utils.ComposeUnicode(tc.input), gc.ShouldEqual, tc.expected)
+		})
+	}
+}
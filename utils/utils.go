@@ -38,8 +38,15 @@ var (
 	HostingFilesDirectory = fmt.Sprintf("%s/files", HostingRoot)
 	// HostingAttributes is the file that stores the static hosting asset descriptions struct
 	HostingAttributes = fmt.Sprintf("%s/metadata.json", HostingRoot)
+	// HostingDirectoryPlaceholder is the name of the file that keeps an otherwise empty hosting directory in
+	// place, since version control does not track empty directories. It is never uploaded itself
+	HostingDirectoryPlaceholder = ".keep"
+	// HostingConfig is the file that stores the app-wide static hosting settings, such as redirects and rewrites
+	HostingConfig = fmt.Sprintf("%s/config.json", HostingRoot)
 	// HostingCacheFileName is the file that stores the cached hosting asset data
 	HostingCacheFileName = ".asset-cache.json"
+	// GroupsCacheFileName is the file that stores the cached list of Atlas projects
+	GroupsCacheFileName = ".groups-cache.json"
 
 	errAppNotFound = errors.New("could not find stitch app")
 )
@@ -257,14 +264,29 @@ func unmarshalFunctionDirectories(path string) ([]interface{}, error) {
 	directories := []interface{}{}
 
 	err := iterDirectories(func(info os.FileInfo, path string) error {
-		var config interface{}
+		// the shared modules of functions are injected into them on import, and are not a function themselves
+		if info.Name() == FunctionLibDir && filepath.Base(filepath.Dir(path)) == functionsName {
+			return nil
+		}
+
+		var config map[string]interface{}
 		if err := readAndUnmarshalJSONInto(filepath.Join(path, configName+jsonExt), &config); err != nil {
 			return err
 		}
 
+		// the source belongs in source.js, though a config with it embedded is reassembled all the same
+		source, embedded := config[sourceName].(string)
+		delete(config, sourceName)
+
 		sourceBytes, err := ioutil.ReadFile(filepath.Join(path, sourceName+jsExt))
 		if err != nil {
-			return err
+			if !os.IsNotExist(err) || !embedded {
+				if _, tsErr := os.Stat(filepath.Join(path, sourceName+tsExt)); os.IsNotExist(err) && tsErr == nil {
+					return fmt.Errorf("%s has a %s but no %s; import it with --build-functions to build one", path, sourceName+tsExt, sourceName+jsExt)
+				}
+				return err
+			}
+			sourceBytes = []byte(source)
 		}
 
 		directory := map[string]interface{}{}
@@ -295,6 +317,10 @@ func unmarshalServiceDirectories(path string) ([]interface{}, error) {
 			return err
 		}
 
+		// rules belong in files of their own, though rules embedded in the config are imported all the same
+		embeddedRules, _ := config[rulesName].([]interface{})
+		delete(config, rulesName)
+
 		svc[configName] = config
 
 		incomingWebhooks, err := unmarshalFunctionDirectories(filepath.Join(path, incomingWebhooksName))
@@ -309,7 +335,7 @@ func unmarshalServiceDirectories(path string) ([]interface{}, error) {
 			return err
 		}
 
-		svc[rulesName] = rules
+		svc[rulesName] = append(rules, embeddedRules...)
 
 		services = append(services, svc)
 
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"io/ioutil"
+	"path/filepath"
+)
+
+// RestoreValues sets the value of each value in the app directory at appPath that is named in values to the
+// one given for it there, such as the secret reference that the deployed value was resolved from
+func RestoreValues(appPath string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	valuesPath := filepath.Join(appPath, valuesName)
+
+	fileInfos, _ := ioutil.ReadDir(valuesPath)
+	for _, fileInfo := range fileInfos {
+		valuePath := filepath.Join(valuesPath, fileInfo.Name())
+		if filepath.Ext(valuePath) != jsonExt {
+			continue
+		}
+
+		if err := rewriteJSONFile(valuePath, func(doc interface{}) bool {
+			value, ok := doc.(map[string]interface{})
+			if !ok {
+				return false
+			}
+
+			name, _ := value["name"].(string)
+			restored, ok := values[name]
+			if !ok {
+				return false
+			}
+
+			value["value"] = restored
+			return true
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRestoreValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stitch-restore-values")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	valuesPath := filepath.Join(dir, "values")
+	u.So(t, os.MkdirAll(valuesPath, 0755), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(valuesPath, "apiKey.json"), []byte(`{"name": "apiKey", "value": "shh"}`), 0644), gc.ShouldBeNil)
+	u.So(t, ioutil.WriteFile(filepath.Join(valuesPath, "limit.json"), []byte(`{"name": "limit", "value": 10}`), 0644), gc.ShouldBeNil)
+
+	u.So(t, utils.RestoreValues(dir, map[string]interface{}{"apiKey": "env:API_KEY"}), gc.ShouldBeNil)
+
+	read := func(name string) map[string]interface{} {
+		data, err := ioutil.ReadFile(filepath.Join(valuesPath, name))
+		u.So(t, err, gc.ShouldBeNil)
+		var value map[string]interface{}
+		u.So(t, json.Unmarshal(data, &value), gc.ShouldBeNil)
+		return value
+	}
+	u.So(t, read("apiKey.json"), gc.ShouldResemble, map[string]interface{}{"name": "apiKey", "value": "env:API_KEY"})
+	u.So(t, read("limit.json"), gc.ShouldResemble, map[string]interface{}{"name": "limit", "value": float64(10)})
+
+	t.Run("it does nothing for an app without values", func(t *testing.T) {
+		u.So(t, utils.RestoreValues(filepath.Join(dir, "missing"), map[string]interface{}{"apiKey": "env:API_KEY"}), gc.ShouldBeNil)
+	})
+}
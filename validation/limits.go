@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/10gen/stitch-cli/models"
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// limitWarningRatio is the share of a limit from which an app is reported as approaching it
+const limitWarningRatio = 0.9
+
+// Usage is how much of each resource the server limits an app uses
+type Usage struct {
+	Functions   int
+	RequestSize int64
+
+	// AssetSizes are the sizes of the app's hosting assets, keyed by path
+	AssetSizes map[string]int64
+}
+
+// LimitProblem describes a limit that an app approaches or exceeds
+type LimitProblem struct {
+	Message string
+
+	// Exceeded is true when the app exceeds the limit, rather than only approaching it
+	Exceeded bool
+}
+
+func (lp LimitProblem) Error() string {
+	return lp.Message
+}
+
+// CheckLimits returns the limits the usage approaches or exceeds. Limits that are not set are not checked
+func CheckLimits(limits models.Limits, usage Usage) []LimitProblem {
+	var problems []LimitProblem
+
+	if problem, ok := checkLimit(int64(usage.Functions), int64(limits.MaxFunctions)); ok {
+		problem.Message = fmt.Sprintf(
+			"the app has %d functions, %s the limit of %d",
+			usage.Functions,
+			problem.Message,
+			limits.MaxFunctions,
+		)
+		problems = append(problems, problem)
+	}
+
+	if problem, ok := checkLimit(usage.RequestSize, limits.MaxRequestSizeBytes); ok {
+		problem.Message = fmt.Sprintf(
+			"the app configuration is %s, %s the limit of %s on the size of an import",
+			utils.FormatSize(usage.RequestSize),
+			problem.Message,
+			utils.FormatSize(limits.MaxRequestSizeBytes),
+		)
+		problems = append(problems, problem)
+	}
+
+	assetPaths := make([]string, 0, len(usage.AssetSizes))
+	for assetPath := range usage.AssetSizes {
+		assetPaths = append(assetPaths, assetPath)
+	}
+	sort.Strings(assetPaths)
+
+	for _, assetPath := range assetPaths {
+		size := usage.AssetSizes[assetPath]
+		if problem, ok := checkLimit(size, limits.MaxAssetSizeBytes); ok {
+			problem.Message = fmt.Sprintf(
+				"the hosting asset %s is %s, %s the limit of %s on the size of an asset",
+				assetPath,
+				utils.FormatSize(size),
+				problem.Message,
+				utils.FormatSize(limits.MaxAssetSizeBytes),
+			)
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
+
+// checkLimit returns a problem whose message describes how used relates to limit, if it approaches or exceeds it
+func checkLimit(used, limit int64) (LimitProblem, bool) {
+	switch {
+	case limit <= 0:
+		return LimitProblem{}, false
+	case used > limit:
+		return LimitProblem{Message: "more than", Exceeded: true}, true
+	case float64(used) >= limitWarningRatio*float64(limit):
+		return LimitProblem{Message: "approaching"}, true
+	default:
+		return LimitProblem{}, false
+	}
+}
@@ -0,0 +1,43 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/models"
+	u "github.com/10gen/stitch-cli/utils/test"
+	"github.com/10gen/stitch-cli/validation"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckLimits(t *testing.T) {
+	limits := models.Limits{MaxFunctions: 100, MaxRequestSizeBytes: 1024 * 1024, MaxAssetSizeBytes: 1000}
+
+	t.Run("it reports nothing well within the limits", func(t *testing.T) {
+		problems := validation.CheckLimits(limits, validation.Usage{
+			Functions:   10,
+			RequestSize: 1024,
+			AssetSizes:  map[string]int64{"/index.html": 100},
+		})
+		u.So(t, problems, gc.ShouldBeEmpty)
+	})
+
+	t.Run("it reports the limits approached and exceeded", func(t *testing.T) {
+		problems := validation.CheckLimits(limits, validation.Usage{
+			Functions:   95,
+			RequestSize: 2 * 1024 * 1024,
+			AssetSizes:  map[string]int64{"/video.mp4": 1001, "/app.js": 900, "/index.html": 100},
+		})
+		u.So(t, problems, gc.ShouldResemble, []validation.LimitProblem{
+			{Message: "the app has 95 functions, approaching the limit of 100"},
+			{Message: "the app configuration is 2.0 MiB, more than the limit of 1.0 MiB on the size of an import", Exceeded: true},
+			{Message: "the hosting asset /app.js is 900 B, approaching the limit of 1000 B on the size of an asset"},
+			{Message: "the hosting asset /video.mp4 is 1001 B, more than the limit of 1000 B on the size of an asset", Exceeded: true},
+		})
+	})
+
+	t.Run("it does not check limits that are not set", func(t *testing.T) {
+		problems := validation.CheckLimits(models.Limits{}, validation.Usage{Functions: 1000, RequestSize: 1 << 30})
+		u.So(t, problems, gc.ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,197 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// annotationPrefix starts the fields of an entity's config that annotate it rather than configure it
+const annotationPrefix = "x-"
+
+// Schema is the subset of JSON Schema used to describe Stitch entity configuration
+type Schema struct {
+	Type                 schemaTypes        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+
+	// AllOf, AnyOf, If and Then combine schemas, e.g. to require fields depending on the type of an entity
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	If    *Schema   `json:"if,omitempty"`
+	Then  *Schema   `json:"then,omitempty"`
+}
+
+// schemaTypes holds the value of a schema's "type" keyword, which may either be a single
+// type name or a list of them
+type schemaTypes []string
+
+// UnmarshalJSON unmarshals either a string or a list of strings into schemaTypes
+func (st *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*st = schemaTypes{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("schema type must be a string or a list of strings: %s", err)
+	}
+
+	*st = multiple
+	return nil
+}
+
+// violation is a single problem found while validating a document against a Schema
+type violation struct {
+	path         string
+	message      string
+	unrecognized bool
+}
+
+func (v violation) String() string {
+	if v.path == "" {
+		return v.message
+	}
+	return fmt.Sprintf("%s %s", v.path, v.message)
+}
+
+// validate checks the value at path against the schema and returns every violation found
+func (s *Schema) validate(value interface{}, path string) []violation {
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		return []violation{{path: path, message: fmt.Sprintf("must be %s", strings.Join(s.Type, " or "))}}
+	}
+
+	if len(s.Enum) > 0 && !s.matchesEnum(value) {
+		options := make([]string, len(s.Enum))
+		for i, option := range s.Enum {
+			b, _ := json.Marshal(option)
+			options[i] = string(b)
+		}
+		return []violation{{path: path, message: fmt.Sprintf("must be one of [%s]", strings.Join(options, ", "))}}
+	}
+
+	var violations []violation
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, field := range s.Required {
+			if _, ok := v[field]; !ok {
+				violations = append(violations, violation{path: path + "." + field, message: "is required"})
+			}
+		}
+
+		fields := make([]string, 0, len(v))
+		for field := range v {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			fieldPath := path + "." + field
+			if propSchema, ok := s.Properties[field]; ok {
+				violations = append(violations, propSchema.validate(v[field], fieldPath)...)
+				continue
+			}
+
+			// annotations such as "x-owner" may be added to any entity and are not part of its schema
+			if path == "" && strings.HasPrefix(field, annotationPrefix) {
+				continue
+			}
+
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				violations = append(violations, violation{path: fieldPath, message: "is not a recognized field", unrecognized: true})
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				violations = append(violations, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	for _, subschema := range s.AllOf {
+		violations = append(violations, subschema.validate(value, path)...)
+	}
+
+	if s.If != nil && s.Then != nil && len(s.If.validate(value, path)) == 0 {
+		violations = append(violations, s.Then.validate(value, path)...)
+	}
+
+	if len(s.AnyOf) > 0 {
+		if anyOfViolation, ok := s.validateAnyOf(value, path); !ok {
+			violations = append(violations, anyOfViolation)
+		}
+	}
+
+	return violations
+}
+
+// validateAnyOf checks that the value at path satisfies at least one of the AnyOf schemas, returning a
+// violation listing what each of them requires otherwise
+func (s *Schema) validateAnyOf(value interface{}, path string) (violation, bool) {
+	var alternatives []string
+	for _, subschema := range s.AnyOf {
+		subViolations := subschema.validate(value, path)
+		if len(subViolations) == 0 {
+			return violation{}, true
+		}
+
+		messages := make([]string, len(subViolations))
+		for i, subViolation := range subViolations {
+			messages[i] = subViolation.String()
+		}
+		alternatives = append(alternatives, strings.Join(messages, " and "))
+	}
+
+	return violation{path: path, message: "must satisfy one of: " + strings.Join(alternatives, ", or ")}, false
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	for _, t := range s.Type {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && v == float64(int64(v))) {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *Schema) matchesEnum(value interface{}) bool {
+	for _, option := range s.Enum {
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+
+	return false
+}
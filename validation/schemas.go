@@ -0,0 +1,238 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// Schemas maps each type of entity to the Schema its config files must satisfy
+type Schemas map[utils.ConfigKind]*Schema
+
+// ParseSchemas parses a set of raw JSON Schema documents keyed by entity type
+func ParseSchemas(raw map[utils.ConfigKind]string) (Schemas, error) {
+	schemas := make(Schemas, len(raw))
+	for kind, doc := range raw {
+		var schema Schema
+		if err := json.Unmarshal([]byte(doc), &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse %s schema: %s", kind, err)
+		}
+		schemas[kind] = &schema
+	}
+
+	return schemas, nil
+}
+
+// Merge returns a new Schemas containing these schemas overridden by any present in other
+func (s Schemas) Merge(other Schemas) Schemas {
+	merged := make(Schemas, len(s)+len(other))
+	for kind, schema := range s {
+		merged[kind] = schema
+	}
+	for kind, schema := range other {
+		merged[kind] = schema
+	}
+
+	return merged
+}
+
+// DefaultSchemas are the schemas bundled with the CLI describing each type of entity
+var DefaultSchemas Schemas
+
+func init() {
+	schemas, err := ParseSchemas(defaultSchemaDocuments)
+	if err != nil {
+		panic(err)
+	}
+
+	DefaultSchemas = schemas
+}
+
+var defaultSchemaDocuments = map[utils.ConfigKind]string{
+	utils.ConfigKindApp: `{
+		"type": "object",
+		"properties": {
+			"app_id": {"type": "string"},
+			"config_version": {"type": "integer"},
+			"name": {"type": "string"},
+			"location": {"type": "string"},
+			"deployment_model": {"type": "string", "enum": ["GLOBAL", "LOCAL"]},
+			"security": {
+				"type": "object",
+				"properties": {
+					"allowed_request_origins": {"type": "array", "items": {"type": "string"}}
+				}
+			},
+			"hosting": {
+				"type": "object",
+				"properties": {
+					"enabled": {"type": "boolean"}
+				}
+			},
+			"custom_user_data_config": {"type": "object"}
+		},
+		"additionalProperties": false
+	}`,
+
+	utils.ConfigKindSecrets: `{
+		"type": "object",
+		"properties": {
+			"services": {"type": "object"},
+			"auth_providers": {"type": "object"}
+		},
+		"additionalProperties": false
+	}`,
+
+	utils.ConfigKindValue: `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"_id": {"type": "string"},
+			"name": {"type": "string"},
+			"value": {},
+			"private": {"type": "boolean"},
+			"from_secret": {"type": "boolean"}
+		},
+		"required": ["name"],
+		"additionalProperties": false
+	}`,
+
+	utils.ConfigKindAuthProvider: `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"_id": {"type": "string"},
+			"name": {"type": "string"},
+			"type": {"type": "string"},
+			"config": {"type": "object"},
+			"secret_config": {"type": "object"},
+			"disabled": {"type": "boolean"},
+			"metadata_fields": {"type": "array"},
+			"redirect_uris": {"type": "array", "items": {"type": "string"}},
+			"domain_restrictions": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["name", "type"],
+		"additionalProperties": false,
+		"allOf": [
+			{
+				"if": {"properties": {"type": {"enum": ["oauth2-google", "oauth2-facebook"]}}, "required": ["type"]},
+				"then": {
+					"properties": {"config": {"properties": {"clientId": {"type": "string"}}, "required": ["clientId"]}},
+					"required": ["config"],
+					"anyOf": [
+						{"properties": {"config": {"required": ["clientSecret"]}}},
+						{"properties": {"secret_config": {"required": ["clientSecret"]}}, "required": ["secret_config"]}
+					]
+				}
+			},
+			{
+				"if": {"properties": {"type": {"enum": ["custom-token"]}}, "required": ["type"]},
+				"then": {
+					"properties": {"config": {"properties": {"audience": {"type": ["string", "array"]}}, "required": ["audience"]}},
+					"required": ["config"],
+					"anyOf": [
+						{"properties": {"config": {"required": ["signingKey"]}}},
+						{"properties": {"secret_config": {"required": ["signingKey"]}}, "required": ["secret_config"]},
+						{"properties": {"secret_config": {"required": ["signingKeys"]}}, "required": ["secret_config"]}
+					]
+				}
+			},
+			{
+				"if": {"properties": {"type": {"enum": ["custom-function"]}}, "required": ["type"]},
+				"then": {
+					"properties": {"config": {"properties": {"authFunctionName": {"type": "string"}}, "required": ["authFunctionName"]}},
+					"required": ["config"]
+				}
+			}
+		]
+	}`,
+
+	utils.ConfigKindFunction: `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"_id": {"type": "string"},
+			"name": {"type": "string"},
+			"private": {"type": "boolean"},
+			"can_evaluate": {"type": "object"},
+			"run_as_system": {"type": "boolean"},
+			"run_as_user_id": {"type": "string"},
+			"run_as_user_id_script_source": {"type": "string"},
+			"disable_arg_logs": {"type": "boolean"},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["name"],
+		"additionalProperties": false
+	}`,
+
+	utils.ConfigKindTrigger: `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"_id": {"type": "string"},
+			"name": {"type": "string"},
+			"type": {"type": "string", "enum": ["DATABASE", "AUTHENTICATION", "SCHEDULED"]},
+			"config": {"type": "object"},
+			"function_name": {"type": "string"},
+			"function_id": {"type": "string"},
+			"disabled": {"type": "boolean"},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["name", "type"],
+		"additionalProperties": false
+	}`,
+
+	utils.ConfigKindService: `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"_id": {"type": "string"},
+			"name": {"type": "string"},
+			"type": {"type": "string"},
+			"config": {"type": "object"},
+			"secret_config": {"type": "object"},
+			"version": {"type": "integer"},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["name", "type"],
+		"additionalProperties": false
+	}`,
+
+	utils.ConfigKindRule: `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"_id": {"type": "string"},
+			"name": {"type": "string"},
+			"actions": {"type": "array", "items": {"type": "string"}},
+			"when": {"type": ["string", "object"]},
+			"database": {"type": "string"},
+			"collection": {"type": "string"},
+			"roles": {"type": "array"},
+			"schema": {"type": "object"},
+			"filters": {"type": "array"}
+		},
+		"additionalProperties": false
+	}`,
+
+	utils.ConfigKindIncomingWebhook: `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"_id": {"type": "string"},
+			"name": {"type": "string"},
+			"run_as_user_id": {"type": "string"},
+			"run_as_user_id_script_source": {"type": "string"},
+			"run_as_authed_user": {"type": "boolean"},
+			"can_evaluate": {"type": "object"},
+			"options": {"type": "object"},
+			"respond_result": {"type": "boolean"},
+			"create_user_on_auth": {"type": "boolean"},
+			"fetch_custom_user_data": {"type": "boolean"},
+			"disable_arg_logs": {"type": "boolean"}
+		},
+		"required": ["name"],
+		"additionalProperties": false
+	}`,
+}
@@ -0,0 +1,99 @@
+// Package validation provides checks that can be run against the local configuration
+// of a Stitch application before it is imported.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/10gen/stitch-cli/utils"
+)
+
+// Error describes a problem found in a single config file of an app directory
+type Error struct {
+	// Path is the location of the file relative to the root of the app directory
+	Path    string
+	Message string
+
+	// Unrecognized is true when the problem is a field that is not part of the entity's schema
+	Unrecognized bool
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks every config file of the app in appPath against the schema for its type of entity
+func Validate(appPath string, schemas Schemas) ([]Error, error) {
+	var errs []Error
+	var secrets interface{}
+	for _, file := range utils.ListConfigFiles(appPath) {
+		var doc interface{}
+		if err := utils.ReadAndUnmarshalInto(json.Unmarshal, filepath.Join(appPath, file.Path), &doc); err != nil {
+			return nil, err
+		}
+
+		// an empty file is treated the same as a missing one
+		if doc == nil {
+			continue
+		}
+
+		// the secrets file is listed before auth providers, whose secrets it may hold rather than their config
+		switch file.Kind {
+		case utils.ConfigKindSecrets:
+			secrets = doc
+		case utils.ConfigKindAuthProvider:
+			doc = withProviderSecrets(doc, secrets)
+		}
+
+		schema, ok := schemas[file.Kind]
+		if !ok {
+			continue
+		}
+
+		for _, v := range schema.validate(doc, "") {
+			errs = append(errs, Error{Path: file.Path, Message: v.String(), Unrecognized: v.unrecognized})
+		}
+	}
+
+	return errs, nil
+}
+
+// withProviderSecrets returns the auth provider doc with the secrets held for it in the secrets file added
+// to its config, as they are when the app is imported
+func withProviderSecrets(doc, secrets interface{}) interface{} {
+	provider, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc
+	}
+
+	name, _ := provider["name"].(string)
+	secretsDoc, _ := secrets.(map[string]interface{})
+	providerSecrets, _ := secretsDoc["auth_providers"].(map[string]interface{})
+	fields, ok := providerSecrets[name].(map[string]interface{})
+	if !ok || len(fields) == 0 {
+		return doc
+	}
+
+	config := map[string]interface{}{}
+	if existing, ok := provider["config"].(map[string]interface{}); ok {
+		for field, value := range existing {
+			config[field] = value
+		}
+	} else if _, ok := provider["config"]; ok {
+		return doc
+	}
+	for field, value := range fields {
+		if _, ok := config[field]; !ok {
+			config[field] = value
+		}
+	}
+
+	merged := make(map[string]interface{}, len(provider)+1)
+	for field, value := range provider {
+		merged[field] = value
+	}
+	merged["config"] = config
+	return merged
+}
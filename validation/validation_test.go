@@ -0,0 +1,124 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/10gen/stitch-cli/utils"
+	u "github.com/10gen/stitch-cli/utils/test"
+	"github.com/10gen/stitch-cli/validation"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func errorStrings(errs []validation.Error) []string {
+	strs := make([]string, len(errs))
+	for i, err := range errs {
+		strs[i] = err.Error()
+	}
+	return strs
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("should not report any errors for a valid app", func(t *testing.T) {
+		errs, err := validation.Validate("../testdata/full_app", validation.DefaultSchemas)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errs, gc.ShouldBeEmpty)
+	})
+
+	t.Run("should not report any errors for an empty app config file", func(t *testing.T) {
+		errs, err := validation.Validate("../testdata/simple_app_empty_stitch_json", validation.DefaultSchemas)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errs, gc.ShouldBeEmpty)
+	})
+
+	t.Run("should report unrecognized fields along with the file they appear in", func(t *testing.T) {
+		errs, err := validation.Validate("../testdata/app_with_unknown_fields", validation.DefaultSchemas)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errs, gc.ShouldHaveLength, 1)
+		u.So(t, errs[0].Error(), gc.ShouldEqual, "functions/greet/config.json: .run_as_sytem is not a recognized field")
+		u.So(t, errs[0].Unrecognized, gc.ShouldBeTrue)
+	})
+
+	t.Run("should not report annotations of an entity's config as unrecognized fields", func(t *testing.T) {
+		errs, err := validation.Validate("../testdata/app_with_owners", validation.DefaultSchemas)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errs, gc.ShouldBeEmpty)
+	})
+
+	t.Run("should accept tags on functions, triggers and services", func(t *testing.T) {
+		errs, err := validation.Validate("../testdata/app_with_tags", validation.DefaultSchemas)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errs, gc.ShouldBeEmpty)
+	})
+
+	t.Run("should report schema violations with the path of the offending field", func(t *testing.T) {
+		errs, err := validation.Validate("../testdata/app_with_invalid_config", validation.DefaultSchemas)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errorStrings(errs), gc.ShouldResemble, []string{
+			`stitch.json: .deployment_model must be one of ["GLOBAL", "LOCAL"]`,
+			"functions/greet/config.json: .private must be boolean",
+			"triggers/onLogin.json: .type is required",
+		})
+		for _, validationErr := range errs {
+			u.So(t, validationErr.Unrecognized, gc.ShouldBeFalse)
+		}
+	})
+
+	t.Run("should check the config each type of auth provider requires", func(t *testing.T) {
+		errs, err := validation.Validate("../testdata/app_with_invalid_auth_providers", validation.DefaultSchemas)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errorStrings(errs), gc.ShouldResemble, []string{
+			"auth_providers/custom-function.json: .config is required",
+			"auth_providers/custom-token.json: .config.audience is required",
+			"auth_providers/oauth2-google.json: .config.clientId must be string",
+			"auth_providers/oauth2-google.json: must satisfy one of: .config.clientSecret is required, or .secret_config is required",
+		})
+	})
+
+	t.Run("should validate using the schemas provided", func(t *testing.T) {
+		overrides, err := validation.ParseSchemas(map[utils.ConfigKind]string{
+			utils.ConfigKindFunction: `{"type": "object", "required": ["can_evaluate"]}`,
+		})
+		u.So(t, err, gc.ShouldBeNil)
+
+		errs, err := validation.Validate("../testdata/full_app", validation.DefaultSchemas.Merge(overrides))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errorStrings(errs), gc.ShouldResemble, []string{
+			"functions/function_a/config.json: .can_evaluate is required",
+			"functions/function_b/config.json: .can_evaluate is required",
+		})
+	})
+
+	t.Run("should compare objects and arrays against enum options by value", func(t *testing.T) {
+		overrides, err := validation.ParseSchemas(map[utils.ConfigKind]string{
+			utils.ConfigKindApp: `{"properties": {
+				"security": {"enum": [{"allowed_request_origins": ["http://www.somewhere.com", "http://www.somewhere-else.com"]}]},
+				"hosting": {"enum": [{"enabled": true}, ["enabled"]]}
+			}}`,
+		})
+		u.So(t, err, gc.ShouldBeNil)
+
+		errs, err := validation.Validate("../testdata/full_app", validation.DefaultSchemas.Merge(overrides))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, errorStrings(errs), gc.ShouldResemble, []string{
+			`stitch.json: .hosting must be one of [{"enabled":true}, ["enabled"]]`,
+		})
+	})
+}
+
+func TestParseSchemas(t *testing.T) {
+	t.Run("should accept a list of types", func(t *testing.T) {
+		_, err := validation.ParseSchemas(map[utils.ConfigKind]string{
+			utils.ConfigKindRule: `{"properties": {"when": {"type": ["string", "object"]}}}`,
+		})
+		u.So(t, err, gc.ShouldBeNil)
+	})
+
+	t.Run("should fail on malformed schemas", func(t *testing.T) {
+		_, err := validation.ParseSchemas(map[utils.ConfigKind]string{
+			utils.ConfigKindRule: `{"type": 5}`,
+		})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "failed to parse rule schema")
+	})
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The types that values can be declared as
+const (
+	ValueTypeString = "string"
+	ValueTypeNumber = "number"
+	ValueTypeBool   = "bool"
+	ValueTypeObject = "object"
+	ValueTypeArray  = "array"
+)
+
+// ValueTypeMismatch describes a value whose type is not the one declared for it
+type ValueTypeMismatch struct {
+	Name     string
+	Declared string
+	Actual   string
+}
+
+func (m ValueTypeMismatch) Error() string {
+	return fmt.Sprintf("value '%s' is %s, but is declared as %s", m.Name, m.Actual, m.Declared)
+}
+
+// CheckValueTypes returns the values, keyed by name, whose type is not the one types declares for them.
+// Values without a declared type, and declared values that are missing, are not checked
+func CheckValueTypes(types map[string]string, values map[string]interface{}) ([]ValueTypeMismatch, error) {
+	names := make([]string, 0, len(types))
+	for name, declared := range types {
+		switch declared {
+		case ValueTypeString, ValueTypeNumber, ValueTypeBool, ValueTypeObject, ValueTypeArray:
+		default:
+			return nil, fmt.Errorf(
+				"value '%s' is declared as %q, which is not one of %s, %s, %s, %s or %s",
+				name,
+				declared,
+				ValueTypeString,
+				ValueTypeNumber,
+				ValueTypeBool,
+				ValueTypeObject,
+				ValueTypeArray,
+			)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mismatches []ValueTypeMismatch
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+
+		if actual := valueType(value); actual != types[name] {
+			mismatches = append(mismatches, ValueTypeMismatch{Name: name, Declared: types[name], Actual: actual})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// valueType returns the type of a value decoded from JSON
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return ValueTypeString
+	case float64:
+		return ValueTypeNumber
+	case bool:
+		return ValueTypeBool
+	case map[string]interface{}:
+		return ValueTypeObject
+	case []interface{}:
+		return ValueTypeArray
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,54 @@
+package validation_test
+
+import (
+	"testing"
+
+	u "github.com/10gen/stitch-cli/utils/test"
+	"github.com/10gen/stitch-cli/validation"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckValueTypes(t *testing.T) {
+	types := map[string]string{
+		"url":      validation.ValueTypeString,
+		"retries":  validation.ValueTypeNumber,
+		"enabled":  validation.ValueTypeBool,
+		"settings": validation.ValueTypeObject,
+		"regions":  validation.ValueTypeArray,
+		"missing":  validation.ValueTypeString,
+	}
+
+	t.Run("should accept values of their declared types", func(t *testing.T) {
+		mismatches, err := validation.CheckValueTypes(types, map[string]interface{}{
+			"url":        "https://example.com",
+			"retries":    float64(3),
+			"enabled":    true,
+			"settings":   map[string]interface{}{},
+			"regions":    []interface{}{"us-east-1"},
+			"undeclared": float64(1),
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, mismatches, gc.ShouldBeEmpty)
+	})
+
+	t.Run("should report values of other types, in order of name", func(t *testing.T) {
+		mismatches, err := validation.CheckValueTypes(types, map[string]interface{}{
+			"url":     "https://example.com",
+			"retries": "3",
+			"enabled": nil,
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, mismatches, gc.ShouldResemble, []validation.ValueTypeMismatch{
+			{Name: "enabled", Declared: validation.ValueTypeBool, Actual: "null"},
+			{Name: "retries", Declared: validation.ValueTypeNumber, Actual: validation.ValueTypeString},
+		})
+		u.So(t, mismatches[1].Error(), gc.ShouldEqual, "value 'retries' is string, but is declared as number")
+	})
+
+	t.Run("should fail for a type that cannot be declared", func(t *testing.T) {
+		_, err := validation.CheckValueTypes(map[string]string{"url": "text"}, nil)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `value 'url' is declared as "text"`)
+	})
+}